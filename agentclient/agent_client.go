@@ -0,0 +1,134 @@
+package agentclient
+
+import (
+	"time"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
+)
+
+// AgentClient adds messages that are not yet part of biagentclient.AgentClient
+type AgentClient interface {
+	biagentclient.AgentClient
+
+	// AddPersistentDisk passes disk hints returned by CPI API v2 attach_disk
+	// to the agent since they are not written into the registry
+	AddPersistentDisk(diskCID string, diskHints interface{}) error
+
+	// Drain runs drain scripts of all jobs and returns their result:
+	// number of seconds to wait before stopping jobs, or when negative,
+	// number of seconds to wait before asking for drain status again
+	Drain(drainType string) (int, error)
+}
+
+type agentClientFactory struct {
+	factory      bihttpagent.AgentClientFactory
+	getTaskDelay time.Duration
+	logger       boshlog.Logger
+}
+
+// NewAgentClientFactory wraps HTTP agent clients created by factory
+// so that they implement AgentClient
+func NewAgentClientFactory(
+	factory bihttpagent.AgentClientFactory,
+	getTaskDelay time.Duration,
+	logger boshlog.Logger,
+) bihttpagent.AgentClientFactory {
+	return agentClientFactory{
+		factory:      factory,
+		getTaskDelay: getTaskDelay,
+		logger:       logger,
+	}
+}
+
+func (f agentClientFactory) NewAgentClient(directorID, mbusURL, caCert string) (biagentclient.AgentClient, error) {
+	client, err := f.factory.NewAgentClient(directorID, mbusURL, caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, ok := client.(*bihttpagent.AgentClient)
+	if !ok {
+		return client, nil
+	}
+
+	return agentClient{
+		AgentClient:  httpClient,
+		getTaskDelay: f.getTaskDelay,
+		logger:       f.logger,
+		logTag:       "agentClient",
+	}, nil
+}
+
+type agentClient struct {
+	*bihttpagent.AgentClient
+
+	getTaskDelay time.Duration
+	logger       boshlog.Logger
+	logTag       string
+}
+
+func (c agentClient) AddPersistentDisk(diskCID string, diskHints interface{}) error {
+	err := c.AgentRequest.Send("add_persistent_disk", []interface{}{diskCID, diskHints}, &bihttpagent.TaskResponse{})
+	if err != nil {
+		return bosherr.WrapError(err, "Sending 'add_persistent_disk' to the agent")
+	}
+
+	return nil
+}
+
+// Drain does not use SendAsyncTaskMessage since it only returns task results that are maps
+func (c agentClient) Drain(drainType string) (int, error) {
+	var response bihttpagent.TaskResponse
+
+	err := c.AgentRequest.Send("drain", []interface{}{drainType}, &response)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Sending 'drain' to the agent")
+	}
+
+	agentTaskID, err := response.TaskID()
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Getting agent task id")
+	}
+
+	var value interface{}
+
+	getTaskRetryable := boshretry.NewRetryable(func() (bool, error) {
+		var response bihttpagent.TaskResponse
+
+		err := c.AgentRequest.Send("get_task", []interface{}{agentTaskID}, &response)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Sending 'get_task' to the agent")
+		}
+
+		taskState, err := response.TaskState()
+		if err != nil {
+			return false, bosherr.WrapError(err, "Getting task state")
+		}
+
+		if taskState == "running" {
+			return true, bosherr.Error("Task drain is still running")
+		}
+
+		value = response.Value
+
+		return true, nil
+	})
+
+	err = boshretry.NewUnlimitedRetryStrategy(c.getTaskDelay, getTaskRetryable, c.logger).Try()
+	if err != nil {
+		return 0, err
+	}
+
+	c.logger.Debug(c.logTag, "Drain responded with %#v", value)
+
+	seconds, ok := value.(float64)
+	if !ok {
+		return 0, bosherr.Errorf("Unable to parse 'drain' response from the agent: %#v", value)
+	}
+
+	return int(seconds), nil
+}
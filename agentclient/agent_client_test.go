@@ -0,0 +1,121 @@
+package agentclient_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/agentclient"
+)
+
+var _ = Describe("AgentClientFactory", func() {
+	var (
+		server  *ghttp.Server
+		factory bihttpagent.AgentClientFactory
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		factory = NewAgentClientFactory(bihttpagent.NewAgentClientFactory(1*time.Millisecond, logger), 1*time.Millisecond, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("AddPersistentDisk", func() {
+		It("sends disk hints to the agent", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/agent"),
+				func(w http.ResponseWriter, req *http.Request) {
+					body, err := ioutil.ReadAll(req.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(body).To(MatchJSON(`{"method":"add_persistent_disk","arguments":["fake-disk-cid",{"path":"/dev/sdc"}],"reply_to":"fake-director-id"}`))
+				},
+				ghttp.RespondWith(http.StatusOK, `{"value":{}}`),
+			))
+
+			client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = client.(AgentClient).AddPersistentDisk("fake-disk-cid", map[string]string{"path": "/dev/sdc"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error when agent responds with exception", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"exception":{"message":"fake-agent-error"}}`))
+
+			client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = client.(AgentClient).AddPersistentDisk("fake-disk-cid", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-agent-error"))
+		})
+	})
+
+	Describe("Drain", func() {
+		It("waits for drain task to finish and returns its result", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					func(w http.ResponseWriter, req *http.Request) {
+						body, err := ioutil.ReadAll(req.Body)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(body).To(MatchJSON(`{"method":"drain","arguments":["shutdown"],"reply_to":"fake-director-id"}`))
+					},
+					ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`),
+				),
+				ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`),
+				ghttp.CombineHandlers(
+					func(w http.ResponseWriter, req *http.Request) {
+						body, err := ioutil.ReadAll(req.Body)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(body).To(MatchJSON(`{"method":"get_task","arguments":["fake-task-id"],"reply_to":"fake-director-id"}`))
+					},
+					ghttp.RespondWith(http.StatusOK, `{"value":-5}`),
+				),
+			)
+
+			client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			seconds, err := client.(AgentClient).Drain("shutdown")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(seconds).To(Equal(-5))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("returns error when drain result is not a number", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`),
+				ghttp.RespondWith(http.StatusOK, `{"value":"stopped"}`),
+			)
+
+			client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.(AgentClient).Drain("shutdown")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unable to parse 'drain' response from the agent"))
+		})
+
+		It("returns error when agent responds with exception", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"exception":{"message":"fake-agent-error"}}`))
+
+			client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.(AgentClient).Drain("shutdown")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-agent-error"))
+		})
+	})
+})
@@ -8,7 +8,16 @@ import (
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
+// MaxCPIAPIVersion is the highest CPI API version spoken by the CLI
+const MaxCPIAPIVersion = 2
+
+type CpiInfo struct {
+	StemcellFormats []string
+	APIVersion      int
+}
+
 type Cloud interface {
+	Info() (CpiInfo, error)
 	CreateStemcell(imagePath string, cloudProperties biproperty.Map) (stemcellCID string, err error)
 	DeleteStemcell(stemcellCID string) error
 	HasVM(vmCID string) (bool, error)
@@ -18,14 +27,20 @@ type Cloud interface {
 		cloudProperties biproperty.Map,
 		networksInterfaces map[string]biproperty.Map,
 		env biproperty.Map,
-	) (vmCID string, err error)
+	) (vmCID string, networks map[string]biproperty.Map, err error)
 	SetVMMetadata(cmCID string, metadata VMMetadata) error
 	SetDiskMetadata(diskCID string, metadata DiskMetadata) error
 	DeleteVM(vmCID string) error
 	CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (diskCID string, err error)
-	AttachDisk(vmCID, diskCID string) error
+	AttachDisk(vmCID, diskCID string) (diskHints interface{}, err error)
 	DetachDisk(vmCID, diskCID string) error
 	DeleteDisk(diskCID string) error
+	ResizeDisk(diskCID string, newSize int) error
+
+	// UsesRegistry is false when both CPI and stemcell support API v2;
+	// agent settings and disk hints are then not passed through the registry
+	UsesRegistry() bool
+
 	fmt.Stringer
 }
 
@@ -40,19 +55,67 @@ type VMMetadata map[string]string
 
 type DiskMetadata map[string]string
 
+// NewCloud uses CPI API apiVersion; stemcellAPIVersion is omitted
+// from CPI requests when it is not known
 func NewCloud(
 	cpiCmdRunner CPICmdRunner,
 	directorID string,
+	apiVersion int,
+	stemcellAPIVersion int,
 	logger boshlog.Logger,
 ) Cloud {
+	context := CmdContext{DirectorID: directorID}
+
+	if apiVersion > 1 {
+		context.APIVersion = apiVersion
+	}
+
+	if stemcellAPIVersion > 0 {
+		context.VM = &CmdVMContext{Stemcell: CmdStemcellContext{APIVersion: stemcellAPIVersion}}
+	}
+
 	return cloud{
 		cpiCmdRunner: cpiCmdRunner,
-		context:      CmdContext{DirectorID: directorID},
+		context:      context,
 		logger:       logger,
 		logTag:       "cloud",
 	}
 }
 
+func (c cloud) Info() (CpiInfo, error) {
+	method := "info"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method)
+	if err != nil {
+		return CpiInfo{}, err
+	}
+
+	if cmdOutput.Error != nil {
+		return CpiInfo{}, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	result, ok := cmdOutput.Result.(map[string]interface{})
+	if !ok {
+		return CpiInfo{}, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+
+	// CPIs that do not report API version implement v1
+	info := CpiInfo{APIVersion: 1}
+
+	if apiVersion, ok := result["api_version"].(float64); ok {
+		info.APIVersion = int(apiVersion)
+	}
+
+	if formats, ok := result["stemcell_formats"].([]interface{}); ok {
+		for _, format := range formats {
+			if formatString, ok := format.(string); ok {
+				info.StemcellFormats = append(info.StemcellFormats, formatString)
+			}
+		}
+	}
+
+	return info, nil
+}
+
 func (c cloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
 	c.logger.Debug(c.logTag, "Creating stemcell")
 
@@ -114,7 +177,7 @@ func (c cloud) CreateVM(
 	cloudProperties biproperty.Map,
 	networksInterfaces map[string]biproperty.Map,
 	env biproperty.Map,
-) (string, error) {
+) (string, map[string]biproperty.Map, error) {
 	method := "create_vm"
 	diskLocality := []interface{}{} // not used with bosh-init
 	cmdOutput, err := c.cpiCmdRunner.Run(
@@ -128,19 +191,61 @@ func (c cloud) CreateVM(
 		env,
 	)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	if cmdOutput.Error != nil {
-		return "", NewCPIError(method, *cmdOutput.Error)
+		return "", nil, NewCPIError(method, *cmdOutput.Error)
 	}
 
-	// for create_vm, the result is a string of the vm cid
-	cidString, ok := cmdOutput.Result.(string)
+	// for create_vm v1, the result is a string of the vm cid
+	if cidString, ok := cmdOutput.Result.(string); ok {
+		return cidString, nil, nil
+	}
+
+	// for create_vm v2, the result is a list of the vm cid and network settings
+	if result, ok := cmdOutput.Result.([]interface{}); ok && len(result) > 0 {
+		cidString, ok := result[0].(string)
+		if !ok {
+			return "", nil, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+		}
+
+		if len(result) < 2 || result[1] == nil {
+			return cidString, nil, nil
+		}
+
+		networks, err := c.parseNetworks(result[1])
+		if err != nil {
+			return "", nil, bosherr.WrapErrorf(err, "Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+		}
+
+		return cidString, networks, nil
+	}
+
+	return "", nil, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+}
+
+func (c cloud) parseNetworks(result interface{}) (map[string]biproperty.Map, error) {
+	networksMap, ok := result.(map[string]interface{})
 	if !ok {
-		return "", bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+		return nil, bosherr.Errorf("Expected network settings to be a hash")
 	}
-	return cidString, nil
+
+	networks := map[string]biproperty.Map{}
+
+	for name, network := range networksMap {
+		networkMap, ok := network.(map[string]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected network settings of '%s' to be a hash", name)
+		}
+
+		networks[name] = biproperty.Map{}
+		for key, value := range networkMap {
+			networks[name][key] = value
+		}
+	}
+
+	return networks, nil
 }
 
 func (c cloud) SetVMMetadata(vmCID string, metadata VMMetadata) error {
@@ -211,7 +316,7 @@ func (c cloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string
 	return cidString, nil
 }
 
-func (c cloud) AttachDisk(vmCID, diskCID string) error {
+func (c cloud) AttachDisk(vmCID, diskCID string) (interface{}, error) {
 	c.logger.Debug(c.logTag, "Attaching disk '%s' to vm '%s'", diskCID, vmCID)
 	method := "attach_disk"
 	cmdOutput, err := c.cpiCmdRunner.Run(
@@ -221,14 +326,19 @@ func (c cloud) AttachDisk(vmCID, diskCID string) error {
 		diskCID,
 	)
 	if err != nil {
-		return bosherr.WrapError(err, "Calling CPI 'attach_disk' method")
+		return nil, bosherr.WrapError(err, "Calling CPI 'attach_disk' method")
 	}
 
 	if cmdOutput.Error != nil {
-		return NewCPIError(method, *cmdOutput.Error)
+		return nil, NewCPIError(method, *cmdOutput.Error)
 	}
 
-	return nil
+	// for attach_disk v2, the result is a disk hint
+	if c.context.APIVersion > 1 {
+		return cmdOutput.Result, nil
+	}
+
+	return nil, nil
 }
 
 func (c cloud) DetachDisk(vmCID, diskCID string) error {
@@ -281,6 +391,25 @@ func (c cloud) DeleteDisk(diskCID string) error {
 	return nil
 }
 
+func (c cloud) ResizeDisk(diskCID string, newSize int) error {
+	c.logger.Debug(c.logTag, "Resizing disk '%s' to size %d", diskCID, newSize)
+	method := "resize_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, diskCID, newSize)
+	if err != nil {
+		return bosherr.WrapError(err, "Calling CPI 'resize_disk' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return nil
+}
+
+func (c cloud) UsesRegistry() bool {
+	return c.context.APIVersion < 2 || c.context.VM == nil || c.context.VM.Stemcell.APIVersion < 2
+}
+
 func (c cloud) String() string {
 	return fmt.Sprintf("Cloud{Context=%s}", c.context)
 }
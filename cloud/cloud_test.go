@@ -23,7 +23,7 @@ var _ = Describe("Cloud", func() {
 	BeforeEach(func() {
 		fakeCPICmdRunner = fakebicloud.NewFakeCPICmdRunner()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		cloud = NewCloud(fakeCPICmdRunner, "fake-director-id", logger)
+		cloud = NewCloud(fakeCPICmdRunner, "fake-director-id", 1, 0, logger)
 		context = CmdContext{DirectorID: "fake-director-id"}
 	})
 
@@ -49,6 +49,64 @@ var _ = Describe("Cloud", func() {
 		})
 	}
 
+	Describe("Info", func() {
+		It("returns reported API version and stemcell formats", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{
+					"api_version":      float64(2),
+					"stemcell_formats": []interface{}{"aws-raw", "aws-light"},
+				},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(CpiInfo{StemcellFormats: []string{"aws-raw", "aws-light"}, APIVersion: 2}))
+
+			Expect(fakeCPICmdRunner.RunInputs).To(Equal([]fakebicloud.RunInput{
+				{Context: context, Method: "info"},
+			}))
+		})
+
+		It("defaults to API version 1", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{"stemcell_formats": []interface{}{"aws-raw"}},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.APIVersion).To(Equal(1))
+		})
+
+		itHandlesCPIErrors("info", func() error {
+			_, err := cloud.Info()
+			return err
+		})
+	})
+
+	Describe("UsesRegistry", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		It("is false only when both CPI and stemcell support API v2", func() {
+			Expect(NewCloud(fakeCPICmdRunner, "fake-director-id", 2, 2, logger).UsesRegistry()).To(BeFalse())
+			Expect(NewCloud(fakeCPICmdRunner, "fake-director-id", 2, 1, logger).UsesRegistry()).To(BeTrue())
+			Expect(NewCloud(fakeCPICmdRunner, "fake-director-id", 2, 0, logger).UsesRegistry()).To(BeTrue())
+			Expect(NewCloud(fakeCPICmdRunner, "fake-director-id", 1, 2, logger).UsesRegistry()).To(BeTrue())
+		})
+
+		It("passes API versions in the context of CPI requests", func() {
+			cloud = NewCloud(fakeCPICmdRunner, "fake-director-id", 2, 2, logger)
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{Result: true}
+
+			_, err := cloud.HasVM("fake-vm-cid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.RunInputs[0].Context).To(Equal(CmdContext{
+				DirectorID: "fake-director-id",
+				APIVersion: 2,
+				VM:         &CmdVMContext{Stemcell: CmdStemcellContext{APIVersion: 2}},
+			}))
+		})
+	})
+
 	Describe("CreateStemcell", func() {
 		var (
 			stemcellImagePath string
@@ -236,7 +294,7 @@ var _ = Describe("Cloud", func() {
 			})
 
 			It("executes the cpi job script with the director UUID and stemcell CID", func() {
-				_, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+				_, _, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fakeCPICmdRunner.RunInputs).To(HaveLen(1))
 				Expect(fakeCPICmdRunner.RunInputs[0]).To(Equal(fakebicloud.RunInput{
@@ -254,7 +312,7 @@ var _ = Describe("Cloud", func() {
 			})
 
 			It("returns the cid returned from executing the cpi script", func() {
-				cid, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+				cid, _, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(cid).To(Equal("fake-vm-cid"))
 			})
@@ -268,7 +326,7 @@ var _ = Describe("Cloud", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+				_, _, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result: '1'"))
 			})
@@ -280,14 +338,50 @@ var _ = Describe("Cloud", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+				_, _, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
 			})
 		})
 
+		Context("when the cpi returns vm cid and network settings", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+					Result: []interface{}{
+						"fake-vm-cid",
+						map[string]interface{}{
+							"bosh": map[string]interface{}{"type": "dynamic", "ip": "10.0.0.5"},
+						},
+					},
+				}
+			})
+
+			It("returns the cid and network settings", func() {
+				cid, networks, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cid).To(Equal("fake-vm-cid"))
+				Expect(networks).To(Equal(map[string]biproperty.Map{
+					"bosh": biproperty.Map{"type": "dynamic", "ip": "10.0.0.5"},
+				}))
+			})
+		})
+
+		Context("when network settings returned by the cpi are not a hash", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+					Result: []interface{}{"fake-vm-cid", "fake-networks"},
+				}
+			})
+
+			It("returns an error", func() {
+				_, _, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected network settings to be a hash"))
+			})
+		})
+
 		itHandlesCPIErrors("create_vm", func() error {
-			_, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
+			_, _, err := cloud.CreateVM(agentID, stemcellCID, cloudProperties, networkInterfaces, env)
 			return err
 		})
 	})
@@ -460,7 +554,7 @@ var _ = Describe("Cloud", func() {
 	Describe("AttachDisk", func() {
 		Context("when the cpi successfully attaches the disk", func() {
 			It("executes the cpi job script with the correct arguments", func() {
-				err := cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
+				_, err := cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fakeCPICmdRunner.RunInputs).To(HaveLen(1))
 				Expect(fakeCPICmdRunner.RunInputs[0]).To(Equal(fakebicloud.RunInput{
@@ -480,14 +574,48 @@ var _ = Describe("Cloud", func() {
 			})
 
 			It("returns an error", func() {
-				err := cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
+				_, err := cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
 			})
 		})
 
+		Context("when CPI API v2 is used", func() {
+			BeforeEach(func() {
+				cloud = NewCloud(fakeCPICmdRunner, "fake-director-id", 2, 2, boshlog.NewLogger(boshlog.LevelNone))
+				fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+					Result: map[string]interface{}{"path": "/dev/sdc"},
+				}
+			})
+
+			It("returns disk hints", func() {
+				diskHints, err := cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskHints).To(Equal(map[string]interface{}{"path": "/dev/sdc"}))
+			})
+		})
+
 		itHandlesCPIErrors("attach_disk", func() error {
-			return cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
+			_, err := cloud.AttachDisk("fake-vm-cid", "fake-disk-cid")
+			return err
+		})
+	})
+
+	Describe("ResizeDisk", func() {
+		It("executes the cpi job script with the correct arguments", func() {
+			err := cloud.ResizeDisk("fake-disk-cid", 2048)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.RunInputs).To(Equal([]fakebicloud.RunInput{
+				{
+					Context:   context,
+					Method:    "resize_disk",
+					Arguments: []interface{}{"fake-disk-cid", 2048},
+				},
+			}))
+		})
+
+		itHandlesCPIErrors("resize_disk", func() error {
+			return cloud.ResizeDisk("fake-disk-cid", 2048)
 		})
 	})
 
@@ -14,10 +14,26 @@ type CmdInput struct {
 	Method    string        `json:"method"`
 	Arguments []interface{} `json:"arguments"`
 	Context   CmdContext    `json:"context"`
+
+	// APIVersion is duplicated from context since CPIs look for it at the top level
+	APIVersion int `json:"api_version,omitempty"`
 }
 
 type CmdContext struct {
 	DirectorID string `json:"director_uuid"`
+
+	// APIVersion is negotiated with CPI via 'info' method; v1 CPIs do not receive it
+	APIVersion int `json:"api_version,omitempty"`
+
+	VM *CmdVMContext `json:"vm,omitempty"`
+}
+
+type CmdVMContext struct {
+	Stemcell CmdStemcellContext `json:"stemcell"`
+}
+
+type CmdStemcellContext struct {
+	APIVersion int `json:"api_version"`
 }
 
 func (c CmdContext) String() string {
@@ -74,9 +90,10 @@ func NewCPICmdRunner(
 
 func (r *cpiCmdRunner) Run(context CmdContext, method string, args ...interface{}) (CmdOutput, error) {
 	cmdInput := CmdInput{
-		Method:    method,
-		Arguments: args,
-		Context:   context,
+		Method:     method,
+		Arguments:  args,
+		Context:    context,
+		APIVersion: context.APIVersion,
 	}
 	inputBytes, err := json.Marshal(cmdInput)
 	if err != nil {
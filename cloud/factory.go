@@ -1,48 +1,139 @@
 package cloud
 
 import (
+	"encoding/json"
+	"path/filepath"
+
+	"code.cloudfoundry.org/clock"
+
 	biinstall "github.com/cloudfoundry/bosh-cli/installation"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 type Factory interface {
-	NewCloud(installation biinstall.Installation, directorID string) (Cloud, error)
+	NewCloud(installation biinstall.Installation, directorID string, stemcellAPIVersion int) (Cloud, error)
 }
 
 type factory struct {
-	fs        boshsys.FileSystem
-	cmdRunner boshsys.CmdRunner
-	logger    boshlog.Logger
+	fs            boshsys.FileSystem
+	cmdRunner     boshsys.CmdRunner
+	cpiRecordPath string
+	timeService   clock.Clock
+	uuidGen       boshuuid.Generator
+	logger        boshlog.Logger
+
+	// replayRunner is shared by all clouds so that calls are replayed in order
+	replayRunner CPICmdRunner
 }
 
+// NewFactory records all CPI calls into cpiRecordPath unless it is empty.
+// When cpiReplayPath is not empty, installed CPI is not executed and
+// responses are served from the recording at cpiReplayPath instead.
 func NewFactory(
 	fs boshsys.FileSystem,
 	cmdRunner boshsys.CmdRunner,
+	cpiRecordPath string,
+	cpiReplayPath string,
+	timeService clock.Clock,
+	uuidGen boshuuid.Generator,
 	logger boshlog.Logger,
 ) Factory {
-	return &factory{
-		fs:        fs,
-		cmdRunner: cmdRunner,
-		logger:    logger,
+	f := &factory{
+		fs:            fs,
+		cmdRunner:     cmdRunner,
+		cpiRecordPath: cpiRecordPath,
+		timeService:   timeService,
+		uuidGen:       uuidGen,
+		logger:        logger,
+	}
+
+	if len(cpiReplayPath) > 0 {
+		f.replayRunner = NewReplayCPICmdRunner(fs, cpiReplayPath, logger)
 	}
+
+	return f
 }
 
-func (f *factory) NewCloud(installation biinstall.Installation, directorID string) (Cloud, error) {
-	cpiJob := installation.Job()
-	target := installation.Target()
-	cpi := CPI{
-		JobPath:     cpiJob.Path,
-		JobsDir:     target.JobsPath(),
-		PackagesDir: target.PackagesPath(),
+// NewCloud negotiates CPI API version with the installed CPI;
+// stemcellAPIVersion is 0 when the stemcell is not known
+func (f *factory) NewCloud(installation biinstall.Installation, directorID string, stemcellAPIVersion int) (Cloud, error) {
+	cpiCmdRunner := f.replayRunner
+
+	if cpiCmdRunner == nil {
+		cpiJob := installation.Job()
+		target := installation.Target()
+
+		localConfigPath := filepath.Join(cpiJob.Path, biinstall.LocalCPIConfigFile)
+		if f.fs.FileExists(localConfigPath) {
+			return f.newLocalCloud(localConfigPath)
+		}
+		cpi := CPI{
+			JobPath:     cpiJob.Path,
+			JobsDir:     target.JobsPath(),
+			PackagesDir: target.PackagesPath(),
+		}
+
+		cmdPath := cpi.ExecutablePath()
+		if !f.fs.FileExists(cmdPath) {
+			return nil, bosherr.Errorf("Installed CPI job '%s' does not contain the required executable '%s'", cpiJob.Name, cmdPath)
+		}
+
+		cpiCmdRunner = NewCPICmdRunner(f.cmdRunner, cpi, f.logger)
+	}
+
+	if len(f.cpiRecordPath) > 0 {
+		cpiCmdRunner = NewRecordingCPICmdRunner(cpiCmdRunner, f.fs, f.cpiRecordPath, f.timeService, f.logger)
 	}
 
-	cmdPath := cpi.ExecutablePath()
-	if !f.fs.FileExists(cmdPath) {
-		return nil, bosherr.Errorf("Installed CPI job '%s' does not contain the required executable '%s'", cpiJob.Name, cmdPath)
+	apiVersion, err := f.negotiateAPIVersion(cpiCmdRunner, directorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCloud(cpiCmdRunner, directorID, apiVersion, stemcellAPIVersion, f.logger), nil
+}
+
+func (f *factory) negotiateAPIVersion(cpiCmdRunner CPICmdRunner, directorID string) (int, error) {
+	info, err := NewCloud(cpiCmdRunner, directorID, 1, 0, f.logger).Info()
+	if err != nil {
+		// CPIs that do not implement info only support v1
+		if cloudErr, ok := err.(Error); ok && cloudErr.Type() == NotImplementedError {
+			return 1, nil
+		}
+		return 0, bosherr.WrapError(err, "Getting CPI info")
+	}
+
+	f.logger.Debug("cloudFactory", "CPI supports API version %d", info.APIVersion)
+
+	if info.APIVersion > MaxCPIAPIVersion {
+		return MaxCPIAPIVersion, nil
+	}
+
+	return info.APIVersion, nil
+}
+
+func (f *factory) newLocalCloud(configPath string) (Cloud, error) {
+	configBytes, err := f.fs.ReadFile(configPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading local CPI config")
+	}
+
+	var config biinstall.LocalCPIConfig
+
+	err = json.Unmarshal(configBytes, &config)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling local CPI config")
+	}
+
+	cloud := NewLocalCloud(f.fs, f.uuidGen, config, f.logger)
+
+	err = cloud.StartAgents()
+	if err != nil {
+		return nil, err
 	}
 
-	cpiCmdRunner := NewCPICmdRunner(f.cmdRunner, cpi, f.logger)
-	return NewCloud(cpiCmdRunner, directorID, f.logger), nil
+	return cloud, nil
 }
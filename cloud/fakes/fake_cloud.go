@@ -6,6 +6,9 @@ import (
 )
 
 type FakeCloud struct {
+	InfoOutput cloud.CpiInfo
+	InfoErr    error
+
 	CreateStemcellInputs []CreateStemcellInput
 	CreateStemcellCID    string
 	CreateStemcellErr    error
@@ -14,16 +17,18 @@ type FakeCloud struct {
 	HasVMFound bool
 	HasVMErr   error
 
-	CreateVMInput CreateVMInput
-	CreateVMCID   string
-	CreateVMErr   error
+	CreateVMInput    CreateVMInput
+	CreateVMCID      string
+	CreateVMNetworks map[string]biproperty.Map
+	CreateVMErr      error
 
 	CreateDiskInput CreateDiskInput
 	CreateDiskCID   string
 	CreateDiskErr   error
 
-	AttachDiskInput AttachDiskInput
-	AttachDiskErr   error
+	AttachDiskInput     AttachDiskInput
+	AttachDiskDiskHints interface{}
+	AttachDiskErr       error
 
 	DetachDiskInput DetachDiskInput
 	DetachDiskErr   error
@@ -34,6 +39,9 @@ type FakeCloud struct {
 	DeleteDiskInputs []DeleteDiskInput
 	DeleteDiskErr    error
 
+	ResizeDiskInputs []ResizeDiskInput
+	ResizeDiskErr    error
+
 	DeleteStemcellInputs []DeleteStemcellInput
 	DeleteStemcellErr    error
 
@@ -44,6 +52,8 @@ type FakeCloud struct {
 	SetDiskMetadataCid      string
 	SetDiskMetadataMetadata cloud.DiskMetadata
 	SetDiskMetadataError    error
+
+	UsesRegistryResult bool
 }
 
 type CreateStemcellInput struct {
@@ -87,6 +97,11 @@ type DeleteDiskInput struct {
 	DiskCID string
 }
 
+type ResizeDiskInput struct {
+	DiskCID string
+	NewSize int
+}
+
 type DeleteStemcellInput struct {
 	StemcellCID string
 }
@@ -98,6 +113,10 @@ func NewFakeCloud() *FakeCloud {
 	}
 }
 
+func (c *FakeCloud) Info() (cloud.CpiInfo, error) {
+	return c.InfoOutput, c.InfoErr
+}
+
 func (c *FakeCloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
 	c.CreateStemcellInputs = append(c.CreateStemcellInputs, CreateStemcellInput{
 		ImagePath:       imagePath,
@@ -127,7 +146,7 @@ func (c *FakeCloud) CreateVM(
 	cloudProperties biproperty.Map,
 	networksInterfaces map[string]biproperty.Map,
 	env biproperty.Map,
-) (string, error) {
+) (string, map[string]biproperty.Map, error) {
 	c.CreateVMInput = CreateVMInput{
 		AgentID:            agentID,
 		StemcellCID:        stemcellCID,
@@ -136,7 +155,7 @@ func (c *FakeCloud) CreateVM(
 		Env:                env,
 	}
 
-	return c.CreateVMCID, c.CreateVMErr
+	return c.CreateVMCID, c.CreateVMNetworks, c.CreateVMErr
 }
 
 func (c *FakeCloud) SetVMMetadata(cid string, metadata cloud.VMMetadata) error {
@@ -165,12 +184,12 @@ func (c *FakeCloud) CreateDisk(
 	return c.CreateDiskCID, c.CreateDiskErr
 }
 
-func (c *FakeCloud) AttachDisk(vmCID, diskCID string) error {
+func (c *FakeCloud) AttachDisk(vmCID, diskCID string) (interface{}, error) {
 	c.AttachDiskInput = AttachDiskInput{
 		VMCID:   vmCID,
 		DiskCID: diskCID,
 	}
-	return c.AttachDiskErr
+	return c.AttachDiskDiskHints, c.AttachDiskErr
 }
 
 func (c *FakeCloud) DetachDisk(vmCID, diskCID string) error {
@@ -195,6 +214,18 @@ func (c *FakeCloud) DeleteDisk(diskCID string) error {
 	return c.DeleteDiskErr
 }
 
+func (c *FakeCloud) ResizeDisk(diskCID string, newSize int) error {
+	c.ResizeDiskInputs = append(c.ResizeDiskInputs, ResizeDiskInput{
+		DiskCID: diskCID,
+		NewSize: newSize,
+	})
+	return c.ResizeDiskErr
+}
+
+func (c *FakeCloud) UsesRegistry() bool {
+	return c.UsesRegistryResult
+}
+
 func (c *FakeCloud) String() string {
 	return "FakeCloud{}"
 }
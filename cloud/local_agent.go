@@ -0,0 +1,490 @@
+package cloud
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+type localAgentRequest struct {
+	Method    string        `json:"method"`
+	Arguments []interface{} `json:"arguments"`
+}
+
+type localAgentException struct {
+	Message string `json:"message"`
+}
+
+type localAgentResponse struct {
+	Value     interface{}          `json:"value,omitempty"`
+	Exception *localAgentException `json:"exception,omitempty"`
+}
+
+// localAgent implements enough of the agent HTTP API (messages and blobstore)
+// for the CLI to deploy a VM created by local CPI. Jobs are never actually run.
+type localAgent struct {
+	fs         boshsys.FileSystem
+	uuidGen    boshuuid.Generator
+	vmDir      string
+	mbus       string
+	recordLock *sync.Mutex
+
+	server   *http.Server
+	listener net.Listener
+
+	// Async tasks finish immediately; results are kept until requested
+	taskResults map[string]interface{}
+	taskLock    sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+func newLocalAgent(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	vmDir string,
+	mbus string,
+	recordLock *sync.Mutex,
+	logger boshlog.Logger,
+) *localAgent {
+	return &localAgent{
+		fs:         fs,
+		uuidGen:    uuidGen,
+		vmDir:      vmDir,
+		mbus:       mbus,
+		recordLock: recordLock,
+
+		taskResults: map[string]interface{}{},
+
+		logger: logger,
+		logTag: "localAgent",
+	}
+}
+
+func (a *localAgent) Start() error {
+	mbusURL, err := url.Parse(a.mbus)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing mbus URL")
+	}
+
+	listener, err := net.Listen("tcp", mbusURL.Host)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on '%s'", mbusURL.Host)
+	}
+
+	if mbusURL.Scheme == "https" {
+		tlsConfig, err := a.tlsConfig(mbusURL.Hostname())
+		if err != nil {
+			listener.Close()
+			return err
+		}
+
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	a.server = &http.Server{Handler: a.handler(mbusURL.User)}
+	a.listener = listener
+
+	a.logger.Debug(a.logTag, "Serving agent for '%s' on '%s'", a.vmDir, mbusURL.Host)
+
+	go func() {
+		err := a.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			a.logger.Error(a.logTag, "Serving agent: %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (a *localAgent) Stop() {
+	if a.server == nil {
+		return
+	}
+
+	err := a.server.Close()
+	if err != nil {
+		a.logger.Warn(a.logTag, "Stopping agent: %s", err.Error())
+	}
+
+	// Server only closes listeners that it started serving
+	a.listener.Close()
+}
+
+func (a *localAgent) handler(userInfo *url.Userinfo) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/agent", a.handleMessage)
+	mux.HandleFunc("/blobs/", a.handleBlob)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userInfo != nil {
+			username, password, _ := r.BasicAuth()
+			expectedPassword, _ := userInfo.Password()
+
+			if username != userInfo.Username() || password != expectedPassword {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (a *localAgent) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request localAgentRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	a.logger.Debug(a.logTag, "Received '%s' with arguments %#v", request.Method, request.Arguments)
+
+	var response localAgentResponse
+
+	value, err := a.dispatch(request)
+	if err != nil {
+		response.Exception = &localAgentException{Message: err.Error()}
+	} else {
+		response.Value = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		a.logger.Error(a.logTag, "Writing response to '%s': %s", request.Method, err.Error())
+	}
+}
+
+func (a *localAgent) dispatch(request localAgentRequest) (interface{}, error) {
+	switch request.Method {
+	case "ping":
+		return "pong", nil
+
+	case "get_task":
+		return a.getTask(request.Arguments)
+
+	case "get_state":
+		record, err := a.vmRecord()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"job_state": record.JobState, "networks": map[string]interface{}{}}, nil
+
+	case "start":
+		err := a.updateVMRecord(func(record *localVMRecord) error {
+			record.JobState = "running"
+			return nil
+		})
+		return "started", err
+
+	case "list_disk":
+		record, err := a.vmRecord()
+		if err != nil {
+			return nil, err
+		}
+		if record.MountedDisks == nil {
+			return []string{}, nil
+		}
+		return record.MountedDisks, nil
+
+	case "delete_arp_entries":
+		return map[string]interface{}{}, nil
+
+	case "sync_dns":
+		return "synced", nil
+
+	case "add_persistent_disk":
+		// disk hints are not needed since disks are only records
+		return map[string]interface{}{}, nil
+
+	case "stop":
+		return a.task(func() (interface{}, error) {
+			return "stopped", a.updateVMRecord(func(record *localVMRecord) error {
+				record.JobState = "stopped"
+				return nil
+			})
+		})
+
+	case "apply":
+		return a.task(func() (interface{}, error) { return "applied", nil })
+
+	case "run_script":
+		return a.task(func() (interface{}, error) { return map[string]interface{}{}, nil })
+
+	case "migrate_disk":
+		return a.task(func() (interface{}, error) { return map[string]interface{}{}, nil })
+
+	case "mount_disk":
+		return a.task(func() (interface{}, error) { return a.mountDisk(request.Arguments) })
+
+	case "unmount_disk":
+		return a.task(func() (interface{}, error) { return a.unmountDisk(request.Arguments) })
+
+	case "compile_package":
+		return a.task(func() (interface{}, error) { return a.compilePackage(request.Arguments) })
+	}
+
+	return nil, bosherr.Errorf("unknown message %s", request.Method)
+}
+
+func (a *localAgent) task(fn func() (interface{}, error)) (interface{}, error) {
+	result, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	taskID, err := a.uuidGen.Generate()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating task ID")
+	}
+
+	a.taskLock.Lock()
+	a.taskResults[taskID] = result
+	a.taskLock.Unlock()
+
+	return map[string]interface{}{"agent_task_id": taskID, "state": "running"}, nil
+}
+
+func (a *localAgent) getTask(args []interface{}) (interface{}, error) {
+	taskID, err := a.stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	a.taskLock.Lock()
+	defer a.taskLock.Unlock()
+
+	result, found := a.taskResults[taskID]
+	if !found {
+		return nil, bosherr.Errorf("Task '%s' not found", taskID)
+	}
+
+	delete(a.taskResults, taskID)
+
+	return result, nil
+}
+
+func (a *localAgent) mountDisk(args []interface{}) (interface{}, error) {
+	diskCID, err := a.stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.updateVMRecord(func(record *localVMRecord) error {
+		for _, attachedDiskCID := range record.AttachedDisks {
+			if attachedDiskCID == diskCID {
+				record.MountedDisks = appendUnique(record.MountedDisks, diskCID)
+				return nil
+			}
+		}
+		return bosherr.Errorf("Persistent disk with volume id '%s' could not be found", diskCID)
+	})
+
+	return map[string]interface{}{}, err
+}
+
+func (a *localAgent) unmountDisk(args []interface{}) (interface{}, error) {
+	diskCID, err := a.stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.updateVMRecord(func(record *localVMRecord) error {
+		record.MountedDisks = removeString(record.MountedDisks, diskCID)
+		return nil
+	})
+
+	return map[string]interface{}{"message": fmt.Sprintf("Unmounted partition of %s", diskCID)}, err
+}
+
+// compilePackage does not compile anything; source blob is stored as compiled package
+func (a *localAgent) compilePackage(args []interface{}) (interface{}, error) {
+	blobID, err := a.stringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := a.fs.ReadFile(a.blobPath(blobID))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading package blob '%s'", blobID)
+	}
+
+	compiledBlobID, err := a.uuidGen.Generate()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating blob ID")
+	}
+
+	err = a.fs.WriteFile(a.blobPath(compiledBlobID), contents)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Writing compiled package blob '%s'", compiledBlobID)
+	}
+
+	return map[string]interface{}{
+		"result": map[string]interface{}{
+			"sha1":         fmt.Sprintf("%x", sha1.Sum(contents)),
+			"blobstore_id": compiledBlobID,
+		},
+	}, nil
+}
+
+// handleBlob serves DAV blobstore used by the CLI; blob ID is the last path segment
+func (a *localAgent) handleBlob(w http.ResponseWriter, r *http.Request) {
+	blobID := path.Base(r.URL.Path)
+	if blobID == "" || blobID == "/" || blobID == "." {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	blobPath := a.blobPath(blobID)
+
+	switch r.Method {
+	case "GET":
+		contents, err := a.fs.ReadFile(blobPath)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(contents)
+
+	case "PUT":
+		contents, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = a.fs.WriteFile(blobPath, contents)
+		if err != nil {
+			a.logger.Error(a.logTag, "Writing blob '%s': %s", blobID, err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+	case "DELETE":
+		err := a.fs.RemoveAll(blobPath)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *localAgent) vmRecord() (localVMRecord, error) {
+	a.recordLock.Lock()
+	defer a.recordLock.Unlock()
+
+	var record localVMRecord
+
+	err := readLocalRecord(a.fs, filepath.Join(a.vmDir, "vm.json"), &record)
+
+	return record, err
+}
+
+func (a *localAgent) updateVMRecord(fn func(*localVMRecord) error) error {
+	a.recordLock.Lock()
+	defer a.recordLock.Unlock()
+
+	recordPath := filepath.Join(a.vmDir, "vm.json")
+
+	var record localVMRecord
+
+	err := readLocalRecord(a.fs, recordPath, &record)
+	if err != nil {
+		return err
+	}
+
+	err = fn(&record)
+	if err != nil {
+		return err
+	}
+
+	return writeLocalRecord(a.fs, recordPath, record)
+}
+
+func (a *localAgent) blobPath(blobID string) string {
+	return filepath.Join(a.vmDir, "blobs", blobID)
+}
+
+func (a *localAgent) stringArg(args []interface{}, i int) (string, error) {
+	if len(args) <= i {
+		return "", bosherr.Errorf("Expected at least %d argument(s)", i+1)
+	}
+
+	str, ok := args[i].(string)
+	if !ok || strings.TrimSpace(str) == "" {
+		return "", bosherr.Errorf("Expected argument %d to be a non-empty string", i+1)
+	}
+
+	return str, nil
+}
+
+// tlsConfig uses self-signed certificate; mbus CA is not verified by the CLI
+// unless cloud_provider.cert.ca is configured
+func (a *localAgent) tlsConfig(host string) (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating agent key")
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating agent certificate")
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{certDER}, PrivateKey: key}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
@@ -0,0 +1,497 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+)
+
+type localStemcellRecord struct {
+	CloudProperties biproperty.Map `json:"cloud_properties"`
+}
+
+type localVMRecord struct {
+	AgentID         string                    `json:"agent_id"`
+	StemcellCID     string                    `json:"stemcell_cid"`
+	CloudProperties biproperty.Map            `json:"cloud_properties"`
+	Networks        map[string]biproperty.Map `json:"networks"`
+	Env             biproperty.Map            `json:"env"`
+	Metadata        VMMetadata                `json:"metadata"`
+
+	// AttachedDisks are managed by the CPI; MountedDisks by the agent
+	AttachedDisks []string `json:"attached_disks"`
+	MountedDisks  []string `json:"mounted_disks"`
+
+	JobState string `json:"job_state"`
+}
+
+type localDiskRecord struct {
+	Size            int            `json:"size"`
+	CloudProperties biproperty.Map `json:"cloud_properties"`
+	Metadata        DiskMetadata   `json:"metadata"`
+	VMCID           string         `json:"vm_cid,omitempty"`
+}
+
+// LocalCloud is used for testing create-env without an IaaS
+type LocalCloud interface {
+	Cloud
+
+	// StartAgents starts fake agents for VMs created by previous CLI invocations
+	StartAgents() error
+
+	// StopAgents stops all fake agents started by this cloud
+	StopAgents()
+}
+
+// localCloud keeps stemcells, VMs and disks on the local filesystem.
+// Each VM is a directory served by a fake agent listening on mbus URL.
+type localCloud struct {
+	fs      boshsys.FileSystem
+	uuidGen boshuuid.Generator
+	config  biinstall.LocalCPIConfig
+
+	agents    map[string]*localAgent
+	agentLock sync.Mutex
+
+	// recordLock is shared with agents which update VM records concurrently
+	recordLock sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewLocalCloud(
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	config biinstall.LocalCPIConfig,
+	logger boshlog.Logger,
+) LocalCloud {
+	return &localCloud{
+		fs:      fs,
+		uuidGen: uuidGen,
+		config:  config,
+
+		agents: map[string]*localAgent{},
+
+		logger: logger,
+		logTag: "localCloud",
+	}
+}
+
+func (c *localCloud) StartAgents() error {
+	vmDirs, err := c.fs.Glob(filepath.Join(c.config.Dir, "vms", "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing local VMs")
+	}
+
+	for _, vmDir := range vmDirs {
+		err = c.startAgent(filepath.Base(vmDir))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *localCloud) StopAgents() {
+	c.agentLock.Lock()
+	defer c.agentLock.Unlock()
+
+	for vmCID, agent := range c.agents {
+		agent.Stop()
+		delete(c.agents, vmCID)
+	}
+}
+
+// Info reports API v2 since local agents do not need the registry
+func (c *localCloud) Info() (CpiInfo, error) {
+	return CpiInfo{StemcellFormats: []string{"local"}, APIVersion: 2}, nil
+}
+
+func (c *localCloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
+	stemcellCID, err := c.newID("stemcell")
+	if err != nil {
+		return "", err
+	}
+
+	stemcellDir := c.stemcellPath(stemcellCID)
+
+	c.logger.Debug(c.logTag, "Creating stemcell '%s' from image '%s'", stemcellCID, imagePath)
+
+	err = c.fs.MkdirAll(stemcellDir, 0700)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating stemcell directory")
+	}
+
+	err = c.fs.CopyFile(imagePath, filepath.Join(stemcellDir, "image"))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Copying stemcell image")
+	}
+
+	err = c.writeRecord(filepath.Join(stemcellDir, "stemcell.json"), localStemcellRecord{CloudProperties: cloudProperties})
+	if err != nil {
+		return "", err
+	}
+
+	return stemcellCID, nil
+}
+
+func (c *localCloud) DeleteStemcell(stemcellCID string) error {
+	c.logger.Debug(c.logTag, "Deleting stemcell '%s'", stemcellCID)
+
+	if !c.fs.FileExists(c.stemcellPath(stemcellCID)) {
+		return c.notFound("delete_stemcell", StemcellNotFoundError, "Stemcell", stemcellCID)
+	}
+
+	return c.fs.RemoveAll(c.stemcellPath(stemcellCID))
+}
+
+func (c *localCloud) HasVM(vmCID string) (bool, error) {
+	return c.fs.FileExists(c.vmPath(vmCID)), nil
+}
+
+func (c *localCloud) CreateVM(
+	agentID string,
+	stemcellCID string,
+	cloudProperties biproperty.Map,
+	networksInterfaces map[string]biproperty.Map,
+	env biproperty.Map,
+) (string, map[string]biproperty.Map, error) {
+	if !c.fs.FileExists(c.stemcellPath(stemcellCID)) {
+		return "", nil, c.notFound("create_vm", StemcellNotFoundError, "Stemcell", stemcellCID)
+	}
+
+	vmCID, err := c.newID("vm")
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.logger.Debug(c.logTag, "Creating VM '%s' for agent '%s'", vmCID, agentID)
+
+	err = c.fs.MkdirAll(filepath.Join(c.vmPath(vmCID), "blobs"), 0700)
+	if err != nil {
+		return "", nil, bosherr.WrapError(err, "Creating VM directory")
+	}
+
+	record := localVMRecord{
+		AgentID:         agentID,
+		StemcellCID:     stemcellCID,
+		CloudProperties: cloudProperties,
+		Networks:        networksInterfaces,
+		Env:             env,
+		JobState:        "stopped",
+	}
+
+	err = c.writeRecord(c.vmRecordPath(vmCID), record)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = c.startAgent(vmCID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return vmCID, networksInterfaces, nil
+}
+
+func (c *localCloud) SetVMMetadata(vmCID string, metadata VMMetadata) error {
+	return c.updateVM("set_vm_metadata", vmCID, func(record *localVMRecord) error {
+		record.Metadata = metadata
+		return nil
+	})
+}
+
+func (c *localCloud) SetDiskMetadata(diskCID string, metadata DiskMetadata) error {
+	return c.updateDisk("set_disk_metadata", diskCID, func(record *localDiskRecord) error {
+		record.Metadata = metadata
+		return nil
+	})
+}
+
+func (c *localCloud) DeleteVM(vmCID string) error {
+	c.logger.Debug(c.logTag, "Deleting VM '%s'", vmCID)
+
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return c.notFound("delete_vm", VMNotFoundError, "VM", vmCID)
+	}
+
+	c.stopAgent(vmCID)
+
+	var record localVMRecord
+
+	err := c.readRecord(c.vmRecordPath(vmCID), &record)
+	if err != nil {
+		return err
+	}
+
+	for _, diskCID := range record.AttachedDisks {
+		err = c.updateDisk("delete_vm", diskCID, func(diskRecord *localDiskRecord) error {
+			diskRecord.VMCID = ""
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.fs.RemoveAll(c.vmPath(vmCID))
+}
+
+func (c *localCloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (string, error) {
+	diskCID, err := c.newID("disk")
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug(c.logTag, "Creating disk '%s' of size %d", diskCID, size)
+
+	err = c.writeRecord(c.diskPath(diskCID), localDiskRecord{Size: size, CloudProperties: cloudProperties})
+	if err != nil {
+		return "", err
+	}
+
+	return diskCID, nil
+}
+
+func (c *localCloud) AttachDisk(vmCID, diskCID string) (interface{}, error) {
+	c.logger.Debug(c.logTag, "Attaching disk '%s' to VM '%s'", diskCID, vmCID)
+
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return nil, c.notFound("attach_disk", VMNotFoundError, "VM", vmCID)
+	}
+
+	err := c.updateDisk("attach_disk", diskCID, func(record *localDiskRecord) error {
+		if record.VMCID != "" && record.VMCID != vmCID {
+			return bosherr.Errorf("Disk '%s' is already attached to VM '%s'", diskCID, record.VMCID)
+		}
+		record.VMCID = vmCID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.updateVM("attach_disk", vmCID, func(record *localVMRecord) error {
+		record.AttachedDisks = appendUnique(record.AttachedDisks, diskCID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"path": c.diskPath(diskCID)}, nil
+}
+
+func (c *localCloud) DetachDisk(vmCID, diskCID string) error {
+	c.logger.Debug(c.logTag, "Detaching disk '%s' from VM '%s'", diskCID, vmCID)
+
+	err := c.updateDisk("detach_disk", diskCID, func(record *localDiskRecord) error {
+		record.VMCID = ""
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.updateVM("detach_disk", vmCID, func(record *localVMRecord) error {
+		record.AttachedDisks = removeString(record.AttachedDisks, diskCID)
+		record.MountedDisks = removeString(record.MountedDisks, diskCID)
+		return nil
+	})
+}
+
+func (c *localCloud) DeleteDisk(diskCID string) error {
+	c.logger.Debug(c.logTag, "Deleting disk '%s'", diskCID)
+
+	if !c.fs.FileExists(c.diskPath(diskCID)) {
+		return c.notFound("delete_disk", DiskNotFoundError, "Disk", diskCID)
+	}
+
+	return c.fs.RemoveAll(c.diskPath(diskCID))
+}
+
+func (c *localCloud) ResizeDisk(diskCID string, newSize int) error {
+	c.logger.Debug(c.logTag, "Resizing disk '%s' to size %d", diskCID, newSize)
+
+	return c.updateDisk("resize_disk", diskCID, func(record *localDiskRecord) error {
+		if newSize < record.Size {
+			return bosherr.Errorf("Disk '%s' cannot be shrunk from %d to %d", diskCID, record.Size, newSize)
+		}
+		record.Size = newSize
+		return nil
+	})
+}
+
+func (c *localCloud) UsesRegistry() bool {
+	return false
+}
+
+func (c *localCloud) String() string {
+	return fmt.Sprintf("localCloud{Dir:%s}", c.config.Dir)
+}
+
+func (c *localCloud) startAgent(vmCID string) error {
+	c.agentLock.Lock()
+	defer c.agentLock.Unlock()
+
+	if _, found := c.agents[vmCID]; found {
+		return nil
+	}
+
+	agent := newLocalAgent(c.fs, c.uuidGen, c.vmPath(vmCID), c.config.Mbus, &c.recordLock, c.logger)
+
+	err := agent.Start()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting agent for VM '%s'", vmCID)
+	}
+
+	c.agents[vmCID] = agent
+
+	return nil
+}
+
+func (c *localCloud) stopAgent(vmCID string) {
+	c.agentLock.Lock()
+	defer c.agentLock.Unlock()
+
+	if agent, found := c.agents[vmCID]; found {
+		agent.Stop()
+		delete(c.agents, vmCID)
+	}
+}
+
+func (c *localCloud) updateVM(method, vmCID string, fn func(*localVMRecord) error) error {
+	if !c.fs.FileExists(c.vmPath(vmCID)) {
+		return c.notFound(method, VMNotFoundError, "VM", vmCID)
+	}
+
+	var record localVMRecord
+
+	return c.updateRecord(c.vmRecordPath(vmCID), &record, func() error { return fn(&record) })
+}
+
+func (c *localCloud) updateDisk(method, diskCID string, fn func(*localDiskRecord) error) error {
+	if !c.fs.FileExists(c.diskPath(diskCID)) {
+		return c.notFound(method, DiskNotFoundError, "Disk", diskCID)
+	}
+
+	var record localDiskRecord
+
+	return c.updateRecord(c.diskPath(diskCID), &record, func() error { return fn(&record) })
+}
+
+func (c *localCloud) updateRecord(path string, record interface{}, fn func() error) error {
+	c.recordLock.Lock()
+	defer c.recordLock.Unlock()
+
+	err := c.readRecord(path, record)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err != nil {
+		return err
+	}
+
+	return c.writeRecord(path, record)
+}
+
+func (c *localCloud) readRecord(path string, record interface{}) error {
+	return readLocalRecord(c.fs, path, record)
+}
+
+func (c *localCloud) writeRecord(path string, record interface{}) error {
+	return writeLocalRecord(c.fs, path, record)
+}
+
+// notFound returns the same errors as external CPIs so that callers can ignore them
+func (c *localCloud) notFound(method, errType, kind, cid string) error {
+	return NewCPIError(method, CmdError{
+		Type:    errType,
+		Message: fmt.Sprintf("%s '%s' not found", kind, cid),
+	})
+}
+
+func (c *localCloud) newID(prefix string) (string, error) {
+	id, err := c.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Generating %s ID", prefix)
+	}
+
+	return prefix + "-" + id, nil
+}
+
+func (c *localCloud) stemcellPath(stemcellCID string) string {
+	return filepath.Join(c.config.Dir, "stemcells", stemcellCID)
+}
+
+func (c *localCloud) vmPath(vmCID string) string {
+	return filepath.Join(c.config.Dir, "vms", vmCID)
+}
+
+func (c *localCloud) vmRecordPath(vmCID string) string {
+	return filepath.Join(c.vmPath(vmCID), "vm.json")
+}
+
+func (c *localCloud) diskPath(diskCID string) string {
+	return filepath.Join(c.config.Dir, "disks", diskCID)
+}
+
+func readLocalRecord(fs boshsys.FileSystem, path string, record interface{}) error {
+	recordBytes, err := fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading local CPI record '%s'", path)
+	}
+
+	err = json.Unmarshal(recordBytes, record)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling local CPI record '%s'", path)
+	}
+
+	return nil
+}
+
+func writeLocalRecord(fs boshsys.FileSystem, path string, record interface{}) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling local CPI record '%s'", path)
+	}
+
+	err = fs.WriteFile(path, recordBytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing local CPI record '%s'", path)
+	}
+
+	return nil
+}
+
+func appendUnique(strs []string, str string) []string {
+	for _, s := range strs {
+		if s == str {
+			return strs
+		}
+	}
+	return append(strs, str)
+}
+
+func removeString(strs []string, str string) []string {
+	var result []string
+	for _, s := range strs {
+		if s != str {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,261 @@
+package cloud_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+)
+
+var _ = Describe("LocalCloud", func() {
+	var (
+		fs        boshsys.FileSystem
+		uuidGen   boshuuid.Generator
+		logger    boshlog.Logger
+		dir       string
+		mbus      string
+		config    biinstall.LocalCPIConfig
+		cloud     LocalCloud
+		imagePath string
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		uuidGen = boshuuid.NewGenerator()
+
+		var err error
+		dir, err = ioutil.TempDir("", "local-cpi")
+		Expect(err).ToNot(HaveOccurred())
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		mbus = fmt.Sprintf("https://mbus:mbus-password@%s", listener.Addr().String())
+		Expect(listener.Close()).To(Succeed())
+
+		imagePath = filepath.Join(dir, "image.tgz")
+		Expect(fs.WriteFileString(imagePath, "fake-image")).To(Succeed())
+
+		config = biinstall.LocalCPIConfig{Mbus: mbus, Dir: filepath.Join(dir, "cpi")}
+		cloud = NewLocalCloud(fs, uuidGen, config, logger)
+	})
+
+	AfterEach(func() {
+		cloud.StopAgents()
+		os.RemoveAll(dir)
+	})
+
+	newAgentClient := func() biagentclient.AgentClient {
+		httpClient := httpclient.NewHTTPClient(httpclient.DefaultClient, logger)
+		return bihttpagent.NewAgentClient(mbus, "fake-director-id", 10*time.Millisecond, 0, httpClient, logger)
+	}
+
+	createVM := func() string {
+		stemcellCID, err := cloud.CreateStemcell(imagePath, biproperty.Map{})
+		Expect(err).ToNot(HaveOccurred())
+
+		vmCID, _, err := cloud.CreateVM("fake-agent-id", stemcellCID, biproperty.Map{}, map[string]biproperty.Map{}, biproperty.Map{})
+		Expect(err).ToNot(HaveOccurred())
+
+		return vmCID
+	}
+
+	Describe("Info", func() {
+		It("supports CPI API v2 so that registry is not used", func() {
+			info, err := cloud.Info()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.APIVersion).To(Equal(2))
+			Expect(cloud.UsesRegistry()).To(BeFalse())
+		})
+	})
+
+	Describe("stemcells", func() {
+		It("copies image and deletes it", func() {
+			stemcellCID, err := cloud.CreateStemcell(imagePath, biproperty.Map{"fake-key": "fake-value"})
+			Expect(err).ToNot(HaveOccurred())
+
+			image, err := fs.ReadFileString(filepath.Join(config.Dir, "stemcells", stemcellCID, "image"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(image).To(Equal("fake-image"))
+
+			Expect(cloud.DeleteStemcell(stemcellCID)).To(Succeed())
+			Expect(fs.FileExists(filepath.Join(config.Dir, "stemcells", stemcellCID))).To(BeFalse())
+		})
+
+		It("returns an error if generating stemcell ID fails", func() {
+			fakeUUIDGen := fakeuuid.NewFakeGenerator()
+			fakeUUIDGen.GenerateError = errors.New("fake-uuid-err")
+			cloud = NewLocalCloud(fs, fakeUUIDGen, config, logger)
+
+			_, err := cloud.CreateStemcell(imagePath, biproperty.Map{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Generating stemcell ID: fake-uuid-err"))
+		})
+
+		It("returns stemcell not found error when deleting unknown stemcell", func() {
+			err := cloud.DeleteStemcell("fake-stemcell-cid")
+			Expect(err).To(HaveOccurred())
+			Expect(err.(Error).Type()).To(Equal(StemcellNotFoundError))
+		})
+	})
+
+	Describe("VMs", func() {
+		It("creates VM with a running agent", func() {
+			vmCID := createVM()
+
+			exists, err := cloud.HasVM(vmCID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			agentClient := newAgentClient()
+
+			Expect(agentClient.Ping()).To(Equal("pong"))
+
+			Expect(agentClient.Start()).To(Succeed())
+
+			state, err := agentClient.GetState()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.JobState).To(Equal("running"))
+
+			Expect(agentClient.Stop()).To(Succeed())
+
+			state, err = agentClient.GetState()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.JobState).To(Equal("stopped"))
+		})
+
+		It("fails to create VM from unknown stemcell", func() {
+			_, _, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, map[string]biproperty.Map{}, biproperty.Map{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(Error).Type()).To(Equal(StemcellNotFoundError))
+		})
+
+		It("deletes VM and stops its agent", func() {
+			vmCID := createVM()
+
+			Expect(cloud.DeleteVM(vmCID)).To(Succeed())
+
+			exists, err := cloud.HasVM(vmCID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			_, err = newAgentClient().Ping()
+			Expect(err).To(HaveOccurred())
+
+			err = cloud.DeleteVM(vmCID)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(Error).Type()).To(Equal(VMNotFoundError))
+		})
+
+		It("rejects agent requests with wrong credentials", func() {
+			createVM()
+
+			mbus = config.Mbus[:len("https://mbus:")] + "wrong" + config.Mbus[len("https://mbus:mbus-password"):]
+
+			_, err := newAgentClient().Ping()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("401"))
+		})
+
+		It("starts agents for VMs created earlier", func() {
+			createVM()
+			cloud.StopAgents()
+
+			_, err := newAgentClient().Ping()
+			Expect(err).To(HaveOccurred())
+
+			cloud = NewLocalCloud(fs, uuidGen, config, logger)
+			Expect(cloud.StartAgents()).To(Succeed())
+
+			Expect(newAgentClient().Ping()).To(Equal("pong"))
+		})
+	})
+
+	Describe("disks", func() {
+		It("attaches disk so that agent can mount it", func() {
+			vmCID := createVM()
+
+			diskCID, err := cloud.CreateDisk(1024, biproperty.Map{}, vmCID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(filepath.Join(config.Dir, "disks", diskCID))).To(BeTrue())
+
+			agentClient := newAgentClient()
+
+			Expect(agentClient.MountDisk(diskCID)).ToNot(Succeed())
+
+			diskHints, err := cloud.AttachDisk(vmCID, diskCID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskHints).To(Equal(map[string]string{"path": filepath.Join(config.Dir, "disks", diskCID)}))
+
+			Expect(agentClient.MountDisk(diskCID)).To(Succeed())
+			Expect(agentClient.ListDisk()).To(Equal([]string{diskCID}))
+
+			Expect(agentClient.UnmountDisk(diskCID)).To(Succeed())
+			Expect(agentClient.ListDisk()).To(BeEmpty())
+
+			Expect(cloud.DetachDisk(vmCID, diskCID)).To(Succeed())
+			Expect(cloud.DeleteDisk(diskCID)).To(Succeed())
+
+			err = cloud.DeleteDisk(diskCID)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(Error).Type()).To(Equal(DiskNotFoundError))
+		})
+
+		It("detaches disks of deleted VMs so that they can be attached again", func() {
+			vmCID := createVM()
+
+			diskCID, err := cloud.CreateDisk(1024, biproperty.Map{}, vmCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = cloud.AttachDisk(vmCID, diskCID)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cloud.DeleteVM(vmCID)).To(Succeed())
+
+			otherVMCID := createVM()
+			_, err = cloud.AttachDisk(otherVMCID, diskCID)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("agent blobstore", func() {
+		It("stores blobs and compiles packages from them", func() {
+			createVM()
+
+			blobstore, err := biblobstore.NewBlobstoreFactory(uuidGen, fs, logger).Create(mbus, httpclient.DefaultClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			blobID, err := blobstore.Add(imagePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			compiledRef, err := newAgentClient().CompilePackage(biagentclient.BlobRef{Name: "fake-pkg", BlobstoreID: blobID}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(compiledRef.BlobstoreID).ToNot(Equal(blobID))
+			Expect(compiledRef.SHA1).To(Equal("36410c671dcafc54f437523177704d491188cdd2"))
+
+			localBlob, err := blobstore.Get(compiledRef.BlobstoreID)
+			Expect(err).ToNot(HaveOccurred())
+			defer localBlob.DeleteSilently()
+
+			Expect(fs.ReadFileString(localBlob.Path())).To(Equal("fake-image"))
+		})
+	})
+})
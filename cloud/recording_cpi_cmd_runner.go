@@ -0,0 +1,111 @@
+package cloud
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CPICallRecord is a single line of CPI recording file
+type CPICallRecord struct {
+	Input  CmdInput   `json:"input"`
+	Output *CmdOutput `json:"output,omitempty"`
+
+	// Error is set when CPI could not be executed or its output could not be parsed;
+	// errors returned by the CPI itself are kept in Output
+	Error string `json:"error,omitempty"`
+
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+type recordingCPICmdRunner struct {
+	runner      CPICmdRunner
+	fs          boshsys.FileSystem
+	path        string
+	timeService clock.Clock
+
+	writeLock sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewRecordingCPICmdRunner appends every CPI call made through runner
+// to a JSON lines file at path
+func NewRecordingCPICmdRunner(
+	runner CPICmdRunner,
+	fs boshsys.FileSystem,
+	path string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) CPICmdRunner {
+	return &recordingCPICmdRunner{
+		runner:      runner,
+		fs:          fs,
+		path:        path,
+		timeService: timeService,
+
+		logger: logger,
+		logTag: "recordingCPICmdRunner",
+	}
+}
+
+func (r *recordingCPICmdRunner) Run(context CmdContext, method string, args ...interface{}) (CmdOutput, error) {
+	record := CPICallRecord{
+		Input: CmdInput{
+			Method:    method,
+			Arguments: args,
+			Context:   context,
+		},
+		StartedAt: r.timeService.Now().UTC(),
+	}
+
+	cmdOutput, err := r.runner.Run(context, method, args...)
+
+	record.DurationMS = int64(r.timeService.Since(record.StartedAt) / time.Millisecond)
+
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Output = &cmdOutput
+	}
+
+	recordErr := r.write(record)
+	if recordErr != nil {
+		// Recording is a debugging aid and should not fail CPI calls
+		r.logger.Error(r.logTag, "Recording CPI call '%s': %s", method, recordErr.Error())
+	}
+
+	return cmdOutput, err
+}
+
+func (r *recordingCPICmdRunner) write(record CPICallRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling CPI call record")
+	}
+
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+
+	file, err := r.fs.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(0600))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening CPI recording file '%s'", r.path)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(recordBytes, '\n'))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing CPI recording file '%s'", r.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package cloud_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+)
+
+var _ = Describe("RecordingCPICmdRunner", func() {
+	var (
+		tmpDir       string
+		recordPath   string
+		cpiCmdRunner *fakebicloud.FakeCPICmdRunner
+		timeService  *fakeclock.FakeClock
+		runner       CPICmdRunner
+		context      CmdContext
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cpi-record")
+		Expect(err).ToNot(HaveOccurred())
+
+		recordPath = filepath.Join(tmpDir, "cpi.jsonl")
+		cpiCmdRunner = fakebicloud.NewFakeCPICmdRunner()
+		timeService = fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 1, 2, 0, time.UTC))
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		context = CmdContext{DirectorID: "fake-director-id"}
+
+		runner = NewRecordingCPICmdRunner(cpiCmdRunner, boshsys.NewOsFileSystem(logger), recordPath, timeService, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	readRecords := func() []CPICallRecord {
+		contents, err := ioutil.ReadFile(recordPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var records []CPICallRecord
+		for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+			var record CPICallRecord
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		return records
+	}
+
+	It("appends input and output of every call", func() {
+		cpiCmdRunner.RunCmdOutput = CmdOutput{Result: "fake-vm-cid"}
+
+		output, err := runner.Run(context, "create_vm", "fake-agent-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output).To(Equal(CmdOutput{Result: "fake-vm-cid"}))
+
+		cpiCmdRunner.RunCmdOutput = CmdOutput{Error: &CmdError{Type: "Bosh::Clouds::VMNotFound", Message: "fake-message"}}
+
+		_, err = runner.Run(context, "delete_vm", "fake-vm-cid")
+		Expect(err).ToNot(HaveOccurred())
+
+		records := readRecords()
+		Expect(records).To(HaveLen(2))
+
+		Expect(records[0].Input).To(Equal(CmdInput{
+			Method:    "create_vm",
+			Arguments: []interface{}{"fake-agent-id"},
+			Context:   context,
+		}))
+		Expect(records[0].Output.Result).To(Equal("fake-vm-cid"))
+		Expect(records[0].StartedAt).To(Equal(time.Date(2009, time.November, 10, 23, 1, 2, 0, time.UTC)))
+
+		Expect(records[1].Input.Method).To(Equal("delete_vm"))
+		Expect(records[1].Output.Error).To(Equal(&CmdError{Type: "Bosh::Clouds::VMNotFound", Message: "fake-message"}))
+	})
+
+	It("records errors returned by runner", func() {
+		cpiCmdRunner.RunErr = errors.New("fake-run-err")
+
+		_, err := runner.Run(context, "create_vm")
+		Expect(err).To(Equal(cpiCmdRunner.RunErr))
+
+		records := readRecords()
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Error).To(Equal("fake-run-err"))
+		Expect(records[0].Output).To(BeNil())
+	})
+
+	It("does not fail CPI call if recording file cannot be written", func() {
+		runner = NewRecordingCPICmdRunner(
+			cpiCmdRunner,
+			boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)),
+			filepath.Join(tmpDir, "missing-dir", "cpi.jsonl"),
+			timeService,
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+		cpiCmdRunner.RunCmdOutput = CmdOutput{Result: "fake-vm-cid"}
+
+		output, err := runner.Run(context, "create_vm")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Result).To(Equal("fake-vm-cid"))
+	})
+})
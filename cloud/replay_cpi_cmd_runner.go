@@ -0,0 +1,110 @@
+package cloud
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type replayCPICmdRunner struct {
+	fs   boshsys.FileSystem
+	path string
+
+	records []CPICallRecord
+	loaded  bool
+	next    int
+	lock    sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewReplayCPICmdRunner serves CPI responses from a file written by recording CPI runner.
+// Calls are expected to be made in the recorded order; only methods are compared
+// since arguments usually contain generated values (e.g. agent IDs).
+func NewReplayCPICmdRunner(fs boshsys.FileSystem, path string, logger boshlog.Logger) CPICmdRunner {
+	return &replayCPICmdRunner{
+		fs:   fs,
+		path: path,
+
+		logger: logger,
+		logTag: "replayCPICmdRunner",
+	}
+}
+
+func (r *replayCPICmdRunner) Run(context CmdContext, method string, args ...interface{}) (CmdOutput, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.loaded {
+		err := r.load()
+		if err != nil {
+			return CmdOutput{}, err
+		}
+	}
+
+	if r.next >= len(r.records) {
+		return CmdOutput{}, bosherr.Errorf(
+			"Replaying CPI call '%s': all %d recorded CPI calls were already replayed", method, len(r.records))
+	}
+
+	record := r.records[r.next]
+
+	if record.Input.Method != method {
+		return CmdOutput{}, bosherr.Errorf(
+			"Replaying CPI call '%s': expected call #%d to be '%s'", method, r.next+1, record.Input.Method)
+	}
+
+	r.next++
+
+	r.logger.Debug(r.logTag, "Replaying CPI call #%d '%s' with arguments %#v", r.next, method, args)
+
+	if record.Error != "" {
+		return CmdOutput{}, bosherr.Error(record.Error)
+	}
+
+	if record.Output == nil {
+		return CmdOutput{}, bosherr.Errorf("Replaying CPI call '%s': recorded call #%d has no output", method, r.next)
+	}
+
+	return *record.Output, nil
+}
+
+func (r *replayCPICmdRunner) load() error {
+	contents, err := r.fs.ReadFile(r.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading CPI recording file '%s'", r.path)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(make([]byte, 64*1024), len(contents)+1)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var record CPICallRecord
+
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling line %d of CPI recording file '%s'", line, r.path)
+		}
+
+		r.records = append(r.records, record)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading CPI recording file '%s'", r.path)
+	}
+
+	r.loaded = true
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cloud_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+var _ = Describe("ReplayCPICmdRunner", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		runner  CPICmdRunner
+		context CmdContext
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = NewReplayCPICmdRunner(fs, "/cpi.jsonl", boshlog.NewLogger(boshlog.LevelNone))
+		context = CmdContext{DirectorID: "fake-director-id"}
+
+		fs.WriteFileString("/cpi.jsonl", `{"input":{"method":"create_vm","arguments":["agent-id"],"context":{"director_uuid":"director-id"}},"output":{"result":"vm-cid","log":""}}
+
+{"input":{"method":"has_vm","arguments":["vm-cid"],"context":{"director_uuid":"director-id"}},"output":{"result":null,"error":{"type":"Bosh::Clouds::CloudError","message":"fake-message","ok_to_retry":false},"log":""}}
+{"input":{"method":"delete_vm","arguments":["vm-cid"],"context":{"director_uuid":"director-id"}},"error":"fake-run-err"}
+`)
+	})
+
+	It("serves recorded responses in order", func() {
+		output, err := runner.Run(context, "create_vm", "other-agent-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Result).To(Equal("vm-cid"))
+
+		output, err = runner.Run(context, "has_vm", "vm-cid")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Error).To(Equal(&CmdError{Type: "Bosh::Clouds::CloudError", Message: "fake-message"}))
+
+		_, err = runner.Run(context, "delete_vm", "vm-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("fake-run-err"))
+
+		_, err = runner.Run(context, "delete_vm", "vm-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("all 3 recorded CPI calls were already replayed"))
+	})
+
+	It("returns error if called method does not match recorded method", func() {
+		_, err := runner.Run(context, "delete_vm", "vm-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Replaying CPI call 'delete_vm': expected call #1 to be 'create_vm'"))
+	})
+
+	It("returns error if recording cannot be parsed", func() {
+		fs.WriteFileString("/cpi.jsonl", "not-json\n")
+
+		_, err := runner.Run(context, "create_vm")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling line 1 of CPI recording file '/cpi.jsonl'"))
+	})
+
+	It("returns error if recording cannot be read", func() {
+		fs.RemoveAll("/cpi.jsonl")
+
+		_, err := runner.Run(context, "create_vm")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading CPI recording file '/cpi.jsonl'"))
+	})
+})
@@ -0,0 +1,36 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("ByteSizeArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			arg ByteSizeArg
+		)
+
+		BeforeEach(func() {
+			arg = ByteSizeArg(0)
+		})
+
+		It("returns parsed size", func() {
+			err := (&arg).UnmarshalFlag("10GB")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(ByteSizeArg(10 * 1000 * 1000 * 1000)))
+
+			err = (&arg).UnmarshalFlag("1KiB")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg).To(Equal(ByteSizeArg(1024)))
+		})
+
+		It("returns error if it cannot be parsed", func() {
+			err := (&arg).UnmarshalFlag("lots")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing size 'lots'"))
+		})
+	})
+})
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type CacheListCmd struct {
+	ui    boshui.UI
+	cache bitarball.Cache
+}
+
+func NewCacheListCmd(ui boshui.UI, cache bitarball.Cache) CacheListCmd {
+	return CacheListCmd{ui: ui, cache: cache}
+}
+
+func (c CacheListCmd) Run(opts CacheListOpts) error {
+	entries, err := c.cache.List()
+	if err != nil {
+		return bosherr.WrapError(err, "Listing downloaded tarballs")
+	}
+
+	table := boshtbl.Table{
+		Content: "tarballs",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Source"),
+			boshtbl.NewHeader("SHA1"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Last Used"),
+		},
+		Notes: []string{"(*) Partially downloaded"},
+	}
+
+	for _, entry := range entries {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueSuffix(boshtbl.NewValueString(cacheEntrySource(entry)), stateRecordMark(entry.Partial)),
+			boshtbl.NewValueString(entry.SHA1),
+			boshtbl.NewValueBytes(uint64(entry.Size)),
+			boshtbl.NewValueTime(entry.LastUsedAt),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+type CachePruneCmd struct {
+	ui                boshui.UI
+	cache             bitarball.Cache
+	installationsPath string
+	stateProvider     func(string) biconfig.DeploymentStateService
+	fs                boshsys.FileSystem
+	timeService       clock.Clock
+}
+
+func NewCachePruneCmd(
+	ui boshui.UI,
+	cache bitarball.Cache,
+	installationsPath string,
+	stateProvider func(string) biconfig.DeploymentStateService,
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+) CachePruneCmd {
+	return CachePruneCmd{
+		ui:                ui,
+		cache:             cache,
+		installationsPath: installationsPath,
+		stateProvider:     stateProvider,
+		fs:                fs,
+		timeService:       timeService,
+	}
+}
+
+func (c CachePruneCmd) Run(opts CachePruneOpts) error {
+	if opts.OlderThan == 0 && opts.KeepSize == 0 && !opts.Installations {
+		return bosherr.Error("Expected --older-than, --keep-size or --installations to be specified")
+	}
+
+	if len(opts.StatePaths) > 0 && !opts.Installations {
+		return bosherr.Error("Expected --state to be used together with --installations")
+	}
+
+	// Without any state files every installation would look unreferenced
+	if opts.Installations && len(opts.StatePaths) == 0 {
+		return bosherr.Error("Expected at least one --state to be specified with --installations")
+	}
+
+	if opts.OlderThan > 0 || opts.KeepSize > 0 {
+		err := c.pruneTarballs(opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Installations {
+		return c.pruneInstallations(opts.StatePaths)
+	}
+
+	return nil
+}
+
+func (c CachePruneCmd) pruneTarballs(opts CachePruneOpts) error {
+	entries, err := c.cache.List()
+	if err != nil {
+		return bosherr.WrapError(err, "Listing downloaded tarballs")
+	}
+
+	threshold := c.timeService.Now().Add(-opts.OlderThan)
+
+	var prunedEntries, keptEntries []bitarball.CacheEntry
+
+	for _, entry := range entries {
+		if opts.OlderThan > 0 && entry.LastUsedAt.Before(threshold) {
+			prunedEntries = append(prunedEntries, entry)
+		} else {
+			keptEntries = append(keptEntries, entry)
+		}
+	}
+
+	if opts.KeepSize > 0 {
+		var keptSize uint64
+
+		for _, entry := range keptEntries {
+			keptSize += uint64(entry.Size)
+		}
+
+		// Entries are listed least recently used first
+		for len(keptEntries) > 0 && keptSize > uint64(opts.KeepSize) {
+			keptSize -= uint64(keptEntries[0].Size)
+			prunedEntries = append(prunedEntries, keptEntries[0])
+			keptEntries = keptEntries[1:]
+		}
+	}
+
+	if len(prunedEntries) == 0 {
+		c.ui.PrintLinef("No downloaded tarballs need to be removed")
+		return nil
+	}
+
+	var size int64
+
+	for _, entry := range prunedEntries {
+		c.ui.PrintLinef("Removing tarball '%s'", cacheEntrySource(entry))
+		size += entry.Size
+	}
+
+	err = c.cache.Delete(prunedEntries)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing downloaded tarballs")
+	}
+
+	c.ui.PrintLinef("Removed %d tarball(s), %s", len(prunedEntries), boshtbl.NewValueBytes(uint64(size)).String())
+
+	return nil
+}
+
+// pruneInstallations removes installation directories that none of the
+// given deployment states refer to via their installation ID
+func (c CachePruneCmd) pruneInstallations(statePaths []string) error {
+	referencedIDs := map[string]struct{}{}
+
+	for _, statePath := range statePaths {
+		stateService := c.stateProvider(statePath)
+
+		if !stateService.Exists() {
+			return bosherr.Errorf("Deployment state '%s' does not exist", stateService.Path())
+		}
+
+		state, err := stateService.Load()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Loading deployment state '%s'", stateService.Path())
+		}
+
+		if len(state.InstallationID) > 0 {
+			referencedIDs[state.InstallationID] = struct{}{}
+		}
+	}
+
+	paths, err := c.fs.Glob(filepath.Join(c.installationsPath, "*"))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listing installations in '%s'", c.installationsPath)
+	}
+
+	var stalePaths []string
+
+	for _, path := range paths {
+		info, err := c.fs.Stat(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking installation '%s'", path)
+		}
+
+		// Installations are directories named after installation IDs
+		if !info.IsDir() {
+			continue
+		}
+
+		if _, found := referencedIDs[filepath.Base(path)]; !found {
+			c.ui.PrintLinef("Installation '%s' (last modified %s) is not referenced by given state files",
+				path, boshtbl.NewValueTime(info.ModTime()).String())
+			stalePaths = append(stalePaths, path)
+		}
+	}
+
+	if len(stalePaths) == 0 {
+		c.ui.PrintLinef("No installations need to be removed")
+		return nil
+	}
+
+	err = c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range stalePaths {
+		err := c.fs.RemoveAll(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing installation '%s'", path)
+		}
+	}
+
+	c.ui.PrintLinef("Removed %d installation(s)", len(stalePaths))
+
+	return nil
+}
+
+type CacheClearCmd struct {
+	ui    boshui.UI
+	cache bitarball.Cache
+}
+
+func NewCacheClearCmd(ui boshui.UI, cache bitarball.Cache) CacheClearCmd {
+	return CacheClearCmd{ui: ui, cache: cache}
+}
+
+func (c CacheClearCmd) Run(opts CacheClearOpts) error {
+	err := c.ui.AskForConfirmation()
+	if err != nil {
+		return err
+	}
+
+	err = c.cache.Clear()
+	if err != nil {
+		return bosherr.WrapError(err, "Removing downloaded tarballs")
+	}
+
+	c.ui.PrintLinef("Removed all downloaded tarballs")
+
+	return nil
+}
+
+// cacheEntrySource falls back to file name for tarballs
+// that were downloaded before their sources were recorded
+func cacheEntrySource(entry bitarball.CacheEntry) string {
+	if len(entry.URL) > 0 {
+		return entry.URL
+	}
+
+	return filepath.Base(entry.Path)
+}
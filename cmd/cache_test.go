@@ -0,0 +1,334 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	mock_tarball "github.com/cloudfoundry/bosh-cli/installation/tarball/mocks"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("Cache commands", func() {
+	var (
+		mockCtrl *gomock.Controller
+		cache    *mock_tarball.MockCache
+		ui       *fakeui.FakeUI
+		entries  []bitarball.CacheEntry
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		cache = mock_tarball.NewMockCache(mockCtrl)
+		ui = &fakeui.FakeUI{}
+
+		entries = []bitarball.CacheEntry{
+			{
+				Path:       "/downloads/old",
+				URL:        "https://example.com/release.tgz",
+				SHA1:       "release-sha1",
+				Size:       1024,
+				LastUsedAt: time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				Path:       "/downloads/url-sha1-stemcell-sha1.partial",
+				SHA1:       "stemcell-sha1",
+				Size:       2048,
+				Partial:    true,
+				LastUsedAt: time.Date(2017, time.February, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				Path:       "/downloads/new",
+				URL:        "https://example.com/stemcell.tgz",
+				SHA1:       "sha256:stemcell-sha256",
+				Size:       4096,
+				LastUsedAt: time.Date(2017, time.February, 10, 0, 0, 0, 0, time.UTC),
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("CacheListCmd", func() {
+		var (
+			command CacheListCmd
+		)
+
+		BeforeEach(func() {
+			command = NewCacheListCmd(ui, cache)
+		})
+
+		It("lists downloaded tarballs", func() {
+			cache.EXPECT().List().Return(entries, nil)
+
+			err := command.Run(CacheListOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "tarballs",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Source"),
+					boshtbl.NewHeader("SHA1"),
+					boshtbl.NewHeader("Size"),
+					boshtbl.NewHeader("Last Used"),
+				},
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueSuffix(boshtbl.NewValueString("https://example.com/release.tgz"), ""),
+						boshtbl.NewValueString("release-sha1"),
+						boshtbl.NewValueBytes(1024),
+						boshtbl.NewValueTime(time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					{
+						boshtbl.NewValueSuffix(boshtbl.NewValueString("url-sha1-stemcell-sha1.partial"), "*"),
+						boshtbl.NewValueString("stemcell-sha1"),
+						boshtbl.NewValueBytes(2048),
+						boshtbl.NewValueTime(time.Date(2017, time.February, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					{
+						boshtbl.NewValueSuffix(boshtbl.NewValueString("https://example.com/stemcell.tgz"), ""),
+						boshtbl.NewValueString("sha256:stemcell-sha256"),
+						boshtbl.NewValueBytes(4096),
+						boshtbl.NewValueTime(time.Date(2017, time.February, 10, 0, 0, 0, 0, time.UTC)),
+					},
+				},
+				Notes: []string{"(*) Partially downloaded"},
+			}))
+		})
+
+		It("returns an error if listing fails", func() {
+			cache.EXPECT().List().Return(nil, errors.New("fake-err"))
+
+			err := command.Run(CacheListOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("CachePruneCmd", func() {
+		var (
+			fs           *fakesys.FakeFileSystem
+			stateService biconfig.DeploymentStateService
+			command      CachePruneCmd
+		)
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			stateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeuuid.NewFakeGenerator(), logger, "/state.json")
+
+			stateProvider := func(statePath string) biconfig.DeploymentStateService {
+				Expect(statePath).To(Equal("/state.json"))
+				return stateService
+			}
+
+			timeService := fakeclock.NewFakeClock(time.Date(2017, time.February, 15, 0, 0, 0, 0, time.UTC))
+			command = NewCachePruneCmd(ui, cache, "/installations", stateProvider, fs, timeService)
+		})
+
+		It("returns an error if nothing to prune is specified", func() {
+			err := command.Run(CachePruneOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected --older-than, --keep-size or --installations to be specified"))
+		})
+
+		It("returns an error if state files are given without --installations", func() {
+			err := command.Run(CachePruneOpts{OlderThan: time.Hour, StatePaths: []string{"/state.json"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected --state to be used together with --installations"))
+		})
+
+		It("returns an error if --installations is given without state files", func() {
+			fs.SetGlob("/installations/*", []string{"/installations/installation-id"})
+			Expect(fs.MkdirAll("/installations/installation-id", 0755)).To(Succeed())
+
+			err := command.Run(CachePruneOpts{Installations: true})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected at least one --state to be specified with --installations"))
+
+			Expect(fs.FileExists("/installations/installation-id")).To(BeTrue())
+		})
+
+		It("removes tarballs that were not used within given duration", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Delete(entries[:2]).Return(nil)
+
+			err := command.Run(CachePruneOpts{OlderThan: 7 * 24 * time.Hour})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"Removing tarball 'https://example.com/release.tgz'",
+				"Removing tarball 'url-sha1-stemcell-sha1.partial'",
+				"Removed 2 tarball(s), 3.0 KiB",
+			}))
+		})
+
+		It("removes least recently used tarballs until cache fits into given size", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Delete(entries[:2]).Return(nil)
+
+			err := command.Run(CachePruneOpts{KeepSize: ByteSizeArg(5000)})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("combines duration and size limits", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Delete(entries[:2]).Return(nil)
+
+			err := command.Run(CachePruneOpts{OlderThan: 30 * 24 * time.Hour, KeepSize: ByteSizeArg(5000)})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not remove anything when tarballs were used recently and fit into given size", func() {
+			cache.EXPECT().List().Return(entries, nil)
+
+			err := command.Run(CachePruneOpts{OlderThan: 60 * 24 * time.Hour, KeepSize: ByteSizeArg(10000)})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{"No downloaded tarballs need to be removed"}))
+		})
+
+		It("returns an error if removing fails", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Delete(gomock.Any()).Return(errors.New("fake-err"))
+
+			err := command.Run(CachePruneOpts{OlderThan: time.Hour})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		Context("when pruning installations", func() {
+			var (
+				opts CachePruneOpts
+			)
+
+			BeforeEach(func() {
+				opts = CachePruneOpts{Installations: true, StatePaths: []string{"/state.json"}}
+
+				err := stateService.Save(biconfig.DeploymentState{
+					DirectorID:     "director-id",
+					InstallationID: "used-installation-id",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, id := range []string{"used-installation-id", "stale-installation-id"} {
+					Expect(fs.MkdirAll("/installations/"+id, 0755)).To(Succeed())
+					fs.GetFileTestStat("/installations/" + id).ModTime = time.Date(2017, time.January, 2, 3, 4, 5, 0, time.UTC)
+				}
+
+				fs.SetGlob("/installations/*", []string{
+					"/installations/stale-installation-id",
+					"/installations/used-installation-id",
+				})
+			})
+
+			It("removes installations that are not referenced by given state files", func() {
+				err := command.Run(opts)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.AskedConfirmationCalled).To(BeTrue())
+
+				Expect(fs.FileExists("/installations/stale-installation-id")).To(BeFalse())
+				Expect(fs.FileExists("/installations/used-installation-id")).To(BeTrue())
+
+				Expect(ui.Said).To(Equal([]string{
+					"Installation '/installations/stale-installation-id' (last modified Mon Jan  2 03:04:05 UTC 2017) is not referenced by given state files",
+					"Removed 1 installation(s)",
+				}))
+			})
+
+			It("only considers directories to be installations", func() {
+				Expect(fs.WriteFileString("/installations/stray-file", "")).To(Succeed())
+
+				fs.SetGlob("/installations/*", []string{
+					"/installations/stray-file",
+					"/installations/used-installation-id",
+				})
+
+				err := command.Run(opts)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+				Expect(fs.FileExists("/installations/stray-file")).To(BeTrue())
+				Expect(ui.Said).To(Equal([]string{"No installations need to be removed"}))
+			})
+
+			It("does not remove installations if confirmation is rejected", func() {
+				ui.AskedConfirmationErr = errors.New("stop")
+
+				err := command.Run(opts)
+				Expect(err).To(HaveOccurred())
+
+				Expect(fs.FileExists("/installations/stale-installation-id")).To(BeTrue())
+			})
+
+			It("returns an error if state file does not exist", func() {
+				Expect(stateService.Cleanup()).To(Succeed())
+
+				err := command.Run(opts)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deployment state '/state.json' does not exist"))
+
+				Expect(fs.FileExists("/installations/stale-installation-id")).To(BeTrue())
+			})
+
+			It("does not ask for confirmation when all installations are referenced", func() {
+				fs.SetGlob("/installations/*", []string{"/installations/used-installation-id"})
+
+				err := command.Run(opts)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.AskedConfirmationCalled).To(BeFalse())
+				Expect(ui.Said).To(Equal([]string{"No installations need to be removed"}))
+			})
+		})
+	})
+
+	Describe("CacheClearCmd", func() {
+		var (
+			command CacheClearCmd
+		)
+
+		BeforeEach(func() {
+			command = NewCacheClearCmd(ui, cache)
+		})
+
+		It("removes all downloaded tarballs after confirmation", func() {
+			cache.EXPECT().Clear().Return(nil)
+
+			err := command.Run(CacheClearOpts{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.AskedConfirmationCalled).To(BeTrue())
+			Expect(ui.Said).To(Equal([]string{"Removed all downloaded tarballs"}))
+		})
+
+		It("does not remove tarballs if confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := command.Run(CacheClearOpts{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if removing fails", func() {
+			cache.EXPECT().Clear().Return(errors.New("fake-err"))
+
+			err := command.Run(CacheClearOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
@@ -7,13 +7,20 @@ import (
 	"github.com/cppforlife/go-patch/patch"
 
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	"github.com/cloudfoundry/bosh-cli/crypto"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
+	"github.com/cloudfoundry/bosh-cli/manifestlint"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
 	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	bitemplateerb "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
 
@@ -67,19 +74,69 @@ func (c Cmd) Execute() (cmdErr error) {
 
 	case *CreateEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op).Preparer()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.ParallelOpt).Preparer()
 		}
 
-		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
-		return NewCreateEnvCmd(deps.UI, envProvider).Run(stage, *opts)
+		return NewCreateEnvCmd(deps.UI, envProvider, c.deploymentStateLockProvider()).Run(c.stage(), *opts)
 
 	case *DeleteEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentDeleter {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op).Deleter()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.ParallelOpt).Deleter()
 		}
 
-		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
-		return NewDeleteCmd(deps.UI, envProvider).Run(stage, *opts)
+		return NewDeleteCmd(deps.UI, envProvider, c.deploymentStateLockProvider()).Run(c.stage(), *opts)
+
+	case *StopEnvOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentStopper {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, 1).Stopper()
+		}
+
+		return NewStopEnvCmd(deps.UI, envProvider, c.deploymentStateLockProvider()).Run(c.stage(), *opts)
+
+	case *StartEnvOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentStarter {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, 1).Starter()
+		}
+
+		return NewStartEnvCmd(deps.UI, envProvider, c.deploymentStateLockProvider()).Run(c.stage(), *opts)
+
+	case *StateShowOpts:
+		return NewStateShowCmd(deps.UI, c.deploymentStateProvider()).Run(*opts)
+
+	case *StateForgetVMOpts:
+		return NewStateForgetVMCmd(deps.UI, c.deploymentStateProvider(), c.deploymentStateLockProvider(), deps.UUIDGen, deps.Time).Run(*opts)
+
+	case *StateForgetDiskOpts:
+		return NewStateForgetDiskCmd(deps.UI, c.deploymentStateProvider(), c.deploymentStateLockProvider(), deps.UUIDGen, deps.Time).Run(*opts)
+
+	case *StateForgetStemcellOpts:
+		return NewStateForgetStemcellCmd(deps.UI, c.deploymentStateProvider(), c.deploymentStateLockProvider(), deps.UUIDGen, deps.Time).Run(*opts)
+
+	case *StateValidateOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentStateValidator {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, 1).StateValidator()
+		}
+
+		return NewStateValidateCmd(deps.UI, envProvider).Run(c.stage(), *opts)
+
+	case *CompiledCacheListOpts:
+		return NewCompiledCacheListCmd(deps.UI, c.compiledPackageCacheProvider()).Run(*opts)
+
+	case *CompiledCachePruneOpts:
+		return NewCompiledCachePruneCmd(deps.UI, c.compiledPackageCacheProvider(), deps.Time).Run(*opts)
+
+	case *CompiledCacheExportOpts:
+		return NewCompiledCacheExportCmd(deps.UI, c.compiledPackageCacheProvider(), deps.Compressor, deps.FS).Run(*opts)
+
+	case *CacheListOpts:
+		return NewCacheListCmd(deps.UI, c.tarballCache()).Run(*opts)
+
+	case *CachePruneOpts:
+		installationsPath := filepath.Join(envWorkspacePath(), "installations")
+		return NewCachePruneCmd(deps.UI, c.tarballCache(), installationsPath, c.deploymentStateProvider(), deps.FS, deps.Time).Run(*opts)
+
+	case *CacheClearOpts:
+		return NewCacheClearCmd(deps.UI, c.tarballCache()).Run(*opts)
 
 	case *AliasEnvOpts:
 		sessionFactory := func(config cmdconf.Config) Session {
@@ -221,6 +278,36 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *InterpolateOpts:
 		return NewInterpolateCmd(deps.UI).Run(*opts)
 
+	case *LintManifestOpts:
+		relProv, _ := c.releaseProviders()
+		return NewLintManifestCmd(deps.UI, relProv.NewExtractingArchiveReader(), manifestlint.NewLinter()).Run(*opts)
+
+	case *RenderTemplatesOpts:
+		relProv, _ := c.releaseProviders()
+		erbRenderer := bitemplateerb.NewDefaultERBRenderer(deps.FS, deps.CmdRunner, deps.Logger)
+		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, deps.FS, deps.UUIDGen, deps.Logger)
+		jobListRenderer := bitemplate.NewJobListRenderer(jobRenderer, deps.Logger)
+		return NewRenderTemplatesCmd(
+			deps.UI, relProv.NewExtractingArchiveReader(), boshjob.NewSourceDirReader(deps.FS), jobListRenderer, deps.FS).Run(*opts)
+
+	case *VarsStoreRekeyOpts:
+		return NewVarsStoreRekeyCmd(deps.FS).Run(*opts)
+
+	case *VarsStoreDecryptOpts:
+		return NewVarsStoreDecryptCmd(deps.UI, deps.FS).Run(*opts)
+
+	case *ConfigsOpts:
+		return NewConfigsCmd(deps.UI, c.director()).Run(*opts)
+
+	case *ConfigOpts:
+		return NewConfigCmd(deps.UI, c.director()).Run(*opts)
+
+	case *UpdateConfigOpts:
+		return NewUpdateConfigCmd(deps.UI, c.director()).Run(*opts)
+
+	case *DeleteConfigOpts:
+		return NewDeleteConfigCmd(deps.UI, c.director()).Run(*opts)
+
 	case *CloudConfigOpts:
 		return NewCloudConfigCmd(deps.UI, c.director()).Run()
 
@@ -290,6 +377,9 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *CloudCheckOpts:
 		return NewCloudCheckCmd(c.deployment(), deps.UI).Run(*opts)
 
+	case *CurlOpts:
+		return NewCurlCmd(deps.UI, c.director()).Run(*opts)
+
 	case *CleanUpOpts:
 		return NewCleanUpCmd(deps.UI, c.director()).Run(*opts)
 
@@ -344,8 +434,10 @@ func (c Cmd) Execute() (cmdErr error) {
 			return releaseReader, releaseDir
 		}
 
-		_, err := NewCreateReleaseCmd(releaseDirFactory, relProv.NewArchiveWriter(), c.deps.FS, c.deps.UI).Run(*opts)
-		return err
+		return c.performEventStage("Creating release", func() error {
+			_, err := NewCreateReleaseCmd(releaseDirFactory, relProv.NewArchiveWriter(), c.deps.FS, c.deps.UI).Run(*opts)
+			return err
+		})
 
 	case *Sha2ifyReleaseOpts:
 		relProv, _ := c.releaseProviders()
@@ -369,7 +461,7 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewRemoveBlobCmd(c.blobsDir(opts.Directory), deps.UI).Run(*opts)
 
 	case *UploadBlobsOpts:
-		return NewUploadBlobsCmd(c.blobsDir(opts.Directory)).Run()
+		return c.performEventStage("Uploading blobs", NewUploadBlobsCmd(c.blobsDir(opts.Directory)).Run)
 
 	case *SyncBlobsOpts:
 		return NewSyncBlobsCmd(c.blobsDir(opts.Directory), opts.ParallelOpt).Run()
@@ -392,7 +484,9 @@ func (c Cmd) configureUI() {
 		c.deps.UI.EnableColor()
 	}
 
-	if c.BoshOpts.JSONOpt {
+	if c.BoshOpts.EventsOpt {
+		c.deps.UI.EnableEvents(c.deps.Time)
+	} else if c.BoshOpts.JSONOpt {
 		c.deps.UI.EnableJSON()
 	}
 
@@ -410,6 +504,47 @@ func (c Cmd) configureUI() {
 	}
 }
 
+func (c Cmd) deploymentStateLockProvider() func(string, string) biconfig.DeploymentStateLock {
+	return func(manifestPath, statePath string) biconfig.DeploymentStateLock {
+		return biconfig.NewDeploymentStateLockForPath(
+			c.deps.FS, c.deps.Time, c.deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
+	}
+}
+
+func (c Cmd) deploymentStateProvider() func(string) biconfig.DeploymentStateService {
+	return func(statePath string) biconfig.DeploymentStateService {
+		return biconfig.NewDeploymentStateService(c.deps.FS, c.deps.UUIDGen, c.deps.Logger, statePath)
+	}
+}
+
+func (c Cmd) compiledPackageCacheProvider() CompiledPackageCacheProvider {
+	return func(location string) (bistatepkg.CompiledPackageCache, error) {
+		return bistatepkg.NewCompiledPackageCacheFromLocation(location, c.deps.FS, c.deps.Time, c.deps.Logger)
+	}
+}
+
+func (c Cmd) tarballCache() bitarball.Cache {
+	return bitarball.NewCache(filepath.Join(envWorkspacePath(), "downloads"), c.deps.FS, c.deps.Time, c.deps.Logger)
+}
+
+func (c Cmd) stage() boshui.Stage {
+	if c.deps.UI.IsEventsEnabled() {
+		return boshui.NewEventStage(c.deps.UI, c.deps.Time, c.deps.Logger)
+	}
+
+	return boshui.NewStage(c.deps.UI, c.deps.Time, c.deps.Logger)
+}
+
+// performEventStage wraps commands that do not report their progress
+// in stages so that their start and finish still show up in events output
+func (c Cmd) performEventStage(name string, closure func() error) error {
+	if !c.deps.UI.IsEventsEnabled() {
+		return closure()
+	}
+
+	return c.stage().Perform(name, closure)
+}
+
 func (c Cmd) configureFS() {
 	tmpDirPath, err := c.deps.FS.ExpandPath(filepath.Join("~", ".bosh", "tmp"))
 	c.panicIfErr(err)
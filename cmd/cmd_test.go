@@ -66,6 +66,19 @@ var _ = Describe("Cmd", func() {
 			Expect(ui.Blocks[0]).To(ContainSubstring(`Blocks": [`))
 		})
 
+		It("allows to enable events output", func() {
+			cmd.BoshOpts = BoshOpts{EventsOpt: true, JSONOpt: true}
+			cmd.Opts = &InterpolateOpts{}
+
+			err := cmd.Execute()
+			Expect(err).ToNot(HaveOccurred())
+
+			confUI.Flush()
+
+			Expect(ui.Blocks).To(HaveLen(1))
+			Expect(ui.Blocks[0]).To(ContainSubstring(`"type":"output","message":"null\n"`))
+		})
+
 		Describe("color", func() {
 			executeCmdAndPrintTable := func() {
 				err := cmd.Execute()
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type CompiledPackageCacheProvider func(string) (bistatepkg.CompiledPackageCache, error)
+
+func compiledPackageCache(provider CompiledPackageCacheProvider, flags CompiledCacheFlags) (bistatepkg.CompiledPackageCache, error) {
+	if len(flags.Cache) == 0 {
+		return nil, bosherr.Error("Expected compiled package cache to be specified via --cache or BOSH_COMPILED_PACKAGE_CACHE")
+	}
+
+	return provider(flags.Cache)
+}
+
+type CompiledCacheListCmd struct {
+	ui            boshui.UI
+	cacheProvider CompiledPackageCacheProvider
+}
+
+func NewCompiledCacheListCmd(ui boshui.UI, cacheProvider CompiledPackageCacheProvider) CompiledCacheListCmd {
+	return CompiledCacheListCmd{ui: ui, cacheProvider: cacheProvider}
+}
+
+func (c CompiledCacheListCmd) Run(opts CompiledCacheListOpts) error {
+	cache, err := compiledPackageCache(c.cacheProvider, opts.CompiledCacheFlags)
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		return bosherr.WrapError(err, "Listing compiled packages")
+	}
+
+	table := boshtbl.Table{
+		Content: "compiled packages",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Fingerprint"),
+			boshtbl.NewHeader("Stemcell"),
+			boshtbl.NewHeader("SHA1"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Last Used"),
+		},
+	}
+
+	for _, entry := range entries {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(entry.Name),
+			boshtbl.NewValueString(entry.Fingerprint),
+			boshtbl.NewValueString(entry.Stemcell().String()),
+			boshtbl.NewValueString(entry.SHA1),
+			boshtbl.NewValueBytes(uint64(entry.Size)),
+			boshtbl.NewValueTime(entry.LastUsedAt),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+type CompiledCachePruneCmd struct {
+	ui            boshui.UI
+	cacheProvider CompiledPackageCacheProvider
+	timeService   clock.Clock
+}
+
+func NewCompiledCachePruneCmd(ui boshui.UI, cacheProvider CompiledPackageCacheProvider, timeService clock.Clock) CompiledCachePruneCmd {
+	return CompiledCachePruneCmd{ui: ui, cacheProvider: cacheProvider, timeService: timeService}
+}
+
+func (c CompiledCachePruneCmd) Run(opts CompiledCachePruneOpts) error {
+	cache, err := compiledPackageCache(c.cacheProvider, opts.CompiledCacheFlags)
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		return bosherr.WrapError(err, "Listing compiled packages")
+	}
+
+	threshold := c.timeService.Now().Add(-opts.OlderThan)
+
+	var prunedEntries []bistatepkg.CompiledPackageCacheEntry
+
+	for _, entry := range entries {
+		if entry.LastUsedAt.Before(threshold) {
+			prunedEntries = append(prunedEntries, entry)
+		}
+	}
+
+	if len(prunedEntries) == 0 {
+		c.ui.PrintLinef("No compiled packages were last used before '%s'", boshtbl.NewValueTime(threshold).String())
+		return nil
+	}
+
+	var size int64
+
+	for _, entry := range prunedEntries {
+		c.ui.PrintLinef("Removing compiled package '%s/%s' for stemcell '%s'", entry.Name, entry.Fingerprint, entry.Stemcell())
+		size += entry.Size
+	}
+
+	err = cache.Delete(prunedEntries)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing compiled packages")
+	}
+
+	c.ui.PrintLinef("Removed %d compiled package(s), %s", len(prunedEntries), boshtbl.NewValueBytes(uint64(size)).String())
+
+	return nil
+}
+
+type CompiledCacheExportCmd struct {
+	ui            boshui.UI
+	cacheProvider CompiledPackageCacheProvider
+	compressor    boshfu.Compressor
+	fs            boshsys.FileSystem
+}
+
+func NewCompiledCacheExportCmd(
+	ui boshui.UI,
+	cacheProvider CompiledPackageCacheProvider,
+	compressor boshfu.Compressor,
+	fs boshsys.FileSystem,
+) CompiledCacheExportCmd {
+	return CompiledCacheExportCmd{ui: ui, cacheProvider: cacheProvider, compressor: compressor, fs: fs}
+}
+
+func (c CompiledCacheExportCmd) Run(opts CompiledCacheExportOpts) error {
+	cache, err := compiledPackageCache(c.cacheProvider, opts.CompiledCacheFlags)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.entries(cache, opts.Stemcell)
+	if err != nil {
+		return err
+	}
+
+	dir, err := c.fs.TempDir("bosh-compiled-cache-export")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating temporary directory")
+	}
+
+	defer c.fs.RemoveAll(dir)
+
+	err = cache.Export(entries, dir)
+	if err != nil {
+		return bosherr.WrapError(err, "Exporting compiled packages")
+	}
+
+	tarballPath, err := c.compressor.CompressFilesInDir(dir)
+	if err != nil {
+		return bosherr.WrapError(err, "Compressing exported compiled packages")
+	}
+
+	err = c.fs.Rename(tarballPath, opts.Args.Path.ExpandedPath)
+	if err != nil {
+		_ = c.compressor.CleanUp(tarballPath)
+		return bosherr.WrapErrorf(err, "Moving exported compiled packages to '%s'", opts.Args.Path.ExpandedPath)
+	}
+
+	c.ui.PrintLinef("Exported %d compiled package(s) to '%s'", len(entries), opts.Args.Path.ExpandedPath)
+
+	return nil
+}
+
+func (c CompiledCacheExportCmd) entries(cache bistatepkg.CompiledPackageCache, stemcell string) ([]bistatepkg.CompiledPackageCacheEntry, error) {
+	entries, err := cache.List()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing compiled packages")
+	}
+
+	if len(stemcell) == 0 {
+		return entries, nil
+	}
+
+	if len(strings.Split(stemcell, "/")) != 2 {
+		return nil, bosherr.Errorf("Expected stemcell '%s' to be in OS/VERSION format", stemcell)
+	}
+
+	var stemcellEntries []bistatepkg.CompiledPackageCacheEntry
+
+	for _, entry := range entries {
+		if entry.Stemcell().String() == stemcell {
+			stemcellEntries = append(stemcellEntries, entry)
+		}
+	}
+
+	if len(stemcellEntries) == 0 {
+		return nil, bosherr.Errorf("No compiled packages found for stemcell '%s'", stemcell)
+	}
+
+	return stemcellEntries, nil
+}
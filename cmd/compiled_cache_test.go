@@ -0,0 +1,270 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	fakefu "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	mock_state_pkg "github.com/cloudfoundry/bosh-cli/state/pkg/mocks"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("CompiledCache commands", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		cache         *mock_state_pkg.MockCompiledPackageCache
+		ui            *fakeui.FakeUI
+		cacheProvider CompiledPackageCacheProvider
+		entries       []bistatepkg.CompiledPackageCacheEntry
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		cache = mock_state_pkg.NewMockCompiledPackageCache(mockCtrl)
+		ui = &fakeui.FakeUI{}
+
+		cacheProvider = func(location string) (bistatepkg.CompiledPackageCache, error) {
+			Expect(location).To(Equal("/cache"))
+			return cache, nil
+		}
+
+		entries = []bistatepkg.CompiledPackageCacheEntry{
+			{
+				Name:            "pkg1",
+				Fingerprint:     "pkg1-fp",
+				StemcellOS:      "ubuntu-trusty",
+				StemcellVersion: "3445.11",
+				SHA1:            "pkg1-sha1",
+				Size:            1024,
+				LastUsedAt:      time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				Name:            "pkg2",
+				Fingerprint:     "pkg2-fp",
+				StemcellOS:      "ubuntu-xenial",
+				StemcellVersion: "97",
+				SHA1:            "pkg2-sha1",
+				Size:            2048,
+				LastUsedAt:      time.Date(2017, time.February, 1, 0, 0, 0, 0, time.UTC),
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("CompiledCacheListCmd", func() {
+		var (
+			command CompiledCacheListCmd
+			opts    CompiledCacheListOpts
+		)
+
+		BeforeEach(func() {
+			command = NewCompiledCacheListCmd(ui, cacheProvider)
+			opts = CompiledCacheListOpts{CompiledCacheFlags: CompiledCacheFlags{Cache: "/cache"}}
+		})
+
+		It("lists cached compiled packages", func() {
+			cache.EXPECT().List().Return(entries, nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "compiled packages",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Fingerprint"),
+					boshtbl.NewHeader("Stemcell"),
+					boshtbl.NewHeader("SHA1"),
+					boshtbl.NewHeader("Size"),
+					boshtbl.NewHeader("Last Used"),
+				},
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("pkg1"),
+						boshtbl.NewValueString("pkg1-fp"),
+						boshtbl.NewValueString("ubuntu-trusty/3445.11"),
+						boshtbl.NewValueString("pkg1-sha1"),
+						boshtbl.NewValueBytes(1024),
+						boshtbl.NewValueTime(time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)),
+					},
+					{
+						boshtbl.NewValueString("pkg2"),
+						boshtbl.NewValueString("pkg2-fp"),
+						boshtbl.NewValueString("ubuntu-xenial/97"),
+						boshtbl.NewValueString("pkg2-sha1"),
+						boshtbl.NewValueBytes(2048),
+						boshtbl.NewValueTime(time.Date(2017, time.February, 1, 0, 0, 0, 0, time.UTC)),
+					},
+				},
+			}))
+		})
+
+		It("returns an error if cache is not specified", func() {
+			err := command.Run(CompiledCacheListOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected compiled package cache to be specified"))
+		})
+
+		It("returns an error if cache cannot be created", func() {
+			command = NewCompiledCacheListCmd(ui, func(string) (bistatepkg.CompiledPackageCache, error) {
+				return nil, errors.New("fake-err")
+			})
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns an error if listing fails", func() {
+			cache.EXPECT().List().Return(nil, errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("CompiledCachePruneCmd", func() {
+		var (
+			command CompiledCachePruneCmd
+			opts    CompiledCachePruneOpts
+		)
+
+		BeforeEach(func() {
+			timeService := fakeclock.NewFakeClock(time.Date(2017, time.February, 15, 0, 0, 0, 0, time.UTC))
+			command = NewCompiledCachePruneCmd(ui, cacheProvider, timeService)
+			opts = CompiledCachePruneOpts{
+				CompiledCacheFlags: CompiledCacheFlags{Cache: "/cache"},
+				OlderThan:          30 * 24 * time.Hour,
+			}
+		})
+
+		It("removes packages that were not used within given duration", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Delete(entries[:1]).Return(nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(Equal([]string{
+				"Removing compiled package 'pkg1/pkg1-fp' for stemcell 'ubuntu-trusty/3445.11'",
+				"Removed 1 compiled package(s), 1.0 KiB",
+			}))
+		})
+
+		It("does not remove anything when all packages were used recently", func() {
+			opts.OlderThan = 60 * 24 * time.Hour
+
+			cache.EXPECT().List().Return(entries, nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(HaveLen(1))
+			Expect(ui.Said[0]).To(ContainSubstring("No compiled packages were last used before"))
+		})
+
+		It("returns an error if removing fails", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Delete(gomock.Any()).Return(errors.New("fake-err"))
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("CompiledCacheExportCmd", func() {
+		var (
+			fs         *fakesys.FakeFileSystem
+			compressor *fakefu.FakeCompressor
+			command    CompiledCacheExportCmd
+			opts       CompiledCacheExportOpts
+		)
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			fs.TempDirDir = "/export-dir"
+
+			compressor = fakefu.NewFakeCompressor()
+			compressor.CompressFilesInDirTarballPath = "/tmp/export.tgz"
+			Expect(fs.WriteFileString("/tmp/export.tgz", "tarball")).To(Succeed())
+			Expect(fs.MkdirAll("/dst", 0755)).To(Succeed())
+
+			command = NewCompiledCacheExportCmd(ui, cacheProvider, compressor, fs)
+			opts = CompiledCacheExportOpts{
+				Args:               CompiledCacheExportArgs{Path: FileArg{ExpandedPath: "/dst/export.tgz"}},
+				CompiledCacheFlags: CompiledCacheFlags{Cache: "/cache"},
+			}
+		})
+
+		It("exports all packages into tarball", func() {
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Export(entries, "/export-dir").Return(nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(compressor.CompressFilesInDirDir).To(Equal("/export-dir"))
+			Expect(fs.ReadFileString("/dst/export.tgz")).To(Equal("tarball"))
+			Expect(fs.FileExists("/export-dir")).To(BeFalse())
+
+			Expect(ui.Said).To(Equal([]string{"Exported 2 compiled package(s) to '/dst/export.tgz'"}))
+		})
+
+		It("exports only packages compiled for given stemcell", func() {
+			opts.Stemcell = "ubuntu-xenial/97"
+
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Export(entries[1:], "/export-dir").Return(nil)
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if stemcell is not in OS/VERSION format", func() {
+			opts.Stemcell = "ubuntu-xenial"
+
+			cache.EXPECT().List().Return(entries, nil)
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected stemcell 'ubuntu-xenial' to be in OS/VERSION format"))
+		})
+
+		It("returns an error if there are no packages for given stemcell", func() {
+			opts.Stemcell = "ubuntu-xenial/98"
+
+			cache.EXPECT().List().Return(entries, nil)
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No compiled packages found for stemcell 'ubuntu-xenial/98'"))
+		})
+
+		It("cleans up tarball if it cannot be moved to destination", func() {
+			fs.RenameError = errors.New("fake-err")
+
+			cache.EXPECT().List().Return(entries, nil)
+			cache.EXPECT().Export(entries, "/export-dir").Return(nil)
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(compressor.CleanUpTarballPath).To(Equal("/tmp/export.tgz"))
+		})
+	})
+})
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type ConfigCmd struct {
+	ui       boshui.UI
+	director boshdir.Director
+}
+
+func NewConfigCmd(ui boshui.UI, director boshdir.Director) ConfigCmd {
+	return ConfigCmd{ui: ui, director: director}
+}
+
+func (c ConfigCmd) Run(opts ConfigOpts) error {
+	var config boshdir.TypedConfig
+	var err error
+
+	hasID := len(opts.Args.ID) > 0
+	hasTypeOrName := len(opts.Type) > 0 || len(opts.Name) > 0
+
+	switch {
+	case hasID && hasTypeOrName:
+		return bosherr.Error("Expected either ID or type and name to be specified, but not both")
+
+	case hasID:
+		config, err = c.director.LatestConfigByID(opts.Args.ID)
+
+	case len(opts.Type) > 0 && len(opts.Name) > 0:
+		config, err = c.director.LatestConfig(opts.Type, opts.Name)
+
+	default:
+		return bosherr.Error("Expected either ID or both type and name to be specified")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintBlock(config.Content)
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("ConfigCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  ConfigCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewConfigCmd(ui, director)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts ConfigOpts
+		)
+
+		BeforeEach(func() {
+			opts = ConfigOpts{}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("shows latest config for given type and name", func() {
+			opts.Type = "resurrection"
+			opts.Name = "team-a"
+
+			director.LatestConfigReturns(boshdir.TypedConfig{Content: "some-content"}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.LatestConfigCallCount()).To(Equal(1))
+
+			configType, name := director.LatestConfigArgsForCall(0)
+			Expect(configType).To(Equal("resurrection"))
+			Expect(name).To(Equal("team-a"))
+
+			Expect(ui.Blocks).To(Equal([]string{"some-content"}))
+		})
+
+		It("shows config with given ID", func() {
+			opts.Args.ID = "123"
+
+			director.LatestConfigByIDReturns(boshdir.TypedConfig{Content: "some-content"}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.LatestConfigByIDCallCount()).To(Equal(1))
+			Expect(director.LatestConfigByIDArgsForCall(0)).To(Equal("123"))
+
+			Expect(ui.Blocks).To(Equal([]string{"some-content"}))
+		})
+
+		It("returns error if both ID and type are given", func() {
+			opts.Args.ID = "123"
+			opts.Type = "cloud"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("but not both"))
+
+			Expect(director.LatestConfigByIDCallCount()).To(Equal(0))
+			Expect(director.LatestConfigCallCount()).To(Equal(0))
+		})
+
+		It("returns error if neither ID nor both type and name are given", func() {
+			opts.Type = "cloud"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected either ID or both type and name to be specified"))
+		})
+
+		It("returns error if config cannot be retrieved", func() {
+			opts.Type = "cloud"
+			opts.Name = "default"
+
+			director.LatestConfigReturns(boshdir.TypedConfig{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
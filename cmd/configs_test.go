@@ -0,0 +1,101 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("ConfigsCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  ConfigsCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewConfigsCmd(ui, director)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts ConfigsOpts
+		)
+
+		BeforeEach(func() {
+			opts = ConfigsOpts{Recent: 1}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("lists configs", func() {
+			configs := []boshdir.TypedConfig{
+				{ID: "2", Type: "cloud", Name: "default", CreatedAt: "2017-10-10 10:00:00 UTC"},
+				{ID: "1", Type: "resurrection", Name: "team-a", CreatedAt: "2017-10-09 10:00:00 UTC"},
+			}
+
+			director.ConfigsReturns(configs, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "configs",
+
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("ID"),
+					boshtbl.NewHeader("Type"),
+					boshtbl.NewHeader("Name"),
+					boshtbl.NewHeader("Created At"),
+				},
+
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("2"),
+						boshtbl.NewValueString("cloud"),
+						boshtbl.NewValueString("default"),
+						boshtbl.NewValueString("2017-10-10 10:00:00 UTC"),
+					},
+					{
+						boshtbl.NewValueString("1"),
+						boshtbl.NewValueString("resurrection"),
+						boshtbl.NewValueString("team-a"),
+						boshtbl.NewValueString("2017-10-09 10:00:00 UTC"),
+					},
+				},
+			}))
+		})
+
+		It("filters configs by type and name and includes previous versions", func() {
+			opts.Type = "cloud"
+			opts.Name = "team-a"
+			opts.Recent = 5
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.ConfigsCallCount()).To(Equal(1))
+
+			limit, filter := director.ConfigsArgsForCall(0)
+			Expect(limit).To(Equal(5))
+			Expect(filter).To(Equal(boshdir.ConfigsFilter{Type: "cloud", Name: "team-a"}))
+		})
+
+		It("returns error if configs cannot be retrieved", func() {
+			director.ConfigsReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
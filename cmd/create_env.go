@@ -3,24 +3,38 @@ package cmd
 import (
 	"github.com/cppforlife/go-patch/patch"
 
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
 type CreateEnvCmd struct {
-	ui          boshui.UI
-	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentPreparer
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) DeploymentPreparer
+	lockProvider func(string, string) biconfig.DeploymentStateLock
 }
 
-func NewCreateEnvCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentPreparer) *CreateEnvCmd {
-	return &CreateEnvCmd{ui: ui, envProvider: envProvider}
+func NewCreateEnvCmd(
+	ui boshui.UI,
+	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentPreparer,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+) *CreateEnvCmd {
+	return &CreateEnvCmd{ui: ui, envProvider: envProvider, lockProvider: lockProvider}
 }
 
 func (c *CreateEnvCmd) Run(stage boshui.Stage, opts CreateEnvOpts) error {
 	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
 
-	depPreparer := c.envProvider(
-		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+	lock := c.lockProvider(opts.Args.Manifest.Path, opts.StatePath)
 
-	return depPreparer.PrepareDeployment(stage)
+	return withDeploymentStateLock(c.ui, lock, opts.ForceUnlock, func() error {
+		depPreparer := c.envProvider(
+			opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+		return depPreparer.PrepareDeployment(stage, DeploymentPreparerOpts{
+			Recreate:  opts.Recreate,
+			SkipDrain: opts.SkipDrain,
+			DryRun:    opts.DryRun,
+		})
+	})
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"code.cloudfoundry.org/clock"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakebihttpclient "github.com/cloudfoundry/bosh-utils/httpclient/fakes"
@@ -27,6 +28,7 @@ import (
 	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
 	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	mock_config "github.com/cloudfoundry/bosh-cli/config/mocks"
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
 	"github.com/cloudfoundry/bosh-cli/crypto"
@@ -75,6 +77,8 @@ var _ = Describe("CreateEnvCmd", func() {
 	Describe("Run", func() {
 		var (
 			command          *bicmd.CreateEnvCmd
+			fakeLock         *fakebiconfig.FakeDeploymentStateLock
+			lockedStatePath  string
 			fs               *fakesys.FakeFileSystem
 			stdOut           *gbytes.Buffer
 			stdErr           *gbytes.Buffer
@@ -138,6 +142,7 @@ var _ = Describe("CreateEnvCmd", func() {
 			cloudStemcell bistemcell.CloudStemcell
 
 			defaultCreateEnvOpts bicmd.CreateEnvOpts
+			preparer             bicmd.DeploymentPreparer
 
 			expectLegacyMigrate        *gomock.Call
 			expectStemcellUpload       *gomock.Call
@@ -304,7 +309,7 @@ var _ = Describe("CreateEnvCmd", func() {
 				return cpiRelease, nil
 			}
 
-			cloud = bicloud.NewCloud(fakebicloud.NewFakeCPICmdRunner(), "fake-director-id", logger)
+			cloud = bicloud.NewCloud(fakebicloud.NewFakeCPICmdRunner(), "fake-director-id", 1, 0, logger)
 			cloudStemcell = fakebistemcell.NewFakeCloudStemcell(
 				"fake-stemcell-cid", "fake-stemcell-name", "fake-stemcell-version")
 
@@ -324,8 +329,8 @@ var _ = Describe("CreateEnvCmd", func() {
 				deploymentRecord := deployment.NewRecord(deploymentRepo, releaseRepo, stemcellRepo)
 
 				fakeHTTPClient := fakebihttpclient.NewFakeHTTPClient()
-				tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
-				tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, 1, 0, logger)
+				tarballCache := bitarball.NewCache("fake-base-path", fs, clock.NewClock(), logger)
+				tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, biui.NewFileReporter(userInterface), 1, 0, logger)
 
 				cpiInstaller := bicpirel.CpiInstaller{
 					ReleaseManager:   releaseManager,
@@ -385,7 +390,16 @@ var _ = Describe("CreateEnvCmd", func() {
 				)
 			}
 
-			command = bicmd.NewCreateEnvCmd(userInterface, doGet)
+			preparer = doGet(deploymentManifestPath, "", boshtpl.StaticVariables{}, patch.Ops{})
+
+			fakeLock = fakebiconfig.NewFakeDeploymentStateLock()
+			lockProvider := func(manifestPath, statePath string) biconfig.DeploymentStateLock {
+				Expect(manifestPath).To(Equal(deploymentManifestPath))
+				lockedStatePath = statePath
+				return fakeLock
+			}
+
+			command = bicmd.NewCreateEnvCmd(userInterface, doGet, lockProvider)
 
 			expectLegacyMigrate = mockLegacyDeploymentStateMigrator.EXPECT().MigrateIfExists(filepath.Join("/", "path", "to", "bosh-deployments.yml")).AnyTimes()
 
@@ -431,12 +445,13 @@ var _ = Describe("CreateEnvCmd", func() {
 				installationManifest.Registry,
 				fakeVMManager,
 				mockBlobstore,
+				false,
 				gomock.Any(),
-			).Do(func(_, _, _, _, _, _ interface{}, stage biui.Stage) {
+			).Do(func(_, _, _, _, _, _, _ interface{}, stage biui.Stage) {
 				Expect(fakeStage.SubStages).To(ContainElement(stage))
 			}).Return(mockDeployment, nil).AnyTimes()
 
-			expectNewCloud = mockCloudFactory.EXPECT().NewCloud(installation, directorID).Return(cloud, nil).AnyTimes()
+			expectNewCloud = mockCloudFactory.EXPECT().NewCloud(installation, directorID, 0).Return(cloud, nil).AnyTimes()
 		})
 
 		Describe("prints the deployment manifest and state file", func() {
@@ -496,6 +511,64 @@ var _ = Describe("CreateEnvCmd", func() {
 			Expect(stdOut).To(gbytes.Say("Migrated legacy deployments file: '" + regexp.QuoteMeta(filepath.Join("/", "path", "to", "bosh-deployments.yml")) + "'"))
 		})
 
+		Describe("deployment state lock", func() {
+			It("locks deployment state for the duration of deploy", func() {
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lockedStatePath).To(Equal(""))
+				Expect(fakeLock.LockCalled).To(BeTrue())
+				Expect(fakeLock.UnlockCalled).To(BeTrue())
+				Expect(fakeLock.ForceUnlockCalled).To(BeFalse())
+			})
+
+			It("does not deploy if deployment state is locked", func() {
+				fakeLock.LockErr = errors.New("fake-lock-err")
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(Equal(fakeLock.LockErr))
+				Expect(fakeLock.UnlockCalled).To(BeFalse())
+			})
+
+			It("unlocks deployment state even if deploy fails", func() {
+				fs.ChangeTempRootErr = errors.New("fake ChangeTempRootErr")
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeLock.UnlockCalled).To(BeTrue())
+			})
+
+			It("returns error if deployment state cannot be unlocked", func() {
+				fakeLock.UnlockErr = errors.New("fake-unlock-err")
+
+				err := command.Run(fakeStage, defaultCreateEnvOpts)
+				Expect(err).To(Equal(fakeLock.UnlockErr))
+			})
+
+			It("removes existing lock if force unlock is requested", func() {
+				opts := defaultCreateEnvOpts
+				opts.ForceUnlock = true
+
+				err := command.Run(fakeStage, opts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeLock.ForceUnlockCalled).To(BeTrue())
+				Expect(fakeLock.LockCalled).To(BeTrue())
+				Expect(stdOut).To(gbytes.Say("Removed deployment state lock"))
+			})
+
+			It("returns error if existing lock cannot be removed", func() {
+				fakeLock.ForceUnlockErr = errors.New("fake-force-unlock-err")
+
+				opts := defaultCreateEnvOpts
+				opts.ForceUnlock = true
+
+				err := command.Run(fakeStage, opts)
+				Expect(err).To(Equal(fakeLock.ForceUnlockErr))
+				Expect(fakeLock.LockCalled).To(BeFalse())
+			})
+		})
+
 		It("sets the temp root", func() {
 			err := command.Run(fakeStage, defaultCreateEnvOpts)
 			Expect(err).NotTo(HaveOccurred())
@@ -699,6 +772,169 @@ var _ = Describe("CreateEnvCmd", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(stdOut).To(gbytes.Say("No deployment, stemcell or release changes. Skipping deploy."))
 			})
+
+			It("deploys when recreate is requested", func() {
+				expectDeploy.Times(1)
+
+				opts := defaultCreateEnvOpts
+				opts.Recreate = true
+
+				err := command.Run(fakeStage, opts)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stdOut).To(gbytes.Say("No deployment, stemcell or release changes. Recreating VM as requested."))
+
+				var stageNames []string
+				for _, call := range fakeStage.PerformCalls {
+					stageNames = append(stageNames, call.Name)
+				}
+				Expect(stageNames).To(ContainElement("deploying (recreating VM)"))
+			})
+
+			It("recreates VM when manifest matches the required one", func() {
+				expectDeploy.Times(1)
+
+				err := preparer.PrepareDeployment(fakeStage, bicmd.DeploymentPreparerOpts{
+					Recreate:            true,
+					RequiredManifestSHA: manifestSHA,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("refuses to deploy when manifest differs from the required one", func() {
+				expectDeploy.Times(0)
+
+				err := preparer.PrepareDeployment(fakeStage, bicmd.DeploymentPreparerOpts{
+					Recreate:            true,
+					RequiredManifestSHA: "other-manifest-sha",
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Deployment manifest differs from the deployed one. Use create-env to apply manifest changes"))
+			})
+		})
+
+		It("skips draining existing VM when requested", func() {
+			expectDeploy.Times(0)
+
+			mockDeployer.EXPECT().Deploy(
+				cloud,
+				boshDeploymentManifest,
+				cloudStemcell,
+				installationManifest.Registry,
+				fakeVMManager,
+				mockBlobstore,
+				true,
+				gomock.Any(),
+			).Return(nil, nil)
+
+			opts := defaultCreateEnvOpts
+			opts.SkipDrain = true
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when dry run is requested", func() {
+			var opts bicmd.CreateEnvOpts
+
+			BeforeEach(func() {
+				opts = defaultCreateEnvOpts
+				opts.DryRun = true
+			})
+
+			It("prints the plan without installing CPI or deploying", func() {
+				expectInstall.Times(0)
+				expectStemcellUpload.Times(0)
+				expectDeploy.Times(0)
+
+				err := command.Run(fakeStage, opts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stdOut).To(gbytes.Say("upload-stemcell"))
+				Expect(stdOut).To(gbytes.Say("create-vm"))
+				Expect(stdOut).To(gbytes.Say("update-jobs"))
+				Expect(stdOut).To(gbytes.Say("Dry run: no changes were made."))
+			})
+
+			It("does not save deployment state", func() {
+				err := command.Run(fakeStage, opts)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(setupDeploymentStateService.Exists()).To(BeFalse())
+			})
+
+			It("does not migrate the legacy bosh-deployments.yml", func() {
+				expectLegacyMigrate.Times(0)
+
+				err := command.Run(fakeStage, opts)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when deployment has not changed", func() {
+				JustBeforeEach(func() {
+					err := setupDeploymentStateService.Save(biconfig.DeploymentState{
+						DirectorID:     directorID,
+						InstallationID: "fake-installation-id",
+						CurrentVMCID:   "fake-vm-cid",
+						Releases: []biconfig.ReleaseRecord{{
+							ID:      "my-release-id-1",
+							Name:    cpiRelease.Name(),
+							Version: cpiRelease.Version(),
+						}},
+						CurrentStemcellID: "my-stemcellRecordID",
+						Stemcells: []biconfig.StemcellRecord{{
+							ID:      "my-stemcellRecordID",
+							Name:    cloudStemcell.Name(),
+							Version: cloudStemcell.Version(),
+						}},
+						CurrentManifestSHA: manifestSHA,
+					})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("prints that there are no changes", func() {
+					err := command.Run(fakeStage, opts)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(stdOut).To(gbytes.Say("0 steps"))
+					Expect(stdOut).To(gbytes.Say("No deployment, stemcell or release changes."))
+				})
+
+				It("prints recreate step when recreate is requested", func() {
+					opts.Recreate = true
+
+					err := command.Run(fakeStage, opts)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(stdOut).To(gbytes.Say("recreate-vm"))
+					Expect(stdOut).To(gbytes.Say("recreate requested"))
+				})
+			})
+
+			Context("when deployment record does not consider deployment to be deployed", func() {
+				JustBeforeEach(func() {
+					err := setupDeploymentStateService.Save(biconfig.DeploymentState{
+						DirectorID:        directorID,
+						InstallationID:    "fake-installation-id",
+						CurrentVMCID:      "fake-vm-cid",
+						CurrentStemcellID: "my-stemcellRecordID",
+						Stemcells: []biconfig.StemcellRecord{{
+							ID:      "my-stemcellRecordID",
+							Name:    cloudStemcell.Name(),
+							Version: cloudStemcell.Version(),
+						}},
+						CurrentManifestSHA: manifestSHA,
+					})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("prints the steps that deploy would take", func() {
+					err := command.Run(fakeStage, opts)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(stdOut).To(gbytes.Say("recreate-vm"))
+					Expect(stdOut).To(gbytes.Say("update-jobs"))
+				})
+			})
 		})
 
 		Context("when parsing the cpi deployment manifest fails", func() {
@@ -1033,6 +1269,7 @@ var _ = Describe("CreateEnvCmd", func() {
 					installationManifest.Registry,
 					fakeVMManager,
 					mockBlobstore,
+					false,
 					gomock.Any(),
 				).Return(nil, errors.New("fake-deploy-error")).AnyTimes()
 
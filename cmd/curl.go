@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type CurlCmd struct {
+	ui       boshui.UI
+	director boshdir.Director
+}
+
+func NewCurlCmd(ui boshui.UI, director boshdir.Director) CurlCmd {
+	return CurlCmd{ui: ui, director: director}
+}
+
+func (c CurlCmd) Run(opts CurlOpts) error {
+	headers := http.Header{}
+
+	for _, header := range opts.Headers {
+		pieces := strings.SplitN(header, ":", 2)
+		if len(pieces) != 2 || len(strings.TrimSpace(pieces[0])) == 0 {
+			return bosherr.Errorf("Expected header '%s' to be in 'Name: value' format", header)
+		}
+
+		headers.Add(strings.TrimSpace(pieces[0]), strings.TrimSpace(pieces[1]))
+	}
+
+	if len(opts.Body.Bytes) > 0 && len(headers.Get("Content-Type")) == 0 {
+		headers.Set("Content-Type", "application/json")
+	}
+
+	req := boshdir.CurlRequest{
+		Method:     opts.Method,
+		Path:       opts.Args.Path,
+		Headers:    headers,
+		Body:       opts.Body.Bytes,
+		FollowTask: opts.FollowTask,
+	}
+
+	resp, err := c.director.Curl(req)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintBlock(c.formatBody(resp.Body))
+
+	return nil
+}
+
+// formatBody indents JSON responses; other responses are shown as is
+func (c CurlCmd) formatBody(body []byte) string {
+	var buf bytes.Buffer
+
+	err := json.Indent(&buf, body, "", "  ")
+	if err != nil {
+		return string(body)
+	}
+
+	return buf.String() + "\n"
+}
@@ -0,0 +1,118 @@
+package cmd_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("CurlCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  CurlCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewCurlCmd(ui, director)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts CurlOpts
+		)
+
+		BeforeEach(func() {
+			opts = CurlOpts{
+				Args:   CurlArgs{Path: "/info"},
+				Method: "GET",
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("makes request and shows indented JSON response", func() {
+			director.CurlReturns(boshdir.CurlResponse{StatusCode: 200, Body: []byte(`{"name":"dir"}`)}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.CurlCallCount()).To(Equal(1))
+			Expect(director.CurlArgsForCall(0)).To(Equal(boshdir.CurlRequest{
+				Method:  "GET",
+				Path:    "/info",
+				Headers: http.Header{},
+			}))
+
+			Expect(ui.Blocks).To(Equal([]string{"{\n  \"name\": \"dir\"\n}\n"}))
+		})
+
+		It("shows non-JSON response as is", func() {
+			director.CurlReturns(boshdir.CurlResponse{StatusCode: 200, Body: []byte("text")}, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Blocks).To(Equal([]string{"text"}))
+		})
+
+		It("passes method, headers, body and follow task flag", func() {
+			opts.Method = "POST"
+			opts.Headers = []string{"X-Custom: val1", "X-Custom:val2"}
+			opts.Body = FileBytesArg{Bytes: []byte(`{}`)}
+			opts.FollowTask = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.CurlArgsForCall(0)).To(Equal(boshdir.CurlRequest{
+				Method: "POST",
+				Path:   "/info",
+				Headers: http.Header{
+					"X-Custom":     []string{"val1", "val2"},
+					"Content-Type": []string{"application/json"},
+				},
+				Body:       []byte(`{}`),
+				FollowTask: true,
+			}))
+		})
+
+		It("does not override explicitly specified content type", func() {
+			opts.Headers = []string{"Content-Type: text/yaml"}
+			opts.Body = FileBytesArg{Bytes: []byte(`---`)}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.CurlArgsForCall(0).Headers).To(Equal(http.Header{
+				"Content-Type": []string{"text/yaml"},
+			}))
+		})
+
+		It("returns error if header is malformed", func() {
+			opts.Headers = []string{"X-Custom"}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected header 'X-Custom' to be in 'Name: value' format"))
+			Expect(director.CurlCallCount()).To(Equal(0))
+		})
+
+		It("returns error if request fails", func() {
+			director.CurlReturns(boshdir.CurlResponse{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+			Expect(ui.Blocks).To(BeEmpty())
+		})
+	})
+})
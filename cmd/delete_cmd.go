@@ -3,24 +3,34 @@ package cmd
 import (
 	"github.com/cppforlife/go-patch/patch"
 
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
 type DeleteCmd struct {
-	ui          boshui.UI
-	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter
+	lockProvider func(string, string) biconfig.DeploymentStateLock
 }
 
-func NewDeleteCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter) *DeleteCmd {
-	return &DeleteCmd{ui: ui, envProvider: envProvider}
+func NewDeleteCmd(
+	ui boshui.UI,
+	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+) *DeleteCmd {
+	return &DeleteCmd{ui: ui, envProvider: envProvider, lockProvider: lockProvider}
 }
 
 func (c *DeleteCmd) Run(stage boshui.Stage, opts DeleteEnvOpts) error {
 	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
 
-	depDeleter := c.envProvider(
-		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+	lock := c.lockProvider(opts.Args.Manifest.Path, opts.StatePath)
 
-	return depDeleter.DeleteDeployment(stage)
+	return withDeploymentStateLock(c.ui, lock, opts.ForceUnlock, func() error {
+		depDeleter := c.envProvider(
+			opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+		return depDeleter.DeleteDeployment(stage)
+	})
 }
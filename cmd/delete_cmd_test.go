@@ -12,6 +12,8 @@ import (
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	"github.com/golang/mock/gomock"
 
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
@@ -36,6 +38,7 @@ var _ = Describe("DeleteCmd", func() {
 
 			fakeUI                 *fakeui.FakeUI
 			fakeStage              *fakebiui.FakeStage
+			fakeLock               *fakebiconfig.FakeDeploymentStateLock
 			deploymentManifestPath = "/deployment-dir/fake-deployment-manifest.yml"
 			statePath              string
 		)
@@ -49,7 +52,11 @@ var _ = Describe("DeleteCmd", func() {
 				return mockDeploymentDeleter
 			}
 
-			return bicmd.NewDeleteCmd(fakeUI, doGetFunc)
+			lockProvider := func(manifestPath string, statePath string) biconfig.DeploymentStateLock {
+				return fakeLock
+			}
+
+			return bicmd.NewDeleteCmd(fakeUI, doGetFunc, lockProvider)
 		}
 
 		var writeDeploymentManifest = func() {
@@ -62,6 +69,7 @@ var _ = Describe("DeleteCmd", func() {
 			fs.EnableStrictTempRootBehavior()
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			fakeUI = &fakeui.FakeUI{}
+			fakeLock = fakebiconfig.NewFakeDeploymentStateLock()
 			writeDeploymentManifest()
 		})
 
@@ -126,6 +134,45 @@ var _ = Describe("DeleteCmd", func() {
 					},
 				})
 				Expect(returnedErr).To(Equal(err))
+				Expect(fakeLock.UnlockCalled).To(BeTrue())
+			})
+		})
+
+		Context("when deployment state is locked", func() {
+			It("does not delete deployment", func() {
+				fakeLock.LockErr = bosherr.Error("fake-lock-err")
+
+				err := newDeleteCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
+					Args: bicmd.DeleteEnvArgs{
+						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+					},
+				})
+				Expect(err).To(Equal(fakeLock.LockErr))
+			})
+		})
+
+		Context("when force unlock is requested", func() {
+			It("removes existing lock before deleting deployment", func() {
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(fakeStage).Return(nil)
+
+				err := newDeleteCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
+					Args: bicmd.DeleteEnvArgs{
+						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+					},
+					VarFlags: bicmd.VarFlags{
+						VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}},
+					},
+					OpsFlags: bicmd.OpsFlags{
+						OpsFiles: []bicmd.OpsFileArg{
+							{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})},
+						},
+					},
+					ForceUnlock: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeLock.ForceUnlockCalled).To(BeTrue())
+				Expect(fakeLock.LockCalled).To(BeTrue())
+				Expect(fakeLock.UnlockCalled).To(BeTrue())
 			})
 		})
 	})
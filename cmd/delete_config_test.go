@@ -0,0 +1,81 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("DeleteConfigCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  DeleteConfigCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewDeleteConfigCmd(ui, director)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts DeleteConfigOpts
+		)
+
+		BeforeEach(func() {
+			opts = DeleteConfigOpts{Type: "resurrection", Name: "team-a"}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("deletes config", func() {
+			director.DeleteConfigReturns(true, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.DeleteConfigCallCount()).To(Equal(1))
+
+			configType, name := director.DeleteConfigArgsForCall(0)
+			Expect(configType).To(Equal("resurrection"))
+			Expect(name).To(Equal("team-a"))
+
+			Expect(ui.Said).To(BeEmpty())
+		})
+
+		It("reports when there was no matching config", func() {
+			director.DeleteConfigReturns(false, nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Said).To(ContainElement(
+				"No configs to delete: no matches for type 'resurrection' and name 'team-a' found."))
+		})
+
+		It("does not delete config if confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("stop"))
+
+			Expect(director.DeleteConfigCallCount()).To(Equal(0))
+		})
+
+		It("returns error if deleting config failed", func() {
+			director.DeleteConfigReturns(false, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
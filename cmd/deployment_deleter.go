@@ -126,15 +126,17 @@ func (c *deploymentDeleter) DeleteDeployment(stage biui.Stage) (err error) {
 			return err
 		}
 
-		cpiReleaseName := installationManifest.Template.Release
-		cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
-		if !found {
-			return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
-		}
+		if !installationManifest.UsesLocalCPI() {
+			cpiReleaseName := installationManifest.Template.Release
+			cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
+			if !found {
+				return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
+			}
 
-		err = c.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
-		if err != nil {
-			return err
+			err = c.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
+			if err != nil {
+				return err
+			}
 		}
 
 		err = c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
@@ -146,9 +148,15 @@ func (c *deploymentDeleter) DeleteDeployment(stage biui.Stage) (err error) {
 	}
 
 	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
-		return localCpiInstallation.WithRunningRegistry(c.logger, stage, func() error {
-			err = c.findAndDeleteDeployment(stage, localCpiInstallation, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+		c.logger.Debug(c.logTag, "Creating cloud client...")
 
+		cloud, err := c.cloudFactory.NewCloud(localCpiInstallation, deploymentState.DirectorID, deploymentState.CurrentStemcellAPIVersion())
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		deleteDeployment := func() error {
+			err := c.findAndDeleteDeployment(stage, cloud, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
 			if err != nil {
 				return err
 			}
@@ -161,14 +169,20 @@ func (c *deploymentDeleter) DeleteDeployment(stage biui.Stage) (err error) {
 
 				return c.deploymentStateService.Cleanup()
 			})
-		})
+		}
+
+		if !cloud.UsesRegistry() {
+			return deleteDeployment()
+		}
+
+		return localCpiInstallation.WithRunningRegistry(c.logger, stage, deleteDeployment)
 	})
 
 	return err
 }
 
-func (c *deploymentDeleter) findAndDeleteDeployment(stage biui.Stage, installation biinstall.Installation, directorID, installationMbus, caCert string) error {
-	deploymentManager, err := c.deploymentManager(installation, directorID, installationMbus, caCert)
+func (c *deploymentDeleter) findAndDeleteDeployment(stage biui.Stage, cloud bicloud.Cloud, directorID, installationMbus, caCert string) error {
+	deploymentManager, err := c.deploymentManager(cloud, directorID, installationMbus, caCert)
 	if err != nil {
 		return err
 	}
@@ -200,14 +214,7 @@ func (c *deploymentDeleter) findCurrentDeploymentAndDelete(stage biui.Stage, dep
 	})
 }
 
-func (c *deploymentDeleter) deploymentManager(installation biinstall.Installation, directorID, installationMbus, caCert string) (bidepl.Manager, error) {
-	c.logger.Debug(c.logTag, "Creating cloud client...")
-
-	cloud, err := c.cloudFactory.NewCloud(installation, directorID)
-	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating CPI client from CPI installation")
-	}
-
+func (c *deploymentDeleter) deploymentManager(cloud bicloud.Cloud, directorID, installationMbus, caCert string) (bidepl.Manager, error) {
 	c.logger.Debug(c.logTag, "Creating agent client...")
 
 	agentClient, _ := c.agentClientFactory.NewAgentClient(directorID, installationMbus, caCert)
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"code.cloudfoundry.org/clock"
 	mock_httpagent "github.com/cloudfoundry/bosh-agent/agentclient/http/mocks"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakebihttpclient "github.com/cloudfoundry/bosh-utils/httpclient/fakes"
@@ -200,7 +201,7 @@ cloud_provider:
 			}).Return(fakeInstallation, nil).AnyTimes()
 			mockCpiInstaller.EXPECT().Cleanup(fakeInstallation).AnyTimes()
 
-			expectNewCloud = mockCloudFactory.EXPECT().NewCloud(fakeInstallation, directorID).Return(mockCloud, nil).AnyTimes()
+			expectNewCloud = mockCloudFactory.EXPECT().NewCloud(fakeInstallation, directorID, 0).Return(mockCloud, nil).AnyTimes()
 		}
 
 		var newDeploymentDeleter = func() bicmd.DeploymentDeleter {
@@ -209,8 +210,8 @@ cloud_provider:
 			installationValidator := biinstallmanifest.NewValidator(logger)
 			installationParser := biinstallmanifest.NewParser(fs, fakeUUIDGenerator, logger, installationValidator)
 			fakeHTTPClient := fakebihttpclient.NewFakeHTTPClient()
-			tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
-			tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, 1, 0, logger)
+			tarballCache := bitarball.NewCache("fake-base-path", fs, clock.NewClock(), logger)
+			tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, biui.NewFileReporter(fakeUI), 1, 0, logger)
 			deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, ""))
 
 			cpiInstaller := bicpirel.CpiInstaller{
@@ -329,6 +330,7 @@ cloud_provider:
 			fakeStage = fakebiui.NewFakeStage()
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+			mockCloud.EXPECT().UsesRegistry().Return(true).AnyTimes()
 			mockCloudFactory = mock_cloud.NewMockFactory(mockCtrl)
 
 			mockCpiInstaller = mock_install.NewMockInstaller(mockCtrl)
@@ -509,7 +511,7 @@ cloud_provider:
 				}).Return(fakeInstallation, nil).AnyTimes()
 				mockCpiInstaller.EXPECT().Cleanup(fakeInstallation).AnyTimes()
 
-				expectNewCloud = mockCloudFactory.EXPECT().NewCloud(fakeInstallation, directorID).Return(mockCloud, nil).AnyTimes()
+				expectNewCloud = mockCloudFactory.EXPECT().NewCloud(fakeInstallation, directorID, 0).Return(mockCloud, nil).AnyTimes()
 			})
 
 			Context("when the call to delete the deployment returns an error", func() {
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type DeploymentStopper interface {
+	StopDeployment(deleteVM bool, stage biui.Stage) error
+}
+
+type DeploymentStarter interface {
+	StartDeployment(stage biui.Stage) error
+}
+
+func NewDeploymentLifecycle(
+	ui biui.UI,
+	logTag string,
+	logger boshlog.Logger,
+	deploymentStateService biconfig.DeploymentStateService,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	agentClientFactory bihttpagent.AgentClientFactory,
+	blobstoreFactory biblobstore.Factory,
+	deploymentManagerFactory bidepl.ManagerFactory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	cpiInstaller bicpirel.CpiInstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	targetProvider biinstall.TargetProvider,
+	preparer DeploymentPreparer,
+) *deploymentLifecycle {
+	return &deploymentLifecycle{
+		ui:                                      ui,
+		logTag:                                  logTag,
+		logger:                                  logger,
+		deploymentStateService:                  deploymentStateService,
+		releaseManager:                          releaseManager,
+		cloudFactory:                            cloudFactory,
+		agentClientFactory:                      agentClientFactory,
+		blobstoreFactory:                        blobstoreFactory,
+		deploymentManagerFactory:                deploymentManagerFactory,
+		deploymentManifestPath:                  deploymentManifestPath,
+		deploymentVars:                          deploymentVars,
+		deploymentOp:                            deploymentOp,
+		cpiInstaller:                            cpiInstaller,
+		releaseFetcher:                          releaseFetcher,
+		releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+		tempRootConfigurator:                    tempRootConfigurator,
+		targetProvider:                          targetProvider,
+		preparer:                                preparer,
+	}
+}
+
+// deploymentLifecycle stops and starts jobs of an existing deployment
+// without changing what is deployed
+type deploymentLifecycle struct {
+	ui                                      biui.UI
+	logTag                                  string
+	logger                                  boshlog.Logger
+	deploymentStateService                  biconfig.DeploymentStateService
+	releaseManager                          biinstall.ReleaseManager
+	cloudFactory                            bicloud.Factory
+	agentClientFactory                      bihttpagent.AgentClientFactory
+	blobstoreFactory                        biblobstore.Factory
+	deploymentManagerFactory                bidepl.ManagerFactory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	cpiInstaller                            bicpirel.CpiInstaller
+	releaseFetcher                          biinstall.ReleaseFetcher
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+	tempRootConfigurator                    TempRootConfigurator
+	targetProvider                          biinstall.TargetProvider
+	preparer                                DeploymentPreparer
+}
+
+func (c *deploymentLifecycle) StopDeployment(deleteVM bool, stage biui.Stage) error {
+	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+
+	if !c.deploymentStateService.Exists() {
+		c.ui.BeginLinef("No deployment state file found.\n")
+		return nil
+	}
+
+	deploymentState, err := c.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	if deploymentState.CurrentVMCID == "" {
+		c.ui.BeginLinef("No VM found. Skipping stop.\n")
+		return nil
+	}
+
+	return c.withCurrentDeployment(deploymentState, stage, func(deployment bidepl.Deployment) error {
+		return stage.PerformComplex("stopping deployment", func(stopStage biui.Stage) error {
+			return deployment.Stop(deleteVM, stopStage)
+		})
+	})
+}
+
+func (c *deploymentLifecycle) StartDeployment(stage biui.Stage) error {
+	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+
+	if !c.deploymentStateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist. Use create-env to create the environment", c.deploymentStateService.Path())
+	}
+
+	deploymentState, err := c.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	if deploymentState.CurrentVMCID == "" {
+		if deploymentState.CurrentManifestSHA == "" {
+			return bosherr.Error("Environment has not been deployed. Use create-env to create the environment")
+		}
+
+		// Recreates VM by redeploying given manifest which must be the one
+		// recorded in deployment state; recorded persistent disk is reattached by deploy
+		c.ui.BeginLinef("No VM found. Recreating VM.\n")
+
+		return c.preparer.PrepareDeployment(stage, DeploymentPreparerOpts{
+			Recreate:            true,
+			RequiredManifestSHA: deploymentState.CurrentManifestSHA,
+		})
+	}
+
+	return c.withCurrentDeployment(deploymentState, stage, func(deployment bidepl.Deployment) error {
+		return stage.PerformComplex("starting deployment", func(startStage biui.Stage) error {
+			return deployment.Start(startStage)
+		})
+	})
+}
+
+func (c *deploymentLifecycle) withCurrentDeployment(deploymentState biconfig.DeploymentState, stage biui.Stage, fn func(bidepl.Deployment) error) error {
+	target, err := c.targetProvider.NewTarget()
+	if err != nil {
+		return bosherr.WrapError(err, "Determining installation target")
+	}
+
+	err = c.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), c.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	var installationManifest biinstallmanifest.Manifest
+
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		if !installationManifest.UsesLocalCPI() {
+			cpiReleaseName := installationManifest.Template.Release
+			cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
+			if !found {
+				return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
+			}
+
+			err = c.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
+		cloud, err := c.cloudFactory.NewCloud(localCpiInstallation, deploymentState.DirectorID, deploymentState.CurrentStemcellAPIVersion())
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		withDeployment := func() error {
+			deploymentManager, err := c.deploymentManager(cloud, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+			if err != nil {
+				return err
+			}
+
+			deployment, found, err := deploymentManager.FindCurrent()
+			if err != nil {
+				return bosherr.WrapError(err, "Finding current deployment")
+			}
+
+			if !found {
+				return bosherr.Error("No current deployment found")
+			}
+
+			return fn(deployment)
+		}
+
+		if !cloud.UsesRegistry() {
+			return withDeployment()
+		}
+
+		return localCpiInstallation.WithRunningRegistry(c.logger, stage, withDeployment)
+	})
+}
+
+func (c *deploymentLifecycle) deploymentManager(cloud bicloud.Cloud, directorID, installationMbus, caCert string) (bidepl.Manager, error) {
+	agentClient, _ := c.agentClientFactory.NewAgentClient(directorID, installationMbus, caCert)
+
+	blobstore, err := c.blobstoreFactory.Create(installationMbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating blobstore client")
+	}
+
+	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore), nil
+}
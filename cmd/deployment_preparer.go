@@ -21,6 +21,7 @@ import (
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
 func NewDeploymentPreparer(
@@ -75,6 +76,20 @@ func NewDeploymentPreparer(
 	}
 }
 
+type DeploymentPreparerOpts struct {
+	// Recreate forces VM recreation even if nothing has changed
+	Recreate bool
+
+	// SkipDrain skips running drain scripts on existing VM
+	SkipDrain bool
+
+	// DryRun only prints what would change without changing anything
+	DryRun bool
+
+	// RequiredManifestSHA refuses to deploy manifest which differs from given one
+	RequiredManifestSHA string
+}
+
 type DeploymentPreparer struct {
 	ui                                      biui.UI
 	logger                                  boshlog.Logger
@@ -101,9 +116,13 @@ type DeploymentPreparer struct {
 	targetProvider                          biinstall.TargetProvider
 }
 
-func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage) (err error) {
+func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, opts DeploymentPreparerOpts) (err error) {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
+	if opts.DryRun {
+		return c.planDeployment(stage, opts)
+	}
+
 	if !c.deploymentStateService.Exists() {
 		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
 		if err != nil {
@@ -136,39 +155,7 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage) (err error) {
 		}
 	}()
 
-	var (
-		extractedStemcell    bistemcell.ExtractedStemcell
-		deploymentManifest   bideplmanifest.Manifest
-		installationManifest biinstallmanifest.Manifest
-		manifestSHA          string
-	)
-	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
-		var releaseSetManifest birelsetmanifest.Manifest
-		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
-		if err != nil {
-			return err
-		}
-
-		for _, releaseRef := range releaseSetManifest.Releases {
-			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
-			if err != nil {
-				return err
-			}
-		}
-
-		err := c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
-		if err != nil {
-			return err
-		}
-
-		deploymentManifest, manifestSHA, err = c.deploymentManifestParser.GetDeploymentManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp, releaseSetManifest, stage)
-		if err != nil {
-			return err
-		}
-
-		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
-		return err
-	})
+	extractedStemcell, deploymentManifest, installationManifest, manifestSHA, err := c.validate(stage)
 	if err != nil {
 		return err
 	}
@@ -179,27 +166,48 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage) (err error) {
 		}
 	}()
 
+	if len(opts.RequiredManifestSHA) > 0 && manifestSHA != opts.RequiredManifestSHA {
+		return bosherr.Error("Deployment manifest differs from the deployed one. Use create-env to apply manifest changes")
+	}
+
 	isDeployed, err := c.deploymentRecord.IsDeployed(manifestSHA, c.releaseManager.List(), extractedStemcell)
 	if err != nil {
 		return bosherr.WrapError(err, "Checking if deployment has changed")
 	}
 
 	if isDeployed {
-		c.ui.BeginLinef("No deployment, stemcell or release changes. Skipping deploy.\n")
-		return nil
+		if !opts.Recreate {
+			c.ui.BeginLinef("No deployment, stemcell or release changes. Skipping deploy.\n")
+			return nil
+		}
+
+		c.ui.BeginLinef("No deployment, stemcell or release changes. Recreating VM as requested.\n")
 	}
 
 	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(installation biinstall.Installation) error {
-		return installation.WithRunningRegistry(c.logger, stage, func() error {
+		cloud, err := c.cloudFactory.NewCloud(installation, deploymentState.DirectorID, extractedStemcell.Manifest().APIVersion)
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		deploy := func() error {
 			return c.deploy(
-				installation,
+				cloud,
 				deploymentState,
 				extractedStemcell,
 				installationManifest,
 				deploymentManifest,
 				manifestSHA,
+				opts,
 				stage)
-		})
+		}
+
+		// Agent settings and disk hints are not passed through the registry with CPI API v2
+		if !cloud.UsesRegistry() {
+			return deploy()
+		}
+
+		return installation.WithRunningRegistry(c.logger, stage, deploy)
 	})
 
 	return err
@@ -207,19 +215,15 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage) (err error) {
 }
 
 func (c *DeploymentPreparer) deploy(
-	installation biinstall.Installation,
+	cloud bicloud.Cloud,
 	deploymentState biconfig.DeploymentState,
 	extractedStemcell bistemcell.ExtractedStemcell,
 	installationManifest biinstallmanifest.Manifest,
 	deploymentManifest bideplmanifest.Manifest,
 	manifestSHA string,
+	opts DeploymentPreparerOpts,
 	stage biui.Stage,
 ) (err error) {
-	cloud, err := c.cloudFactory.NewCloud(installation, deploymentState.DirectorID)
-	if err != nil {
-		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
-	}
-
 	stemcellManager := c.stemcellManagerFactory.NewManager(cloud)
 
 	cloudStemcell, err := stemcellManager.Upload(extractedStemcell, stage)
@@ -238,7 +242,17 @@ func (c *DeploymentPreparer) deploy(
 		return bosherr.WrapError(err, "Creating blobstore client")
 	}
 
-	err = stage.PerformComplex("deploying", func(deployStage biui.Stage) error {
+	registryConfig := installationManifest.Registry
+	if !cloud.UsesRegistry() {
+		registryConfig = biinstallmanifest.Registry{}
+	}
+
+	stageName := "deploying"
+	if opts.Recreate {
+		stageName = "deploying (recreating VM)"
+	}
+
+	err = stage.PerformComplex(stageName, func(deployStage biui.Stage) error {
 		err = c.deploymentRecord.Clear()
 		if err != nil {
 			return bosherr.WrapError(err, "Clearing deployment record")
@@ -248,9 +262,10 @@ func (c *DeploymentPreparer) deploy(
 			cloud,
 			deploymentManifest,
 			cloudStemcell,
-			installationManifest.Registry,
+			registryConfig,
 			vmManager,
 			blobstore,
+			opts.SkipDrain,
 			deployStage,
 		)
 		if err != nil {
@@ -277,3 +292,132 @@ func (c *DeploymentPreparer) deploy(
 
 	return nil
 }
+
+func (c *DeploymentPreparer) validate(stage biui.Stage) (
+	extractedStemcell bistemcell.ExtractedStemcell,
+	deploymentManifest bideplmanifest.Manifest,
+	installationManifest biinstallmanifest.Manifest,
+	manifestSHA string,
+	err error,
+) {
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		for _, releaseRef := range releaseSetManifest.Releases {
+			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		deploymentManifest, manifestSHA, err = c.deploymentManifestParser.GetDeploymentManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp, releaseSetManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
+		return err
+	})
+
+	return
+}
+
+// planDeployment prints changes that deploy would make. It does not
+// install the CPI, does not compile packages and does not save deployment state.
+func (c *DeploymentPreparer) planDeployment(stage biui.Stage, opts DeploymentPreparerOpts) error {
+	var deploymentState biconfig.DeploymentState
+
+	if c.deploymentStateService.Exists() {
+		var err error
+
+		deploymentState, err = c.deploymentStateService.Load()
+		if err != nil {
+			return bosherr.WrapError(err, "Loading deployment state")
+		}
+	}
+
+	// Installation target is only known once it was recorded in deployment state
+	if deploymentState.InstallationID != "" {
+		target, err := c.targetProvider.NewTarget()
+		if err != nil {
+			return bosherr.WrapError(err, "Determining installation target")
+		}
+
+		err = c.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), c.logger)
+		if err != nil {
+			return bosherr.WrapError(err, "Setting temp root")
+		}
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	extractedStemcell, deploymentManifest, _, manifestSHA, err := c.validate(stage)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		deleteErr := extractedStemcell.Cleanup()
+		if deleteErr != nil {
+			c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+		}
+	}()
+
+	// Deployment record saves deployment state when loading it for the first time;
+	// without deployment state nothing could have been deployed anyway
+	isDeployed := false
+
+	if c.deploymentStateService.Exists() {
+		isDeployed, err = c.deploymentRecord.IsDeployed(manifestSHA, c.releaseManager.List(), extractedStemcell)
+		if err != nil {
+			return bosherr.WrapError(err, "Checking if deployment has changed")
+		}
+	}
+
+	plan, err := bidepl.NewPlan(deploymentState, deploymentManifest, manifestSHA,
+		c.releaseManager.List(), extractedStemcell, isDeployed, opts.Recreate)
+	if err != nil {
+		return bosherr.WrapError(err, "Planning deployment")
+	}
+
+	table := boshtbl.Table{
+		Title:   "Plan",
+		Content: "steps",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("#"),
+			boshtbl.NewHeader("Action"),
+			boshtbl.NewHeader("Description"),
+		},
+	}
+
+	for i, step := range plan.Steps {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueInt(i + 1),
+			boshtbl.NewValueString(step.Action),
+			boshtbl.NewValueString(step.Description),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if !plan.HasChanges() {
+		c.ui.BeginLinef("No deployment, stemcell or release changes.\n")
+	}
+
+	c.ui.BeginLinef("Dry run: no changes were made.\n")
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// withDeploymentStateLock holds deployment state lock for the whole duration of closure
+func withDeploymentStateLock(ui boshui.UI, lock biconfig.DeploymentStateLock, forceUnlock bool, closure func() error) error {
+	if forceUnlock {
+		err := lock.ForceUnlock()
+		if err != nil {
+			return err
+		}
+
+		ui.BeginLinef("Removed deployment state lock\n")
+	}
+
+	err := lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	err = closure()
+
+	unlockErr := lock.Unlock()
+	if err == nil {
+		err = unlockErr
+	}
+
+	return err
+}
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type DeploymentStateValidator interface {
+	ValidateDeploymentState(stage biui.Stage) error
+}
+
+type deploymentStateValidator struct {
+	ui                                      biui.UI
+	logTag                                  string
+	logger                                  boshlog.Logger
+	fs                                      boshsys.FileSystem
+	uuidGen                                 boshuuid.Generator
+	deploymentStateService                  biconfig.DeploymentStateService
+	releaseManager                          biinstall.ReleaseManager
+	cloudFactory                            bicloud.Factory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	cpiInstaller                            bicpirel.CpiInstaller
+	releaseFetcher                          biinstall.ReleaseFetcher
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+	tempRootConfigurator                    TempRootConfigurator
+	targetProvider                          biinstall.TargetProvider
+}
+
+func NewDeploymentStateValidator(
+	ui biui.UI,
+	logTag string,
+	logger boshlog.Logger,
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	deploymentStateService biconfig.DeploymentStateService,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	cpiInstaller bicpirel.CpiInstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	targetProvider biinstall.TargetProvider,
+) DeploymentStateValidator {
+	return &deploymentStateValidator{
+		ui:                                      ui,
+		logTag:                                  logTag,
+		logger:                                  logger,
+		fs:                                      fs,
+		uuidGen:                                 uuidGen,
+		deploymentStateService:                  deploymentStateService,
+		releaseManager:                          releaseManager,
+		cloudFactory:                            cloudFactory,
+		deploymentManifestPath:                  deploymentManifestPath,
+		deploymentVars:                          deploymentVars,
+		deploymentOp:                            deploymentOp,
+		cpiInstaller:                            cpiInstaller,
+		releaseFetcher:                          releaseFetcher,
+		releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+		tempRootConfigurator:                    tempRootConfigurator,
+		targetProvider:                          targetProvider,
+	}
+}
+
+type deploymentStateCheck struct {
+	Record string
+	ID     string
+	Status string
+	Ok     bool
+}
+
+func (c *deploymentStateValidator) ValidateDeploymentState(stage biui.Stage) error {
+	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+
+	if !c.deploymentStateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist", c.deploymentStateService.Path())
+	}
+
+	deploymentState, err := c.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	checks, err := c.checkRecords(deploymentState)
+	if err != nil {
+		return err
+	}
+
+	// Installation ID is recorded during first deploy; target is not created without it
+	// since target provider would save newly generated ID
+	if deploymentState.InstallationID != "" {
+		target, err := c.targetProvider.NewTarget()
+		if err != nil {
+			return bosherr.WrapError(err, "Determining installation target")
+		}
+
+		check := deploymentStateCheck{Record: "installation", ID: target.Path(), Status: "ok", Ok: true}
+		if !c.fs.FileExists(target.Path()) {
+			check.Status, check.Ok = "missing", false
+		}
+		checks = append(checks, check)
+
+		vmCID, found, err := biconfig.NewVMRepo(c.deploymentStateService).FindCurrent()
+		if err != nil {
+			return bosherr.WrapError(err, "Finding current VM")
+		}
+
+		if found {
+			check, err := c.checkVM(deploymentState, vmCID, target, stage)
+			if err != nil {
+				return err
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	table := boshtbl.Table{
+		Content: "records",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Record"),
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("Status"),
+		},
+	}
+
+	problems := 0
+
+	for _, check := range checks {
+		if !check.Ok {
+			problems++
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(check.Record),
+			boshtbl.NewValueString(check.ID),
+			boshtbl.NewValueFmt(boshtbl.NewValueString(check.Status), !check.Ok),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if problems > 0 {
+		return bosherr.Errorf("Found %d problem(s) in deployment state", problems)
+	}
+
+	return nil
+}
+
+func (c *deploymentStateValidator) checkRecords(deploymentState biconfig.DeploymentState) ([]deploymentStateCheck, error) {
+	var checks []deploymentStateCheck
+
+	if deploymentState.CurrentStemcellID != "" {
+		_, found, err := biconfig.NewStemcellRepo(c.deploymentStateService, c.uuidGen).FindCurrent()
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Finding current stemcell")
+		}
+
+		checks = append(checks, c.recordCheck("stemcell", deploymentState.CurrentStemcellID, found))
+	}
+
+	if deploymentState.CurrentDiskID != "" {
+		_, found, err := biconfig.NewDiskRepo(c.deploymentStateService, c.uuidGen).FindCurrent()
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Finding current disk")
+		}
+
+		checks = append(checks, c.recordCheck("disk", deploymentState.CurrentDiskID, found))
+	}
+
+	for _, id := range deploymentState.CurrentReleaseIDs {
+		found := false
+		for _, record := range deploymentState.Releases {
+			if record.ID == id {
+				found = true
+			}
+		}
+
+		checks = append(checks, c.recordCheck("release", id, found))
+	}
+
+	return checks, nil
+}
+
+func (c *deploymentStateValidator) recordCheck(record, id string, found bool) deploymentStateCheck {
+	if found {
+		return deploymentStateCheck{Record: record, ID: id, Status: "ok", Ok: true}
+	}
+	return deploymentStateCheck{Record: record, ID: id, Status: "current record missing", Ok: false}
+}
+
+func (c *deploymentStateValidator) checkVM(deploymentState biconfig.DeploymentState, vmCID string, target biinstall.Target, stage biui.Stage) (deploymentStateCheck, error) {
+	check := deploymentStateCheck{Record: "vm", ID: vmCID}
+
+	err := c.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), c.logger)
+	if err != nil {
+		return check, bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := c.releaseManager.DeleteAll()
+		if err != nil {
+			c.logger.Warn(c.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	var installationManifest biinstallmanifest.Manifest
+
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = c.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(c.deploymentManifestPath, c.deploymentVars, c.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		if !installationManifest.UsesLocalCPI() {
+			cpiReleaseName := installationManifest.Template.Release
+			cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
+			if !found {
+				return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
+			}
+
+			err = c.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		return c.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+	})
+	if err != nil {
+		return check, err
+	}
+
+	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
+		cloud, err := c.cloudFactory.NewCloud(localCpiInstallation, deploymentState.DirectorID, deploymentState.CurrentStemcellAPIVersion())
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		return stage.Perform("Checking VM in the IaaS", func() error {
+			exists, err := cloud.HasVM(vmCID)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Checking existence of VM '%s'", vmCID)
+			}
+
+			if exists {
+				check.Status, check.Ok = "ok", true
+			} else {
+				check.Status = "not found in the IaaS"
+			}
+
+			return nil
+		})
+	})
+
+	return check, err
+}
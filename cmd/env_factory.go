@@ -8,6 +8,7 @@ import (
 	"github.com/cppforlife/go-patch/patch"
 
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	biagentclient "github.com/cloudfoundry/bosh-cli/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -33,6 +34,7 @@ import (
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
 	bitemplateerb "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 )
 
@@ -66,7 +68,15 @@ type envFactory struct {
 	deploymentRecord   bidepl.Record
 }
 
-func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manifestVars boshtpl.Variables, manifestOp patch.Op) *envFactory {
+// envWorkspacePath is where create-env keeps downloaded tarballs and installations
+func envWorkspacePath() string {
+	// todo expand path?
+	return filepath.Join(os.Getenv("HOME"), ".bosh")
+}
+
+// NewEnvFactory returns factory whose installer and instance builders
+// compile up to parallel packages at the same time.
+func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manifestVars boshtpl.Variables, manifestOp patch.Op, parallel int) *envFactory {
 	f := envFactory{
 		deps:         deps,
 		manifestPath: manifestPath,
@@ -77,15 +87,14 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	f.releaseManager = boshinst.NewReleaseManager(deps.Logger)
 	releaseJobResolver := bideplrel.NewJobResolver(f.releaseManager)
 
-	// todo expand path?
-	workspaceRootPath := filepath.Join(os.Getenv("HOME"), ".bosh")
+	workspaceRootPath := envWorkspacePath()
 
 	{
 		tarballCacheBasePath := filepath.Join(workspaceRootPath, "downloads")
-		tarballCache := bitarball.NewCache(tarballCacheBasePath, deps.FS, deps.Logger)
+		tarballCache := bitarball.NewCache(tarballCacheBasePath, deps.FS, deps.Time, deps.Logger)
 		httpClient := bihttpclient.NewHTTPClient(bitarball.HTTPClient, deps.Logger)
 		tarballProvider := bitarball.NewProvider(
-			tarballCache, deps.FS, httpClient, 3, 500*time.Millisecond, deps.Logger)
+			tarballCache, deps.FS, httpClient, biui.NewFileReporter(deps.UI), 3, 500*time.Millisecond, deps.Logger)
 
 		releaseProvider := boshrel.NewProvider(
 			deps.CmdRunner, deps.Compressor, deps.DigestCalculator, deps.FS, deps.Logger)
@@ -105,14 +114,14 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 		}
 	}
 
-	f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
+	f.deploymentStateService = biconfig.NewDeploymentStateService(
 		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
 
 	{
 		registryServer := biregistry.NewServerManager(deps.Logger)
 		installerFactory := boshinst.NewInstallerFactory(
 			deps.UI, deps.CmdRunner, deps.Compressor, releaseJobResolver,
-			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms)
+			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms, parallel)
 
 		f.cpiInstaller = bicpirel.CpiInstaller{
 			ReleaseManager:   f.releaseManager,
@@ -144,19 +153,23 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	{
 		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger)
 		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond)
-		f.agentClientFactory = bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger)
-		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
+		f.agentClientFactory = biagentclient.NewAgentClientFactory(
+			bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger), 1*time.Second, deps.Logger)
+		f.cloudFactory = bicloud.NewFactory(
+			deps.FS, deps.CmdRunner, os.Getenv("BOSH_CPI_RECORD"), os.Getenv("BOSH_CPI_REPLAY"), deps.Time, deps.UUIDGen, deps.Logger)
 	}
 
 	{
-		erbRenderer := bitemplateerb.NewERBRenderer(deps.FS, deps.CmdRunner, deps.Logger)
+		erbRenderer := bitemplateerb.NewDefaultERBRenderer(deps.FS, deps.CmdRunner, deps.Logger)
 		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, deps.FS, deps.UUIDGen, deps.Logger)
 
 		builderFactory := biinstancestate.NewBuilderFactory(
 			bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex()),
+			f.compiledPackageCache(os.Getenv("BOSH_COMPILED_PACKAGE_CACHE")),
 			releaseJobResolver,
 			bitemplate.NewJobListRenderer(jobRenderer, deps.Logger),
 			bitemplate.NewRenderedJobListCompressor(deps.FS, deps.Compressor, deps.DigestCalculator, deps.Logger),
+			parallel,
 			deps.Logger,
 		)
 
@@ -183,6 +196,22 @@ func NewEnvFactory(deps BasicDeps, manifestPath string, statePath string, manife
 	return &f
 }
 
+// compiledPackageCache returns nil when cache is not configured. Cache is only
+// an optimization so misconfigured cache is logged instead of failing deploys.
+func (f *envFactory) compiledPackageCache(location string) bistatepkg.CompiledPackageCache {
+	if len(location) == 0 {
+		return nil
+	}
+
+	cache, err := bistatepkg.NewCompiledPackageCacheFromLocation(location, f.deps.FS, f.deps.Time, f.deps.Logger)
+	if err != nil {
+		f.deps.Logger.Warn("envFactory", "Not using compiled package cache: %s", err)
+		return nil
+	}
+
+	return cache
+}
+
 func (f *envFactory) Preparer() DeploymentPreparer {
 	return NewDeploymentPreparer(
 		f.deps.UI,
@@ -254,3 +283,61 @@ func (f *envFactory) Deleter() DeploymentDeleter {
 		f.targetProvider,
 	)
 }
+
+func (f *envFactory) Stopper() DeploymentStopper {
+	return f.lifecycle()
+}
+
+func (f *envFactory) Starter() DeploymentStarter {
+	return f.lifecycle()
+}
+
+func (f *envFactory) StateValidator() DeploymentStateValidator {
+	return NewDeploymentStateValidator(
+		f.deps.UI,
+		"DeploymentStateValidator",
+		f.deps.Logger,
+		f.deps.FS,
+		f.deps.UUIDGen,
+		f.deploymentStateService,
+		f.releaseManager,
+		f.cloudFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.cpiInstaller,
+		f.releaseFetcher,
+		f.installationManifestParser,
+		NewTempRootConfigurator(f.deps.FS),
+		f.targetProvider,
+	)
+}
+
+func (f *envFactory) lifecycle() *deploymentLifecycle {
+	return NewDeploymentLifecycle(
+		f.deps.UI,
+		"DeploymentLifecycle",
+		f.deps.Logger,
+		f.deploymentStateService,
+		f.releaseManager,
+		f.cloudFactory,
+		f.agentClientFactory,
+		f.blobstoreFactory,
+		bidepl.NewManagerFactory(
+			f.vmManagerFactory,
+			f.instanceManagerFactory,
+			f.diskManagerFactory,
+			f.stemcellManagerFactory,
+			f.deploymentFactory,
+		),
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.cpiInstaller,
+		f.releaseFetcher,
+		f.installationManifestParser,
+		NewTempRootConfigurator(f.deps.FS),
+		f.targetProvider,
+		f.Preparer(),
+	)
+}
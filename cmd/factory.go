@@ -101,6 +101,10 @@ func (f Factory) New(args []string) (Cmd, error) {
 				field.Set(reflect.ValueOf(f.deps.FS))
 			}
 		}
+
+		if store, ok := val.(*VarsStore); ok {
+			store.Logger = f.deps.Logger
+		}
 	}
 
 	helpText := bytes.NewBufferString("")
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -18,11 +19,12 @@ import (
 var _ = Describe("Factory", func() {
 	var (
 		fs      *fakesys.FakeFileSystem
+		logger  boshlog.Logger
 		factory Factory
 	)
 
 	BeforeEach(func() {
-		logger := boshlog.NewLogger(boshlog.LevelNone)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 		fs = fakesys.NewFakeFileSystem()
 
 		ui := boshui.NewConfUI(logger)
@@ -56,6 +58,8 @@ var _ = Describe("Factory", func() {
 			"delete-deployment":     []string{},
 			"delete-disk":           []string{"cid"},
 			"delete-env":            []string{filepath.Join("/", "file")},
+			"stop-env":              []string{filepath.Join("/", "file")},
+			"start-env":             []string{filepath.Join("/", "file")},
 			"delete-release":        []string{"release-version"},
 			"delete-snapshot":       []string{"cid"},
 			"delete-snapshots":      []string{},
@@ -237,6 +241,78 @@ var _ = Describe("Factory", func() {
 		})
 	})
 
+	Describe("--vars-store flag", func() {
+		It("gives vars store file system and logger", func() {
+			err := fs.WriteFileString(filepath.Join("/", "file"), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			cmd, err := factory.New([]string{"interpolate", filepath.Join("/", "file"), "--vars-store", "credhub://host/namespace"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*InterpolateOpts)
+			Expect(opts.VarsStore.FS).To(Equal(fs))
+			Expect(opts.VarsStore.Logger).To(Equal(logger))
+		})
+	})
+
+	Describe("vars-store command", func() {
+		It("dispatches to subcommand", func() {
+			cmd, err := factory.New([]string{"vars-store", "rekey", "/file", "--new-passphrase", "new"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*VarsStoreRekeyOpts)
+			Expect(opts.Args.Path.ExpandedPath).To(Equal("/file"))
+			Expect(opts.NewPassphrase).To(Equal("new"))
+		})
+
+		It("requires subcommand", func() {
+			_, err := factory.New([]string{"vars-store"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("decrypt"))
+		})
+	})
+
+	Describe("state command", func() {
+		It("dispatches to subcommand", func() {
+			cmd, err := factory.New([]string{"state", "forget-disk", "disk-cid", "--state", "/state.json"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*StateForgetDiskOpts)
+			Expect(opts.Args.CID).To(Equal("disk-cid"))
+			Expect(opts.StatePath).To(Equal("/state.json"))
+		})
+
+		It("requires state path", func() {
+			_, err := factory.New([]string{"state", "show"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("--state"))
+		})
+	})
+
+	Describe("compiled-cache command", func() {
+		It("dispatches to subcommand", func() {
+			cmd, err := factory.New([]string{"compiled-cache", "prune", "--cache", "/cache", "--older-than", "24h"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*CompiledCachePruneOpts)
+			Expect(opts.Cache).To(Equal("/cache"))
+			Expect(opts.OlderThan).To(Equal(24 * time.Hour))
+		})
+	})
+
+	Describe("cache command", func() {
+		It("dispatches to subcommand", func() {
+			cmd, err := factory.New([]string{
+				"cache", "prune", "--keep-size", "10GB", "--installations", "--state", "/state-1.json", "--state", "/state-2.json"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts := cmd.Opts.(*CachePruneOpts)
+			Expect(opts.KeepSize).To(Equal(ByteSizeArg(10 * 1000 * 1000 * 1000)))
+			Expect(opts.Installations).To(BeTrue())
+			Expect(opts.StatePaths).To(Equal([]string{"/state-1.json", "/state-2.json"}))
+		})
+	})
+
 	Describe("alias-env command", func() {
 		It("is passed global environment URL", func() {
 			cmd, err := factory.New([]string{"alias-env", "-e", "env", "alias"})
@@ -377,6 +453,12 @@ var _ = Describe("Factory", func() {
 			boshOpts.SCP = SCPOpts{}
 			boshOpts.Deploy = DeployOpts{}
 			boshOpts.UpdateRuntimeConfig = UpdateRuntimeConfigOpts{}
+			boshOpts.Configs = ConfigsOpts{}
+			boshOpts.UpdateConfig = UpdateConfigOpts{}
+			boshOpts.DeleteConfig = DeleteConfigOpts{}
+			boshOpts.Curl = CurlOpts{}
+			boshOpts.CreateEnv = CreateEnvOpts{}
+			boshOpts.DeleteEnv = DeleteEnvOpts{}
 			return boshOpts
 		}
 
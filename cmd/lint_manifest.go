@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	"github.com/cloudfoundry/bosh-cli/manifestlint"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type LintManifestCmd struct {
+	ui            boshui.UI
+	releaseReader boshrel.Reader
+	linter        manifestlint.Linter
+}
+
+func NewLintManifestCmd(
+	ui boshui.UI,
+	releaseReader boshrel.Reader,
+	linter manifestlint.Linter,
+) LintManifestCmd {
+	return LintManifestCmd{ui: ui, releaseReader: releaseReader, linter: linter}
+}
+
+func (c LintManifestCmd) Run(opts LintManifestOpts) error {
+	tpl := boshtpl.NewTemplate(opts.Args.Manifest.Bytes)
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest")
+	}
+
+	var releases []boshrel.Release
+
+	defer func() {
+		// Failing to remove extracted releases should not affect linting results
+		for _, release := range releases {
+			_ = release.CleanUp()
+		}
+	}()
+
+	for _, path := range opts.Releases {
+		release, err := c.releaseReader.Read(path.ExpandedPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading release '%s'", path.ExpandedPath)
+		}
+
+		releases = append(releases, release)
+	}
+
+	issues, err := c.linter.Lint(bytes, releases, opts.CloudConfig.Bytes)
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "issues",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Path"),
+			boshtbl.NewHeader("Issue"),
+		},
+	}
+
+	for _, issue := range issues {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(issue.Path),
+			boshtbl.NewValueString(issue.Message),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if len(issues) > 0 {
+		return bosherr.Errorf("Expected manifest to have no issues but found %d", len(issues))
+	}
+
+	return nil
+}
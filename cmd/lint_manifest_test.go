@@ -0,0 +1,124 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	"github.com/cloudfoundry/bosh-cli/manifestlint"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("LintManifestCmd", func() {
+	var (
+		ui            *fakeui.FakeUI
+		releaseReader *fakerel.FakeReader
+		release       *fakerel.FakeRelease
+		command       LintManifestCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		releaseReader = &fakerel.FakeReader{}
+
+		release = &fakerel.FakeRelease{}
+		release.NameReturns("app")
+		release.FindJobByNameReturns(boshjob.Job{
+			Properties: map[string]boshjob.PropertyDefinition{"port": {}},
+		}, true)
+
+		releaseReader.ReadStub = func(path string) (boshrel.Release, error) {
+			Expect(path).To(Equal("/app.tgz"))
+			return release, nil
+		}
+
+		command = NewLintManifestCmd(ui, releaseReader, manifestlint.NewLinter())
+	})
+
+	Describe("Run", func() {
+		var (
+			opts LintManifestOpts
+		)
+
+		BeforeEach(func() {
+			opts = LintManifestOpts{
+				Args: LintManifestArgs{
+					Manifest: FileBytesArg{Bytes: []byte(`
+releases: [{name: app}]
+instance_groups:
+- name: web
+  jobs: [{name: web, release: app, properties: {port: ((port)), prot: 80}}]
+`)},
+				},
+				Releases: []FileArg{{ExpandedPath: "/app.tgz"}},
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{{Name: "port", Value: 80}}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("shows all issues and returns error", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected manifest to have no issues but found 1"))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "issues",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("Path"),
+					boshtbl.NewHeader("Issue"),
+				},
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString("/instance_groups/name=web/jobs/name=web/properties/prot"),
+						boshtbl.NewValueString("property 'prot' is not defined in job 'web' spec"),
+					},
+				},
+			}))
+
+			Expect(release.CleanUpCallCount()).To(Equal(1))
+		})
+
+		It("succeeds if there are no issues", func() {
+			opts.Args.Manifest.Bytes = []byte(`
+releases: [{name: app}]
+instance_groups:
+- name: web
+  jobs: [{name: web, release: app, properties: {port: 80}}]
+`)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Table.Rows).To(BeEmpty())
+		})
+
+		It("returns error if release cannot be read", func() {
+			releaseReader.ReadStub = nil
+			releaseReader.ReadReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Reading release '/app.tgz': fake-err"))
+		})
+
+		It("returns error if manifest cannot be evaluated", func() {
+			opts.OpsFlags.OpsFiles = []OpsFileArg{
+				{Ops: patch.Ops{patch.ErrOp{Err: errors.New("fake-err")}}},
+			}
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Evaluating manifest: fake-err"))
+			Expect(releaseReader.ReadCallCount()).To(Equal(0))
+		})
+	})
+})
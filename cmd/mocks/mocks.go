@@ -1,5 +1,5 @@
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/cloudfoundry/bosh-cli/cmd (interfaces: DeploymentDeleter)
+// Source: github.com/cloudfoundry/bosh-cli/cmd (interfaces: DeploymentDeleter,DeploymentStarter,DeploymentStateValidator,DeploymentStopper)
 
 package mocks
 
@@ -38,3 +38,96 @@ func (_m *MockDeploymentDeleter) DeleteDeployment(_param0 ui.Stage) error {
 func (_mr *_MockDeploymentDeleterRecorder) DeleteDeployment(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteDeployment", arg0)
 }
+
+// Mock of DeploymentStarter interface
+type MockDeploymentStarter struct {
+	ctrl     *gomock.Controller
+	recorder *_MockDeploymentStarterRecorder
+}
+
+// Recorder for MockDeploymentStarter (not exported)
+type _MockDeploymentStarterRecorder struct {
+	mock *MockDeploymentStarter
+}
+
+func NewMockDeploymentStarter(ctrl *gomock.Controller) *MockDeploymentStarter {
+	mock := &MockDeploymentStarter{ctrl: ctrl}
+	mock.recorder = &_MockDeploymentStarterRecorder{mock}
+	return mock
+}
+
+func (_m *MockDeploymentStarter) EXPECT() *_MockDeploymentStarterRecorder {
+	return _m.recorder
+}
+
+func (_m *MockDeploymentStarter) StartDeployment(_param0 ui.Stage) error {
+	ret := _m.ctrl.Call(_m, "StartDeployment", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockDeploymentStarterRecorder) StartDeployment(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StartDeployment", arg0)
+}
+
+// Mock of DeploymentStateValidator interface
+type MockDeploymentStateValidator struct {
+	ctrl     *gomock.Controller
+	recorder *_MockDeploymentStateValidatorRecorder
+}
+
+// Recorder for MockDeploymentStateValidator (not exported)
+type _MockDeploymentStateValidatorRecorder struct {
+	mock *MockDeploymentStateValidator
+}
+
+func NewMockDeploymentStateValidator(ctrl *gomock.Controller) *MockDeploymentStateValidator {
+	mock := &MockDeploymentStateValidator{ctrl: ctrl}
+	mock.recorder = &_MockDeploymentStateValidatorRecorder{mock}
+	return mock
+}
+
+func (_m *MockDeploymentStateValidator) EXPECT() *_MockDeploymentStateValidatorRecorder {
+	return _m.recorder
+}
+
+func (_m *MockDeploymentStateValidator) ValidateDeploymentState(_param0 ui.Stage) error {
+	ret := _m.ctrl.Call(_m, "ValidateDeploymentState", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockDeploymentStateValidatorRecorder) ValidateDeploymentState(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ValidateDeploymentState", arg0)
+}
+
+// Mock of DeploymentStopper interface
+type MockDeploymentStopper struct {
+	ctrl     *gomock.Controller
+	recorder *_MockDeploymentStopperRecorder
+}
+
+// Recorder for MockDeploymentStopper (not exported)
+type _MockDeploymentStopperRecorder struct {
+	mock *MockDeploymentStopper
+}
+
+func NewMockDeploymentStopper(ctrl *gomock.Controller) *MockDeploymentStopper {
+	mock := &MockDeploymentStopper{ctrl: ctrl}
+	mock.recorder = &_MockDeploymentStopperRecorder{mock}
+	return mock
+}
+
+func (_m *MockDeploymentStopper) EXPECT() *_MockDeploymentStopperRecorder {
+	return _m.recorder
+}
+
+func (_m *MockDeploymentStopper) StopDeployment(_param0 bool, _param1 ui.Stage) error {
+	ret := _m.ctrl.Call(_m, "StopDeployment", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockDeploymentStopperRecorder) StopDeployment(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StopDeployment", arg0, arg1)
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/go-patch/patch"
 
@@ -31,6 +33,7 @@ type BoshOpts struct {
 	// Output formatting
 	ColumnOpt         []ColumnOpt `long:"column"                    description:"Filter to show only given column(s)"`
 	JSONOpt           bool        `long:"json"                      description:"Output as JSON"`
+	EventsOpt         bool        `long:"events"                    description:"Output progress as newline delimited JSON events"`
 	TTYOpt            bool        `long:"tty"                       description:"Force TTY-like output"`
 	NoColorOpt        bool        `long:"no-color"                  description:"Toggle colorized output"`
 	NonInteractiveOpt bool        `long:"non-interactive" short:"n" description:"Don't ask for user input" env:"BOSH_NON_INTERACTIVE"`
@@ -44,8 +47,17 @@ type BoshOpts struct {
 	Environments EnvironmentsOpts `command:"environments" alias:"envs" description:"List environments"`
 	CreateEnv    CreateEnvOpts    `command:"create-env"                description:"Create or update BOSH environment"`
 	DeleteEnv    DeleteEnvOpts    `command:"delete-env"                description:"Delete BOSH environment"`
+	StopEnv      StopEnvOpts      `command:"stop-env"                  description:"Stop BOSH environment"`
+	StartEnv     StartEnvOpts     `command:"start-env"                 description:"Start BOSH environment"`
+	State        StateOpts        `command:"state"                     description:"Inspect and repair BOSH environment deployment state"`
 	AliasEnv     AliasEnvOpts     `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
 
+	// Compiled package cache used by create-env
+	CompiledCache CompiledCacheOpts `command:"compiled-cache" description:"Manage compiled package cache used by create-env"`
+
+	// Downloads and installations kept by create-env
+	Cache CacheOpts `command:"cache" description:"Manage releases and stemcells downloaded by create-env"`
+
 	// Authentication
 	LogIn  LogInOpts  `command:"log-in"  alias:"l" alias:"login"  description:"Log in"`
 	LogOut LogOutOpts `command:"log-out"           alias:"logout" description:"Log out"`
@@ -58,6 +70,13 @@ type BoshOpts struct {
 	// Misc
 	Locks   LocksOpts   `command:"locks"    description:"List current locks"`
 	CleanUp CleanUpOpts `command:"clean-up" description:"Clean up releases, stemcells, disks, etc."`
+	Curl    CurlOpts    `command:"curl"     description:"Make an authenticated request to the Director API"`
+
+	// Generic configs
+	Configs      ConfigsOpts      `command:"configs"       description:"List configs"`
+	Config       ConfigOpts       `command:"config"        description:"Show config by ID or by type and name"`
+	UpdateConfig UpdateConfigOpts `command:"update-config" description:"Update config of given type and name"`
+	DeleteConfig DeleteConfigOpts `command:"delete-config" description:"Delete config of given type and name"`
 
 	// Cloud config
 	CloudConfig       CloudConfigOpts       `command:"cloud-config"        alias:"cc"  description:"Show current cloud config"`
@@ -81,6 +100,12 @@ type BoshOpts struct {
 
 	Interpolate InterpolateOpts `command:"interpolate" alias:"int" description:"Interpolates variables into a manifest"`
 
+	VarsStore VarsStoreOpts `command:"vars-store" description:"Manage encrypted variables file store"`
+
+	LintManifest LintManifestOpts `command:"lint-manifest" description:"Check deployment manifest against releases and cloud config"`
+
+	RenderTemplates RenderTemplatesOpts `command:"render-templates" description:"Render job templates locally and show differences from previous render"`
+
 	// Events
 	Events EventsOpts `command:"events" description:"List events"`
 	Event  EventOpts  `command:"event" description:"Show event details"`
@@ -166,7 +191,12 @@ type CreateEnvOpts struct {
 	Args CreateEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath   string `long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`
+	ForceUnlock bool   `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	Recreate    bool   `long:"recreate" description:"Recreate VM in deployment"`
+	SkipDrain   bool   `long:"skip-drain" description:"Skip running drain scripts"`
+	DryRun      bool   `long:"dry-run" description:"Print what would change without changing anything"`
+	ParallelOpt int    `long:"parallel" description:"Compile packages in parallel with given number of workers (default: 1)" default:"1"`
 	cmd
 }
 
@@ -178,7 +208,9 @@ type DeleteEnvOpts struct {
 	Args DeleteEnvArgs `positional-args:"true" required:"true"`
 	VarFlags
 	OpsFlags
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	StatePath   string `long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`
+	ForceUnlock bool   `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	ParallelOpt int    `long:"parallel" description:"Compile packages in parallel with given number of workers (default: 1)" default:"1"`
 	cmd
 }
 
@@ -186,6 +218,142 @@ type DeleteEnvArgs struct {
 	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type StopEnvOpts struct {
+	Args StopEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath   string `long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`
+	ForceUnlock bool   `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	DeleteVM    bool   `long:"delete-vm" description:"Delete VM after stopping jobs while keeping persistent disk"`
+	cmd
+}
+
+type StopEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type StartEnvOpts struct {
+	Args StartEnvArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath   string `long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`
+	ForceUnlock bool   `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	cmd
+}
+
+type StartEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type StateOpts struct {
+	Show           StateShowOpts           `command:"show"            description:"Show deployment state records"`
+	ForgetVM       StateForgetVMOpts       `command:"forget-vm"       description:"Remove VM record from deployment state"`
+	ForgetDisk     StateForgetDiskOpts     `command:"forget-disk"     description:"Remove persistent disk record from deployment state"`
+	ForgetStemcell StateForgetStemcellOpts `command:"forget-stemcell" description:"Remove stemcell record from deployment state"`
+	Validate       StateValidateOpts       `command:"validate"        description:"Check deployment state records against the CPI and local installation"`
+}
+
+type StateFlags struct {
+	StatePath string `long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)" required:"true"`
+}
+
+type StateShowOpts struct {
+	StateFlags
+	cmd
+}
+
+type StateForgetVMOpts struct {
+	StateFlags
+	ForceUnlock bool `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	cmd
+}
+
+type StateForgetDiskOpts struct {
+	Args StateForgetArgs `positional-args:"true" required:"true"`
+	StateFlags
+	ForceUnlock bool `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	cmd
+}
+
+type StateForgetStemcellOpts struct {
+	Args StateForgetArgs `positional-args:"true" required:"true"`
+	StateFlags
+	ForceUnlock bool `long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`
+	cmd
+}
+
+type StateForgetArgs struct {
+	CID string `positional-arg-name:"CID" description:"Cloud ID of the record"`
+}
+
+type StateValidateOpts struct {
+	Args StateValidateArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`
+	cmd
+}
+
+type StateValidateArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type CompiledCacheOpts struct {
+	List   CompiledCacheListOpts   `command:"list"   alias:"ls" description:"List cached compiled packages"`
+	Prune  CompiledCachePruneOpts  `command:"prune"             description:"Remove cached compiled packages that were not used recently"`
+	Export CompiledCacheExportOpts `command:"export"            description:"Export cached compiled packages to a tarball that can be extracted into a cache directory"`
+}
+
+type CompiledCacheFlags struct {
+	Cache string `long:"cache" value-name:"PATH" description:"Compiled package cache directory or object store URL (s3://bucket/prefix, gs://bucket/prefix)" env:"BOSH_COMPILED_PACKAGE_CACHE"`
+}
+
+type CompiledCacheListOpts struct {
+	CompiledCacheFlags
+	cmd
+}
+
+type CompiledCachePruneOpts struct {
+	CompiledCacheFlags
+	OlderThan time.Duration `long:"older-than" value-name:"DURATION" description:"Remove packages not used for longer than duration (e.g. 720h)" required:"true"`
+	cmd
+}
+
+type CompiledCacheExportOpts struct {
+	Args CompiledCacheExportArgs `positional-args:"true" required:"true"`
+	CompiledCacheFlags
+	Stemcell string `long:"stemcell" value-name:"OS/VERSION" description:"Only export packages compiled for stemcell (e.g. ubuntu-trusty/3445.11)"`
+	cmd
+}
+
+type CompiledCacheExportArgs struct {
+	Path FileArg `positional-arg-name:"PATH" description:"Destination tarball path"`
+}
+
+type CacheOpts struct {
+	List  CacheListOpts  `command:"list"  alias:"ls" description:"List downloaded release and stemcell tarballs"`
+	Prune CachePruneOpts `command:"prune"            description:"Remove downloaded tarballs that were not used recently and unused installations"`
+	Clear CacheClearOpts `command:"clear"            description:"Remove all downloaded tarballs"`
+}
+
+type CacheListOpts struct {
+	cmd
+}
+
+type CachePruneOpts struct {
+	OlderThan time.Duration `long:"older-than" value-name:"DURATION" description:"Remove tarballs not used for longer than duration (e.g. 720h)"`
+	KeepSize  ByteSizeArg   `long:"keep-size"  value-name:"SIZE"     description:"Remove least recently used tarballs until cache is at most given size (e.g. 10GB)"`
+
+	Installations bool     `long:"installations"                  description:"Remove installations not referenced by given state files. Every state file still in use must be given, otherwise its installation is removed"`
+	StatePaths    []string `long:"state"         value-name:"PATH" description:"State file path or object store URL whose installation is kept (multiple allowed)"`
+
+	cmd
+}
+
+type CacheClearOpts struct {
+	cmd
+}
+
 // Environment
 type EnvironmentOpts struct {
 	cmd
@@ -259,6 +427,21 @@ type CleanUpOpts struct {
 	cmd
 }
 
+type CurlOpts struct {
+	Args CurlArgs `positional-args:"true" required:"true"`
+
+	Method     string       `long:"method" short:"X" value-name:"METHOD" description:"HTTP method (GET, POST, PUT or DELETE)" default:"GET"`
+	Headers    []string     `long:"header" short:"H" value-name:"HEADER" description:"HTTP header (e.g.: 'Content-Type: application/json')"`
+	Body       FileBytesArg `long:"body"             value-name:"PATH"   description:"Path to a file with request body"`
+	FollowTask bool         `long:"follow-task"                          description:"Wait for task started by the request to finish"`
+
+	cmd
+}
+
+type CurlArgs struct {
+	Path string `positional-arg-name:"PATH" description:"Director API path (e.g.: /info)"`
+}
+
 type AttachDiskOpts struct {
 	Args AttachDiskArgs `positional-args:"true" required:"true"`
 
@@ -287,6 +470,114 @@ type InterpolateArgs struct {
 	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a template that will be interpolated"`
 }
 
+type LintManifestOpts struct {
+	Args LintManifestArgs `positional-args:"true" required:"true"`
+
+	Releases    []FileArg    `long:"release"      value-name:"PATH" description:"Path to a release tarball used to check jobs and properties"`
+	CloudConfig FileBytesArg `long:"cloud-config" value-name:"PATH" description:"Path to a cloud config used to check AZs, networks, VM types, etc."`
+
+	VarFlags
+	OpsFlags
+
+	cmd
+}
+
+type LintManifestArgs struct {
+	Manifest FileBytesArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type RenderTemplatesOpts struct {
+	Args RenderTemplatesArgs `positional-args:"true" required:"true"`
+
+	Properties    FileBytesArg `long:"properties"     value-name:"PATH" description:"Path to a properties file or deployment manifest"`
+	InstanceGroup string       `long:"instance-group" value-name:"NAME" description:"Instance group to take properties from when using deployment manifest"`
+
+	VarFlags
+	OpsFlags
+
+	cmd
+}
+
+type RenderTemplatesArgs struct {
+	Release string `positional-arg-name:"RELEASE"    description:"Path to a release directory or tarball"`
+	Job     string `positional-arg-name:"JOB"        description:"Job name"`
+	Dir     string `positional-arg-name:"OUTPUT-DIR" description:"Directory to write rendered templates to"`
+}
+
+type VarsStoreOpts struct {
+	Rekey   VarsStoreRekeyOpts   `command:"rekey"   description:"Encrypt variables file store with a new passphrase"`
+	Decrypt VarsStoreDecryptOpts `command:"decrypt" description:"Show or save decrypted variables file store"`
+}
+
+type VarsStoreRekeyOpts struct {
+	Args VarsStoreArgs `positional-args:"true" required:"true"`
+
+	VarsStoreKeyFlags
+
+	NewPassphrase string `long:"new-passphrase" value-name:"PASSPHRASE" description:"New passphrase used to encrypt vars store" env:"BOSH_VARS_STORE_NEW_PASSPHRASE"`
+	NewKeyFile    string `long:"new-key-file"   value-name:"PATH"       description:"Path to a file with new passphrase used to encrypt vars store"`
+
+	cmd
+}
+
+type VarsStoreDecryptOpts struct {
+	Args VarsStoreArgs `positional-args:"true" required:"true"`
+
+	VarsStoreKeyFlags
+
+	InPlace bool `long:"in-place" description:"Overwrite variables file store with decrypted contents"`
+
+	cmd
+}
+
+type VarsStoreArgs struct {
+	Path FileArg `positional-arg-name:"PATH" description:"Path to a variables file store"`
+}
+
+// Generic configs
+type ConfigsOpts struct {
+	Type   string `long:"type"             description:"Show configs of given type"`
+	Name   string `long:"name"             description:"Show configs with given name"`
+	Recent int    `long:"recent" short:"r" description:"Number of configs to show, including previous versions" default:"1"`
+
+	cmd
+}
+
+type ConfigOpts struct {
+	Args ConfigArgs `positional-args:"true"`
+
+	Type string `long:"type" description:"Config type"`
+	Name string `long:"name" description:"Config name"`
+
+	cmd
+}
+
+type ConfigArgs struct {
+	ID string `positional-arg-name:"ID" description:"Config ID"`
+}
+
+type UpdateConfigOpts struct {
+	Args UpdateConfigArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+
+	Type string `long:"type" description:"Config type, e.g. 'cloud', 'runtime', 'cpi'" required:"true"`
+	Name string `long:"name" description:"Config name (default: 'default')" default:"default"`
+
+	cmd
+}
+
+type UpdateConfigArgs struct {
+	Config FileBytesArg `positional-arg-name:"PATH" description:"Path to a config file"`
+}
+
+type DeleteConfigOpts struct {
+	Type string `long:"type" description:"Config type, e.g. 'cloud', 'runtime', 'cpi'" required:"true"`
+	Name string `long:"name" description:"Config name (default: 'default')" default:"default"`
+
+	cmd
+}
+
 // Cloud config
 type CloudConfigOpts struct {
 	cmd
@@ -171,6 +171,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("EventsOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EventsOpt", opts)).To(Equal(
+					`long:"events" description:"Output progress as newline delimited JSON events"`,
+				))
+			})
+		})
+
 		Describe("TTYOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("TTYOpt", opts)).To(Equal(
@@ -211,6 +219,46 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("StopEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("StopEnv", opts)).To(Equal(
+					`command:"stop-env" description:"Stop BOSH environment"`,
+				))
+			})
+		})
+
+		Describe("StartEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("StartEnv", opts)).To(Equal(
+					`command:"start-env" description:"Start BOSH environment"`,
+				))
+			})
+		})
+
+		Describe("State", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("State", opts)).To(Equal(
+					`command:"state" description:"Inspect and repair BOSH environment deployment state"`,
+				))
+			})
+		})
+
+		Describe("CompiledCache", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CompiledCache", opts)).To(Equal(
+					`command:"compiled-cache" description:"Manage compiled package cache used by create-env"`,
+				))
+			})
+		})
+
+		Describe("Cache", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Cache", opts)).To(Equal(
+					`command:"cache" description:"Manage releases and stemcells downloaded by create-env"`,
+				))
+			})
+		})
+
 		Describe("Environment", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Environment", opts)).To(Equal(
@@ -283,6 +331,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("Curl", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Curl", opts)).To(Equal(
+					`command:"curl" description:"Make an authenticated request to the Director API"`,
+				))
+			})
+		})
+
 		Describe("Interpolate", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Interpolate", opts)).To(Equal(
@@ -291,6 +347,62 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("LintManifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("LintManifest", opts)).To(Equal(
+					`command:"lint-manifest" description:"Check deployment manifest against releases and cloud config"`,
+				))
+			})
+		})
+
+		Describe("RenderTemplates", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RenderTemplates", opts)).To(Equal(
+					`command:"render-templates" description:"Render job templates locally and show differences from previous render"`,
+				))
+			})
+		})
+
+		Describe("VarsStore", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("VarsStore", opts)).To(Equal(
+					`command:"vars-store" description:"Manage encrypted variables file store"`,
+				))
+			})
+		})
+
+		Describe("Configs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Configs", opts)).To(Equal(
+					`command:"configs" description:"List configs"`,
+				))
+			})
+		})
+
+		Describe("Config", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Config", opts)).To(Equal(
+					`command:"config" description:"Show config by ID or by type and name"`,
+				))
+			})
+		})
+
+		Describe("UpdateConfig", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("UpdateConfig", opts)).To(Equal(
+					`command:"update-config" description:"Update config of given type and name"`,
+				))
+			})
+		})
+
+		Describe("DeleteConfig", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("DeleteConfig", opts)).To(Equal(
+					`command:"delete-config" description:"Delete config of given type and name"`,
+				))
+			})
+		})
+
 		Describe("CloudConfig", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("CloudConfig", opts)).To(Equal(
@@ -747,7 +859,37 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`,
+			))
+		})
+
+		It("has --force-unlock", func() {
+			Expect(getStructTagForName("ForceUnlock", opts)).To(Equal(
+				`long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`,
+			))
+		})
+
+		It("has --recreate", func() {
+			Expect(getStructTagForName("Recreate", opts)).To(Equal(
+				`long:"recreate" description:"Recreate VM in deployment"`,
+			))
+		})
+
+		It("has --skip-drain", func() {
+			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
+				`long:"skip-drain" description:"Skip running drain scripts"`,
+			))
+		})
+
+		It("has --dry-run", func() {
+			Expect(getStructTagForName("DryRun", opts)).To(Equal(
+				`long:"dry-run" description:"Print what would change without changing anything"`,
+			))
+		})
+
+		It("has --parallel", func() {
+			Expect(getStructTagForName("ParallelOpt", opts)).To(Equal(
+				`long:"parallel" description:"Compile packages in parallel with given number of workers (default: 1)" default:"1"`,
 			))
 		})
 	})
@@ -783,7 +925,19 @@ var _ = Describe("Opts", func() {
 
 		It("has --state", func() {
 			Expect(getStructTagForName("StatePath", opts)).To(Equal(
-				`long:"state" value-name:"PATH" description:"State file path"`,
+				`long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`,
+			))
+		})
+
+		It("has --force-unlock", func() {
+			Expect(getStructTagForName("ForceUnlock", opts)).To(Equal(
+				`long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`,
+			))
+		})
+
+		It("has --parallel", func() {
+			Expect(getStructTagForName("ParallelOpt", opts)).To(Equal(
+				`long:"parallel" description:"Compile packages in parallel with given number of workers (default: 1)" default:"1"`,
 			))
 		})
 	})
@@ -804,6 +958,294 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("StopEnvOpts", func() {
+		var opts *StopEnvOpts
+
+		BeforeEach(func() {
+			opts = &StopEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`,
+			))
+		})
+
+		It("has --force-unlock", func() {
+			Expect(getStructTagForName("ForceUnlock", opts)).To(Equal(
+				`long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`,
+			))
+		})
+
+		It("has --delete-vm", func() {
+			Expect(getStructTagForName("DeleteVM", opts)).To(Equal(
+				`long:"delete-vm" description:"Delete VM after stopping jobs while keeping persistent disk"`,
+			))
+		})
+	})
+
+	Describe("StopEnvArgs", func() {
+		var args *StopEnvArgs
+
+		BeforeEach(func() {
+			args = &StopEnvArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+				))
+			})
+		})
+	})
+
+	Describe("StartEnvOpts", func() {
+		var opts *StartEnvOpts
+
+		BeforeEach(func() {
+			opts = &StartEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`,
+			))
+		})
+
+		It("has --force-unlock", func() {
+			Expect(getStructTagForName("ForceUnlock", opts)).To(Equal(
+				`long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`,
+			))
+		})
+	})
+
+	Describe("StartEnvArgs", func() {
+		var args *StartEnvArgs
+
+		BeforeEach(func() {
+			args = &StartEnvArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+				))
+			})
+		})
+	})
+
+	Describe("StateOpts", func() {
+		var opts StateOpts
+
+		It("has Show", func() {
+			Expect(getStructTagForName("Show", &opts)).To(Equal(
+				`command:"show" description:"Show deployment state records"`,
+			))
+		})
+
+		It("has ForgetVM", func() {
+			Expect(getStructTagForName("ForgetVM", &opts)).To(Equal(
+				`command:"forget-vm" description:"Remove VM record from deployment state"`,
+			))
+		})
+
+		It("has ForgetDisk", func() {
+			Expect(getStructTagForName("ForgetDisk", &opts)).To(Equal(
+				`command:"forget-disk" description:"Remove persistent disk record from deployment state"`,
+			))
+		})
+
+		It("has ForgetStemcell", func() {
+			Expect(getStructTagForName("ForgetStemcell", &opts)).To(Equal(
+				`command:"forget-stemcell" description:"Remove stemcell record from deployment state"`,
+			))
+		})
+
+		It("has Validate", func() {
+			Expect(getStructTagForName("Validate", &opts)).To(Equal(
+				`command:"validate" description:"Check deployment state records against the CPI and local installation"`,
+			))
+		})
+	})
+
+	Describe("StateFlags", func() {
+		var opts StateFlags
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", &opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)" required:"true"`,
+			))
+		})
+	})
+
+	Describe("StateForgetDiskOpts", func() {
+		var opts StateForgetDiskOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --force-unlock", func() {
+			Expect(getStructTagForName("ForceUnlock", &opts)).To(Equal(
+				`long:"force-unlock" description:"Remove existing deployment state lock before acquiring it"`,
+			))
+		})
+	})
+
+	Describe("StateForgetArgs", func() {
+		var args StateForgetArgs
+
+		It("has CID", func() {
+			Expect(getStructTagForName("CID", &args)).To(Equal(
+				`positional-arg-name:"CID" description:"Cloud ID of the record"`,
+			))
+		})
+	})
+
+	Describe("StateValidateOpts", func() {
+		var opts StateValidateOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", &opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path or object store URL (s3://bucket/key, gs://bucket/key)"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheOpts", func() {
+		var opts CompiledCacheOpts
+
+		It("has List", func() {
+			Expect(getStructTagForName("List", &opts)).To(Equal(
+				`command:"list" alias:"ls" description:"List cached compiled packages"`,
+			))
+		})
+
+		It("has Prune", func() {
+			Expect(getStructTagForName("Prune", &opts)).To(Equal(
+				`command:"prune" description:"Remove cached compiled packages that were not used recently"`,
+			))
+		})
+
+		It("has Export", func() {
+			Expect(getStructTagForName("Export", &opts)).To(Equal(
+				`command:"export" description:"Export cached compiled packages to a tarball that can be extracted into a cache directory"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheFlags", func() {
+		var opts CompiledCacheFlags
+
+		It("has --cache", func() {
+			Expect(getStructTagForName("Cache", &opts)).To(Equal(
+				`long:"cache" value-name:"PATH" description:"Compiled package cache directory or object store URL (s3://bucket/prefix, gs://bucket/prefix)" env:"BOSH_COMPILED_PACKAGE_CACHE"`,
+			))
+		})
+	})
+
+	Describe("CompiledCachePruneOpts", func() {
+		var opts CompiledCachePruneOpts
+
+		It("has --older-than", func() {
+			Expect(getStructTagForName("OlderThan", &opts)).To(Equal(
+				`long:"older-than" value-name:"DURATION" description:"Remove packages not used for longer than duration (e.g. 720h)" required:"true"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheExportOpts", func() {
+		var opts CompiledCacheExportOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has --stemcell", func() {
+			Expect(getStructTagForName("Stemcell", &opts)).To(Equal(
+				`long:"stemcell" value-name:"OS/VERSION" description:"Only export packages compiled for stemcell (e.g. ubuntu-trusty/3445.11)"`,
+			))
+		})
+	})
+
+	Describe("CompiledCacheExportArgs", func() {
+		var args CompiledCacheExportArgs
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", &args)).To(Equal(
+				`positional-arg-name:"PATH" description:"Destination tarball path"`,
+			))
+		})
+	})
+
+	Describe("CacheOpts", func() {
+		var opts CacheOpts
+
+		It("has List", func() {
+			Expect(getStructTagForName("List", &opts)).To(Equal(
+				`command:"list" alias:"ls" description:"List downloaded release and stemcell tarballs"`,
+			))
+		})
+
+		It("has Prune", func() {
+			Expect(getStructTagForName("Prune", &opts)).To(Equal(
+				`command:"prune" description:"Remove downloaded tarballs that were not used recently and unused installations"`,
+			))
+		})
+
+		It("has Clear", func() {
+			Expect(getStructTagForName("Clear", &opts)).To(Equal(
+				`command:"clear" description:"Remove all downloaded tarballs"`,
+			))
+		})
+	})
+
+	Describe("CachePruneOpts", func() {
+		var opts CachePruneOpts
+
+		It("has --older-than", func() {
+			Expect(getStructTagForName("OlderThan", &opts)).To(Equal(
+				`long:"older-than" value-name:"DURATION" description:"Remove tarballs not used for longer than duration (e.g. 720h)"`,
+			))
+		})
+
+		It("has --keep-size", func() {
+			Expect(getStructTagForName("KeepSize", &opts)).To(Equal(
+				`long:"keep-size" value-name:"SIZE" description:"Remove least recently used tarballs until cache is at most given size (e.g. 10GB)"`,
+			))
+		})
+
+		It("has --installations", func() {
+			Expect(getStructTagForName("Installations", &opts)).To(Equal(
+				`long:"installations" description:"Remove installations not referenced by given state files. Every state file still in use must be given, otherwise its installation is removed"`,
+			))
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePaths", &opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path or object store URL whose installation is kept (multiple allowed)"`,
+			))
+		})
+	})
+
 	Describe("AliasEnvOpts", func() {
 		var opts *AliasEnvOpts
 
@@ -987,6 +1429,48 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("CurlOpts", func() {
+		var opts CurlOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has Method", func() {
+			Expect(getStructTagForName("Method", &opts)).To(Equal(
+				`long:"method" short:"X" value-name:"METHOD" description:"HTTP method (GET, POST, PUT or DELETE)" default:"GET"`,
+			))
+		})
+
+		It("has Headers", func() {
+			Expect(getStructTagForName("Headers", &opts)).To(Equal(
+				`long:"header" short:"H" value-name:"HEADER" description:"HTTP header (e.g.: 'Content-Type: application/json')"`,
+			))
+		})
+
+		It("has Body", func() {
+			Expect(getStructTagForName("Body", &opts)).To(Equal(
+				`long:"body" value-name:"PATH" description:"Path to a file with request body"`,
+			))
+		})
+
+		It("has FollowTask", func() {
+			Expect(getStructTagForName("FollowTask", &opts)).To(Equal(
+				`long:"follow-task" description:"Wait for task started by the request to finish"`,
+			))
+		})
+	})
+
+	Describe("CurlArgs", func() {
+		var opts CurlArgs
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", &opts)).To(Equal(
+				`positional-arg-name:"PATH" description:"Director API path (e.g.: /info)"`,
+			))
+		})
+	})
+
 	Describe("InterpolateOpts", func() {
 		var opts InterpolateOpts
 
@@ -1029,6 +1513,292 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("LintManifestOpts", func() {
+		var opts LintManifestOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has Releases", func() {
+			Expect(getStructTagForName("Releases", &opts)).To(Equal(
+				`long:"release" value-name:"PATH" description:"Path to a release tarball used to check jobs and properties"`,
+			))
+		})
+
+		It("has CloudConfig", func() {
+			Expect(getStructTagForName("CloudConfig", &opts)).To(Equal(
+				`long:"cloud-config" value-name:"PATH" description:"Path to a cloud config used to check AZs, networks, VM types, etc."`,
+			))
+		})
+	})
+
+	Describe("LintManifestArgs", func() {
+		var opts LintManifestArgs
+
+		It("has Manifest", func() {
+			Expect(getStructTagForName("Manifest", &opts)).To(Equal(
+				`positional-arg-name:"PATH" description:"Path to a manifest file"`,
+			))
+		})
+	})
+
+	Describe("RenderTemplatesOpts", func() {
+		var opts RenderTemplatesOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has Properties", func() {
+			Expect(getStructTagForName("Properties", &opts)).To(Equal(
+				`long:"properties" value-name:"PATH" description:"Path to a properties file or deployment manifest"`,
+			))
+		})
+
+		It("has InstanceGroup", func() {
+			Expect(getStructTagForName("InstanceGroup", &opts)).To(Equal(
+				`long:"instance-group" value-name:"NAME" description:"Instance group to take properties from when using deployment manifest"`,
+			))
+		})
+	})
+
+	Describe("RenderTemplatesArgs", func() {
+		var opts RenderTemplatesArgs
+
+		It("has Release", func() {
+			Expect(getStructTagForName("Release", &opts)).To(Equal(
+				`positional-arg-name:"RELEASE" description:"Path to a release directory or tarball"`,
+			))
+		})
+
+		It("has Job", func() {
+			Expect(getStructTagForName("Job", &opts)).To(Equal(
+				`positional-arg-name:"JOB" description:"Job name"`,
+			))
+		})
+
+		It("has Dir", func() {
+			Expect(getStructTagForName("Dir", &opts)).To(Equal(
+				`positional-arg-name:"OUTPUT-DIR" description:"Directory to write rendered templates to"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreOpts", func() {
+		var opts VarsStoreOpts
+
+		It("has Rekey", func() {
+			Expect(getStructTagForName("Rekey", &opts)).To(Equal(
+				`command:"rekey" description:"Encrypt variables file store with a new passphrase"`,
+			))
+		})
+
+		It("has Decrypt", func() {
+			Expect(getStructTagForName("Decrypt", &opts)).To(Equal(
+				`command:"decrypt" description:"Show or save decrypted variables file store"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreRekeyOpts", func() {
+		var opts VarsStoreRekeyOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has NewPassphrase", func() {
+			Expect(getStructTagForName("NewPassphrase", &opts)).To(Equal(
+				`long:"new-passphrase" value-name:"PASSPHRASE" description:"New passphrase used to encrypt vars store" env:"BOSH_VARS_STORE_NEW_PASSPHRASE"`,
+			))
+		})
+
+		It("has NewKeyFile", func() {
+			Expect(getStructTagForName("NewKeyFile", &opts)).To(Equal(
+				`long:"new-key-file" value-name:"PATH" description:"Path to a file with new passphrase used to encrypt vars store"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreDecryptOpts", func() {
+		var opts VarsStoreDecryptOpts
+
+		It("has Args", func() {
+			Expect(getStructTagForName("Args", &opts)).To(Equal(`positional-args:"true" required:"true"`))
+		})
+
+		It("has InPlace", func() {
+			Expect(getStructTagForName("InPlace", &opts)).To(Equal(
+				`long:"in-place" description:"Overwrite variables file store with decrypted contents"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreArgs", func() {
+		var opts VarsStoreArgs
+
+		It("has Path", func() {
+			Expect(getStructTagForName("Path", &opts)).To(Equal(
+				`positional-arg-name:"PATH" description:"Path to a variables file store"`,
+			))
+		})
+	})
+
+	Describe("VarsStoreKeyFlags", func() {
+		var opts VarsStoreKeyFlags
+
+		It("has Passphrase", func() {
+			Expect(getStructTagForName("Passphrase", &opts)).To(Equal(
+				`long:"passphrase" value-name:"PASSPHRASE" description:"Passphrase used to encrypt vars store" env:"BOSH_VARS_STORE_PASSPHRASE"`,
+			))
+		})
+
+		It("has KeyFile", func() {
+			Expect(getStructTagForName("KeyFile", &opts)).To(Equal(
+				`long:"key-file" value-name:"PATH" description:"Path to a file with passphrase used to encrypt vars store" env:"BOSH_VARS_STORE_KEY_FILE"`,
+			))
+		})
+	})
+
+	Describe("ConfigsOpts", func() {
+		var opts *ConfigsOpts
+
+		BeforeEach(func() {
+			opts = &ConfigsOpts{}
+		})
+
+		Describe("Type", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Type", opts)).To(Equal(`long:"type" description:"Show configs of given type"`))
+			})
+		})
+
+		Describe("Name", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Name", opts)).To(Equal(`long:"name" description:"Show configs with given name"`))
+			})
+		})
+
+		Describe("Recent", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Recent", opts)).To(Equal(
+					`long:"recent" short:"r" description:"Number of configs to show, including previous versions" default:"1"`,
+				))
+			})
+		})
+	})
+
+	Describe("ConfigOpts", func() {
+		var opts *ConfigOpts
+
+		BeforeEach(func() {
+			opts = &ConfigOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		Describe("Type", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Type", opts)).To(Equal(`long:"type" description:"Config type"`))
+			})
+		})
+
+		Describe("Name", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Name", opts)).To(Equal(`long:"name" description:"Config name"`))
+			})
+		})
+	})
+
+	Describe("ConfigArgs", func() {
+		var opts *ConfigArgs
+
+		BeforeEach(func() {
+			opts = &ConfigArgs{}
+		})
+
+		Describe("ID", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ID", opts)).To(Equal(`positional-arg-name:"ID" description:"Config ID"`))
+			})
+		})
+	})
+
+	Describe("UpdateConfigOpts", func() {
+		var opts *UpdateConfigOpts
+
+		BeforeEach(func() {
+			opts = &UpdateConfigOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		Describe("Type", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Type", opts)).To(Equal(
+					`long:"type" description:"Config type, e.g. 'cloud', 'runtime', 'cpi'" required:"true"`,
+				))
+			})
+		})
+
+		Describe("Name", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Name", opts)).To(Equal(
+					`long:"name" description:"Config name (default: 'default')" default:"default"`,
+				))
+			})
+		})
+	})
+
+	Describe("UpdateConfigArgs", func() {
+		var opts *UpdateConfigArgs
+
+		BeforeEach(func() {
+			opts = &UpdateConfigArgs{}
+		})
+
+		Describe("Config", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Config", opts)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a config file"`,
+				))
+			})
+		})
+	})
+
+	Describe("DeleteConfigOpts", func() {
+		var opts *DeleteConfigOpts
+
+		BeforeEach(func() {
+			opts = &DeleteConfigOpts{}
+		})
+
+		Describe("Type", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Type", opts)).To(Equal(
+					`long:"type" description:"Config type, e.g. 'cloud', 'runtime', 'cpi'" required:"true"`,
+				))
+			})
+		})
+
+		Describe("Name", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Name", opts)).To(Equal(
+					`long:"name" description:"Config name (default: 'default')" default:"default"`,
+				))
+			})
+		})
+	})
+
 	Describe("UpdateCloudConfigOpts", func() {
 		var opts *UpdateCloudConfigOpts
 
@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type RenderTemplatesCmd struct {
+	ui              boshui.UI
+	releaseReader   boshrel.Reader
+	jobDirReader    boshjob.DirReader
+	jobListRenderer bitemplate.JobListRenderer
+	fs              boshsys.FileSystem
+}
+
+func NewRenderTemplatesCmd(
+	ui boshui.UI,
+	releaseReader boshrel.Reader,
+	jobDirReader boshjob.DirReader,
+	jobListRenderer bitemplate.JobListRenderer,
+	fs boshsys.FileSystem,
+) RenderTemplatesCmd {
+	return RenderTemplatesCmd{
+		ui:              ui,
+		releaseReader:   releaseReader,
+		jobDirReader:    jobDirReader,
+		jobListRenderer: jobListRenderer,
+		fs:              fs,
+	}
+}
+
+type renderTemplatesManifest struct {
+	Name       string                      `yaml:"name"`
+	Properties map[interface{}]interface{} `yaml:"properties"`
+
+	InstanceGroups []renderTemplatesInstanceGroup `yaml:"instance_groups"`
+}
+
+type renderTemplatesInstanceGroup struct {
+	Name       string                      `yaml:"name"`
+	Properties map[interface{}]interface{} `yaml:"properties"`
+
+	Jobs []struct {
+		Name       string                       `yaml:"name"`
+		Properties *map[interface{}]interface{} `yaml:"properties"`
+	} `yaml:"jobs"`
+}
+
+type renderTemplatesProperties struct {
+	deploymentName       string
+	releaseJobProperties *biproperty.Map
+	jobProperties        biproperty.Map
+	globalProperties     biproperty.Map
+}
+
+func (c RenderTemplatesCmd) Run(opts RenderTemplatesOpts) error {
+	job, release, err := c.readJob(opts.Args.Release, opts.Args.Job)
+	if err != nil {
+		return err
+	}
+
+	if release != nil {
+		defer func() {
+			// Failing to remove extracted release should not affect rendering results
+			_ = release.CleanUp()
+		}()
+	}
+
+	props, err := c.properties(opts)
+	if err != nil {
+		return err
+	}
+
+	releaseJobProperties := map[string]*biproperty.Map{}
+	if props.releaseJobProperties != nil {
+		releaseJobProperties[job.Name()] = props.releaseJobProperties
+	}
+
+	renderedJobList, err := c.jobListRenderer.Render(
+		[]boshjob.Job{*job}, releaseJobProperties, nil, props.jobProperties, props.globalProperties, props.deploymentName, "")
+	if err != nil {
+		return err
+	}
+
+	defer renderedJobList.DeleteSilently()
+
+	outputDir, err := c.fs.ExpandPath(opts.Args.Dir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Expanding output directory path")
+	}
+
+	table := boshtbl.Table{
+		Content: "files",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("File"),
+			boshtbl.NewHeader("Status"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, renderedJob := range renderedJobList.All() {
+		files, err := c.renderedFiles(renderedJob.Path())
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			status, err := c.writeFile(filepath.Join(renderedJob.Path(), file), filepath.Join(outputDir, file), file)
+			if err != nil {
+				return err
+			}
+
+			table.Rows = append(table.Rows, []boshtbl.Value{
+				boshtbl.NewValueString(file),
+				boshtbl.NewValueString(status),
+			})
+		}
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+// readJob reads job from release directory or release tarball. Release is returned
+// only when it was extracted and needs to be cleaned up.
+func (c RenderTemplatesCmd) readJob(releasePath, jobName string) (*boshjob.Job, boshrel.Release, error) {
+	releasePath, err := c.fs.ExpandPath(releasePath)
+	if err != nil {
+		return nil, nil, bosherr.WrapErrorf(err, "Expanding release path")
+	}
+
+	stat, err := c.fs.Stat(releasePath)
+	if err != nil {
+		return nil, nil, bosherr.WrapErrorf(err, "Checking release path '%s'", releasePath)
+	}
+
+	if stat.IsDir() {
+		job, err := c.jobDirReader.Read(filepath.Join(releasePath, "jobs", jobName))
+		if err != nil {
+			return nil, nil, bosherr.WrapErrorf(err, "Reading job '%s' from release directory '%s'", jobName, releasePath)
+		}
+
+		return job, nil, nil
+	}
+
+	release, err := c.releaseReader.Read(releasePath)
+	if err != nil {
+		return nil, nil, bosherr.WrapErrorf(err, "Reading release '%s'", releasePath)
+	}
+
+	job, found := release.FindJobByName(jobName)
+	if found {
+		return &job, release, nil
+	}
+
+	_ = release.CleanUp()
+
+	return nil, nil, bosherr.Errorf("Expected to find job '%s' in release '%s'", jobName, releasePath)
+}
+
+// properties determines properties used for rendering. When given file is a deployment manifest
+// properties are taken from the manifest the same way Director does; otherwise whole file is
+// treated as job properties.
+func (c RenderTemplatesCmd) properties(opts RenderTemplatesOpts) (renderTemplatesProperties, error) {
+	var props renderTemplatesProperties
+
+	if len(opts.Properties.Bytes) == 0 {
+		return props, nil
+	}
+
+	tpl := boshtpl.NewTemplate(opts.Properties.Bytes)
+
+	bytes, err := tpl.Evaluate(opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return props, bosherr.WrapErrorf(err, "Evaluating properties")
+	}
+
+	var manifest renderTemplatesManifest
+
+	err = yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return props, bosherr.WrapErrorf(err, "Unmarshalling properties")
+	}
+
+	if len(manifest.InstanceGroups) == 0 {
+		var rawProps map[interface{}]interface{}
+
+		err = yaml.Unmarshal(bytes, &rawProps)
+		if err != nil {
+			return props, bosherr.WrapErrorf(err, "Unmarshalling properties")
+		}
+
+		props.jobProperties, err = biproperty.BuildMap(rawProps)
+		if err != nil {
+			return props, bosherr.WrapErrorf(err, "Building properties")
+		}
+
+		return props, nil
+	}
+
+	props.deploymentName = manifest.Name
+
+	props.globalProperties, err = biproperty.BuildMap(manifest.Properties)
+	if err != nil {
+		return props, bosherr.WrapErrorf(err, "Building global properties")
+	}
+
+	for _, group := range manifest.InstanceGroups {
+		if len(opts.InstanceGroup) > 0 && group.Name != opts.InstanceGroup {
+			continue
+		}
+
+		for _, job := range group.Jobs {
+			if job.Name != opts.Args.Job {
+				continue
+			}
+
+			props.jobProperties, err = biproperty.BuildMap(group.Properties)
+			if err != nil {
+				return props, bosherr.WrapErrorf(err, "Building instance group '%s' properties", group.Name)
+			}
+
+			if job.Properties != nil {
+				releaseJobProperties, err := biproperty.BuildMap(*job.Properties)
+				if err != nil {
+					return props, bosherr.WrapErrorf(err, "Building job '%s' properties", job.Name)
+				}
+
+				props.releaseJobProperties = &releaseJobProperties
+			}
+
+			return props, nil
+		}
+	}
+
+	if len(opts.InstanceGroup) > 0 {
+		return props, bosherr.Errorf("Expected to find job '%s' in instance group '%s'", opts.Args.Job, opts.InstanceGroup)
+	}
+
+	return props, bosherr.Errorf("Expected to find job '%s' in manifest instance groups", opts.Args.Job)
+}
+
+func (c RenderTemplatesCmd) renderedFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := c.fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			files = append(files, relPath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing rendered files")
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// writeFile copies rendered file into output directory and prints
+// differences from previously rendered file if there was one
+func (c RenderTemplatesCmd) writeFile(srcPath, dstPath, name string) (string, error) {
+	contents, err := c.fs.ReadFileString(srcPath)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Reading rendered file '%s'", name)
+	}
+
+	status := "added"
+
+	if c.fs.FileExists(dstPath) {
+		prevContents, err := c.fs.ReadFileString(dstPath)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Reading previously rendered file '%s'", name)
+		}
+
+		if prevContents == contents {
+			return "unchanged", nil
+		}
+
+		status = "changed"
+
+		c.ui.BeginLinef("%s\n", name)
+		NewTextDiff(prevContents, contents).Print(c.ui)
+		c.ui.BeginLinef("\n")
+	}
+
+	err = c.fs.MkdirAll(filepath.Dir(dstPath), os.ModePerm)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating directory for rendered file '%s'", name)
+	}
+
+	err = c.fs.WriteFileString(dstPath, contents)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Writing rendered file '%s'", name)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,225 @@
+package cmd_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("RenderTemplatesCmd", func() {
+	var (
+		ui            *fakeui.FakeUI
+		fs            *fakesys.FakeFileSystem
+		releaseReader *fakerel.FakeReader
+		command       RenderTemplatesCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		releaseReader = &fakerel.FakeReader{}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		erbRenderer := bierbrenderer.NewGoERBRenderer(fs, logger)
+		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, fs, &fakeuuid.FakeGenerator{}, logger)
+
+		command = NewRenderTemplatesCmd(
+			ui, releaseReader, boshjob.NewSourceDirReader(fs), bitemplate.NewJobListRenderer(jobRenderer, logger), fs)
+
+		fs.WriteFileString("/release/jobs/web/spec", `---
+name: web
+templates:
+  config.yml.erb: config/config.yml
+properties:
+  port: {default: 80}
+  name: {}
+`)
+		fs.WriteFileString("/release/jobs/web/monit", "check process web")
+		fs.WriteFileString("/release/jobs/web/templates/config.yml.erb",
+			"deployment: <%= spec.deployment %>\nport: <%= p('port') %>\nname: <%= p('name', 'none') %>\n")
+	})
+
+	Describe("Run", func() {
+		var (
+			opts RenderTemplatesOpts
+		)
+
+		BeforeEach(func() {
+			opts = RenderTemplatesOpts{
+				Args: RenderTemplatesArgs{Release: "/release", Job: "web", Dir: "/out"},
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("renders job templates from release directory into output directory", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/out/config/config.yml")).To(Equal("deployment: \nport: 80\nname: none\n"))
+			Expect(fs.ReadFileString("/out/monit")).To(Equal("check process web"))
+
+			Expect(ui.Table).To(Equal(boshtbl.Table{
+				Content: "files",
+				Header: []boshtbl.Header{
+					boshtbl.NewHeader("File"),
+					boshtbl.NewHeader("Status"),
+				},
+				SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+				Rows: [][]boshtbl.Value{
+					{boshtbl.NewValueString("config/config.yml"), boshtbl.NewValueString("added")},
+					{boshtbl.NewValueString("monit"), boshtbl.NewValueString("added")},
+				},
+			}))
+		})
+
+		It("uses file as job properties if it is not a deployment manifest", func() {
+			opts.Properties = FileBytesArg{Bytes: []byte("port: ((port))\nname: app\n")}
+			opts.VarKVs = []boshtpl.VarKV{{Name: "port", Value: 8080}}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/out/config/config.yml")).To(Equal("deployment: \nport: 8080\nname: app\n"))
+		})
+
+		It("uses properties from deployment manifest instance group that includes job", func() {
+			opts.Properties = FileBytesArg{Bytes: []byte(`
+name: dep
+properties: {name: global}
+instance_groups:
+- name: other
+  jobs: [{name: db}]
+- name: web-ig
+  properties: {port: 81}
+  jobs: [{name: web, properties: {port: 8443}}]
+`)}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/out/config/config.yml")).To(Equal("deployment: dep\nport: 8443\nname: none\n"))
+		})
+
+		It("returns error if job is not found in specified instance group", func() {
+			opts.Properties = FileBytesArg{Bytes: []byte("instance_groups: [{name: web-ig, jobs: [{name: web}]}]")}
+			opts.InstanceGroup = "other"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected to find job 'web' in instance group 'other'"))
+		})
+
+		It("prints differences from previously rendered files", func() {
+			fs.WriteFileString("/out/config/config.yml", "deployment: \nport: 79\nname: none\n")
+			fs.WriteFileString("/out/monit", "check process web")
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/out/config/config.yml")).To(Equal("deployment: \nport: 80\nname: none\n"))
+
+			Expect(ui.Said).To(Equal([]string{
+				"config/config.yml\n",
+				"  deployment: \n",
+				"- port: 79\n",
+				"+ port: 80\n",
+				"  name: none\n",
+				"\n",
+			}))
+
+			Expect(ui.Table.Rows).To(Equal([][]boshtbl.Value{
+				{boshtbl.NewValueString("config/config.yml"), boshtbl.NewValueString("changed")},
+				{boshtbl.NewValueString("monit"), boshtbl.NewValueString("unchanged")},
+			}))
+		})
+
+		It("renders job templates from release tarball", func() {
+			fs.WriteFileString("/release.tgz", "")
+			opts.Args.Release = "/release.tgz"
+
+			job, err := boshjob.NewSourceDirReader(fs).Read("/release/jobs/web")
+			Expect(err).ToNot(HaveOccurred())
+
+			release := &fakerel.FakeRelease{}
+			release.FindJobByNameReturns(*job, true)
+
+			releaseReader.ReadStub = func(path string) (boshrel.Release, error) {
+				Expect(path).To(Equal("/release.tgz"))
+				return release, nil
+			}
+
+			err = act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/out/monit")).To(Equal("check process web"))
+			Expect(release.FindJobByNameArgsForCall(0)).To(Equal("web"))
+			Expect(release.CleanUpCallCount()).To(Equal(1))
+		})
+
+		It("returns error if job is not found in release tarball", func() {
+			fs.WriteFileString("/release.tgz", "")
+			opts.Args.Release = "/release.tgz"
+
+			release := &fakerel.FakeRelease{}
+			releaseReader.ReadReturns(release, nil)
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected to find job 'web' in release '/release.tgz'"))
+			Expect(release.CleanUpCallCount()).To(Equal(1))
+		})
+
+		It("returns error if release cannot be read", func() {
+			fs.WriteFileString("/release.tgz", "")
+			opts.Args.Release = "/release.tgz"
+
+			releaseReader.ReadReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if templates cannot be rendered", func() {
+			fs.WriteFileString("/release/jobs/web/templates/config.yml.erb", "<%= p('unknown') %>")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Can't find property 'unknown'"))
+		})
+	})
+})
+
+var _ = Describe("NewTextDiff", func() {
+	It("omits unchanged lines far away from changes", func() {
+		ui := &fakeui.FakeUI{}
+
+		NewTextDiff("1\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\n5\n6\nseven\n8\n9\n").Print(ui)
+
+		Expect(ui.Said).To(Equal([]string{
+			"  ...\n",
+			"  4\n",
+			"  5\n",
+			"  6\n",
+			"- 7\n",
+			"+ seven\n",
+			"  8\n",
+			"  9\n",
+		}))
+	})
+})
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type StartEnvCmd struct {
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) DeploymentStarter
+	lockProvider func(string, string) biconfig.DeploymentStateLock
+}
+
+func NewStartEnvCmd(
+	ui boshui.UI,
+	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentStarter,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+) *StartEnvCmd {
+	return &StartEnvCmd{ui: ui, envProvider: envProvider, lockProvider: lockProvider}
+}
+
+func (c *StartEnvCmd) Run(stage boshui.Stage, opts StartEnvOpts) error {
+	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
+
+	lock := c.lockProvider(opts.Args.Manifest.Path, opts.StatePath)
+
+	return withDeploymentStateLock(c.ui, lock, opts.ForceUnlock, func() error {
+		depStarter := c.envProvider(
+			opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+		return depStarter.StartDeployment(stage)
+	})
+}
@@ -0,0 +1,97 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+
+	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+var _ = Describe("StartEnvCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			mockDeploymentStarter *mock_cmd.MockDeploymentStarter
+			fakeUI                *fakeui.FakeUI
+			fakeStage             *fakeui.FakeStage
+			fakeLock              *fakebiconfig.FakeDeploymentStateLock
+
+			manifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+			statePath    string
+			lockedPath   string
+		)
+
+		BeforeEach(func() {
+			mockDeploymentStarter = mock_cmd.NewMockDeploymentStarter(mockCtrl)
+			fakeUI = &fakeui.FakeUI{}
+			fakeStage = fakeui.NewFakeStage()
+			fakeLock = fakebiconfig.NewFakeDeploymentStateLock()
+		})
+
+		act := func(opts bicmd.StartEnvOpts) error {
+			envProvider := func(manifestPath_ string, statePath_ string, vars boshtpl.Variables, op patch.Op) bicmd.DeploymentStarter {
+				Expect(manifestPath_).To(Equal(manifestPath))
+				Expect(vars).To(Equal(boshtpl.NewMultiVars([]boshtpl.Variables{boshtpl.StaticVariables{"key": "value"}})))
+				Expect(op).To(Equal(patch.Ops{patch.ErrOp{}}))
+				statePath = statePath_
+				return mockDeploymentStarter
+			}
+
+			lockProvider := func(manifestPath string, statePath string) biconfig.DeploymentStateLock {
+				lockedPath = statePath
+				return fakeLock
+			}
+
+			opts.Args = bicmd.StartEnvArgs{Manifest: bicmd.FileBytesWithPathArg{Path: manifestPath}}
+			opts.VarFlags = bicmd.VarFlags{VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}}}
+			opts.OpsFlags = bicmd.OpsFlags{OpsFiles: []bicmd.OpsFileArg{{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})}}}
+
+			return bicmd.NewStartEnvCmd(fakeUI, envProvider, lockProvider).Run(fakeStage, opts)
+		}
+
+		It("starts deployment while holding deployment state lock", func() {
+			mockDeploymentStarter.EXPECT().StartDeployment(fakeStage).Return(nil)
+
+			err := act(bicmd.StartEnvOpts{StatePath: "/state.json"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(statePath).To(Equal("/state.json"))
+			Expect(lockedPath).To(Equal("/state.json"))
+			Expect(fakeLock.LockCalled).To(BeTrue())
+			Expect(fakeLock.UnlockCalled).To(BeTrue())
+		})
+
+		It("returns error if starting deployment fails", func() {
+			mockDeploymentStarter.EXPECT().StartDeployment(fakeStage).Return(bosherr.Error("fake-err"))
+
+			err := act(bicmd.StartEnvOpts{})
+			Expect(err).To(Equal(bosherr.Error("fake-err")))
+			Expect(fakeLock.UnlockCalled).To(BeTrue())
+		})
+
+		It("does not start deployment if deployment state is locked", func() {
+			fakeLock.LockErr = bosherr.Error("fake-lock-err")
+
+			err := act(bicmd.StartEnvOpts{})
+			Expect(err).To(Equal(fakeLock.LockErr))
+		})
+	})
+})
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// stateRecordForgetter removes records from deployment state
+// after saving a backup copy of the whole state next to it
+type stateRecordForgetter struct {
+	ui            boshui.UI
+	stateProvider func(string) biconfig.DeploymentStateService
+	lockProvider  func(string, string) biconfig.DeploymentStateLock
+	uuidGen       boshuuid.Generator
+	timeService   clock.Clock
+}
+
+func (f stateRecordForgetter) forget(statePath string, forceUnlock bool, fn func(biconfig.DeploymentStateService, func() error) error) error {
+	lock := f.lockProvider("", statePath)
+
+	return withDeploymentStateLock(f.ui, lock, forceUnlock, func() error {
+		stateService := f.stateProvider(statePath)
+
+		if !stateService.Exists() {
+			return bosherr.Errorf("Deployment state '%s' does not exist", stateService.Path())
+		}
+
+		return fn(stateService, func() error { return f.backup(stateService) })
+	})
+}
+
+func (f stateRecordForgetter) backup(stateService biconfig.DeploymentStateService) error {
+	state, err := stateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	backupPath := f.backupPath(stateService.Path())
+
+	err = f.stateProvider(backupPath).Save(state)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving deployment state backup")
+	}
+
+	f.ui.PrintLinef("Saved deployment state backup to '%s'", backupPath)
+
+	return nil
+}
+
+// backupPath keeps query of object store URLs (e.g. s3://bucket/key?region=x)
+// at the end so that backup is saved into the same bucket
+func (f stateRecordForgetter) backupPath(statePath string) string {
+	suffix := fmt.Sprintf(".%s.bak", f.timeService.Now().UTC().Format("20060102T150405Z"))
+
+	if biconfig.IsObjectStoreURL(statePath) {
+		pieces := strings.SplitN(statePath, "?", 2)
+		if len(pieces) == 2 {
+			return pieces[0] + suffix + "?" + pieces[1]
+		}
+	}
+
+	return statePath + suffix
+}
+
+type StateForgetVMCmd struct {
+	forgetter stateRecordForgetter
+}
+
+func NewStateForgetVMCmd(
+	ui boshui.UI,
+	stateProvider func(string) biconfig.DeploymentStateService,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+	uuidGen boshuuid.Generator,
+	timeService clock.Clock,
+) StateForgetVMCmd {
+	return StateForgetVMCmd{stateRecordForgetter{ui, stateProvider, lockProvider, uuidGen, timeService}}
+}
+
+func (c StateForgetVMCmd) Run(opts StateForgetVMOpts) error {
+	return c.forgetter.forget(opts.StatePath, opts.ForceUnlock, func(stateService biconfig.DeploymentStateService, backup func() error) error {
+		vmRepo := biconfig.NewVMRepo(stateService)
+
+		cid, found, err := vmRepo.FindCurrent()
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			c.forgetter.ui.PrintLinef("No VM found in deployment state")
+			return nil
+		}
+
+		c.forgetter.ui.PrintLinef("Forgetting VM '%s'. VM will not be deleted from the IaaS.", cid)
+
+		err = c.forgetter.ui.AskForConfirmation()
+		if err != nil {
+			return err
+		}
+
+		err = backup()
+		if err != nil {
+			return err
+		}
+
+		return vmRepo.ClearCurrent()
+	})
+}
+
+type StateForgetDiskCmd struct {
+	forgetter stateRecordForgetter
+}
+
+func NewStateForgetDiskCmd(
+	ui boshui.UI,
+	stateProvider func(string) biconfig.DeploymentStateService,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+	uuidGen boshuuid.Generator,
+	timeService clock.Clock,
+) StateForgetDiskCmd {
+	return StateForgetDiskCmd{stateRecordForgetter{ui, stateProvider, lockProvider, uuidGen, timeService}}
+}
+
+func (c StateForgetDiskCmd) Run(opts StateForgetDiskOpts) error {
+	return c.forgetter.forget(opts.StatePath, opts.ForceUnlock, func(stateService biconfig.DeploymentStateService, backup func() error) error {
+		diskRepo := biconfig.NewDiskRepo(stateService, c.forgetter.uuidGen)
+
+		record, found, err := diskRepo.Find(opts.Args.CID)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return bosherr.Errorf("Disk '%s' not found in deployment state", opts.Args.CID)
+		}
+
+		c.forgetter.ui.PrintLinef("Forgetting disk '%s'. Disk will not be deleted from the IaaS.", record.CID)
+
+		err = c.forgetter.ui.AskForConfirmation()
+		if err != nil {
+			return err
+		}
+
+		err = backup()
+		if err != nil {
+			return err
+		}
+
+		return diskRepo.Delete(record)
+	})
+}
+
+type StateForgetStemcellCmd struct {
+	forgetter stateRecordForgetter
+}
+
+func NewStateForgetStemcellCmd(
+	ui boshui.UI,
+	stateProvider func(string) biconfig.DeploymentStateService,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+	uuidGen boshuuid.Generator,
+	timeService clock.Clock,
+) StateForgetStemcellCmd {
+	return StateForgetStemcellCmd{stateRecordForgetter{ui, stateProvider, lockProvider, uuidGen, timeService}}
+}
+
+func (c StateForgetStemcellCmd) Run(opts StateForgetStemcellOpts) error {
+	return c.forgetter.forget(opts.StatePath, opts.ForceUnlock, func(stateService biconfig.DeploymentStateService, backup func() error) error {
+		stemcellRepo := biconfig.NewStemcellRepo(stateService, c.forgetter.uuidGen)
+
+		records, err := stemcellRepo.All()
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.CID != opts.Args.CID {
+				continue
+			}
+
+			c.forgetter.ui.PrintLinef("Forgetting stemcell '%s/%s' ('%s'). Stemcell will not be deleted from the IaaS.",
+				record.Name, record.Version, record.CID)
+
+			err = c.forgetter.ui.AskForConfirmation()
+			if err != nil {
+				return err
+			}
+
+			err = backup()
+			if err != nil {
+				return err
+			}
+
+			return stemcellRepo.Delete(record)
+		}
+
+		return bosherr.Errorf("Stemcell '%s' not found in deployment state", opts.Args.CID)
+	})
+}
@@ -0,0 +1,191 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("StateForget commands", func() {
+	var (
+		fs            *fakesys.FakeFileSystem
+		logger        boshlog.Logger
+		uuidGen       *fakeuuid.FakeGenerator
+		ui            *fakeui.FakeUI
+		fakeLock      *fakebiconfig.FakeDeploymentStateLock
+		timeService   *fakeclock.FakeClock
+		stateProvider func(string) biconfig.DeploymentStateService
+		lockProvider  func(string, string) biconfig.DeploymentStateLock
+		lockedPath    string
+
+		backupPath = "/state.json.20091110T230102Z.bak"
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		uuidGen = fakeuuid.NewFakeGenerator()
+		ui = &fakeui.FakeUI{}
+		fakeLock = fakebiconfig.NewFakeDeploymentStateLock()
+		timeService = fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 1, 2, 333, time.UTC))
+
+		stateProvider = func(statePath string) biconfig.DeploymentStateService {
+			return biconfig.NewFileSystemDeploymentStateService(fs, uuidGen, logger, statePath)
+		}
+
+		lockProvider = func(manifestPath, statePath string) biconfig.DeploymentStateLock {
+			Expect(manifestPath).To(Equal(""))
+			lockedPath = statePath
+			return fakeLock
+		}
+
+		err := stateProvider("/state.json").Save(biconfig.DeploymentState{
+			DirectorID:        "director-id",
+			CurrentVMCID:      "vm-cid",
+			CurrentStemcellID: "stemcell-id",
+			Stemcells: []biconfig.StemcellRecord{
+				{ID: "stemcell-id", Name: "stemcell", Version: "1", CID: "stemcell-cid"},
+			},
+			CurrentDiskID: "disk-id",
+			Disks: []biconfig.DiskRecord{
+				{ID: "disk-id", CID: "disk-cid", Size: 1024},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	loadState := func(path string) biconfig.DeploymentState {
+		state, err := stateProvider(path).Load()
+		Expect(err).ToNot(HaveOccurred())
+		return state
+	}
+
+	Describe("StateForgetVMCmd", func() {
+		var (
+			command StateForgetVMCmd
+			opts    StateForgetVMOpts
+		)
+
+		BeforeEach(func() {
+			command = NewStateForgetVMCmd(ui, stateProvider, lockProvider, uuidGen, timeService)
+			opts = StateForgetVMOpts{StateFlags: StateFlags{StatePath: "/state.json"}}
+		})
+
+		It("clears current VM after saving backup while holding deployment state lock", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(loadState("/state.json").CurrentVMCID).To(BeEmpty())
+			Expect(loadState(backupPath).CurrentVMCID).To(Equal("vm-cid"))
+
+			Expect(ui.AskedConfirmationCalled).To(BeTrue())
+			Expect(lockedPath).To(Equal("/state.json"))
+			Expect(fakeLock.LockCalled).To(BeTrue())
+			Expect(fakeLock.UnlockCalled).To(BeTrue())
+		})
+
+		It("does not change state if confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+
+			Expect(loadState("/state.json").CurrentVMCID).To(Equal("vm-cid"))
+			Expect(fs.FileExists(backupPath)).To(BeFalse())
+		})
+
+		It("does not lock if deployment state is locked", func() {
+			fakeLock.LockErr = errors.New("fake-lock-err")
+
+			err := command.Run(opts)
+			Expect(err).To(Equal(fakeLock.LockErr))
+			Expect(loadState("/state.json").CurrentVMCID).To(Equal("vm-cid"))
+		})
+
+		It("returns error if state does not exist", func() {
+			opts.StatePath = "/other-state.json"
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/other-state.json' does not exist"))
+		})
+	})
+
+	Describe("StateForgetDiskCmd", func() {
+		var (
+			command StateForgetDiskCmd
+			opts    StateForgetDiskOpts
+		)
+
+		BeforeEach(func() {
+			command = NewStateForgetDiskCmd(ui, stateProvider, lockProvider, uuidGen, timeService)
+			opts = StateForgetDiskOpts{StateFlags: StateFlags{StatePath: "/state.json"}}
+		})
+
+		It("removes disk record after saving backup", func() {
+			opts.Args.CID = "disk-cid"
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			state := loadState("/state.json")
+			Expect(state.Disks).To(BeEmpty())
+			Expect(state.CurrentDiskID).To(BeEmpty())
+
+			Expect(loadState(backupPath).Disks).To(HaveLen(1))
+		})
+
+		It("returns error if disk is not found", func() {
+			opts.Args.CID = "unknown-cid"
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Disk 'unknown-cid' not found in deployment state"))
+			Expect(fs.FileExists(backupPath)).To(BeFalse())
+		})
+	})
+
+	Describe("StateForgetStemcellCmd", func() {
+		var (
+			command StateForgetStemcellCmd
+			opts    StateForgetStemcellOpts
+		)
+
+		BeforeEach(func() {
+			command = NewStateForgetStemcellCmd(ui, stateProvider, lockProvider, uuidGen, timeService)
+			opts = StateForgetStemcellOpts{StateFlags: StateFlags{StatePath: "/state.json"}}
+		})
+
+		It("removes stemcell record after saving backup", func() {
+			opts.Args.CID = "stemcell-cid"
+
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			state := loadState("/state.json")
+			Expect(state.Stemcells).To(BeEmpty())
+			Expect(state.CurrentStemcellID).To(BeEmpty())
+
+			Expect(loadState(backupPath).Stemcells).To(HaveLen(1))
+		})
+
+		It("returns error if stemcell is not found", func() {
+			opts.Args.CID = "unknown-cid"
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Stemcell 'unknown-cid' not found in deployment state"))
+		})
+	})
+})
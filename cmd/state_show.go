@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type StateShowCmd struct {
+	ui            boshui.UI
+	stateProvider func(string) biconfig.DeploymentStateService
+}
+
+func NewStateShowCmd(ui boshui.UI, stateProvider func(string) biconfig.DeploymentStateService) StateShowCmd {
+	return StateShowCmd{ui: ui, stateProvider: stateProvider}
+}
+
+func (c StateShowCmd) Run(opts StateShowOpts) error {
+	stateService := c.stateProvider(opts.StatePath)
+
+	if !stateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist", stateService.Path())
+	}
+
+	state, err := stateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	c.ui.PrintTable(boshtbl.Table{
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Director ID"),
+			boshtbl.NewHeader("Installation ID"),
+			boshtbl.NewHeader("VM CID"),
+			boshtbl.NewHeader("Manifest SHA"),
+		},
+		Rows: [][]boshtbl.Value{
+			{
+				boshtbl.NewValueString(state.DirectorID),
+				boshtbl.NewValueString(state.InstallationID),
+				boshtbl.NewValueString(state.CurrentVMCID),
+				boshtbl.NewValueString(state.CurrentManifestSHA),
+			},
+		},
+		Transpose: true,
+	})
+
+	stemcellsTable := boshtbl.Table{
+		Title:   "Stemcells",
+		Content: "stemcells",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+			boshtbl.NewHeader("CID"),
+		},
+		Notes: []string{"(*) Currently deployed"},
+	}
+
+	for _, record := range state.Stemcells {
+		stemcellsTable.Rows = append(stemcellsTable.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(record.ID),
+			boshtbl.NewValueString(record.Name),
+			boshtbl.NewValueSuffix(boshtbl.NewValueString(record.Version), stateRecordMark(record.ID == state.CurrentStemcellID)),
+			boshtbl.NewValueString(record.CID),
+		})
+	}
+
+	c.ui.PrintTable(stemcellsTable)
+
+	disksTable := boshtbl.Table{
+		Title:   "Disks",
+		Content: "disks",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("CID"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Cloud Properties"),
+		},
+		Notes: []string{"(*) Currently attached"},
+	}
+
+	for _, record := range state.Disks {
+		disksTable.Rows = append(disksTable.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(record.ID),
+			boshtbl.NewValueSuffix(boshtbl.NewValueString(record.CID), stateRecordMark(record.ID == state.CurrentDiskID)),
+			boshtbl.NewValueMegaBytes(uint64(record.Size)),
+			boshtbl.NewValueInterface(record.CloudProperties),
+		})
+	}
+
+	c.ui.PrintTable(disksTable)
+
+	releasesTable := boshtbl.Table{
+		Title:   "Releases",
+		Content: "releases",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("ID"),
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+		},
+		Notes: []string{"(*) Currently deployed"},
+	}
+
+	for _, record := range state.Releases {
+		current := false
+		for _, id := range state.CurrentReleaseIDs {
+			if id == record.ID {
+				current = true
+			}
+		}
+
+		releasesTable.Rows = append(releasesTable.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(record.ID),
+			boshtbl.NewValueString(record.Name),
+			boshtbl.NewValueSuffix(boshtbl.NewValueString(record.Version), stateRecordMark(current)),
+		})
+	}
+
+	c.ui.PrintTable(releasesTable)
+
+	return nil
+}
+
+func stateRecordMark(current bool) string {
+	if current {
+		return "*"
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package cmd_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("StateShowCmd", func() {
+	var (
+		fs           *fakesys.FakeFileSystem
+		stateService biconfig.DeploymentStateService
+		ui           *fakeui.FakeUI
+		command      StateShowCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		stateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeuuid.NewFakeGenerator(), logger, "/state.json")
+		ui = &fakeui.FakeUI{}
+
+		stateProvider := func(statePath string) biconfig.DeploymentStateService {
+			Expect(statePath).To(Equal("/state.json"))
+			return stateService
+		}
+
+		command = NewStateShowCmd(ui, stateProvider)
+	})
+
+	Describe("Run", func() {
+		var opts StateShowOpts
+
+		BeforeEach(func() {
+			opts = StateShowOpts{StateFlags: StateFlags{StatePath: "/state.json"}}
+		})
+
+		It("lists records marking current ones", func() {
+			err := stateService.Save(biconfig.DeploymentState{
+				DirectorID:         "director-id",
+				InstallationID:     "installation-id",
+				CurrentVMCID:       "vm-cid",
+				CurrentManifestSHA: "manifest-sha",
+				CurrentStemcellID:  "stemcell-id-2",
+				Stemcells: []biconfig.StemcellRecord{
+					{ID: "stemcell-id-1", Name: "stemcell", Version: "1", CID: "stemcell-cid-1"},
+					{ID: "stemcell-id-2", Name: "stemcell", Version: "2", CID: "stemcell-cid-2"},
+				},
+				CurrentDiskID: "disk-id",
+				Disks: []biconfig.DiskRecord{
+					{ID: "disk-id", CID: "disk-cid", Size: 1024},
+				},
+				CurrentReleaseIDs: []string{"release-id"},
+				Releases: []biconfig.ReleaseRecord{
+					{ID: "release-id", Name: "release", Version: "3"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ui.Tables).To(HaveLen(4))
+
+			Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("director-id"),
+					boshtbl.NewValueString("installation-id"),
+					boshtbl.NewValueString("vm-cid"),
+					boshtbl.NewValueString("manifest-sha"),
+				},
+			}))
+
+			Expect(ui.Tables[1].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("stemcell-id-1"),
+					boshtbl.NewValueString("stemcell"),
+					boshtbl.NewValueSuffix(boshtbl.NewValueString("1"), ""),
+					boshtbl.NewValueString("stemcell-cid-1"),
+				},
+				{
+					boshtbl.NewValueString("stemcell-id-2"),
+					boshtbl.NewValueString("stemcell"),
+					boshtbl.NewValueSuffix(boshtbl.NewValueString("2"), "*"),
+					boshtbl.NewValueString("stemcell-cid-2"),
+				},
+			}))
+
+			Expect(ui.Tables[2].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("disk-id"),
+					boshtbl.NewValueSuffix(boshtbl.NewValueString("disk-cid"), "*"),
+					boshtbl.NewValueMegaBytes(1024),
+					boshtbl.NewValueInterface(biproperty.Map(nil)),
+				},
+			}))
+
+			Expect(ui.Tables[3].Rows).To(Equal([][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("release-id"),
+					boshtbl.NewValueString("release"),
+					boshtbl.NewValueSuffix(boshtbl.NewValueString("3"), "*"),
+				},
+			}))
+		})
+
+		It("returns error if state does not exist", func() {
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Deployment state '/state.json' does not exist"))
+			Expect(fs.FileExists("/state.json")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type StateValidateCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentStateValidator
+}
+
+func NewStateValidateCmd(
+	ui boshui.UI,
+	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentStateValidator,
+) *StateValidateCmd {
+	return &StateValidateCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *StateValidateCmd) Run(stage boshui.Stage, opts StateValidateOpts) error {
+	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
+
+	validator := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	return validator.ValidateDeploymentState(stage)
+}
@@ -0,0 +1,75 @@
+package cmd_test
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+var _ = Describe("StateValidateCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			mockValidator *mock_cmd.MockDeploymentStateValidator
+			fakeUI        *fakeui.FakeUI
+			fakeStage     *fakeui.FakeStage
+
+			manifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+			statePath    string
+		)
+
+		BeforeEach(func() {
+			mockValidator = mock_cmd.NewMockDeploymentStateValidator(mockCtrl)
+			fakeUI = &fakeui.FakeUI{}
+			fakeStage = fakeui.NewFakeStage()
+		})
+
+		act := func(opts bicmd.StateValidateOpts) error {
+			envProvider := func(manifestPath_ string, statePath_ string, vars boshtpl.Variables, op patch.Op) bicmd.DeploymentStateValidator {
+				Expect(manifestPath_).To(Equal(manifestPath))
+				Expect(vars).To(Equal(boshtpl.NewMultiVars([]boshtpl.Variables{boshtpl.StaticVariables{"key": "value"}})))
+				Expect(op).To(Equal(patch.Ops{patch.ErrOp{}}))
+				statePath = statePath_
+				return mockValidator
+			}
+
+			opts.Args = bicmd.StateValidateArgs{Manifest: bicmd.FileBytesWithPathArg{Path: manifestPath}}
+			opts.VarFlags = bicmd.VarFlags{VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}}}
+			opts.OpsFlags = bicmd.OpsFlags{OpsFiles: []bicmd.OpsFileArg{{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})}}}
+
+			return bicmd.NewStateValidateCmd(fakeUI, envProvider).Run(fakeStage, opts)
+		}
+
+		It("validates deployment state", func() {
+			mockValidator.EXPECT().ValidateDeploymentState(fakeStage).Return(nil)
+
+			err := act(bicmd.StateValidateOpts{StatePath: "/state.json"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statePath).To(Equal("/state.json"))
+		})
+
+		It("returns error if validation fails", func() {
+			mockValidator.EXPECT().ValidateDeploymentState(fakeStage).Return(bosherr.Error("fake-err"))
+
+			err := act(bicmd.StateValidateOpts{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-err"))
+		})
+	})
+})
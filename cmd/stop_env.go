@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type StopEnvCmd struct {
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) DeploymentStopper
+	lockProvider func(string, string) biconfig.DeploymentStateLock
+}
+
+func NewStopEnvCmd(
+	ui boshui.UI,
+	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentStopper,
+	lockProvider func(string, string) biconfig.DeploymentStateLock,
+) *StopEnvCmd {
+	return &StopEnvCmd{ui: ui, envProvider: envProvider, lockProvider: lockProvider}
+}
+
+func (c *StopEnvCmd) Run(stage boshui.Stage, opts StopEnvOpts) error {
+	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
+
+	lock := c.lockProvider(opts.Args.Manifest.Path, opts.StatePath)
+
+	return withDeploymentStateLock(c.ui, lock, opts.ForceUnlock, func() error {
+		depStopper := c.envProvider(
+			opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+		return depStopper.StopDeployment(opts.DeleteVM, stage)
+	})
+}
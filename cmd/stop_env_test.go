@@ -0,0 +1,104 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+
+	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+var _ = Describe("StopEnvCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			mockDeploymentStopper *mock_cmd.MockDeploymentStopper
+			fakeUI                *fakeui.FakeUI
+			fakeStage             *fakeui.FakeStage
+			fakeLock              *fakebiconfig.FakeDeploymentStateLock
+
+			manifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+			statePath    string
+			lockedPath   string
+		)
+
+		BeforeEach(func() {
+			mockDeploymentStopper = mock_cmd.NewMockDeploymentStopper(mockCtrl)
+			fakeUI = &fakeui.FakeUI{}
+			fakeStage = fakeui.NewFakeStage()
+			fakeLock = fakebiconfig.NewFakeDeploymentStateLock()
+		})
+
+		act := func(opts bicmd.StopEnvOpts) error {
+			envProvider := func(manifestPath_ string, statePath_ string, vars boshtpl.Variables, op patch.Op) bicmd.DeploymentStopper {
+				Expect(manifestPath_).To(Equal(manifestPath))
+				Expect(vars).To(Equal(boshtpl.NewMultiVars([]boshtpl.Variables{boshtpl.StaticVariables{"key": "value"}})))
+				Expect(op).To(Equal(patch.Ops{patch.ErrOp{}}))
+				statePath = statePath_
+				return mockDeploymentStopper
+			}
+
+			lockProvider := func(manifestPath string, statePath string) biconfig.DeploymentStateLock {
+				lockedPath = statePath
+				return fakeLock
+			}
+
+			opts.Args = bicmd.StopEnvArgs{Manifest: bicmd.FileBytesWithPathArg{Path: manifestPath}}
+			opts.VarFlags = bicmd.VarFlags{VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}}}
+			opts.OpsFlags = bicmd.OpsFlags{OpsFiles: []bicmd.OpsFileArg{{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})}}}
+
+			return bicmd.NewStopEnvCmd(fakeUI, envProvider, lockProvider).Run(fakeStage, opts)
+		}
+
+		It("stops deployment while holding deployment state lock", func() {
+			mockDeploymentStopper.EXPECT().StopDeployment(false, fakeStage).Return(nil)
+
+			err := act(bicmd.StopEnvOpts{StatePath: "/state.json"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(statePath).To(Equal("/state.json"))
+			Expect(lockedPath).To(Equal("/state.json"))
+			Expect(fakeLock.LockCalled).To(BeTrue())
+			Expect(fakeLock.UnlockCalled).To(BeTrue())
+		})
+
+		It("requests VM deletion when --delete-vm is given", func() {
+			mockDeploymentStopper.EXPECT().StopDeployment(true, fakeStage).Return(nil)
+
+			err := act(bicmd.StopEnvOpts{DeleteVM: true})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if stopping deployment fails", func() {
+			mockDeploymentStopper.EXPECT().StopDeployment(false, fakeStage).Return(bosherr.Error("fake-err"))
+
+			err := act(bicmd.StopEnvOpts{})
+			Expect(err).To(Equal(bosherr.Error("fake-err")))
+			Expect(fakeLock.UnlockCalled).To(BeTrue())
+		})
+
+		It("does not stop deployment if deployment state is locked", func() {
+			fakeLock.LockErr = bosherr.Error("fake-lock-err")
+
+			err := act(bicmd.StopEnvOpts{})
+			Expect(err).To(Equal(fakeLock.LockErr))
+		})
+	})
+})
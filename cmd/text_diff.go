@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strings"
+)
+
+const textDiffContextLines = 3
+
+// NewTextDiff returns line based diff between two texts suitable for printing.
+// Unchanged lines further than few lines away from changes are omitted.
+func NewTextDiff(before, after string) Diff {
+	beforeLines := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	afterLines := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(beforeLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(afterLines)+1)
+	}
+
+	for i := len(beforeLines) - 1; i >= 0; i-- {
+		for j := len(afterLines) - 1; j >= 0; j-- {
+			if beforeLines[i] == afterLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines [][]interface{}
+
+	i, j := 0, 0
+
+	for i < len(beforeLines) || j < len(afterLines) {
+		switch {
+		case i < len(beforeLines) && j < len(afterLines) && beforeLines[i] == afterLines[j]:
+			lines = append(lines, []interface{}{beforeLines[i], nil})
+			i++
+			j++
+		case i < len(beforeLines) && (j == len(afterLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, []interface{}{beforeLines[i], "removed"})
+			i++
+		default:
+			lines = append(lines, []interface{}{afterLines[j], "added"})
+			j++
+		}
+	}
+
+	return NewDiff(textDiffWithContext(lines))
+}
+
+func textDiffWithContext(lines [][]interface{}) [][]interface{} {
+	keep := make([]bool, len(lines))
+
+	for i, line := range lines {
+		if line[1] == nil {
+			continue
+		}
+
+		for j := i - textDiffContextLines; j <= i+textDiffContextLines; j++ {
+			if j >= 0 && j < len(lines) {
+				keep[j] = true
+			}
+		}
+	}
+
+	var result [][]interface{}
+
+	for i, line := range lines {
+		if keep[i] {
+			result = append(result, line)
+		} else if i == 0 || keep[i-1] {
+			result = append(result, []interface{}{"...", nil})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,136 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	fakedir "github.com/cloudfoundry/bosh-cli/director/directorfakes"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("UpdateConfigCmd", func() {
+	var (
+		ui       *fakeui.FakeUI
+		director *fakedir.FakeDirector
+		command  UpdateConfigCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		director = &fakedir.FakeDirector{}
+		command = NewUpdateConfigCmd(ui, director)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts UpdateConfigOpts
+		)
+
+		BeforeEach(func() {
+			opts = UpdateConfigOpts{
+				Args: UpdateConfigArgs{
+					Config: FileBytesArg{Bytes: []byte("some: config")},
+				},
+				Type: "resurrection",
+				Name: "team-a",
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("updates config", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(1))
+
+			configType, name, bytes := director.UpdateConfigArgsForCall(0)
+			Expect(configType).To(Equal("resurrection"))
+			Expect(name).To(Equal("team-a"))
+			Expect(bytes).To(Equal([]byte("some: config\n")))
+		})
+
+		It("updates templated config", func() {
+			opts.Args.Config = FileBytesArg{
+				Bytes: []byte("name1: ((name1))\nname2: ((name2))"),
+			}
+
+			opts.VarKVs = []boshtpl.VarKV{
+				{Name: "name1", Value: "val1-from-kv"},
+			}
+
+			opts.VarsFiles = []boshtpl.VarsFileArg{
+				{Vars: boshtpl.StaticVariables(map[string]interface{}{"name1": "val1-from-file"})},
+				{Vars: boshtpl.StaticVariables(map[string]interface{}{"name2": "val2-from-file"})},
+			}
+
+			opts.OpsFiles = []OpsFileArg{
+				{
+					Ops: patch.Ops([]patch.Op{
+						patch.ReplaceOp{Path: patch.MustNewPointerFromString("/xyz?"), Value: "val"},
+					}),
+				},
+			}
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(1))
+
+			_, _, bytes := director.UpdateConfigArgsForCall(0)
+			Expect(bytes).To(Equal([]byte("name1: val1-from-kv\nname2: val2-from-file\nxyz: val\n")))
+		})
+
+		It("prints diff against the current config of same type and name", func() {
+			director.DiffConfigReturns(boshdir.NewConfigDiff([][]interface{}{
+				[]interface{}{"some: config", "added"},
+			}), nil)
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(director.DiffConfigCallCount()).To(Equal(1))
+
+			configType, name, bytes := director.DiffConfigArgsForCall(0)
+			Expect(configType).To(Equal("resurrection"))
+			Expect(name).To(Equal("team-a"))
+			Expect(bytes).To(Equal([]byte("some: config\n")))
+
+			Expect(ui.Said).To(ContainElement("+ some: config\n"))
+		})
+
+		It("does not update if confirmation is rejected", func() {
+			ui.AskedConfirmationErr = errors.New("stop")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("stop"))
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(0))
+		})
+
+		It("returns error if diffing failed", func() {
+			director.DiffConfigReturns(boshdir.ConfigDiff{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(director.UpdateConfigCallCount()).To(Equal(0))
+		})
+
+		It("returns error if updating failed", func() {
+			director.UpdateConfigReturns(boshdir.TypedConfig{}, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
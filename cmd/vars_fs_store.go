@@ -14,6 +14,9 @@ type VarsFSStore struct {
 
 	ValueGeneratorFactory cfgtypes.ValueGeneratorFactory
 
+	// Cipher, if set, encrypts saved variables; encrypted files cannot be loaded without it
+	Cipher VarsFSStoreCipher
+
 	path string
 }
 
@@ -92,6 +95,18 @@ func (s VarsFSStore) load() (boshtpl.StaticVariables, error) {
 			return vars, err
 		}
 
+		if IsEncryptedVars(bytes) {
+			if s.Cipher == nil {
+				return vars, bosherr.Errorf("Expected passphrase or key file to be configured via "+
+					"%s or %s to decrypt variables file store '%s'", varsStorePassphraseEnv, varsStoreKeyFileEnv, s.path)
+			}
+
+			bytes, err = s.Cipher.Decrypt(bytes)
+			if err != nil {
+				return vars, bosherr.WrapErrorf(err, "Decrypting variables file store '%s'", s.path)
+			}
+		}
+
 		err = yaml.Unmarshal(bytes, &vars)
 		if err != nil {
 			return vars, bosherr.WrapErrorf(err, "Deserializing variables file store '%s'", s.path)
@@ -107,6 +122,13 @@ func (s VarsFSStore) save(vars boshtpl.StaticVariables) error {
 		return bosherr.WrapErrorf(err, "Serializing variables")
 	}
 
+	if s.Cipher != nil {
+		bytes, err = s.Cipher.Encrypt(bytes)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Encrypting variables")
+		}
+	}
+
 	err = s.FS.WriteFile(s.path, bytes)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Writing variables to file store '%s'", s.path)
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	varsStorePassphraseEnv = "BOSH_VARS_STORE_PASSPHRASE"
+	varsStoreKeyFileEnv    = "BOSH_VARS_STORE_KEY_FILE"
+
+	encryptedVarsHeader = "# bosh-vars-store: encrypted v1\n"
+
+	encryptedVarsSaltLen  = 16
+	encryptedVarsNonceLen = 24
+	encryptedVarsKeyLen   = 32
+)
+
+type VarsFSStoreCipher interface {
+	Encrypt([]byte) ([]byte, error)
+	Decrypt([]byte) ([]byte, error)
+}
+
+// SecretBoxVarsCipher encrypts variables with NaCl secretbox
+// using a key derived from a passphrase via scrypt.
+// Encrypted contents are stored as a header line followed by
+// base64 encoded salt, nonce and sealed box.
+type SecretBoxVarsCipher struct {
+	passphrase []byte
+	randReader io.Reader
+
+	salt []byte
+	keys map[string]*[encryptedVarsKeyLen]byte
+}
+
+func NewSecretBoxVarsCipher(passphrase []byte) *SecretBoxVarsCipher {
+	return NewSecretBoxVarsCipherWithRand(passphrase, rand.Reader)
+}
+
+func NewSecretBoxVarsCipherWithRand(passphrase []byte, randReader io.Reader) *SecretBoxVarsCipher {
+	return &SecretBoxVarsCipher{
+		passphrase: passphrase,
+		randReader: randReader,
+		keys:       map[string]*[encryptedVarsKeyLen]byte{},
+	}
+}
+
+// IsEncryptedVars returns true if contents were produced by SecretBoxVarsCipher.
+func IsEncryptedVars(contents []byte) bool {
+	return bytes.HasPrefix(contents, []byte(encryptedVarsHeader))
+}
+
+func (c *SecretBoxVarsCipher) Encrypt(plain []byte) ([]byte, error) {
+	if c.salt == nil {
+		salt := make([]byte, encryptedVarsSaltLen)
+
+		_, err := io.ReadFull(c.randReader, salt)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Generating salt")
+		}
+
+		c.salt = salt
+	}
+
+	key, err := c.key(c.salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [encryptedVarsNonceLen]byte
+
+	_, err = io.ReadFull(c.randReader, nonce[:])
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating nonce")
+	}
+
+	payload := append([]byte{}, c.salt...)
+	payload = append(payload, nonce[:]...)
+	payload = secretbox.Seal(payload, plain, &nonce, key)
+
+	encoded := base64.StdEncoding.EncodeToString(payload)
+
+	return []byte(encryptedVarsHeader + encoded + "\n"), nil
+}
+
+func (c *SecretBoxVarsCipher) Decrypt(contents []byte) ([]byte, error) {
+	if !IsEncryptedVars(contents) {
+		return nil, bosherr.Error("Expected contents to be encrypted")
+	}
+
+	encoded := strings.TrimSpace(strings.TrimPrefix(string(contents), encryptedVarsHeader))
+
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Decoding encrypted contents")
+	}
+
+	if len(payload) < encryptedVarsSaltLen+encryptedVarsNonceLen+secretbox.Overhead {
+		return nil, bosherr.Error("Expected encrypted contents to include salt, nonce and data")
+	}
+
+	salt := payload[:encryptedVarsSaltLen]
+
+	var nonce [encryptedVarsNonceLen]byte
+	copy(nonce[:], payload[encryptedVarsSaltLen:encryptedVarsSaltLen+encryptedVarsNonceLen])
+
+	key, err := c.key(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, ok := secretbox.Open(nil, payload[encryptedVarsSaltLen+encryptedVarsNonceLen:], &nonce, key)
+	if !ok {
+		return nil, bosherr.Error("Decrypting contents: passphrase or key file does not match")
+	}
+
+	// Keep using the same salt so that subsequent saves do not re-derive the key
+	if c.salt == nil {
+		c.salt = append([]byte{}, salt...)
+	}
+
+	return plain, nil
+}
+
+func (c *SecretBoxVarsCipher) key(salt []byte) (*[encryptedVarsKeyLen]byte, error) {
+	if key, found := c.keys[string(salt)]; found {
+		return key, nil
+	}
+
+	derived, err := scrypt.Key(c.passphrase, salt, 1<<15, 8, 1, encryptedVarsKeyLen)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Deriving encryption key")
+	}
+
+	var key [encryptedVarsKeyLen]byte
+	copy(key[:], derived)
+
+	c.keys[string(salt)] = &key
+
+	return &key, nil
+}
+
+// NewVarsFSStoreCipherFromEnv returns a cipher configured via
+// BOSH_VARS_STORE_PASSPHRASE or BOSH_VARS_STORE_KEY_FILE, or nil if neither is set.
+func NewVarsFSStoreCipherFromEnv(getenv func(string) string, fs boshsys.FileSystem) (VarsFSStoreCipher, error) {
+	flags := VarsStoreKeyFlags{
+		Passphrase: getenv(varsStorePassphraseEnv),
+		KeyFile:    getenv(varsStoreKeyFileEnv),
+	}
+
+	return flags.cipher(fs)
+}
@@ -0,0 +1,141 @@
+package cmd_test
+
+import (
+	"errors"
+	"strings"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+type erroringReader struct{}
+
+func (erroringReader) Read([]byte) (int, error) { return 0, errors.New("fake-err") }
+
+var _ = Describe("SecretBoxVarsCipher", func() {
+	var (
+		cipher *SecretBoxVarsCipher
+	)
+
+	BeforeEach(func() {
+		cipher = NewSecretBoxVarsCipher([]byte("passphrase"))
+	})
+
+	It("encrypts contents so that they can be decrypted with the same passphrase", func() {
+		encrypted, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(IsEncryptedVars(encrypted)).To(BeTrue())
+		Expect(string(encrypted)).ToNot(ContainSubstring("val"))
+
+		plain, err := NewSecretBoxVarsCipher([]byte("passphrase")).Decrypt(encrypted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(plain)).To(Equal("key: val\n"))
+	})
+
+	It("uses a different nonce for each encryption", func() {
+		encrypted1, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		encrypted2, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(encrypted1).ToNot(Equal(encrypted2))
+	})
+
+	It("returns error if passphrase does not match", func() {
+		encrypted, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewSecretBoxVarsCipher([]byte("other")).Decrypt(encrypted)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Decrypting contents: passphrase or key file does not match"))
+	})
+
+	It("returns error if contents are not encrypted", func() {
+		_, err := cipher.Decrypt([]byte("key: val\n"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected contents to be encrypted"))
+	})
+
+	It("returns error if encrypted contents are truncated", func() {
+		encrypted, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		lines := strings.SplitN(string(encrypted), "\n", 2)
+
+		_, err = cipher.Decrypt([]byte(lines[0] + "\nYWJj\n"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected encrypted contents to include salt, nonce and data"))
+	})
+
+	It("returns error if random data cannot be generated", func() {
+		cipher = NewSecretBoxVarsCipherWithRand([]byte("passphrase"), erroringReader{})
+
+		_, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Generating salt: fake-err"))
+	})
+})
+
+var _ = Describe("NewVarsFSStoreCipherFromEnv", func() {
+	var (
+		fs  *fakesys.FakeFileSystem
+		env map[string]string
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		env = map[string]string{}
+	})
+
+	getenv := func(name string) string { return env[name] }
+
+	It("returns nil if neither passphrase nor key file is configured", func() {
+		cipher, err := NewVarsFSStoreCipherFromEnv(getenv, fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cipher).To(BeNil())
+	})
+
+	It("uses passphrase from key file", func() {
+		env["BOSH_VARS_STORE_KEY_FILE"] = "/key"
+		fs.WriteFileString("/key", "passphrase\n")
+
+		cipher, err := NewVarsFSStoreCipherFromEnv(getenv, fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		encrypted, err := cipher.Encrypt([]byte("key: val\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = NewSecretBoxVarsCipher([]byte("passphrase")).Decrypt(encrypted)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("prefers passphrase over key file", func() {
+		env["BOSH_VARS_STORE_PASSPHRASE"] = "passphrase"
+		env["BOSH_VARS_STORE_KEY_FILE"] = "/key"
+
+		cipher, err := NewVarsFSStoreCipherFromEnv(getenv, fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cipher).ToNot(BeNil())
+	})
+
+	It("returns error if key file cannot be read", func() {
+		env["BOSH_VARS_STORE_KEY_FILE"] = "/key"
+
+		_, err := NewVarsFSStoreCipherFromEnv(getenv, fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading vars store key file '/key'"))
+	})
+
+	It("returns error if key file is empty", func() {
+		env["BOSH_VARS_STORE_KEY_FILE"] = "/key"
+		fs.WriteFileString("/key", "\n")
+
+		_, err := NewVarsFSStoreCipherFromEnv(getenv, fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected vars store key file '/key' to be non-empty"))
+	})
+})
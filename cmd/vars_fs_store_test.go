@@ -122,6 +122,75 @@ var _ = Describe("VarsFSStore", func() {
 		})
 	})
 
+	Context("when cipher is configured", func() {
+		BeforeEach(func() {
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			store.Cipher = NewSecretBoxVarsCipher([]byte("passphrase"))
+		})
+
+		It("saves generated variables encrypted and loads them back", func() {
+			val, found, err := store.Get(boshtpl.VariableDefinition{Name: "key", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			contents, err := fs.ReadFile("/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsEncryptedVars(contents)).To(BeTrue())
+			Expect(string(contents)).ToNot(ContainSubstring(val.(string)))
+
+			store.Cipher = NewSecretBoxVarsCipher([]byte("passphrase"))
+
+			loadedVal, found, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(loadedVal).To(Equal(val))
+		})
+
+		It("loads plain file and encrypts it on next save", func() {
+			fs.WriteFileString("/file", "key: val")
+
+			val, found, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal("val"))
+
+			_, _, err = store.Get(boshtpl.VariableDefinition{Name: "key2", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := fs.ReadFile("/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsEncryptedVars(contents)).To(BeTrue())
+		})
+
+		It("returns error if passphrase does not match", func() {
+			_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+
+			store.Cipher = NewSecretBoxVarsCipher([]byte("other-passphrase"))
+
+			_, _, err = store.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting variables file store '/file'"))
+		})
+	})
+
+	It("returns error if file is encrypted but cipher is not configured", func() {
+		err := (&store).UnmarshalFlag("/file")
+		Expect(err).ToNot(HaveOccurred())
+
+		encrypted, err := NewSecretBoxVarsCipher([]byte("passphrase")).Encrypt([]byte("key: val"))
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.WriteFile("/file", encrypted)
+
+		_, _, err = store.Get(boshtpl.VariableDefinition{Name: "key"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected passphrase or key file to be configured via " +
+			"BOSH_VARS_STORE_PASSPHRASE or BOSH_VARS_STORE_KEY_FILE to decrypt variables file store '/file'"))
+	})
+
 	Describe("List", func() {
 		BeforeEach(func() {
 			err := (&store).UnmarshalFlag("/file")
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"os"
+	"strings"
+	"time"
+
+	gourl "net/url"
+
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttp "github.com/cloudfoundry/bosh-utils/http"
+	boshhttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	cfgtypes "github.com/cloudfoundry/config-server/types"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshuaa "github.com/cloudfoundry/bosh-cli/uaa"
+)
+
+const credHubVarsStoreScheme = "credhub"
+
+// VarsStore loads/saves variables from/to either a local YAML file
+// or a credential manager when given a credhub://host:port/namespace URL.
+// Credential manager client credentials and CA certificate
+// are taken from CREDHUB_CLIENT, CREDHUB_SECRET and CREDHUB_CA_CERT.
+// YAML file is encrypted if BOSH_VARS_STORE_PASSPHRASE or BOSH_VARS_STORE_KEY_FILE is set.
+type VarsStore struct {
+	FS     boshsys.FileSystem
+	Logger boshlog.Logger
+
+	GetenvFunc func(string) string
+
+	fsStore    *VarsFSStore
+	credHubURL *gourl.URL
+	credHub    boshtpl.Variables
+}
+
+var _ boshtpl.Variables = &VarsStore{}
+
+func (s VarsStore) IsSet() bool { return s.fsStore != nil || s.credHubURL != nil }
+
+func (s *VarsStore) Get(varDef boshtpl.VariableDefinition) (interface{}, bool, error) {
+	vars, err := s.vars()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return vars.Get(varDef)
+}
+
+func (s *VarsStore) List() ([]boshtpl.VariableDefinition, error) {
+	vars, err := s.vars()
+	if err != nil {
+		return nil, err
+	}
+
+	return vars.List()
+}
+
+// UseValueGeneratorFactory configures how file based store generates values;
+// credential manager generates values itself.
+func (s *VarsStore) UseValueGeneratorFactory(factory cfgtypes.ValueGeneratorFactory) {
+	if s.fsStore != nil {
+		s.fsStore.ValueGeneratorFactory = factory
+	}
+}
+
+func (s *VarsStore) vars() (boshtpl.Variables, error) {
+	if s.fsStore != nil {
+		return s.fsStore, nil
+	}
+
+	if s.credHub != nil {
+		return s.credHub, nil
+	}
+
+	if s.credHubURL == nil {
+		return nil, bosherr.Error("Expected vars store to be configured")
+	}
+
+	logger := s.Logger
+	if logger == nil {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	}
+
+	credHub, err := s.buildCredHub(logger)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Connecting to credential manager '%s'", s.credHubURL.Host)
+	}
+
+	s.credHub = credHub
+
+	return credHub, nil
+}
+
+func (s *VarsStore) buildCredHub(logger boshlog.Logger) (boshtpl.Variables, error) {
+	caCert, err := s.caCert()
+	if err != nil {
+		return nil, err
+	}
+
+	var certPool *x509.CertPool
+
+	if len(caCert) > 0 {
+		certPool, err = crypto.CertPoolFromPEM([]byte(caCert))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing CA certificate")
+		}
+	}
+
+	rawClient := boshhttpclient.CreateDefaultClient(certPool)
+	retryClient := boshhttp.NewNetworkSafeRetryClient(rawClient, 5, 500*time.Millisecond, logger)
+	httpClient := boshhttpclient.NewHTTPClient(retryClient, logger)
+
+	endpoint := "https://" + s.credHubURL.Host
+	namespace := s.credHubURL.Path
+
+	authServerURL, err := boshtpl.NewCredHubVariables(endpoint, namespace, nil, httpClient).AuthServerURL()
+	if err != nil {
+		return nil, err
+	}
+
+	uaaConfig, err := boshuaa.NewConfigFromURL(authServerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	uaaConfig.Client = s.getenv("CREDHUB_CLIENT")
+	uaaConfig.ClientSecret = s.getenv("CREDHUB_SECRET")
+	uaaConfig.CACert = caCert
+
+	uaa, err := boshuaa.NewFactory(logger).New(uaaConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenFunc := boshuaa.NewClientTokenSession(uaa).TokenFunc
+
+	return boshtpl.NewCredHubVariables(endpoint, namespace, tokenFunc, httpClient), nil
+}
+
+func (s VarsStore) caCert() (string, error) {
+	caCert := s.getenv("CREDHUB_CA_CERT")
+	if len(caCert) == 0 {
+		return "", nil
+	}
+
+	arg := CACertArg{FS: s.FS}
+
+	err := (&arg).UnmarshalFlag(caCert)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading CREDHUB_CA_CERT")
+	}
+
+	return arg.Content, nil
+}
+
+func (s VarsStore) getenv(name string) string {
+	if s.GetenvFunc == nil {
+		return os.Getenv(name)
+	}
+
+	return s.GetenvFunc(name)
+}
+
+func (s *VarsStore) UnmarshalFlag(data string) error {
+	if strings.HasPrefix(data, credHubVarsStoreScheme+"://") {
+		parsedURL, err := gourl.Parse(data)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing credential manager URL '%s'", data)
+		}
+
+		if len(parsedURL.Host) == 0 {
+			return bosherr.Errorf("Expected credential manager URL '%s' to include host", data)
+		}
+
+		(*s).credHubURL = parsedURL
+
+		return nil
+	}
+
+	fsStore := &VarsFSStore{FS: s.FS}
+
+	err := fsStore.UnmarshalFlag(data)
+	if err != nil {
+		return err
+	}
+
+	fsStore.Cipher, err = NewVarsFSStoreCipherFromEnv(s.getenv, s.FS)
+	if err != nil {
+		return err
+	}
+
+	(*s).fsStore = fsStore
+
+	return nil
+}
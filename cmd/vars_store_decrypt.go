@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type VarsStoreDecryptCmd struct {
+	ui boshui.UI
+	fs boshsys.FileSystem
+}
+
+func NewVarsStoreDecryptCmd(ui boshui.UI, fs boshsys.FileSystem) VarsStoreDecryptCmd {
+	return VarsStoreDecryptCmd{ui: ui, fs: fs}
+}
+
+func (c VarsStoreDecryptCmd) Run(opts VarsStoreDecryptOpts) error {
+	path := opts.Args.Path.ExpandedPath
+
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading variables file store '%s'", path)
+	}
+
+	if !IsEncryptedVars(contents) {
+		return bosherr.Errorf("Expected variables file store '%s' to be encrypted", path)
+	}
+
+	plain, err := readVarsFSStore(c.fs, path, opts.VarsStoreKeyFlags)
+	if err != nil {
+		return err
+	}
+
+	if !opts.InPlace {
+		c.ui.PrintBlock(string(plain))
+		return nil
+	}
+
+	err = c.fs.WriteFile(path, plain)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing variables to file store '%s'", path)
+	}
+
+	return nil
+}
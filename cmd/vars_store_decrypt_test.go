@@ -0,0 +1,91 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("VarsStoreDecryptCmd", func() {
+	var (
+		ui      *fakeui.FakeUI
+		fs      *fakesys.FakeFileSystem
+		command VarsStoreDecryptCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+		command = NewVarsStoreDecryptCmd(ui, fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts VarsStoreDecryptOpts
+		)
+
+		BeforeEach(func() {
+			opts = VarsStoreDecryptOpts{
+				Args:              VarsStoreArgs{Path: FileArg{ExpandedPath: "/file"}},
+				VarsStoreKeyFlags: VarsStoreKeyFlags{Passphrase: "passphrase"},
+			}
+
+			encrypted, err := NewSecretBoxVarsCipher([]byte("passphrase")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/file", encrypted)
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("shows decrypted variables", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Blocks).To(Equal([]string{"key: val\n"}))
+
+			contents, err := fs.ReadFile("/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsEncryptedVars(contents)).To(BeTrue())
+		})
+
+		It("overwrites file with decrypted variables if requested", func() {
+			opts.InPlace = true
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ui.Blocks).To(BeEmpty())
+			Expect(fs.ReadFileString("/file")).To(Equal("key: val\n"))
+		})
+
+		It("returns error if file is not encrypted", func() {
+			fs.WriteFileString("/file", "key: val\n")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected variables file store '/file' to be encrypted"))
+		})
+
+		It("returns error if passphrase does not match", func() {
+			opts.Passphrase = "wrong"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting variables file store '/file'"))
+			Expect(ui.Blocks).To(BeEmpty())
+		})
+
+		It("returns error if writing file fails", func() {
+			opts.InPlace = true
+			fs.WriteFileError = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
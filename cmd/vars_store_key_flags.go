@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type VarsStoreKeyFlags struct {
+	Passphrase string `long:"passphrase" value-name:"PASSPHRASE" description:"Passphrase used to encrypt vars store" env:"BOSH_VARS_STORE_PASSPHRASE"`
+	KeyFile    string `long:"key-file"   value-name:"PATH"       description:"Path to a file with passphrase used to encrypt vars store" env:"BOSH_VARS_STORE_KEY_FILE"`
+}
+
+func (f VarsStoreKeyFlags) IsSet() bool {
+	return len(f.Passphrase) > 0 || len(f.KeyFile) > 0
+}
+
+// passphrase returns configured passphrase preferring explicit passphrase over key file;
+// nil is returned when neither is set.
+func (f VarsStoreKeyFlags) passphrase(fs boshsys.FileSystem) ([]byte, error) {
+	if len(f.Passphrase) > 0 {
+		return []byte(f.Passphrase), nil
+	}
+
+	if len(f.KeyFile) == 0 {
+		return nil, nil
+	}
+
+	absPath, err := fs.ExpandPath(f.KeyFile)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting absolute path '%s'", f.KeyFile)
+	}
+
+	contents, err := fs.ReadFileString(absPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading vars store key file '%s'", absPath)
+	}
+
+	passphrase := strings.TrimSpace(contents)
+	if len(passphrase) == 0 {
+		return nil, bosherr.Errorf("Expected vars store key file '%s' to be non-empty", absPath)
+	}
+
+	return []byte(passphrase), nil
+}
+
+func (f VarsStoreKeyFlags) cipher(fs boshsys.FileSystem) (VarsFSStoreCipher, error) {
+	passphrase, err := f.passphrase(fs)
+	if err != nil || passphrase == nil {
+		return nil, err
+	}
+
+	return NewSecretBoxVarsCipher(passphrase), nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+type VarsStoreRekeyCmd struct {
+	fs boshsys.FileSystem
+}
+
+func NewVarsStoreRekeyCmd(fs boshsys.FileSystem) VarsStoreRekeyCmd {
+	return VarsStoreRekeyCmd{fs: fs}
+}
+
+func (c VarsStoreRekeyCmd) Run(opts VarsStoreRekeyOpts) error {
+	path := opts.Args.Path.ExpandedPath
+
+	newKeyFlags := VarsStoreKeyFlags{Passphrase: opts.NewPassphrase, KeyFile: opts.NewKeyFile}
+
+	newCipher, err := newKeyFlags.cipher(c.fs)
+	if err != nil {
+		return err
+	}
+
+	if newCipher == nil {
+		return bosherr.Error("Expected new passphrase or new key file to be specified")
+	}
+
+	plain, err := readVarsFSStore(c.fs, path, opts.VarsStoreKeyFlags)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := newCipher.Encrypt(plain)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Encrypting variables file store '%s'", path)
+	}
+
+	err = c.fs.WriteFile(path, encrypted)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing variables to file store '%s'", path)
+	}
+
+	return nil
+}
+
+// readVarsFSStore returns plain contents of a variables file store
+// after making sure that they are valid variables.
+func readVarsFSStore(fs boshsys.FileSystem, path string, keyFlags VarsStoreKeyFlags) ([]byte, error) {
+	contents, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading variables file store '%s'", path)
+	}
+
+	if IsEncryptedVars(contents) {
+		cipher, err := keyFlags.cipher(fs)
+		if err != nil {
+			return nil, err
+		}
+
+		if cipher == nil {
+			return nil, bosherr.Errorf("Expected passphrase or key file to be specified to decrypt variables file store '%s'", path)
+		}
+
+		contents, err = cipher.Decrypt(contents)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Decrypting variables file store '%s'", path)
+		}
+	}
+
+	var vars boshtpl.StaticVariables
+
+	err = yaml.Unmarshal(contents, &vars)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Deserializing variables file store '%s'", path)
+	}
+
+	return contents, nil
+}
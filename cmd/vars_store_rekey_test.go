@@ -0,0 +1,122 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("VarsStoreRekeyCmd", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		command VarsStoreRekeyCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		command = NewVarsStoreRekeyCmd(fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts VarsStoreRekeyOpts
+		)
+
+		BeforeEach(func() {
+			opts = VarsStoreRekeyOpts{
+				Args:          VarsStoreArgs{Path: FileArg{ExpandedPath: "/file"}},
+				NewPassphrase: "new-passphrase",
+			}
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		decrypt := func(passphrase string) string {
+			contents, err := fs.ReadFile("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			plain, err := NewSecretBoxVarsCipher([]byte(passphrase)).Decrypt(contents)
+			Expect(err).ToNot(HaveOccurred())
+
+			return string(plain)
+		}
+
+		It("re-encrypts encrypted file with new passphrase", func() {
+			encrypted, err := NewSecretBoxVarsCipher([]byte("passphrase")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/file", encrypted)
+
+			opts.Passphrase = "passphrase"
+
+			err = act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypt("new-passphrase")).To(Equal("key: val\n"))
+		})
+
+		It("encrypts plain file with new passphrase from key file", func() {
+			fs.WriteFileString("/file", "key: val\n")
+			fs.WriteFileString("/new-key", "new-passphrase\n")
+
+			opts.NewPassphrase = ""
+			opts.NewKeyFile = "/new-key"
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypt("new-passphrase")).To(Equal("key: val\n"))
+		})
+
+		It("returns error if new passphrase is not specified", func() {
+			fs.WriteFileString("/file", "key: val\n")
+
+			opts.NewPassphrase = ""
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected new passphrase or new key file to be specified"))
+			Expect(fs.ReadFileString("/file")).To(Equal("key: val\n"))
+		})
+
+		It("returns error if current passphrase is not specified for encrypted file", func() {
+			encrypted, err := NewSecretBoxVarsCipher([]byte("passphrase")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/file", encrypted)
+
+			err = act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Expected passphrase or key file to be specified to decrypt variables file store '/file'"))
+		})
+
+		It("returns error if current passphrase does not match", func() {
+			encrypted, err := NewSecretBoxVarsCipher([]byte("passphrase")).Encrypt([]byte("key: val\n"))
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFile("/file", encrypted)
+
+			opts.Passphrase = "wrong"
+
+			err = act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decrypting variables file store '/file'"))
+		})
+
+		It("returns error if file does not contain variables", func() {
+			fs.WriteFileString("/file", "content")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Deserializing variables file store '/file'"))
+			Expect(fs.ReadFileString("/file")).To(Equal("content"))
+		})
+
+		It("returns error if file cannot be read", func() {
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading variables file store '/file'"))
+		})
+	})
+})
@@ -0,0 +1,126 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("VarsStore", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		store VarsStore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		store = VarsStore{FS: fs}
+	})
+
+	Describe("IsSet", func() {
+		It("returns false if not configured", func() {
+			Expect(store.IsSet()).To(BeFalse())
+		})
+
+		It("returns true if configured with a file path", func() {
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.IsSet()).To(BeTrue())
+		})
+
+		It("returns true if configured with a credential manager URL", func() {
+			err := (&store).UnmarshalFlag("credhub://host:8844/namespace")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.IsSet()).To(BeTrue())
+		})
+	})
+
+	Describe("Get/List", func() {
+		It("uses file store when configured with a file path", func() {
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString("/file", "key: val")
+
+			val, found, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal("val"))
+
+			defs, err := store.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(defs).To(Equal([]boshtpl.VariableDefinition{{Name: "key"}}))
+		})
+
+		It("encrypts file store when passphrase is configured", func() {
+			store.GetenvFunc = func(name string) string {
+				if name == "BOSH_VARS_STORE_PASSPHRASE" {
+					return "passphrase"
+				}
+				return ""
+			}
+
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found, err := store.Get(boshtpl.VariableDefinition{Name: "key", Type: "password"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			contents, err := fs.ReadFile("/file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsEncryptedVars(contents)).To(BeTrue())
+		})
+
+		It("returns error if not configured", func() {
+			_, _, err := store.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected vars store to be configured"))
+		})
+
+		It("returns error if credential manager CA certificate cannot be read", func() {
+			store.GetenvFunc = func(name string) string {
+				if name == "CREDHUB_CA_CERT" {
+					return "/ca-cert"
+				}
+				return ""
+			}
+
+			err := (&store).UnmarshalFlag("credhub://host:8844/namespace")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = store.Get(boshtpl.VariableDefinition{Name: "key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Connecting to credential manager 'host:8844': Reading CREDHUB_CA_CERT"))
+		})
+	})
+
+	Describe("UnmarshalFlag", func() {
+		It("returns error if credential manager URL does not include host", func() {
+			err := (&store).UnmarshalFlag("credhub:///namespace")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected credential manager URL 'credhub:///namespace' to include host"))
+		})
+
+		It("returns error if vars store key file cannot be read", func() {
+			store.GetenvFunc = func(name string) string {
+				if name == "BOSH_VARS_STORE_KEY_FILE" {
+					return "/key"
+				}
+				return ""
+			}
+
+			err := (&store).UnmarshalFlag("/file")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading vars store key file '/key'"))
+		})
+
+		It("returns error if file path is empty", func() {
+			err := (&store).UnmarshalFlag("")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
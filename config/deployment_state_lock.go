@@ -0,0 +1,270 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Locks held longer than this are considered to be left behind by killed processes
+const DeploymentStateLockStaleAfter = 24 * time.Hour
+
+// DeploymentStateLock is an advisory lock that prevents concurrent
+// create-env/delete-env runs against the same deployment state
+type DeploymentStateLock interface {
+	Lock() error
+	Unlock() error
+	ForceUnlock() error
+}
+
+type DeploymentStateLockOwner struct {
+	User string `json:"user"`
+	Host string `json:"host"`
+	PID  int    `json:"pid"`
+}
+
+type DeploymentStateLockInfo struct {
+	DeploymentStateLockOwner
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DeploymentStateLockedError struct {
+	Path string
+	Info DeploymentStateLockInfo
+}
+
+func (e DeploymentStateLockedError) Error() string {
+	return fmt.Sprintf(
+		"Deployment state '%s' is locked by user '%s' (pid %d on host '%s') since %s. "+
+			"If nobody else is using this deployment state, re-run with --force-unlock",
+		e.Path, e.Info.User, e.Info.PID, e.Info.Host, e.Info.CreatedAt.Format(time.RFC3339))
+}
+
+// ProcessChecker determines whether process that holds a lock is still running
+type ProcessChecker interface {
+	Exists(pid int) bool
+}
+
+type deploymentStateLock struct {
+	path  string
+	key   string
+	store ObjectStore
+
+	owner          DeploymentStateLockOwner
+	processChecker ProcessChecker
+	timeService    clock.Clock
+
+	// contents of the lock object written by this process
+	acquired []byte
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewDeploymentStateLock(
+	store ObjectStore,
+	path string,
+	key string,
+	owner DeploymentStateLockOwner,
+	processChecker ProcessChecker,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) DeploymentStateLock {
+	return &deploymentStateLock{
+		path:  path,
+		key:   key,
+		store: store,
+
+		owner:          owner,
+		processChecker: processChecker,
+		timeService:    timeService,
+
+		logTag: "deploymentStateLock",
+		logger: logger,
+	}
+}
+
+// NewDeploymentStateLockForPath places lock next to the deployment state,
+// either as a local file or as an object in the same bucket
+func NewDeploymentStateLockForPath(
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+	deploymentStatePath string,
+) DeploymentStateLock {
+	store, key := deploymentStateObjectStore(fs, deploymentStatePath)
+
+	return NewDeploymentStateLock(
+		store, deploymentStatePath, key+".lock", CurrentDeploymentStateLockOwner(), OSProcessChecker{}, timeService, logger)
+}
+
+func (l *deploymentStateLock) Lock() error {
+	info := DeploymentStateLockInfo{
+		DeploymentStateLockOwner: l.owner,
+		CreatedAt:                l.timeService.Now().UTC(),
+	}
+
+	contents, err := json.Marshal(info)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state lock")
+	}
+
+	_, err = l.store.Put(l.key, contents, "")
+	if err == nil {
+		l.acquired = contents
+		return nil
+	}
+
+	if _, ok := err.(ObjectVersionMismatchError); !ok {
+		return bosherr.WrapErrorf(err, "Locking deployment state '%s'", l.path)
+	}
+
+	currInfo, currVersion, found, err := l.current()
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		// Lock was released after failing to acquire it
+		return l.Lock()
+	}
+
+	if !l.isStale(currInfo) {
+		return DeploymentStateLockedError{Path: l.path, Info: currInfo}
+	}
+
+	l.logger.Info(l.logTag, "Taking over stale lock for deployment state '%s' held by pid %d on host '%s'",
+		l.path, currInfo.PID, currInfo.Host)
+
+	_, err = l.store.Put(l.key, contents, currVersion)
+	if err != nil {
+		if _, ok := err.(ObjectVersionMismatchError); ok {
+			// Someone else took over stale lock first
+			currInfo, _, _, _ = l.current()
+			return DeploymentStateLockedError{Path: l.path, Info: currInfo}
+		}
+		return bosherr.WrapErrorf(err, "Locking deployment state '%s'", l.path)
+	}
+
+	l.acquired = contents
+
+	return nil
+}
+
+// Unlock releases lock only if it is still held by this process
+func (l *deploymentStateLock) Unlock() error {
+	if l.acquired == nil {
+		return nil
+	}
+
+	acquired := l.acquired
+	l.acquired = nil
+
+	contents, _, found, err := l.store.Get(l.key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unlocking deployment state '%s'", l.path)
+	}
+
+	// Lock could have been forcefully removed and acquired by someone else
+	if !found || string(contents) != string(acquired) {
+		return nil
+	}
+
+	err = l.store.Delete(l.key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unlocking deployment state '%s'", l.path)
+	}
+
+	return nil
+}
+
+func (l *deploymentStateLock) ForceUnlock() error {
+	err := l.store.Delete(l.key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Force unlocking deployment state '%s'", l.path)
+	}
+
+	return nil
+}
+
+func (l *deploymentStateLock) current() (DeploymentStateLockInfo, string, bool, error) {
+	var info DeploymentStateLockInfo
+
+	contents, version, found, err := l.store.Get(l.key)
+	if err != nil {
+		return info, "", false, bosherr.WrapErrorf(err, "Reading deployment state '%s' lock", l.path)
+	}
+
+	if !found {
+		return info, "", false, nil
+	}
+
+	err = json.Unmarshal(contents, &info)
+	if err != nil {
+		// Treat unreadable lock as stale so that it does not block forever
+		l.logger.Error(l.logTag, "Unmarshalling deployment state lock: %s", err)
+		return DeploymentStateLockInfo{}, version, true, nil
+	}
+
+	return info, version, true, nil
+}
+
+func (l *deploymentStateLock) isStale(info DeploymentStateLockInfo) bool {
+	if l.timeService.Now().Sub(info.CreatedAt) > DeploymentStateLockStaleAfter {
+		return true
+	}
+
+	return info.Host == l.owner.Host && !l.processChecker.Exists(info.PID)
+}
+
+func CurrentDeploymentStateLockOwner() DeploymentStateLockOwner {
+	owner := DeploymentStateLockOwner{
+		User: os.Getenv("USER"),
+		PID:  os.Getpid(),
+	}
+
+	if currUser, err := user.Current(); err == nil {
+		owner.User = currUser.Username
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		owner.Host = host
+	}
+
+	return owner
+}
+
+type OSProcessChecker struct{}
+
+func (OSProcessChecker) Exists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// Finding process on Windows fails if it does not exist
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	err = process.Signal(syscall.Signal(0))
+	if err == nil {
+		return true
+	}
+
+	// Signalling existing process that belongs to another user is not permitted
+	return err == syscall.EPERM
+}
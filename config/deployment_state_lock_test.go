@@ -0,0 +1,206 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	fakeconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+)
+
+type fakeProcessChecker struct {
+	ExistingPIDs map[int]bool
+}
+
+func (c fakeProcessChecker) Exists(pid int) bool { return c.ExistingPIDs[pid] }
+
+var _ = Describe("DeploymentStateLock", func() {
+	var (
+		store          *fakeconfig.FakeObjectStore
+		processChecker fakeProcessChecker
+		timeService    *fakeclock.FakeClock
+		logger         boshlog.Logger
+		now            time.Time
+		owner          DeploymentStateLockOwner
+		lock           DeploymentStateLock
+	)
+
+	newLock := func(owner DeploymentStateLockOwner) DeploymentStateLock {
+		return NewDeploymentStateLock(store, "/state.json", "/state.json.lock", owner, processChecker, timeService, logger)
+	}
+
+	writeLock := func(info DeploymentStateLockInfo) {
+		bytes, err := json.Marshal(info)
+		Expect(err).ToNot(HaveOccurred())
+
+		store.Objects["/state.json.lock"] = bytes
+		store.Versions["/state.json.lock"] = "other-version"
+	}
+
+	BeforeEach(func() {
+		store = fakeconfig.NewFakeObjectStore()
+		processChecker = fakeProcessChecker{ExistingPIDs: map[int]bool{}}
+		now = time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+		timeService = fakeclock.NewFakeClock(now)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		owner = DeploymentStateLockOwner{User: "alice", Host: "host-a", PID: 100}
+		lock = newLock(owner)
+	})
+
+	Describe("Lock", func() {
+		It("writes lock with owner details", func() {
+			err := lock.Lock()
+			Expect(err).ToNot(HaveOccurred())
+
+			var info DeploymentStateLockInfo
+
+			err = json.Unmarshal(store.Objects["/state.json.lock"], &info)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info).To(Equal(DeploymentStateLockInfo{DeploymentStateLockOwner: owner, CreatedAt: now}))
+		})
+
+		It("returns error naming lock owner if lock is held by running process", func() {
+			writeLock(DeploymentStateLockInfo{
+				DeploymentStateLockOwner: DeploymentStateLockOwner{User: "bob", Host: "host-b", PID: 200},
+				CreatedAt:                now.Add(-time.Hour),
+			})
+
+			err := lock.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"Deployment state '/state.json' is locked by user 'bob' (pid 200 on host 'host-b') since 2017-03-01T09:00:00Z. " +
+					"If nobody else is using this deployment state, re-run with --force-unlock"))
+		})
+
+		It("returns error if lock is held by running process on the same host", func() {
+			processChecker.ExistingPIDs[200] = true
+
+			writeLock(DeploymentStateLockInfo{
+				DeploymentStateLockOwner: DeploymentStateLockOwner{User: "alice", Host: "host-a", PID: 200},
+				CreatedAt:                now,
+			})
+
+			err := lock.Lock()
+			Expect(err).To(BeAssignableToTypeOf(DeploymentStateLockedError{}))
+		})
+
+		It("takes over lock held by process that no longer exists on the same host", func() {
+			writeLock(DeploymentStateLockInfo{
+				DeploymentStateLockOwner: DeploymentStateLockOwner{User: "alice", Host: "host-a", PID: 200},
+				CreatedAt:                now,
+			})
+
+			err := lock.Lock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(store.Objects["/state.json.lock"])).To(ContainSubstring(`"pid":100`))
+		})
+
+		It("takes over lock that is held for too long", func() {
+			writeLock(DeploymentStateLockInfo{
+				DeploymentStateLockOwner: DeploymentStateLockOwner{User: "bob", Host: "host-b", PID: 200},
+				CreatedAt:                now.Add(-DeploymentStateLockStaleAfter - time.Minute),
+			})
+
+			err := lock.Lock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(store.Objects["/state.json.lock"])).To(ContainSubstring(`"user":"alice"`))
+		})
+
+		It("returns error if lock cannot be written", func() {
+			store.PutErr = errors.New("fake-err")
+
+			err := lock.Lock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("Unlock", func() {
+		It("removes lock acquired by this process", func() {
+			Expect(lock.Lock()).ToNot(HaveOccurred())
+
+			err := lock.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Objects).To(BeEmpty())
+		})
+
+		It("does not remove lock that was not acquired", func() {
+			writeLock(DeploymentStateLockInfo{
+				DeploymentStateLockOwner: DeploymentStateLockOwner{User: "bob", Host: "host-b", PID: 200},
+				CreatedAt:                now,
+			})
+
+			Expect(lock.Lock()).To(HaveOccurred())
+
+			err := lock.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Objects).To(HaveKey("/state.json.lock"))
+		})
+
+		It("does not remove lock that was taken over by someone else", func() {
+			Expect(lock.Lock()).ToNot(HaveOccurred())
+
+			otherLock := newLock(DeploymentStateLockOwner{User: "bob", Host: "host-b", PID: 200})
+			Expect(otherLock.ForceUnlock()).ToNot(HaveOccurred())
+			Expect(otherLock.Lock()).ToNot(HaveOccurred())
+
+			err := lock.Unlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(store.Objects["/state.json.lock"])).To(ContainSubstring(`"user":"bob"`))
+		})
+	})
+
+	Describe("ForceUnlock", func() {
+		It("removes lock regardless of its owner", func() {
+			writeLock(DeploymentStateLockInfo{
+				DeploymentStateLockOwner: DeploymentStateLockOwner{User: "bob", Host: "host-b", PID: 200},
+				CreatedAt:                now,
+			})
+
+			err := lock.ForceUnlock()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Objects).To(BeEmpty())
+
+			Expect(lock.Lock()).ToNot(HaveOccurred())
+		})
+
+		It("returns error if lock cannot be removed", func() {
+			store.DeleteErr = errors.New("fake-err")
+
+			err := lock.ForceUnlock()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+
+	Describe("NewDeploymentStateLockForPath", func() {
+		It("keeps lock next to local state file", func() {
+			fs := fakesys.NewFakeFileSystem()
+
+			lock := NewDeploymentStateLockForPath(fs, timeService, logger, "/path/state.json")
+			Expect(lock.Lock()).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/path/state.json.lock")).To(BeTrue())
+
+			Expect(lock.Unlock()).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/path/state.json.lock")).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("OSProcessChecker", func() {
+	It("returns true for running process", func() {
+		Expect(OSProcessChecker{}.Exists(os.Getpid())).To(BeTrue())
+	})
+
+	It("returns false for invalid pid", func() {
+		Expect(OSProcessChecker{}.Exists(0)).To(BeFalse())
+	})
+})
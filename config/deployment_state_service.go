@@ -1,7 +1,10 @@
 package config
 
 import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 type DeploymentState struct {
@@ -17,11 +20,22 @@ type DeploymentState struct {
 	Releases           []ReleaseRecord  `json:"releases"`
 }
 
+// CurrentStemcellAPIVersion is 0 when current stemcell is not known
+func (s DeploymentState) CurrentStemcellAPIVersion() int {
+	for _, stemcell := range s.Stemcells {
+		if stemcell.ID == s.CurrentStemcellID {
+			return stemcell.APIVersion
+		}
+	}
+	return 0
+}
+
 type StemcellRecord struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	CID     string `json:"cid"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	APIVersion int    `json:"api_version,omitempty"`
+	CID        string `json:"cid"`
 }
 
 type DiskRecord struct {
@@ -44,3 +58,40 @@ type DeploymentStateService interface {
 	Save(DeploymentState) error
 	Cleanup() error
 }
+
+// NewDeploymentStateService keeps state in object storage when state path
+// is an object store URL (s3://bucket/key or gs://bucket/key); otherwise
+// state is kept in a local file.
+func NewDeploymentStateService(
+	fs boshsys.FileSystem,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+	deploymentStatePath string,
+) DeploymentStateService {
+	if !IsObjectStoreURL(deploymentStatePath) {
+		return NewFileSystemDeploymentStateService(fs, uuidGenerator, logger, deploymentStatePath)
+	}
+
+	store, key := deploymentStateObjectStore(fs, deploymentStatePath)
+
+	return NewObjectStoreDeploymentStateService(store, uuidGenerator, logger, deploymentStatePath, key)
+}
+
+func deploymentStateObjectStore(fs boshsys.FileSystem, deploymentStatePath string) (ObjectStore, string) {
+	if !IsObjectStoreURL(deploymentStatePath) {
+		return NewFileObjectStore(fs), deploymentStatePath
+	}
+
+	var store ObjectStore
+
+	storeURL, err := ParseObjectStoreURL(deploymentStatePath)
+	if err == nil {
+		store, err = NewObjectStore(storeURL)
+	}
+
+	if err != nil {
+		store = NewErrObjectStore(err)
+	}
+
+	return store, storeURL.Key
+}
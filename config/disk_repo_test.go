@@ -118,6 +118,29 @@ var _ = Describe("DiskRepo", func() {
 		})
 	})
 
+	Describe("UpdateSize", func() {
+		It("updates size of existing disk record in place", func() {
+			savedRecord, err := repo.Save("fake-cid", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateSize("fake-cid", 2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			foundRecord, found, err := repo.Find("fake-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(foundRecord.ID).To(Equal(savedRecord.ID))
+			Expect(foundRecord.Size).To(Equal(2048))
+			Expect(foundRecord.CloudProperties).To(Equal(cloudProperties))
+		})
+
+		It("returns an error when disk record does not exist", func() {
+			err := repo.UpdateSize("fake-unknown-cid", 2048)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying disk record exists with cid 'fake-unknown-cid'"))
+		})
+	})
+
 	Describe("FindCurrent", func() {
 		Context("when current disk exists", func() {
 			var (
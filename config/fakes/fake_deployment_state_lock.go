@@ -0,0 +1,31 @@
+package fakes
+
+type FakeDeploymentStateLock struct {
+	LockCalled bool
+	LockErr    error
+
+	UnlockCalled bool
+	UnlockErr    error
+
+	ForceUnlockCalled bool
+	ForceUnlockErr    error
+}
+
+func NewFakeDeploymentStateLock() *FakeDeploymentStateLock {
+	return &FakeDeploymentStateLock{}
+}
+
+func (l *FakeDeploymentStateLock) Lock() error {
+	l.LockCalled = true
+	return l.LockErr
+}
+
+func (l *FakeDeploymentStateLock) Unlock() error {
+	l.UnlockCalled = true
+	return l.UnlockErr
+}
+
+func (l *FakeDeploymentStateLock) ForceUnlock() error {
+	l.ForceUnlockCalled = true
+	return l.ForceUnlockErr
+}
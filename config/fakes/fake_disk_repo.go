@@ -14,6 +14,9 @@ type FakeDiskRepo struct {
 	SaveInputs []DiskRepoSaveInput
 	saveOutput diskRepoSaveOutput
 
+	UpdateSizeInputs []DiskRepoUpdateSizeInput
+	UpdateSizeErr    error
+
 	findOutput map[string]diskRepoFindOutput
 
 	DeleteInputs []DiskRepoDeleteInput
@@ -38,6 +41,11 @@ type DiskRepoSaveInput struct {
 	CloudProperties biproperty.Map
 }
 
+type DiskRepoUpdateSizeInput struct {
+	CID  string
+	Size int
+}
+
 type diskRepoSaveOutput struct {
 	diskRecord biconfig.DiskRecord
 	err        error
@@ -92,6 +100,15 @@ func (r *FakeDiskRepo) Save(cid string, size int, cloudProperties biproperty.Map
 	return r.saveOutput.diskRecord, r.saveOutput.err
 }
 
+func (r *FakeDiskRepo) UpdateSize(cid string, size int) error {
+	r.UpdateSizeInputs = append(r.UpdateSizeInputs, DiskRepoUpdateSizeInput{
+		CID:  cid,
+		Size: size,
+	})
+
+	return r.UpdateSizeErr
+}
+
 func (r *FakeDiskRepo) Find(cid string) (biconfig.DiskRecord, bool, error) {
 	return r.findOutput[cid].diskRecord, r.findOutput[cid].found, r.findOutput[cid].err
 }
@@ -0,0 +1,64 @@
+package fakes
+
+import (
+	"strconv"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+// FakeObjectStore keeps objects in memory and bumps object version on every put
+type FakeObjectStore struct {
+	Objects  map[string][]byte
+	Versions map[string]string
+
+	GetErr    error
+	PutErr    error
+	DeleteErr error
+
+	PutCount int
+}
+
+func NewFakeObjectStore() *FakeObjectStore {
+	return &FakeObjectStore{
+		Objects:  map[string][]byte{},
+		Versions: map[string]string{},
+	}
+}
+
+func (s *FakeObjectStore) Get(key string) ([]byte, string, bool, error) {
+	if s.GetErr != nil {
+		return nil, "", false, s.GetErr
+	}
+
+	contents, found := s.Objects[key]
+
+	return contents, s.Versions[key], found, nil
+}
+
+func (s *FakeObjectStore) Put(key string, contents []byte, version string) (string, error) {
+	if s.PutErr != nil {
+		return "", s.PutErr
+	}
+
+	if s.Versions[key] != version {
+		return "", biconfig.ObjectVersionMismatchError{Key: key}
+	}
+
+	s.PutCount++
+
+	s.Objects[key] = contents
+	s.Versions[key] = strconv.Itoa(s.PutCount)
+
+	return s.Versions[key], nil
+}
+
+func (s *FakeObjectStore) Delete(key string) error {
+	if s.DeleteErr != nil {
+		return s.DeleteErr
+	}
+
+	delete(s.Objects, key)
+	delete(s.Versions, key)
+
+	return nil
+}
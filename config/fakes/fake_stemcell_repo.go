@@ -9,9 +9,10 @@ import (
 )
 
 type StemcellRepoSaveInput struct {
-	Name    string
-	Version string
-	CID     string
+	Name       string
+	Version    string
+	CID        string
+	APIVersion int
 }
 
 type StemcellRepoSaveOutput struct {
@@ -89,11 +90,12 @@ func (fr *FakeStemcellRepo) All() ([]biconfig.StemcellRecord, error) {
 	return fr.AllStemcellRecords, fr.AllErr
 }
 
-func (fr *FakeStemcellRepo) Save(name, version, cid string) (biconfig.StemcellRecord, error) {
+func (fr *FakeStemcellRepo) Save(name, version, cid string, apiVersion int) (biconfig.StemcellRecord, error) {
 	input := StemcellRepoSaveInput{
-		Name:    name,
-		Version: version,
-		CID:     cid,
+		Name:       name,
+		Version:    version,
+		CID:        cid,
+		APIVersion: apiVersion,
 	}
 	fr.SaveInputs = append(fr.SaveInputs, input)
 
@@ -110,11 +112,12 @@ func (fr *FakeStemcellRepo) Save(name, version, cid string) (biconfig.StemcellRe
 	return output.stemcellRecord, output.err
 }
 
-func (fr *FakeStemcellRepo) SetSaveBehavior(name, version, cid string, stemcellRecord biconfig.StemcellRecord, err error) error {
+func (fr *FakeStemcellRepo) SetSaveBehavior(name, version, cid string, apiVersion int, stemcellRecord biconfig.StemcellRecord, err error) error {
 	input := StemcellRepoSaveInput{
-		Name:    name,
-		Version: version,
-		CID:     cid,
+		Name:       name,
+		Version:    version,
+		CID:        cid,
+		APIVersion: apiVersion,
 	}
 
 	inputString, marshalErr := bitestutils.MarshalToString(input)
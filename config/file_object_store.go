@@ -0,0 +1,100 @@
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// FileObjectStore keeps objects as local files named by their keys.
+// Version of an object is a digest of its contents. Creating an object that
+// must not exist yet is atomic; updating existing one is not.
+type FileObjectStore struct {
+	fs boshsys.FileSystem
+}
+
+func NewFileObjectStore(fs boshsys.FileSystem) FileObjectStore {
+	return FileObjectStore{fs: fs}
+}
+
+func (s FileObjectStore) Get(key string) ([]byte, string, bool, error) {
+	if !s.fs.FileExists(key) {
+		return nil, "", false, nil
+	}
+
+	contents, err := s.fs.ReadFile(key)
+	if err != nil {
+		return nil, "", false, bosherr.WrapErrorf(err, "Reading file '%s'", key)
+	}
+
+	return contents, s.version(contents), true, nil
+}
+
+func (s FileObjectStore) Put(key string, contents []byte, version string) (string, error) {
+	if len(version) == 0 {
+		return s.create(key, contents)
+	}
+
+	currContents, currVersion, found, err := s.Get(key)
+	if err != nil {
+		return "", err
+	}
+
+	if !found || currVersion != version {
+		return "", ObjectVersionMismatchError{Key: key}
+	}
+
+	if string(currContents) != string(contents) {
+		err = s.fs.WriteFile(key, contents)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Writing file '%s'", key)
+		}
+	}
+
+	return s.version(contents), nil
+}
+
+func (s FileObjectStore) Delete(key string) error {
+	err := s.fs.RemoveAll(key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing file '%s'", key)
+	}
+
+	return nil
+}
+
+func (s FileObjectStore) create(key string, contents []byte) (string, error) {
+	if s.fs.FileExists(key) {
+		return "", ObjectVersionMismatchError{Key: key}
+	}
+
+	err := s.fs.MkdirAll(filepath.Dir(key), os.ModePerm)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating directory for file '%s'", key)
+	}
+
+	file, err := s.fs.OpenFile(key, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", ObjectVersionMismatchError{Key: key}
+		}
+		return "", bosherr.WrapErrorf(err, "Creating file '%s'", key)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(contents)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Writing file '%s'", key)
+	}
+
+	return s.version(contents), nil
+}
+
+func (s FileObjectStore) version(contents []byte) string {
+	return fmt.Sprintf("%x", sha1.Sum(contents))
+}
@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+)
+
+var _ = Describe("FileObjectStore", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		store FileObjectStore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		store = NewFileObjectStore(fs)
+	})
+
+	It("returns not found for missing files", func() {
+		_, _, found, err := store.Get("/dir/obj")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("creates, updates and deletes files", func() {
+		version, err := store.Put("/dir/obj", []byte("v1"), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, getVersion, found, err := store.Get("/dir/obj")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(string(contents)).To(Equal("v1"))
+		Expect(getVersion).To(Equal(version))
+
+		_, err = store.Put("/dir/obj", []byte("v2"), version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.ReadFileString("/dir/obj")).To(Equal("v2"))
+
+		err = store.Delete("/dir/obj")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists("/dir/obj")).To(BeFalse())
+	})
+
+	It("does not create file that already exists", func() {
+		fs.WriteFileString("/dir/obj", "other")
+
+		_, err := store.Put("/dir/obj", []byte("v1"), "")
+		Expect(err).To(Equal(ObjectVersionMismatchError{Key: "/dir/obj"}))
+		Expect(fs.ReadFileString("/dir/obj")).To(Equal("other"))
+	})
+
+	It("does not update file that was changed", func() {
+		version, err := store.Put("/dir/obj", []byte("v1"), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.WriteFileString("/dir/obj", "other")
+
+		_, err = store.Put("/dir/obj", []byte("v2"), version)
+		Expect(err).To(Equal(ObjectVersionMismatchError{Key: "/dir/obj"}))
+		Expect(fs.ReadFileString("/dir/obj")).To(Equal("other"))
+	})
+})
@@ -0,0 +1,135 @@
+package config
+
+import (
+	gobytes "bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"google.golang.org/api/googleapi"
+
+	gcsclient "github.com/cloudfoundry/bosh-gcscli/client"
+	gcsconfig "github.com/cloudfoundry/bosh-gcscli/config"
+)
+
+// GCSObjectStore uses object generations to detect concurrent modifications
+type GCSObjectStore struct {
+	options map[string]interface{}
+}
+
+func NewGCSObjectStore(options map[string]interface{}) GCSObjectStore {
+	return GCSObjectStore{options: options}
+}
+
+func (s GCSObjectStore) Get(key string) ([]byte, string, bool, error) {
+	ctx, obj, err := s.object(key)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, "", false, nil
+		}
+		return nil, "", false, bosherr.WrapErrorf(err, "Getting object '%s' attributes", key)
+	}
+
+	// Read exact generation so that contents match returned version
+	reader, err := obj.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, "", false, bosherr.WrapErrorf(err, "Getting object '%s'", key)
+	}
+
+	defer reader.Close()
+
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", false, bosherr.WrapErrorf(err, "Reading object '%s'", key)
+	}
+
+	return contents, strconv.FormatInt(attrs.Generation, 10), true, nil
+}
+
+func (s GCSObjectStore) Put(key string, contents []byte, version string) (string, error) {
+	ctx, obj, err := s.object(key)
+	if err != nil {
+		return "", err
+	}
+
+	conds := storage.Conditions{DoesNotExist: true}
+
+	if len(version) > 0 {
+		generation, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Parsing object '%s' generation", key)
+		}
+
+		conds = storage.Conditions{GenerationMatch: generation}
+	}
+
+	writer := obj.If(conds).NewWriter(ctx)
+
+	_, err = gobytes.NewReader(contents).WriteTo(writer)
+	if err != nil {
+		_ = writer.CloseWithError(err)
+		return "", s.putErr(key, err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", s.putErr(key, err)
+	}
+
+	return strconv.FormatInt(writer.Attrs().Generation, 10), nil
+}
+
+func (s GCSObjectStore) Delete(key string) error {
+	ctx, obj, err := s.object(key)
+	if err != nil {
+		return err
+	}
+
+	err = obj.Delete(ctx)
+	if err != nil && err != storage.ErrObjectNotExist {
+		return bosherr.WrapErrorf(err, "Deleting object '%s'", key)
+	}
+
+	return nil
+}
+
+func (s GCSObjectStore) putErr(key string, err error) error {
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusPreconditionFailed {
+		return ObjectVersionMismatchError{Key: key}
+	}
+	return bosherr.WrapErrorf(err, "Putting object '%s'", key)
+}
+
+func (s GCSObjectStore) object(key string) (context.Context, *storage.ObjectHandle, error) {
+	bytes, err := json.Marshal(s.options)
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Marshaling config")
+	}
+
+	conf, err := gcsconfig.NewFromReader(gobytes.NewBuffer(bytes))
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Reading config")
+	}
+
+	ctx, client, err := gcsclient.NewSDK(conf)
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Building client SDK")
+	}
+
+	obj := client.Bucket(conf.BucketName).Object(key)
+
+	if len(conf.EncryptionKey) > 0 {
+		obj = obj.Key(conf.EncryptionKey)
+	}
+
+	return ctx, obj, nil
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ObjectStore keeps deployment state in remote object storage.
+// Versions are opaque strings (ETag for S3, generation for GCS)
+// used to detect concurrent modifications.
+type ObjectStore interface {
+	// Get returns object contents and version; found is false if object does not exist
+	Get(key string) (contents []byte, version string, found bool, err error)
+
+	// Put saves object only if it still has given version or,
+	// when version is empty, if it does not exist yet.
+	// Returns ObjectVersionMismatchError otherwise.
+	Put(key string, contents []byte, version string) (newVersion string, err error)
+
+	Delete(key string) error
+}
+
+type ObjectVersionMismatchError struct {
+	Key string
+}
+
+func (e ObjectVersionMismatchError) Error() string {
+	return "Object '" + e.Key + "' was modified by someone else since it was last read"
+}
+
+// ObjectStoreURL describes location of an object in object storage, e.g.
+// s3://bucket/path/to/state.json?region=us-east-1 or gs://bucket/state.json
+type ObjectStoreURL struct {
+	Scheme  string
+	Bucket  string
+	Key     string
+	Options map[string]string
+}
+
+func IsObjectStoreURL(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://")
+}
+
+func ParseObjectStoreURL(path string) (ObjectStoreURL, error) {
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return ObjectStoreURL{}, bosherr.WrapErrorf(err, "Parsing object store URL '%s'", path)
+	}
+
+	storeURL := ObjectStoreURL{
+		Scheme:  parsed.Scheme,
+		Bucket:  parsed.Host,
+		Key:     strings.TrimPrefix(parsed.Path, "/"),
+		Options: map[string]string{},
+	}
+
+	if len(storeURL.Bucket) == 0 {
+		return ObjectStoreURL{}, bosherr.Errorf("Expected object store URL '%s' to include bucket name", path)
+	}
+
+	if len(storeURL.Key) == 0 {
+		return ObjectStoreURL{}, bosherr.Errorf("Expected object store URL '%s' to include object key", path)
+	}
+
+	for name, vals := range parsed.Query() {
+		if len(vals) > 0 {
+			storeURL.Options[name] = vals[0]
+		}
+	}
+
+	return storeURL, nil
+}
+
+// NewObjectStore returns object store for URL scheme. Credentials are not part
+// of the URL: S3 uses AWS environment variables or profile by default and
+// GCS uses application default credentials.
+func NewObjectStore(storeURL ObjectStoreURL) (ObjectStore, error) {
+	options := map[string]interface{}{
+		"bucket_name": storeURL.Bucket,
+	}
+
+	switch storeURL.Scheme {
+	case "s3":
+		options["credentials_source"] = "env_or_profile"
+	case "gs":
+	default:
+		return nil, bosherr.Errorf("Unsupported object store scheme '%s'", storeURL.Scheme)
+	}
+
+	for name, val := range storeURL.Options {
+		switch name {
+		case "port":
+			port, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing object store option '%s'", name)
+			}
+			options[name] = port
+		case "use_ssl", "ssl_verify_peer":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing object store option '%s'", name)
+			}
+			options[name] = b
+		default:
+			options[name] = val
+		}
+	}
+
+	if storeURL.Scheme == "s3" {
+		return NewS3ObjectStore(options), nil
+	}
+
+	return NewGCSObjectStore(options), nil
+}
+
+// ErrObjectStore postpones returning an error until one of the actions are performed.
+type ErrObjectStore struct {
+	err error
+}
+
+func NewErrObjectStore(err error) ErrObjectStore {
+	return ErrObjectStore{err: err}
+}
+
+func (s ErrObjectStore) Get(key string) ([]byte, string, bool, error) { return nil, "", false, s.err }
+func (s ErrObjectStore) Put(key string, contents []byte, version string) (string, error) {
+	return "", s.err
+}
+func (s ErrObjectStore) Delete(key string) error { return s.err }
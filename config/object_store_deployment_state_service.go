@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+type objectStoreDeploymentStateService struct {
+	path  string
+	key   string
+	store ObjectStore
+
+	// version of the state object as of last load or save;
+	// saving fails if object was changed since then
+	version string
+
+	uuidGenerator boshuuid.Generator
+	logger        boshlog.Logger
+	logTag        string
+}
+
+func NewObjectStoreDeploymentStateService(
+	store ObjectStore,
+	uuidGenerator boshuuid.Generator,
+	logger boshlog.Logger,
+	path string,
+	key string,
+) DeploymentStateService {
+	return &objectStoreDeploymentStateService{
+		path:  path,
+		key:   key,
+		store: store,
+
+		uuidGenerator: uuidGenerator,
+		logger:        logger,
+		logTag:        "config",
+	}
+}
+
+func (s *objectStoreDeploymentStateService) Path() string {
+	return s.path
+}
+
+func (s *objectStoreDeploymentStateService) Exists() bool {
+	_, _, found, err := s.store.Get(s.key)
+	if err != nil {
+		s.logger.Error(s.logTag, "Checking if deployment state '%s' exists: %s", s.path, err)
+		return false
+	}
+
+	return found
+}
+
+func (s *objectStoreDeploymentStateService) Load() (DeploymentState, error) {
+	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.path)
+
+	deploymentState := &DeploymentState{}
+
+	contents, version, found, err := s.store.Get(s.key)
+	if err != nil {
+		return DeploymentState{}, bosherr.WrapErrorf(err, "Reading deployment state '%s'", s.path)
+	}
+
+	s.version = version
+
+	if found {
+		err = json.Unmarshal(contents, deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state '%s'", s.path)
+		}
+	}
+
+	if deploymentState.DirectorID == "" {
+		uuid, err := s.uuidGenerator.Generate()
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapErrorf(
+				bosherr.WrapError(err, "Generating DirectorID"), "Initializing deployment state defaults")
+		}
+
+		deploymentState.DirectorID = uuid
+
+		err = s.Save(*deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapErrorf(
+				bosherr.WrapError(err, "Saving deployment state"), "Initializing deployment state defaults")
+		}
+	}
+
+	return *deploymentState, nil
+}
+
+func (s *objectStoreDeploymentStateService) Save(deploymentState DeploymentState) error {
+	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
+
+	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
+	}
+
+	version, err := s.store.Put(s.key, jsonContent, s.version)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing deployment state '%s'", s.path)
+	}
+
+	s.version = version
+
+	return nil
+}
+
+func (s *objectStoreDeploymentStateService) Cleanup() error {
+	err := s.store.Delete(s.key)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Could not delete deployment state %s", s.path)
+	}
+
+	s.version = ""
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package config_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+	fakeconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+)
+
+var _ = Describe("objectStoreDeploymentStateService", func() {
+	var (
+		store             *fakeconfig.FakeObjectStore
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+		logger            boshlog.Logger
+		service           DeploymentStateService
+	)
+
+	BeforeEach(func() {
+		store = fakeconfig.NewFakeObjectStore()
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		service = NewObjectStoreDeploymentStateService(store, fakeUUIDGenerator, logger, "s3://bucket/state.json", "state.json")
+	})
+
+	Describe("Path", func() {
+		It("returns object store URL", func() {
+			Expect(service.Path()).To(Equal("s3://bucket/state.json"))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("returns true if state object exists", func() {
+			store.Objects["state.json"] = []byte("{}")
+			Expect(service.Exists()).To(BeTrue())
+		})
+
+		It("returns false if state object does not exist", func() {
+			Expect(service.Exists()).To(BeFalse())
+		})
+
+		It("returns false if state object cannot be checked", func() {
+			store.GetErr = errors.New("fake-err")
+			Expect(service.Exists()).To(BeFalse())
+		})
+	})
+
+	Describe("Load", func() {
+		It("loads saved state", func() {
+			store.Objects["state.json"] = []byte(`{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid"}`)
+			store.Versions["state.json"] = "fake-version"
+
+			state, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.DirectorID).To(Equal("fake-director-id"))
+			Expect(state.CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(store.PutCount).To(Equal(0))
+		})
+
+		It("generates and saves director id when state does not exist", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-uuid"
+
+			state, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state.DirectorID).To(Equal("fake-uuid"))
+			Expect(string(store.Objects["state.json"])).To(ContainSubstring(`"director_id": "fake-uuid"`))
+		})
+
+		It("returns error if state cannot be read", func() {
+			store.GetErr = errors.New("fake-err")
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if state is not valid JSON", func() {
+			store.Objects["state.json"] = []byte("-")
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling deployment state 's3://bucket/state.json'"))
+		})
+	})
+
+	Describe("Save", func() {
+		It("saves state based on last loaded version", func() {
+			store.Objects["state.json"] = []byte(`{"director_id":"fake-director-id"}`)
+			store.Versions["state.json"] = "fake-version"
+
+			state, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			state.CurrentVMCID = "fake-vm-cid"
+
+			err = service.Save(state)
+			Expect(err).ToNot(HaveOccurred())
+
+			state.CurrentDiskID = "fake-disk-id"
+
+			err = service.Save(state)
+			Expect(err).ToNot(HaveOccurred())
+
+			reloadedState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reloadedState.CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(reloadedState.CurrentDiskID).To(Equal("fake-disk-id"))
+		})
+
+		It("returns error if state was modified by someone else since it was loaded", func() {
+			store.Objects["state.json"] = []byte(`{"director_id":"fake-director-id"}`)
+			store.Versions["state.json"] = "fake-version"
+
+			state, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			otherService := NewObjectStoreDeploymentStateService(store, fakeUUIDGenerator, logger, "s3://bucket/state.json", "state.json")
+
+			otherState, err := otherService.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			otherState.CurrentVMCID = "other-vm-cid"
+			Expect(otherService.Save(otherState)).ToNot(HaveOccurred())
+
+			state.CurrentVMCID = "fake-vm-cid"
+
+			err = service.Save(state)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Object 'state.json' was modified by someone else since it was last read"))
+
+			Expect(string(store.Objects["state.json"])).To(ContainSubstring("other-vm-cid"))
+		})
+
+		It("returns error if state exists but was never loaded", func() {
+			store.Objects["state.json"] = []byte(`{"director_id":"fake-director-id"}`)
+			store.Versions["state.json"] = "fake-version"
+
+			err := service.Save(DeploymentState{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Cleanup", func() {
+		It("deletes state object", func() {
+			store.Objects["state.json"] = []byte("{}")
+
+			err := service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Objects).To(BeEmpty())
+		})
+
+		It("returns error if state object cannot be deleted", func() {
+			store.DeleteErr = errors.New("fake-err")
+
+			err := service.Cleanup()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+)
+
+var _ = Describe("ParseObjectStoreURL", func() {
+	It("parses bucket, key and options", func() {
+		storeURL, err := ParseObjectStoreURL("s3://bucket/path/to/state.json?region=eu-west-1&host=minio")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(storeURL).To(Equal(ObjectStoreURL{
+			Scheme:  "s3",
+			Bucket:  "bucket",
+			Key:     "path/to/state.json",
+			Options: map[string]string{"region": "eu-west-1", "host": "minio"},
+		}))
+	})
+
+	It("returns error if bucket is missing", func() {
+		_, err := ParseObjectStoreURL("gs:///state.json")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected object store URL 'gs:///state.json' to include bucket name"))
+	})
+
+	It("returns error if key is missing", func() {
+		_, err := ParseObjectStoreURL("gs://bucket/")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected object store URL 'gs://bucket/' to include object key"))
+	})
+})
+
+var _ = Describe("NewObjectStore", func() {
+	It("returns S3 store for s3 scheme", func() {
+		store, err := NewObjectStore(ObjectStoreURL{Scheme: "s3", Bucket: "bucket", Options: map[string]string{"port": "9000"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store).To(Equal(NewS3ObjectStore(map[string]interface{}{
+			"bucket_name":        "bucket",
+			"credentials_source": "env_or_profile",
+			"port":               9000,
+		})))
+	})
+
+	It("returns GCS store for gs scheme", func() {
+		store, err := NewObjectStore(ObjectStoreURL{Scheme: "gs", Bucket: "bucket"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store).To(Equal(NewGCSObjectStore(map[string]interface{}{"bucket_name": "bucket"})))
+	})
+
+	It("returns error if option cannot be parsed", func() {
+		_, err := NewObjectStore(ObjectStoreURL{Scheme: "s3", Bucket: "bucket", Options: map[string]string{"use_ssl": "maybe"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing object store option 'use_ssl'"))
+	})
+})
+
+var _ = Describe("NewDeploymentStateService", func() {
+	var (
+		fs            *fakesys.FakeFileSystem
+		uuidGenerator *fakeuuid.FakeGenerator
+		logger        boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		uuidGenerator = fakeuuid.NewFakeGenerator()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	It("keeps state in local file for file paths", func() {
+		service := NewDeploymentStateService(fs, uuidGenerator, logger, "/path/state.json")
+		Expect(service).To(Equal(NewFileSystemDeploymentStateService(fs, uuidGenerator, logger, "/path/state.json")))
+	})
+
+	It("keeps state in object store for object store URLs", func() {
+		service := NewDeploymentStateService(fs, uuidGenerator, logger, "gs://bucket/state.json")
+		Expect(service.Path()).To(Equal("gs://bucket/state.json"))
+		Expect(service).To(Equal(NewObjectStoreDeploymentStateService(
+			NewGCSObjectStore(map[string]interface{}{"bucket_name": "bucket"}), uuidGenerator, logger, "gs://bucket/state.json", "state.json")))
+	})
+
+	It("postpones returning error for invalid object store URLs", func() {
+		service := NewDeploymentStateService(fs, uuidGenerator, logger, "s3://bucket/")
+
+		_, err := service.Load()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("to include object key"))
+	})
+})
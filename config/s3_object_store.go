@@ -0,0 +1,154 @@
+package config
+
+import (
+	gobytes "bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	s3client "github.com/pivotal-golang/s3cli/client"
+	s3config "github.com/pivotal-golang/s3cli/config"
+)
+
+// S3ObjectStore uses ETags to detect concurrent modifications. Besides sending
+// conditional (If-Match/If-None-Match) requests it checks current ETag before
+// uploading for S3-compatible stores that ignore conditional headers on PUT.
+type S3ObjectStore struct {
+	options map[string]interface{}
+}
+
+func NewS3ObjectStore(options map[string]interface{}) S3ObjectStore {
+	return S3ObjectStore{options: options}
+}
+
+func (s S3ObjectStore) Get(key string) ([]byte, string, bool, error) {
+	client, bucket, err := s.client()
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if s.isNotFound(err) {
+			return nil, "", false, nil
+		}
+		return nil, "", false, bosherr.WrapErrorf(err, "Getting object '%s'", key)
+	}
+
+	defer output.Body.Close()
+
+	contents, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", false, bosherr.WrapErrorf(err, "Reading object '%s'", key)
+	}
+
+	return contents, aws.StringValue(output.ETag), true, nil
+}
+
+func (s S3ObjectStore) Put(key string, contents []byte, version string) (string, error) {
+	client, bucket, err := s.client()
+	if err != nil {
+		return "", err
+	}
+
+	currVersion, err := s.currentVersion(client, bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	if currVersion != version {
+		return "", ObjectVersionMismatchError{Key: key}
+	}
+
+	req, output := client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   gobytes.NewReader(contents),
+	})
+
+	req.Handlers.Build.PushBack(func(r *request.Request) {
+		if len(version) > 0 {
+			r.HTTPRequest.Header.Set("If-Match", version)
+		} else {
+			r.HTTPRequest.Header.Set("If-None-Match", "*")
+		}
+	})
+
+	err = req.Send()
+	if err != nil {
+		if reqErr, ok := err.(awserr.RequestFailure); ok {
+			if reqErr.StatusCode() == http.StatusPreconditionFailed || reqErr.StatusCode() == http.StatusConflict {
+				return "", ObjectVersionMismatchError{Key: key}
+			}
+		}
+		return "", bosherr.WrapErrorf(err, "Putting object '%s'", key)
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+func (s S3ObjectStore) Delete(key string) error {
+	client, bucket, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil && !s.isNotFound(err) {
+		return bosherr.WrapErrorf(err, "Deleting object '%s'", key)
+	}
+
+	return nil
+}
+
+func (s S3ObjectStore) currentVersion(client *s3.S3, bucket, key string) (string, error) {
+	output, err := client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if s.isNotFound(err) {
+			return "", nil
+		}
+		return "", bosherr.WrapErrorf(err, "Checking object '%s'", key)
+	}
+
+	return aws.StringValue(output.ETag), nil
+}
+
+func (s S3ObjectStore) isNotFound(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() == http.StatusNotFound
+	}
+	return false
+}
+
+func (s S3ObjectStore) client() (*s3.S3, string, error) {
+	bytes, err := json.Marshal(s.options)
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Marshaling config")
+	}
+
+	conf, err := s3config.NewFromReader(gobytes.NewBuffer(bytes))
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Reading config")
+	}
+
+	client, err := s3client.NewSDK(conf)
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Building client SDK")
+	}
+
+	return client, conf.BucketName, nil
+}
@@ -0,0 +1,174 @@
+package config_test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+)
+
+// s3Stub is a minimal path-style S3-compatible server that honors conditional PUTs
+type s3Stub struct {
+	objects map[string][]byte
+	lock    sync.Mutex
+
+	// ignoreConditions imitates stores that ignore If-Match/If-None-Match
+	ignoreConditions bool
+	// beforePut is called before object is stored to imitate concurrent writers
+	beforePut func()
+}
+
+func (s *s3Stub) etag(contents []byte) string {
+	return fmt.Sprintf(`"%x"`, md5.Sum(contents))
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "PUT" && s.beforePut != nil {
+		s.beforePut()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := r.URL.Path
+	contents, found := s.objects[key]
+
+	switch r.Method {
+	case "GET", "HEAD":
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", s.etag(contents))
+		w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "GET" {
+			w.Write(contents)
+		}
+
+	case "PUT":
+		if !s.ignoreConditions {
+			ifMatch := r.Header.Get("If-Match")
+			ifNoneMatch := r.Header.Get("If-None-Match")
+
+			if (len(ifMatch) > 0 && (!found || ifMatch != s.etag(contents))) || (ifNoneMatch == "*" && found) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		s.objects[key] = body
+		w.Header().Set("ETag", s.etag(body))
+		w.WriteHeader(http.StatusOK)
+
+	case "DELETE":
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+var _ = Describe("S3ObjectStore", func() {
+	var (
+		stub   *s3Stub
+		server *httptest.Server
+		store  S3ObjectStore
+	)
+
+	BeforeEach(func() {
+		stub = &s3Stub{objects: map[string][]byte{}}
+		server = httptest.NewServer(stub)
+
+		host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+		Expect(err).ToNot(HaveOccurred())
+
+		portNum, err := strconv.Atoi(port)
+		Expect(err).ToNot(HaveOccurred())
+
+		store = NewS3ObjectStore(map[string]interface{}{
+			"bucket_name":       "bucket",
+			"access_key_id":     "fake-access-key",
+			"secret_access_key": "fake-secret-key",
+			"host":              host,
+			"port":              portNum,
+			"use_ssl":           false,
+			"region":            "us-east-1",
+			"signature_version": "4",
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns not found for missing objects", func() {
+		_, _, found, err := store.Get("state.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("creates, updates and deletes objects", func() {
+		version, err := store.Put("state.json", []byte("v1"), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(stub.etag([]byte("v1"))))
+
+		contents, getVersion, found, err := store.Get("state.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(string(contents)).To(Equal("v1"))
+		Expect(getVersion).To(Equal(version))
+
+		_, err = store.Put("state.json", []byte("v2"), version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(stub.objects["/bucket/state.json"])).To(Equal("v2"))
+
+		err = store.Delete("state.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stub.objects).To(BeEmpty())
+	})
+
+	It("does not overwrite object with different version", func() {
+		stub.objects["/bucket/state.json"] = []byte("other")
+
+		_, err := store.Put("state.json", []byte("v2"), stub.etag([]byte("v1")))
+		Expect(err).To(Equal(ObjectVersionMismatchError{Key: "state.json"}))
+
+		_, err = store.Put("state.json", []byte("v2"), "")
+		Expect(err).To(Equal(ObjectVersionMismatchError{Key: "state.json"}))
+
+		Expect(string(stub.objects["/bucket/state.json"])).To(Equal("other"))
+	})
+
+	It("does not overwrite object that was modified right before upload", func() {
+		stub.objects["/bucket/state.json"] = []byte("v1")
+
+		stub.beforePut = func() {
+			stub.lock.Lock()
+			stub.objects["/bucket/state.json"] = []byte("other")
+			stub.lock.Unlock()
+		}
+
+		_, err := store.Put("state.json", []byte("v2"), stub.etag([]byte("v1")))
+		Expect(err).To(Equal(ObjectVersionMismatchError{Key: "state.json"}))
+		Expect(string(stub.objects["/bucket/state.json"])).To(Equal("other"))
+	})
+
+	It("checks version before upload for stores that ignore conditional requests", func() {
+		stub.ignoreConditions = true
+		stub.objects["/bucket/state.json"] = []byte("other")
+
+		_, err := store.Put("state.json", []byte("v2"), stub.etag([]byte("v1")))
+		Expect(err).To(Equal(ObjectVersionMismatchError{Key: "state.json"}))
+		Expect(string(stub.objects["/bucket/state.json"])).To(Equal("other"))
+	})
+})
@@ -27,7 +27,7 @@ var _ = Describe("StemcellRepo", func() {
 
 	Describe("Save", func() {
 		It("saves the stemcell record using the config service", func() {
-			_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+			_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 			Expect(err).ToNot(HaveOccurred())
 
 			deploymentState, err := deploymentStateService.Load()
@@ -49,7 +49,7 @@ var _ = Describe("StemcellRepo", func() {
 
 		It("returns the stemcell record with a new uuid", func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-uuid-1"
-			record, err := repo.Save("fake-name", "fake-version-1", "fake-cid-1")
+			record, err := repo.Save("fake-name", "fake-version-1", "fake-cid-1", 0)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(record).To(Equal(StemcellRecord{
 				ID:      "fake-uuid-1",
@@ -59,7 +59,7 @@ var _ = Describe("StemcellRepo", func() {
 			}))
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-uuid-2"
-			record, err = repo.Save("fake-name", "fake-version-2", "fake-cid-2")
+			record, err = repo.Save("fake-name", "fake-version-2", "fake-cid-2", 0)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(record).To(Equal(StemcellRecord{
 				ID:      "fake-uuid-2",
@@ -71,12 +71,12 @@ var _ = Describe("StemcellRepo", func() {
 
 		Context("when a stemcell record with the same name and version exists", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
 			It("returns an error", func() {
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid-2")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid-2", 0)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("duplicate name/version"))
 			})
@@ -84,12 +84,12 @@ var _ = Describe("StemcellRepo", func() {
 
 		Context("when there stemcell record with the same cid exists (cpi does not garentee cid uniqueness)", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-name-1", "fake-version-1", "fake-cid-1")
+				_, err := repo.Save("fake-name-1", "fake-version-1", "fake-cid-1", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
 			It("saves the stemcell record using the config service", func() {
-				_, err := repo.Save("fake-name-2", "fake-version-2", "fake-cid-1")
+				_, err := repo.Save("fake-name-2", "fake-version-2", "fake-cid-1", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				deploymentState, err := deploymentStateService.Load()
@@ -116,7 +116,7 @@ var _ = Describe("StemcellRepo", func() {
 			})
 
 			It("returns the stemcell record with a new uuid", func() {
-				record, err := repo.Save("fake-name-2", "fake-version-2", "fake-cid-1")
+				record, err := repo.Save("fake-name-2", "fake-version-2", "fake-cid-1", 0)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(record).To(Equal(StemcellRecord{
 					ID:      "fake-uuid-2",
@@ -131,7 +131,7 @@ var _ = Describe("StemcellRepo", func() {
 	Describe("Find", func() {
 		Context("when a stemcell record with the same name and version exists", func() {
 			BeforeEach(func() {
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -161,7 +161,7 @@ var _ = Describe("StemcellRepo", func() {
 		Context("when a stemcell record exists with the same ID", func() {
 			BeforeEach(func() {
 				fakeUUIDGenerator.GeneratedUUID = "fake-uuid-1"
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -179,7 +179,7 @@ var _ = Describe("StemcellRepo", func() {
 		Context("when a stemcell record does not exists with the same ID", func() {
 			BeforeEach(func() {
 				fakeUUIDGenerator.GeneratedUUID = "fake-uuid-1"
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -195,7 +195,7 @@ var _ = Describe("StemcellRepo", func() {
 		Context("when a stemcell record exists with the same ID", func() {
 			BeforeEach(func() {
 				fakeUUIDGenerator.GeneratedUUID = "fake-uuid-1"
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = repo.UpdateCurrent("fake-uuid-1")
@@ -224,13 +224,13 @@ var _ = Describe("StemcellRepo", func() {
 		BeforeEach(func() {
 			var err error
 			fakeUUIDGenerator.GeneratedUUID = "fake-uuid-1"
-			firstStemcellRecord, err = repo.Save("fake-name1", "fake-version1", "fake-cid1")
+			firstStemcellRecord, err = repo.Save("fake-name1", "fake-version1", "fake-cid1", 0)
 			Expect(err).ToNot(HaveOccurred())
 			fakeUUIDGenerator.GeneratedUUID = "fake-uuid-2"
-			secondStemcellRecord, err = repo.Save("fake-name2", "fake-version2", "fake-cid2")
+			secondStemcellRecord, err = repo.Save("fake-name2", "fake-version2", "fake-cid2", 0)
 			Expect(err).ToNot(HaveOccurred())
 			fakeUUIDGenerator.GeneratedUUID = "fake-uuid-3"
-			thirdStemcellRecord, err = repo.Save("fake-name3", "fake-version3", "fake-cid3")
+			thirdStemcellRecord, err = repo.Save("fake-name3", "fake-version3", "fake-cid3", 0)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
@@ -275,11 +275,11 @@ var _ = Describe("StemcellRepo", func() {
 		Context("when current stemcell exists", func() {
 			BeforeEach(func() {
 				fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
-				_, err := repo.Save("fake-name", "fake-version-1", "fake-cid-1")
+				_, err := repo.Save("fake-name", "fake-version-1", "fake-cid-1", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				fakeUUIDGenerator.GeneratedUUID = "fake-guid-2"
-				record, err := repo.Save("fake-name", "fake-version-2", "fake-cid-2")
+				record, err := repo.Save("fake-name", "fake-version-2", "fake-cid-2", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				repo.UpdateCurrent(record.ID)
@@ -301,7 +301,7 @@ var _ = Describe("StemcellRepo", func() {
 		Context("when current stemcell does not exist", func() {
 			BeforeEach(func() {
 				fakeUUIDGenerator.GeneratedUUID = "fake-guid-1"
-				_, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				_, err := repo.Save("fake-name", "fake-version", "fake-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -16,6 +16,10 @@ type CpiInstaller struct {
 
 func (i CpiInstaller) ValidateCpiRelease(installationManifest biinstallmanifest.Manifest, stage biui.Stage) error {
 	return stage.Perform("Validating cpi release", func() error {
+		if installationManifest.UsesLocalCPI() {
+			return biui.NewSkipStageError(bosherr.Error("Local CPI is built into the CLI"), "Local CPI")
+		}
+
 		cpiReleaseName := installationManifest.Template.Release
 		cpiRelease, found := i.ReleaseManager.Find(cpiReleaseName)
 		if !found {
@@ -45,7 +49,13 @@ func (i CpiInstaller) installCpiRelease(installer biinstall.Installer, installat
 }
 
 func (i CpiInstaller) WithInstalledCpiRelease(installationManifest biinstallmanifest.Manifest, target biinstall.Target, stage biui.Stage, fn func(biinstall.Installation) error) (errToReturn error) {
-	installer := i.InstallerFactory.NewInstaller(target)
+	var installer biinstall.Installer
+
+	if installationManifest.UsesLocalCPI() {
+		installer = i.InstallerFactory.NewLocalCPIInstaller(target)
+	} else {
+		installer = i.InstallerFactory.NewInstaller(target)
+	}
 
 	installation, err := i.installCpiRelease(installer, installationManifest, target, stage)
 	if err != nil {
@@ -13,6 +13,8 @@ import (
 
 type Deployment interface {
 	Delete(biui.Stage) error
+	Stop(deleteVM bool, stage biui.Stage) error
+	Start(biui.Stage) error
 }
 
 type deployment struct {
@@ -45,7 +47,8 @@ func (d *deployment) Delete(deleteStage biui.Stage) error {
 		lastIdx := len(d.instances) - 1
 		instance := d.instances[lastIdx]
 
-		if err := instance.Delete(d.pingTimeout, d.pingDelay, deleteStage); err != nil {
+		// jobs are not drained when deleting whole deployment
+		if err := instance.Delete(d.pingTimeout, d.pingDelay, true, deleteStage); err != nil {
 			return err
 		}
 
@@ -77,6 +80,28 @@ func (d *deployment) Delete(deleteStage biui.Stage) error {
 	return nil
 }
 
+// Stop stops jobs on all instances, optionally deleting their VMs.
+// Disks and stemcells are kept so that the deployment can be started again.
+func (d *deployment) Stop(deleteVM bool, stopStage biui.Stage) error {
+	for _, instance := range d.instances {
+		if err := instance.Stop(d.pingTimeout, d.pingDelay, deleteVM, stopStage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *deployment) Start(startStage biui.Stage) error {
+	for _, instance := range d.instances {
+		if err := instance.Start(d.pingTimeout, d.pingDelay, startStage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *deployment) deleteDisk(deleteStage biui.Stage, disk bidisk.Disk) error {
 	stepName := fmt.Sprintf("Deleting disk '%s'", disk.CID())
 	return deleteStage.Perform(stepName, func() error {
@@ -10,6 +10,7 @@ import (
 	mock_agentclient "github.com/cloudfoundry/bosh-cli/agentclient/mocks"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
 	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
+	mock_instance "github.com/cloudfoundry/bosh-cli/deployment/instance/mocks"
 	mock_instance_state "github.com/cloudfoundry/bosh-cli/deployment/instance/state/mocks"
 	"github.com/golang/mock/gomock"
 
@@ -105,7 +106,7 @@ var _ = Describe("Deployment", func() {
 				ConfigurationHash:        "",
 			}
 
-			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, mockAgentClient).Return(mockStateBuilder).AnyTimes()
+			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, mockAgentClient, gomock.Any()).Return(mockStateBuilder).AnyTimes()
 			mockState.EXPECT().ToApplySpec().Return(applySpec).AnyTimes()
 		}
 
@@ -381,7 +382,7 @@ var _ = Describe("Deployment", func() {
 		Context("when a current stemcell exists", func() {
 			BeforeEach(func() {
 				deploymentStateService.Save(biconfig.DeploymentState{})
-				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 				stemcellRepo.UpdateCurrent(stemcellRecord.ID)
 			})
@@ -413,4 +414,95 @@ var _ = Describe("Deployment", func() {
 			})
 		})
 	})
+
+	Describe("Stop", func() {
+		var (
+			firstInstance  *mock_instance.MockInstance
+			secondInstance *mock_instance.MockInstance
+			fakeStage      *fakebiui.FakeStage
+			deployment     Deployment
+
+			pingTimeout = 10 * time.Second
+			pingDelay   = 500 * time.Millisecond
+		)
+
+		BeforeEach(func() {
+			firstInstance = mock_instance.NewMockInstance(mockCtrl)
+			secondInstance = mock_instance.NewMockInstance(mockCtrl)
+			fakeStage = fakebiui.NewFakeStage()
+
+			deployment = NewDeployment(
+				[]biinstance.Instance{firstInstance, secondInstance},
+				[]bidisk.Disk{},
+				[]bistemcell.CloudStemcell{},
+				pingTimeout,
+				pingDelay,
+			)
+		})
+
+		It("stops all instances", func() {
+			gomock.InOrder(
+				firstInstance.EXPECT().Stop(pingTimeout, pingDelay, false, fakeStage),
+				secondInstance.EXPECT().Stop(pingTimeout, pingDelay, false, fakeStage),
+			)
+
+			err := deployment.Stop(false, fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("asks instances to delete their VMs if requested", func() {
+			firstInstance.EXPECT().Stop(pingTimeout, pingDelay, true, fakeStage)
+			secondInstance.EXPECT().Stop(pingTimeout, pingDelay, true, fakeStage)
+
+			err := deployment.Stop(true, fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if stopping an instance fails", func() {
+			firstInstance.EXPECT().Stop(pingTimeout, pingDelay, false, fakeStage).Return(bosherr.Error("fake-stop-err"))
+
+			err := deployment.Stop(false, fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-stop-err"))
+		})
+	})
+
+	Describe("Start", func() {
+		var (
+			instance   *mock_instance.MockInstance
+			fakeStage  *fakebiui.FakeStage
+			deployment Deployment
+
+			pingTimeout = 10 * time.Second
+			pingDelay   = 500 * time.Millisecond
+		)
+
+		BeforeEach(func() {
+			instance = mock_instance.NewMockInstance(mockCtrl)
+			fakeStage = fakebiui.NewFakeStage()
+
+			deployment = NewDeployment(
+				[]biinstance.Instance{instance},
+				[]bidisk.Disk{},
+				[]bistemcell.CloudStemcell{},
+				pingTimeout,
+				pingDelay,
+			)
+		})
+
+		It("starts all instances", func() {
+			instance.EXPECT().Start(pingTimeout, pingDelay, fakeStage)
+
+			err := deployment.Start(fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error if starting an instance fails", func() {
+			instance.EXPECT().Start(pingTimeout, pingDelay, fakeStage).Return(bosherr.Error("fake-start-err"))
+
+			err := deployment.Start(fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-start-err"))
+		})
+	})
 })
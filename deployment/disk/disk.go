@@ -12,6 +12,8 @@ import (
 type Disk interface {
 	CID() string
 	NeedsMigration(newSize int, newCloudProperties biproperty.Map) bool
+	NeedsResize(newSize int, newCloudProperties biproperty.Map) bool
+	Resize(newSize int) error
 	Delete() error
 }
 
@@ -46,6 +48,32 @@ func (d *disk) NeedsMigration(newSize int, newCloudProperties biproperty.Map) bo
 	return d.size != newSize || !reflect.DeepEqual(d.cloudProperties, newCloudProperties)
 }
 
+// NeedsResize is true when disk could be grown in place instead of migrated
+func (d *disk) NeedsResize(newSize int, newCloudProperties biproperty.Map) bool {
+	return newSize > d.size && reflect.DeepEqual(d.cloudProperties, newCloudProperties)
+}
+
+func (d *disk) Resize(newSize int) error {
+	err := d.cloud.ResizeDisk(d.cid, newSize)
+	if err != nil {
+		// returns bicloud.Error as is if it is a NotImplementedError so that disk could be migrated instead
+		cloudErr, ok := err.(bicloud.Error)
+		if ok && cloudErr.Type() == bicloud.NotImplementedError {
+			return cloudErr
+		}
+		return bosherr.WrapError(err, "Resizing disk in the cloud")
+	}
+
+	err = d.repo.UpdateSize(d.cid, newSize)
+	if err != nil {
+		return bosherr.WrapError(err, "Updating disk record")
+	}
+
+	d.size = newSize
+
+	return nil
+}
+
 func (d *disk) Delete() error {
 	deleteErr := d.cloud.DeleteDisk(d.cid)
 	if deleteErr != nil {
@@ -82,6 +82,67 @@ var _ = Describe("Disk", func() {
 		})
 	})
 
+	Describe("NeedsResize", func() {
+		It("returns true when only size increased", func() {
+			Expect(disk.NeedsResize(2048, diskCloudProperties)).To(BeTrue())
+		})
+
+		It("returns false when size decreased", func() {
+			Expect(disk.NeedsResize(512, diskCloudProperties)).To(BeFalse())
+		})
+
+		It("returns false when cloud properties are different", func() {
+			newDiskCloudProperties := biproperty.Map{
+				"fake-cloud-property-key": "new-fake-cloud-property-value",
+			}
+
+			Expect(disk.NeedsResize(2048, newDiskCloudProperties)).To(BeFalse())
+		})
+	})
+
+	Describe("Resize", func() {
+		BeforeEach(func() {
+			_, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("resizes disk in the cloud and updates disk record", func() {
+			err := disk.Resize(2048)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.ResizeDiskInputs).To(Equal([]fakebicloud.ResizeDiskInput{
+				{DiskCID: "fake-disk-cid", NewSize: 2048},
+			}))
+
+			diskRecord, found, err := diskRepo.Find("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(diskRecord.Size).To(Equal(2048))
+
+			Expect(disk.NeedsMigration(2048, diskCloudProperties)).To(BeFalse())
+		})
+
+		It("returns NotImplementedError as is so that disk could be migrated instead", func() {
+			resizeErr := bicloud.NewCPIError("resize_disk", bicloud.CmdError{Type: bicloud.NotImplementedError})
+			fakeCloud.ResizeDiskErr = resizeErr
+
+			err := disk.Resize(2048)
+			Expect(err).To(Equal(resizeErr))
+
+			diskRecord, _, err := diskRepo.Find("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskRecord.Size).To(Equal(1024))
+		})
+
+		It("returns an error when resizing disk in the cloud fails", func() {
+			fakeCloud.ResizeDiskErr = errors.New("fake-resize-disk-error")
+
+			err := disk.Resize(2048)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-resize-disk-error"))
+		})
+	})
+
 	Describe("Delete", func() {
 		It("deletes disk from cloud", func() {
 			err := disk.Delete()
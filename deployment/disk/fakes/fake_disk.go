@@ -10,6 +10,12 @@ type FakeDisk struct {
 	NeedsMigrationInputs []NeedsMigrationInput
 	needsMigrationOutput needsMigrationOutput
 
+	NeedsResizeInputs []NeedsMigrationInput
+	needsResize       bool
+
+	ResizeInputs []int
+	resizeErr    error
+
 	DeleteCalledTimes int
 	deleteErr         error
 }
@@ -43,6 +49,20 @@ func (d *FakeDisk) NeedsMigration(size int, cloudProperties biproperty.Map) bool
 	return d.needsMigrationOutput.needsMigration
 }
 
+func (d *FakeDisk) NeedsResize(size int, cloudProperties biproperty.Map) bool {
+	d.NeedsResizeInputs = append(d.NeedsResizeInputs, NeedsMigrationInput{
+		Size:            size,
+		CloudProperties: cloudProperties,
+	})
+
+	return d.needsResize
+}
+
+func (d *FakeDisk) Resize(size int) error {
+	d.ResizeInputs = append(d.ResizeInputs, size)
+	return d.resizeErr
+}
+
 func (d *FakeDisk) Delete() error {
 	d.DeleteCalledTimes++
 	return d.deleteErr
@@ -54,6 +74,14 @@ func (d *FakeDisk) SetNeedsMigrationBehavior(needsMigration bool) {
 	}
 }
 
+func (d *FakeDisk) SetNeedsResizeBehavior(needsResize bool) {
+	d.needsResize = needsResize
+}
+
+func (d *FakeDisk) SetResizeBehavior(err error) {
+	d.resizeErr = err
+}
+
 func (d *FakeDisk) SetDeleteBehavior(err error) {
 	d.deleteErr = err
 }
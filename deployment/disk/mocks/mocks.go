@@ -62,6 +62,26 @@ func (_mr *_MockDiskRecorder) NeedsMigration(arg0, arg1 interface{}) *gomock.Cal
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "NeedsMigration", arg0, arg1)
 }
 
+func (_m *MockDisk) NeedsResize(_param0 int, _param1 property.Map) bool {
+	ret := _m.ctrl.Call(_m, "NeedsResize", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+func (_mr *_MockDiskRecorder) NeedsResize(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NeedsResize", arg0, arg1)
+}
+
+func (_m *MockDisk) Resize(_param0 int) error {
+	ret := _m.ctrl.Call(_m, "Resize", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockDiskRecorder) Resize(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Resize", arg0)
+}
+
 // Mock of Manager interface
 type MockManager struct {
 	ctrl     *gomock.Controller
@@ -5,6 +5,8 @@ import (
 	biinstancestate "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
@@ -16,6 +18,7 @@ type Factory interface {
 		vmManager bivm.Manager,
 		sshTunnelFactory bisshtunnel.Factory,
 		blobstore biblobstore.Blobstore,
+		stemcell bistemcell.CloudStemcell,
 		logger boshlog.Logger,
 	) Instance
 }
@@ -39,9 +42,17 @@ func (f *factory) NewInstance(
 	vmManager bivm.Manager,
 	sshTunnelFactory bisshtunnel.Factory,
 	blobstore biblobstore.Blobstore,
+	stemcell bistemcell.CloudStemcell,
 	logger boshlog.Logger,
 ) Instance {
-	stateBuilder := f.stateBuilderFactory.NewBuilder(blobstore, vm.AgentClient())
+	// Stemcell is not known for instances found in deployment state;
+	// those are only deleted so their packages are never compiled
+	var compiledPackageStemcell bistatepkg.Stemcell
+	if stemcell != nil {
+		compiledPackageStemcell = bistatepkg.Stemcell{OS: stemcell.OS(), Version: stemcell.Version()}
+	}
+
+	stateBuilder := f.stateBuilderFactory.NewBuilder(blobstore, vm.AgentClient(), compiledPackageStemcell)
 
 	return NewInstance(
 		jobName,
@@ -24,12 +24,28 @@ type Instance interface {
 	UpdateDisks(bideplmanifest.Manifest, biui.Stage) ([]bidisk.Disk, error)
 	UpdateJobs(bideplmanifest.Manifest, biui.Stage) error
 	Delete(
+		pingTimeout time.Duration,
+		pingDelay time.Duration,
+		skipDrain bool,
+		stage biui.Stage,
+	) error
+	Stop(
+		pingTimeout time.Duration,
+		pingDelay time.Duration,
+		deleteVM bool,
+		stage biui.Stage,
+	) error
+	Start(
 		pingTimeout time.Duration,
 		pingDelay time.Duration,
 		stage biui.Stage,
 	) error
 }
 
+// startWatchTime is used when starting jobs without a deployment manifest
+// and matches default update_watch_time of a deployment manifest
+var startWatchTime = bideplmanifest.WatchTime{Start: 0, End: 300000}
+
 type instance struct {
 	jobName          string
 	id               int
@@ -199,6 +215,7 @@ func (i *instance) UpdateJobs(
 func (i *instance) Delete(
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
+	skipDrain bool,
 	stage biui.Stage,
 ) error {
 	vmExists, err := i.vm.Exists()
@@ -207,40 +224,84 @@ func (i *instance) Delete(
 	}
 
 	if vmExists {
-		if err = i.shutdown(pingTimeout, pingDelay, stage); err != nil {
+		if err = i.shutdown(pingTimeout, pingDelay, skipDrain, stage); err != nil {
 			return err
 		}
 	}
 
 	// non-existent VMs still need to be 'deleted' to clean up related resources owned by the CPI
-	stepName := fmt.Sprintf("Deleting VM '%s'", i.vm.CID())
-	return stage.Perform(stepName, func() error {
-		err := i.vm.Delete()
-		cloudErr, ok := err.(bicloud.Error)
-		if ok && cloudErr.Type() == bicloud.VMNotFoundError {
-			return biui.NewSkipStageError(cloudErr, "VM not found")
-		}
+	return i.deleteVM(stage)
+}
+
+// Stop drains and stops jobs on the instance. When deleteVM is set
+// disks are unmounted and the VM is deleted; disks remain recorded
+// so that the VM can be recreated with the same persistent disk.
+func (i *instance) Stop(
+	pingTimeout time.Duration,
+	pingDelay time.Duration,
+	deleteVM bool,
+	stage biui.Stage,
+) error {
+	if err := i.waitForAgent(pingTimeout, pingDelay, stage); err != nil {
+		return err
+	}
+	if err := i.drainJobs(stage); err != nil {
+		return err
+	}
+	if err := i.stopJobs(stage); err != nil {
+		return err
+	}
+
+	if !deleteVM {
+		return nil
+	}
+
+	if err := i.unmountDisks(stage); err != nil {
+		return err
+	}
+
+	return i.deleteVM(stage)
+}
+
+// Start starts previously stopped jobs on the instance.
+func (i *instance) Start(
+	pingTimeout time.Duration,
+	pingDelay time.Duration,
+	stage biui.Stage,
+) error {
+	if err := i.waitForAgent(pingTimeout, pingDelay, stage); err != nil {
 		return err
+	}
+
+	stepName := fmt.Sprintf("Starting jobs on instance '%s/%d'", i.jobName, i.id)
+	err := stage.Perform(stepName, func() error {
+		return i.vm.Start()
 	})
+	if err != nil {
+		return err
+	}
+
+	return i.waitUntilJobsAreRunning(startWatchTime, stage)
 }
 
 func (i *instance) shutdown(
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
+	skipDrain bool,
 	stage biui.Stage,
 ) error {
-	stepName := fmt.Sprintf("Waiting for the agent on VM '%s'", i.vm.CID())
-	waitingForAgentErr := stage.Perform(stepName, func() error {
-		if err := i.vm.WaitUntilReady(pingTimeout, pingDelay); err != nil {
-			return bosherr.WrapError(err, "Agent unreachable")
-		}
-		return nil
-	})
+	waitingForAgentErr := i.waitForAgent(pingTimeout, pingDelay, stage)
 	if waitingForAgentErr != nil {
 		i.logger.Warn(i.logTag, "Gave up waiting for agent: %s", waitingForAgentErr.Error())
 		return nil
 	}
 
+	if !skipDrain {
+		if err := i.drainJobs(stage); err != nil {
+			return err
+		}
+	}
+
 	if err := i.stopJobs(stage); err != nil {
 		return err
 	}
@@ -250,6 +311,28 @@ func (i *instance) shutdown(
 	return nil
 }
 
+func (i *instance) waitForAgent(pingTimeout, pingDelay time.Duration, stage biui.Stage) error {
+	stepName := fmt.Sprintf("Waiting for the agent on VM '%s'", i.vm.CID())
+	return stage.Perform(stepName, func() error {
+		if err := i.vm.WaitUntilReady(pingTimeout, pingDelay); err != nil {
+			return bosherr.WrapError(err, "Agent unreachable")
+		}
+		return nil
+	})
+}
+
+func (i *instance) deleteVM(stage biui.Stage) error {
+	stepName := fmt.Sprintf("Deleting VM '%s'", i.vm.CID())
+	return stage.Perform(stepName, func() error {
+		err := i.vm.Delete()
+		cloudErr, ok := err.(bicloud.Error)
+		if ok && cloudErr.Type() == bicloud.VMNotFoundError {
+			return biui.NewSkipStageError(cloudErr, "VM not found")
+		}
+		return err
+	})
+}
+
 func (i *instance) waitUntilJobsAreRunning(updateWatchTime bideplmanifest.WatchTime, stage biui.Stage) error {
 	start := time.Duration(updateWatchTime.Start) * time.Millisecond
 	end := time.Duration(updateWatchTime.End) * time.Millisecond
@@ -263,6 +346,13 @@ func (i *instance) waitUntilJobsAreRunning(updateWatchTime bideplmanifest.WatchT
 	})
 }
 
+func (i *instance) drainJobs(stage biui.Stage) error {
+	stepName := fmt.Sprintf("Draining jobs on instance '%s/%d'", i.jobName, i.id)
+	return stage.Perform(stepName, func() error {
+		return i.vm.Drain()
+	})
+}
+
 func (i *instance) stopJobs(stage biui.Stage) error {
 	stepName := fmt.Sprintf("Stopping jobs on instance '%s/%d'", i.jobName, i.id)
 	return stage.Perform(stepName, func() error {
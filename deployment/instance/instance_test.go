@@ -19,10 +19,15 @@ import (
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 
 	"github.com/cloudfoundry/bosh-agent/agentclient"
+	fakebiagentclient "github.com/cloudfoundry/bosh-agent/agentclient/fakes"
+	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	fakebidisk "github.com/cloudfoundry/bosh-cli/deployment/disk/fakes"
 	fakebisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel/fakes"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	fakebivm "github.com/cloudfoundry/bosh-cli/deployment/vm/fakes"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
@@ -87,7 +92,7 @@ var _ = Describe("Instance", func() {
 
 	Describe("Delete", func() {
 		It("checks if the agent on the vm is responsive", func() {
-			err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+			err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeVM.WaitUntilReadyInputs).To(ContainElement(fakebivm.WaitUntilReadyInput{
@@ -97,14 +102,14 @@ var _ = Describe("Instance", func() {
 		})
 
 		It("deletes existing vm", func() {
-			err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+			err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeVM.DeleteCalled).To(Equal(1))
 		})
 
 		It("logs start and stop events", func() {
-			err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+			err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
@@ -116,7 +121,7 @@ var _ = Describe("Instance", func() {
 
 		Context("when agent is responsive", func() {
 			It("logs waiting for the agent event", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls[0]).To(Equal(&fakebiui.PerformCall{
@@ -125,7 +130,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("stops vm", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeVM.StopCalled).To(Equal(1))
@@ -136,7 +141,7 @@ var _ = Describe("Instance", func() {
 				secondDisk := fakebidisk.NewFakeDisk("fake-disk-2")
 				fakeVM.ListDisksDisks = []bidisk.Disk{firstDisk, secondDisk}
 
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeVM.UnmountDiskInputs).To(Equal([]fakebivm.UnmountDiskInput{
@@ -160,7 +165,7 @@ var _ = Describe("Instance", func() {
 				})
 
 				It("returns an error", func() {
-					err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+					err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-stop-error"))
 
@@ -181,7 +186,7 @@ var _ = Describe("Instance", func() {
 				})
 
 				It("returns an error", func() {
-					err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+					err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-unmount-error"))
 
@@ -192,13 +197,38 @@ var _ = Describe("Instance", func() {
 			})
 		})
 
+		Context("when drain is not skipped", func() {
+			It("drains jobs before stopping them", func() {
+				err := instance.Delete(pingTimeout, pingDelay, false, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.DrainCalled).To(Equal(1))
+				Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+					{Name: "Waiting for the agent on VM 'fake-vm-cid'"},
+					{Name: "Draining jobs on instance 'fake-job-name/0'"},
+					{Name: "Stopping jobs on instance 'fake-job-name/0'"},
+					{Name: "Deleting VM 'fake-vm-cid'"},
+				}))
+			})
+
+			It("returns an error if draining fails", func() {
+				fakeVM.DrainErr = bosherr.Error("fake-drain-error")
+
+				err := instance.Delete(pingTimeout, pingDelay, false, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-drain-error"))
+				Expect(fakeVM.StopCalled).To(Equal(0))
+				Expect(fakeVM.DeleteCalled).To(Equal(0))
+			})
+		})
+
 		Context("when agent fails to respond", func() {
 			BeforeEach(func() {
 				fakeVM.WaitUntilReadyErr = bosherr.Error("fake-wait-error")
 			})
 
 			It("logs failed event", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls[0].Name).To(Equal("Waiting for the agent on VM 'fake-vm-cid'"))
@@ -216,7 +246,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("returns an error", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-delete-error"))
 
@@ -241,14 +271,14 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("deletes existing vm", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeVM.DeleteCalled).To(Equal(1))
 			})
 
 			It("does not contact the agent", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeVM.WaitUntilReadyInputs).To(HaveLen(0))
@@ -257,7 +287,7 @@ var _ = Describe("Instance", func() {
 			})
 
 			It("logs vm delete as skipped", func() {
-				err := instance.Delete(pingTimeout, pingDelay, fakeStage)
+				err := instance.Delete(pingTimeout, pingDelay, true, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls[0].Name).To(Equal("Deleting VM 'fake-vm-cid'"))
@@ -266,6 +296,174 @@ var _ = Describe("Instance", func() {
 		})
 	})
 
+	Describe("Delete with drain", func() {
+		var (
+			events []string
+		)
+
+		BeforeEach(func() {
+			events = []string{}
+
+			agentClient := &fakeDrainingAgentClient{
+				FakeAgentClient: &fakebiagentclient.FakeAgentClient{},
+				results:         []int{-5, 3},
+				events:          &events,
+			}
+			agentClient.StopStub = func() error {
+				events = append(events, "stop")
+				return nil
+			}
+
+			cloud := &fakeRecordingCloud{FakeCloud: fakebicloud.NewFakeCloud(), events: &events}
+			cloud.HasVMFound = true
+
+			vm := bivm.NewVM(
+				"fake-vm-cid",
+				fakebiconfig.NewFakeVMRepo(),
+				fakebiconfig.NewFakeStemcellRepo(),
+				fakebivm.NewFakeDiskDeployer(),
+				agentClient,
+				cloud,
+				&fakeRecordingClock{events: &events},
+				fakesys.NewFakeFileSystem(),
+				boshlog.NewLogger(boshlog.LevelNone),
+			)
+
+			instance = NewInstance(
+				jobName,
+				jobIndex,
+				vm,
+				fakeVMManager,
+				fakeSSHTunnelFactory,
+				mockStateBuilder,
+				boshlog.NewLogger(boshlog.LevelNone),
+			)
+		})
+
+		It("deletes vm only after waiting for drain to finish", func() {
+			err := instance.Delete(pingTimeout, pingDelay, false, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(events).To(Equal([]string{
+				"drain shutdown",
+				"sleep 5s",
+				"drain status",
+				"sleep 3s",
+				"stop",
+				"delete_vm fake-vm-cid",
+			}))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("drains and stops jobs", func() {
+			err := instance.Stop(pingTimeout, pingDelay, false, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVM.WaitUntilReadyInputs).To(ContainElement(fakebivm.WaitUntilReadyInput{
+				Timeout: pingTimeout,
+				Delay:   pingDelay,
+			}))
+			Expect(fakeVM.DrainCalled).To(Equal(1))
+			Expect(fakeVM.StopCalled).To(Equal(1))
+			Expect(fakeVM.DeleteCalled).To(Equal(0))
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+				{Name: "Waiting for the agent on VM 'fake-vm-cid'"},
+				{Name: "Draining jobs on instance 'fake-job-name/0'"},
+				{Name: "Stopping jobs on instance 'fake-job-name/0'"},
+			}))
+		})
+
+		It("unmounts disks and deletes vm when requested", func() {
+			fakeVM.ListDisksDisks = []bidisk.Disk{fakebidisk.NewFakeDisk("fake-disk-1")}
+
+			err := instance.Stop(pingTimeout, pingDelay, true, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVM.UnmountDiskInputs).To(HaveLen(1))
+			Expect(fakeVM.DeleteCalled).To(Equal(1))
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+				{Name: "Waiting for the agent on VM 'fake-vm-cid'"},
+				{Name: "Draining jobs on instance 'fake-job-name/0'"},
+				{Name: "Stopping jobs on instance 'fake-job-name/0'"},
+				{Name: "Unmounting disk 'fake-disk-1'"},
+				{Name: "Deleting VM 'fake-vm-cid'"},
+			}))
+		})
+
+		Context("when agent fails to respond", func() {
+			BeforeEach(func() {
+				fakeVM.WaitUntilReadyErr = bosherr.Error("fake-wait-error")
+			})
+
+			It("returns an error", func() {
+				err := instance.Stop(pingTimeout, pingDelay, false, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Agent unreachable: fake-wait-error"))
+				Expect(fakeVM.StopCalled).To(Equal(0))
+			})
+		})
+
+		Context("when draining fails", func() {
+			BeforeEach(func() {
+				fakeVM.DrainErr = bosherr.Error("fake-drain-error")
+			})
+
+			It("does not stop jobs", func() {
+				err := instance.Stop(pingTimeout, pingDelay, false, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-drain-error"))
+				Expect(fakeVM.StopCalled).To(Equal(0))
+			})
+		})
+
+		Context("when stopping jobs fails", func() {
+			BeforeEach(func() {
+				fakeVM.StopErr = bosherr.Error("fake-stop-error")
+			})
+
+			It("does not delete vm", func() {
+				err := instance.Stop(pingTimeout, pingDelay, true, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-stop-error"))
+				Expect(fakeVM.DeleteCalled).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Start", func() {
+		It("starts jobs and waits for them to be running", func() {
+			err := instance.Start(pingTimeout, pingDelay, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeVM.StartCalled).To(Equal(1))
+			Expect(fakeVM.WaitToBeRunningInputs).To(Equal([]fakebivm.WaitInput{
+				{MaxAttempts: 300, Delay: 1 * time.Second},
+			}))
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+				{Name: "Waiting for the agent on VM 'fake-vm-cid'"},
+				{Name: "Starting jobs on instance 'fake-job-name/0'"},
+				{Name: "Waiting for instance 'fake-job-name/0' to be running"},
+			}))
+		})
+
+		Context("when starting jobs fails", func() {
+			BeforeEach(func() {
+				fakeVM.StartErr = bosherr.Error("fake-start-error")
+			})
+
+			It("returns an error", func() {
+				err := instance.Start(pingTimeout, pingDelay, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-start-error"))
+				Expect(fakeVM.WaitToBeRunningInputs).To(HaveLen(0))
+			})
+		})
+	})
+
 	Describe("UpdateJobs", func() {
 		var (
 			deploymentManifest bideplmanifest.Manifest
@@ -593,3 +791,40 @@ var _ = Describe("Instance", func() {
 		})
 	})
 })
+
+type fakeDrainingAgentClient struct {
+	*fakebiagentclient.FakeAgentClient
+
+	results []int
+	events  *[]string
+}
+
+func (c *fakeDrainingAgentClient) Drain(drainType string) (int, error) {
+	*c.events = append(*c.events, "drain "+drainType)
+	result := c.results[0]
+	c.results = c.results[1:]
+	return result, nil
+}
+
+type fakeRecordingCloud struct {
+	*fakebicloud.FakeCloud
+
+	events *[]string
+}
+
+func (c *fakeRecordingCloud) DeleteVM(vmCID string) error {
+	*c.events = append(*c.events, "delete_vm "+vmCID)
+	return c.FakeCloud.DeleteVM(vmCID)
+}
+
+type fakeRecordingClock struct {
+	events *[]string
+}
+
+func (c *fakeRecordingClock) Sleep(d time.Duration) {
+	*c.events = append(*c.events, "sleep "+d.String())
+}
+
+func (c *fakeRecordingClock) Now() time.Time {
+	return time.Now()
+}
@@ -30,6 +30,7 @@ type Manager interface {
 	DeleteAll(
 		pingTimeout time.Duration,
 		pingDelay time.Duration,
+		skipDrain bool,
 		eventLoggerStage biui.Stage,
 	) error
 }
@@ -84,6 +85,7 @@ func (m *manager) FindCurrent() ([]Instance, error) {
 			m.vmManager,
 			m.sshTunnelFactory,
 			m.blobstore,
+			nil,
 			m.logger,
 		)
 		instances = append(instances, instance)
@@ -119,7 +121,7 @@ func (m *manager) Create(
 		return nil, []bidisk.Disk{}, err
 	}
 
-	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, m.logger)
+	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, cloudStemcell, m.logger)
 
 	if err := instance.WaitUntilReady(registryConfig, eventLoggerStage); err != nil {
 		return instance, []bidisk.Disk{}, bosherr.WrapError(err, "Waiting until instance is ready")
@@ -136,6 +138,7 @@ func (m *manager) Create(
 func (m *manager) DeleteAll(
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
+	skipDrain bool,
 	eventLoggerStage biui.Stage,
 ) error {
 	instances, err := m.FindCurrent()
@@ -144,7 +147,7 @@ func (m *manager) DeleteAll(
 	}
 
 	for _, instance := range instances {
-		if err = instance.Delete(pingTimeout, pingDelay, eventLoggerStage); err != nil {
+		if err = instance.Delete(pingTimeout, pingDelay, skipDrain, eventLoggerStage); err != nil {
 			return bosherr.WrapErrorf(err, "Deleting existing instance '%s/%d'", instance.JobName(), instance.ID())
 		}
 	}
@@ -74,8 +74,10 @@ func (b *builder) Build(jobName string, instanceID int, deploymentManifest bidep
 	}
 
 	releaseJobProperties := make(map[string]*biproperty.Map)
+	linkOverrides := make(map[string]bitemplate.LinkOverrides)
 	for _, releaseJob := range deploymentJob.Templates {
 		releaseJobProperties[releaseJob.Name] = releaseJob.Properties
+		linkOverrides[releaseJob.Name] = b.linkOverrides(releaseJob)
 	}
 
 	defaultAddress, err := b.defaultAddress(initialState.NetworkInterfaces(), agentState)
@@ -83,7 +85,7 @@ func (b *builder) Build(jobName string, instanceID int, deploymentManifest bidep
 		return nil, err
 	}
 
-	renderedJobTemplates, err := b.renderJobTemplates(releaseJobs, releaseJobProperties, deploymentJob.Properties, deploymentManifest.Properties, deploymentManifest.Name, defaultAddress, stage)
+	renderedJobTemplates, err := b.renderJobTemplates(releaseJobs, releaseJobProperties, linkOverrides, deploymentJob.Properties, deploymentManifest.Properties, deploymentManifest.Name, defaultAddress, stage)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Rendering job templates for instance '%s/%d'", jobName, instanceID)
 	}
@@ -179,10 +181,52 @@ func (b *builder) resolveJobs(jobRefs []bideplmanifest.ReleaseJobRef) ([]bireljo
 	return releaseJobs, nil
 }
 
+// linkOverrides converts links specified for a release job in the deployment manifest
+func (b *builder) linkOverrides(jobRef bideplmanifest.ReleaseJobRef) bitemplate.LinkOverrides {
+	overrides := bitemplate.LinkOverrides{
+		Provides: map[string]bitemplate.ProvidedLinkOverride{},
+		Consumes: map[string]bitemplate.ConsumedLinkOverride{},
+	}
+
+	for name, providedLink := range jobRef.Provides {
+		overrides.Provides[name] = bitemplate.ProvidedLinkOverride{As: providedLink.As}
+	}
+
+	for name, consumedLink := range jobRef.Consumes {
+		override := bitemplate.ConsumedLinkOverride{
+			From:     consumedLink.From,
+			Disabled: consumedLink.Disabled,
+		}
+
+		if consumedLink.Manual != nil {
+			manualLink := &bitemplate.Link{
+				Address:    consumedLink.Manual.Address,
+				Instances:  []bitemplate.LinkInstance{},
+				Properties: consumedLink.Manual.Properties,
+			}
+
+			for i, instance := range consumedLink.Manual.Instances {
+				manualLink.Instances = append(manualLink.Instances, bitemplate.LinkInstance{
+					Index:     i,
+					Address:   instance.Address,
+					Bootstrap: i == 0,
+				})
+			}
+
+			override.Manual = manualLink
+		}
+
+		overrides.Consumes[name] = override
+	}
+
+	return overrides
+}
+
 // renderJobTemplates renders all the release job templates for multiple release jobs specified by a deployment job
 func (b *builder) renderJobTemplates(
 	releaseJobs []bireljob.Job,
 	releaseJobProperties map[string]*biproperty.Map,
+	linkOverrides map[string]bitemplate.LinkOverrides,
 	jobProperties biproperty.Map,
 	globalProperties biproperty.Map,
 	deploymentName string,
@@ -194,7 +238,7 @@ func (b *builder) renderJobTemplates(
 		blobID                 string
 	)
 	err := stage.Perform("Rendering job templates", func() error {
-		renderedJobList, err := b.jobListRenderer.Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address)
+		renderedJobList, err := b.jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
 		if err != nil {
 			return err
 		}
@@ -11,36 +11,42 @@ import (
 )
 
 type BuilderFactory interface {
-	NewBuilder(biblobstore.Blobstore, biagentclient.AgentClient) Builder
+	NewBuilder(biblobstore.Blobstore, biagentclient.AgentClient, bistatepkg.Stemcell) Builder
 }
 
 type builderFactory struct {
 	packageRepo               bistatepkg.CompiledPackageRepo
+	packageCache              bistatepkg.CompiledPackageCache
 	releaseJobResolver        bideplrel.JobResolver
 	jobRenderer               bitemplate.JobListRenderer
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor
+	parallel                  int
 	logger                    boshlog.Logger
 }
 
 func NewBuilderFactory(
 	packageRepo bistatepkg.CompiledPackageRepo,
+	packageCache bistatepkg.CompiledPackageCache,
 	releaseJobResolver bideplrel.JobResolver,
 	jobRenderer bitemplate.JobListRenderer,
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor,
+	parallel int,
 	logger boshlog.Logger,
 ) BuilderFactory {
 	return &builderFactory{
 		packageRepo:               packageRepo,
+		packageCache:              packageCache,
 		releaseJobResolver:        releaseJobResolver,
 		jobRenderer:               jobRenderer,
 		renderedJobListCompressor: renderedJobListCompressor,
+		parallel:                  parallel,
 		logger: logger,
 	}
 }
 
-func (f *builderFactory) NewBuilder(blobstore biblobstore.Blobstore, agentClient biagentclient.AgentClient) Builder {
-	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, f.packageRepo)
-	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, f.logger)
+func (f *builderFactory) NewBuilder(blobstore biblobstore.Blobstore, agentClient biagentclient.AgentClient, stemcell bistatepkg.Stemcell) Builder {
+	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, f.packageRepo, f.packageCache, stemcell, f.logger)
+	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, f.parallel, f.logger)
 
 	return NewBuilder(
 		f.releaseJobResolver,
@@ -18,6 +18,7 @@ import (
 	. "github.com/cloudfoundry/bosh-cli/release/resource"
 	bistatejob "github.com/cloudfoundry/bosh-cli/state/job"
 	mock_state_job "github.com/cloudfoundry/bosh-cli/state/job/mocks"
+	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
 	mock_template "github.com/cloudfoundry/bosh-cli/templatescompiler/mocks"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
@@ -190,6 +191,19 @@ var _ = Describe("Builder", func() {
 								Properties: &biproperty.Map{
 									"fake-template-property": "fake-template-property-value",
 								},
+								Provides: map[string]bideplmanifest.ProvidedLink{
+									"fake-provided-link": {As: "fake-alias"},
+								},
+								Consumes: map[string]bideplmanifest.ConsumedLink{
+									"fake-consumed-link": {From: "fake-alias"},
+									"fake-disabled-link": {Disabled: true},
+									"fake-manual-link": {
+										Manual: &bideplmanifest.ManualLink{
+											Instances:  []bideplmanifest.ManualLinkInstance{{Address: "fake-address"}},
+											Properties: biproperty.Map{"fake-link-property": "fake-link-property-value"},
+										},
+									},
+								},
 							},
 						},
 						Properties: biproperty.Map{
@@ -284,7 +298,25 @@ var _ = Describe("Builder", func() {
 				"fake-job-property": "fake-global-property-value",
 			}
 
-			mockJobListRenderer.EXPECT().Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", expectedIP).Return(mockRenderedJobList, nil)
+			linkOverrides := map[string]bitemplate.LinkOverrides{
+				"job-name": {
+					Provides: map[string]bitemplate.ProvidedLinkOverride{
+						"fake-provided-link": {As: "fake-alias"},
+					},
+					Consumes: map[string]bitemplate.ConsumedLinkOverride{
+						"fake-consumed-link": {From: "fake-alias"},
+						"fake-disabled-link": {Disabled: true},
+						"fake-manual-link": {
+							Manual: &bitemplate.Link{
+								Instances:  []bitemplate.LinkInstance{{Index: 0, Address: "fake-address", Bootstrap: true}},
+								Properties: biproperty.Map{"fake-link-property": "fake-link-property-value"},
+							},
+						},
+					},
+				},
+			}
+
+			mockJobListRenderer.EXPECT().Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, "fake-deployment-name", expectedIP).Return(mockRenderedJobList, nil)
 
 			mockRenderedJobList.EXPECT().DeleteSilently()
 
@@ -9,6 +9,7 @@ import (
 	blobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	state "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	manifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	pkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	ui "github.com/cloudfoundry/bosh-cli/ui"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,14 +35,14 @@ func (_m *MockBuilderFactory) EXPECT() *_MockBuilderFactoryRecorder {
 	return _m.recorder
 }
 
-func (_m *MockBuilderFactory) NewBuilder(_param0 blobstore.Blobstore, _param1 agentclient.AgentClient) state.Builder {
-	ret := _m.ctrl.Call(_m, "NewBuilder", _param0, _param1)
+func (_m *MockBuilderFactory) NewBuilder(_param0 blobstore.Blobstore, _param1 agentclient.AgentClient, _param2 pkg.Stemcell) state.Builder {
+	ret := _m.ctrl.Call(_m, "NewBuilder", _param0, _param1, _param2)
 	ret0, _ := ret[0].(state.Builder)
 	return ret0
 }
 
-func (_mr *_MockBuilderFactoryRecorder) NewBuilder(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewBuilder", arg0, arg1)
+func (_mr *_MockBuilderFactoryRecorder) NewBuilder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewBuilder", arg0, arg1, arg2)
 }
 
 // Mock of Builder interface
@@ -6,29 +6,58 @@ import (
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 type remotePackageCompiler struct {
-	blobstore   biblobstore.Blobstore
-	agentClient biagentclient.AgentClient
-	packageRepo bistatepkg.CompiledPackageRepo
+	blobstore    biblobstore.Blobstore
+	agentClient  biagentclient.AgentClient
+	packageRepo  bistatepkg.CompiledPackageRepo
+	packageCache bistatepkg.CompiledPackageCache
+	stemcell     bistatepkg.Stemcell
+
+	logTag string
+	logger boshlog.Logger
 }
 
+// NewRemotePackageCompiler returns compiler that consults package cache,
+// when one is given and stemcell operating system is known,
+// before compiling packages with the agent.
 func NewRemotePackageCompiler(
 	blobstore biblobstore.Blobstore,
 	agentClient biagentclient.AgentClient,
 	packageRepo bistatepkg.CompiledPackageRepo,
+	packageCache bistatepkg.CompiledPackageCache,
+	stemcell bistatepkg.Stemcell,
+	logger boshlog.Logger,
 ) bistatepkg.Compiler {
 	return &remotePackageCompiler{
-		blobstore:   blobstore,
-		agentClient: agentClient,
-		packageRepo: packageRepo,
+		blobstore:    blobstore,
+		agentClient:  agentClient,
+		packageRepo:  packageRepo,
+		packageCache: packageCache,
+		stemcell:     stemcell,
+
+		logTag: "remotePackageCompiler",
+		logger: logger,
 	}
 }
 
 func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
 	var record bistatepkg.CompiledPackageRecord
 
+	if !pkg.IsCompiled() {
+		cachedRecord, found := c.findInCache(pkg)
+		if found {
+			err := c.packageRepo.Save(pkg, cachedRecord)
+			if err != nil {
+				return cachedRecord, true, bosherr.WrapErrorf(err, "Saving compiled package record '%#v' of package '%#v'", cachedRecord, pkg)
+			}
+
+			return cachedRecord, true, nil
+		}
+	}
+
 	blobID, err := c.blobstore.Add(pkg.ArchivePath())
 	if err != nil {
 		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Adding release package archive '%s' to blobstore", pkg.ArchivePath())
@@ -86,6 +115,8 @@ func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.Com
 			BlobID:   compiledPackageRef.BlobstoreID,
 			BlobSHA1: compiledPackageRef.SHA1,
 		}
+
+		c.saveInCache(pkg, record)
 	} else {
 		isAlreadyCompiled = true
 
@@ -102,3 +133,54 @@ func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.Com
 
 	return record, isAlreadyCompiled, nil
 }
+
+func (c *remotePackageCompiler) cacheEnabled() bool {
+	return c.packageCache != nil && len(c.stemcell.OS) > 0
+}
+
+// findInCache uploads cached compiled package to the blobstore.
+// Cache is only an optimization so failures fall back to compilation.
+func (c *remotePackageCompiler) findInCache(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool) {
+	if !c.cacheEnabled() {
+		return bistatepkg.CompiledPackageRecord{}, false
+	}
+
+	localBlob, entry, found, err := c.packageCache.Find(pkg, c.stemcell)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to find package '%s/%s' in compiled package cache: %s", pkg.Name(), pkg.Fingerprint(), err)
+		return bistatepkg.CompiledPackageRecord{}, false
+	}
+
+	if !found {
+		return bistatepkg.CompiledPackageRecord{}, false
+	}
+
+	defer localBlob.DeleteSilently()
+
+	blobID, err := c.blobstore.Add(localBlob.Path())
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to add cached compiled package '%s/%s' to blobstore: %s", pkg.Name(), pkg.Fingerprint(), err)
+		return bistatepkg.CompiledPackageRecord{}, false
+	}
+
+	return bistatepkg.CompiledPackageRecord{BlobID: blobID, BlobSHA1: entry.SHA1}, true
+}
+
+func (c *remotePackageCompiler) saveInCache(pkg birelpkg.Compilable, record bistatepkg.CompiledPackageRecord) {
+	if !c.cacheEnabled() {
+		return
+	}
+
+	localBlob, err := c.blobstore.Get(record.BlobID)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to get compiled package '%s/%s' for compiled package cache: %s", pkg.Name(), pkg.Fingerprint(), err)
+		return
+	}
+
+	defer localBlob.DeleteSilently()
+
+	err = c.packageCache.Save(pkg, c.stemcell, localBlob.Path())
+	if err != nil {
+		c.logger.Warn(c.logTag, "Failed to save package '%s/%s' in compiled package cache: %s", pkg.Name(), pkg.Fingerprint(), err)
+	}
+}
@@ -1,18 +1,24 @@
 package state_test
 
 import (
+	"errors"
+
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	mock_agentclient "github.com/cloudfoundry/bosh-cli/agentclient/mocks"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
 	. "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	. "github.com/cloudfoundry/bosh-cli/release/resource"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	mock_state_pkg "github.com/cloudfoundry/bosh-cli/state/pkg/mocks"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("RemotePackageCompiler", func() {
@@ -38,6 +44,8 @@ var _ = Describe("RemotePackageCompiler", func() {
 
 		expectBlobstoreAdd *gomock.Call
 		expectAgentCompile *gomock.Call
+
+		logger boshlog.Logger
 	)
 
 	BeforeEach(func() {
@@ -46,7 +54,8 @@ var _ = Describe("RemotePackageCompiler", func() {
 
 		index := biindex.NewInMemoryIndex()
 		packageRepo = bistatepkg.NewCompiledPackageRepo(index)
-		remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo, nil, bistatepkg.Stemcell{}, logger)
 	})
 
 	Describe("Compile", func() {
@@ -132,6 +141,87 @@ var _ = Describe("RemotePackageCompiler", func() {
 				Expect(record).To(Equal(compiledPackageRecord))
 			})
 
+			Context("when compiled package cache is configured", func() {
+				var (
+					mockPackageCache *mock_state_pkg.MockCompiledPackageCache
+					fs               *fakesys.FakeFileSystem
+					stemcell         bistatepkg.Stemcell
+				)
+
+				BeforeEach(func() {
+					mockPackageCache = mock_state_pkg.NewMockCompiledPackageCache(mockCtrl)
+					fs = fakesys.NewFakeFileSystem()
+					stemcell = bistatepkg.Stemcell{OS: "fake-stemcell-os", Version: "fake-stemcell-version"}
+
+					remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo, mockPackageCache, stemcell, logger)
+				})
+
+				It("uploads cached compiled package instead of compiling the package", func() {
+					Expect(fs.WriteFileString("/cached-package", "fake-compiled-package")).To(Succeed())
+					cachedBlob := biblobstore.NewLocalBlob("/cached-package", fs, logger)
+					cacheEntry := bistatepkg.CompiledPackageCacheEntry{Name: "fake-package-name", SHA1: "fake-cached-package-sha1"}
+
+					mockPackageCache.EXPECT().Find(pkg, stemcell).Return(cachedBlob, cacheEntry, true, nil)
+					mockBlobstore.EXPECT().Add("/cached-package").Return("fake-cached-package-blob-id", nil)
+					expectBlobstoreAdd.Times(0)
+					expectAgentCompile.Times(0)
+
+					compiledPackageRecord, isAlreadyCompiled, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(isAlreadyCompiled).To(BeTrue())
+					Expect(compiledPackageRecord).To(Equal(bistatepkg.CompiledPackageRecord{
+						BlobID:   "fake-cached-package-blob-id",
+						BlobSHA1: "fake-cached-package-sha1",
+					}))
+
+					record, found, err := packageRepo.Find(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(record).To(Equal(compiledPackageRecord))
+
+					Expect(fs.FileExists("/cached-package")).To(BeFalse())
+				})
+
+				It("saves compiled package in the cache after compiling it", func() {
+					Expect(fs.WriteFileString("/compiled-package", "fake-compiled-package")).To(Succeed())
+					compiledBlob := biblobstore.NewLocalBlob("/compiled-package", fs, logger)
+
+					mockPackageCache.EXPECT().Find(pkg, stemcell).Return(nil, bistatepkg.CompiledPackageCacheEntry{}, false, nil)
+					mockBlobstore.EXPECT().Get("fake-compiled-package-blob-id").Return(compiledBlob, nil)
+					mockPackageCache.EXPECT().Save(pkg, stemcell, "/compiled-package").Return(nil)
+
+					compiledPackageRecord, isAlreadyCompiled, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(isAlreadyCompiled).To(BeFalse())
+					Expect(compiledPackageRecord.BlobID).To(Equal("fake-compiled-package-blob-id"))
+
+					Expect(fs.FileExists("/compiled-package")).To(BeFalse())
+				})
+
+				It("compiles the package when cache cannot be used", func() {
+					mockPackageCache.EXPECT().Find(pkg, stemcell).Return(nil, bistatepkg.CompiledPackageCacheEntry{}, false, errors.New("fake-find-err"))
+					mockBlobstore.EXPECT().Get("fake-compiled-package-blob-id").Return(nil, errors.New("fake-get-err"))
+					expectAgentCompile.Times(1)
+
+					compiledPackageRecord, _, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(compiledPackageRecord.BlobID).To(Equal("fake-compiled-package-blob-id"))
+				})
+
+				Context("when stemcell operating system is not known", func() {
+					BeforeEach(func() {
+						remotePackageCompiler = NewRemotePackageCompiler(mockBlobstore, mockAgentClient, packageRepo, mockPackageCache, bistatepkg.Stemcell{}, logger)
+					})
+
+					It("does not use the cache", func() {
+						expectAgentCompile.Times(1)
+
+						_, _, err := remotePackageCompiler.Compile(pkg)
+						Expect(err).ToNot(HaveOccurred())
+					})
+				})
+			})
+
 			Context("when the dependencies are not in the repo", func() {
 				BeforeEach(func() {
 					compiledPackages = map[bistatepkg.CompiledPackageRecord]*boshpkg.Package{}
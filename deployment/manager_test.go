@@ -215,7 +215,7 @@ var _ = Describe("Manager", func() {
 				err = diskRepo.UpdateCurrent(currentDiskRecord.ID)
 				Expect(err).ToNot(HaveOccurred())
 
-				currentStemcellRecord, err = stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+				currentStemcellRecord, err = stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 				err = stemcellRepo.UpdateCurrent(currentStemcellRecord.ID)
 				Expect(err).ToNot(HaveOccurred())
@@ -304,7 +304,7 @@ var _ = Describe("Manager", func() {
 
 		Context("orphan stemcell records exist", func() {
 			BeforeEach(func() {
-				_, err := stemcellRepo.Save("orphan-stemcell-name", "orphan-stemcell-version", "orphan-stemcell-cid")
+				_, err := stemcellRepo.Save("orphan-stemcell-name", "orphan-stemcell-version", "orphan-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
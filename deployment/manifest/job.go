@@ -27,6 +27,33 @@ type ReleaseJobRef struct {
 	Name       string
 	Release    string
 	Properties *biproperty.Map
+	Provides   map[string]ProvidedLink
+	Consumes   map[string]ConsumedLink
+}
+
+type ProvidedLink struct {
+	As string
+}
+
+type ConsumedLink struct {
+	From string
+
+	// Disabled is set when link is explicitly set to nil
+	Disabled bool
+
+	// Manual is set when link is specified in the manifest
+	// instead of being provided by another job
+	Manual *ManualLink
+}
+
+type ManualLink struct {
+	Address    string
+	Instances  []ManualLinkInstance
+	Properties biproperty.Map
+}
+
+type ManualLinkInstance struct {
+	Address string
 }
 
 type JobNetwork struct {
@@ -14,6 +14,10 @@ type Manifest struct {
 	ResourcePools []ResourcePool
 	Update        Update
 	Tags          map[string]string
+
+	// InstanceGroups is set when jobs are specified under instance_groups key;
+	// their resource pools may still come from resource_pools key
+	InstanceGroups bool
 }
 
 type Update struct {
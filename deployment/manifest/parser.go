@@ -26,6 +26,9 @@ type manifest struct {
 	Networks       []network
 	ResourcePools  []resourcePool `yaml:"resource_pools"`
 	DiskPools      []diskPool     `yaml:"disk_pools"`
+	VMTypes        []vmType       `yaml:"vm_types"`
+	DiskTypes      []diskPool     `yaml:"disk_types"`
+	Stemcells      []stemcell
 	Jobs           []job
 	InstanceGroups []job `yaml:"instance_groups"`
 	Properties     map[interface{}]interface{}
@@ -68,6 +71,18 @@ type diskPool struct {
 	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
 }
 
+type vmType struct {
+	Name            string                      `yaml:"name"`
+	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
+}
+
+type stemcell struct {
+	Alias   string   `yaml:"alias"`
+	URL     string   `yaml:"url"`
+	Mirrors []string `yaml:"mirrors"`
+	SHA1    string   `yaml:"sha1"`
+}
+
 type job struct {
 	Name               string
 	Instances          int
@@ -77,7 +92,11 @@ type job struct {
 	Networks           []jobNetwork
 	PersistentDisk     int    `yaml:"persistent_disk"`
 	PersistentDiskPool string `yaml:"persistent_disk_pool"`
+	PersistentDiskType string `yaml:"persistent_disk_type"`
 	ResourcePool       string `yaml:"resource_pool"`
+	VMType             string `yaml:"vm_type"`
+	Stemcell           string
+	Env                map[interface{}]interface{}
 	Properties         map[interface{}]interface{}
 }
 
@@ -88,11 +107,50 @@ type releaseJobRef struct {
 	// This is a pointer so we can differentiate between `properties: {}`
 	// and not specifying the key at all.
 	Properties *map[interface{}]interface{}
+
+	Provides map[string]providedLink  `yaml:"provides"`
+	Consumes map[string]*consumedLink `yaml:"consumes"`
+}
+
+type providedLink struct {
+	As string `yaml:"as"`
+}
+
+type consumedLink struct {
+	From       string                      `yaml:"from"`
+	Address    string                      `yaml:"address"`
+	Instances  []manualLinkInstance        `yaml:"instances"`
+	Properties map[interface{}]interface{} `yaml:"properties"`
+
+	disabled bool
+}
+
+type manualLinkInstance struct {
+	Address string `yaml:"address"`
+}
+
+// UnmarshalYAML allows links to be disabled with null or 'nil' string
+// the same way as it's done in Director manifests
+func (l *consumedLink) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+
+	if unmarshal(&str) == nil {
+		if str != "nil" && str != "" {
+			return bosherr.Errorf("Expected link to be a hash or 'nil' but was '%s'", str)
+		}
+		l.disabled = true
+		return nil
+	}
+
+	type plainConsumedLink consumedLink
+
+	return unmarshal((*plainConsumedLink)(l))
 }
 
 type stemcellRef struct {
-	URL  string
-	SHA1 string
+	URL     string
+	Mirrors []string
+	SHA1    string
 }
 
 type jobNetwork struct {
@@ -156,9 +214,17 @@ func (p *parser) parseDeploymentManifest(depManifest manifest, path string) (Man
 
 	deployment.ResourcePools = resourcePools
 
-	diskPools, err := p.parseDiskPoolManifests(depManifest.DiskPools)
+	if len(depManifest.DiskPools) > 0 && len(depManifest.DiskTypes) > 0 {
+		return Manifest{}, bosherr.Error("Deployment specifies both disk_pools and disk_types keys, only one is allowed")
+	}
+
+	rawDiskPools := depManifest.DiskPools
+	if len(depManifest.DiskTypes) > 0 {
+		rawDiskPools = depManifest.DiskTypes
+	}
+	diskPools, err := p.parseDiskPoolManifests(rawDiskPools)
 	if err != nil {
-		return Manifest{}, bosherr.WrapErrorf(err, "Parsing disk_pools: %#v", depManifest.DiskPools)
+		return Manifest{}, bosherr.WrapErrorf(err, "Parsing disk_pools: %#v", rawDiskPools)
 	}
 	deployment.DiskPools = diskPools
 
@@ -166,16 +232,37 @@ func (p *parser) parseDeploymentManifest(depManifest manifest, path string) (Man
 		return Manifest{}, bosherr.Error("Deployment specifies both jobs and instance_groups keys, only one is allowed")
 	}
 
+	if len(depManifest.ResourcePools) > 0 && len(depManifest.VMTypes) > 0 {
+		return Manifest{}, bosherr.Error("Deployment specifies both resource_pools and vm_types keys, only one is allowed")
+	}
+
 	rawJobs := depManifest.Jobs
 	if len(depManifest.InstanceGroups) > 0 {
 		rawJobs = depManifest.InstanceGroups
+		deployment.InstanceGroups = true
 	}
 	jobs, err := p.parseJobManifests(rawJobs)
 	if err != nil {
-		return Manifest{}, bosherr.WrapErrorf(err, "Parsing jobs: %#v", depManifest.Jobs)
+		return Manifest{}, bosherr.WrapErrorf(err, "Parsing jobs: %#v", rawJobs)
 	}
 	deployment.Jobs = jobs
 
+	// Instance groups refer to vm_types and stemcells instead of resource pools,
+	// each such instance group gets its own resource pool
+	for i, rawJob := range rawJobs {
+		if rawJob.VMType == "" && rawJob.Stemcell == "" {
+			continue
+		}
+
+		resourcePool, err := p.parseInstanceGroupResourcePool(rawJob, depManifest, path)
+		if err != nil {
+			return Manifest{}, bosherr.WrapErrorf(err, "Parsing instance_group '%s'", rawJob.Name)
+		}
+
+		deployment.ResourcePools = append(deployment.ResourcePools, resourcePool)
+		deployment.Jobs[i].ResourcePool = resourcePool.Name
+	}
+
 	properties, err := biproperty.BuildMap(depManifest.Properties)
 	if err != nil {
 		return Manifest{}, bosherr.WrapErrorf(err, "Parsing global manifest properties: %#v", depManifest.Properties)
@@ -208,6 +295,14 @@ func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 			ResourcePool:       rawJob.ResourcePool,
 		}
 
+		if rawJob.PersistentDiskPool != "" && rawJob.PersistentDiskType != "" {
+			return jobs, bosherr.Error("Deployment specifies both persistent_disk_pool and persistent_disk_type keys for instance_group " + job.Name + ", only one is allowed")
+		}
+
+		if rawJob.PersistentDiskType != "" {
+			job.PersistentDiskPool = rawJob.PersistentDiskType
+		}
+
 		if len(rawJob.Templates) > 0 && len(rawJob.Jobs) > 0 {
 			return jobs, bosherr.Error("Deployment specifies both templates and jobs keys for instance_group " + job.Name + ", only one is allowed")
 		}
@@ -234,6 +329,11 @@ func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 					ref.Properties = &properties
 				}
 
+				err := p.parseReleaseJobLinks(rawJobRef, &ref)
+				if err != nil {
+					return []Job{}, bosherr.WrapErrorf(err, "Parsing release job '%s' links", rawJobRef.Name)
+				}
+
 				releaseJobRefs[i] = ref
 			}
 			job.Templates = releaseJobRefs
@@ -274,6 +374,51 @@ func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 	return jobs, nil
 }
 
+func (p *parser) parseReleaseJobLinks(rawJobRef releaseJobRef, ref *ReleaseJobRef) error {
+	if rawJobRef.Provides != nil {
+		ref.Provides = map[string]ProvidedLink{}
+
+		for name, rawLink := range rawJobRef.Provides {
+			ref.Provides[name] = ProvidedLink{As: rawLink.As}
+		}
+	}
+
+	if rawJobRef.Consumes != nil {
+		ref.Consumes = map[string]ConsumedLink{}
+
+		for name, rawLink := range rawJobRef.Consumes {
+			if rawLink == nil || rawLink.disabled {
+				ref.Consumes[name] = ConsumedLink{Disabled: true}
+				continue
+			}
+
+			link := ConsumedLink{From: rawLink.From}
+
+			if rawLink.Instances != nil || rawLink.Properties != nil || rawLink.Address != "" {
+				properties, err := biproperty.BuildMap(rawLink.Properties)
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Parsing link '%s' properties: %#v", name, rawLink.Properties)
+				}
+
+				manualLink := &ManualLink{
+					Address:    rawLink.Address,
+					Properties: properties,
+				}
+
+				for _, rawInstance := range rawLink.Instances {
+					manualLink.Instances = append(manualLink.Instances, ManualLinkInstance{Address: rawInstance.Address})
+				}
+
+				link.Manual = manualLink
+			}
+
+			ref.Consumes[name] = link
+		}
+	}
+
+	return nil
+}
+
 func (p *parser) parseNetworkManifests(rawNetworks []network) ([]Network, error) {
 	networks := make([]Network, len(rawNetworks), len(rawNetworks))
 	for i, rawNetwork := range rawNetworks {
@@ -341,6 +486,53 @@ func (p *parser) parseResourcePoolManifests(rawResourcePools []resourcePool, pat
 	return resourcePools, nil
 }
 
+func (p *parser) parseInstanceGroupResourcePool(rawJob job, depManifest manifest, path string) (ResourcePool, error) {
+	if rawJob.ResourcePool != "" {
+		return ResourcePool{}, bosherr.Error("Instance group specifies both resource_pool and vm_type/stemcell keys, only one is allowed")
+	}
+
+	var rawVMType *vmType
+	for i := range depManifest.VMTypes {
+		if depManifest.VMTypes[i].Name == rawJob.VMType {
+			rawVMType = &depManifest.VMTypes[i]
+		}
+	}
+	if rawVMType == nil {
+		return ResourcePool{}, bosherr.Errorf("vm_type '%s' must be the name of a vm type", rawJob.VMType)
+	}
+
+	var rawStemcell *stemcell
+	for i := range depManifest.Stemcells {
+		if depManifest.Stemcells[i].Alias == rawJob.Stemcell {
+			rawStemcell = &depManifest.Stemcells[i]
+		}
+	}
+	if rawStemcell == nil {
+		return ResourcePool{}, bosherr.Errorf("stemcell '%s' must be the alias of a stemcell", rawJob.Stemcell)
+	}
+
+	rawResourcePool := resourcePool{
+		Name:            rawVMType.Name,
+		CloudProperties: rawVMType.CloudProperties,
+		Env:             rawJob.Env,
+		Stemcell:        stemcellRef{URL: rawStemcell.URL, Mirrors: rawStemcell.Mirrors, SHA1: rawStemcell.SHA1},
+	}
+
+	// Resource pools require a network which instance groups do not specify
+	if len(rawJob.Networks) > 0 {
+		rawResourcePool.Network = rawJob.Networks[0].Name
+	}
+
+	resourcePools, err := p.parseResourcePoolManifests([]resourcePool{rawResourcePool}, path)
+	if err != nil {
+		return ResourcePool{}, err
+	}
+
+	resourcePools[0].FromVMType = true
+
+	return resourcePools[0], nil
+}
+
 func (p *parser) parseDiskPoolManifests(rawDiskPools []diskPool) ([]DiskPool, error) {
 	diskPools := make([]DiskPool, len(rawDiskPools), len(rawDiskPools))
 	for i, rawDiskPool := range rawDiskPools {
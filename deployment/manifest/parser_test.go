@@ -550,6 +550,292 @@ instance_groups:
 			})
 		})
 
+		Context("when instance_groups refer to vm_types, disk_types and stemcells", func() {
+			BeforeEach(func() {
+				contents := `
+---
+name: fake-deployment-name
+networks:
+- name: fake-network-name
+  type: dynamic
+vm_types:
+- name: fake-vm-type-name
+  cloud_properties:
+    fake-property: fake-property-value
+disk_types:
+- name: fake-disk-type-name
+  disk_size: 2048
+  cloud_properties:
+    fake-disk-type-cloud-property-key: fake-disk-type-cloud-property-value
+stemcells:
+- alias: default
+  url: http://fake-stemcell-url
+  mirrors:
+  - http://fake-stemcell-mirror-url
+  sha1: fake-stemcell-sha1
+instance_groups:
+- name: bosh
+  instances: 1
+  jobs:
+  - name: fake-job-name
+    release: fake-release-name
+    properties:
+      fake-prop-key: fake-prop-value
+  vm_type: fake-vm-type-name
+  stemcell: default
+  persistent_disk_type: fake-disk-type-name
+  env:
+    bosh:
+      password: secret
+  networks:
+  - name: fake-network-name
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("normalizes them into jobs, resource pools and disk pools", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deploymentManifest.InstanceGroups).To(BeTrue())
+
+				Expect(deploymentManifest.ResourcePools).To(Equal([]ResourcePool{
+					{
+						Name:    "fake-vm-type-name",
+						Network: "fake-network-name",
+						CloudProperties: biproperty.Map{
+							"fake-property": "fake-property-value",
+						},
+						Env: biproperty.Map{
+							"bosh": biproperty.Map{
+								"password": "secret",
+							},
+						},
+						Stemcell: StemcellRef{
+							URL:     "http://fake-stemcell-url",
+							Mirrors: []string{"http://fake-stemcell-mirror-url"},
+							SHA1:    "fake-stemcell-sha1",
+						},
+						FromVMType: true,
+					},
+				}))
+
+				Expect(deploymentManifest.DiskPools).To(Equal([]DiskPool{
+					{
+						Name:     "fake-disk-type-name",
+						DiskSize: 2048,
+						CloudProperties: biproperty.Map{
+							"fake-disk-type-cloud-property-key": "fake-disk-type-cloud-property-value",
+						},
+					},
+				}))
+
+				Expect(deploymentManifest.Jobs).To(Equal([]Job{
+					{
+						Name:      "bosh",
+						Instances: 1,
+						Templates: []ReleaseJobRef{
+							{
+								Name:       "fake-job-name",
+								Release:    "fake-release-name",
+								Properties: &biproperty.Map{"fake-prop-key": "fake-prop-value"},
+							},
+						},
+						Networks:           []JobNetwork{{Name: "fake-network-name"}},
+						PersistentDiskPool: "fake-disk-type-name",
+						ResourcePool:       "fake-vm-type-name",
+					},
+				}))
+			})
+		})
+
+		Context("when instance group refers to unknown vm_type", func() {
+			BeforeEach(func() {
+				contents := `
+---
+stemcells:
+- alias: default
+  url: http://fake-stemcell-url
+instance_groups:
+- name: jobby
+  vm_type: unknown
+  stemcell: default
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("vm_type 'unknown' must be the name of a vm type"))
+			})
+		})
+
+		Context("when instance group refers to unknown stemcell", func() {
+			BeforeEach(func() {
+				contents := `
+---
+vm_types:
+- name: default
+instance_groups:
+- name: jobby
+  vm_type: default
+  stemcell: unknown
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stemcell 'unknown' must be the alias of a stemcell"))
+			})
+		})
+
+		Context("when both resource_pools and vm_types are present in deployment manifest", func() {
+			BeforeEach(func() {
+				contents := `
+---
+resource_pools:
+- name: default
+vm_types:
+- name: default
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deployment specifies both resource_pools and vm_types keys, only one is allowed"))
+			})
+		})
+
+		Context("when both disk_pools and disk_types are present in deployment manifest", func() {
+			BeforeEach(func() {
+				contents := `
+---
+disk_pools:
+- name: default
+disk_types:
+- name: default
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deployment specifies both disk_pools and disk_types keys, only one is allowed"))
+			})
+		})
+
+		Context("when both persistent_disk_pool and persistent_disk_type are present in instance group", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  persistent_disk_pool: default
+  persistent_disk_type: default
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Deployment specifies both persistent_disk_pool and persistent_disk_type keys for instance_group jobby, only one is allowed"))
+			})
+		})
+
+		Context("when both resource_pool and vm_type are present in instance group", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  resource_pool: default
+  vm_type: default
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Instance group specifies both resource_pool and vm_type/stemcell keys, only one is allowed"))
+			})
+		})
+
+		Context("when job in an instance_group specifies links", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  jobs:
+  - name: job1
+    provides:
+      conn: {as: primary-db}
+    consumes:
+      primary: {from: primary-db}
+      backup: nil
+      cache: ~
+      external:
+        address: external.example.com
+        instances:
+        - address: 10.0.0.5
+        properties:
+          port: 5432
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses provided and consumed links", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				jobRef := deploymentManifest.Jobs[0].Templates[0]
+				Expect(jobRef.Provides).To(Equal(map[string]ProvidedLink{
+					"conn": {As: "primary-db"},
+				}))
+				Expect(jobRef.Consumes).To(Equal(map[string]ConsumedLink{
+					"primary": {From: "primary-db"},
+					"backup":  {Disabled: true},
+					"cache":   {Disabled: true},
+					"external": {
+						Manual: &ManualLink{
+							Address:    "external.example.com",
+							Instances:  []ManualLinkInstance{{Address: "10.0.0.5"}},
+							Properties: biproperty.Map{"port": 5432},
+						},
+					},
+				}))
+			})
+		})
+
+		Context("when job in an instance_group consumes link specified as a string other than nil", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  jobs:
+  - name: job1
+    consumes:
+      primary: primary-db
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("returns an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected link to be a hash or 'nil' but was 'primary-db'"))
+			})
+		})
+
 		Context("when both instance_groups and jobs are present at root level in deployment manifest", func() {
 			BeforeEach(func() {
 				contents := `
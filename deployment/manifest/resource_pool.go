@@ -10,17 +10,26 @@ type ResourcePool struct {
 	CloudProperties biproperty.Map
 	Env             biproperty.Map
 	Stemcell        StemcellRef
+
+	// FromVMType is set when resource pool was built
+	// from vm_type and stemcell of an instance group
+	FromVMType bool
 }
 
 type StemcellRef struct {
-	URL  string
-	SHA1 string
+	URL     string
+	Mirrors []string
+	SHA1    string
 }
 
 func (s StemcellRef) GetURL() string {
 	return s.URL
 }
 
+func (s StemcellRef) GetMirrorURLs() []string {
+	return s.Mirrors
+}
+
 func (s StemcellRef) GetSHA1() string {
 	return s.SHA1
 }
@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"fmt"
 	"net"
 	"regexp"
 	"strings"
@@ -38,6 +39,17 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 	errs = append(errs, networksErrors...)
 
 	for idx, resourcePool := range deploymentManifest.ResourcePools {
+		// Resource pools built from vm_types and stemcells use instance group networks,
+		// so only the referenced stemcell needs to be checked
+		if resourcePool.FromVMType {
+			for jobIdx, job := range deploymentManifest.Jobs {
+				if job.ResourcePool == resourcePool.Name {
+					errs = append(errs, v.validateStemcell(resourcePool.Stemcell, fmt.Sprintf("instance_groups[%d].stemcell", jobIdx))...)
+				}
+			}
+			continue
+		}
+
 		if v.isBlank(resourcePool.Name) {
 			errs = append(errs, bosherr.Errorf("resource_pools[%d].name must be provided", idx))
 		}
@@ -47,18 +59,7 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 			errs = append(errs, bosherr.Errorf("resource_pools[%d].network must be the name of a network", idx))
 		}
 
-		if v.isBlank(resourcePool.Stemcell.URL) {
-			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.url must be provided", idx))
-		}
-
-		matched, err := regexp.MatchString("^(file|http|https)://", resourcePool.Stemcell.URL)
-		if err != nil || !matched {
-			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.url must be a valid URL (file:// or http(s)://)", idx))
-		}
-
-		if strings.HasPrefix(resourcePool.Stemcell.URL, "http") && v.isBlank(resourcePool.Stemcell.SHA1) {
-			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.sha1 must be provided for http URL", idx))
-		}
+		errs = append(errs, v.validateStemcell(resourcePool.Stemcell, fmt.Sprintf("resource_pools[%d].stemcell", idx))...)
 	}
 
 	for idx, diskPool := range deploymentManifest.DiskPools {
@@ -70,58 +71,68 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 		}
 	}
 
+	jobsKey, templatesKey := v.jobsKeys(deploymentManifest)
+
 	if len(deploymentManifest.Jobs) > 1 {
-		errs = append(errs, bosherr.Error("jobs must be of size 1"))
+		errs = append(errs, bosherr.Errorf("%s must be of size 1", jobsKey))
 	}
 
 	for idx, job := range deploymentManifest.Jobs {
 		if v.isBlank(job.Name) {
-			errs = append(errs, bosherr.Errorf("jobs[%d].name must be provided", idx))
+			errs = append(errs, bosherr.Errorf("%s[%d].name must be provided", jobsKey, idx))
 		}
 		if job.PersistentDisk < 0 {
-			errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk must be >= 0", idx))
+			errs = append(errs, bosherr.Errorf("%s[%d].persistent_disk must be >= 0", jobsKey, idx))
 		}
 		if job.PersistentDiskPool != "" {
 			if _, ok := v.diskPoolNames(deploymentManifest)[job.PersistentDiskPool]; !ok {
-				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk_pool must be the name of a disk pool", idx))
+				if deploymentManifest.InstanceGroups {
+					errs = append(errs, bosherr.Errorf("%s[%d].persistent_disk_type must be the name of a disk type", jobsKey, idx))
+				} else {
+					errs = append(errs, bosherr.Errorf("%s[%d].persistent_disk_pool must be the name of a disk pool", jobsKey, idx))
+				}
 			}
 		}
 		if job.Instances < 0 {
-			errs = append(errs, bosherr.Errorf("jobs[%d].instances must be >= 0", idx))
+			errs = append(errs, bosherr.Errorf("%s[%d].instances must be >= 0", jobsKey, idx))
 		}
 		if len(job.Networks) == 0 {
-			errs = append(errs, bosherr.Errorf("jobs[%d].networks must be a non-empty array", idx))
+			errs = append(errs, bosherr.Errorf("%s[%d].networks must be a non-empty array", jobsKey, idx))
 		}
 		if v.isBlank(job.ResourcePool) {
-			errs = append(errs, bosherr.Errorf("jobs[%d].resource_pool must be provided", idx))
+			if v.usesVMTypes(deploymentManifest) {
+				errs = append(errs, bosherr.Errorf("%s[%d].vm_type and stemcell must be provided", jobsKey, idx))
+			} else {
+				errs = append(errs, bosherr.Errorf("%s[%d].resource_pool must be provided", jobsKey, idx))
+			}
 		} else {
 			if _, ok := v.resourcePoolNames(deploymentManifest)[job.ResourcePool]; !ok {
-				errs = append(errs, bosherr.Errorf("jobs[%d].resource_pool must be the name of a resource pool", idx))
+				errs = append(errs, bosherr.Errorf("%s[%d].resource_pool must be the name of a resource pool", jobsKey, idx))
 			}
 		}
 
-		errs = append(errs, v.validateJobNetworks(job.Networks, deploymentManifest.Networks, idx)...)
+		errs = append(errs, v.validateJobNetworks(job.Networks, deploymentManifest.Networks, jobsKey, idx)...)
 
 		if job.Lifecycle != "" && job.Lifecycle != JobLifecycleService {
-			errs = append(errs, bosherr.Errorf("jobs[%d].lifecycle must be 'service' ('%s' not supported)", idx, job.Lifecycle))
+			errs = append(errs, bosherr.Errorf("%s[%d].lifecycle must be 'service' ('%s' not supported)", jobsKey, idx, job.Lifecycle))
 		}
 
 		templateNames := map[string]struct{}{}
 		for templateIdx, template := range job.Templates {
 			if v.isBlank(template.Name) {
-				errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d].name must be provided", idx, templateIdx))
+				errs = append(errs, bosherr.Errorf("%s[%d].%s[%d].name must be provided", jobsKey, idx, templatesKey, templateIdx))
 			}
 			if _, found := templateNames[template.Name]; found {
-				errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d].name '%s' must be unique", idx, templateIdx, template.Name))
+				errs = append(errs, bosherr.Errorf("%s[%d].%s[%d].name '%s' must be unique", jobsKey, idx, templatesKey, templateIdx, template.Name))
 			}
 			templateNames[template.Name] = struct{}{}
 
 			if v.isBlank(template.Release) {
-				errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d].release must be provided", idx, templateIdx))
+				errs = append(errs, bosherr.Errorf("%s[%d].%s[%d].release must be provided", jobsKey, idx, templatesKey, templateIdx))
 			} else {
 				_, found := releaseSetManifest.FindByName(template.Release)
 				if !found {
-					errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d].release '%s' must refer to release in releases", idx, templateIdx, template.Release))
+					errs = append(errs, bosherr.Errorf("%s[%d].%s[%d].release '%s' must refer to release in releases", jobsKey, idx, templatesKey, templateIdx, template.Release))
 				}
 			}
 		}
@@ -136,16 +147,17 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 
 func (v *validator) ValidateReleaseJobs(deploymentManifest Manifest, releaseManager boshinst.ReleaseManager) error {
 	errs := []error{}
+	jobsKey, templatesKey := v.jobsKeys(deploymentManifest)
 
 	for idx, job := range deploymentManifest.Jobs {
 		for templateIdx, template := range job.Templates {
 			release, found := releaseManager.Find(template.Release)
 			if !found {
-				errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d].release '%s' must refer to release in releases", idx, templateIdx, template.Release))
+				errs = append(errs, bosherr.Errorf("%s[%d].%s[%d].release '%s' must refer to release in releases", jobsKey, idx, templatesKey, templateIdx, template.Release))
 			} else {
 				_, found := release.FindJobByName(template.Name)
 				if !found {
-					errs = append(errs, bosherr.Errorf("jobs[%d].templates[%d] must refer to a job in '%s', but there is no job named '%s'", idx, templateIdx, release.Name(), template.Name))
+					errs = append(errs, bosherr.Errorf("%s[%d].%s[%d] must refer to a job in '%s', but there is no job named '%s'", jobsKey, idx, templatesKey, templateIdx, release.Name(), template.Name))
 				}
 			}
 		}
@@ -158,6 +170,28 @@ func (v *validator) ValidateReleaseJobs(deploymentManifest Manifest, releaseMana
 	return nil
 }
 
+// jobsKeys returns manifest keys used in error messages
+// so that they match the schema of the given manifest
+func (v *validator) jobsKeys(deploymentManifest Manifest) (string, string) {
+	if deploymentManifest.InstanceGroups {
+		return "instance_groups", "jobs"
+	}
+	return "jobs", "templates"
+}
+
+// usesVMTypes returns true when instance groups do not refer to any resource_pools
+func (v *validator) usesVMTypes(deploymentManifest Manifest) bool {
+	if !deploymentManifest.InstanceGroups {
+		return false
+	}
+	for _, resourcePool := range deploymentManifest.ResourcePools {
+		if !resourcePool.FromVMType {
+			return false
+		}
+	}
+	return true
+}
+
 func (v *validator) isBlank(str string) bool {
 	return str == "" || strings.TrimSpace(str) == ""
 }
@@ -261,13 +295,39 @@ func (v *validator) validateNetwork(network Network, networkIdx int) []error {
 	return errs
 }
 
-func (v *validator) validateJobNetworks(jobNetworks []JobNetwork, networks []Network, jobIdx int) []error {
+func (v *validator) validateStemcell(stemcell StemcellRef, key string) []error {
+	errs := []error{}
+
+	if v.isBlank(stemcell.URL) {
+		errs = append(errs, bosherr.Errorf("%s.url must be provided", key))
+	}
+
+	matched, err := regexp.MatchString("^(file|http|https)://", stemcell.URL)
+	if err != nil || !matched {
+		errs = append(errs, bosherr.Errorf("%s.url must be a valid URL (file:// or http(s)://)", key))
+	}
+
+	if strings.HasPrefix(stemcell.URL, "http") && v.isBlank(stemcell.SHA1) {
+		errs = append(errs, bosherr.Errorf("%s.sha1 must be provided for http URL", key))
+	}
+
+	for mirrorIdx, mirror := range stemcell.Mirrors {
+		matched, err := regexp.MatchString("^(http|https)://", mirror)
+		if err != nil || !matched {
+			errs = append(errs, bosherr.Errorf("%s.mirrors[%d] must be a valid URL (http(s)://)", key, mirrorIdx))
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) validateJobNetworks(jobNetworks []JobNetwork, networks []Network, jobsKey string, jobIdx int) []error {
 	errs := []error{}
 	defaultCounts := make(map[NetworkDefault]int)
 
 	for networkIdx, jobNetwork := range jobNetworks {
 		if v.isBlank(jobNetwork.Name) {
-			errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].name must be provided", jobIdx, networkIdx))
+			errs = append(errs, bosherr.Errorf("%s[%d].networks[%d].name must be provided", jobsKey, jobIdx, networkIdx))
 		}
 
 		var matchingNetwork Network
@@ -282,17 +342,17 @@ func (v *validator) validateJobNetworks(jobNetworks []JobNetwork, networks []Net
 		}
 
 		if !found {
-			errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d] not found in networks", jobIdx, networkIdx))
+			errs = append(errs, bosherr.Errorf("%s[%d].networks[%d] not found in networks", jobsKey, jobIdx, networkIdx))
 		}
 
 		for ipIdx, ip := range jobNetwork.StaticIPs {
-			staticIPErrors := v.validateStaticIP(ip, jobNetwork, matchingNetwork, jobIdx, networkIdx, ipIdx)
+			staticIPErrors := v.validateStaticIP(ip, jobNetwork, matchingNetwork, jobsKey, jobIdx, networkIdx, ipIdx)
 			errs = append(errs, staticIPErrors...)
 		}
 
 		for defaultIdx, value := range jobNetwork.Defaults {
 			if value != NetworkDefaultDNS && value != NetworkDefaultGateway {
-				errs = append(errs, bosherr.Errorf("jobs[%d].networks[%d].default[%d] must be 'dns' or 'gateway'", jobIdx, networkIdx, defaultIdx))
+				errs = append(errs, bosherr.Errorf("%s[%d].networks[%d].default[%d] must be 'dns' or 'gateway'", jobsKey, jobIdx, networkIdx, defaultIdx))
 			}
 		}
 
@@ -318,9 +378,9 @@ func (v *validator) validateJobNetworks(jobNetworks []JobNetwork, networks []Net
 	return errs
 }
 
-func (v *validator) validateStaticIP(ip string, jobNetwork JobNetwork, network Network, jobIdx, networkIdx, ipIdx int) []error {
+func (v *validator) validateStaticIP(ip string, jobNetwork JobNetwork, network Network, jobsKey string, jobIdx, networkIdx, ipIdx int) []error {
 	if !v.isValidIP(ip) {
-		return []error{bosherr.Errorf("jobs[%d].networks[%d].static_ips[%d] must be a valid IP", jobIdx, networkIdx, ipIdx)}
+		return []error{bosherr.Errorf("%s[%d].networks[%d].static_ips[%d] must be a valid IP", jobsKey, jobIdx, networkIdx, ipIdx)}
 	}
 
 	if network.Type != Manual {
@@ -339,7 +399,7 @@ func (v *validator) validateStaticIP(ip string, jobNetwork JobNetwork, network N
 		return []error{}
 	}
 
-	return []error{bosherr.Errorf("jobs[%d].networks[%d] static ip '%s' must be within subnet range", jobIdx, networkIdx, ip)}
+	return []error{bosherr.Errorf("%s[%d].networks[%d] static ip '%s' must be within subnet range", jobsKey, jobIdx, networkIdx, ip)}
 }
 
 func (v *validator) validateGateway(idx int, gateway string, ipNet maybeIPNet) []error {
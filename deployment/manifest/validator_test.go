@@ -247,6 +247,25 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("resource_pools[0].stemcell.sha1 must be provided for http URL"))
 		})
 
+		It("validates resource pool stemcell mirrors are http(s) urls", func() {
+			deploymentManifest := Manifest{
+				ResourcePools: []ResourcePool{
+					{
+						Stemcell: StemcellRef{
+							URL:     "https://fake-url",
+							Mirrors: []string{"https://fake-mirror", "invalid-url"},
+							SHA1:    "fake-sha1",
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).ToNot(ContainSubstring("resource_pools[0].stemcell.mirrors[0]"))
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].stemcell.mirrors[1] must be a valid URL (http(s)://)"))
+		})
+
 		It("validates disk pool name", func() {
 			deploymentManifest := Manifest{
 				DiskPools: []DiskPool{
@@ -1012,6 +1031,104 @@ var _ = Describe("Validator", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("jobs[0].templates[0].release 'fake-other-release-name' must refer to release in releases"))
 		})
+
+		Context("when manifest uses instance_groups", func() {
+			BeforeEach(func() {
+				validManifest.InstanceGroups = true
+				validManifest.ResourcePools[0].FromVMType = true
+			})
+
+			It("validates successfully", func() {
+				err := validator.Validate(validManifest, validReleaseSetManifest)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("does not require resource pool network because instance group networks are used", func() {
+				deploymentManifest := validManifest
+				deploymentManifest.ResourcePools = []ResourcePool{validManifest.ResourcePools[0]}
+				deploymentManifest.ResourcePools[0].Network = ""
+
+				err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("refers to instance groups and their jobs in errors", func() {
+				deploymentManifest := validManifest
+				deploymentManifest.Jobs = []Job{validManifest.Jobs[0]}
+				deploymentManifest.Jobs[0].Instances = -1
+				deploymentManifest.Jobs[0].Templates = []ReleaseJobRef{
+					{Name: "fake-job-name", Release: "fake-other-release-name"},
+				}
+
+				err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].instances must be >= 0"))
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].jobs[0].release 'fake-other-release-name' must refer to release in releases"))
+			})
+
+			It("validates instance group vm_type and stemcell are provided", func() {
+				deploymentManifest := Manifest{
+					InstanceGroups: true,
+					Jobs:           []Job{{}},
+				}
+
+				err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].vm_type and stemcell must be provided"))
+			})
+
+			It("validates instance group persistent_disk_type", func() {
+				deploymentManifest := validManifest
+				deploymentManifest.Jobs = []Job{validManifest.Jobs[0]}
+				deploymentManifest.Jobs[0].PersistentDiskPool = "non-existent-disk-type"
+
+				err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].persistent_disk_type must be the name of a disk type"))
+			})
+
+			It("validates instance group stemcell", func() {
+				deploymentManifest := validManifest
+				deploymentManifest.ResourcePools = []ResourcePool{validManifest.ResourcePools[0]}
+				deploymentManifest.ResourcePools[0].Stemcell = StemcellRef{URL: "http://fake-stemcell-url"}
+
+				err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("instance_groups[0].stemcell.sha1 must be provided for http URL"))
+			})
+
+			Context("when instance groups refer to resource_pools", func() {
+				BeforeEach(func() {
+					validManifest.ResourcePools[0].FromVMType = false
+				})
+
+				It("validates successfully", func() {
+					err := validator.Validate(validManifest, validReleaseSetManifest)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("validates resource pool network", func() {
+					deploymentManifest := validManifest
+					deploymentManifest.ResourcePools = []ResourcePool{validManifest.ResourcePools[0]}
+					deploymentManifest.ResourcePools[0].Network = "non-existent-network"
+
+					err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("resource_pools[0].network must be the name of a network"))
+				})
+
+				It("validates instance group resource_pool is provided", func() {
+					deploymentManifest := validManifest
+					deploymentManifest.Jobs = []Job{validManifest.Jobs[0]}
+					deploymentManifest.Jobs[0].ResourcePool = ""
+
+					err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("instance_groups[0].resource_pool must be provided"))
+					Expect(err.Error()).ToNot(ContainSubstring("vm_type"))
+				})
+			})
+		})
 	})
 
 	Describe("ValidateReleaseJobs", func() {
@@ -0,0 +1,193 @@
+package deployment
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	birel "github.com/cloudfoundry/bosh-cli/release"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	PlanActionUploadStemcell = "upload-stemcell"
+	PlanActionCreateVM       = "create-vm"
+	PlanActionRecreateVM     = "recreate-vm"
+	PlanActionCreateDisk     = "create-disk"
+	PlanActionResizeDisk     = "resize-disk"
+	PlanActionMigrateDisk    = "migrate-disk"
+	PlanActionAttachDisk     = "attach-disk"
+	PlanActionUpdateJobs     = "update-jobs"
+	PlanActionDeleteStemcell = "delete-stemcell"
+)
+
+type PlanStep struct {
+	Action      string
+	Description string
+}
+
+// Plan lists changes that deploying would make in the order they would happen.
+// Plan is calculated only from deployment state and manifest, without contacting the CPI.
+type Plan struct {
+	Steps []PlanStep
+}
+
+func (p Plan) HasChanges() bool {
+	return len(p.Steps) > 0
+}
+
+// NewPlan describes steps of deploying; whether anything needs to be deployed
+// is decided by the caller (see Record.IsDeployed) the same way as in create-env.
+func NewPlan(
+	deploymentState biconfig.DeploymentState,
+	deploymentManifest bideplmanifest.Manifest,
+	manifestSHA string,
+	releases []birel.Release,
+	extractedStemcell bistemcell.ExtractedStemcell,
+	isDeployed bool,
+	recreate bool,
+) (Plan, error) {
+	plan := Plan{}
+
+	// Deploy is skipped when nothing changed, same as in create-env
+	if isDeployed && !recreate {
+		return plan, nil
+	}
+
+	stemcellName := extractedStemcell.Manifest().Name
+	stemcellVersion := extractedStemcell.Manifest().Version
+	stemcellDesc := fmt.Sprintf("'%s/%s'", stemcellName, stemcellVersion)
+
+	newStemcellRecord, stemcellUploaded := findStemcellRecord(deploymentState, stemcellName, stemcellVersion)
+	if !stemcellUploaded {
+		plan.add(PlanActionUploadStemcell, "Upload stemcell %s", stemcellDesc)
+	}
+
+	reasons := changeReasons(deploymentState, manifestSHA, releases, newStemcellRecord, stemcellUploaded)
+	if recreate {
+		reasons = append(reasons, "recreate requested")
+	}
+
+	if len(deploymentManifest.Jobs) != 1 {
+		return plan, bosherr.Errorf("There must only be one job, found %d", len(deploymentManifest.Jobs))
+	}
+
+	jobName := deploymentManifest.Jobs[0].Name
+
+	if deploymentState.CurrentVMCID != "" {
+		desc := fmt.Sprintf("Delete VM '%s' and create VM from stemcell %s", deploymentState.CurrentVMCID, stemcellDesc)
+		if len(reasons) > 0 {
+			desc += fmt.Sprintf(" (%s)", strings.Join(reasons, ", "))
+		}
+		plan.add(PlanActionRecreateVM, "%s", desc)
+	} else {
+		plan.add(PlanActionCreateVM, "Create VM for instance '%s/0' from stemcell %s", jobName, stemcellDesc)
+	}
+
+	diskPool, err := deploymentManifest.DiskPool(jobName)
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Getting disk pool")
+	}
+
+	if diskPool.DiskSize > 0 {
+		currentDisk, found := findCurrentDiskRecord(deploymentState)
+
+		switch {
+		case !found:
+			plan.add(PlanActionCreateDisk, "Create persistent disk of %d MB", diskPool.DiskSize)
+
+		// Mirrors disk.NeedsResize; deploying falls back to migration if CPI responds with NotImplemented
+		case diskPool.DiskSize > currentDisk.Size && reflect.DeepEqual(currentDisk.CloudProperties, diskPool.CloudProperties):
+			plan.add(PlanActionResizeDisk, "Resize persistent disk '%s' from %d MB to %d MB (migrate if CPI does not support resizing)",
+				currentDisk.CID, currentDisk.Size, diskPool.DiskSize)
+
+		case currentDisk.Size != diskPool.DiskSize:
+			plan.add(PlanActionMigrateDisk, "Migrate persistent disk '%s' from %d MB to %d MB",
+				currentDisk.CID, currentDisk.Size, diskPool.DiskSize)
+
+		case !reflect.DeepEqual(currentDisk.CloudProperties, diskPool.CloudProperties):
+			plan.add(PlanActionMigrateDisk, "Migrate persistent disk '%s' to disk with new cloud properties", currentDisk.CID)
+
+		default:
+			plan.add(PlanActionAttachDisk, "Attach existing persistent disk '%s'", currentDisk.CID)
+		}
+	}
+
+	plan.add(PlanActionUpdateJobs, "Render jobs and update instance '%s/0'", jobName)
+
+	for _, record := range deploymentState.Stemcells {
+		if record.Name != stemcellName || record.Version != stemcellVersion {
+			plan.add(PlanActionDeleteStemcell, "Delete unused stemcell '%s/%s' ('%s')", record.Name, record.Version, record.CID)
+		}
+	}
+
+	return plan, nil
+}
+
+func (p *Plan) add(action, descFmt string, args ...interface{}) {
+	p.Steps = append(p.Steps, PlanStep{Action: action, Description: fmt.Sprintf(descFmt, args...)})
+}
+
+// changeReasons only describes what differs from deployment state
+// to explain why VM is recreated
+func changeReasons(
+	deploymentState biconfig.DeploymentState,
+	manifestSHA string,
+	releases []birel.Release,
+	newStemcellRecord biconfig.StemcellRecord,
+	stemcellUploaded bool,
+) []string {
+	var reasons []string
+
+	if deploymentState.CurrentManifestSHA != manifestSHA {
+		reasons = append(reasons, "manifest changed")
+	}
+
+	if !stemcellUploaded || deploymentState.CurrentStemcellID != newStemcellRecord.ID {
+		reasons = append(reasons, "stemcell changed")
+	}
+
+	for _, release := range releases {
+		found := false
+		for _, record := range deploymentState.Releases {
+			if record.Name == release.Name() && record.Version == release.Version() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			reasons = append(reasons, fmt.Sprintf("release '%s/%s' changed", release.Name(), release.Version()))
+		}
+	}
+
+	if len(releases) != len(deploymentState.Releases) && len(reasons) == 0 {
+		reasons = append(reasons, "releases changed")
+	}
+
+	return reasons
+}
+
+func findStemcellRecord(deploymentState biconfig.DeploymentState, name, version string) (biconfig.StemcellRecord, bool) {
+	for _, record := range deploymentState.Stemcells {
+		if record.Name == name && record.Version == version {
+			return record, true
+		}
+	}
+	return biconfig.StemcellRecord{}, false
+}
+
+func findCurrentDiskRecord(deploymentState biconfig.DeploymentState) (biconfig.DiskRecord, bool) {
+	if deploymentState.CurrentDiskID == "" {
+		return biconfig.DiskRecord{}, false
+	}
+
+	for _, record := range deploymentState.Disks {
+		if record.ID == deploymentState.CurrentDiskID {
+			return record, true
+		}
+	}
+	return biconfig.DiskRecord{}, false
+}
@@ -0,0 +1,220 @@
+package deployment_test
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/cloudfoundry/bosh-cli/deployment"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+)
+
+var _ = Describe("NewPlan", func() {
+	var (
+		deploymentState    biconfig.DeploymentState
+		deploymentManifest bideplmanifest.Manifest
+		releases           []boshrel.Release
+		stemcell           bistemcell.ExtractedStemcell
+	)
+
+	BeforeEach(func() {
+		release := &fakerel.FakeRelease{
+			NameStub:    func() string { return "fake-release-name" },
+			VersionStub: func() string { return "fake-release-version" },
+		}
+		releases = []boshrel.Release{release}
+
+		stemcell = bistemcell.NewExtractedStemcell(
+			bistemcell.Manifest{
+				Name:    "fake-stemcell-name",
+				Version: "fake-stemcell-version",
+			},
+			"fake-extracted-path",
+			nil,
+			fakesys.NewFakeFileSystem(),
+		)
+
+		deploymentManifest = bideplmanifest.Manifest{
+			Jobs: []bideplmanifest.Job{{
+				Name:           "fake-job-name",
+				PersistentDisk: 1024,
+			}},
+		}
+
+		deploymentState = biconfig.DeploymentState{
+			CurrentVMCID:       "fake-vm-cid",
+			CurrentManifestSHA: "fake-manifest-sha",
+			CurrentStemcellID:  "fake-stemcell-id",
+			Stemcells: []biconfig.StemcellRecord{{
+				ID:      "fake-stemcell-id",
+				Name:    "fake-stemcell-name",
+				Version: "fake-stemcell-version",
+				CID:     "fake-stemcell-cid",
+			}},
+			Releases: []biconfig.ReleaseRecord{{
+				ID:      "fake-release-id",
+				Name:    "fake-release-name",
+				Version: "fake-release-version",
+			}},
+			CurrentDiskID: "fake-disk-id",
+			Disks: []biconfig.DiskRecord{{
+				ID:              "fake-disk-id",
+				CID:             "fake-disk-cid",
+				Size:            1024,
+				CloudProperties: biproperty.Map{},
+			}},
+		}
+	})
+
+	actions := func(plan Plan) []string {
+		var result []string
+		for _, step := range plan.Steps {
+			result = append(result, step.Action)
+		}
+		return result
+	}
+
+	It("returns empty plan when already deployed", func() {
+		plan, err := NewPlan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, true, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.HasChanges()).To(BeFalse())
+	})
+
+	It("plans deploy when not deployed even if deployment state does not differ", func() {
+		plan, err := NewPlan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			PlanActionRecreateVM,
+			PlanActionAttachDisk,
+			PlanActionUpdateJobs,
+		}))
+		Expect(plan.Steps[0].Description).To(Equal(
+			"Delete VM 'fake-vm-cid' and create VM from stemcell 'fake-stemcell-name/fake-stemcell-version'"))
+	})
+
+	It("recreates VM with existing disk when recreate is requested", func() {
+		plan, err := NewPlan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, true, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			PlanActionRecreateVM,
+			PlanActionAttachDisk,
+			PlanActionUpdateJobs,
+		}))
+		Expect(plan.Steps[0].Description).To(ContainSubstring("recreate requested"))
+	})
+
+	It("creates everything when nothing was deployed", func() {
+		plan, err := NewPlan(biconfig.DeploymentState{}, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			PlanActionUploadStemcell,
+			PlanActionCreateVM,
+			PlanActionCreateDisk,
+			PlanActionUpdateJobs,
+		}))
+		Expect(plan.Steps[1].Description).To(Equal("Create VM for instance 'fake-job-name/0' from stemcell 'fake-stemcell-name/fake-stemcell-version'"))
+	})
+
+	It("resizes disk when disk only grew", func() {
+		deploymentManifest.Jobs[0].PersistentDisk = 2048
+
+		plan, err := NewPlan(deploymentState, deploymentManifest, "new-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			PlanActionRecreateVM,
+			PlanActionResizeDisk,
+			PlanActionUpdateJobs,
+		}))
+		Expect(plan.Steps[0].Description).To(ContainSubstring("manifest changed"))
+		Expect(plan.Steps[1].Description).To(Equal(
+			"Resize persistent disk 'fake-disk-cid' from 1024 MB to 2048 MB (migrate if CPI does not support resizing)"))
+	})
+
+	It("migrates disk when disk shrank", func() {
+		deploymentManifest.Jobs[0].PersistentDisk = 512
+
+		plan, err := NewPlan(deploymentState, deploymentManifest, "new-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			PlanActionRecreateVM,
+			PlanActionMigrateDisk,
+			PlanActionUpdateJobs,
+		}))
+		Expect(plan.Steps[1].Description).To(Equal("Migrate persistent disk 'fake-disk-cid' from 1024 MB to 512 MB"))
+	})
+
+	It("migrates disk when disk grew and its cloud properties changed", func() {
+		deploymentManifest.Jobs[0].PersistentDisk = 0
+		deploymentManifest.Jobs[0].PersistentDiskPool = "fake-disk-pool"
+		deploymentManifest.DiskPools = []bideplmanifest.DiskPool{{
+			Name:            "fake-disk-pool",
+			DiskSize:        2048,
+			CloudProperties: biproperty.Map{"type": "ssd"},
+		}}
+
+		plan, err := NewPlan(deploymentState, deploymentManifest, "new-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(ContainElement(PlanActionMigrateDisk))
+		Expect(actions(plan)).ToNot(ContainElement(PlanActionResizeDisk))
+	})
+
+	It("migrates disk when disk cloud properties changed", func() {
+		deploymentManifest.Jobs[0].PersistentDisk = 0
+		deploymentManifest.Jobs[0].PersistentDiskPool = "fake-disk-pool"
+		deploymentManifest.DiskPools = []bideplmanifest.DiskPool{{
+			Name:            "fake-disk-pool",
+			DiskSize:        1024,
+			CloudProperties: biproperty.Map{"type": "ssd"},
+		}}
+
+		plan, err := NewPlan(deploymentState, deploymentManifest, "new-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(ContainElement(PlanActionMigrateDisk))
+	})
+
+	It("uploads new stemcell and deletes unused one when stemcell changed", func() {
+		stemcell = bistemcell.NewExtractedStemcell(
+			bistemcell.Manifest{
+				Name:    "fake-stemcell-name",
+				Version: "new-stemcell-version",
+			},
+			"fake-extracted-path",
+			nil,
+			fakesys.NewFakeFileSystem(),
+		)
+
+		plan, err := NewPlan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actions(plan)).To(Equal([]string{
+			PlanActionUploadStemcell,
+			PlanActionRecreateVM,
+			PlanActionAttachDisk,
+			PlanActionUpdateJobs,
+			PlanActionDeleteStemcell,
+		}))
+		Expect(plan.Steps[1].Description).To(ContainSubstring("stemcell changed"))
+		Expect(plan.Steps[4].Description).To(Equal("Delete unused stemcell 'fake-stemcell-name/fake-stemcell-version' ('fake-stemcell-cid')"))
+	})
+
+	It("recreates VM when release changed", func() {
+		deploymentState.Releases[0].Version = "old-release-version"
+
+		plan, err := NewPlan(deploymentState, deploymentManifest, "fake-manifest-sha", releases, stemcell, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan.Steps[0].Action).To(Equal(PlanActionRecreateVM))
+		Expect(plan.Steps[0].Description).To(ContainSubstring("release 'fake-release-name/fake-release-version' changed"))
+	})
+
+	It("returns error when manifest does not have exactly one job", func() {
+		deploymentManifest.Jobs = append(deploymentManifest.Jobs, bideplmanifest.Job{Name: "other-job"})
+
+		_, err := NewPlan(deploymentState, deploymentManifest, "new-manifest-sha", releases, stemcell, false, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("There must only be one job, found 2"))
+	})
+})
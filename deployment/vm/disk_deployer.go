@@ -75,6 +75,15 @@ func (d *diskDeployer) deployExistingDisk(disk bidisk.Disk, diskPool bideplmanif
 	// the disk is already part of the deployment, and should already be attached
 	disks = append(disks, disk)
 
+	// resize before attaching since CPIs expect disk to be detached;
+	// disk is migrated below if CPI does not support resizing
+	if disk.NeedsResize(diskPool.DiskSize, diskPool.CloudProperties) {
+		err := d.resizeDisk(disk, diskPool, stage)
+		if err != nil {
+			return disks, err
+		}
+	}
+
 	// attach is idempotent
 	err := d.attachDisk(disk, vm, stage)
 	if err != nil {
@@ -118,6 +127,19 @@ func (d *diskDeployer) deployNewDisk(diskPool bideplmanifest.DiskPool, vm VM, st
 	return disks, nil
 }
 
+func (d *diskDeployer) resizeDisk(disk bidisk.Disk, diskPool bideplmanifest.DiskPool, stage biui.Stage) error {
+	d.logger.Debug(d.logTag, "Resizing disk '%s'", disk.CID())
+
+	stageName := fmt.Sprintf("Resizing disk '%s' to size %d", disk.CID(), diskPool.DiskSize)
+	return stage.Perform(stageName, func() error {
+		err := disk.Resize(diskPool.DiskSize)
+		if cloudErr, ok := err.(bicloud.Error); ok && cloudErr.Type() == bicloud.NotImplementedError {
+			return biui.NewSkipStageError(cloudErr, "Not supported by CPI, migrating disk instead")
+		}
+		return err
+	})
+}
+
 func (d *diskDeployer) migrateDisk(
 	originalDisk bidisk.Disk,
 	diskPool bideplmanifest.DiskPool,
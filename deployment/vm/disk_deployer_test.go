@@ -3,6 +3,7 @@ package vm_test
 import (
 	. "github.com/cloudfoundry/bosh-cli/deployment/vm"
 
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -106,6 +107,54 @@ var _ = Describe("DiskDeployer", func() {
 				})
 			})
 
+			Context("when disk needs resize", func() {
+				BeforeEach(func() {
+					existingDisk.SetNeedsResizeBehavior(true)
+				})
+
+				It("resizes disk before attaching it", func() {
+					disks, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(disks).To(Equal([]bidisk.Disk{existingDisk}))
+
+					Expect(existingDisk.ResizeInputs).To(Equal([]int{1024}))
+					Expect(fakeStage.PerformCalls[0].Name).To(Equal("Resizing disk 'fake-existing-disk-cid' to size 1024"))
+					Expect(fakeStage.PerformCalls[1].Name).To(Equal("Attaching disk 'fake-existing-disk-cid' to VM 'fake-vm-cid'"))
+				})
+
+				Context("when CPI does not support resizing", func() {
+					BeforeEach(func() {
+						existingDisk.SetResizeBehavior(bicloud.NewCPIError("resize_disk", bicloud.CmdError{Type: bicloud.NotImplementedError}))
+						existingDisk.SetNeedsMigrationBehavior(true)
+
+						secondaryDisk := fakebidisk.NewFakeDisk("fake-secondary-disk-cid")
+						fakeDiskManager.CreateDisk = secondaryDisk
+						fakeDiskRepo.SetFindBehavior("fake-secondary-disk-cid", biconfig.DiskRecord{ID: "fake-secondary-disk-id"}, true, nil)
+					})
+
+					It("skips resizing and migrates disk", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fakeStage.PerformCalls[0].SkipError).To(HaveOccurred())
+						Expect(fakeVM.MigrateDiskCalledTimes).To(Equal(1))
+					})
+				})
+
+				Context("when resizing fails", func() {
+					BeforeEach(func() {
+						existingDisk.SetResizeBehavior(bosherr.Error("fake-resize-error"))
+					})
+
+					It("returns an error without attaching disk", func() {
+						_, err := diskDeployer.Deploy(diskPool, cloud, fakeVM, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-resize-error"))
+						Expect(fakeVM.AttachDiskInputs).To(BeEmpty())
+					})
+				})
+			})
+
 			Context("when disk needs migration", func() {
 				var secondaryDisk *fakebidisk.FakeDisk
 
@@ -47,6 +47,9 @@ type FakeVM struct {
 	StopCalled int
 	StopErr    error
 
+	DrainCalled int
+	DrainErr    error
+
 	ListDisksDisks []bidisk.Disk
 	ListDisksErr   error
 
@@ -196,6 +199,11 @@ func (vm *FakeVM) Stop() error {
 	return vm.StopErr
 }
 
+func (vm *FakeVM) Drain() error {
+	vm.DrainCalled++
+	return vm.DrainErr
+}
+
 func (vm *FakeVM) Disks() ([]bidisk.Disk, error) {
 	return vm.ListDisksDisks, vm.ListDisksErr
 }
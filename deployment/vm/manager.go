@@ -103,7 +103,7 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 		return nil, bosherr.WrapError(err, "Generating agent ID")
 	}
 
-	cid, err := m.createAndRecordVM(agentID, stemcell, resourcePool, networkInterfaces)
+	cid, networkSettings, err := m.createAndRecordVM(agentID, stemcell, resourcePool, networkInterfaces)
 	if err != nil {
 		return nil, err
 	}
@@ -142,22 +142,28 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 		m.fs,
 		m.logger,
 		metadata,
+		networkSettings,
 	)
 
 	return vm, nil
 }
 
-func (m *manager) createAndRecordVM(agentID string, stemcell bistemcell.CloudStemcell, resourcePool bideplmanifest.ResourcePool, networkInterfaces map[string]biproperty.Map) (string, error) {
-	cid, err := m.cloud.CreateVM(agentID, stemcell.CID(), resourcePool.CloudProperties, networkInterfaces, resourcePool.Env)
+// createAndRecordVM also returns network settings if CPI responded with them (CPI API v2)
+func (m *manager) createAndRecordVM(agentID string, stemcell bistemcell.CloudStemcell, resourcePool bideplmanifest.ResourcePool, networkInterfaces map[string]biproperty.Map) (string, map[string]biproperty.Map, error) {
+	cid, networks, err := m.cloud.CreateVM(agentID, stemcell.CID(), resourcePool.CloudProperties, networkInterfaces, resourcePool.Env)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Creating vm with stemcell cid '%s'", stemcell.CID())
+		return "", nil, bosherr.WrapErrorf(err, "Creating vm with stemcell cid '%s'", stemcell.CID())
+	}
+
+	if networks != nil {
+		m.logger.Debug(m.logTag, "Created VM '%s' with network settings: %#v", cid, networks)
 	}
 
 	// Record vm info immediately so we don't leak it
 	err = m.vmRepo.UpdateCurrent(cid)
 	if err != nil {
-		return "", bosherr.WrapError(err, "Updating current vm record")
+		return "", nil, bosherr.WrapError(err, "Updating current vm record")
 	}
 
-	return cid, nil
+	return cid, networks, nil
 }
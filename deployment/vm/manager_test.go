@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"code.cloudfoundry.org/clock"
+	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
 	fakebiagentclient "github.com/cloudfoundry/bosh-agent/agentclient/fakes"
 	"github.com/cloudfoundry/bosh-cli/cloud"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
@@ -118,7 +119,7 @@ var _ = Describe("Manager", func() {
 		}
 
 		stemcellRecord := biconfig.StemcellRecord{CID: "fake-stemcell-cid"}
-		stemcell = bistemcell.NewCloudStemcell(stemcellRecord, stemcellRepo, fakeCloud)
+		stemcell = bistemcell.NewCloudStemcell(stemcellRecord, "", stemcellRepo, fakeCloud)
 	})
 
 	Describe("Create", func() {
@@ -143,6 +144,7 @@ var _ = Describe("Manager", func() {
 					"director":       "bosh-init",
 					"created_at":     "2016-11-10T23:00:00Z",
 				},
+				nil,
 			)
 			Expect(vm).To(Equal(expectedVM))
 
@@ -157,6 +159,26 @@ var _ = Describe("Manager", func() {
 			))
 		})
 
+		It("keeps network settings returned by the CPI for applying them to the VM", func() {
+			fakeCloud.CreateVMNetworks = map[string]biproperty.Map{
+				"fake-network-name": biproperty.Map{"ip": "10.0.0.5"},
+			}
+
+			vm, err := manager.Create(stemcell, deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = vm.Apply(bias.ApplySpec{
+				Networks: map[string]interface{}{
+					"fake-network-name": map[string]interface{}{"type": "dynamic"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeAgentClient.ApplyArgsForCall(0).Networks).To(Equal(map[string]interface{}{
+				"fake-network-name": map[string]interface{}{"type": "dynamic", "ip": "10.0.0.5"},
+			}))
+		})
+
 		It("sets the vm metadata", func() {
 			_, err := manager.Create(stemcell, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
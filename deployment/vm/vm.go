@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"strings"
 	"time"
 
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
@@ -12,6 +13,7 @@ import (
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
@@ -28,6 +30,7 @@ type VM interface {
 	WaitUntilReady(timeout time.Duration, delay time.Duration) error
 	Start() error
 	Stop() error
+	Drain() error
 	Apply(bias.ApplySpec) error
 	UpdateDisks(bideplmanifest.DiskPool, biui.Stage) ([]bidisk.Disk, error)
 	WaitToBeRunning(maxAttempts int, delay time.Duration) error
@@ -41,6 +44,17 @@ type VM interface {
 	GetState() (biagentclient.AgentState, error)
 }
 
+// drainAgentClient is implemented by agent clients that are able to run drain scripts
+type drainAgentClient interface {
+	Drain(drainType string) (int, error)
+}
+
+// diskHintsAgentClient is implemented by agent clients that are able to pass
+// disk hints to the agent when they are not written into the registry
+type diskHintsAgentClient interface {
+	AddPersistentDisk(diskCID string, diskHints interface{}) error
+}
+
 type vm struct {
 	cid          string
 	vmRepo       biconfig.VMRepo
@@ -53,6 +67,9 @@ type vm struct {
 	logger       boshlog.Logger
 	logTag       string
 	metadata     bicloud.VMMetadata
+
+	// networkSettings are returned by CPI API v2 create_vm
+	networkSettings map[string]biproperty.Map
 }
 
 func NewVM(
@@ -91,19 +108,21 @@ func NewVMWithMetadata(
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 	metadata bicloud.VMMetadata,
+	networkSettings map[string]biproperty.Map,
 ) VM {
 	return &vm{
-		cid:          cid,
-		vmRepo:       vmRepo,
-		stemcellRepo: stemcellRepo,
-		diskDeployer: diskDeployer,
-		agentClient:  agentClient,
-		cloud:        cloud,
-		timeService:  timeService,
-		fs:           fs,
-		logger:       logger,
-		logTag:       "vm",
-		metadata:     metadata,
+		cid:             cid,
+		vmRepo:          vmRepo,
+		stemcellRepo:    stemcellRepo,
+		diskDeployer:    diskDeployer,
+		agentClient:     agentClient,
+		cloud:           cloud,
+		timeService:     timeService,
+		fs:              fs,
+		logger:          logger,
+		logTag:          "vm",
+		metadata:        metadata,
+		networkSettings: networkSettings,
 	}
 }
 
@@ -149,7 +168,46 @@ func (vm *vm) Stop() error {
 	return nil
 }
 
+func (vm *vm) Drain() error {
+	agentClient, ok := vm.agentClient.(drainAgentClient)
+	if !ok {
+		vm.logger.Warn(vm.logTag, "Skipping drain since agent client does not support it")
+		return nil
+	}
+
+	vm.logger.Debug(vm.logTag, "Draining jobs")
+	seconds, err := agentClient.Drain("shutdown")
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown message") {
+			// ignore 'unknown message' errors for backwards compatibility with older agents
+			vm.logger.Warn(vm.logTag, "Ignoring drain 'unknown message' error from the agent: %s", err.Error())
+			return nil
+		}
+		return bosherr.WrapError(err, "Draining jobs")
+	}
+
+	// Negative result means that drain scripts are dynamic and have to be asked again
+	for seconds < 0 {
+		vm.logger.Debug(vm.logTag, "Waiting %d second(s) before checking drain status", -seconds)
+		vm.timeService.Sleep(time.Duration(-seconds) * time.Second)
+
+		seconds, err = agentClient.Drain("status")
+		if err != nil {
+			return bosherr.WrapError(err, "Checking drain status")
+		}
+	}
+
+	if seconds > 0 {
+		vm.logger.Debug(vm.logTag, "Waiting %d second(s) for drain to finish", seconds)
+		vm.timeService.Sleep(time.Duration(seconds) * time.Second)
+	}
+
+	return nil
+}
+
 func (vm *vm) Apply(newState bias.ApplySpec) error {
+	newState = vm.withNetworkSettings(newState)
+
 	vm.logger.Debug(vm.logTag, "Sending apply message to the agent with '%#v'", newState)
 	err := vm.agentClient.Apply(newState)
 	if err != nil {
@@ -159,6 +217,42 @@ func (vm *vm) Apply(newState bias.ApplySpec) error {
 	return nil
 }
 
+// withNetworkSettings makes network settings returned by the CPI
+// (e.g. IPs assigned to dynamic networks) override ones from the manifest
+func (vm *vm) withNetworkSettings(spec bias.ApplySpec) bias.ApplySpec {
+	if len(vm.networkSettings) == 0 {
+		return spec
+	}
+
+	networks := make(map[string]interface{}, len(spec.Networks))
+
+	for name, network := range spec.Networks {
+		settings, found := vm.networkSettings[name]
+		networkInterface, ok := network.(map[string]interface{})
+
+		if !found || !ok {
+			networks[name] = network
+			continue
+		}
+
+		merged := make(map[string]interface{}, len(networkInterface)+len(settings))
+
+		for k, v := range networkInterface {
+			merged[k] = v
+		}
+
+		for k, v := range settings {
+			merged[k] = v
+		}
+
+		networks[name] = merged
+	}
+
+	spec.Networks = networks
+
+	return spec
+}
+
 func (vm *vm) UpdateDisks(diskPool bideplmanifest.DiskPool, eventLoggerStage biui.Stage) ([]bidisk.Disk, error) {
 	disks, err := vm.diskDeployer.Deploy(diskPool, vm.cloud, vm, eventLoggerStage)
 	if err != nil {
@@ -174,7 +268,7 @@ func (vm *vm) WaitToBeRunning(maxAttempts int, delay time.Duration) error {
 }
 
 func (vm *vm) AttachDisk(disk bidisk.Disk) error {
-	err := vm.cloud.AttachDisk(vm.cid, disk.CID())
+	diskHints, err := vm.cloud.AttachDisk(vm.cid, disk.CID())
 	if err != nil {
 		return bosherr.WrapError(err, "Attaching disk in the cloud")
 	}
@@ -194,6 +288,13 @@ func (vm *vm) AttachDisk(disk bidisk.Disk) error {
 		return bosherr.WrapError(err, "Waiting for agent to be accessible after attaching disk")
 	}
 
+	if diskHints != nil && !vm.cloud.UsesRegistry() {
+		err = vm.addPersistentDisk(disk.CID(), diskHints)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = vm.agentClient.MountDisk(disk.CID())
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting disk")
@@ -202,6 +303,22 @@ func (vm *vm) AttachDisk(disk bidisk.Disk) error {
 	return nil
 }
 
+func (vm *vm) addPersistentDisk(diskCID string, diskHints interface{}) error {
+	agentClient, ok := vm.agentClient.(diskHintsAgentClient)
+	if !ok {
+		return bosherr.Errorf("Agent client is not able to pass disk hints for disk '%s' to the agent", diskCID)
+	}
+
+	vm.logger.Debug(vm.logTag, "Sending disk hints for disk '%s' to the agent: %#v", diskCID, diskHints)
+
+	err := agentClient.AddPersistentDisk(diskCID, diskHints)
+	if err != nil {
+		return bosherr.WrapError(err, "Adding persistent disk")
+	}
+
+	return nil
+}
+
 func (vm *vm) DetachDisk(disk bidisk.Disk) error {
 	err := vm.cloud.DetachDisk(vm.cid, disk.CID())
 	if err != nil {
@@ -152,6 +152,117 @@ var _ = Describe("VM", func() {
 		})
 	})
 
+	Describe("Drain", func() {
+		var drainingAgentClient *fakeDrainingAgentClient
+
+		BeforeEach(func() {
+			drainingAgentClient = &fakeDrainingAgentClient{FakeAgentClient: fakeAgentClient}
+			vm = NewVM(
+				"fake-vm-cid",
+				fakeVMRepo,
+				fakeStemcellRepo,
+				fakeDiskDeployer,
+				drainingAgentClient,
+				fakeCloud,
+				timeService,
+				fs,
+				logger,
+			)
+		})
+
+		It("does not wait when drain scripts finished immediately", func() {
+			drainingAgentClient.Results = []int{0}
+
+			err := vm.Drain()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(drainingAgentClient.DrainTypes).To(Equal([]string{"shutdown"}))
+			Expect(timeService.Slept).To(BeEmpty())
+		})
+
+		It("waits for the number of seconds returned by static drain scripts", func() {
+			drainingAgentClient.Results = []int{10}
+
+			err := vm.Drain()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(drainingAgentClient.DrainTypes).To(Equal([]string{"shutdown"}))
+			Expect(timeService.Slept).To(Equal([]time.Duration{10 * time.Second}))
+		})
+
+		It("polls drain status while dynamic drain scripts return negative numbers", func() {
+			drainingAgentClient.Results = []int{-3, -5, 2}
+
+			err := vm.Drain()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(drainingAgentClient.DrainTypes).To(Equal([]string{"shutdown", "status", "status"}))
+			Expect(timeService.Slept).To(Equal([]time.Duration{3 * time.Second, 5 * time.Second, 2 * time.Second}))
+		})
+
+		It("returns an error when checking drain status fails", func() {
+			drainingAgentClient.Results = []int{-3}
+			statusErrClient := &failingStatusDrainAgentClient{fakeDrainingAgentClient: drainingAgentClient}
+			vm = NewVM(
+				"fake-vm-cid",
+				fakeVMRepo,
+				fakeStemcellRepo,
+				fakeDiskDeployer,
+				statusErrClient,
+				fakeCloud,
+				timeService,
+				fs,
+				logger,
+			)
+
+			err := vm.Drain()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking drain status"))
+			Expect(err.Error()).To(ContainSubstring("fake-status-error"))
+		})
+
+		Context("when draining fails", func() {
+			BeforeEach(func() {
+				drainingAgentClient.Err = errors.New("fake-drain-error")
+			})
+
+			It("returns an error", func() {
+				err := vm.Drain()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-drain-error"))
+			})
+		})
+
+		Context("when agent does not know drain message", func() {
+			BeforeEach(func() {
+				drainingAgentClient.Err = errors.New("Agent responded with error: unknown message drain")
+			})
+
+			It("does not return an error", func() {
+				err := vm.Drain()
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when agent client is not able to drain", func() {
+			BeforeEach(func() {
+				vm = NewVM(
+					"fake-vm-cid",
+					fakeVMRepo,
+					fakeStemcellRepo,
+					fakeDiskDeployer,
+					fakeAgentClient,
+					fakeCloud,
+					timeService,
+					fs,
+					logger,
+				)
+			})
+
+			It("skips draining", func() {
+				err := vm.Drain()
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+
 	Describe("Apply", func() {
 		It("sends apply spec to the agent", func() {
 			err := vm.Apply(applySpec)
@@ -159,6 +270,42 @@ var _ = Describe("VM", func() {
 			Expect(fakeAgentClient.ApplyArgsForCall(0)).To(Equal(applySpec))
 		})
 
+		It("overrides network interfaces with network settings returned by the CPI", func() {
+			vm = NewVMWithMetadata(
+				"fake-vm-cid",
+				fakeVMRepo,
+				fakeStemcellRepo,
+				fakeDiskDeployer,
+				fakeAgentClient,
+				fakeCloud,
+				timeService,
+				fs,
+				logger,
+				bicloud.VMMetadata{},
+				map[string]biproperty.Map{
+					"fake-dynamic-network": biproperty.Map{"ip": "10.0.0.5", "netmask": "255.255.255.0"},
+				},
+			)
+
+			applySpec.Networks = map[string]interface{}{
+				"fake-dynamic-network": map[string]interface{}{"type": "dynamic", "cloud_properties": map[string]interface{}{}},
+				"fake-manual-network":  map[string]interface{}{"type": "manual", "ip": "10.0.1.5"},
+			}
+
+			err := vm.Apply(applySpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeAgentClient.ApplyArgsForCall(0).Networks).To(Equal(map[string]interface{}{
+				"fake-dynamic-network": map[string]interface{}{
+					"type":             "dynamic",
+					"cloud_properties": map[string]interface{}{},
+					"ip":               "10.0.0.5",
+					"netmask":          "255.255.255.0",
+				},
+				"fake-manual-network": map[string]interface{}{"type": "manual", "ip": "10.0.1.5"},
+			}))
+		})
+
 		Context("when sending apply spec to the agent fails", func() {
 			BeforeEach(func() {
 				fakeAgentClient.ApplyReturns(errors.New("fake-agent-apply-err"))
@@ -244,6 +391,7 @@ var _ = Describe("VM", func() {
 				fs,
 				logger,
 				metadata,
+				nil,
 			)
 		})
 
@@ -263,6 +411,51 @@ var _ = Describe("VM", func() {
 			Expect(fakeAgentClient.MountDiskArgsForCall(0)).To(Equal("fake-disk-cid"))
 		})
 
+		Context("when the CPI returns disk hints", func() {
+			var diskHintsAgentClient *fakeDiskHintsAgentClient
+
+			BeforeEach(func() {
+				fakeCloud.AttachDiskDiskHints = map[string]interface{}{"path": "/dev/sdc"}
+				diskHintsAgentClient = &fakeDiskHintsAgentClient{FakeAgentClient: fakeAgentClient}
+				vm = NewVM(
+					"fake-vm-cid",
+					fakeVMRepo,
+					fakeStemcellRepo,
+					fakeDiskDeployer,
+					diskHintsAgentClient,
+					fakeCloud,
+					timeService,
+					fs,
+					logger,
+				)
+			})
+
+			It("sends disk hints to the agent before mounting disk when registry is not used", func() {
+				err := vm.AttachDisk(disk)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diskHintsAgentClient.DiskCIDs).To(Equal([]string{"fake-disk-cid"}))
+				Expect(diskHintsAgentClient.DiskHints).To(Equal([]interface{}{map[string]interface{}{"path": "/dev/sdc"}}))
+				Expect(fakeAgentClient.MountDiskArgsForCall(0)).To(Equal("fake-disk-cid"))
+			})
+
+			It("does not send disk hints to the agent when registry is used", func() {
+				fakeCloud.UsesRegistryResult = true
+
+				err := vm.AttachDisk(disk)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diskHintsAgentClient.DiskCIDs).To(BeEmpty())
+			})
+
+			It("returns an error when sending disk hints fails", func() {
+				diskHintsAgentClient.Err = errors.New("fake-add-persistent-disk-error")
+
+				err := vm.AttachDisk(disk)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-add-persistent-disk-error"))
+				Expect(fakeAgentClient.MountDiskCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when metadata is set", func() {
 			It("sets the metadata to the disk", func() {
 				expectedDiskMetadata := bicloud.DiskMetadata{
@@ -558,12 +751,58 @@ var _ = Describe("VM", func() {
 
 type FakeClock struct {
 	Times []time.Time
+	Slept []time.Duration
 }
 
-func (c *FakeClock) Sleep(_ time.Duration) {}
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.Slept = append(c.Slept, d)
+}
 
 func (c *FakeClock) Now() time.Time {
 	t1 := c.Times[0]
 	c.Times = c.Times[1:]
 	return t1
 }
+
+type fakeDrainingAgentClient struct {
+	*fakebiagentclient.FakeAgentClient
+
+	DrainTypes []string
+	Results    []int
+	Err        error
+}
+
+func (c *fakeDrainingAgentClient) Drain(drainType string) (int, error) {
+	c.DrainTypes = append(c.DrainTypes, drainType)
+	if c.Err != nil {
+		return 0, c.Err
+	}
+	result := c.Results[0]
+	c.Results = c.Results[1:]
+	return result, nil
+}
+
+type failingStatusDrainAgentClient struct {
+	*fakeDrainingAgentClient
+}
+
+func (c *failingStatusDrainAgentClient) Drain(drainType string) (int, error) {
+	if drainType == "status" {
+		return 0, errors.New("fake-status-error")
+	}
+	return c.fakeDrainingAgentClient.Drain(drainType)
+}
+
+type fakeDiskHintsAgentClient struct {
+	*fakebiagentclient.FakeAgentClient
+
+	DiskCIDs  []string
+	DiskHints []interface{}
+	Err       error
+}
+
+func (c *fakeDiskHintsAgentClient) AddPersistentDisk(diskCID string, diskHints interface{}) error {
+	c.DiskCIDs = append(c.DiskCIDs, diskCID)
+	c.DiskHints = append(c.DiskHints, diskHints)
+	return c.Err
+}
@@ -151,9 +151,14 @@ func (r ClientRequest) RawPut(path string, payload []byte, f func(*http.Request)
 
 // RawDelete follows redirects via GET unlike generic HTTP clients
 func (r ClientRequest) RawDelete(path string) ([]byte, *http.Response, error) {
+	return r.RawDeleteCustomized(path, nil)
+}
+
+// RawDeleteCustomized follows redirects via GET unlike generic HTTP clients
+func (r ClientRequest) RawDeleteCustomized(path string, f func(*http.Request)) ([]byte, *http.Response, error) {
 	url := fmt.Sprintf("%s%s", r.endpoint, path)
 
-	resp, err := r.httpClient.Delete(url)
+	resp, err := r.httpClient.DeleteCustomized(url, f)
 	if err != nil {
 		return nil, nil, bosherr.WrapErrorf(err, "Performing request DELETE '%s'", url)
 	}
@@ -0,0 +1,162 @@
+package director
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gourl "net/url"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type TypedConfig struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ConfigsFilter struct {
+	Type string
+	Name string
+}
+
+type UpdateConfigBody struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+func (d DirectorImpl) Configs(limit int, filter ConfigsFilter) ([]TypedConfig, error) {
+	return d.client.Configs(limit, filter)
+}
+
+func (d DirectorImpl) LatestConfig(configType string, name string) (TypedConfig, error) {
+	resps, err := d.client.Configs(1, ConfigsFilter{Type: configType, Name: name})
+	if err != nil {
+		return TypedConfig{}, err
+	}
+
+	if len(resps) == 0 {
+		return TypedConfig{}, bosherr.Errorf("No config with type '%s' and name '%s'", configType, name)
+	}
+
+	return resps[0], nil
+}
+
+func (d DirectorImpl) LatestConfigByID(id string) (TypedConfig, error) {
+	return d.client.ConfigByID(id)
+}
+
+func (d DirectorImpl) UpdateConfig(configType string, name string, content []byte) (TypedConfig, error) {
+	return d.client.UpdateConfig(configType, name, content)
+}
+
+func (d DirectorImpl) DeleteConfig(configType string, name string) (bool, error) {
+	return d.client.DeleteConfig(configType, name)
+}
+
+func (d DirectorImpl) DiffConfig(configType string, name string, manifest []byte) (ConfigDiff, error) {
+	resp, err := d.client.DiffConfig(configType, name, manifest)
+	if err != nil {
+		return ConfigDiff{}, err
+	}
+
+	return NewConfigDiff(resp.Diff), nil
+}
+
+func (c Client) Configs(limit int, filter ConfigsFilter) ([]TypedConfig, error) {
+	var resps []TypedConfig
+
+	query := gourl.Values{}
+
+	if len(filter.Type) > 0 {
+		query.Add("type", filter.Type)
+	}
+
+	if len(filter.Name) > 0 {
+		query.Add("name", filter.Name)
+	}
+
+	if limit > 1 {
+		query.Add("latest", "false")
+		query.Add("limit", strconv.Itoa(limit))
+	} else {
+		query.Add("latest", "true")
+	}
+
+	path := fmt.Sprintf("/configs?%s", query.Encode())
+
+	err := c.clientRequest.Get(path, &resps)
+	if err != nil {
+		return resps, bosherr.WrapErrorf(err, "Finding configs")
+	}
+
+	return resps, nil
+}
+
+func (c Client) ConfigByID(id string) (TypedConfig, error) {
+	var resp TypedConfig
+
+	err := c.clientRequest.Get(fmt.Sprintf("/configs/%s", id), &resp)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Finding config '%s'", id)
+	}
+
+	return resp, nil
+}
+
+func (c Client) UpdateConfig(configType string, name string, content []byte) (TypedConfig, error) {
+	var resp TypedConfig
+
+	body, err := json.Marshal(UpdateConfigBody{Type: configType, Name: name, Content: string(content)})
+	if err != nil {
+		return resp, bosherr.WrapError(err, "Marshaling request body")
+	}
+
+	setHeaders := func(req *http.Request) {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	err = c.clientRequest.Post("/configs", body, setHeaders, &resp)
+	if err != nil {
+		return resp, bosherr.WrapErrorf(err, "Updating config")
+	}
+
+	return resp, nil
+}
+
+func (c Client) DeleteConfig(configType string, name string) (bool, error) {
+	query := gourl.Values{}
+	query.Add("type", configType)
+	query.Add("name", name)
+
+	path := fmt.Sprintf("/configs?%s", query.Encode())
+
+	_, response, err := c.clientRequest.RawDelete(path)
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, bosherr.WrapErrorf(err, "Deleting config")
+	}
+
+	return true, nil
+}
+
+func (c Client) DiffConfig(configType string, name string, manifest []byte) (ConfigDiffResponse, error) {
+	body, err := json.Marshal(UpdateConfigBody{Type: configType, Name: name, Content: string(manifest)})
+	if err != nil {
+		return ConfigDiffResponse{}, bosherr.WrapError(err, "Marshaling request body")
+	}
+
+	setHeaders := func(req *http.Request) {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	return c.postConfigDiff("/configs/diff", body, setHeaders)
+}
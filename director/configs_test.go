@@ -0,0 +1,246 @@
+package director_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/director"
+)
+
+var _ = Describe("Director", func() {
+	var (
+		director Director
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		director, server = BuildServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Configs", func() {
+		It("returns latest configs matching filter", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/configs", "latest=true&name=team-a&type=cloud"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.RespondWith(http.StatusOK, `[
+	{"id": "2", "type": "cloud", "name": "team-a", "content": "first", "created_at": "2017-10-10 10:00:00 UTC"}
+]`),
+				),
+			)
+
+			configs, err := director.Configs(1, ConfigsFilter{Type: "cloud", Name: "team-a"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configs).To(Equal([]TypedConfig{
+				{ID: "2", Type: "cloud", Name: "team-a", Content: "first", CreatedAt: "2017-10-10 10:00:00 UTC"},
+			}))
+		})
+
+		It("includes previous versions when limit is greater than one", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/configs", "latest=false&limit=5"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.RespondWith(http.StatusOK, `[
+	{"id": "2", "type": "cloud", "name": "default", "content": "second"},
+	{"id": "1", "type": "cloud", "name": "default", "content": "first"}
+]`),
+				),
+			)
+
+			configs, err := director.Configs(5, ConfigsFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configs).To(Equal([]TypedConfig{
+				{ID: "2", Type: "cloud", Name: "default", Content: "second"},
+				{ID: "1", Type: "cloud", Name: "default", Content: "first"},
+			}))
+		})
+
+		It("returns error if response is non-200", func() {
+			AppendBadRequest(ghttp.VerifyRequest("GET", "/configs"), server)
+
+			_, err := director.Configs(1, ConfigsFilter{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Finding configs: Director responded with non-successful status code"))
+		})
+	})
+
+	Describe("LatestConfig", func() {
+		It("returns latest config for given type and name", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/configs", "latest=true&name=team-a&type=resurrection"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.RespondWith(http.StatusOK, `[
+	{"id": "3", "type": "resurrection", "name": "team-a", "content": "first"}
+]`),
+				),
+			)
+
+			config, err := director.LatestConfig("resurrection", "team-a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(TypedConfig{ID: "3", Type: "resurrection", Name: "team-a", Content: "first"}))
+		})
+
+		It("returns error if there is no matching config", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/configs"),
+					ghttp.RespondWith(http.StatusOK, `[]`),
+				),
+			)
+
+			_, err := director.LatestConfig("resurrection", "team-a")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("No config with type 'resurrection' and name 'team-a'"))
+		})
+
+		It("returns error if response cannot be unmarshalled", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/configs"),
+					ghttp.RespondWith(http.StatusOK, ``),
+				),
+			)
+
+			_, err := director.LatestConfig("resurrection", "team-a")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Finding configs: Unmarshaling Director response"))
+		})
+	})
+
+	Describe("LatestConfigByID", func() {
+		It("returns config with given ID", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/configs/3"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.RespondWith(http.StatusOK, `{"id": "3", "type": "cloud", "name": "default", "content": "first"}`),
+				),
+			)
+
+			config, err := director.LatestConfigByID("3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(TypedConfig{ID: "3", Type: "cloud", Name: "default", Content: "first"}))
+		})
+
+		It("returns error if response is non-200", func() {
+			AppendBadRequest(ghttp.VerifyRequest("GET", "/configs/3"), server)
+
+			_, err := director.LatestConfigByID("3")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Finding config '3': Director responded with non-successful status code"))
+		})
+	})
+
+	Describe("UpdateConfig", func() {
+		It("updates config of given type and name", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/configs"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.VerifyHeader(http.Header{
+						"Content-Type": []string{"application/json"},
+					}),
+					ghttp.VerifyJSON(`{"type":"resurrection","name":"team-a","content":"config"}`),
+					ghttp.RespondWith(http.StatusCreated, `{"id": "4", "type": "resurrection", "name": "team-a", "content": "config"}`),
+				),
+			)
+
+			config, err := director.UpdateConfig("resurrection", "team-a", []byte("config"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(TypedConfig{ID: "4", Type: "resurrection", Name: "team-a", Content: "config"}))
+		})
+
+		It("returns error if response is non-200", func() {
+			AppendBadRequest(ghttp.VerifyRequest("POST", "/configs"), server)
+
+			_, err := director.UpdateConfig("resurrection", "team-a", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Updating config: Director responded with non-successful status code"))
+		})
+	})
+
+	Describe("DeleteConfig", func() {
+		It("deletes config of given type and name", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/configs", "name=team-a&type=resurrection"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+
+			deleted, err := director.DeleteConfig("resurrection", "team-a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(BeTrue())
+		})
+
+		It("reports that nothing was deleted if there is no matching config", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/configs", "name=team-a&type=resurrection"),
+					ghttp.RespondWith(http.StatusNotFound, ""),
+				),
+			)
+
+			deleted, err := director.DeleteConfig("resurrection", "team-a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deleted).To(BeFalse())
+		})
+
+		It("returns error if response is non-200", func() {
+			AppendBadRequest(ghttp.VerifyRequest("DELETE", "/configs"), server)
+
+			_, err := director.DeleteConfig("resurrection", "team-a")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Deleting config: Director responded with non-successful status code"))
+		})
+	})
+
+	Describe("DiffConfig", func() {
+		It("diffs config of given type and name", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/configs/diff"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.VerifyHeader(http.Header{
+						"Content-Type": []string{"application/json"},
+					}),
+					ghttp.VerifyJSON(`{"type":"resurrection","name":"team-a","content":"config"}`),
+					ghttp.RespondWith(http.StatusOK, `{"diff":[["rules:",null],["- enabled: true","added"]]}`),
+				),
+			)
+
+			diff, err := director.DiffConfig("resurrection", "team-a", []byte("config"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diff).To(Equal(ConfigDiff{
+				Diff: [][]interface{}{
+					[]interface{}{"rules:", nil},
+					[]interface{}{"- enabled: true", "added"},
+				},
+			}))
+		})
+
+		It("returns error if response is non-200", func() {
+			AppendBadRequest(ghttp.VerifyRequest("POST", "/configs/diff"), server)
+
+			_, err := director.DiffConfig("resurrection", "team-a", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Fetching diff result: Director responded with non-successful status code"))
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package director
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type CurlRequest struct {
+	Method  string
+	Path    string
+	Headers http.Header
+	Body    []byte
+
+	// FollowTask waits for a task started by the request to finish
+	// and results in task's final state instead of the original response
+	FollowTask bool
+}
+
+type CurlResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (d DirectorImpl) Curl(req CurlRequest) (CurlResponse, error) {
+	return d.client.Curl(req)
+}
+
+func (c Client) Curl(req CurlRequest) (CurlResponse, error) {
+	if !strings.HasPrefix(req.Path, "/") {
+		return CurlResponse{}, bosherr.Errorf("Expected path '%s' to start with '/'", req.Path)
+	}
+
+	setHeaders := func(httpReq *http.Request) {
+		for name, vals := range req.Headers {
+			for _, val := range vals {
+				httpReq.Header.Add(name, val)
+			}
+		}
+	}
+
+	var body []byte
+	var resp *http.Response
+	var err error
+
+	switch strings.ToUpper(req.Method) {
+	case "", "GET":
+		body, resp, err = c.clientRequest.RawGet(req.Path, nil, setHeaders)
+	case "POST":
+		body, resp, err = c.clientRequest.RawPost(req.Path, req.Body, setHeaders)
+	case "PUT":
+		body, resp, err = c.clientRequest.RawPut(req.Path, req.Body, setHeaders)
+	case "DELETE":
+		body, resp, err = c.clientRequest.RawDeleteCustomized(req.Path, setHeaders)
+	default:
+		return CurlResponse{}, bosherr.Errorf("Unsupported HTTP method '%s'", req.Method)
+	}
+
+	if err != nil {
+		return CurlResponse{}, bosherr.WrapErrorf(err, "Requesting '%s'", req.Path)
+	}
+
+	if !req.FollowTask {
+		return CurlResponse{StatusCode: resp.StatusCode, Body: body}, nil
+	}
+
+	var taskResp taskShortResp
+
+	err = json.Unmarshal(body, &taskResp)
+	if err != nil || taskResp.ID == 0 {
+		return CurlResponse{}, bosherr.Errorf("Expected response to '%s' to be a task", req.Path)
+	}
+
+	err = c.taskClientRequest.WaitForCompletion(taskResp.ID, "event", c.taskClientRequest.taskReporter)
+	if err != nil {
+		return CurlResponse{}, err
+	}
+
+	body, resp, err = c.clientRequest.RawGet(fmt.Sprintf("/tasks/%d", taskResp.ID), nil, nil)
+	if err != nil {
+		return CurlResponse{}, bosherr.WrapErrorf(err, "Finding task '%d'", taskResp.ID)
+	}
+
+	return CurlResponse{StatusCode: resp.StatusCode, Body: body}, nil
+}
@@ -0,0 +1,151 @@
+package director_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/director"
+)
+
+var _ = Describe("Director", func() {
+	var (
+		director Director
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		director, server = BuildServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Curl", func() {
+		It("makes authenticated GET request with given headers", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/deployments", "exclude_configs=true"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.VerifyHeader(http.Header{"X-Custom": []string{"val"}}),
+					ghttp.RespondWith(http.StatusOK, `[{"name":"dep"}]`),
+				),
+			)
+
+			resp, err := director.Curl(CurlRequest{
+				Method:  "get",
+				Path:    "/deployments?exclude_configs=true",
+				Headers: http.Header{"X-Custom": []string{"val"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp).To(Equal(CurlResponse{StatusCode: http.StatusOK, Body: []byte(`[{"name":"dep"}]`)}))
+		})
+
+		It("makes POST request with body", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/configs"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.VerifyHeader(http.Header{"Content-Type": []string{"application/json"}}),
+					ghttp.VerifyBody([]byte(`{"type":"cloud"}`)),
+					ghttp.RespondWith(http.StatusCreated, `{"id":"1"}`),
+				),
+			)
+
+			resp, err := director.Curl(CurlRequest{
+				Method:  "POST",
+				Path:    "/configs",
+				Headers: http.Header{"Content-Type": []string{"application/json"}},
+				Body:    []byte(`{"type":"cloud"}`),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp).To(Equal(CurlResponse{StatusCode: http.StatusCreated, Body: []byte(`{"id":"1"}`)}))
+		})
+
+		It("makes DELETE request", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/configs", "type=cloud&name=default"),
+					ghttp.VerifyBasicAuth("username", "password"),
+					ghttp.RespondWith(http.StatusNoContent, ``),
+				),
+			)
+
+			resp, err := director.Curl(CurlRequest{Method: "DELETE", Path: "/configs?type=cloud&name=default"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+		})
+
+		It("returns task created by the request without waiting for it by default", func() {
+			redirectHeader := http.Header{}
+			redirectHeader.Add("Location", "/tasks/123")
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/deployments/dep/jobs/job"),
+					ghttp.RespondWith(http.StatusFound, nil, redirectHeader),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/tasks/123"),
+					ghttp.RespondWith(http.StatusOK, `{"id":123,"state":"queued"}`),
+				),
+			)
+
+			resp, err := director.Curl(CurlRequest{Method: "PUT", Path: "/deployments/dep/jobs/job"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Body).To(Equal([]byte(`{"id":123,"state":"queued"}`)))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("waits for task to finish and returns its final state if requested", func() {
+			ConfigureTaskResult(ghttp.VerifyRequest("POST", "/deployments/dep/errands/errand/runs"), "", server)
+
+			// Replace result output request with final task state
+			server.SetHandler(4, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/tasks/123"),
+				ghttp.VerifyBasicAuth("username", "password"),
+				ghttp.RespondWith(http.StatusOK, `{"id":123,"state":"done","result":"ok"}`),
+			))
+
+			resp, err := director.Curl(CurlRequest{
+				Method:     "POST",
+				Path:       "/deployments/dep/errands/errand/runs",
+				FollowTask: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp.Body).To(Equal([]byte(`{"id":123,"state":"done","result":"ok"}`)))
+		})
+
+		It("returns error if following task is requested but response is not a task", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"name":"dir"}`))
+
+			_, err := director.Curl(CurlRequest{Path: "/info", FollowTask: true})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected response to '/info' to be a task"))
+		})
+
+		It("returns error if method is not supported", func() {
+			_, err := director.Curl(CurlRequest{Method: "PATCH", Path: "/info"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Unsupported HTTP method 'PATCH'"))
+		})
+
+		It("returns error if path is not absolute", func() {
+			_, err := director.Curl(CurlRequest{Path: "info"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected path 'info' to start with '/'"))
+		})
+
+		It("returns error if response is non-successful", func() {
+			AppendBadRequest(ghttp.VerifyRequest("GET", "/info"), server)
+
+			_, err := director.Curl(CurlRequest{Path: "/info"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Requesting '/info': Director responded with non-successful status code '400'"))
+		})
+	})
+})
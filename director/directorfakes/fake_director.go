@@ -221,6 +221,67 @@ type FakeDirector struct {
 	uploadStemcellFileReturns struct {
 		result1 error
 	}
+	ConfigsStub        func(limit int, filter director.ConfigsFilter) ([]director.TypedConfig, error)
+	configsMutex       sync.RWMutex
+	configsArgsForCall []struct {
+		limit  int
+		filter director.ConfigsFilter
+	}
+	configsReturns struct {
+		result1 []director.TypedConfig
+		result2 error
+	}
+	LatestConfigStub        func(configType string, name string) (director.TypedConfig, error)
+	latestConfigMutex       sync.RWMutex
+	latestConfigArgsForCall []struct {
+		configType string
+		name       string
+	}
+	latestConfigReturns struct {
+		result1 director.TypedConfig
+		result2 error
+	}
+	LatestConfigByIDStub        func(configID string) (director.TypedConfig, error)
+	latestConfigByIDMutex       sync.RWMutex
+	latestConfigByIDArgsForCall []struct {
+		configID string
+	}
+	latestConfigByIDReturns struct {
+		result1 director.TypedConfig
+		result2 error
+	}
+	UpdateConfigStub        func(configType string, name string, content []byte) (director.TypedConfig, error)
+	updateConfigMutex       sync.RWMutex
+	updateConfigArgsForCall []struct {
+		configType string
+		name       string
+		content    []byte
+	}
+	updateConfigReturns struct {
+		result1 director.TypedConfig
+		result2 error
+	}
+	DeleteConfigStub        func(configType string, name string) (bool, error)
+	deleteConfigMutex       sync.RWMutex
+	deleteConfigArgsForCall []struct {
+		configType string
+		name       string
+	}
+	deleteConfigReturns struct {
+		result1 bool
+		result2 error
+	}
+	DiffConfigStub        func(configType string, name string, manifest []byte) (director.ConfigDiff, error)
+	diffConfigMutex       sync.RWMutex
+	diffConfigArgsForCall []struct {
+		configType string
+		name       string
+		manifest   []byte
+	}
+	diffConfigReturns struct {
+		result1 director.ConfigDiff
+		result2 error
+	}
 	LatestCloudConfigStub        func() (director.CloudConfig, error)
 	latestCloudConfigMutex       sync.RWMutex
 	latestCloudConfigArgsForCall []struct{}
@@ -348,6 +409,15 @@ type FakeDirector struct {
 	downloadResourceUncheckedReturns struct {
 		result1 error
 	}
+	CurlStub        func(director.CurlRequest) (director.CurlResponse, error)
+	curlMutex       sync.RWMutex
+	curlArgsForCall []struct {
+		arg1 director.CurlRequest
+	}
+	curlReturns struct {
+		result1 director.CurlResponse
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -1104,6 +1174,221 @@ func (fake *FakeDirector) UploadStemcellFileReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDirector) Configs(limit int, filter director.ConfigsFilter) ([]director.TypedConfig, error) {
+	fake.configsMutex.Lock()
+	fake.configsArgsForCall = append(fake.configsArgsForCall, struct {
+		limit  int
+		filter director.ConfigsFilter
+	}{limit, filter})
+	fake.recordInvocation("Configs", []interface{}{limit, filter})
+	fake.configsMutex.Unlock()
+	if fake.ConfigsStub != nil {
+		return fake.ConfigsStub(limit, filter)
+	}
+	return fake.configsReturns.result1, fake.configsReturns.result2
+}
+
+func (fake *FakeDirector) ConfigsCallCount() int {
+	fake.configsMutex.RLock()
+	defer fake.configsMutex.RUnlock()
+	return len(fake.configsArgsForCall)
+}
+
+func (fake *FakeDirector) ConfigsArgsForCall(i int) (int, director.ConfigsFilter) {
+	fake.configsMutex.RLock()
+	defer fake.configsMutex.RUnlock()
+	return fake.configsArgsForCall[i].limit, fake.configsArgsForCall[i].filter
+}
+
+func (fake *FakeDirector) ConfigsReturns(result1 []director.TypedConfig, result2 error) {
+	fake.ConfigsStub = nil
+	fake.configsReturns = struct {
+		result1 []director.TypedConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) LatestConfig(configType string, name string) (director.TypedConfig, error) {
+	fake.latestConfigMutex.Lock()
+	fake.latestConfigArgsForCall = append(fake.latestConfigArgsForCall, struct {
+		configType string
+		name       string
+	}{configType, name})
+	fake.recordInvocation("LatestConfig", []interface{}{configType, name})
+	fake.latestConfigMutex.Unlock()
+	if fake.LatestConfigStub != nil {
+		return fake.LatestConfigStub(configType, name)
+	}
+	return fake.latestConfigReturns.result1, fake.latestConfigReturns.result2
+}
+
+func (fake *FakeDirector) LatestConfigCallCount() int {
+	fake.latestConfigMutex.RLock()
+	defer fake.latestConfigMutex.RUnlock()
+	return len(fake.latestConfigArgsForCall)
+}
+
+func (fake *FakeDirector) LatestConfigArgsForCall(i int) (string, string) {
+	fake.latestConfigMutex.RLock()
+	defer fake.latestConfigMutex.RUnlock()
+	return fake.latestConfigArgsForCall[i].configType, fake.latestConfigArgsForCall[i].name
+}
+
+func (fake *FakeDirector) LatestConfigReturns(result1 director.TypedConfig, result2 error) {
+	fake.LatestConfigStub = nil
+	fake.latestConfigReturns = struct {
+		result1 director.TypedConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) LatestConfigByID(configID string) (director.TypedConfig, error) {
+	fake.latestConfigByIDMutex.Lock()
+	fake.latestConfigByIDArgsForCall = append(fake.latestConfigByIDArgsForCall, struct {
+		configID string
+	}{configID})
+	fake.recordInvocation("LatestConfigByID", []interface{}{configID})
+	fake.latestConfigByIDMutex.Unlock()
+	if fake.LatestConfigByIDStub != nil {
+		return fake.LatestConfigByIDStub(configID)
+	}
+	return fake.latestConfigByIDReturns.result1, fake.latestConfigByIDReturns.result2
+}
+
+func (fake *FakeDirector) LatestConfigByIDCallCount() int {
+	fake.latestConfigByIDMutex.RLock()
+	defer fake.latestConfigByIDMutex.RUnlock()
+	return len(fake.latestConfigByIDArgsForCall)
+}
+
+func (fake *FakeDirector) LatestConfigByIDArgsForCall(i int) string {
+	fake.latestConfigByIDMutex.RLock()
+	defer fake.latestConfigByIDMutex.RUnlock()
+	return fake.latestConfigByIDArgsForCall[i].configID
+}
+
+func (fake *FakeDirector) LatestConfigByIDReturns(result1 director.TypedConfig, result2 error) {
+	fake.LatestConfigByIDStub = nil
+	fake.latestConfigByIDReturns = struct {
+		result1 director.TypedConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) UpdateConfig(configType string, name string, content []byte) (director.TypedConfig, error) {
+	var contentCopy []byte
+	if content != nil {
+		contentCopy = make([]byte, len(content))
+		copy(contentCopy, content)
+	}
+	fake.updateConfigMutex.Lock()
+	fake.updateConfigArgsForCall = append(fake.updateConfigArgsForCall, struct {
+		configType string
+		name       string
+		content    []byte
+	}{configType, name, contentCopy})
+	fake.recordInvocation("UpdateConfig", []interface{}{configType, name, contentCopy})
+	fake.updateConfigMutex.Unlock()
+	if fake.UpdateConfigStub != nil {
+		return fake.UpdateConfigStub(configType, name, content)
+	}
+	return fake.updateConfigReturns.result1, fake.updateConfigReturns.result2
+}
+
+func (fake *FakeDirector) UpdateConfigCallCount() int {
+	fake.updateConfigMutex.RLock()
+	defer fake.updateConfigMutex.RUnlock()
+	return len(fake.updateConfigArgsForCall)
+}
+
+func (fake *FakeDirector) UpdateConfigArgsForCall(i int) (string, string, []byte) {
+	fake.updateConfigMutex.RLock()
+	defer fake.updateConfigMutex.RUnlock()
+	return fake.updateConfigArgsForCall[i].configType, fake.updateConfigArgsForCall[i].name, fake.updateConfigArgsForCall[i].content
+}
+
+func (fake *FakeDirector) UpdateConfigReturns(result1 director.TypedConfig, result2 error) {
+	fake.UpdateConfigStub = nil
+	fake.updateConfigReturns = struct {
+		result1 director.TypedConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) DeleteConfig(configType string, name string) (bool, error) {
+	fake.deleteConfigMutex.Lock()
+	fake.deleteConfigArgsForCall = append(fake.deleteConfigArgsForCall, struct {
+		configType string
+		name       string
+	}{configType, name})
+	fake.recordInvocation("DeleteConfig", []interface{}{configType, name})
+	fake.deleteConfigMutex.Unlock()
+	if fake.DeleteConfigStub != nil {
+		return fake.DeleteConfigStub(configType, name)
+	}
+	return fake.deleteConfigReturns.result1, fake.deleteConfigReturns.result2
+}
+
+func (fake *FakeDirector) DeleteConfigCallCount() int {
+	fake.deleteConfigMutex.RLock()
+	defer fake.deleteConfigMutex.RUnlock()
+	return len(fake.deleteConfigArgsForCall)
+}
+
+func (fake *FakeDirector) DeleteConfigArgsForCall(i int) (string, string) {
+	fake.deleteConfigMutex.RLock()
+	defer fake.deleteConfigMutex.RUnlock()
+	return fake.deleteConfigArgsForCall[i].configType, fake.deleteConfigArgsForCall[i].name
+}
+
+func (fake *FakeDirector) DeleteConfigReturns(result1 bool, result2 error) {
+	fake.DeleteConfigStub = nil
+	fake.deleteConfigReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) DiffConfig(configType string, name string, manifest []byte) (director.ConfigDiff, error) {
+	var manifestCopy []byte
+	if manifest != nil {
+		manifestCopy = make([]byte, len(manifest))
+		copy(manifestCopy, manifest)
+	}
+	fake.diffConfigMutex.Lock()
+	fake.diffConfigArgsForCall = append(fake.diffConfigArgsForCall, struct {
+		configType string
+		name       string
+		manifest   []byte
+	}{configType, name, manifestCopy})
+	fake.recordInvocation("DiffConfig", []interface{}{configType, name, manifestCopy})
+	fake.diffConfigMutex.Unlock()
+	if fake.DiffConfigStub != nil {
+		return fake.DiffConfigStub(configType, name, manifest)
+	}
+	return fake.diffConfigReturns.result1, fake.diffConfigReturns.result2
+}
+
+func (fake *FakeDirector) DiffConfigCallCount() int {
+	fake.diffConfigMutex.RLock()
+	defer fake.diffConfigMutex.RUnlock()
+	return len(fake.diffConfigArgsForCall)
+}
+
+func (fake *FakeDirector) DiffConfigArgsForCall(i int) (string, string, []byte) {
+	fake.diffConfigMutex.RLock()
+	defer fake.diffConfigMutex.RUnlock()
+	return fake.diffConfigArgsForCall[i].configType, fake.diffConfigArgsForCall[i].name, fake.diffConfigArgsForCall[i].manifest
+}
+
+func (fake *FakeDirector) DiffConfigReturns(result1 director.ConfigDiff, result2 error) {
+	fake.DiffConfigStub = nil
+	fake.diffConfigReturns = struct {
+		result1 director.ConfigDiff
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeDirector) LatestCloudConfig() (director.CloudConfig, error) {
 	fake.latestCloudConfigMutex.Lock()
 	fake.latestCloudConfigArgsForCall = append(fake.latestCloudConfigArgsForCall, struct{}{})
@@ -1603,6 +1888,39 @@ func (fake *FakeDirector) DownloadResourceUncheckedReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDirector) Curl(arg1 director.CurlRequest) (director.CurlResponse, error) {
+	fake.curlMutex.Lock()
+	fake.curlArgsForCall = append(fake.curlArgsForCall, struct {
+		arg1 director.CurlRequest
+	}{arg1})
+	fake.recordInvocation("Curl", []interface{}{arg1})
+	fake.curlMutex.Unlock()
+	if fake.CurlStub != nil {
+		return fake.CurlStub(arg1)
+	}
+	return fake.curlReturns.result1, fake.curlReturns.result2
+}
+
+func (fake *FakeDirector) CurlCallCount() int {
+	fake.curlMutex.RLock()
+	defer fake.curlMutex.RUnlock()
+	return len(fake.curlArgsForCall)
+}
+
+func (fake *FakeDirector) CurlArgsForCall(i int) director.CurlRequest {
+	fake.curlMutex.RLock()
+	defer fake.curlMutex.RUnlock()
+	return fake.curlArgsForCall[i].arg1
+}
+
+func (fake *FakeDirector) CurlReturns(result1 director.CurlResponse, result2 error) {
+	fake.CurlStub = nil
+	fake.curlReturns = struct {
+		result1 director.CurlResponse
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeDirector) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -1654,6 +1972,18 @@ func (fake *FakeDirector) Invocations() map[string][][]interface{} {
 	defer fake.uploadStemcellURLMutex.RUnlock()
 	fake.uploadStemcellFileMutex.RLock()
 	defer fake.uploadStemcellFileMutex.RUnlock()
+	fake.configsMutex.RLock()
+	defer fake.configsMutex.RUnlock()
+	fake.latestConfigMutex.RLock()
+	defer fake.latestConfigMutex.RUnlock()
+	fake.latestConfigByIDMutex.RLock()
+	defer fake.latestConfigByIDMutex.RUnlock()
+	fake.updateConfigMutex.RLock()
+	defer fake.updateConfigMutex.RUnlock()
+	fake.deleteConfigMutex.RLock()
+	defer fake.deleteConfigMutex.RUnlock()
+	fake.diffConfigMutex.RLock()
+	defer fake.diffConfigMutex.RUnlock()
 	fake.latestCloudConfigMutex.RLock()
 	defer fake.latestCloudConfigMutex.RUnlock()
 	fake.updateCloudConfigMutex.RLock()
@@ -1684,6 +2014,8 @@ func (fake *FakeDirector) Invocations() map[string][][]interface{} {
 	defer fake.cleanUpMutex.RUnlock()
 	fake.downloadResourceUncheckedMutex.RLock()
 	defer fake.downloadResourceUncheckedMutex.RUnlock()
+	fake.curlMutex.RLock()
+	defer fake.curlMutex.RUnlock()
 	return fake.invocations
 }
 
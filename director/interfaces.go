@@ -43,6 +43,13 @@ type Director interface {
 	UploadStemcellURL(url, sha1 string, fix bool) error
 	UploadStemcellFile(file UploadFile, fix bool) error
 
+	Configs(limit int, filter ConfigsFilter) ([]TypedConfig, error)
+	LatestConfig(configType string, name string) (TypedConfig, error)
+	LatestConfigByID(configID string) (TypedConfig, error)
+	UpdateConfig(configType string, name string, content []byte) (TypedConfig, error)
+	DeleteConfig(configType string, name string) (bool, error)
+	DiffConfig(configType string, name string, manifest []byte) (ConfigDiff, error)
+
 	LatestCloudConfig() (CloudConfig, error)
 	UpdateCloudConfig([]byte) error
 	DiffCloudConfig(manifest []byte) (ConfigDiff, error)
@@ -62,6 +69,8 @@ type Director interface {
 	EnableResurrection(bool) error
 	CleanUp(bool) error
 	DownloadResourceUnchecked(blobstoreID string, out io.Writer) error
+
+	Curl(CurlRequest) (CurlResponse, error)
 }
 
 var _ Director = &DirectorImpl{}
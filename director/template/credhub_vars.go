@@ -0,0 +1,319 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	gourl "net/url"
+	"path"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+	"gopkg.in/yaml.v2"
+)
+
+// CredHubVariables finds, generates and lists variables stored in a
+// CredHub (or config-server) compatible credential manager.
+// Relative variable names are placed under given namespace.
+type CredHubVariables struct {
+	endpoint  string
+	namespace string
+	tokenFunc func(bool) (string, error)
+	client    boshhttpclient.HTTPClient
+}
+
+type credHubDataResp struct {
+	Data []credHubCredResp `json:"data"`
+}
+
+type credHubCredResp struct {
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type credHubFindResp struct {
+	Credentials []credHubCredResp `json:"credentials"`
+}
+
+type credHubGenerateReq struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Parameters interface{} `json:"parameters,omitempty"`
+}
+
+type credHubInfoResp struct {
+	AuthServer struct {
+		URL string `json:"url"`
+	} `json:"auth-server"`
+}
+
+var _ Variables = CredHubVariables{}
+
+func NewCredHubVariables(
+	endpoint string,
+	namespace string,
+	tokenFunc func(bool) (string, error),
+	client boshhttpclient.HTTPClient,
+) CredHubVariables {
+	return CredHubVariables{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		namespace: path.Join("/", namespace),
+		tokenFunc: tokenFunc,
+		client:    client,
+	}
+}
+
+// AuthServerURL returns URL of the UAA that issues tokens for the credential manager.
+func (v CredHubVariables) AuthServerURL() (string, error) {
+	var resp credHubInfoResp
+
+	_, err := v.request("GET", "/info", nil, &resp)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Fetching credential manager info")
+	}
+
+	return resp.AuthServer.URL, nil
+}
+
+func (v CredHubVariables) Get(varDef VariableDefinition) (interface{}, bool, error) {
+	name := v.fullName(varDef.Name)
+
+	var resp credHubDataResp
+
+	query := gourl.Values{}
+	query.Add("name", name)
+	query.Add("current", "true")
+
+	status, err := v.request("GET", "/api/v1/data?"+query.Encode(), nil, &resp)
+	if err != nil && status != http.StatusNotFound {
+		return nil, false, bosherr.WrapErrorf(err, "Finding variable '%s'", name)
+	}
+
+	if len(resp.Data) > 0 {
+		val, err := v.yamlCompatible(resp.Data[0].Value)
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Finding variable '%s'", name)
+		}
+
+		return val, true, nil
+	}
+
+	if len(varDef.Type) == 0 {
+		return nil, false, nil
+	}
+
+	val, err := v.generate(name, varDef)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Generating variable '%s'", name)
+	}
+
+	return val, true, nil
+}
+
+func (v CredHubVariables) List() ([]VariableDefinition, error) {
+	var resp credHubFindResp
+
+	query := gourl.Values{}
+	query.Add("path", v.namespace)
+
+	_, err := v.request("GET", "/api/v1/data?"+query.Encode(), nil, &resp)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing variables under '%s'", v.namespace)
+	}
+
+	var defs []VariableDefinition
+
+	for _, cred := range resp.Credentials {
+		defs = append(defs, VariableDefinition{Name: v.relativeName(cred.Name)})
+	}
+
+	return defs, nil
+}
+
+func (v CredHubVariables) generate(name string, varDef VariableDefinition) (interface{}, error) {
+	params, err := v.generateParams(varDef)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(credHubGenerateReq{Name: name, Type: varDef.Type, Parameters: params})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshaling generation request")
+	}
+
+	var resp credHubCredResp
+
+	_, err = v.request("POST", "/api/v1/data", reqBody, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.yamlCompatible(resp.Value)
+}
+
+// generateParams converts variable options into JSON friendly parameters
+// and makes certificate's CA reference use the same namespace as the variable.
+func (v CredHubVariables) generateParams(varDef VariableDefinition) (map[string]interface{}, error) {
+	if varDef.Options == nil {
+		return nil, nil
+	}
+
+	opts, ok := varDef.Options.(map[interface{}]interface{})
+	if !ok {
+		return nil, bosherr.Errorf("Expected variable options to be a hash")
+	}
+
+	params := map[string]interface{}{}
+
+	for key, val := range opts {
+		keyStr, ok := key.(string)
+		if !ok {
+			return nil, bosherr.Errorf("Expected variable option key '%v' to be a string", key)
+		}
+
+		params[keyStr] = v.jsonCompatible(val)
+	}
+
+	if ca, ok := params["ca"].(string); ok && varDef.Type == "certificate" {
+		params["ca"] = v.fullName(ca)
+	}
+
+	return params, nil
+}
+
+func (v CredHubVariables) jsonCompatible(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for k, v2 := range typedVal {
+			result[fmt.Sprintf("%v", k)] = v.jsonCompatible(v2)
+		}
+		return result
+
+	case []interface{}:
+		result := []interface{}{}
+		for _, v2 := range typedVal {
+			result = append(result, v.jsonCompatible(v2))
+		}
+		return result
+
+	default:
+		return val
+	}
+}
+
+// yamlCompatible converts JSON objects (map[string]interface{}) into
+// map[interface{}]interface{} so that interpolation can find their keys
+// (e.g. '((cert.certificate))') the same way as in values loaded from YAML.
+func (v CredHubVariables) yamlCompatible(val interface{}) (interface{}, error) {
+	bytes, err := yaml.Marshal(val)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshaling variable value")
+	}
+
+	var result interface{}
+
+	err = yaml.Unmarshal(bytes, &result)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshaling variable value")
+	}
+
+	return result, nil
+}
+
+func (v CredHubVariables) request(method, urlPath string, body []byte, response interface{}) (int, error) {
+	url := v.endpoint + urlPath
+
+	resp, err := v.doRequest(method, url, body, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+
+		resp, err = v.doRequest(method, url, body, true)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, bosherr.WrapError(err, "Reading credential manager response")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := "Credential manager responded with non-successful status code '%d' response '%s'"
+		return resp.StatusCode, bosherr.Errorf(msg, resp.StatusCode, respBody)
+	}
+
+	err = json.Unmarshal(respBody, response)
+	if err != nil {
+		return resp.StatusCode, bosherr.WrapError(err, "Unmarshaling credential manager response")
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (v CredHubVariables) doRequest(method, url string, body []byte, retried bool) (*http.Response, error) {
+	var authHeader string
+
+	if v.tokenFunc != nil {
+		var err error
+
+		authHeader, err = v.tokenFunc(retried)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Retrieving access token")
+		}
+	}
+
+	setHeaders := func(req *http.Request) {
+		if len(authHeader) > 0 {
+			req.Header.Set("Authorization", authHeader)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	var resp *http.Response
+	var err error
+
+	switch method {
+	case "GET":
+		resp, err = v.client.GetCustomized(url, setHeaders)
+	case "POST":
+		resp, err = v.client.PostCustomized(url, body, setHeaders)
+	default:
+		return nil, bosherr.Errorf("Unsupported request method '%s'", method)
+	}
+
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Performing request %s '%s'", method, url)
+	}
+
+	return resp, nil
+}
+
+func (v CredHubVariables) fullName(name string) string {
+	if strings.HasPrefix(name, "/") {
+		return name
+	}
+
+	return path.Join(v.namespace, name)
+}
+
+func (v CredHubVariables) relativeName(name string) string {
+	if v.namespace == "/" {
+		return strings.TrimPrefix(name, "/")
+	}
+
+	return strings.TrimPrefix(name, v.namespace+"/")
+}
@@ -0,0 +1,233 @@
+package template_test
+
+import (
+	"errors"
+	"net/http"
+
+	boshhttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("CredHubVariables", func() {
+	var (
+		server    *ghttp.Server
+		retries   []bool
+		tokenErr  error
+		tokenFunc func(bool) (string, error)
+		vars      CredHubVariables
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		retries = nil
+		tokenErr = nil
+
+		tokenFunc = func(retried bool) (string, error) {
+			retries = append(retries, retried)
+			if retried {
+				return "bearer new-token", tokenErr
+			}
+			return "bearer token", tokenErr
+		}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		client := boshhttpclient.NewHTTPClient(boshhttpclient.DefaultClient, logger)
+
+		vars = NewCredHubVariables(server.URL(), "namespace", tokenFunc, client)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("AuthServerURL", func() {
+		It("returns auth server URL from credential manager info", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/info"),
+					ghttp.RespondWith(http.StatusOK, `{"auth-server":{"url":"https://uaa:8443"}}`),
+				),
+			)
+
+			url, err := vars.AuthServerURL()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(url).To(Equal("https://uaa:8443"))
+		})
+
+		It("returns error if info cannot be fetched", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, "err"))
+
+			_, err := vars.AuthServerURL()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(
+				"Fetching credential manager info: Credential manager responded with non-successful status code '500' response 'err'"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns current value of namespaced variable", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "current=true&name=%2Fnamespace%2Fkey"),
+					ghttp.VerifyHeader(http.Header{"Authorization": []string{"bearer token"}}),
+					ghttp.RespondWith(http.StatusOK, `{"data":[{"name":"/namespace/key","type":"value","value":"val"}]}`),
+				),
+			)
+
+			val, found, err := vars.Get(VariableDefinition{Name: "key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal("val"))
+		})
+
+		It("does not namespace absolute variable names", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "current=true&name=%2Fother%2Fkey"),
+					ghttp.RespondWith(http.StatusOK, `{"data":[{"value":"val"}]}`),
+				),
+			)
+
+			val, found, err := vars.Get(VariableDefinition{Name: "/other/key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal("val"))
+		})
+
+		It("returns not found if variable does not exist and cannot be generated", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data"),
+					ghttp.RespondWith(http.StatusNotFound, `{"error":"not found"}`),
+				),
+			)
+
+			val, found, err := vars.Get(VariableDefinition{Name: "key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(val).To(BeNil())
+		})
+
+		It("generates variable with namespaced certificate CA if variable does not exist", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data"),
+					ghttp.RespondWith(http.StatusNotFound, `{"error":"not found"}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/data"),
+					ghttp.VerifyHeader(http.Header{
+						"Authorization": []string{"bearer token"},
+						"Content-Type":  []string{"application/json"},
+					}),
+					ghttp.VerifyJSON(`{
+						"name": "/namespace/cert",
+						"type": "certificate",
+						"parameters": {"ca": "/namespace/ca", "common_name": "cert", "alternative_names": ["a", "b"]}
+					}`),
+					ghttp.RespondWith(http.StatusOK, `{"name":"/namespace/cert","value":{"certificate":"cert-val"}}`),
+				),
+			)
+
+			val, found, err := vars.Get(VariableDefinition{
+				Name: "cert",
+				Type: "certificate",
+				Options: map[interface{}]interface{}{
+					"ca":                "ca",
+					"common_name":       "cert",
+					"alternative_names": []interface{}{"a", "b"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal(map[interface{}]interface{}{"certificate": "cert-val"}))
+		})
+
+		It("returns hash values that can be interpolated by their keys", func() {
+			server.RouteToHandler("GET", "/api/v1/data", ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/data", "current=true&name=%2Fnamespace%2Fcert"),
+				ghttp.RespondWith(http.StatusOK, `{"data":[{"name":"/namespace/cert","type":"certificate","value":{"certificate":"cert-val","private_key":"key-val"}}]}`),
+			))
+
+			tpl := NewTemplate([]byte("cert: ((cert.certificate))\nkey: ((cert.private_key))\n"))
+
+			result, err := tpl.Evaluate(vars, nil, EvaluateOpts{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(result)).To(Equal("cert: cert-val\nkey: key-val\n"))
+		})
+
+		It("retries with refreshed token if request is unauthorized", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeader(http.Header{"Authorization": []string{"bearer token"}}),
+					ghttp.RespondWith(http.StatusUnauthorized, ""),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeader(http.Header{"Authorization": []string{"bearer new-token"}}),
+					ghttp.RespondWith(http.StatusOK, `{"data":[{"value":"val"}]}`),
+				),
+			)
+
+			val, found, err := vars.Get(VariableDefinition{Name: "key"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(val).To(Equal("val"))
+			Expect(retries).To(Equal([]bool{false, true}))
+		})
+
+		It("returns error if token cannot be retrieved", func() {
+			tokenErr = errors.New("fake-err")
+
+			_, _, err := vars.Get(VariableDefinition{Name: "key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Retrieving access token: fake-err"))
+		})
+
+		It("returns error if finding variable fails", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, "err"))
+
+			_, _, err := vars.Get(VariableDefinition{Name: "key"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Finding variable '/namespace/key'"))
+		})
+
+		It("returns error if generating variable fails", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusNotFound, ""),
+				ghttp.RespondWith(http.StatusBadRequest, "bad"),
+			)
+
+			_, _, err := vars.Get(VariableDefinition{Name: "key", Type: "password"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Generating variable '/namespace/key'"))
+		})
+	})
+
+	Describe("List", func() {
+		It("returns variables under namespace with relative names", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "path=%2Fnamespace"),
+					ghttp.RespondWith(http.StatusOK, `{"credentials":[{"name":"/namespace/a"},{"name":"/namespace/b"}]}`),
+				),
+			)
+
+			defs, err := vars.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(defs).To(Equal([]VariableDefinition{{Name: "a"}, {Name: "b"}}))
+		})
+
+		It("returns error if listing fails", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, "err"))
+
+			_, err := vars.List()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Listing variables under '/namespace'"))
+		})
+	})
+})
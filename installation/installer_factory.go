@@ -21,6 +21,7 @@ import (
 
 type InstallerFactory interface {
 	NewInstaller(Target) Installer
+	NewLocalCPIInstaller(Target) Installer
 }
 
 type installerFactory struct {
@@ -34,6 +35,7 @@ type installerFactory struct {
 	logTag                 string
 	fs                     boshsys.FileSystem
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	parallel               int
 }
 
 func NewInstallerFactory(
@@ -46,6 +48,7 @@ func NewInstallerFactory(
 	logger boshlog.Logger,
 	fs boshsys.FileSystem,
 	digestCreateAlgorithms []boshcrypto.Algorithm,
+	parallel int,
 ) InstallerFactory {
 	return &installerFactory{
 		ui:                    ui,
@@ -58,6 +61,7 @@ func NewInstallerFactory(
 		logTag:                "installer",
 		fs:                    fs,
 		digestCreateAlgorithms: digestCreateAlgorithms,
+		parallel:               parallel,
 	}
 }
 
@@ -71,6 +75,7 @@ func (f *installerFactory) NewInstaller(target Target) Installer {
 		releaseJobResolver: f.releaseJobResolver,
 		fs:                 f.fs,
 		digestCreateAlgorithms: f.digestCreateAlgorithms,
+		parallel:               f.parallel,
 	}
 
 	return NewInstaller(
@@ -84,6 +89,10 @@ func (f *installerFactory) NewInstaller(target Target) Installer {
 	)
 }
 
+func (f *installerFactory) NewLocalCPIInstaller(target Target) Installer {
+	return NewLocalCPIInstaller(target, f.fs, f.registryServerManager, f.logger)
+}
+
 type installerFactoryContext struct {
 	target             Target
 	fs                 boshsys.FileSystem
@@ -99,11 +108,12 @@ type installerFactoryContext struct {
 	blobExtractor          blobextract.Extractor
 	compiledPackageRepo    bistatepkg.CompiledPackageRepo
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	parallel               int
 }
 
 func (c *installerFactoryContext) JobRenderer() JobRenderer {
 
-	erbRenderer := bierbrenderer.NewERBRenderer(c.fs, c.runner, c.logger)
+	erbRenderer := bierbrenderer.NewDefaultERBRenderer(c.fs, c.runner, c.logger)
 	jobRenderer := bitemplate.NewJobRenderer(erbRenderer, c.fs, c.uuidGenerator, c.logger)
 	jobListRenderer := bitemplate.NewJobListRenderer(jobRenderer, c.logger)
 
@@ -132,6 +142,7 @@ func (c *installerFactoryContext) JobDependencyCompiler() bistatejob.DependencyC
 
 	c.jobDependencyCompiler = bistatejob.NewDependencyCompiler(
 		c.InstallationStatePackageCompiler(),
+		c.parallel,
 		c.logger,
 	)
 
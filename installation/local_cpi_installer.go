@@ -0,0 +1,95 @@
+package installation
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// LocalCPIConfigFile is relative to the installed job path
+const LocalCPIConfigFile = "config/local_cpi.json"
+
+// LocalCPIConfig is written into installed local CPI job instead of rendered templates
+type LocalCPIConfig struct {
+	// Mbus is used by the fake agent started for each VM
+	Mbus string `json:"mbus"`
+
+	// Dir keeps stemcells, VMs and disks created by the local CPI
+	Dir string `json:"dir"`
+}
+
+type localCPIInstaller struct {
+	target                Target
+	fs                    boshsys.FileSystem
+	registryServerManager biregistry.ServerManager
+	logger                boshlog.Logger
+	logTag                string
+}
+
+// NewLocalCPIInstaller installs CPI that is built into the CLI;
+// nothing needs to be compiled or rendered for it
+func NewLocalCPIInstaller(
+	target Target,
+	fs boshsys.FileSystem,
+	registryServerManager biregistry.ServerManager,
+	logger boshlog.Logger,
+) Installer {
+	return &localCPIInstaller{
+		target:                target,
+		fs:                    fs,
+		registryServerManager: registryServerManager,
+		logger:                logger,
+		logTag:                "localCPIInstaller",
+	}
+}
+
+func (i *localCPIInstaller) Install(manifest biinstallmanifest.Manifest, stage biui.Stage) (Installation, error) {
+	i.logger.Info(i.logTag, "Installing local CPI for deployment '%s'", manifest.Name)
+
+	jobDir := filepath.Join(i.target.JobsPath(), manifest.Template.Name)
+
+	err := stage.Perform("Installing local CPI", func() error {
+		config := LocalCPIConfig{
+			Mbus: manifest.Mbus,
+			Dir:  filepath.Join(i.target.Path(), "local-cpi"),
+		}
+
+		// Stemcells, VMs and disks could be kept outside of installation
+		// so that they survive removal of installation directory
+		if localProps, ok := manifest.Properties["local"].(biproperty.Map); ok {
+			if dir, ok := localProps["dir"].(string); ok && dir != "" {
+				config.Dir = dir
+			}
+		}
+
+		configBytes, err := json.Marshal(config)
+		if err != nil {
+			return bosherr.WrapError(err, "Marshalling local CPI config")
+		}
+
+		err = i.fs.WriteFile(filepath.Join(jobDir, LocalCPIConfigFile), configBytes)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing local CPI config")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	installedJob := NewInstalledJob(RenderedJobRef{Name: manifest.Template.Name}, jobDir)
+
+	return NewInstallation(i.target, installedJob, manifest, i.registryServerManager), nil
+}
+
+func (i *localCPIInstaller) Cleanup(installation Installation) error {
+	return i.fs.RemoveAll(installation.Job().Path)
+}
@@ -0,0 +1,9 @@
+package manifest
+
+// LocalCPITemplateName selects CPI built into the CLI when it is used
+// as cloud_provider.template.name without cloud_provider.template.release
+const LocalCPITemplateName = "local"
+
+func (m Manifest) UsesLocalCPI() bool {
+	return m.Template.Name == LocalCPITemplateName && m.Template.Release == ""
+}
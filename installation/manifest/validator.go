@@ -30,14 +30,17 @@ func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanif
 		errs = append(errs, bosherr.Error("cloud_provider.template.name must be provided"))
 	}
 
-	cpiReleaseName := manifest.Template.Release
-	if v.isBlank(cpiReleaseName) {
-		errs = append(errs, bosherr.Error("cloud_provider.template.release must be provided"))
-	}
-
-	_, found := releaseSetManifest.FindByName(cpiReleaseName)
-	if !found {
-		errs = append(errs, bosherr.Errorf("cloud_provider.template.release '%s' must refer to a release in releases", cpiReleaseName))
+	// Local CPI is built into the CLI hence it does not come from a release
+	if !manifest.UsesLocalCPI() {
+		cpiReleaseName := manifest.Template.Release
+		if v.isBlank(cpiReleaseName) {
+			errs = append(errs, bosherr.Error("cloud_provider.template.release must be provided"))
+		}
+
+		_, found := releaseSetManifest.FindByName(cpiReleaseName)
+		if !found {
+			errs = append(errs, bosherr.Errorf("cloud_provider.template.release '%s' must refer to a release in releases", cpiReleaseName))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -101,5 +101,16 @@ var _ = Describe("Validator", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cloud_provider.template.release 'not-provided-valid-release-name' must refer to a release in releases"))
 		})
+
+		It("does not require a release for the local CPI", func() {
+			manifest := Manifest{
+				Template: ReleaseJobRef{
+					Name: "local",
+				},
+			}
+
+			err := validator.Validate(manifest, releaseSetManifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
 	})
 })
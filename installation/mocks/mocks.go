@@ -156,6 +156,16 @@ func (_mr *_MockInstallerFactoryRecorder) NewInstaller(arg0 interface{}) *gomock
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewInstaller", arg0)
 }
 
+func (_m *MockInstallerFactory) NewLocalCPIInstaller(_param0 installation.Target) installation.Installer {
+	ret := _m.ctrl.Call(_m, "NewLocalCPIInstaller", _param0)
+	ret0, _ := ret[0].(installation.Installer)
+	return ret0
+}
+
+func (_mr *_MockInstallerFactoryRecorder) NewLocalCPIInstaller(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "NewLocalCPIInstaller", arg0)
+}
+
 // Mock of Uninstaller interface
 type MockUninstaller struct {
 	ctrl     *gomock.Controller
@@ -3,6 +3,7 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -24,6 +25,11 @@ type compiler struct {
 	blobExtractor       blobextract.Extractor
 	logger              boshlog.Logger
 	logTag              string
+
+	// packageDirs counts compilations in progress that use each directory
+	// in packages dir since packages may be compiled concurrently
+	packageDirs     map[string]int
+	packageDirsLock sync.Mutex
 }
 
 func NewPackageCompiler(
@@ -46,6 +52,7 @@ func NewPackageCompiler(
 		blobExtractor:       blobExtractor,
 		logger:              logger,
 		logTag:              "packageCompiler",
+		packageDirs:         map[string]int{},
 	}
 }
 
@@ -66,17 +73,14 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 
 	c.logger.Debug(c.logTag, "Installing dependencies of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
-	err = c.installPackages(pkg.Deps())
+	usedDirs, err := c.installPackages(pkg)
+
+	defer c.removePackages(usedDirs)
+
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Installing dependencies of package '%s'", pkg.Name())
 	}
 
-	defer func() {
-		if err = c.fileSystem.RemoveAll(c.packagesDir); err != nil {
-			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
-		}
-	}()
-
 	c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	installDir := filepath.Join(c.packagesDir, pkg.Name())
@@ -140,24 +144,74 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 	return record, isCompiledPackage, nil
 }
 
-func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
-	for _, pkg := range packages {
-		c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+// installPackages installs dependencies of the package into packages dir
+// unless they were already installed for another compilation in progress.
+// Returned directories, including the one package is compiled into,
+// have to be released with removePackages once compilation is done.
+func (c *compiler) installPackages(pkg birelpkg.Compilable) ([]string, error) {
+	c.packageDirsLock.Lock()
+	defer c.packageDirsLock.Unlock()
+
+	c.packageDirs[pkg.Name()]++
+	usedDirs := []string{pkg.Name()}
+
+	for _, dependency := range pkg.Deps() {
+		if c.packageDirs[dependency.Name()] > 0 {
+			c.packageDirs[dependency.Name()]++
+			usedDirs = append(usedDirs, dependency.Name())
+			continue
+		}
+
+		c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", dependency.Name(), dependency.Fingerprint())
 
-		record, found, err := c.compiledPackageRepo.Find(pkg)
+		record, found, err := c.compiledPackageRepo.Find(dependency)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
+			return usedDirs, bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", dependency.Name())
 		} else if !found {
-			return bosherr.Errorf("Finding compiled package '%s'", pkg.Name())
+			return usedDirs, bosherr.Errorf("Finding compiled package '%s'", dependency.Name())
 		}
 
-		c.logger.Debug(c.logTag, "Installing package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+		c.logger.Debug(c.logTag, "Installing package '%s/%s'", dependency.Name(), dependency.Fingerprint())
+
+		installDir := filepath.Join(c.packagesDir, dependency.Name())
 
-		err = c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, filepath.Join(c.packagesDir, pkg.Name()))
+		err = c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, installDir)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
+			if removeErr := c.fileSystem.RemoveAll(installDir); removeErr != nil {
+				c.logger.Warn(c.logTag, "Failed to remove package dir: %s", removeErr.Error())
+			}
+			return usedDirs, bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", dependency.Name(), c.packagesDir)
+		}
+
+		c.packageDirs[dependency.Name()]++
+		usedDirs = append(usedDirs, dependency.Name())
+	}
+
+	return usedDirs, nil
+}
+
+// removePackages removes directories that are no longer used by any compilation
+// and packages dir itself once there are no compilations in progress
+func (c *compiler) removePackages(usedDirs []string) {
+	c.packageDirsLock.Lock()
+	defer c.packageDirsLock.Unlock()
+
+	for _, name := range usedDirs {
+		c.packageDirs[name]--
+		if c.packageDirs[name] > 0 {
+			continue
+		}
+
+		delete(c.packageDirs, name)
+
+		if err := c.fileSystem.RemoveAll(filepath.Join(c.packagesDir, name)); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove package dir: %s", err.Error())
 		}
 	}
 
-	return nil
+	if len(c.packageDirs) == 0 {
+		if err := c.fileSystem.RemoveAll(c.packagesDir); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
+		}
+	}
 }
@@ -219,6 +219,49 @@ var _ = Describe("PackageCompiler", func() {
 			Expect(fs.FileExists(packagesDir)).To(BeFalse())
 		})
 
+		Context("when another package with the same dependency is compiled at the same time", func() {
+			var otherPkg *birelpkg.Package
+
+			JustBeforeEach(func() {
+				otherPkg = birelpkg.NewExtractedPackage(NewResource("pkg2-name", "", nil), []string{"pkg-dep1-name"}, "/other-pkg-dir", fs)
+				otherPkg.AttachDependencies([]*birelpkg.Package{dependency1})
+
+				fs.WriteFileString("/other-pkg-dir/packaging", "")
+
+				mockCompiledPackageRepo.EXPECT().Find(otherPkg).Return(bistatepkg.CompiledPackageRecord{}, false, nil)
+				mockCompiledPackageRepo.EXPECT().Save(otherPkg, gomock.Any())
+
+				digest := boshcrypto.MustParseMultipleDigest("fakefingerprint")
+				compilingOtherPkg := false
+
+				blobstore.CreateStub = func(string) (string, boshcrypto.MultipleDigest, error) {
+					if !compilingOtherPkg {
+						compilingOtherPkg = true
+
+						_, _, err := compiler.Compile(otherPkg)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fs.FileExists(filepath.Join(packagesDir, "pkg2-name"))).To(BeFalse())
+						Expect(fs.FileExists(filepath.Join(packagesDir, "pkg-dep1-name"))).To(BeTrue())
+					}
+
+					return "fake-blob-id", digest, nil
+				}
+			})
+
+			It("installs shared dependency once and keeps it until both compilations are done", func() {
+				// dependency dir is normally created by extractor
+				err := fs.MkdirAll(filepath.Join(packagesDir, "pkg-dep1-name"), 0755)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, err = compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeExtractor.ExtractCallCount()).To(Equal(2))
+				Expect(fs.FileExists(packagesDir)).To(BeFalse())
+			})
+		})
+
 		Context("when dependency installation fails", func() {
 			JustBeforeEach(func() {
 				fakeExtractor.ExtractReturns(errors.New("fake-install-error"))
@@ -2,35 +2,75 @@ package tarball
 
 import (
 	"crypto/sha1"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+const (
+	cacheIndexName = "index.json"
+
+	partialSuffix = ".partial"
+)
+
 type Cache interface {
 	Get(source Source) (path string, found bool)
 	Path(source Source) (path string)
+	PartialPath(source Source) (path string)
 	Save(sourcePath string, source Source) error
+
+	// List returns cached and partially downloaded tarballs, least recently used first
+	List() ([]CacheEntry, error)
+	Delete([]CacheEntry) error
+	Clear() error
+}
+
+type CacheEntry struct {
+	Path string `json:"-"`
+
+	// URL is empty for tarballs downloaded before cache kept track of sources
+	URL  string `json:"url"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"-"`
+
+	// Partial tarballs are left behind by interrupted downloads
+	Partial bool `json:"-"`
+
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type cacheIndex struct {
+	Tarballs map[string]CacheEntry `json:"tarballs"`
 }
 
+// digestReplacer keeps multi-digest strings (e.g. 'sha256:abc;sha1:def') usable in file names
+var digestReplacer = strings.NewReplacer(":", "-", ";", "_")
+
 type cache struct {
-	basePath string
-	fs       boshsys.FileSystem
-	logger   boshlog.Logger
-	logTag   string
+	basePath    string
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+	logger      boshlog.Logger
+	logTag      string
 }
 
-func NewCache(basePath string, fs boshsys.FileSystem, logger boshlog.Logger) Cache {
+func NewCache(basePath string, fs boshsys.FileSystem, timeService clock.Clock, logger boshlog.Logger) Cache {
 	return &cache{
-		basePath: basePath,
-		fs:       fs,
-		logger:   logger,
-		logTag:   "tarballCache",
+		basePath:    basePath,
+		fs:          fs,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "tarballCache",
 	}
 }
 
@@ -38,6 +78,12 @@ func (c *cache) Get(source Source) (string, bool) {
 	cachedPath := c.Path(source)
 	if c.fs.FileExists(cachedPath) {
 		c.logger.Debug(c.logTag, "Found cached tarball at: '%s'", cachedPath)
+
+		err := c.markUsed(source)
+		if err != nil {
+			c.logger.Warn(c.logTag, "Failed to record usage of cached tarball: %s", err.Error())
+		}
+
 		return cachedPath, true
 	}
 
@@ -56,11 +102,169 @@ func (c *cache) Save(sourcePath string, source Source) error {
 	}
 
 	c.logger.Debug(c.logTag, "Saving tarball in cache at: '%s'", c.Path(source))
+
+	err = c.markUsed(source)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to record tarball '%s' in cache", sourcePath)
+	}
+
 	return nil
 }
 
 func (c *cache) Path(source Source) string {
 	urlSHA1 := sha1.Sum([]byte(source.GetURL()))
-	filename := fmt.Sprintf("%x-%s", string(urlSHA1[:]), source.GetSHA1())
+	filename := fmt.Sprintf("%x-%s", string(urlSHA1[:]), digestReplacer.Replace(source.GetSHA1()))
 	return filepath.Join(c.basePath, filename)
 }
+
+// PartialPath returns path of the file that holds bits downloaded so far
+// so that interrupted downloads can be resumed
+func (c *cache) PartialPath(source Source) string {
+	return c.Path(source) + partialSuffix
+}
+
+func (c *cache) List() ([]CacheEntry, error) {
+	if !c.fs.FileExists(c.basePath) {
+		return nil, nil
+	}
+
+	index, err := c.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := c.fs.Glob(filepath.Join(c.basePath, "*"))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing cache directory '%s'", c.basePath)
+	}
+
+	var entries []CacheEntry
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == cacheIndexName {
+			continue
+		}
+
+		stat, err := c.fs.Stat(path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Checking cached tarball '%s'", path)
+		}
+
+		entry, found := index.Tarballs[name]
+		if !found {
+			entry = CacheEntry{
+				SHA1:       c.digestFromName(strings.TrimSuffix(name, partialSuffix)),
+				LastUsedAt: stat.ModTime(),
+			}
+		}
+
+		entry.Path = path
+		entry.Size = stat.Size()
+		entry.Partial = strings.HasSuffix(name, partialSuffix)
+
+		entries = append(entries, entry)
+	}
+
+	sort.Stable(cacheEntryByLastUsed(entries))
+
+	return entries, nil
+}
+
+func (c *cache) Delete(entries []CacheEntry) error {
+	for _, entry := range entries {
+		err := c.fs.RemoveAll(entry.Path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing cached tarball '%s'", entry.Path)
+		}
+	}
+
+	return c.updateIndex(func(index *cacheIndex) {
+		for _, entry := range entries {
+			delete(index.Tarballs, filepath.Base(entry.Path))
+		}
+	})
+}
+
+func (c *cache) Clear() error {
+	err := c.fs.RemoveAll(c.basePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing cache directory '%s'", c.basePath)
+	}
+
+	return nil
+}
+
+func (c *cache) markUsed(source Source) error {
+	return c.updateIndex(func(index *cacheIndex) {
+		index.Tarballs[filepath.Base(c.Path(source))] = CacheEntry{
+			URL:        source.GetURL(),
+			SHA1:       source.GetSHA1(),
+			LastUsedAt: c.timeService.Now(),
+		}
+	})
+}
+
+// digestFromName recovers digest of tarballs that are not in the index
+// from their '<url sha1>-<digest>' file names
+func (c *cache) digestFromName(name string) string {
+	pieces := strings.SplitN(name, "-", 2)
+	if len(pieces) != 2 {
+		return ""
+	}
+
+	return pieces[1]
+}
+
+func (c *cache) loadIndex() (cacheIndex, error) {
+	index := cacheIndex{Tarballs: map[string]CacheEntry{}}
+
+	indexPath := filepath.Join(c.basePath, cacheIndexName)
+
+	if !c.fs.FileExists(indexPath) {
+		return index, nil
+	}
+
+	contents, err := c.fs.ReadFile(indexPath)
+	if err != nil {
+		return index, bosherr.WrapError(err, "Reading tarball cache index")
+	}
+
+	err = json.Unmarshal(contents, &index)
+	if err != nil {
+		return index, bosherr.WrapError(err, "Unmarshalling tarball cache index")
+	}
+
+	if index.Tarballs == nil {
+		index.Tarballs = map[string]CacheEntry{}
+	}
+
+	return index, nil
+}
+
+func (c *cache) updateIndex(update func(*cacheIndex)) error {
+	index, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	update(&index)
+
+	contents, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling tarball cache index")
+	}
+
+	err = c.fs.WriteFile(filepath.Join(c.basePath, cacheIndexName), contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving tarball cache index")
+	}
+
+	return nil
+}
+
+type cacheEntryByLastUsed []CacheEntry
+
+func (s cacheEntryByLastUsed) Len() int           { return len(s) }
+func (s cacheEntryByLastUsed) Less(i, j int) bool { return s[i].LastUsedAt.Before(s[j].LastUsedAt) }
+func (s cacheEntryByLastUsed) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -14,16 +16,19 @@ import (
 
 var _ = Describe("Cache", func() {
 	var (
-		cache Cache
-		fs    *fakesys.FakeFileSystem
+		cache           Cache
+		fs              *fakesys.FakeFileSystem
+		fakeTimeService *fakeclock.FakeClock
 	)
 
 	BeforeEach(func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fs = fakesys.NewFakeFileSystem()
+		fakeTimeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
 		cache = NewCache(
 			"/fake-base-path",
 			fs,
+			fakeTimeService,
 			logger,
 		)
 	})
@@ -101,6 +106,22 @@ var _ = Describe("Cache", func() {
 		Expect(fs.FileExists(filepath.Join("/", "fake-base-path", "587cd74a86333e7f1ebca70474a1f4456e4b5d3e-fake-sha1"))).To(BeTrue())
 	})
 
+	It("replaces digest separators in file names when multiple digests are given", func() {
+		Expect(cache.Path(&fakeSource{
+			sha1:        "sha256:fake-sha256;sha1:fake-sha1",
+			url:         "http://foo.bar.com",
+			description: "some tarball",
+		})).To(Equal(filepath.Join("/", "fake-base-path", "587cd74a86333e7f1ebca70474a1f4456e4b5d3e-sha256-fake-sha256_sha1-fake-sha1")))
+	})
+
+	It("keeps partially downloaded files next to cached files", func() {
+		Expect(cache.PartialPath(&fakeSource{
+			sha1:        "fake-sha1",
+			url:         "http://foo.bar.com",
+			description: "some tarball",
+		})).To(Equal(filepath.Join("/", "fake-base-path", "587cd74a86333e7f1ebca70474a1f4456e4b5d3e-fake-sha1.partial")))
+	})
+
 	It("saves files across devices when necessary", func() {
 		fs.RenameError = &os.LinkError{
 			Err: syscall.Errno(0x12),
@@ -114,4 +135,102 @@ var _ = Describe("Cache", func() {
 		})
 		Expect(err).ToNot(HaveOccurred())
 	})
+	Describe("List", func() {
+		var (
+			source1, source2 *fakeSource
+		)
+
+		BeforeEach(func() {
+			source1 = &fakeSource{url: "http://foo.bar.com", sha1: "fake-sha1", description: "some tarball"}
+			source2 = &fakeSource{url: "http://baz.bar.com", sha1: "fake-sha2", description: "other tarball"}
+		})
+
+		It("returns tarballs with their sources least recently used first", func() {
+			fs.WriteFileString("source-path-1", "tarball-1")
+			Expect(cache.Save("source-path-1", source1)).To(Succeed())
+
+			fakeTimeService.Increment(time.Hour)
+			fs.WriteFileString("source-path-2", "tarball-22")
+			Expect(cache.Save("source-path-2", source2)).To(Succeed())
+
+			fakeTimeService.Increment(time.Hour)
+			_, found := cache.Get(source1)
+			Expect(found).To(BeTrue())
+
+			fs.SetGlob(filepath.Join("/fake-base-path", "*"), []string{
+				cache.Path(source1),
+				cache.Path(source2),
+				filepath.Join("/fake-base-path", "index.json"),
+			})
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]CacheEntry{
+				{
+					Path:       cache.Path(source2),
+					URL:        "http://baz.bar.com",
+					SHA1:       "fake-sha2",
+					Size:       10,
+					LastUsedAt: time.Date(2017, time.March, 1, 11, 0, 0, 0, time.UTC),
+				},
+				{
+					Path:       cache.Path(source1),
+					URL:        "http://foo.bar.com",
+					SHA1:       "fake-sha1",
+					Size:       9,
+					LastUsedAt: time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC),
+				},
+			}))
+		})
+
+		It("returns partially downloaded and untracked tarballs based on their names and modification times", func() {
+			partialPath := cache.PartialPath(source1)
+			fs.WriteFileString(partialPath, "tarb")
+			fs.GetFileTestStat(partialPath).ModTime = time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			fs.SetGlob(filepath.Join("/fake-base-path", "*"), []string{partialPath})
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(Equal([]CacheEntry{
+				{
+					Path:       partialPath,
+					SHA1:       "fake-sha1",
+					Size:       4,
+					Partial:    true,
+					LastUsedAt: time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+				},
+			}))
+		})
+
+		It("returns no tarballs when cache directory does not exist", func() {
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes tarballs and forgets about their sources", func() {
+			source := &fakeSource{url: "http://foo.bar.com", sha1: "fake-sha1", description: "some tarball"}
+
+			fs.WriteFileString("source-path", "tarball")
+			Expect(cache.Save("source-path", source)).To(Succeed())
+
+			err := cache.Delete([]CacheEntry{{Path: cache.Path(source)}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(cache.Path(source))).To(BeFalse())
+			Expect(fs.ReadFileString(filepath.Join("/fake-base-path", "index.json"))).ToNot(ContainSubstring("http://foo.bar.com"))
+		})
+	})
+
+	Describe("Clear", func() {
+		It("removes cache directory", func() {
+			fs.WriteFileString(filepath.Join("/fake-base-path", "tarball"), "")
+
+			Expect(cache.Clear()).To(Succeed())
+			Expect(fs.FileExists("/fake-base-path")).To(BeFalse())
+		})
+	})
 })
@@ -1,5 +1,5 @@
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/cloudfoundry/bosh-cli/installation/tarball (interfaces: Provider)
+// Source: github.com/cloudfoundry/bosh-cli/installation/tarball (interfaces: Provider,Cache)
 
 package mocks
 
@@ -40,3 +40,96 @@ func (_m *MockProvider) Get(_param0 tarball.Source, _param1 ui.Stage) (string, e
 func (_mr *_MockProviderRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0, arg1)
 }
+
+// Mock of Cache interface
+type MockCache struct {
+	ctrl     *gomock.Controller
+	recorder *_MockCacheRecorder
+}
+
+// Recorder for MockCache (not exported)
+type _MockCacheRecorder struct {
+	mock *MockCache
+}
+
+func NewMockCache(ctrl *gomock.Controller) *MockCache {
+	mock := &MockCache{ctrl: ctrl}
+	mock.recorder = &_MockCacheRecorder{mock}
+	return mock
+}
+
+func (_m *MockCache) EXPECT() *_MockCacheRecorder {
+	return _m.recorder
+}
+
+func (_m *MockCache) Clear() error {
+	ret := _m.ctrl.Call(_m, "Clear")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCacheRecorder) Clear() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Clear")
+}
+
+func (_m *MockCache) Delete(_param0 []tarball.CacheEntry) error {
+	ret := _m.ctrl.Call(_m, "Delete", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCacheRecorder) Delete(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0)
+}
+
+func (_m *MockCache) Get(_param0 tarball.Source) (string, bool) {
+	ret := _m.ctrl.Call(_m, "Get", _param0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+func (_mr *_MockCacheRecorder) Get(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0)
+}
+
+func (_m *MockCache) List() ([]tarball.CacheEntry, error) {
+	ret := _m.ctrl.Call(_m, "List")
+	ret0, _ := ret[0].([]tarball.CacheEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockCacheRecorder) List() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "List")
+}
+
+func (_m *MockCache) PartialPath(_param0 tarball.Source) string {
+	ret := _m.ctrl.Call(_m, "PartialPath", _param0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockCacheRecorder) PartialPath(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PartialPath", arg0)
+}
+
+func (_m *MockCache) Path(_param0 tarball.Source) string {
+	ret := _m.ctrl.Call(_m, "Path", _param0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockCacheRecorder) Path(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Path", arg0)
+}
+
+func (_m *MockCache) Save(_param0 string, _param1 tarball.Source) error {
+	ret := _m.ctrl.Call(_m, "Save", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCacheRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Save", arg0, arg1)
+}
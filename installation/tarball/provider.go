@@ -5,6 +5,9 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,10 +22,16 @@ import (
 
 type Source interface {
 	GetURL() string
+	GetMirrorURLs() []string
 	GetSHA1() string
 	Description() string
 }
 
+// DownloadReporter tracks progress of tarballs being written to disk
+type DownloadReporter interface {
+	TrackResumedDownload(offset, size int64, writer io.Writer) io.Writer
+}
+
 type Provider interface {
 	Get(Source, biui.Stage) (path string, err error)
 }
@@ -42,6 +51,7 @@ type provider struct {
 	cache            Cache
 	fs               boshsys.FileSystem
 	httpClient       bihttpclient.HTTPClient
+	reporter         DownloadReporter
 	downloadAttempts int
 	delayTimeout     time.Duration
 	logger           boshlog.Logger
@@ -52,6 +62,7 @@ func NewProvider(
 	cache Cache,
 	fs boshsys.FileSystem,
 	httpClient bihttpclient.HTTPClient,
+	reporter DownloadReporter,
 	downloadAttempts int,
 	delayTimeout time.Duration,
 	logger boshlog.Logger,
@@ -60,6 +71,7 @@ func NewProvider(
 		cache:            cache,
 		fs:               fs,
 		httpClient:       httpClient,
+		reporter:         reporter,
 		downloadAttempts: downloadAttempts,
 		delayTimeout:     delayTimeout,
 
@@ -97,10 +109,15 @@ func (p *provider) Get(source Source, stage biui.Stage) (string, error) {
 			return biui.NewSkipStageError(bosherr.Error("Already downloaded"), "Found in local cache")
 		}
 
+		digest, err := boshcrypto.ParseMultipleDigest(source.GetSHA1())
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing digest '%s'", source.GetSHA1())
+		}
+
 		retryStrategy := boshretry.NewAttemptRetryStrategy(
-			p.downloadAttempts, p.delayTimeout, p.downloadRetryable(source), p.logger)
+			p.downloadAttempts, p.delayTimeout, p.downloadRetryable(source, digest), p.logger)
 
-		err := retryStrategy.Try()
+		err = retryStrategy.Try()
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Failed to download from '%s'", source.GetURL())
 		}
@@ -116,50 +133,169 @@ func (p *provider) Get(source Source, stage biui.Stage) (string, error) {
 	return p.cache.Path(source), nil
 }
 
-func (p *provider) downloadRetryable(source Source) boshretry.Retryable {
+// downloadRetryable tries source URL followed by its mirrors in order.
+// Each download continues from the partially downloaded file
+// left behind by previously interrupted attempts.
+func (p *provider) downloadRetryable(source Source, digest boshcrypto.MultipleDigest) boshretry.Retryable {
 	return boshretry.NewRetryable(func() (bool, error) {
-		downloadedFile, err := p.fs.TempFile("tarballProvider")
-		if err != nil {
-			return true, bosherr.WrapError(err, "Unable to create temporary file")
-		}
+		urls := append([]string{source.GetURL()}, source.GetMirrorURLs()...)
 
-		defer func() {
-			if err = p.fs.RemoveAll(downloadedFile.Name()); err != nil {
-				p.logger.Warn(p.logTag, "Failed to remove downloaded file: %s", err.Error())
+		var errs []error
+
+		for _, url := range urls {
+			err := p.download(url, source, digest)
+			if err == nil {
+				return false, nil
 			}
-		}()
 
-		response, err := p.httpClient.Get(source.GetURL())
-		if err != nil {
-			return true, bosherr.WrapError(err, "Unable to download")
+			if len(urls) == 1 {
+				return true, err
+			}
+
+			p.logger.Warn(p.logTag, "Failed to download from '%s': %s", url, err.Error())
+			errs = append(errs, bosherr.WrapErrorf(err, "Downloading from '%s'", url))
 		}
 
-		defer func() {
-			if err = response.Body.Close(); err != nil {
-				p.logger.Warn(p.logTag, "Failed to close download response body: %s", err.Error())
-			}
-		}()
+		return true, bosherr.NewMultiError(errs...)
+	})
+}
+
+func (p *provider) download(url string, source Source, digest boshcrypto.MultipleDigest) error {
+	partialPath := p.cache.PartialPath(source)
+
+	err := p.fs.MkdirAll(filepath.Dir(partialPath), os.FileMode(0766))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory for '%s'", partialPath)
+	}
 
-		_, err = io.Copy(downloadedFile, response.Body)
+	var offset int64
+
+	if p.fs.FileExists(partialPath) {
+		stat, err := p.fs.Stat(partialPath)
 		if err != nil {
-			return true, bosherr.WrapError(err, "Saving downloaded bits to temporary file")
+			return bosherr.WrapError(err, "Checking partially downloaded file")
 		}
 
-		digest, err := boshcrypto.ParseMultipleDigest(source.GetSHA1())
-		if err != nil {
-			return true, err
+		offset = stat.Size()
+	}
+
+	response, err := p.httpClient.GetCustomized(url, func(request *http.Request) {
+		if offset > 0 {
+			request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 		}
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Unable to download")
+	}
 
-		err = digest.VerifyFilePath(downloadedFile.Name(), p.fs)
-		if err != nil {
-			return true, bosherr.WrapError(err, "Verifying digest for downloaded file")
+	defer func() {
+		if err = response.Body.Close(); err != nil {
+			p.logger.Warn(p.logTag, "Failed to close download response body: %s", err.Error())
 		}
+	}()
 
-		err = p.cache.Save(downloadedFile.Name(), source)
-		if err != nil {
-			return true, bosherr.WrapError(err, "Saving downloaded file in cache")
+	switch response.StatusCode {
+	case http.StatusOK:
+		err = p.saveResponse(response, partialPath, os.O_TRUNC, 0, response.ContentLength)
+
+	case http.StatusPartialContent:
+		start, size, found := parseContentRange(response.Header.Get("Content-Range"))
+		if size < 0 && response.ContentLength >= 0 {
+			size = start + response.ContentLength
 		}
 
-		return false, nil
-	})
+		switch {
+		case found && start == offset:
+			p.logger.Debug(p.logTag, "Resuming download from '%s' at byte %d", url, offset)
+			err = p.saveResponse(response, partialPath, os.O_APPEND, offset, size)
+
+		case found && start == 0:
+			p.logger.Debug(p.logTag, "Restarting download from '%s' since server sent whole tarball", url)
+			err = p.saveResponse(response, partialPath, os.O_TRUNC, 0, size)
+
+		case offset > 0:
+			// Appending bits from another position would corrupt partially downloaded file
+			p.logger.Debug(p.logTag, "Restarting download from '%s' since server sent range '%s' instead of byte %d",
+				url, response.Header.Get("Content-Range"), offset)
+
+			err = p.fs.WriteFile(partialPath, []byte{})
+			if err != nil {
+				return bosherr.WrapError(err, "Truncating partially downloaded file")
+			}
+
+			return p.download(url, source, digest)
+
+		default:
+			err = bosherr.Errorf("Unable to download: unexpected content range '%s'", response.Header.Get("Content-Range"))
+		}
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Partially downloaded file might already hold the whole tarball
+		p.logger.Debug(p.logTag, "Nothing left to download from '%s' after byte %d", url, offset)
+
+	default:
+		err = bosherr.Errorf("Unable to download: unexpected response status code %d", response.StatusCode)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = digest.VerifyFilePath(partialPath, p.fs)
+	if err != nil {
+		if removeErr := p.fs.RemoveAll(partialPath); removeErr != nil {
+			p.logger.Warn(p.logTag, "Failed to remove downloaded file: %s", removeErr.Error())
+		}
+
+		return bosherr.WrapError(err, "Verifying digest for downloaded file")
+	}
+
+	err = p.cache.Save(partialPath, source)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving downloaded file in cache")
+	}
+
+	return nil
+}
+
+func (p *provider) saveResponse(response *http.Response, path string, flag int, offset, size int64) error {
+	file, err := p.fs.OpenFile(path, os.O_CREATE|os.O_WRONLY|flag, os.FileMode(0644))
+	if err != nil {
+		return bosherr.WrapError(err, "Opening partially downloaded file")
+	}
+
+	defer func() {
+		if err = file.Close(); err != nil {
+			p.logger.Warn(p.logTag, "Failed to close downloaded file: %s", err.Error())
+		}
+	}()
+
+	_, err = io.Copy(p.reporter.TrackResumedDownload(offset, size, file), response.Body)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving downloaded bits to partially downloaded file")
+	}
+
+	return nil
+}
+
+// parseContentRange parses 'bytes START-END/SIZE' value of Content-Range header.
+// Size is -1 when server does not know complete length.
+func parseContentRange(value string) (start, size int64, found bool) {
+	var end int64
+	var sizeStr string
+
+	_, err := fmt.Sscanf(value, "bytes %d-%d/%s", &start, &end, &sizeStr)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if sizeStr == "*" {
+		return start, -1, true
+	}
+
+	size, err = strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return start, size, true
 }
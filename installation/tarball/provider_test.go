@@ -2,17 +2,23 @@ package tarball_test
 
 import (
 	"errors"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 
+	"code.cloudfoundry.org/clock"
 	. "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 	fakebihttpclient "github.com/cloudfoundry/bosh-utils/httpclient/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Provider", func() {
@@ -21,16 +27,19 @@ var _ = Describe("Provider", func() {
 		cache      Cache
 		fs         *fakesys.FakeFileSystem
 		httpClient *fakebihttpclient.FakeHTTPClient
+		reporter   *fakeDownloadReporter
 		source     *fakeSource
 		fakeStage  *fakebiui.FakeStage
+		logger     boshlog.Logger
 	)
 
 	BeforeEach(func() {
 		fs = fakesys.NewFakeFileSystem()
-		logger := boshlog.NewLogger(boshlog.LevelNone)
-		cache = NewCache(filepath.Join("/", "fake-base-path"), fs, logger)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		cache = NewCache(filepath.Join("/", "fake-base-path"), fs, clock.NewClock(), logger)
 		httpClient = fakebihttpclient.NewFakeHTTPClient()
-		provider = NewProvider(cache, fs, httpClient, 3, 0, logger)
+		reporter = &fakeDownloadReporter{}
+		provider = NewProvider(cache, fs, httpClient, reporter, 3, 0, logger)
 		fakeStage = fakebiui.NewFakeStage()
 	})
 
@@ -51,7 +60,7 @@ var _ = Describe("Provider", func() {
 
 		Context("when URL starts with http(s)://", func() {
 			BeforeEach(func() {
-				source = newFakeSource("http://fake-url", "da39a3ee5e6b4b0d3255bfef95601890afd80709", "fake-description")
+				source = newFakeSource("http://fake-url", "fab3c263ec568e150550b814e84b7898d477c3c2", "fake-description")
 			})
 
 			Context("when tarball is present in cache", func() {
@@ -63,7 +72,7 @@ var _ = Describe("Provider", func() {
 				It("returns cached tarball path", func() {
 					path, err := provider.Get(source, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
-					Expect(path).To(Equal(filepath.Join("/", "fake-base-path", "9db1fb7c47637e8709e944a232e1aa98ce6fec26-da39a3ee5e6b4b0d3255bfef95601890afd80709")))
+					Expect(path).To(Equal(filepath.Join("/", "fake-base-path", "9db1fb7c47637e8709e944a232e1aa98ce6fec26-fab3c263ec568e150550b814e84b7898d477c3c2")))
 				})
 
 				It("skips downloading stage", func() {
@@ -77,18 +86,11 @@ var _ = Describe("Provider", func() {
 
 			Context("when tarball is not present in cache", func() {
 				var (
-					tempDownloadFilePath string
+					partialPath string
 				)
 
 				BeforeEach(func() {
-					tempDownloadFile, err := ioutil.TempFile("", "temp-download-file")
-					Expect(err).ToNot(HaveOccurred())
-					fs.ReturnTempFile = tempDownloadFile
-					tempDownloadFilePath = tempDownloadFile.Name()
-				})
-
-				AfterEach(func() {
-					os.RemoveAll(tempDownloadFilePath)
+					partialPath = cache.PartialPath(source)
 				})
 
 				Context("when downloading succeds", func() {
@@ -101,10 +103,20 @@ var _ = Describe("Provider", func() {
 					It("downloads tarball from given URL and returns saved cache tarball path", func() {
 						path, err := provider.Get(source, fakeStage)
 						Expect(err).ToNot(HaveOccurred())
-						Expect(path).To(Equal(filepath.Join("/", "fake-base-path", "9db1fb7c47637e8709e944a232e1aa98ce6fec26-da39a3ee5e6b4b0d3255bfef95601890afd80709")))
+						Expect(path).To(Equal(filepath.Join("/", "fake-base-path", "9db1fb7c47637e8709e944a232e1aa98ce6fec26-fab3c263ec568e150550b814e84b7898d477c3c2")))
 
 						Expect(httpClient.GetInputs).To(HaveLen(1))
 						Expect(httpClient.GetInputs[0].Endpoint).To(Equal("http://fake-url"))
+
+						Expect(fs.ReadFileString(path)).To(Equal("fake-body"))
+						Expect(fs.FileExists(partialPath)).To(BeFalse())
+					})
+
+					It("reports download progress", func() {
+						_, err := provider.Get(source, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(reporter.Sizes).To(HaveLen(1))
 					})
 
 					It("logs downloading stage", func() {
@@ -124,7 +136,7 @@ var _ = Describe("Provider", func() {
 						It("returns an error", func() {
 							_, err := provider.Get(source, fakeStage)
 							Expect(err).To(HaveOccurred())
-							Expect(err.Error()).To(ContainSubstring("Failed to download from 'http://fake-url': Verifying digest for downloaded file: Expected stream to have digest 'expectedsha1' but was 'da39a3ee5e6b4b0d3255bfef95601890afd80709'"))
+							Expect(err.Error()).To(ContainSubstring("Failed to download from 'http://fake-url': Verifying digest for downloaded file: Expected stream to have digest 'expectedsha1' but was"))
 						})
 
 						It("retries downloading up to 3 times", func() {
@@ -137,7 +149,24 @@ var _ = Describe("Provider", func() {
 						It("removes the downloaded file", func() {
 							_, err := provider.Get(source, fakeStage)
 							Expect(err).To(HaveOccurred())
-							Expect(fs.FileExists(tempDownloadFilePath)).To(BeFalse())
+							Expect(fs.FileExists(cache.PartialPath(source))).To(BeFalse())
+						})
+					})
+
+					Context("when sha256 digest is given", func() {
+						BeforeEach(func() {
+							source = newFakeSource(
+								"http://fake-url",
+								"sha256:1937d6472a97b1fca28f0ee963ea85bb56d6f4089dcfcd73ac7e5d025cefd881;sha1:expectedsha1",
+								"fake-description",
+							)
+						})
+
+						It("verifies downloaded file with the strongest digest", func() {
+							path, err := provider.Get(source, fakeStage)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(path).To(Equal(filepath.Join("/", "fake-base-path", "9db1fb7c47637e8709e944a232e1aa98ce6fec26-sha256-1937d6472a97b1fca28f0ee963ea85bb56d6f4089dcfcd73ac7e5d025cefd881_sha1-expectedsha1")))
+							Expect(fs.ReadFileString(path)).To(Equal("fake-body"))
 						})
 					})
 
@@ -153,14 +182,80 @@ var _ = Describe("Provider", func() {
 							Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 						})
 
-						It("removes the downloaded file", func() {
+						It("does not leave downloaded file behind", func() {
 							_, err := provider.Get(source, fakeStage)
 							Expect(err).To(HaveOccurred())
-							Expect(fs.FileExists(tempDownloadFilePath)).To(BeFalse())
+							Expect(fs.FileExists(partialPath)).To(BeFalse())
 						})
 					})
 				})
 
+				Context("when digest cannot be parsed", func() {
+					BeforeEach(func() {
+						source = newFakeSource("http://fake-url", "fake-sha1", "fake-description")
+					})
+
+					It("returns an error without downloading", func() {
+						_, err := provider.Get(source, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Parsing digest 'fake-sha1'"))
+
+						Expect(httpClient.GetInputs).To(BeEmpty())
+					})
+				})
+
+				Context("when server responds with unexpected status code", func() {
+					BeforeEach(func() {
+						httpClient.SetGetBehavior("not-found", 404, nil)
+						httpClient.SetGetBehavior("not-found", 404, nil)
+						httpClient.SetGetBehavior("not-found", 404, nil)
+					})
+
+					It("returns an error without saving response", func() {
+						_, err := provider.Get(source, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("unexpected response status code 404"))
+
+						Expect(httpClient.GetInputs).To(HaveLen(3))
+						Expect(fs.FileExists(partialPath)).To(BeFalse())
+					})
+				})
+
+				Context("when source has mirrors", func() {
+					BeforeEach(func() {
+						source.mirrors = []string{"http://fake-mirror-url-1", "http://fake-mirror-url-2"}
+					})
+
+					It("tries mirrors in order until download succeeds", func() {
+						httpClient.SetGetBehavior("", 500, errors.New("fake-download-error"))
+						httpClient.SetGetBehavior("", 500, nil)
+						httpClient.SetGetBehavior("fake-body", 200, nil)
+
+						path, err := provider.Get(source, fakeStage)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(path).To(Equal(cache.Path(source)))
+
+						Expect(httpClient.GetInputs).To(HaveLen(3))
+						Expect(httpClient.GetInputs[0].Endpoint).To(Equal("http://fake-url"))
+						Expect(httpClient.GetInputs[1].Endpoint).To(Equal("http://fake-mirror-url-1"))
+						Expect(httpClient.GetInputs[2].Endpoint).To(Equal("http://fake-mirror-url-2"))
+					})
+
+					It("returns errors from all urls when all of them fail", func() {
+						for i := 0; i < 9; i++ {
+							httpClient.SetGetBehavior("", 500, errors.New("fake-download-error"))
+						}
+
+						_, err := provider.Get(source, fakeStage)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Downloading from 'http://fake-url'"))
+						Expect(err.Error()).To(ContainSubstring("Downloading from 'http://fake-mirror-url-1'"))
+						Expect(err.Error()).To(ContainSubstring("Downloading from 'http://fake-mirror-url-2'"))
+
+						Expect(httpClient.GetInputs).To(HaveLen(9))
+					})
+				})
+
 				Context("when downloading fails", func() {
 					BeforeEach(func() {
 						httpClient.SetGetBehavior("", 500, errors.New("fake-download-error-1"))
@@ -176,13 +271,199 @@ var _ = Describe("Provider", func() {
 						Expect(httpClient.GetInputs).To(HaveLen(3))
 					})
 
-					It("removes the downloaded file", func() {
+					It("does not leave downloaded file behind", func() {
 						_, err := provider.Get(source, fakeStage)
 						Expect(err).To(HaveOccurred())
-						Expect(fs.FileExists(tempDownloadFilePath)).To(BeFalse())
+						Expect(fs.FileExists(partialPath)).To(BeFalse())
 					})
 				})
 			})
+
+			Context("when download is interrupted", func() {
+				var (
+					server    *ghttp.Server
+					realFS    boshsys.FileSystem
+					cachePath string
+				)
+
+				BeforeEach(func() {
+					server = ghttp.NewServer()
+
+					var err error
+					cachePath, err = ioutil.TempDir("", "tarball-cache")
+					Expect(err).ToNot(HaveOccurred())
+
+					realFS = boshsys.NewOsFileSystem(logger)
+					cache = NewCache(cachePath, realFS, clock.NewClock(), logger)
+					httpClient := bihttpclient.NewHTTPClient(HTTPClient, logger)
+					provider = NewProvider(cache, realFS, httpClient, reporter, 3, 0, logger)
+
+					source = newFakeSource(server.URL()+"/tarball", "fab3c263ec568e150550b814e84b7898d477c3c2", "fake-description")
+				})
+
+				AfterEach(func() {
+					server.Close()
+					os.RemoveAll(cachePath)
+				})
+
+				interruptedResponse := func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set("Content-Length", "9")
+					w.WriteHeader(http.StatusOK)
+					io.WriteString(w, "fake-")
+				}
+
+				contentRange := func(value string) http.Header {
+					return http.Header{"Content-Range": []string{value}}
+				}
+
+				It("resumes download from where it stopped", func() {
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/tarball"),
+							ghttp.VerifyHeaderKV("Range", "bytes=5-"),
+							ghttp.RespondWith(http.StatusPartialContent, "body", contentRange("bytes 5-8/9")),
+						),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(server.ReceivedRequests()).To(HaveLen(2))
+
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+					Expect(realFS.FileExists(cache.PartialPath(source))).To(BeFalse())
+
+					Expect(reporter.Offsets).To(Equal([]int64{0, 5}))
+					Expect(reporter.Sizes).To(Equal([]int64{9, 9}))
+				})
+
+				It("reports total size based on content length when server does not know complete length", func() {
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.RespondWith(http.StatusPartialContent, "body", contentRange("bytes 5-8/*")),
+					)
+
+					_, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(reporter.Offsets).To(Equal([]int64{0, 5}))
+					Expect(reporter.Sizes).To(Equal([]int64{9, 9}))
+				})
+
+				It("starts over when server sends range which does not start at downloaded size", func() {
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.CombineHandlers(
+							ghttp.VerifyHeaderKV("Range", "bytes=5-"),
+							ghttp.RespondWith(http.StatusPartialContent, "-body", contentRange("bytes 4-8/9")),
+						),
+						ghttp.CombineHandlers(
+							func(_ http.ResponseWriter, r *http.Request) {
+								Expect(r.Header.Get("Range")).To(BeEmpty())
+							},
+							ghttp.RespondWith(http.StatusOK, "fake-body"),
+						),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(server.ReceivedRequests()).To(HaveLen(3))
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+				})
+
+				It("starts over when server sends range without Content-Range header", func() {
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.RespondWith(http.StatusPartialContent, "body"),
+						ghttp.RespondWith(http.StatusOK, "fake-body"),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+				})
+
+				It("overwrites partially downloaded file when server sends range from the beginning", func() {
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.RespondWith(http.StatusPartialContent, "fake-body", contentRange("bytes 0-8/9")),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(server.ReceivedRequests()).To(HaveLen(2))
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+
+					Expect(reporter.Offsets).To(Equal([]int64{0, 0}))
+				})
+
+				It("resumes download from mirror", func() {
+					source.mirrors = []string{server.URL() + "/mirror"}
+
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/mirror"),
+							ghttp.VerifyHeaderKV("Range", "bytes=5-"),
+							ghttp.RespondWith(http.StatusPartialContent, "body", contentRange("bytes 5-8/9")),
+						),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+				})
+
+				It("starts over when server does not support ranges", func() {
+					server.AppendHandlers(
+						interruptedResponse,
+						ghttp.CombineHandlers(
+							ghttp.VerifyHeaderKV("Range", "bytes=5-"),
+							ghttp.RespondWith(http.StatusOK, "fake-body"),
+						),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+				})
+
+				It("verifies already downloaded bits when nothing is left to download", func() {
+					Expect(realFS.WriteFileString(cache.PartialPath(source), "fake-body")).To(Succeed())
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyHeaderKV("Range", "bytes=9-"),
+							ghttp.RespondWith(http.StatusRequestedRangeNotSatisfiable, ""),
+						),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+				})
+
+				It("starts over when resumed download does not match digest", func() {
+					Expect(realFS.WriteFileString(cache.PartialPath(source), "corrupt-")).To(Succeed())
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyHeaderKV("Range", "bytes=8-"),
+							ghttp.RespondWith(http.StatusPartialContent, "body", contentRange("bytes 8-11/12")),
+						),
+						ghttp.CombineHandlers(
+							func(_ http.ResponseWriter, r *http.Request) {
+								Expect(r.Header.Get("Range")).To(BeEmpty())
+							},
+							ghttp.RespondWith(http.StatusOK, "fake-body"),
+						),
+					)
+
+					path, err := provider.Get(source, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(realFS.ReadFileString(path)).To(Equal("fake-body"))
+				})
+			})
 		})
 
 		Context("when URL does not start with either file:// or http(s)://", func() {
@@ -201,14 +482,27 @@ var _ = Describe("Provider", func() {
 
 type fakeSource struct {
 	url         string
+	mirrors     []string
 	sha1        string
 	description string
 }
 
 func newFakeSource(url, sha1, description string) *fakeSource {
-	return &fakeSource{url, sha1, description}
+	return &fakeSource{url: url, sha1: sha1, description: description}
 }
 
-func (s *fakeSource) GetURL() string      { return s.url }
-func (s *fakeSource) GetSHA1() string     { return s.sha1 }
-func (s *fakeSource) Description() string { return s.description }
+func (s *fakeSource) GetURL() string          { return s.url }
+func (s *fakeSource) GetMirrorURLs() []string { return s.mirrors }
+func (s *fakeSource) GetSHA1() string         { return s.sha1 }
+func (s *fakeSource) Description() string     { return s.description }
+
+type fakeDownloadReporter struct {
+	Offsets []int64
+	Sizes   []int64
+}
+
+func (r *fakeDownloadReporter) TrackResumedDownload(offset, size int64, writer io.Writer) io.Writer {
+	r.Offsets = append(r.Offsets, offset)
+	r.Sizes = append(r.Sizes, size)
+	return writer
+}
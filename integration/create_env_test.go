@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakebihttpclient "github.com/cloudfoundry/bosh-utils/httpclient/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -28,6 +30,7 @@ import (
 	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
 	fakebicrypto "github.com/cloudfoundry/bosh-cli/crypto/fakes"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
@@ -80,6 +83,7 @@ var _ = Describe("bosh", func() {
 			mockInstaller          *mock_install.MockInstaller
 			mockInstallerFactory   *mock_install.MockInstallerFactory
 			mockCloudFactory       *mock_cloud.MockFactory
+			cloudFactory           bicloud.Factory
 			mockCloud              *mock_cloud.MockCloud
 			mockAgentClient        *mock_agentclient.MockAgentClient
 			mockAgentClientFactory *mock_httpagent.MockAgentClientFactory
@@ -316,7 +320,7 @@ cloud_provider:
 				Expect(fakeStage.SubStages).To(ContainElement(stage))
 			}).Return(installation, nil).AnyTimes()
 			mockInstaller.EXPECT().Cleanup(installation).AnyTimes()
-			mockCloudFactory.EXPECT().NewCloud(installation, directorID).Return(mockCloud, nil).AnyTimes()
+			mockCloudFactory.EXPECT().NewCloud(installation, directorID, 0).Return(mockCloud, nil).AnyTimes()
 		}
 
 		var writeStemcellReleaseTarball = func() {
@@ -373,7 +377,7 @@ cloud_provider:
 
 			//TODO: use a real state builder
 
-			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, mockAgentClient).Return(mockStateBuilder).AnyTimes()
+			mockStateBuilderFactory.EXPECT().NewBuilder(mockBlobstore, mockAgentClient, gomock.Any()).Return(mockStateBuilder).AnyTimes()
 			mockStateBuilder.EXPECT().Build(jobName, jobIndex, gomock.Any(), gomock.Any(), gomock.Any()).Return(mockState, nil).AnyTimes()
 			mockStateBuilder.EXPECT().BuildInitialState(jobName, jobIndex, gomock.Any()).Return(mockState, nil).AnyTimes()
 			mockState.EXPECT().ToApplySpec().Return(applySpec).AnyTimes()
@@ -420,8 +424,8 @@ cloud_provider:
 					logger,
 				)
 				fakeHTTPClient := fakebihttpclient.NewFakeHTTPClient()
-				tarballCache := bitarball.NewCache("fake-base-path", fs, logger)
-				tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, 1, 0, logger)
+				tarballCache := bitarball.NewCache("fake-base-path", fs, clock.NewClock(), logger)
+				tarballProvider := bitarball.NewProvider(tarballCache, fs, fakeHTTPClient, biui.NewFileReporter(ui), 1, 0, logger)
 
 				cpiInstaller := bicpirel.CpiInstaller{
 					ReleaseManager:   releaseManager,
@@ -463,7 +467,7 @@ cloud_provider:
 					legacyDeploymentStateMigrator,
 					releaseManager,
 					deploymentRecord,
-					mockCloudFactory,
+					cloudFactory,
 					stemcellManagerFactory,
 					mockAgentClientFactory,
 					vmManagerFactory,
@@ -482,7 +486,11 @@ cloud_provider:
 				)
 			}
 
-			return NewCreateEnvCmd(ui, doGet)
+			lockProvider := func(string, string) biconfig.DeploymentStateLock {
+				return fakebiconfig.NewFakeDeploymentStateLock()
+			}
+
+			return NewCreateEnvCmd(ui, doGet, lockProvider)
 		}
 
 		var expectDeployFlow = func() {
@@ -495,7 +503,7 @@ cloud_provider:
 
 			gomock.InOrder(
 				mockCloud.EXPECT().CreateStemcell("fake-stemcell-extracted-dir/image", stemcellCloudProperties).Return(stemcellCID, nil),
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(vmCID, nil),
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(vmCID, nil, nil),
 				mockCloud.EXPECT().SetVMMetadata(vmCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
@@ -516,6 +524,11 @@ cloud_provider:
 			)
 		}
 
+		resizeDiskNotImplementedErr := bicloud.NewCPIError("resize_disk", bicloud.CmdError{
+			Type:    bicloud.NotImplementedError,
+			Message: "fake-not-implemented-message",
+		})
+
 		var expectDeployWithDiskMigration = func() {
 			agentID := "fake-uuid-1"
 			oldVMCID := "fake-vm-cid-1"
@@ -537,10 +550,13 @@ cloud_provider:
 				mockCloud.EXPECT().DeleteVM(oldVMCID),
 
 				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil, nil),
 				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
+				// resize is not supported by the CPI, so disk is migrated
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, newDiskSize).Return(resizeDiskNotImplementedErr),
+
 				// attach both disks and migrate
 				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
@@ -568,6 +584,47 @@ cloud_provider:
 			)
 		}
 
+		var expectDeployWithDiskResize = func() {
+			agentID := "fake-uuid-1"
+			oldVMCID := "fake-vm-cid-1"
+			newVMCID := "fake-vm-cid-2"
+			diskCID := "fake-disk-cid-1"
+			newDiskSize := 2048
+
+			gomock.InOrder(
+				mockCloud.EXPECT().HasVM(oldVMCID).Return(true, nil),
+
+				// shutdown old vm
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{diskCID}, nil),
+				mockAgentClient.EXPECT().UnmountDisk(diskCID),
+				mockCloud.EXPECT().DeleteVM(oldVMCID),
+
+				// create new vm
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil, nil),
+				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+
+				// resize and attach the same disk
+				mockCloud.EXPECT().ResizeDisk(diskCID, newDiskSize),
+				mockCloud.EXPECT().AttachDisk(newVMCID, diskCID),
+				mockCloud.EXPECT().SetDiskMetadata(diskCID, gomock.Any()).Return(nil),
+				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+				mockAgentClient.EXPECT().MountDisk(diskCID),
+
+				// start jobs & wait for running
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().GetState(),
+				mockAgentClient.EXPECT().Stop(),
+				mockAgentClient.EXPECT().Apply(applySpec),
+				mockAgentClient.EXPECT().RunScript("pre-start", map[string]interface{}{}),
+				mockAgentClient.EXPECT().Start(),
+				mockAgentClient.EXPECT().GetState().Return(agentRunningState, nil),
+				mockAgentClient.EXPECT().RunScript("post-start", map[string]interface{}{}),
+			)
+		}
+
 		var expectDeployWithDiskMigrationMissingVM = func() {
 			agentID := "fake-uuid-1"
 			oldVMCID := "fake-vm-cid-1"
@@ -585,10 +642,13 @@ cloud_provider:
 				expectDeleteVM1,
 
 				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil, nil),
 				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
+				// resize is not supported by the CPI, so disk is migrated
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, newDiskSize).Return(resizeDiskNotImplementedErr),
+
 				// attach both disks and migrate
 				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
@@ -633,12 +693,15 @@ cloud_provider:
 				mockCloud.EXPECT().DeleteVM(oldVMCID),
 
 				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil, nil),
 				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
+				// resize is not supported by the CPI
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, 2048).Return(resizeDiskNotImplementedErr),
+
 				// attaching a missing disk will fail
-				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID).Return(
+				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID).Return(nil,
 					bicloud.NewCPIError("attach_disk", bicloud.CmdError{
 						Type:    bicloud.DiskNotFoundError,
 						Message: "fake-disk-not-found-message",
@@ -666,10 +729,13 @@ cloud_provider:
 				mockCloud.EXPECT().DeleteVM(oldVMCID),
 
 				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil, nil),
 				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
+				// resize is not supported by the CPI, so disk is migrated
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, newDiskSize).Return(resizeDiskNotImplementedErr),
+
 				// attach both disks and migrate (with error)
 				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
@@ -705,10 +771,13 @@ cloud_provider:
 				mockCloud.EXPECT().DeleteVM(oldVMCID),
 
 				// create new vm
-				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil),
+				mockCloud.EXPECT().CreateVM(agentID, stemcellCID, vmCloudProperties, networkInterfaces, vmEnv).Return(newVMCID, nil, nil),
 				mockCloud.EXPECT().SetVMMetadata(newVMCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 
+				// resize is not supported by the CPI, so disk is migrated
+				mockCloud.EXPECT().ResizeDisk(oldDiskCID, newDiskSize).Return(resizeDiskNotImplementedErr),
+
 				// attach both disks and migrate
 				mockCloud.EXPECT().AttachDisk(newVMCID, oldDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
@@ -754,12 +823,14 @@ cloud_provider:
 			mockInstaller = mock_install.NewMockInstaller(mockCtrl)
 			mockInstallerFactory = mock_install.NewMockInstallerFactory(mockCtrl)
 			mockCloudFactory = mock_cloud.NewMockFactory(mockCtrl)
+			cloudFactory = mockCloudFactory
 
 			sshTunnelFactory = bisshtunnel.NewFactory(logger)
 
 			fakeRepoUUIDGenerator = fakeuuid.NewFakeGenerator()
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+			mockCloud.EXPECT().UsesRegistry().Return(true).AnyTimes()
 
 			registryServerManager = biregistry.NewServerManager(logger)
 
@@ -803,6 +874,65 @@ cloud_provider:
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		Context("when CPI calls are replayed from a recording", func() {
+			var (
+				cpiReplayPath = "/cpi-replay.jsonl"
+			)
+
+			BeforeEach(func() {
+				err := fs.WriteFileString(cpiReplayPath, strings.Join([]string{
+					`{"input":{"method":"info","arguments":[]},"output":{"result":{"api_version":1,"stemcell_formats":["fake-format"]},"log":""}}`,
+					`{"input":{"method":"create_stemcell","arguments":[]},"output":{"result":"replayed-stemcell-cid","log":""}}`,
+					`{"input":{"method":"create_vm","arguments":[]},"output":{"result":"replayed-vm-cid","log":""}}`,
+					`{"input":{"method":"set_vm_metadata","arguments":[]},"output":{"result":null,"log":""}}`,
+					`{"input":{"method":"create_disk","arguments":[]},"output":{"result":"replayed-disk-cid","log":""}}`,
+					`{"input":{"method":"attach_disk","arguments":[]},"output":{"result":null,"log":""}}`,
+					`{"input":{"method":"set_disk_metadata","arguments":[]},"output":{"result":null,"log":""}}`,
+				}, "\n"))
+				Expect(err).ToNot(HaveOccurred())
+
+				cloudFactory = bicloud.NewFactory(
+					fs, fakesys.NewFakeCmdRunner(), "", cpiReplayPath, clock.NewClock(), fakeuuid.NewFakeGenerator(), logger)
+			})
+
+			It("deploys using recorded CPI responses instead of running the CPI", func() {
+				gomock.InOrder(
+					mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+					mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+					mockAgentClient.EXPECT().MountDisk("replayed-disk-cid"),
+
+					mockAgentClient.EXPECT().Apply(applySpec),
+					mockAgentClient.EXPECT().GetState(),
+					mockAgentClient.EXPECT().Stop(),
+					mockAgentClient.EXPECT().Apply(applySpec),
+					mockAgentClient.EXPECT().RunScript("pre-start", map[string]interface{}{}),
+					mockAgentClient.EXPECT().Start(),
+					mockAgentClient.EXPECT().GetState().Return(agentRunningState, nil),
+					mockAgentClient.EXPECT().RunScript("post-start", map[string]interface{}{}),
+				)
+
+				err := newCreateEnvCmd().Run(fakeStage, newDeployOpts(deploymentManifestPath, ""))
+				Expect(err).ToNot(HaveOccurred())
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.CurrentVMCID).To(Equal("replayed-vm-cid"))
+				Expect(deploymentState.Stemcells[0].CID).To(Equal("replayed-stemcell-cid"))
+				Expect(deploymentState.Disks[0].CID).To(Equal("replayed-disk-cid"))
+			})
+
+			It("fails when CPI calls do not match the recording", func() {
+				err := fs.WriteFileString(cpiReplayPath,
+					`{"input":{"method":"info","arguments":[]},"output":{"result":{"api_version":1},"log":""}}`+"\n"+
+						`{"input":{"method":"create_vm","arguments":[]},"output":{"result":"replayed-vm-cid","log":""}}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = newCreateEnvCmd().Run(fakeStage, newDeployOpts(deploymentManifestPath, ""))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Replaying CPI call 'create_stemcell': expected call #2 to be 'create_vm'"))
+			})
+		})
+
 		Context("when multiple releases are provided", func() {
 			var (
 				otherReleaseTarballPath = "/fake-other-release.tgz"
@@ -906,6 +1036,19 @@ cloud_provider:
 					Expect(err).ToNot(HaveOccurred())
 				})
 
+				It("resizes the disk in place when the CPI supports it", func() {
+					expectDeployWithDiskResize()
+
+					err := newCreateEnvCmd().Run(fakeStage, newDeployOpts(deploymentManifestPath, ""))
+					Expect(err).ToNot(HaveOccurred())
+
+					deploymentState, err := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, "")).Load()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(deploymentState.Disks).To(HaveLen(1))
+					Expect(deploymentState.Disks[0].CID).To(Equal("fake-disk-cid-1"))
+					Expect(deploymentState.Disks[0].Size).To(Equal(2048))
+				})
+
 				Context("when current VM has been deleted manually (outside of bosh)", func() {
 					It("migrates the disk content, but does not shutdown the old VM", func() {
 						expectDeployWithDiskMigrationMissingVM()
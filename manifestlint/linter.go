@@ -0,0 +1,219 @@
+package manifestlint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+// Issue describes a single problem found in a deployment manifest.
+// Path uses go-patch syntax (e.g. /instance_groups/name=web/vm_type).
+type Issue struct {
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string { return fmt.Sprintf("%s: %s", i.Path, i.Message) }
+
+type Linter struct{}
+
+func NewLinter() Linter {
+	return Linter{}
+}
+
+// Lint checks deployment manifest against given releases and optionally against cloud config.
+// Jobs from releases that were not given are not checked.
+func (l Linter) Lint(manifestBytes []byte, releases []boshrel.Release, cloudConfigBytes []byte) ([]Issue, error) {
+	var man manifest
+
+	err := yaml.Unmarshal(manifestBytes, &man)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshaling manifest")
+	}
+
+	var cc *cloudConfig
+
+	if len(cloudConfigBytes) > 0 {
+		cc = &cloudConfig{}
+
+		err = yaml.Unmarshal(cloudConfigBytes, cc)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Unmarshaling cloud config")
+		}
+	}
+
+	releasesByName := map[string]boshrel.Release{}
+
+	for _, rel := range releases {
+		releasesByName[rel.Name()] = rel
+	}
+
+	var issues []Issue
+
+	declaredReleases := map[string]struct{}{}
+
+	for _, rel := range man.Releases {
+		declaredReleases[rel.Name] = struct{}{}
+	}
+
+	if len(man.InstanceGroups) == 0 {
+		issues = append(issues, Issue{"/instance_groups", "at least one instance group must be specified"})
+	}
+
+	igNames := map[string]struct{}{}
+
+	for igIdx, ig := range man.InstanceGroups {
+		igPath := l.itemPath("/instance_groups", igIdx, ig.Name)
+
+		if len(ig.Name) == 0 {
+			issues = append(issues, Issue{igPath + "/name", "name must be provided"})
+		} else if _, found := igNames[ig.Name]; found {
+			issues = append(issues, Issue{igPath + "/name", fmt.Sprintf("name '%s' must be unique", ig.Name)})
+		}
+
+		igNames[ig.Name] = struct{}{}
+
+		if len(ig.Jobs) == 0 {
+			issues = append(issues, Issue{igPath + "/jobs", "at least one job must be specified"})
+		}
+
+		for jobIdx, job := range ig.Jobs {
+			jobPath := l.itemPath(igPath+"/jobs", jobIdx, job.Name)
+
+			issues = append(issues, l.lintJob(jobPath, job, declaredReleases, releasesByName)...)
+		}
+
+		if cc != nil {
+			issues = append(issues, l.lintCloudProperties(igPath, ig, *cc)...)
+		}
+	}
+
+	return issues, nil
+}
+
+func (l Linter) lintJob(jobPath string, job job, declaredReleases map[string]struct{}, releases map[string]boshrel.Release) []Issue {
+	if len(job.Name) == 0 {
+		return []Issue{{jobPath + "/name", "name must be provided"}}
+	}
+
+	if len(job.Release) == 0 {
+		return []Issue{{jobPath + "/release", "release must be provided"}}
+	}
+
+	var issues []Issue
+
+	if _, found := declaredReleases[job.Release]; !found {
+		msg := fmt.Sprintf("release '%s' must be listed in /releases", job.Release)
+		issues = append(issues, Issue{jobPath + "/release", msg})
+	}
+
+	rel, found := releases[job.Release]
+	if !found {
+		return issues
+	}
+
+	relJob, found := rel.FindJobByName(job.Name)
+	if !found {
+		msg := fmt.Sprintf("job '%s' is not found in release '%s'", job.Name, job.Release)
+		return append(issues, Issue{jobPath + "/name", msg})
+	}
+
+	for _, propPath := range l.leafPaths(job.Properties, nil) {
+		if !l.isDefined(propPath, relJob.Properties) {
+			msg := fmt.Sprintf("property '%s' is not defined in job '%s' spec", strings.Join(propPath, "."), job.Name)
+			issues = append(issues, Issue{jobPath + "/properties/" + strings.Join(propPath, "/"), msg})
+		}
+	}
+
+	return issues
+}
+
+func (l Linter) lintCloudProperties(igPath string, ig instanceGroup, cc cloudConfig) []Issue {
+	var issues []Issue
+
+	check := func(path, kind, name string, defined []namedItem) {
+		if len(name) == 0 {
+			return
+		}
+		for _, item := range defined {
+			if item.Name == name {
+				return
+			}
+		}
+		msg := fmt.Sprintf("%s '%s' is not defined in cloud config", kind, name)
+		issues = append(issues, Issue{path, msg})
+	}
+
+	if len(ig.VMType) == 0 && len(ig.VMResources) == 0 {
+		issues = append(issues, Issue{igPath + "/vm_type", "vm_type must be provided"})
+	}
+
+	check(igPath+"/vm_type", "vm_type", ig.VMType, cc.VMTypes)
+	check(igPath+"/persistent_disk_type", "disk_type", ig.PersistentDiskType, cc.DiskTypes)
+
+	for idx, ext := range ig.VMExtensions {
+		check(fmt.Sprintf("%s/vm_extensions/%d", igPath, idx), "vm_extension", ext, cc.VMExtensions)
+	}
+
+	for idx, az := range ig.AZs {
+		check(fmt.Sprintf("%s/azs/%d", igPath, idx), "az", az, cc.AZs)
+	}
+
+	if len(ig.Networks) == 0 {
+		issues = append(issues, Issue{igPath + "/networks", "at least one network must be specified"})
+	}
+
+	for idx, net := range ig.Networks {
+		check(l.itemPath(igPath+"/networks", idx, net.Name)+"/name", "network", net.Name, cc.Networks)
+	}
+
+	return issues
+}
+
+// leafPaths returns paths to all non-hash values (and empty hashes) sorted for stable output
+func (l Linter) leafPaths(props map[interface{}]interface{}, prefix []string) [][]string {
+	var paths [][]string
+
+	for key, val := range props {
+		path := append(append([]string{}, prefix...), fmt.Sprintf("%v", key))
+
+		if nested, ok := val.(map[interface{}]interface{}); ok && len(nested) > 0 {
+			paths = append(paths, l.leafPaths(nested, path)...)
+		} else {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Join(paths[i], ".") < strings.Join(paths[j], ".")
+	})
+
+	return paths
+}
+
+// isDefined allows property to be defined directly, to be a part of
+// a defined hash property, or to be an intermediate hash of a defined property
+func (l Linter) isDefined(path []string, defs map[string]boshjob.PropertyDefinition) bool {
+	name := strings.Join(path, ".")
+
+	for defName := range defs {
+		if name == defName || strings.HasPrefix(name, defName+".") || strings.HasPrefix(defName, name+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l Linter) itemPath(listPath string, idx int, name string) string {
+	if len(name) > 0 {
+		return fmt.Sprintf("%s/name=%s", listPath, name)
+	}
+	return fmt.Sprintf("%s/%d", listPath, idx)
+}
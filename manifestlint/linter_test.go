@@ -0,0 +1,173 @@
+package manifestlint_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/manifestlint"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+)
+
+var _ = Describe("Linter", func() {
+	var (
+		release  *fakerel.FakeRelease
+		releases []boshrel.Release
+		linter   Linter
+	)
+
+	BeforeEach(func() {
+		release = &fakerel.FakeRelease{}
+		release.NameReturns("app")
+		release.FindJobByNameStub = func(name string) (boshjob.Job, bool) {
+			if name != "web" {
+				return boshjob.Job{}, false
+			}
+			job := boshjob.Job{
+				Properties: map[string]boshjob.PropertyDefinition{
+					"port":         {},
+					"tls.cert":     {},
+					"tls.key":      {},
+					"env":          {},
+					"users.admin":  {},
+					"users.viewer": {},
+				},
+			}
+			return job, true
+		}
+
+		releases = []boshrel.Release{release}
+		linter = NewLinter()
+	})
+
+	const cloudConfig = `
+azs: [{name: z1}]
+vm_types: [{name: small}]
+vm_extensions: [{name: lb}]
+disk_types: [{name: 10GB}]
+networks: [{name: default}]
+`
+
+	It("returns no issues for a valid manifest", func() {
+		manifest := `
+releases: [{name: app}]
+instance_groups:
+- name: web
+  azs: [z1]
+  vm_type: small
+  vm_extensions: [lb]
+  persistent_disk_type: 10GB
+  networks: [{name: default}]
+  jobs:
+  - name: web
+    release: app
+    properties:
+      port: 80
+      tls: {cert: cert, key: key}
+      env: {ANY: value}
+      users: {}
+`
+
+		issues, err := linter.Lint([]byte(manifest), releases, []byte(cloudConfig))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issues).To(BeEmpty())
+	})
+
+	It("reports all job and property issues with paths", func() {
+		manifest := `
+releases: [{name: app}]
+instance_groups:
+- name: web
+  jobs:
+  - name: web
+    release: app
+    properties:
+      prot: 80
+      tls: {cret: cert}
+  - name: worker
+    release: app
+  - name: agent
+    release: other
+  - name: ""
+    release: app
+- name: web
+  jobs: []
+`
+
+		issues, err := linter.Lint([]byte(manifest), releases, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issues).To(Equal([]Issue{
+			{"/instance_groups/name=web/jobs/name=web/properties/prot", "property 'prot' is not defined in job 'web' spec"},
+			{"/instance_groups/name=web/jobs/name=web/properties/tls/cret", "property 'tls.cret' is not defined in job 'web' spec"},
+			{"/instance_groups/name=web/jobs/name=worker/name", "job 'worker' is not found in release 'app'"},
+			{"/instance_groups/name=web/jobs/name=agent/release", "release 'other' must be listed in /releases"},
+			{"/instance_groups/name=web/jobs/3/name", "name must be provided"},
+			{"/instance_groups/name=web/name", "name 'web' must be unique"},
+			{"/instance_groups/name=web/jobs", "at least one job must be specified"},
+		}))
+	})
+
+	It("reports cloud config issues if cloud config is given", func() {
+		manifest := `
+releases: [{name: app}]
+instance_groups:
+- name: web
+  azs: [z1, z2]
+  vm_type: large
+  vm_extensions: [lb, public]
+  persistent_disk_type: 100GB
+  networks: [{name: private}]
+  jobs: [{name: web, release: app}]
+- name: db
+  jobs: [{name: web, release: app}]
+`
+
+		issues, err := linter.Lint([]byte(manifest), releases, []byte(cloudConfig))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issues).To(Equal([]Issue{
+			{"/instance_groups/name=web/vm_type", "vm_type 'large' is not defined in cloud config"},
+			{"/instance_groups/name=web/persistent_disk_type", "disk_type '100GB' is not defined in cloud config"},
+			{"/instance_groups/name=web/vm_extensions/1", "vm_extension 'public' is not defined in cloud config"},
+			{"/instance_groups/name=web/azs/1", "az 'z2' is not defined in cloud config"},
+			{"/instance_groups/name=web/networks/name=private/name", "network 'private' is not defined in cloud config"},
+			{"/instance_groups/name=db/vm_type", "vm_type must be provided"},
+			{"/instance_groups/name=db/networks", "at least one network must be specified"},
+		}))
+	})
+
+	It("does not check jobs from releases that were not given", func() {
+		manifest := `
+releases: [{name: other}]
+instance_groups:
+- name: web
+  jobs: [{name: anything, release: other, properties: {any: val}}]
+`
+
+		issues, err := linter.Lint([]byte(manifest), releases, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issues).To(BeEmpty())
+	})
+
+	It("reports missing instance groups", func() {
+		issues, err := linter.Lint([]byte("releases: []"), releases, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(issues).To(Equal([]Issue{{"/instance_groups", "at least one instance group must be specified"}}))
+	})
+
+	It("formats issue with its path", func() {
+		Expect(Issue{"/path", "msg"}.String()).To(Equal("/path: msg"))
+	})
+
+	It("returns error if manifest cannot be parsed", func() {
+		_, err := linter.Lint([]byte("-"), releases, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshaling manifest"))
+	})
+
+	It("returns error if cloud config cannot be parsed", func() {
+		_, err := linter.Lint([]byte("instance_groups: []"), releases, []byte("-"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshaling cloud config"))
+	})
+})
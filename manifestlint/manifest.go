@@ -0,0 +1,38 @@
+package manifestlint
+
+// manifest includes only parts of a deployment manifest that are linted
+type manifest struct {
+	Releases       []namedItem     `yaml:"releases"`
+	InstanceGroups []instanceGroup `yaml:"instance_groups"`
+}
+
+type instanceGroup struct {
+	Name string `yaml:"name"`
+
+	AZs                []string               `yaml:"azs"`
+	VMType             string                 `yaml:"vm_type"`
+	VMResources        map[string]interface{} `yaml:"vm_resources"`
+	VMExtensions       []string               `yaml:"vm_extensions"`
+	PersistentDiskType string                 `yaml:"persistent_disk_type"`
+	Networks           []namedItem            `yaml:"networks"`
+
+	Jobs []job `yaml:"jobs"`
+}
+
+type job struct {
+	Name       string                      `yaml:"name"`
+	Release    string                      `yaml:"release"`
+	Properties map[interface{}]interface{} `yaml:"properties"`
+}
+
+type cloudConfig struct {
+	AZs          []namedItem `yaml:"azs"`
+	VMTypes      []namedItem `yaml:"vm_types"`
+	VMExtensions []namedItem `yaml:"vm_extensions"`
+	DiskTypes    []namedItem `yaml:"disk_types"`
+	Networks     []namedItem `yaml:"networks"`
+}
+
+type namedItem struct {
+	Name string `yaml:"name"`
+}
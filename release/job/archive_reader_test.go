@@ -49,6 +49,15 @@ properties:
   prop:
     description: prop-desc
     default: prop-default
+  typed-prop:
+    description: typed-prop-desc
+    type: integer
+    required: true
+    example: 5
+provides:
+- {name: conn, type: db, properties: [prop]}
+consumes:
+- {name: backup, type: db, optional: true}
 `)
 
 			job, err := reader.Read(ref, "archive-path")
@@ -66,6 +75,18 @@ properties:
 					Description: "prop-desc",
 					Default:     biproperty.Property("prop-default"),
 				},
+				"typed-prop": PropertyDefinition{
+					Description: "typed-prop-desc",
+					Type:        "integer",
+					Required:    true,
+					Example:     biproperty.Property(5),
+				},
+			}))
+			Expect(job.Provides).To(Equal([]LinkDefinition{
+				{Name: "conn", Type: "db", Properties: []string{"prop"}},
+			}))
+			Expect(job.Consumes).To(Equal([]LinkDefinition{
+				{Name: "backup", Type: "db", Optional: true},
 			}))
 
 			Expect(job.ExtractedPath()).To(Equal("/extracted/job"))
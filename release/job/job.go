@@ -6,6 +6,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	"github.com/cloudfoundry/bosh-cli/crypto"
+	boshjobman "github.com/cloudfoundry/bosh-cli/release/job/manifest"
 	boshpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	. "github.com/cloudfoundry/bosh-cli/release/resource"
 	crypto2 "github.com/cloudfoundry/bosh-utils/crypto"
@@ -24,6 +25,8 @@ type Job struct {
 	PackageNames []string
 	Packages     []boshpkg.Compilable
 	Properties   map[string]PropertyDefinition
+	Provides     []LinkDefinition
+	Consumes     []LinkDefinition
 
 	extractedPath string
 	fs            boshsys.FileSystem
@@ -32,6 +35,18 @@ type Job struct {
 type PropertyDefinition struct {
 	Description string
 	Default     biproperty.Property
+
+	Type     string
+	Required bool
+	Example  biproperty.Property
+}
+
+type LinkDefinition struct {
+	Name string
+	Type string
+
+	Optional   bool
+	Properties []string
 }
 
 func NewJob(resource Resource) *Job {
@@ -42,6 +57,54 @@ func NewExtractedJob(resource Resource, extractedPath string, fs boshsys.FileSys
 	return &Job{resource: resource, extractedPath: extractedPath, fs: fs}
 }
 
+// populateFromManifest sets templates, packages, property and link definitions from job spec
+func (j *Job) populateFromManifest(manifest boshjobman.Manifest) error {
+	j.Templates = manifest.Templates
+	j.PackageNames = manifest.Packages
+
+	properties := make(map[string]PropertyDefinition, len(manifest.Properties))
+
+	for propertyName, rawPropertyDef := range manifest.Properties {
+		defaultValue, err := biproperty.Build(rawPropertyDef.Default)
+		if err != nil {
+			errMsg := "Parsing job '%s' property '%s' default: %#v"
+			return bosherr.WrapErrorf(err, errMsg, j.Name(), propertyName, rawPropertyDef.Default)
+		}
+
+		exampleValue, err := biproperty.Build(rawPropertyDef.Example)
+		if err != nil {
+			errMsg := "Parsing job '%s' property '%s' example: %#v"
+			return bosherr.WrapErrorf(err, errMsg, j.Name(), propertyName, rawPropertyDef.Example)
+		}
+
+		properties[propertyName] = PropertyDefinition{
+			Description: rawPropertyDef.Description,
+			Default:     defaultValue,
+
+			Type:     rawPropertyDef.Type,
+			Required: rawPropertyDef.Required,
+			Example:  exampleValue,
+		}
+	}
+
+	j.Properties = properties
+
+	j.Provides = j.linkDefinitions(manifest.Provides)
+	j.Consumes = j.linkDefinitions(manifest.Consumes)
+
+	return nil
+}
+
+func (j *Job) linkDefinitions(rawLinkDefs []boshjobman.LinkDefinition) []LinkDefinition {
+	var linkDefs []LinkDefinition
+
+	for _, rawLinkDef := range rawLinkDefs {
+		linkDefs = append(linkDefs, LinkDefinition(rawLinkDef))
+	}
+
+	return linkDefs
+}
+
 func (j Job) Name() string        { return j.resource.Name() }
 func (j Job) Fingerprint() string { return j.resource.Fingerprint() }
 
@@ -80,6 +143,8 @@ func (j *Job) RehashWithCalculator(calculator crypto.DigestCalculator, archiveFi
 		PackageNames: j.PackageNames,
 		Packages:     j.Packages,
 		Properties:   j.Properties,
+		Provides:     j.Provides,
+		Consumes:     j.Consumes,
 
 		extractedPath: j.extractedPath,
 		fs:            j.fs,
@@ -11,11 +11,30 @@ type Manifest struct {
 	Templates  map[string]string             `yaml:"templates"`
 	Packages   []string                      `yaml:"packages"`
 	Properties map[string]PropertyDefinition `yaml:"properties"`
+
+	Provides []LinkDefinition `yaml:"provides"`
+	Consumes []LinkDefinition `yaml:"consumes"`
+}
+
+type LinkDefinition struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+
+	// Only used for consumed links
+	Optional bool `yaml:"optional"`
+
+	// Only used for provided links; names of job properties exposed via link
+	Properties []string `yaml:"properties"`
 }
 
 type PropertyDefinition struct {
 	Description string      `yaml:"description"`
 	Default     interface{} `yaml:"default"`
+
+	// Optional schema: one of string, integer, boolean, certificate, array or hash
+	Type     string      `yaml:"type"`
+	Required bool        `yaml:"required"`
+	Example  interface{} `yaml:"example"`
 }
 
 func NewManifestFromPath(path string, fs boshsys.FileSystem) (Manifest, error) {
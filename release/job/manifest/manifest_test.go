@@ -63,6 +63,34 @@ properties:
 		}))
 	})
 
+	It("parses provided and consumed links", func() {
+		contents := `---
+name: name
+provides:
+- name: conn
+  type: db
+  properties: [port, user.name]
+consumes:
+- name: primary
+  type: db
+- name: backup
+  type: db
+  optional: true
+`
+
+		fs.WriteFileString("/path", contents)
+
+		manifest, err := NewManifestFromPath("/path", fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Provides).To(Equal([]LinkDefinition{
+			{Name: "conn", Type: "db", Properties: []string{"port", "user.name"}},
+		}))
+		Expect(manifest.Consumes).To(Equal([]LinkDefinition{
+			{Name: "primary", Type: "db"},
+			{Name: "backup", Type: "db", Optional: true},
+		}))
+	})
+
 	It("returns error if manifest is not valid yaml", func() {
 		fs.WriteFileString("/path", "-")
 
@@ -0,0 +1,39 @@
+package job
+
+import (
+	"path/filepath"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshjobman "github.com/cloudfoundry/bosh-cli/release/job/manifest"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+)
+
+// SourceDirReader reads job from its source directory in a release directory
+// (e.g. jobs/foo) without building job archive. Returned job's extracted path
+// points to source directory so that its templates could be rendered;
+// source directory is never removed by CleanUp.
+type SourceDirReader struct {
+	fs boshsys.FileSystem
+}
+
+func NewSourceDirReader(fs boshsys.FileSystem) SourceDirReader {
+	return SourceDirReader{fs: fs}
+}
+
+func (r SourceDirReader) Read(path string) (*Job, error) {
+	manifest, err := boshjobman.NewManifestFromPath(filepath.Join(path, "spec"), r.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	job := NewJob(NewResourceWithBuiltArchive(manifest.Name, "", "", ""))
+	job.extractedPath = path
+
+	err = job.populateFromManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
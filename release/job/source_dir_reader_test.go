@@ -0,0 +1,76 @@
+package job_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/release/job"
+)
+
+var _ = Describe("SourceDirReader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		reader SourceDirReader
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		reader = NewSourceDirReader(fs)
+	})
+
+	Describe("Read", func() {
+		It("returns a job with templates and properties from job spec", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "spec"), `---
+name: name
+templates: {src: dst}
+packages: [pkg]
+properties:
+  prop:
+    description: prop-desc
+    default: prop-default
+    type: string
+    required: true
+`)
+
+			job, err := reader.Read(filepath.Join("/", "dir"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(job.Name()).To(Equal("name"))
+			Expect(job.ExtractedPath()).To(Equal(filepath.Join("/", "dir")))
+			Expect(job.Templates).To(Equal(map[string]string{"src": "dst"}))
+			Expect(job.PackageNames).To(Equal([]string{"pkg"}))
+			Expect(job.Properties).To(Equal(map[string]PropertyDefinition{
+				"prop": PropertyDefinition{
+					Description: "prop-desc",
+					Default:     biproperty.Property("prop-default"),
+					Type:        "string",
+					Required:    true,
+				},
+			}))
+		})
+
+		It("does not remove source directory when cleaning up", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "spec"), "name: name")
+
+			job, err := reader.Read(filepath.Join("/", "dir"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(job.CleanUp()).ToNot(HaveOccurred())
+			Expect(fs.FileExists(filepath.Join("/", "dir", "spec"))).To(BeTrue())
+		})
+
+		It("returns error if spec cannot be read", func() {
+			fs.WriteFileString(filepath.Join("/", "dir", "spec"), "name: name")
+			fs.RegisterReadFileError(filepath.Join("/", "dir", "spec"), errors.New("fake-err"))
+
+			_, err := reader.Read(filepath.Join("/", "dir"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+	})
+})
@@ -163,6 +163,28 @@ releases:
 				},
 			}))
 		})
+
+		It("parses release mirrors", func() {
+			fs.WriteFileString(comboManifestPath, `
+---
+releases:
+- name: fake-release-name-4
+  url: http://fake-url/fake-release-4.tgz
+  mirrors:
+  - http://fake-mirror-1/fake-release-4.tgz
+  - https://fake-mirror-2/fake-release-4.tgz
+  sha1: sha256:fake-sha256
+`)
+
+			deploymentManifest, err := parser.Parse(comboManifestPath, boshtpl.StaticVariables{}, patch.Ops{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deploymentManifest.Releases[0].Mirrors).To(Equal([]string{
+				"http://fake-mirror-1/fake-release-4.tgz",
+				"https://fake-mirror-2/fake-release-4.tgz",
+			}))
+			Expect(deploymentManifest.Releases[0].SHA1).To(Equal("sha256:fake-sha256"))
+		})
 	})
 
 	It("parses release set manifest from combo manifest file", func() {
@@ -49,6 +49,13 @@ func (v *validator) Validate(manifest Manifest) error {
 		if strings.HasPrefix(release.URL, "http") && v.isBlank(release.SHA1) {
 			errs = append(errs, bosherr.Errorf("releases[%d].sha1 must be provided for http URL", releaseIdx))
 		}
+
+		for mirrorIdx, mirror := range release.Mirrors {
+			matched, err := regexp.MatchString("^(http|https)://", mirror)
+			if err != nil || !matched {
+				errs = append(errs, bosherr.Errorf("releases[%d].mirrors[%d] must be a valid URL (http(s)://)", releaseIdx, mirrorIdx))
+			}
+		}
 	}
 
 	if len(errs) > 0 {
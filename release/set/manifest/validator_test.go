@@ -104,6 +104,24 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("releases[0].sha1 must be provided for http URL"))
 		})
 
+		It("validates release mirrors are http(s) urls", func() {
+			manifest := Manifest{
+				Releases: []boshman.ReleaseRef{
+					{
+						Name:    "fake-release-name",
+						URL:     "http://fake-url",
+						Mirrors: []string{"https://fake-mirror", "file://fake-file"},
+						SHA1:    "fake-sha1",
+					},
+				},
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).ToNot(ContainSubstring("releases[0].mirrors[0]"))
+			Expect(err.Error()).To(ContainSubstring("releases[0].mirrors[1] must be a valid URL (http(s)://)"))
+		})
+
 		It("validates releases have valid urls", func() {
 			manifest := Manifest{
 				Releases: []boshman.ReleaseRef{
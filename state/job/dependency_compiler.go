@@ -2,6 +2,7 @@ package job
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -26,14 +27,22 @@ type DependencyCompiler interface {
 
 type dependencyCompiler struct {
 	packageCompiler bistatepkg.Compiler
+	parallel        int
 
 	logTag string
 	logger boshlog.Logger
 }
 
-func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, logger boshlog.Logger) DependencyCompiler {
+// NewDependencyCompiler returns compiler that compiles up to parallel packages
+// at the same time. Package is compiled only after all of its dependencies are.
+func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, parallel int, logger boshlog.Logger) DependencyCompiler {
+	if parallel < 1 {
+		parallel = 1
+	}
+
 	return &dependencyCompiler{
 		packageCompiler: packageCompiler,
+		parallel:        parallel,
 
 		logTag: "dependencyCompiler",
 		logger: logger,
@@ -104,39 +113,164 @@ func (c *dependencyCompiler) resolvePackageDependencies(releasePackage birelpkg.
 	}
 }
 
-// compilePackages compiles the specified packages, in the order specified, uploads them to the Blobstore, and returns the blob references
+// compilePackages compiles the specified packages, uploads them to the Blobstore, and returns the blob references
+// in the order specified. Packages that do not depend on each other are compiled concurrently.
+// Failure of a package does not stop compilation of packages that do not depend on it
+// so that returned errors, ordered by package name, do not depend on the order in which packages finish.
 func (c *dependencyCompiler) compilePackages(requiredPackages []birelpkg.Compilable, stage biui.Stage) ([]CompiledPackageRef, error) {
-	packageRefs := make([]CompiledPackageRef, 0, len(requiredPackages))
+	if c.parallel > 1 {
+		stage = biui.NewConcurrentStage(stage)
+	}
 
-	for _, pkg := range requiredPackages {
-		stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+	type compileResult struct {
+		index int
+		err   error
+	}
 
-		err := stage.Perform(stepName, func() error {
-			compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
-			if err != nil {
-				return err
-			}
+	packageRefs := make([]CompiledPackageRef, len(requiredPackages))
+	errs := make([]error, len(requiredPackages))
+	states := make([]compileState, len(requiredPackages))
+	results := make(chan compileResult)
+	running := 0
+
+	indexes := map[string]int{}
 
-			packageRef := CompiledPackageRef{
-				Name:        pkg.Name(),
-				Version:     pkg.Fingerprint(),
-				BlobstoreID: compiledPackageRecord.BlobID,
-				SHA1:        compiledPackageRecord.BlobSHA1,
+	for i, pkg := range requiredPackages {
+		indexes[c.pkgKey(pkg)] = i
+	}
+
+	for {
+		for running < c.parallel {
+			i, found := c.nextPackage(requiredPackages, indexes, states)
+			if !found {
+				break
 			}
-			packageRefs = append(packageRefs, packageRef)
 
-			if isAlreadyCompiled {
-				return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
+			states[i] = compileStateRunning
+			running++
+
+			go func(i int) {
+				ref, err := c.compilePackage(requiredPackages[i], stage)
+				packageRefs[i] = ref
+				results <- compileResult{index: i, err: err}
+			}(i)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+
+		if result.err != nil {
+			states[result.index] = compileStateFailed
+			errs[result.index] = result.err
+		} else {
+			states[result.index] = compileStateCompiled
+		}
+	}
+
+	var failedIndexes []int
+
+	for i, state := range states {
+		pkg := requiredPackages[i]
+
+		switch state {
+		case compileStateFailed:
+			failedIndexes = append(failedIndexes, i)
+		case compileStateBlocked:
+			c.logger.Debug(c.logTag, "Not compiling package '%s/%s' because its dependencies failed to compile", pkg.Name(), pkg.Fingerprint())
+		}
+	}
+
+	// compilation order of independent packages is not stable
+	sort.Slice(failedIndexes, func(i, j int) bool {
+		return c.pkgKey(requiredPackages[failedIndexes[i]]) < c.pkgKey(requiredPackages[failedIndexes[j]])
+	})
+
+	var compileErrs []error
+
+	for _, i := range failedIndexes {
+		compileErrs = append(compileErrs, errs[i])
+	}
+
+	switch len(compileErrs) {
+	case 0:
+		return packageRefs, nil
+	case 1:
+		return nil, compileErrs[0]
+	default:
+		return nil, bosherr.NewMultiError(compileErrs...)
+	}
+}
+
+type compileState int
+
+const (
+	compileStatePending compileState = iota
+	compileStateRunning
+	compileStateCompiled
+	compileStateFailed
+	compileStateBlocked
+)
+
+// nextPackage returns first pending package, in the order specified, whose dependencies are compiled.
+// Pending packages that depend on failed packages are marked as blocked.
+func (c *dependencyCompiler) nextPackage(requiredPackages []birelpkg.Compilable, indexes map[string]int, states []compileState) (int, bool) {
+	for i, pkg := range requiredPackages {
+		if states[i] != compileStatePending {
+			continue
+		}
+
+		ready := true
+
+		for _, dependency := range pkg.Deps() {
+			switch states[indexes[c.pkgKey(dependency)]] {
+			case compileStateCompiled:
+				continue
+			case compileStateFailed, compileStateBlocked:
+				states[i] = compileStateBlocked
 			}
 
-			return nil
-		})
-		if err != nil {
-			return nil, err
+			ready = false
+			break
+		}
+
+		if ready {
+			return i, true
 		}
 	}
 
-	return packageRefs, nil
+	return 0, false
+}
+
+func (c *dependencyCompiler) compilePackage(pkg birelpkg.Compilable, stage biui.Stage) (CompiledPackageRef, error) {
+	var packageRef CompiledPackageRef
+
+	stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	err := stage.Perform(stepName, func() error {
+		compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
+		if err != nil {
+			return err
+		}
+
+		packageRef = CompiledPackageRef{
+			Name:        pkg.Name(),
+			Version:     pkg.Fingerprint(),
+			BlobstoreID: compiledPackageRecord.BlobID,
+			SHA1:        compiledPackageRecord.BlobSHA1,
+		}
+
+		if isAlreadyCompiled {
+			return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
+		}
+
+		return nil
+	})
+
+	return packageRef, err
 }
 
 func (c *dependencyCompiler) pkgKey(pkg birelpkg.Compilable) string { return pkg.Name() }
@@ -1,6 +1,9 @@
 package job_test
 
 import (
+	"errors"
+	"sync"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -47,7 +50,7 @@ var _ = Describe("DependencyCompiler", func() {
 		mockPackageCompiler = mock_state_package.NewMockCompiler(mockCtrl)
 
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, logger)
+		dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, 1, logger)
 
 		stage = fakeui.NewFakeStage()
 
@@ -215,4 +218,135 @@ var _ = Describe("DependencyCompiler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("when compiling packages in parallel", func() {
+		var (
+			pkg3              *boshrelpkg.Package
+			expectCompilePkg3 *gomock.Call
+		)
+
+		BeforeEach(func() {
+			dependencyCompiler = NewDependencyCompiler(mockPackageCompiler, 2, logger)
+
+			pkg3 = newPkg("pkg3-name", "pkg3-fp", nil)
+
+			job.PackageNames = append(job.PackageNames, pkg3.Name())
+			job.AttachPackages([]*boshrelpkg.Package{pkg2, pkg3})
+			jobs = []boshreljob.Job{*job}
+		})
+
+		JustBeforeEach(func() {
+			compiledPackageRecord3 := bistatepkg.CompiledPackageRecord{
+				BlobID:   "fake-compiled-package-blobstore-id-3",
+				BlobSHA1: "fake-compiled-package-sha1-3",
+			}
+			expectCompilePkg3 = mockPackageCompiler.EXPECT().Compile(pkg3).Return(compiledPackageRecord3, false, nil).AnyTimes()
+		})
+
+		It("compiles independent packages at the same time", func() {
+			started := make(chan string, 2)
+			release := make(chan struct{})
+
+			block := func(pkg boshrelpkg.Compilable) {
+				started <- pkg.Name()
+				<-release
+			}
+
+			expectCompilePkg1.Do(block)
+			expectCompilePkg3.Do(block)
+
+			done := make(chan error, 1)
+
+			go func() {
+				_, err := dependencyCompiler.Compile(jobs, stage)
+				done <- err
+			}()
+
+			var names []string
+			for i := 0; i < 2; i++ {
+				var name string
+				Eventually(started).Should(Receive(&name))
+				names = append(names, name)
+			}
+			Expect(names).To(ConsistOf("pkg1-name", "pkg3-name"))
+
+			close(release)
+
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		It("compiles package only after its dependencies are compiled", func() {
+			var (
+				lock     sync.Mutex
+				compiled []string
+			)
+
+			record := func(pkg boshrelpkg.Compilable) {
+				lock.Lock()
+				defer lock.Unlock()
+				compiled = append(compiled, pkg.Name())
+			}
+
+			expectCompilePkg1.Do(record)
+			expectCompilePkg3.Do(record)
+			expectCompilePkg2.Do(func(pkg boshrelpkg.Compilable) {
+				lock.Lock()
+				defer lock.Unlock()
+				Expect(compiled).To(ContainElement("pkg1-name"))
+			})
+
+			compiledPackageRefs, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(compiledPackageRefs).To(ConsistOf(
+				CompiledPackageRef{
+					Name:        "pkg1-name",
+					Version:     "pkg1-fp",
+					BlobstoreID: "fake-compiled-package-blobstore-id-1",
+					SHA1:        "fake-compiled-package-sha1-1",
+				},
+				CompiledPackageRef{
+					Name:        "pkg2-name",
+					Version:     "pkg2-fp",
+					BlobstoreID: "fake-compiled-package-blobstore-id-2",
+					SHA1:        "fake-compiled-package-sha1-2",
+				},
+				CompiledPackageRef{
+					Name:        "pkg3-name",
+					Version:     "pkg3-fp",
+					BlobstoreID: "fake-compiled-package-blobstore-id-3",
+					SHA1:        "fake-compiled-package-sha1-3",
+				},
+			))
+		})
+
+		It("logs compile stage of each package once it is compiled", func() {
+			_, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+
+			var names []string
+			for _, call := range stage.PerformCalls {
+				names = append(names, call.Name)
+			}
+
+			Expect(names).To(ConsistOf(
+				"Compiling package 'pkg1-name/pkg1-fp'",
+				"Compiling package 'pkg2-name/pkg2-fp'",
+				"Compiling package 'pkg3-name/pkg3-fp'",
+			))
+
+			// package is logged once compiled so it always follows its dependencies
+			Expect(names[0]).ToNot(Equal("Compiling package 'pkg2-name/pkg2-fp'"))
+		})
+
+		It("compiles remaining independent packages and returns errors ordered by package name", func() {
+			expectCompilePkg1.Return(bistatepkg.CompiledPackageRecord{}, false, errors.New("fake-pkg1-err"))
+			expectCompilePkg3.Return(bistatepkg.CompiledPackageRecord{}, false, errors.New("fake-pkg3-err"))
+			expectCompilePkg2.Times(0)
+
+			_, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Compiling job package dependencies: fake-pkg1-err\nfake-pkg3-err"))
+		})
+	})
 })
@@ -0,0 +1,475 @@
+package pkg
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+)
+
+const (
+	compiledPackageCacheIndexName = "index.json"
+
+	maxCompiledPackageCacheIndexUpdates = 5
+)
+
+// Stemcell identifies operating system packages were compiled on
+type Stemcell struct {
+	OS      string
+	Version string
+}
+
+func (s Stemcell) String() string { return s.OS + "/" + s.Version }
+
+type CompiledPackageCacheEntry struct {
+	Name            string `json:"name"`
+	Fingerprint     string `json:"fingerprint"`
+	DependencyKey   string `json:"dependency_key"`
+	StemcellOS      string `json:"stemcell_os"`
+	StemcellVersion string `json:"stemcell_version"`
+
+	// Blob is a name of the compiled package archive relative to cache location
+	Blob string `json:"blob"`
+	SHA1 string `json:"sha1"`
+	Size int64  `json:"size"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func (e CompiledPackageCacheEntry) Stemcell() Stemcell {
+	return Stemcell{OS: e.StemcellOS, Version: e.StemcellVersion}
+}
+
+func (e CompiledPackageCacheEntry) matches(other CompiledPackageCacheEntry) bool {
+	return e.Name == other.Name &&
+		e.Fingerprint == other.Fingerprint &&
+		e.DependencyKey == other.DependencyKey &&
+		e.StemcellOS == other.StemcellOS &&
+		e.StemcellVersion == other.StemcellVersion
+}
+
+// CompiledPackageCache keeps compiled package archives so that environments
+// using the same stemcell do not have to compile the same packages again.
+// Cache is either a local directory or an object store location shared
+// between multiple machines; entries are listed in an index next to archives.
+type CompiledPackageCache interface {
+	// Find copies cached archive into a local blob that should be deleted by the caller
+	Find(birelpkg.Compilable, Stemcell) (biblobstore.LocalBlob, CompiledPackageCacheEntry, bool, error)
+	Save(pkg birelpkg.Compilable, stemcell Stemcell, archivePath string) error
+
+	List() ([]CompiledPackageCacheEntry, error)
+	Delete([]CompiledPackageCacheEntry) error
+
+	// Export copies entries into a directory that can be used as a local cache
+	Export(entries []CompiledPackageCacheEntry, dir string) error
+}
+
+type compiledPackageCacheIndex struct {
+	Packages []CompiledPackageCacheEntry `json:"packages"`
+}
+
+type compiledPackageCache struct {
+	store       biconfig.ObjectStore
+	location    string
+	local       bool
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+
+	// packages may be compiled concurrently within single deploy
+	indexLock *sync.Mutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewCompiledPackageCache(
+	store biconfig.ObjectStore,
+	location string,
+	local bool,
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) CompiledPackageCache {
+	return compiledPackageCache{
+		store:       store,
+		location:    location,
+		local:       local,
+		fs:          fs,
+		timeService: timeService,
+		indexLock:   &sync.Mutex{},
+
+		logTag: "compiledPackageCache",
+		logger: logger,
+	}
+}
+
+// NewCompiledPackageCacheFromLocation returns cache kept in a local directory
+// or, for s3://bucket/prefix and gs://bucket/prefix URLs, in an object store.
+func NewCompiledPackageCacheFromLocation(
+	location string,
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) (CompiledPackageCache, error) {
+	if !biconfig.IsObjectStoreURL(location) {
+		dir, err := fs.ExpandPath(location)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Expanding compiled package cache path '%s'", location)
+		}
+
+		return NewCompiledPackageCache(biconfig.NewFileObjectStore(fs), dir, true, fs, timeService, logger), nil
+	}
+
+	storeURL, err := biconfig.ParseObjectStoreURL(location)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing compiled package cache location")
+	}
+
+	store, err := biconfig.NewObjectStore(storeURL)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating compiled package cache object store")
+	}
+
+	return NewCompiledPackageCache(store, storeURL.Key, false, fs, timeService, logger), nil
+}
+
+func (c compiledPackageCache) Find(pkg birelpkg.Compilable, stemcell Stemcell) (biblobstore.LocalBlob, CompiledPackageCacheEntry, bool, error) {
+	wanted := c.newEntry(pkg, stemcell)
+
+	index, _, err := c.loadIndex()
+	if err != nil {
+		return nil, CompiledPackageCacheEntry{}, false, err
+	}
+
+	entry, found := c.findEntry(index, wanted)
+	if !found {
+		return nil, CompiledPackageCacheEntry{}, false, nil
+	}
+
+	contents, err := c.getBlob(entry)
+	if err != nil {
+		return nil, CompiledPackageCacheEntry{}, false, err
+	}
+
+	file, err := c.fs.TempFile("bosh-compiled-package")
+	if err != nil {
+		return nil, CompiledPackageCacheEntry{}, false, bosherr.WrapError(err, "Creating temporary file for compiled package")
+	}
+
+	archivePath := file.Name()
+	_ = file.Close()
+
+	err = c.fs.WriteFile(archivePath, contents)
+	if err != nil {
+		_ = c.fs.RemoveAll(archivePath)
+		return nil, CompiledPackageCacheEntry{}, false, bosherr.WrapErrorf(err, "Writing compiled package '%s'", entry.Name)
+	}
+
+	now := c.timeService.Now()
+
+	err = c.updateIndex(func(index *compiledPackageCacheIndex) {
+		for i, e := range index.Packages {
+			if e.matches(entry) {
+				index.Packages[i].LastUsedAt = now
+			}
+		}
+	})
+	if err != nil {
+		// Failing to record usage only affects pruning so it should not fail compilation
+		c.logger.Warn(c.logTag, "Failed to record usage of compiled package '%s': %s", entry.Name, err)
+	}
+
+	entry.LastUsedAt = now
+
+	return biblobstore.NewLocalBlob(archivePath, c.fs, c.logger), entry, true, nil
+}
+
+func (c compiledPackageCache) Save(pkg birelpkg.Compilable, stemcell Stemcell, archivePath string) error {
+	contents, err := c.fs.ReadFile(archivePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading compiled package archive '%s'", archivePath)
+	}
+
+	entry := c.newEntry(pkg, stemcell)
+	entry.SHA1 = fmt.Sprintf("%x", sha1.Sum(contents))
+	entry.Size = int64(len(contents))
+	entry.Blob = path.Join("packages", entry.Name, entry.SHA1)
+	entry.CreatedAt = c.timeService.Now()
+	entry.LastUsedAt = entry.CreatedAt
+
+	_, err = c.store.Put(c.key(entry.Blob), contents, "")
+	if err != nil {
+		// Blobs are named by their contents so an existing blob can be reused
+		if _, ok := err.(biconfig.ObjectVersionMismatchError); !ok {
+			return bosherr.WrapErrorf(err, "Saving compiled package '%s/%s'", entry.Name, entry.Fingerprint)
+		}
+	}
+
+	var unusedBlobs []string
+
+	err = c.updateIndex(func(index *compiledPackageCacheIndex) {
+		var replaced []CompiledPackageCacheEntry
+
+		packages := []CompiledPackageCacheEntry{}
+
+		for _, e := range index.Packages {
+			if e.matches(entry) {
+				replaced = append(replaced, e)
+				continue
+			}
+			packages = append(packages, e)
+		}
+
+		index.Packages = append(packages, entry)
+		unusedBlobs = c.unusedBlobs(*index, replaced)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range unusedBlobs {
+		err = c.store.Delete(c.key(blob))
+		if err != nil {
+			c.logger.Warn(c.logTag, "Failed to delete replaced compiled package blob '%s': %s", blob, err)
+		}
+	}
+
+	return nil
+}
+
+func (c compiledPackageCache) List() ([]CompiledPackageCacheEntry, error) {
+	index, _, err := c.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := index.Packages
+
+	sort.Sort(compiledPackageCacheEntrySorting(entries))
+
+	return entries, nil
+}
+
+func (c compiledPackageCache) Delete(entries []CompiledPackageCacheEntry) error {
+	var unusedBlobs []string
+
+	err := c.updateIndex(func(index *compiledPackageCacheIndex) {
+		packages := []CompiledPackageCacheEntry{}
+
+		for _, e := range index.Packages {
+			deleted := false
+			for _, entry := range entries {
+				if e.matches(entry) {
+					deleted = true
+				}
+			}
+			if !deleted {
+				packages = append(packages, e)
+			}
+		}
+
+		index.Packages = packages
+		unusedBlobs = c.unusedBlobs(*index, entries)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Blobs are removed after index no longer refers to them
+	// so that concurrent readers never find missing blobs
+	for _, blob := range unusedBlobs {
+		err = c.store.Delete(c.key(blob))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Deleting compiled package blob '%s'", blob)
+		}
+	}
+
+	return nil
+}
+
+func (c compiledPackageCache) Export(entries []CompiledPackageCacheEntry, dir string) error {
+	dst := compiledPackageCache{
+		store:       biconfig.NewFileObjectStore(c.fs),
+		location:    dir,
+		local:       true,
+		fs:          c.fs,
+		timeService: c.timeService,
+		indexLock:   &sync.Mutex{},
+
+		logTag: c.logTag,
+		logger: c.logger,
+	}
+
+	for _, entry := range entries {
+		contents, err := c.getBlob(entry)
+		if err != nil {
+			return err
+		}
+
+		_, err = dst.store.Put(dst.key(entry.Blob), contents, "")
+		if err != nil {
+			if _, ok := err.(biconfig.ObjectVersionMismatchError); !ok {
+				return bosherr.WrapErrorf(err, "Exporting compiled package '%s/%s'", entry.Name, entry.Fingerprint)
+			}
+		}
+	}
+
+	return dst.updateIndex(func(index *compiledPackageCacheIndex) {
+		for _, entry := range entries {
+			if _, found := dst.findEntry(*index, entry); !found {
+				index.Packages = append(index.Packages, entry)
+			}
+		}
+	})
+}
+
+func (c compiledPackageCache) newEntry(pkg birelpkg.Compilable, stemcell Stemcell) CompiledPackageCacheEntry {
+	return CompiledPackageCacheEntry{
+		Name:            pkg.Name(),
+		Fingerprint:     pkg.Fingerprint(),
+		DependencyKey:   dependencyKey(ResolveDependencies(pkg)),
+		StemcellOS:      stemcell.OS,
+		StemcellVersion: stemcell.Version,
+	}
+}
+
+func (c compiledPackageCache) findEntry(index compiledPackageCacheIndex, wanted CompiledPackageCacheEntry) (CompiledPackageCacheEntry, bool) {
+	for _, entry := range index.Packages {
+		if entry.matches(wanted) {
+			return entry, true
+		}
+	}
+
+	return CompiledPackageCacheEntry{}, false
+}
+
+// unusedBlobs returns blobs of removed entries that no remaining entry refers to
+func (c compiledPackageCache) unusedBlobs(index compiledPackageCacheIndex, removed []CompiledPackageCacheEntry) []string {
+	used := map[string]bool{}
+
+	for _, entry := range index.Packages {
+		used[entry.Blob] = true
+	}
+
+	var blobs []string
+
+	for _, entry := range removed {
+		if !used[entry.Blob] {
+			used[entry.Blob] = true
+			blobs = append(blobs, entry.Blob)
+		}
+	}
+
+	return blobs
+}
+
+func (c compiledPackageCache) getBlob(entry CompiledPackageCacheEntry) ([]byte, error) {
+	contents, _, found, err := c.store.Get(c.key(entry.Blob))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting compiled package '%s/%s'", entry.Name, entry.Fingerprint)
+	}
+
+	if !found {
+		return nil, bosherr.Errorf("Compiled package '%s/%s' is listed in cache index but its archive is missing", entry.Name, entry.Fingerprint)
+	}
+
+	actualSHA1 := fmt.Sprintf("%x", sha1.Sum(contents))
+	if actualSHA1 != entry.SHA1 {
+		return nil, bosherr.Errorf("Expected compiled package '%s/%s' to have SHA1 '%s' but was '%s'", entry.Name, entry.Fingerprint, entry.SHA1, actualSHA1)
+	}
+
+	return contents, nil
+}
+
+func (c compiledPackageCache) loadIndex() (compiledPackageCacheIndex, string, error) {
+	var index compiledPackageCacheIndex
+
+	contents, version, found, err := c.store.Get(c.key(compiledPackageCacheIndexName))
+	if err != nil {
+		return index, "", bosherr.WrapError(err, "Reading compiled package cache index")
+	}
+
+	if !found {
+		return index, "", nil
+	}
+
+	err = json.Unmarshal(contents, &index)
+	if err != nil {
+		return index, "", bosherr.WrapError(err, "Unmarshalling compiled package cache index")
+	}
+
+	return index, version, nil
+}
+
+// updateIndex retries updates when index was modified by someone else
+// since cache may be shared by multiple concurrent deploys
+func (c compiledPackageCache) updateIndex(update func(*compiledPackageCacheIndex)) error {
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	for i := 0; i < maxCompiledPackageCacheIndexUpdates; i++ {
+		index, version, err := c.loadIndex()
+		if err != nil {
+			return err
+		}
+
+		update(&index)
+
+		contents, err := json.MarshalIndent(index, "", "  ")
+		if err != nil {
+			return bosherr.WrapError(err, "Marshalling compiled package cache index")
+		}
+
+		_, err = c.store.Put(c.key(compiledPackageCacheIndexName), contents, version)
+		if err == nil {
+			return nil
+		}
+
+		if _, ok := err.(biconfig.ObjectVersionMismatchError); !ok {
+			return bosherr.WrapError(err, "Saving compiled package cache index")
+		}
+
+		c.logger.Debug(c.logTag, "Compiled package cache index was modified concurrently, retrying")
+	}
+
+	return bosherr.Errorf("Saving compiled package cache index: index kept being modified concurrently")
+}
+
+func (c compiledPackageCache) key(name string) string {
+	if c.local {
+		return filepath.Join(c.location, filepath.FromSlash(name))
+	}
+
+	return path.Join(c.location, name)
+}
+
+type compiledPackageCacheEntrySorting []CompiledPackageCacheEntry
+
+func (s compiledPackageCacheEntrySorting) Len() int      { return len(s) }
+func (s compiledPackageCacheEntrySorting) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s compiledPackageCacheEntrySorting) Less(i, j int) bool {
+	if s[i].Name != s[j].Name {
+		return s[i].Name < s[j].Name
+	}
+	if s[i].StemcellOS != s[j].StemcellOS {
+		return s[i].StemcellOS < s[j].StemcellOS
+	}
+	if s[i].StemcellVersion != s[j].StemcellVersion {
+		return s[i].StemcellVersion < s[j].StemcellVersion
+	}
+	return s[i].Fingerprint < s[j].Fingerprint
+}
@@ -0,0 +1,236 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	boshrelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/state/pkg"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("CompiledPackageCache", func() {
+	var (
+		store       *fakebiconfig.FakeObjectStore
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		cache       CompiledPackageCache
+
+		stemcell Stemcell
+		pkg      *boshrelpkg.Package
+	)
+
+	BeforeEach(func() {
+		store = fakebiconfig.NewFakeObjectStore()
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC))
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		cache = NewCompiledPackageCache(store, "cache", false, fs, timeService, logger)
+
+		stemcell = Stemcell{OS: "ubuntu-trusty", Version: "3445.11"}
+
+		dependency := newPkg("dep-name", "dep-fp", nil)
+		pkg = newPkg("pkg-name", "pkg-fp", []string{"dep-name"})
+		pkg.AttachDependencies([]*boshrelpkg.Package{dependency})
+
+		Expect(fs.WriteFileString("/compiled-package.tgz", "fake-compiled-package")).To(Succeed())
+	})
+
+	readIndex := func() map[string][]map[string]interface{} {
+		var index map[string][]map[string]interface{}
+		Expect(json.Unmarshal(store.Objects["cache/index.json"], &index)).To(Succeed())
+		return index
+	}
+
+	Describe("Save/Find", func() {
+		It("saves compiled package archive and finds it for the same stemcell", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			Expect(store.Objects).To(HaveKeyWithValue(
+				"cache/packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61", []byte("fake-compiled-package")))
+
+			timeService.Increment(time.Hour)
+
+			localBlob, entry, found, err := cache.Find(pkg, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(fs.ReadFileString(localBlob.Path())).To(Equal("fake-compiled-package"))
+			Expect(entry).To(Equal(CompiledPackageCacheEntry{
+				Name:            "pkg-name",
+				Fingerprint:     "pkg-fp",
+				DependencyKey:   "dep-name:dep-fp",
+				StemcellOS:      "ubuntu-trusty",
+				StemcellVersion: "3445.11",
+				Blob:            "packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61",
+				SHA1:            "50931e8992c4260b3eb0f3a359ef2035db7e3a61",
+				Size:            21,
+				CreatedAt:       time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+				LastUsedAt:      time.Date(2017, time.January, 1, 1, 0, 0, 0, time.UTC),
+			}))
+
+			Expect(readIndex()["packages"][0]["last_used_at"]).To(Equal("2017-01-01T01:00:00Z"))
+		})
+
+		It("does not find package compiled for different stemcell", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			_, _, found, err := cache.Find(pkg, Stemcell{OS: "ubuntu-trusty", Version: "3445.12"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			_, _, found, err = cache.Find(pkg, Stemcell{OS: "ubuntu-xenial", Version: "3445.11"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not find package compiled with different dependencies", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			dependency := newPkg("dep-name", "dep-fp-2", nil)
+			otherPkg := newPkg("pkg-name", "pkg-fp", []string{"dep-name"})
+			otherPkg.AttachDependencies([]*boshrelpkg.Package{dependency})
+
+			_, _, found, err := cache.Find(otherPkg, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("replaces previously saved archive of the same package", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			Expect(fs.WriteFileString("/compiled-package.tgz", "fake-recompiled-package")).To(Succeed())
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			Expect(readIndex()["packages"]).To(HaveLen(1))
+			Expect(store.Objects).ToNot(HaveKey("cache/packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61"))
+
+			localBlob, _, found, err := cache.Find(pkg, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(fs.ReadFileString(localBlob.Path())).To(Equal("fake-recompiled-package"))
+		})
+
+		It("returns an error when archive does not match recorded SHA1", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			store.Objects["cache/packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61"] = []byte("corrupted")
+
+			_, _, _, err := cache.Find(pkg, stemcell)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected compiled package 'pkg-name/pkg-fp' to have SHA1 '50931e8992c4260b3eb0f3a359ef2035db7e3a61'"))
+		})
+
+		It("returns an error when index cannot be read", func() {
+			store.GetErr = errors.New("fake-get-err")
+
+			_, _, _, err := cache.Find(pkg, stemcell)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-err"))
+		})
+
+		It("retries index update when index was modified concurrently", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			otherStore := &concurrentObjectStore{FakeObjectStore: store, conflicts: 2}
+			otherCache := NewCompiledPackageCache(otherStore, "cache", false, fs, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+			otherPkg := newPkg("other-pkg-name", "other-pkg-fp", nil)
+			Expect(otherCache.Save(otherPkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			Expect(readIndex()["packages"]).To(HaveLen(2))
+		})
+	})
+
+	Describe("List/Delete", func() {
+		It("lists saved packages and deletes them with their archives", func() {
+			otherPkg := newPkg("a-pkg-name", "a-pkg-fp", nil)
+
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+			Expect(fs.WriteFileString("/compiled-package.tgz", "fake-other-compiled-package")).To(Succeed())
+			Expect(cache.Save(otherPkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Name).To(Equal("a-pkg-name"))
+			Expect(entries[1].Name).To(Equal("pkg-name"))
+
+			Expect(cache.Delete(entries[1:])).To(Succeed())
+
+			entries, err = cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name).To(Equal("a-pkg-name"))
+
+			Expect(store.Objects).ToNot(HaveKey("cache/packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61"))
+			Expect(store.Objects).To(HaveKey("cache/" + entries[0].Blob))
+		})
+
+		It("keeps archives that are still used by other packages", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+			Expect(cache.Save(pkg, Stemcell{OS: "ubuntu-trusty", Version: "3445.12"}, "/compiled-package.tgz")).To(Succeed())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			Expect(cache.Delete(entries[:1])).To(Succeed())
+
+			Expect(store.Objects).To(HaveKey("cache/packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61"))
+		})
+
+		It("returns empty list when cache does not have index yet", func() {
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+
+	Describe("Export", func() {
+		It("copies packages into directory that can be used as a local cache", func() {
+			Expect(cache.Save(pkg, stemcell, "/compiled-package.tgz")).To(Succeed())
+
+			entries, err := cache.List()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cache.Export(entries, "/export")).To(Succeed())
+
+			Expect(fs.ReadFileString("/export/packages/pkg-name/50931e8992c4260b3eb0f3a359ef2035db7e3a61")).To(Equal("fake-compiled-package"))
+
+			localCache := NewCompiledPackageCache(biconfig.NewFileObjectStore(fs), "/export", true, fs, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+			exportedEntries, err := localCache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exportedEntries).To(Equal(entries))
+
+			_, _, found, err := localCache.Find(pkg, stemcell)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+		})
+	})
+})
+
+// concurrentObjectStore simulates other writers updating index right before each put
+type concurrentObjectStore struct {
+	*fakebiconfig.FakeObjectStore
+	conflicts int
+}
+
+func (s *concurrentObjectStore) Put(key string, contents []byte, version string) (string, error) {
+	if s.conflicts > 0 && len(version) > 0 {
+		s.conflicts--
+		s.Versions[key] = "modified-by-someone-else"
+	}
+
+	return s.FakeObjectStore.Put(key, contents, version)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -22,6 +23,9 @@ type CompiledPackageRepo interface {
 
 type compiledPackageRepo struct {
 	index biindex.Index
+
+	// packages may be compiled concurrently but indexes are not safe for concurrent use
+	lock sync.RWMutex
 }
 
 func NewCompiledPackageRepo(index biindex.Index) CompiledPackageRepo {
@@ -29,6 +33,9 @@ func NewCompiledPackageRepo(index biindex.Index) CompiledPackageRepo {
 }
 
 func (cpr *compiledPackageRepo) Save(pkg birelpkg.Compilable, record CompiledPackageRecord) error {
+	cpr.lock.Lock()
+	defer cpr.lock.Unlock()
+
 	err := cpr.index.Save(cpr.pkgKey(pkg), record)
 
 	if err != nil {
@@ -41,6 +48,9 @@ func (cpr *compiledPackageRepo) Save(pkg birelpkg.Compilable, record CompiledPac
 func (cpr *compiledPackageRepo) Find(pkg birelpkg.Compilable) (CompiledPackageRecord, bool, error) {
 	var record CompiledPackageRecord
 
+	cpr.lock.RLock()
+	defer cpr.lock.RUnlock()
+
 	err := cpr.index.Find(cpr.pkgKey(pkg), &record)
 	if err != nil {
 		if err == biindex.ErrNotFound {
@@ -61,15 +71,16 @@ type packageToCompiledPackageKey struct {
 	DependencyKey      string
 }
 
-func (cpr compiledPackageRepo) pkgKey(pkg birelpkg.Compilable) packageToCompiledPackageKey {
+func (cpr *compiledPackageRepo) pkgKey(pkg birelpkg.Compilable) packageToCompiledPackageKey {
 	return packageToCompiledPackageKey{
 		PackageName:        pkg.Name(),
 		PackageFingerprint: pkg.Fingerprint(),
-		DependencyKey:      cpr.convertToDependencyKey(ResolveDependencies(pkg)),
+		DependencyKey:      dependencyKey(ResolveDependencies(pkg)),
 	}
 }
 
-func (cpr compiledPackageRepo) convertToDependencyKey(packages []birelpkg.Compilable) string {
+// dependencyKey identifies exact versions of packages a package was compiled with
+func dependencyKey(packages []birelpkg.Compilable) string {
 	dependencyKeys := []string{}
 
 	for _, pkg := range packages {
@@ -1,9 +1,10 @@
 // Automatically generated by MockGen. DO NOT EDIT!
-// Source: github.com/cloudfoundry/bosh-cli/state/pkg (interfaces: Compiler,CompiledPackageRepo)
+// Source: github.com/cloudfoundry/bosh-cli/state/pkg (interfaces: Compiler,CompiledPackageRepo,CompiledPackageCache)
 
 package mocks
 
 import (
+	blobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	pkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	pkg0 "github.com/cloudfoundry/bosh-cli/state/pkg"
 	gomock "github.com/golang/mock/gomock"
@@ -84,3 +85,78 @@ func (_m *MockCompiledPackageRepo) Save(_param0 pkg.Compilable, _param1 pkg0.Com
 func (_mr *_MockCompiledPackageRepoRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Save", arg0, arg1)
 }
+
+// Mock of CompiledPackageCache interface
+type MockCompiledPackageCache struct {
+	ctrl     *gomock.Controller
+	recorder *_MockCompiledPackageCacheRecorder
+}
+
+// Recorder for MockCompiledPackageCache (not exported)
+type _MockCompiledPackageCacheRecorder struct {
+	mock *MockCompiledPackageCache
+}
+
+func NewMockCompiledPackageCache(ctrl *gomock.Controller) *MockCompiledPackageCache {
+	mock := &MockCompiledPackageCache{ctrl: ctrl}
+	mock.recorder = &_MockCompiledPackageCacheRecorder{mock}
+	return mock
+}
+
+func (_m *MockCompiledPackageCache) EXPECT() *_MockCompiledPackageCacheRecorder {
+	return _m.recorder
+}
+
+func (_m *MockCompiledPackageCache) Delete(_param0 []pkg0.CompiledPackageCacheEntry) error {
+	ret := _m.ctrl.Call(_m, "Delete", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCompiledPackageCacheRecorder) Delete(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Delete", arg0)
+}
+
+func (_m *MockCompiledPackageCache) Export(_param0 []pkg0.CompiledPackageCacheEntry, _param1 string) error {
+	ret := _m.ctrl.Call(_m, "Export", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCompiledPackageCacheRecorder) Export(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Export", arg0, arg1)
+}
+
+func (_m *MockCompiledPackageCache) Find(_param0 pkg.Compilable, _param1 pkg0.Stemcell) (blobstore.LocalBlob, pkg0.CompiledPackageCacheEntry, bool, error) {
+	ret := _m.ctrl.Call(_m, "Find", _param0, _param1)
+	ret0, _ := ret[0].(blobstore.LocalBlob)
+	ret1, _ := ret[1].(pkg0.CompiledPackageCacheEntry)
+	ret2, _ := ret[2].(bool)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+func (_mr *_MockCompiledPackageCacheRecorder) Find(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Find", arg0, arg1)
+}
+
+func (_m *MockCompiledPackageCache) List() ([]pkg0.CompiledPackageCacheEntry, error) {
+	ret := _m.ctrl.Call(_m, "List")
+	ret0, _ := ret[0].([]pkg0.CompiledPackageCacheEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockCompiledPackageCacheRecorder) List() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "List")
+}
+
+func (_m *MockCompiledPackageCache) Save(_param0 pkg.Compilable, _param1 pkg0.Stemcell, _param2 string) error {
+	ret := _m.ctrl.Call(_m, "Save", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockCompiledPackageCacheRecorder) Save(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Save", arg0, arg1, arg2)
+}
@@ -10,6 +10,7 @@ type CloudStemcell interface {
 	CID() string
 	Name() string
 	Version() string
+	OS() string
 	PromoteAsCurrent() error
 	Delete() error
 }
@@ -18,12 +19,16 @@ type cloudStemcell struct {
 	cid     string
 	name    string
 	version string
+	os      string
 	repo    biconfig.StemcellRepo
 	cloud   bicloud.Cloud
 }
 
+// NewCloudStemcell returns stemcell uploaded to the cloud. Operating system
+// is not kept in stemcell records so it is empty unless stemcell was extracted.
 func NewCloudStemcell(
 	stemcellRecord biconfig.StemcellRecord,
+	os string,
 	repo biconfig.StemcellRepo,
 	cloud bicloud.Cloud,
 ) CloudStemcell {
@@ -31,6 +36,7 @@ func NewCloudStemcell(
 		cid:     stemcellRecord.CID,
 		name:    stemcellRecord.Name,
 		version: stemcellRecord.Version,
+		os:      os,
 		repo:    repo,
 		cloud:   cloud,
 	}
@@ -48,6 +54,10 @@ func (s *cloudStemcell) Version() string {
 	return s.version
 }
 
+func (s *cloudStemcell) OS() string {
+	return s.os
+}
+
 func (s *cloudStemcell) PromoteAsCurrent() error {
 	stemcellRecord, found, err := s.repo.Find(s.name, s.version)
 	if err != nil {
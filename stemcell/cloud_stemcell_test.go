@@ -35,14 +35,14 @@ var _ = Describe("CloudStemcell", func() {
 		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, "/fake/path")
 		stemcellRepo = biconfig.NewStemcellRepo(deploymentStateService, fakeUUIDGenerator)
 		fakeCloud = fakebicloud.NewFakeCloud()
-		cloudStemcell = NewCloudStemcell(stemcellRecord, stemcellRepo, fakeCloud)
+		cloudStemcell = NewCloudStemcell(stemcellRecord, "", stemcellRepo, fakeCloud)
 	})
 
 	Describe("PromoteAsCurrent", func() {
 		Context("when stemcell is in the repo", func() {
 			BeforeEach(func() {
 				fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id"
-				_, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+				_, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -83,7 +83,7 @@ var _ = Describe("CloudStemcell", func() {
 		})
 
 		It("deletes stemcell from repo", func() {
-			_, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+			_, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid", 0)
 			Expect(err).ToNot(HaveOccurred())
 
 			err = cloudStemcell.Delete()
@@ -94,7 +94,7 @@ var _ = Describe("CloudStemcell", func() {
 
 		Context("when deleted stemcell is the current stemcell", func() {
 			BeforeEach(func() {
-				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = stemcellRepo.UpdateCurrent(stemcellRecord.ID)
@@ -130,7 +130,7 @@ var _ = Describe("CloudStemcell", func() {
 			})
 
 			BeforeEach(func() {
-				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = stemcellRepo.UpdateCurrent(stemcellRecord.ID)
@@ -37,7 +37,7 @@ func (m *manager) FindCurrent() ([]CloudStemcell, error) {
 	}
 
 	if found {
-		stemcell := NewCloudStemcell(stemcellRecord, m.repo, m.cloud)
+		stemcell := NewCloudStemcell(stemcellRecord, "", m.repo, m.cloud)
 		stemcells = append(stemcells, stemcell)
 	}
 
@@ -57,7 +57,7 @@ func (m *manager) Upload(extractedStemcell ExtractedStemcell, uploadStage biui.S
 		}
 
 		if found {
-			cloudStemcell = NewCloudStemcell(foundStemcellRecord, m.repo, m.cloud)
+			cloudStemcell = NewCloudStemcell(foundStemcellRecord, manifest.OS, m.repo, m.cloud)
 			return biui.NewSkipStageError(bosherr.Errorf("Found stemcell: %#v", foundStemcellRecord), "Stemcell already uploaded")
 		}
 
@@ -66,13 +66,13 @@ func (m *manager) Upload(extractedStemcell ExtractedStemcell, uploadStage biui.S
 			return bosherr.WrapErrorf(err, "creating stemcell (%s %s)", manifest.Name, manifest.Version)
 		}
 
-		stemcellRecord, err := m.repo.Save(manifest.Name, manifest.Version, cid)
+		stemcellRecord, err := m.repo.Save(manifest.Name, manifest.Version, cid, manifest.APIVersion)
 		if err != nil {
 			//TODO: delete stemcell from cloud when saving fails
 			return bosherr.WrapErrorf(err, "saving stemcell record in repo (cid=%s, stemcell=%s)", cid, extractedStemcell)
 		}
 
-		cloudStemcell = NewCloudStemcell(stemcellRecord, m.repo, m.cloud)
+		cloudStemcell = NewCloudStemcell(stemcellRecord, manifest.OS, m.repo, m.cloud)
 		return nil
 	})
 	if err != nil {
@@ -97,7 +97,7 @@ func (m *manager) FindUnused() ([]CloudStemcell, error) {
 
 	for _, stemcellRecord := range stemcellRecords {
 		if !found || stemcellRecord.ID != currentStemcellRecord.ID {
-			stemcell := NewCloudStemcell(stemcellRecord, m.repo, m.cloud)
+			stemcell := NewCloudStemcell(stemcellRecord, "", m.repo, m.cloud)
 			unusedStemcells = append(unusedStemcells, stemcell)
 		}
 	}
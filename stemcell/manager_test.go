@@ -50,8 +50,10 @@ var _ = Describe("Manager", func() {
 
 		expectedExtractedStemcell = NewExtractedStemcell(
 			Manifest{
-				Name:    "fake-stemcell-name",
-				Version: "fake-stemcell-version",
+				Name:       "fake-stemcell-name",
+				Version:    "fake-stemcell-version",
+				OS:         "fake-stemcell-os",
+				APIVersion: 2,
 				CloudProperties: biproperty.Map{
 					"fake-prop-key": "fake-prop-value",
 				},
@@ -71,11 +73,12 @@ var _ = Describe("Manager", func() {
 		BeforeEach(func() {
 			fakeCloud.CreateStemcellCID = "fake-stemcell-cid"
 			stemcellRecord := biconfig.StemcellRecord{
-				CID:     "fake-stemcell-cid",
-				Name:    "fake-stemcell-name",
-				Version: "fake-stemcell-version",
+				CID:        "fake-stemcell-cid",
+				Name:       "fake-stemcell-name",
+				Version:    "fake-stemcell-version",
+				APIVersion: 2,
 			}
-			expectedCloudStemcell = NewCloudStemcell(stemcellRecord, stemcellRepo, fakeCloud)
+			expectedCloudStemcell = NewCloudStemcell(stemcellRecord, "fake-stemcell-os", stemcellRepo, fakeCloud)
 		})
 
 		It("uploads the stemcell to the infrastructure and returns the cid", func() {
@@ -101,10 +104,11 @@ var _ = Describe("Manager", func() {
 			stemcellRecords, err := stemcellRepo.All()
 			Expect(stemcellRecords).To(Equal([]biconfig.StemcellRecord{
 				{
-					ID:      "fake-stemcell-id-1",
-					Name:    "fake-stemcell-name",
-					Version: "fake-stemcell-version",
-					CID:     "fake-stemcell-cid",
+					ID:         "fake-stemcell-id-1",
+					Name:       "fake-stemcell-name",
+					Version:    "fake-stemcell-version",
+					APIVersion: 2,
+					CID:        "fake-stemcell-cid",
 				},
 			}))
 		})
@@ -147,14 +151,14 @@ var _ = Describe("Manager", func() {
 
 			BeforeEach(func() {
 				var err error
-				foundStemcellRecord, err = stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-existing-cid")
+				foundStemcellRecord, err = stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-existing-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
 			It("returns the existing cloud stemcell", func() {
 				stemcell, err := manager.Upload(expectedExtractedStemcell, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
-				foundStemcell := NewCloudStemcell(foundStemcellRecord, stemcellRepo, fakeCloud)
+				foundStemcell := NewCloudStemcell(foundStemcellRecord, "fake-stemcell-os", stemcellRepo, fakeCloud)
 				Expect(stemcell).To(Equal(foundStemcell))
 			})
 
@@ -178,7 +182,7 @@ var _ = Describe("Manager", func() {
 	Describe("FindCurrent", func() {
 		Context("when stemcell already exists in stemcell repo", func() {
 			BeforeEach(func() {
-				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-existing-stemcell-cid")
+				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-existing-stemcell-cid", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				err = stemcellRepo.UpdateCurrent(stemcellRecord.ID)
@@ -223,20 +227,20 @@ var _ = Describe("Manager", func() {
 
 		BeforeEach(func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-1"
-			firstStemcellRecord, err := stemcellRepo.Save("fake-stemcell-name-1", "fake-stemcell-version-1", "fake-stemcell-cid-1")
+			firstStemcellRecord, err := stemcellRepo.Save("fake-stemcell-name-1", "fake-stemcell-version-1", "fake-stemcell-cid-1", 0)
 			Expect(err).ToNot(HaveOccurred())
-			firstStemcell = NewCloudStemcell(firstStemcellRecord, stemcellRepo, fakeCloud)
+			firstStemcell = NewCloudStemcell(firstStemcellRecord, "", stemcellRepo, fakeCloud)
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-2"
-			_, err = stemcellRepo.Save("fake-stemcell-name-2", "fake-stemcell-version-2", "fake-stemcell-cid-2")
+			_, err = stemcellRepo.Save("fake-stemcell-name-2", "fake-stemcell-version-2", "fake-stemcell-cid-2", 0)
 			Expect(err).ToNot(HaveOccurred())
 			err = stemcellRepo.UpdateCurrent("fake-stemcell-id-2")
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-3"
-			secondStemcellRecord, err := stemcellRepo.Save("fake-stemcell-name-3", "fake-stemcell-version-3", "fake-stemcell-cid-3")
+			secondStemcellRecord, err := stemcellRepo.Save("fake-stemcell-name-3", "fake-stemcell-version-3", "fake-stemcell-cid-3", 0)
 			Expect(err).ToNot(HaveOccurred())
-			secondStemcell = NewCloudStemcell(secondStemcellRecord, stemcellRepo, fakeCloud)
+			secondStemcell = NewCloudStemcell(secondStemcellRecord, "", stemcellRepo, fakeCloud)
 		})
 
 		It("returns unused stemcells", func() {
@@ -255,17 +259,17 @@ var _ = Describe("Manager", func() {
 		)
 		BeforeEach(func() {
 			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-1"
-			_, err := stemcellRepo.Save("fake-stemcell-name-1", "fake-stemcell-version-1", "fake-stemcell-cid-1")
+			_, err := stemcellRepo.Save("fake-stemcell-name-1", "fake-stemcell-version-1", "fake-stemcell-cid-1", 0)
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-2"
-			secondStemcellRecord, err = stemcellRepo.Save("fake-stemcell-name-2", "fake-stemcell-version-2", "fake-stemcell-cid-2")
+			secondStemcellRecord, err = stemcellRepo.Save("fake-stemcell-name-2", "fake-stemcell-version-2", "fake-stemcell-cid-2", 0)
 			Expect(err).ToNot(HaveOccurred())
 			err = stemcellRepo.UpdateCurrent(secondStemcellRecord.ID)
 			Expect(err).ToNot(HaveOccurred())
 
 			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-3"
-			_, err = stemcellRepo.Save("fake-stemcell-name-3", "fake-stemcell-version-3", "fake-stemcell-cid-3")
+			_, err = stemcellRepo.Save("fake-stemcell-name-3", "fake-stemcell-version-3", "fake-stemcell-cid-3", 0)
 			Expect(err).ToNot(HaveOccurred())
 		})
 
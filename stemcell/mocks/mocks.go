@@ -60,6 +60,16 @@ func (_mr *_MockCloudStemcellRecorder) Name() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Name")
 }
 
+func (_m *MockCloudStemcell) OS() string {
+	ret := _m.ctrl.Call(_m, "OS")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockCloudStemcellRecorder) OS() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "OS")
+}
+
 func (_m *MockCloudStemcell) PromoteAsCurrent() error {
 	ret := _m.ctrl.Call(_m, "PromoteAsCurrent")
 	ret0, _ := ret[0].(error)
@@ -30,6 +30,7 @@ version: '2690'
 operating_system: ubuntu-trusty
 sha1: sha
 bosh_protocol: 1
+api_version: 2
 cloud_properties:
   infrastructure: aws
   ami:
@@ -55,6 +56,7 @@ cloud_properties:
 				OS:           "ubuntu-trusty",
 				SHA1:         "sha",
 				BoshProtocol: "1",
+				APIVersion:   2,
 				CloudProperties: biproperty.Map{
 					"infrastructure": "aws",
 					"ami": biproperty.Map{
@@ -4,6 +4,7 @@ type FakeCloudStemcell struct {
 	cid     string
 	name    string
 	version string
+	os      string
 
 	PromoteAsCurrentCalledTimes int
 	PromoteAsCurrentErr         error
@@ -32,6 +33,10 @@ func (s *FakeCloudStemcell) Version() string {
 	return s.version
 }
 
+func (s *FakeCloudStemcell) OS() string {
+	return s.os
+}
+
 func (s *FakeCloudStemcell) PromoteAsCurrent() error {
 	s.PromoteAsCurrentCalledTimes++
 	return s.PromoteAsCurrentErr
@@ -0,0 +1,97 @@
+package erbrenderer_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Each fixture in assets/conformance is rendered by both renderers against
+// shared context.json and compared with expected.txt
+var _ = Describe("ERB renderer conformance", func() {
+	var (
+		fs     boshsys.FileSystem
+		runner boshsys.CmdRunner
+		logger boshlog.Logger
+		tmpDir string
+
+		fixtureNames []string
+		contextBytes []byte
+	)
+
+	fixturesDir := filepath.Join("assets", "conformance")
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		runner = boshsys.NewExecCmdRunner(logger)
+
+		var err error
+
+		tmpDir, err = ioutil.TempDir("", "erb-conformance")
+		Expect(err).ToNot(HaveOccurred())
+
+		fixtures, err := ioutil.ReadDir(fixturesDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		fixtureNames = nil
+
+		for _, fixture := range fixtures {
+			if fixture.IsDir() {
+				fixtureNames = append(fixtureNames, fixture.Name())
+			}
+		}
+
+		Expect(fixtureNames).ToNot(BeEmpty())
+
+		contextBytes, err = ioutil.ReadFile(filepath.Join(fixturesDir, "context.json"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	renderers := map[string]func() (ERBRenderer, bool){
+		"go": func() (ERBRenderer, bool) {
+			return NewGoERBRenderer(fs, logger), true
+		},
+		"ruby": func() (ERBRenderer, bool) {
+			return NewERBRenderer(fs, runner, logger), runner.CommandExists("ruby")
+		},
+	}
+
+	for rendererName, rendererFunc := range renderers {
+		rendererName, rendererFunc := rendererName, rendererFunc
+
+		It("renders all fixtures with "+rendererName+" renderer", func() {
+			renderer, available := rendererFunc()
+			if !available {
+				Skip(rendererName + " renderer is not available")
+			}
+
+			for _, fixtureName := range fixtureNames {
+				fixtureDir := filepath.Join(fixturesDir, fixtureName)
+
+				expected, err := ioutil.ReadFile(filepath.Join(fixtureDir, "expected.txt"))
+				Expect(err).ToNot(HaveOccurred(), "fixture '%s'", fixtureName)
+
+				dstPath := filepath.Join(tmpDir, fixtureName)
+
+				err = renderer.Render(filepath.Join(fixtureDir, "template.erb"), dstPath, json.RawMessage(contextBytes))
+				Expect(err).ToNot(HaveOccurred(), "fixture '%s'", fixtureName)
+
+				rendered, err := ioutil.ReadFile(dstPath)
+				Expect(err).ToNot(HaveOccurred(), "fixture '%s'", fixtureName)
+				Expect(string(rendered)).To(Equal(string(expected)), "fixture '%s'", fixtureName)
+			}
+		})
+	}
+})
@@ -0,0 +1,1283 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+func (i *rbInterpreter) eachItem(items []interface{}, block *rbBlock) (interface{}, error) {
+	for _, item := range items {
+		if _, err := block.Call(item); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func rbPair(key, val interface{}) *rbArray {
+	return newRbArray(key, val)
+}
+
+func rbHashPairs(hash *rbHash) []interface{} {
+	var pairs []interface{}
+	for _, key := range hash.keys {
+		pairs = append(pairs, rbPair(key, hash.vals[key]))
+	}
+	return pairs
+}
+
+// rbEnumeratorMethods return enumerator when called without a block and arguments
+var rbEnumeratorMethods = map[string]bool{
+	"each": true, "each_pair": true, "each_with_index": true, "map": true, "collect": true,
+	"flat_map": true, "collect_concat": true, "select": true, "filter": true, "find_all": true,
+	"reject": true,
+}
+
+// rbYielded packs values yielded by iteration method into single value
+func rbYielded(args []interface{}) interface{} {
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	default:
+		return newRbArray(args...)
+	}
+}
+
+func (i *rbInterpreter) iterate(recv *rbEnumerator, block *rbBlock) (interface{}, error) {
+	return i.callMethod(recv.recv, recv.method, recv.args, block)
+}
+
+func (i *rbInterpreter) callEnumeratorMethod(recv *rbEnumerator, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "each":
+		if block == nil {
+			return recv, true, nil
+		}
+		val, err := i.iterate(recv, block)
+		return val, true, err
+	case "with_index", "each_with_index":
+		if block == nil {
+			return &rbEnumerator{recv: recv, method: name, args: args}, true, nil
+		}
+		maxArgs := 0
+		if name == "with_index" {
+			maxArgs = 1
+		}
+		if err := rbCheckArgs(args, 0, maxArgs); err != nil {
+			return nil, true, err
+		}
+		idx := 0
+		if len(args) == 1 && args[0] != nil {
+			offset, err := rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			idx = offset
+		}
+		// Result of underlying method is returned, e.g. mapped array for map.with_index
+		val, err := i.iterate(recv, &rbBlock{native: func(yielded []interface{}) (interface{}, error) {
+			val, err := block.Call(rbYielded(yielded), idx)
+			idx++
+			return val, err
+		}})
+		return val, true, err
+	}
+
+	// Remaining methods behave the same as on array of yielded values
+	var items []interface{}
+	_, err := i.iterate(recv, &rbBlock{native: func(yielded []interface{}) (interface{}, error) {
+		items = append(items, rbYielded(yielded))
+		return nil, nil
+	}})
+	if err != nil {
+		return nil, true, err
+	}
+
+	return i.callArrayMethod(newRbArray(items...), name, args, block)
+}
+
+func (i *rbInterpreter) callRangeMethod(recv *rbRange, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "first", "begin", "min":
+		if len(args) == 0 {
+			return recv.from, true, nil
+		}
+	case "last", "end", "max":
+		if len(args) == 0 {
+			if name == "max" && recv.exclusive {
+				return recv.to - 1, true, nil
+			}
+			return recv.to, true, nil
+		}
+	case "include?", "member?", "cover?", "===":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		f, ok := rbToFloat(args[0])
+		if !ok {
+			return false, true, nil
+		}
+		if recv.exclusive {
+			return f >= float64(recv.from) && f < float64(recv.to), true, nil
+		}
+		return f >= float64(recv.from) && f <= float64(recv.to), true, nil
+	case "exclude_end?":
+		return recv.exclusive, true, nil
+	case "step":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		step, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if step <= 0 {
+			return nil, true, newRbError("ArgumentError", "step can't be negative or zero")
+		}
+		arr := newRbArray()
+		for idx, item := range recv.Items() {
+			if idx%step == 0 {
+				arr.items = append(arr.items, item)
+			}
+		}
+		if block == nil {
+			return arr, true, nil
+		}
+		_, err = i.eachItem(arr.items, block)
+		return recv, true, err
+	}
+
+	// Remaining methods behave the same as on array of range items
+	val, handled, err := i.callArrayMethod(newRbArray(recv.Items()...), name, args, block)
+	if handled && name == "each" {
+		return recv, handled, err
+	}
+
+	return val, handled, err
+}
+
+func (i *rbInterpreter) callHashMethod(recv *rbHash, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "[]":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, _ := recv.Get(args[0])
+		return val, true, nil
+	case "[]=", "store":
+		if err := rbCheckArgs(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		recv.Set(args[0], args[1])
+		return args[1], true, nil
+	case "fetch":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		if val, found := recv.Get(args[0]); found {
+			return val, true, nil
+		}
+		if len(args) > 1 {
+			return args[1], true, nil
+		}
+		if block != nil {
+			val, err := block.Call(args[0])
+			return val, true, err
+		}
+		return nil, true, newRbError("KeyError", fmt.Sprintf("key not found: %s", rbInspect(args[0])))
+	case "dig":
+		if err := rbCheckArgs(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		val, _ := recv.Get(args[0])
+		if len(args) == 1 || val == nil {
+			return val, true, nil
+		}
+		nested, err := i.callMethod(val, "dig", args[1:], nil)
+		return nested, true, err
+	case "key?", "has_key?", "include?", "member?":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		_, found := recv.Get(args[0])
+		return found, true, nil
+	case "value?", "has_value?":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, key := range recv.keys {
+			if rbEqual(recv.vals[key], args[0]) {
+				return true, true, nil
+			}
+		}
+		return false, true, nil
+	case "key":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, key := range recv.keys {
+			if rbEqual(recv.vals[key], args[0]) {
+				return key, true, nil
+			}
+		}
+		return nil, true, nil
+	case "keys":
+		return newRbArray(append([]interface{}{}, recv.keys...)...), true, nil
+	case "values":
+		arr := newRbArray()
+		for _, key := range recv.keys {
+			arr.items = append(arr.items, recv.vals[key])
+		}
+		return arr, true, nil
+	case "values_at":
+		arr := newRbArray()
+		for _, arg := range args {
+			val, _ := recv.Get(arg)
+			arr.items = append(arr.items, val)
+		}
+		return arr, true, nil
+	case "length", "size":
+		return recv.Len(), true, nil
+	case "empty?":
+		return recv.Len() == 0, true, nil
+	case "each", "each_pair":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		_, err := i.eachItem(rbHashPairs(recv), block)
+		return recv, true, err
+	case "each_key", "each_value":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		for _, key := range append([]interface{}{}, recv.keys...) {
+			item := key
+			if name == "each_value" {
+				item = recv.vals[key]
+			}
+			if _, err := block.Call(item); err != nil {
+				return nil, true, err
+			}
+		}
+		return recv, true, nil
+	case "select", "filter", "reject", "keep_if", "delete_if":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		result := newRbHash()
+		for _, key := range recv.keys {
+			val, err := block.Call(key, recv.vals[key])
+			if err != nil {
+				return nil, true, err
+			}
+			keep := rbTruthy(val)
+			if name == "reject" || name == "delete_if" {
+				keep = !keep
+			}
+			if keep {
+				result.Set(key, recv.vals[key])
+			}
+		}
+		if name == "keep_if" || name == "delete_if" {
+			*recv = *result
+			return recv, true, nil
+		}
+		return result, true, nil
+	case "merge", "update", "merge!":
+		result := recv
+		if name == "merge" {
+			result = recv.Copy()
+		}
+		for _, arg := range args {
+			other, ok := arg.(*rbHash)
+			if !ok {
+				return nil, true, rbTypeError(arg, "Hash")
+			}
+			for _, key := range other.keys {
+				val := other.vals[key]
+				if existing, found := result.Get(key); found && block != nil {
+					merged, err := block.Call(key, existing, val)
+					if err != nil {
+						return nil, true, err
+					}
+					val = merged
+				}
+				result.Set(key, val)
+			}
+		}
+		return result, true, nil
+	case "delete":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, _ := recv.Delete(args[0])
+		return val, true, nil
+	case "to_h":
+		if block == nil {
+			return recv, true, nil
+		}
+	case "to_a":
+		return newRbArray(rbHashPairs(recv)...), true, nil
+	case "invert":
+		result := newRbHash()
+		for _, key := range recv.keys {
+			result.Set(recv.vals[key], key)
+		}
+		return result, true, nil
+	case "transform_values", "transform_keys":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		result := newRbHash()
+		for _, key := range recv.keys {
+			if name == "transform_values" {
+				val, err := block.Call(recv.vals[key])
+				if err != nil {
+					return nil, true, err
+				}
+				result.Set(key, val)
+			} else {
+				newKey, err := block.Call(key)
+				if err != nil {
+					return nil, true, err
+				}
+				result.Set(newKey, recv.vals[key])
+			}
+		}
+		return result, true, nil
+	case "sort_by", "min_by", "max_by", "sort", "map", "collect", "flat_map", "each_with_index",
+		"each_with_object", "inject", "reduce", "find", "detect", "any?", "all?", "none?",
+		"count", "partition", "group_by", "sum", "first", "to_set", "each_slice", "find_all", "zip", "uniq":
+		return i.callArrayMethod(newRbArray(rbHashPairs(recv)...), name, args, block)
+	case "clear":
+		*recv = *newRbHash()
+		return recv, true, nil
+	}
+
+	if name == "to_h" {
+		return i.callArrayMethod(newRbArray(rbHashPairs(recv)...), name, args, block)
+	}
+
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callArrayMethod(recv *rbArray, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	items := recv.items
+
+	switch name {
+	case "[]", "slice":
+		val, err := rbArrayIndex(items, args)
+		return val, true, err
+	case "[]=":
+		if err := rbCheckArgs(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		idx, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items)
+		}
+		if idx < 0 {
+			return nil, true, newRbError("IndexError", fmt.Sprintf("index %d too small for array", idx-len(items)))
+		}
+		for len(recv.items) <= idx {
+			recv.items = append(recv.items, nil)
+		}
+		recv.items[idx] = args[1]
+		return args[1], true, nil
+	case "dig":
+		if err := rbCheckArgs(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		val, err := rbArrayIndex(items, args[:1])
+		if err != nil || len(args) == 1 || val == nil {
+			return val, true, err
+		}
+		nested, err := i.callMethod(val, "dig", args[1:], nil)
+		return nested, true, err
+	case "at":
+		val, err := rbArrayIndex(items, args)
+		return val, true, err
+	case "fetch":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		idx, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items)
+		}
+		if idx >= 0 && idx < len(items) {
+			return items[idx], true, nil
+		}
+		if len(args) > 1 {
+			return args[1], true, nil
+		}
+		return nil, true, newRbError("IndexError", fmt.Sprintf("index %d outside of array bounds", idx))
+	case "first", "last", "take", "drop":
+		if len(args) == 0 && (name == "first" || name == "last") {
+			if len(items) == 0 {
+				return nil, true, nil
+			}
+			if name == "first" {
+				return items[0], true, nil
+			}
+			return items[len(items)-1], true, nil
+		}
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		n, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if n < 0 {
+			return nil, true, newRbError("ArgumentError", "negative array size")
+		}
+		if n > len(items) {
+			n = len(items)
+		}
+		switch name {
+		case "first", "take":
+			return newRbArray(append([]interface{}{}, items[:n]...)...), true, nil
+		case "last":
+			return newRbArray(append([]interface{}{}, items[len(items)-n:]...)...), true, nil
+		default:
+			return newRbArray(append([]interface{}{}, items[n:]...)...), true, nil
+		}
+	case "length", "size":
+		return len(items), true, nil
+	case "empty?":
+		return len(items) == 0, true, nil
+	case "count":
+		if len(args) == 0 && block == nil {
+			return len(items), true, nil
+		}
+		count := 0
+		for _, item := range items {
+			matched, err := i.matchItem(item, args, block)
+			if err != nil {
+				return nil, true, err
+			}
+			if matched {
+				count++
+			}
+		}
+		return count, true, nil
+	case "include?", "member?":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		for _, item := range items {
+			if rbEqual(item, args[0]) {
+				return true, true, nil
+			}
+		}
+		return false, true, nil
+	case "any?", "all?", "none?", "one?":
+		count := 0
+		for _, item := range items {
+			var (
+				matched bool
+				err     error
+			)
+			if len(args) == 0 && block == nil {
+				matched = rbTruthy(item)
+			} else {
+				matched, err = i.matchItem(item, args, block)
+				if err != nil {
+					return nil, true, err
+				}
+			}
+			if matched {
+				count++
+			}
+		}
+		switch name {
+		case "any?":
+			return count > 0, true, nil
+		case "all?":
+			return count == len(items), true, nil
+		case "none?":
+			return count == 0, true, nil
+		default:
+			return count == 1, true, nil
+		}
+	case "index", "find_index", "rindex":
+		indices := make([]int, len(items))
+		for idx := range items {
+			indices[idx] = idx
+		}
+		if name == "rindex" {
+			for left, right := 0, len(indices)-1; left < right; left, right = left+1, right-1 {
+				indices[left], indices[right] = indices[right], indices[left]
+			}
+		}
+		for _, idx := range indices {
+			var matched bool
+			if len(args) > 0 {
+				matched = rbEqual(items[idx], args[0])
+			} else if block != nil {
+				val, err := block.Call(items[idx])
+				if err != nil {
+					return nil, true, err
+				}
+				matched = rbTruthy(val)
+			}
+			if matched {
+				return idx, true, nil
+			}
+		}
+		return nil, true, nil
+	case "join":
+		sep := ""
+		if len(args) > 0 && args[0] != nil {
+			var err error
+			sep, err = rbArgString(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		return rbJoin(items, sep), true, nil
+	case "each":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		_, err := i.eachItem(items, block)
+		return recv, true, err
+	case "each_with_index", "each_with_object", "map_with_index":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		var memo interface{}
+		if name == "each_with_object" {
+			if err := rbCheckArgs(args, 1, 1); err != nil {
+				return nil, true, err
+			}
+			memo = args[0]
+		}
+		for idx, item := range items {
+			second := interface{}(idx)
+			if name == "each_with_object" {
+				second = memo
+			}
+			if _, err := block.Call(item, second); err != nil {
+				return nil, true, err
+			}
+		}
+		if name == "each_with_object" {
+			return memo, true, nil
+		}
+		return recv, true, nil
+	case "each_slice", "each_cons":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		size, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if size <= 0 {
+			return nil, true, newRbError("ArgumentError", "invalid size")
+		}
+		groups := newRbArray()
+		if name == "each_slice" {
+			for start := 0; start < len(items); start += size {
+				end := start + size
+				if end > len(items) {
+					end = len(items)
+				}
+				groups.items = append(groups.items, newRbArray(append([]interface{}{}, items[start:end]...)...))
+			}
+		} else {
+			for start := 0; start+size <= len(items); start++ {
+				groups.items = append(groups.items, newRbArray(append([]interface{}{}, items[start:start+size]...)...))
+			}
+		}
+		if block == nil {
+			return groups, true, nil
+		}
+		_, err = i.eachItem(groups.items, block)
+		return recv, true, err
+	case "map", "collect", "flat_map", "collect_concat", "map!", "collect!":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		result := newRbArray()
+		for _, item := range items {
+			val, err := block.Call(item)
+			if err != nil {
+				return nil, true, err
+			}
+			if arr, ok := val.(*rbArray); ok && (name == "flat_map" || name == "collect_concat") {
+				result.items = append(result.items, arr.items...)
+			} else {
+				result.items = append(result.items, val)
+			}
+		}
+		if strings.HasSuffix(name, "!") {
+			recv.items = result.items
+			return recv, true, nil
+		}
+		return result, true, nil
+	case "select", "filter", "find_all", "reject", "select!", "reject!", "keep_if", "delete_if", "partition":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		selected, rejected := newRbArray(), newRbArray()
+		for _, item := range items {
+			val, err := block.Call(item)
+			if err != nil {
+				return nil, true, err
+			}
+			if rbTruthy(val) {
+				selected.items = append(selected.items, item)
+			} else {
+				rejected.items = append(rejected.items, item)
+			}
+		}
+		switch name {
+		case "partition":
+			return newRbArray(selected, rejected), true, nil
+		case "select!", "keep_if":
+			recv.items = selected.items
+			return recv, true, nil
+		case "reject!", "delete_if":
+			recv.items = rejected.items
+			return recv, true, nil
+		case "reject":
+			return rejected, true, nil
+		default:
+			return selected, true, nil
+		}
+	case "find", "detect":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		for _, item := range items {
+			val, err := block.Call(item)
+			if err != nil {
+				return nil, true, err
+			}
+			if rbTruthy(val) {
+				return item, true, nil
+			}
+		}
+		return nil, true, nil
+	case "take_while", "drop_while":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		idx := 0
+		for ; idx < len(items); idx++ {
+			val, err := block.Call(items[idx])
+			if err != nil {
+				return nil, true, err
+			}
+			if !rbTruthy(val) {
+				break
+			}
+		}
+		if name == "take_while" {
+			return newRbArray(append([]interface{}{}, items[:idx]...)...), true, nil
+		}
+		return newRbArray(append([]interface{}{}, items[idx:]...)...), true, nil
+	case "group_by":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		result := newRbHash()
+		for _, item := range items {
+			key, err := block.Call(item)
+			if err != nil {
+				return nil, true, err
+			}
+			group, found := result.Get(key)
+			if !found {
+				group = newRbArray()
+				result.Set(key, group)
+			}
+			group.(*rbArray).items = append(group.(*rbArray).items, item)
+		}
+		return result, true, nil
+	case "inject", "reduce", "sum":
+		return i.inject(items, name, args, block)
+	case "min", "max", "min_by", "max_by", "sort", "sort_by", "sort!", "sort_by!":
+		return i.sortItems(recv, name, args, block)
+	case "uniq", "uniq!":
+		result := newRbArray()
+		var seen []interface{}
+		for _, item := range items {
+			key := item
+			if block != nil {
+				var err error
+				key, err = block.Call(item)
+				if err != nil {
+					return nil, true, err
+				}
+			}
+			duplicate := false
+			for _, seenKey := range seen {
+				if rbEqual(seenKey, key) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				seen = append(seen, key)
+				result.items = append(result.items, item)
+			}
+		}
+		if name == "uniq!" {
+			recv.items = result.items
+			return recv, true, nil
+		}
+		return result, true, nil
+	case "compact", "compact!":
+		result := newRbArray()
+		for _, item := range items {
+			if item != nil {
+				result.items = append(result.items, item)
+			}
+		}
+		if name == "compact!" {
+			recv.items = result.items
+			return recv, true, nil
+		}
+		return result, true, nil
+	case "flatten", "flatten!":
+		depth := -1
+		if len(args) > 0 {
+			var err error
+			depth, err = rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		result := newRbArray(rbFlatten(items, depth)...)
+		if name == "flatten!" {
+			recv.items = result.items
+			return recv, true, nil
+		}
+		return result, true, nil
+	case "reverse", "reverse!":
+		result := newRbArray()
+		for idx := len(items) - 1; idx >= 0; idx-- {
+			result.items = append(result.items, items[idx])
+		}
+		if name == "reverse!" {
+			recv.items = result.items
+			return recv, true, nil
+		}
+		return result, true, nil
+	case "rotate":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			n, err = rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		if len(items) == 0 {
+			return newRbArray(), true, nil
+		}
+		n = ((n % len(items)) + len(items)) % len(items)
+		return newRbArray(append(append([]interface{}{}, items[n:]...), items[:n]...)...), true, nil
+	case "push", "append", "<<":
+		if name == "<<" {
+			if err := rbCheckArgs(args, 1, 1); err != nil {
+				return nil, true, err
+			}
+		}
+		recv.items = append(recv.items, args...)
+		return recv, true, nil
+	case "unshift", "prepend":
+		recv.items = append(append([]interface{}{}, args...), recv.items...)
+		return recv, true, nil
+	case "insert":
+		if err := rbCheckArgs(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		idx, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items) + 1
+		}
+		for len(recv.items) < idx {
+			recv.items = append(recv.items, nil)
+		}
+		recv.items = append(append(append([]interface{}{}, recv.items[:idx]...), args[1:]...), recv.items[idx:]...)
+		return recv, true, nil
+	case "pop", "shift":
+		if len(items) == 0 {
+			return nil, true, nil
+		}
+		if name == "pop" {
+			recv.items = items[:len(items)-1]
+			return items[len(items)-1], true, nil
+		}
+		recv.items = items[1:]
+		return items[0], true, nil
+	case "concat":
+		for _, arg := range args {
+			other, ok := arg.(*rbArray)
+			if !ok {
+				return nil, true, rbTypeError(arg, "Array")
+			}
+			recv.items = append(recv.items, other.items...)
+		}
+		return recv, true, nil
+	case "delete":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		var (
+			kept  []interface{}
+			found interface{}
+		)
+		for _, item := range items {
+			if rbEqual(item, args[0]) {
+				found = item
+			} else {
+				kept = append(kept, item)
+			}
+		}
+		recv.items = kept
+		return found, true, nil
+	case "delete_at":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		idx, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if idx < 0 {
+			idx += len(items)
+		}
+		if idx < 0 || idx >= len(items) {
+			return nil, true, nil
+		}
+		removed := items[idx]
+		recv.items = append(append([]interface{}{}, items[:idx]...), items[idx+1:]...)
+		return removed, true, nil
+	case "clear":
+		recv.items = nil
+		return recv, true, nil
+	case "+", "-", "&", "|":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		other, ok := args[0].(*rbArray)
+		if !ok {
+			return nil, true, rbTypeError(args[0], "Array")
+		}
+		return rbArraySetOp(items, name, other.items), true, nil
+	case "*":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		if sep, ok := args[0].(string); ok {
+			return rbJoin(items, sep), true, nil
+		}
+		n, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		result := newRbArray()
+		for idx := 0; idx < n; idx++ {
+			result.items = append(result.items, items...)
+		}
+		return result, true, nil
+	case "<=>":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		result, err := rbCompare(recv, args[0])
+		if err != nil {
+			return nil, true, nil
+		}
+		return result, true, nil
+	case "to_a", "entries", "to_ary", "to_set":
+		return recv, true, nil
+	case "to_h":
+		result := newRbHash()
+		for _, item := range items {
+			if block != nil {
+				var err error
+				item, err = block.Call(item)
+				if err != nil {
+					return nil, true, err
+				}
+			}
+			pair, ok := item.(*rbArray)
+			if !ok || len(pair.items) != 2 {
+				return nil, true, newRbError("TypeError", fmt.Sprintf("wrong element type %s (expected array)", rbClassName(item)))
+			}
+			result.Set(pair.items[0], pair.items[1])
+		}
+		return result, true, nil
+	case "zip":
+		result := newRbArray()
+		for idx, item := range items {
+			tuple := newRbArray(item)
+			for _, arg := range args {
+				other, ok := arg.(*rbArray)
+				if !ok {
+					return nil, true, rbTypeError(arg, "Array")
+				}
+				if idx < len(other.items) {
+					tuple.items = append(tuple.items, other.items[idx])
+				} else {
+					tuple.items = append(tuple.items, nil)
+				}
+			}
+			result.items = append(result.items, tuple)
+		}
+		return result, true, nil
+	case "transpose":
+		result := newRbArray()
+		for rowIdx, row := range items {
+			rowArr, ok := row.(*rbArray)
+			if !ok {
+				return nil, true, rbTypeError(row, "Array")
+			}
+			for colIdx, val := range rowArr.items {
+				if rowIdx == 0 {
+					result.items = append(result.items, newRbArray())
+				}
+				if colIdx >= len(result.items) {
+					return nil, true, newRbError("IndexError", "element size differs")
+				}
+				col := result.items[colIdx].(*rbArray)
+				col.items = append(col.items, val)
+			}
+		}
+		return result, true, nil
+	case "values_at":
+		result := newRbArray()
+		for _, arg := range args {
+			val, err := rbArrayIndex(items, []interface{}{arg})
+			if err != nil {
+				return nil, true, err
+			}
+			result.items = append(result.items, val)
+		}
+		return result, true, nil
+	case "tally":
+		result := newRbHash()
+		for _, item := range items {
+			count, _ := result.Get(item)
+			if count == nil {
+				count = 0
+			}
+			result.Set(item, count.(int)+1)
+		}
+		return result, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) matchItem(item interface{}, args []interface{}, block *rbBlock) (bool, error) {
+	if len(args) > 0 {
+		val, err := i.callMethod(args[0], "===", []interface{}{item}, nil)
+		if err != nil {
+			return false, err
+		}
+		return rbTruthy(val), nil
+	}
+
+	val, err := block.Call(item)
+	if err != nil {
+		return false, err
+	}
+
+	return rbTruthy(val), nil
+}
+
+func (i *rbInterpreter) inject(items []interface{}, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	var (
+		acc     interface{}
+		hasAcc  bool
+		opName  string
+		useSum  = name == "sum"
+		initial = args
+	)
+
+	if useSum {
+		acc, hasAcc = 0, true
+		if len(args) > 0 {
+			acc = args[0]
+		}
+	} else {
+		if len(initial) > 0 {
+			if sym, ok := initial[len(initial)-1].(rbSymbol); ok && block == nil {
+				opName = string(sym)
+				initial = initial[:len(initial)-1]
+			}
+		}
+		if len(initial) > 0 {
+			acc, hasAcc = initial[0], true
+		}
+		if block == nil && len(opName) == 0 {
+			return nil, true, rbNoBlockError()
+		}
+	}
+
+	for _, item := range items {
+		if useSum && block != nil {
+			var err error
+			item, err = block.Call(item)
+			if err != nil {
+				return nil, true, err
+			}
+		}
+
+		if !hasAcc {
+			acc, hasAcc = item, true
+			continue
+		}
+
+		var err error
+
+		switch {
+		case useSum:
+			acc, err = i.callMethod(acc, "+", []interface{}{item}, nil)
+		case len(opName) > 0:
+			acc, err = i.callMethod(acc, opName, []interface{}{item}, nil)
+		default:
+			acc, err = block.Call(acc, item)
+		}
+
+		if err != nil {
+			return nil, true, err
+		}
+	}
+
+	return acc, true, nil
+}
+
+func (i *rbInterpreter) sortItems(recv *rbArray, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	type keyed struct {
+		key  interface{}
+		item interface{}
+	}
+
+	var keyedItems []interface{}
+
+	for _, item := range recv.items {
+		key := item
+		if strings.HasSuffix(strings.TrimSuffix(name, "!"), "_by") {
+			if block == nil {
+				return nil, true, rbNoBlockError()
+			}
+			var err error
+			key, err = block.Call(item)
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		keyedItems = append(keyedItems, keyed{key: key, item: item})
+	}
+
+	compare := func(a, b interface{}) (int, error) {
+		ka, kb := a.(keyed).key, b.(keyed).key
+
+		if block != nil && !strings.HasSuffix(strings.TrimSuffix(name, "!"), "_by") {
+			val, err := block.Call(ka, kb)
+			if err != nil {
+				return 0, err
+			}
+			result, ok := val.(int)
+			if !ok {
+				return 0, newRbError("ArgumentError", fmt.Sprintf("comparison of %s with %s failed", rbClassName(ka), rbClassName(kb)))
+			}
+			return result, nil
+		}
+
+		return rbCompare(ka, kb)
+	}
+
+	err := rbSort(keyedItems, compare)
+	if err != nil {
+		return nil, true, err
+	}
+
+	result := newRbArray()
+	for _, item := range keyedItems {
+		result.items = append(result.items, item.(keyed).item)
+	}
+
+	switch name {
+	case "sort!", "sort_by!":
+		recv.items = result.items
+		return recv, true, nil
+	case "min", "min_by":
+		if len(args) > 0 {
+			n, err := rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			if n > len(result.items) {
+				n = len(result.items)
+			}
+			return newRbArray(result.items[:n]...), true, nil
+		}
+		if len(result.items) == 0 {
+			return nil, true, nil
+		}
+		return result.items[0], true, nil
+	case "max", "max_by":
+		if len(args) > 0 {
+			n, err := rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			reversed := newRbArray()
+			for idx := len(result.items) - 1; idx >= 0 && len(reversed.items) < n; idx-- {
+				reversed.items = append(reversed.items, result.items[idx])
+			}
+			return reversed, true, nil
+		}
+		if len(result.items) == 0 {
+			return nil, true, nil
+		}
+		// Ruby returns first maximum element
+		maxIdx := len(result.items) - 1
+		for maxIdx > 0 {
+			cmp, err := compare(keyedItems[maxIdx-1], keyedItems[maxIdx])
+			if err != nil || cmp != 0 {
+				break
+			}
+			maxIdx--
+		}
+		return result.items[maxIdx], true, nil
+	default:
+		return result, true, nil
+	}
+}
+
+func rbArrayIndex(items []interface{}, args []interface{}) (interface{}, error) {
+	if err := rbCheckArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	switch idx := args[0].(type) {
+	case int:
+		if len(args) == 2 {
+			length, err := rbArgInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if idx < 0 {
+				idx += len(items)
+			}
+			if idx < 0 || idx > len(items) || length < 0 {
+				return nil, nil
+			}
+			end := idx + length
+			if end > len(items) {
+				end = len(items)
+			}
+			return newRbArray(append([]interface{}{}, items[idx:end]...)...), nil
+		}
+		if idx < 0 {
+			idx += len(items)
+		}
+		if idx < 0 || idx >= len(items) {
+			return nil, nil
+		}
+		return items[idx], nil
+	case float64, *big.Int:
+		intIdx, err := rbArgInt(idx)
+		if err != nil {
+			return nil, err
+		}
+		return rbArrayIndex(items, append([]interface{}{intIdx}, args[1:]...))
+	case *rbRange:
+		start, end, ok := rbRangeBounds(idx, len(items))
+		if !ok {
+			return nil, nil
+		}
+		return newRbArray(append([]interface{}{}, items[start:end]...)...), nil
+	default:
+		return nil, rbTypeError(args[0], "Integer")
+	}
+}
+
+func rbJoin(items []interface{}, sep string) string {
+	var parts []string
+
+	for _, item := range items {
+		if arr, ok := item.(*rbArray); ok {
+			parts = append(parts, rbJoin(arr.items, sep))
+		} else {
+			parts = append(parts, rbToS(item))
+		}
+	}
+
+	return strings.Join(parts, sep)
+}
+
+func rbFlatten(items []interface{}, depth int) []interface{} {
+	var result []interface{}
+
+	for _, item := range items {
+		if arr, ok := item.(*rbArray); ok && depth != 0 {
+			result = append(result, rbFlatten(arr.items, depth-1)...)
+		} else {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func rbArraySetOp(left []interface{}, op string, right []interface{}) *rbArray {
+	contains := func(items []interface{}, val interface{}) bool {
+		for _, item := range items {
+			if rbEqual(item, val) {
+				return true
+			}
+		}
+		return false
+	}
+
+	result := newRbArray()
+
+	switch op {
+	case "+":
+		result.items = append(append(result.items, left...), right...)
+	case "-":
+		for _, item := range left {
+			if !contains(right, item) {
+				result.items = append(result.items, item)
+			}
+		}
+	case "&":
+		for _, item := range left {
+			if contains(right, item) && !contains(result.items, item) {
+				result.items = append(result.items, item)
+			}
+		}
+	case "|":
+		for _, item := range append(append([]interface{}{}, left...), right...) {
+			if !contains(result.items, item) {
+				result.items = append(result.items, item)
+			}
+		}
+	}
+
+	return result
+}
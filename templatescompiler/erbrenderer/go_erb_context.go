@@ -0,0 +1,469 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rbTemplateContext mirrors TemplateEvaluationContext from erb-render.rb
+type rbTemplateContext struct {
+	name  interface{}
+	index interface{}
+
+	rawProperties *rbHash
+	properties    interface{}
+	spec          interface{}
+	links         *rbHash
+}
+
+// rbLink mirrors EvaluationLink returned by link() and if_link()
+type rbLink struct {
+	instances  *rbArray
+	properties *rbHash
+	address    interface{}
+}
+
+var rbContextMethods = map[string]bool{
+	"p": true, "if_p": true, "if_link": true, "link": true,
+	"spec": true, "properties": true, "raw_properties": true, "name": true, "index": true,
+	"raise": true, "fail": true, "format": true, "sprintf": true, "require": true,
+	"Integer": true, "Float": true, "String": true, "Array": true,
+	"puts": true, "print": true, "loop": true, "lambda": true, "proc": true,
+}
+
+func newRbTemplateContext(contextJSON []byte) (*rbTemplateContext, error) {
+	decoded, err := rbFromJSON(contextJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, ok := decoded.(*rbHash)
+	if !ok {
+		return nil, fmt.Errorf("Expected context to be a hash but was '%s'", rbClassName(decoded))
+	}
+
+	context := &rbTemplateContext{}
+
+	if job, ok := rbHashGet(spec, "job").(*rbHash); ok {
+		context.name, _ = job.Get("name")
+	}
+
+	context.index, _ = spec.Get("index")
+
+	var sourceProperties *rbHash
+
+	if jobProperties, ok := rbHashGet(spec, "job_properties").(*rbHash); ok {
+		sourceProperties = jobProperties
+	} else {
+		sourceProperties = newRbHash()
+		if globalProperties, ok := rbHashGet(spec, "global_properties").(*rbHash); ok {
+			sourceProperties = globalProperties
+		}
+		if clusterProperties, ok := rbHashGet(spec, "cluster_properties").(*rbHash); ok {
+			rbRecursiveMerge(sourceProperties, clusterProperties)
+		}
+	}
+
+	properties := newRbHash()
+
+	if defaultProperties, ok := rbHashGet(spec, "default_properties").(*rbHash); ok {
+		for _, name := range defaultProperties.keys {
+			rbCopyProperty(properties, sourceProperties, rbToS(name), defaultProperties.vals[name])
+		}
+	}
+
+	context.rawProperties = properties
+	context.properties = rbToOpenStruct(properties)
+	context.spec = rbToOpenStruct(spec)
+
+	context.links = newRbHash()
+	if links, ok := rbHashGet(spec, "links").(*rbHash); ok {
+		context.links = links
+	}
+
+	return context, nil
+}
+
+// Defines returns true if method is available without receiver
+func (c *rbTemplateContext) Defines(name string) bool {
+	return rbContextMethods[name]
+}
+
+// Call invokes method on template context (receiver-less calls in templates)
+func (c *rbTemplateContext) Call(i *rbInterpreter, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	switch name {
+	case "p":
+		return c.p(args)
+	case "if_p":
+		return c.ifP(args, block)
+	case "if_link":
+		return c.ifLink(args, block)
+	case "link":
+		return c.link(args)
+	case "spec":
+		return c.spec, rbCheckArgs(args, 0, 0)
+	case "properties":
+		return c.properties, rbCheckArgs(args, 0, 0)
+	case "raw_properties":
+		return c.rawProperties, rbCheckArgs(args, 0, 0)
+	case "name":
+		return c.name, rbCheckArgs(args, 0, 0)
+	case "index":
+		return c.index, rbCheckArgs(args, 0, 0)
+	case "raise", "fail":
+		return nil, c.raise(args)
+	case "format", "sprintf":
+		if err := rbCheckArgs(args, 1, -1); err != nil {
+			return nil, err
+		}
+		format, err := rbArgString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return rbFormat(format, args[1:])
+	case "Integer":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, err
+		}
+		return rbKernelInteger(args[0])
+	case "Float":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return rbKernelFloat(args[0])
+	case "String":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return rbToS(args[0]), nil
+	case "Array":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return rbKernelArray(args[0]), nil
+	case "require":
+		return true, rbCheckArgs(args, 1, 1)
+	case "puts", "print":
+		// Ruby writes to stdout which is not part of rendered template
+		return nil, nil
+	case "loop":
+		if block == nil {
+			return nil, rbNoBlockError()
+		}
+		for {
+			if _, err := block.Call(); err != nil {
+				if rbErr, ok := err.(*rbError); ok && rbErr.class == "StopIteration" {
+					return nil, nil
+				}
+				return nil, err
+			}
+		}
+	case "lambda", "proc":
+		if block == nil {
+			return nil, newRbError("ArgumentError", "tried to create Proc object without a block")
+		}
+		return block, nil
+	}
+
+	val, handled, err := i.callObjectMethod(c, name, args, block)
+	if handled {
+		return val, err
+	}
+
+	if len(args) == 0 && block == nil {
+		return nil, newRbError("NameError", fmt.Sprintf("undefined local variable or method `%s' for %s", name, rbInspect(c)))
+	}
+
+	return nil, newRbError("NoMethodError", fmt.Sprintf("undefined method `%s' for %s", name, rbInspect(c)))
+}
+
+func (c *rbTemplateContext) p(args []interface{}) (interface{}, error) {
+	return rbP(c.rawProperties, args)
+}
+
+func (c *rbTemplateContext) ifP(args []interface{}, block *rbBlock) (interface{}, error) {
+	return rbIfP(c.rawProperties, args, block)
+}
+
+func (c *rbTemplateContext) link(args []interface{}) (interface{}, error) {
+	if err := rbCheckArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	linkSpec, ok := rbHashGet(c.links, rbToS(args[0])).(*rbHash)
+	if !ok {
+		return nil, newRbError("RuntimeError", fmt.Sprintf("Can't find link '%s'", rbToS(args[0])))
+	}
+
+	return newRbLink(linkSpec), nil
+}
+
+func (c *rbTemplateContext) ifLink(args []interface{}, block *rbBlock) (interface{}, error) {
+	if err := rbCheckArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+
+	linkSpec, ok := rbHashGet(c.links, rbToS(args[0])).(*rbHash)
+	if !ok {
+		return &rbElseBlock{active: true}, nil
+	}
+
+	if block == nil {
+		return nil, rbNoBlockError()
+	}
+
+	if _, err := block.Call(newRbLink(linkSpec)); err != nil {
+		return nil, err
+	}
+
+	return &rbElseBlock{}, nil
+}
+
+func newRbLink(linkSpec *rbHash) *rbLink {
+	link := &rbLink{
+		instances:  newRbArray(),
+		properties: newRbHash(),
+		address:    rbHashGet(linkSpec, "address"),
+	}
+
+	if instances, ok := rbHashGet(linkSpec, "instances").(*rbArray); ok {
+		for _, instance := range instances.items {
+			link.instances.items = append(link.instances.items, rbToOpenStruct(instance))
+		}
+	}
+
+	if properties, ok := rbHashGet(linkSpec, "properties").(*rbHash); ok {
+		link.properties = properties
+	}
+
+	return link
+}
+
+// Call invokes method on link returned by link() and if_link()
+func (l *rbLink) Call(name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "instances":
+		return l.instances, true, rbCheckArgs(args, 0, 0)
+	case "properties":
+		return l.properties, true, rbCheckArgs(args, 0, 0)
+	case "address":
+		return l.address, true, rbCheckArgs(args, 0, 0)
+	case "p":
+		val, err := rbP(l.properties, args)
+		return val, true, err
+	case "if_p":
+		val, err := rbIfP(l.properties, args, block)
+		return val, true, err
+	}
+	return nil, false, nil
+}
+
+func rbP(properties *rbHash, args []interface{}) (interface{}, error) {
+	if err := rbCheckArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	names := rbKernelArray(args[0]).(*rbArray).items
+
+	for _, name := range names {
+		strName, err := rbArgString(name)
+		if err != nil {
+			return nil, err
+		}
+		if val := rbLookupProperty(properties, strName); val != nil {
+			return val, nil
+		}
+	}
+
+	if len(args) == 2 {
+		return args[1], nil
+	}
+
+	var quoted []string
+	for _, name := range names {
+		quoted = append(quoted, rbToS(name))
+	}
+
+	return nil, newRbError("TemplateEvaluationContext::UnknownProperty",
+		fmt.Sprintf("Can't find property '%s'", strings.Join(quoted, "', or '")))
+}
+
+func rbIfP(properties *rbHash, args []interface{}, block *rbBlock) (interface{}, error) {
+	var values []interface{}
+
+	for _, name := range args {
+		strName, err := rbArgString(name)
+		if err != nil {
+			return nil, err
+		}
+		val := rbLookupProperty(properties, strName)
+		if val == nil {
+			return &rbElseBlock{active: true}, nil
+		}
+		values = append(values, val)
+	}
+
+	if block == nil {
+		return nil, rbNoBlockError()
+	}
+
+	if _, err := block.Call(values...); err != nil {
+		return nil, err
+	}
+
+	return &rbElseBlock{}, nil
+}
+
+func (c *rbTemplateContext) raise(args []interface{}) error {
+	if err := rbCheckArgs(args, 0, 2); err != nil {
+		return err
+	}
+
+	switch len(args) {
+	case 0:
+		return newRbError("RuntimeError", "unhandled exception")
+	case 1:
+		switch typedArg := args[0].(type) {
+		case *rbError:
+			return typedArg
+		case rbClass:
+			return newRbError(string(typedArg), string(typedArg))
+		default:
+			return newRbError("RuntimeError", rbToS(typedArg))
+		}
+	default:
+		class, ok := args[0].(rbClass)
+		if !ok {
+			return newRbError("TypeError", "exception class/object expected")
+		}
+		return newRbError(string(class), rbToS(args[1]))
+	}
+}
+
+func rbHashGet(hash *rbHash, key string) interface{} {
+	val, _ := hash.Get(key)
+	return val
+}
+
+func rbRecursiveMerge(dst, src *rbHash) {
+	for _, key := range src.keys {
+		newVal := src.vals[key]
+		oldVal, _ := dst.Get(key)
+
+		oldHash, oldIsHash := oldVal.(*rbHash)
+		newHash, newIsHash := newVal.(*rbHash)
+
+		if oldIsHash && newIsHash {
+			rbRecursiveMerge(oldHash, newHash)
+		} else {
+			dst.Set(key, newVal)
+		}
+	}
+}
+
+func rbCopyProperty(dst, src *rbHash, name string, defaultVal interface{}) {
+	keys := strings.Split(name, ".")
+
+	var srcRef interface{} = src
+
+	for _, key := range keys {
+		hash, ok := srcRef.(*rbHash)
+		if !ok {
+			srcRef = nil
+			break
+		}
+		srcRef, _ = hash.Get(key)
+		if srcRef == nil {
+			break
+		}
+	}
+
+	dstRef := dst
+
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := rbHashGet(dstRef, key).(*rbHash)
+		if !ok {
+			next = newRbHash()
+			dstRef.Set(key, next)
+		}
+		dstRef = next
+	}
+
+	if srcRef == nil {
+		srcRef = defaultVal
+	}
+
+	dstRef.Set(keys[len(keys)-1], srcRef)
+}
+
+func rbLookupProperty(collection *rbHash, name string) interface{} {
+	var ref interface{} = collection
+
+	for _, key := range strings.Split(name, ".") {
+		hash, ok := ref.(*rbHash)
+		if !ok {
+			return nil
+		}
+		ref, _ = hash.Get(key)
+		if ref == nil {
+			return nil
+		}
+	}
+
+	return ref
+}
+
+func rbKernelArray(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case nil:
+		return newRbArray()
+	case *rbArray:
+		return typedVal
+	case *rbHash:
+		return newRbArray(rbHashPairs(typedVal)...)
+	case *rbRange:
+		return newRbArray(typedVal.Items()...)
+	default:
+		return newRbArray(val)
+	}
+}
+
+func rbKernelInteger(val interface{}) (interface{}, error) {
+	switch typedVal := val.(type) {
+	case int:
+		return typedVal, nil
+	case float64:
+		return int(typedVal), nil
+	case string:
+		str := strings.Replace(strings.TrimSpace(typedVal), "_", "", -1)
+		result, err := strconv.ParseInt(str, 0, 64)
+		if err != nil {
+			return nil, newRbError("ArgumentError", fmt.Sprintf("invalid value for Integer(): %s", rbInspect(typedVal)))
+		}
+		return int(result), nil
+	case nil:
+		return nil, newRbError("TypeError", "can't convert nil into Integer")
+	default:
+		return nil, rbTypeError(val, "Integer")
+	}
+}
+
+func rbKernelFloat(val interface{}) (interface{}, error) {
+	switch typedVal := val.(type) {
+	case int:
+		return float64(typedVal), nil
+	case float64:
+		return typedVal, nil
+	case string:
+		result, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(typedVal), "_", "", -1), 64)
+		if err != nil {
+			return nil, newRbError("ArgumentError", fmt.Sprintf("invalid value for Float(): %s", rbInspect(typedVal)))
+		}
+		return result, nil
+	case nil:
+		return nil, newRbError("TypeError", "can't convert nil into Float")
+	default:
+		return nil, rbTypeError(val, "Float")
+	}
+}
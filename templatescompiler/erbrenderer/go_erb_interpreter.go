@@ -0,0 +1,665 @@
+package erbrenderer
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type rbError struct {
+	class string
+	msg   string
+	line  int
+}
+
+func newRbError(class, msg string) *rbError {
+	return &rbError{class: class, msg: msg}
+}
+
+func newRbErrorAt(class, msg string, line int) *rbError {
+	return &rbError{class: class, msg: msg, line: line}
+}
+
+func (e *rbError) Error() string { return e.msg }
+
+// Inspect formats error the same way as Ruby's Exception#inspect
+func (e *rbError) Inspect() string { return fmt.Sprintf("#<%s: %s>", e.class, e.msg) }
+
+func rbNoMethodError(name string, recv interface{}) *rbError {
+	return newRbError("NoMethodError", fmt.Sprintf("undefined method `%s' for %s:%s", name, rbInspect(recv), rbClassName(recv)))
+}
+
+func rbArgumentError(given int, expected string) *rbError {
+	return newRbError("ArgumentError", fmt.Sprintf("wrong number of arguments (given %d, expected %s)", given, expected))
+}
+
+func rbTypeError(val interface{}, into string) *rbError {
+	return newRbError("TypeError", fmt.Sprintf("no implicit conversion of %s into %s", rbClassName(val), into))
+}
+
+// Control flow is propagated as errors until construct handling it is reached
+type rbNextSignal struct{ val interface{} }
+
+func (rbNextSignal) Error() string { return "next used outside of block" }
+
+type rbBreakSignal struct{ val interface{} }
+
+func (rbBreakSignal) Error() string { return "break from proc-closure" }
+
+type rbEnv struct {
+	vars   map[string]interface{}
+	parent *rbEnv
+}
+
+func newRbEnv(parent *rbEnv) *rbEnv {
+	return &rbEnv{vars: map[string]interface{}{}, parent: parent}
+}
+
+func (e *rbEnv) Get(name string) (interface{}, bool) {
+	for env := e; env != nil; env = env.parent {
+		if val, found := env.vars[name]; found {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Set assigns to existing variable in outer scope or defines it in current scope
+func (e *rbEnv) Set(name string, val interface{}) {
+	for env := e; env != nil; env = env.parent {
+		if _, found := env.vars[name]; found {
+			env.vars[name] = val
+			return
+		}
+	}
+	e.vars[name] = val
+}
+
+type rbBlock struct {
+	node   *rbBlockNode
+	env    *rbEnv
+	interp *rbInterpreter
+	native func(args []interface{}) (interface{}, error)
+}
+
+func (b *rbBlock) Call(args ...interface{}) (interface{}, error) {
+	if b.native != nil {
+		return b.native(args)
+	}
+
+	env := newRbEnv(b.env)
+
+	params := b.node.params
+
+	// Procs auto-splat single array argument when they accept multiple parameters
+	if len(args) == 1 && (len(params) > 1 || (len(params) == 1 && params[0].sub != nil)) {
+		if arr, ok := args[0].(*rbArray); ok && len(params) > 1 {
+			args = arr.items
+		}
+	}
+
+	b.bindParams(env, params, args)
+
+	val, err := b.interp.evalStatements(b.node.body, env)
+	if err != nil {
+		if next, ok := err.(rbNextSignal); ok {
+			return next.val, nil
+		}
+		return nil, err
+	}
+
+	return val, nil
+}
+
+func (b *rbBlock) bindParams(env *rbEnv, params []rbParam, args []interface{}) {
+	for i, param := range params {
+		var val interface{}
+
+		if param.splat {
+			rest := newRbArray()
+			if i < len(args) {
+				rest.items = append(rest.items, args[i:]...)
+			}
+			env.vars[param.name] = rest
+			continue
+		}
+
+		if i < len(args) {
+			val = args[i]
+		}
+
+		if param.sub != nil {
+			var subArgs []interface{}
+			if arr, ok := val.(*rbArray); ok {
+				subArgs = arr.items
+			} else {
+				subArgs = []interface{}{val}
+			}
+			b.bindParams(env, param.sub, subArgs)
+			continue
+		}
+
+		env.vars[param.name] = val
+	}
+}
+
+// rbInterpreter evaluates parsed template against template evaluation context
+type rbInterpreter struct {
+	context *rbTemplateContext
+	out     bytes.Buffer
+}
+
+func (i *rbInterpreter) evalStatements(stmts []rbNode, env *rbEnv) (interface{}, error) {
+	var (
+		val interface{}
+		err error
+	)
+
+	for _, stmt := range stmts {
+		val, err = i.eval(stmt, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return val, nil
+}
+
+func (i *rbInterpreter) eval(node rbNode, env *rbEnv) (interface{}, error) {
+	switch n := node.(type) {
+	case *rbTextNode:
+		i.out.WriteString(n.text)
+		return nil, nil
+
+	case *rbOutputNode:
+		val, err := i.eval(n.expr, env)
+		if err != nil {
+			return nil, err
+		}
+		i.out.WriteString(rbToS(val))
+		return nil, nil
+
+	case *rbLiteralNode:
+		return n.val, nil
+
+	case *rbStringNode:
+		var buf bytes.Buffer
+		for _, part := range n.parts {
+			val, err := i.eval(part, env)
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString(rbToS(val))
+		}
+		return buf.String(), nil
+
+	case *rbSymbolNode:
+		val, err := i.eval(n.str, env)
+		if err != nil {
+			return nil, err
+		}
+		return rbSymbol(val.(string)), nil
+
+	case *rbArrayNode:
+		items, err := i.evalArgs(n.elems, env)
+		if err != nil {
+			return nil, err
+		}
+		return newRbArray(items...), nil
+
+	case *rbHashNode:
+		hash := newRbHash()
+		for idx := range n.keys {
+			key, err := i.eval(n.keys[idx], env)
+			if err != nil {
+				return nil, err
+			}
+			val, err := i.eval(n.vals[idx], env)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(key, val)
+		}
+		return hash, nil
+
+	case *rbRangeNode:
+		return i.evalRange(n, env)
+
+	case *rbSplatNode:
+		return i.eval(n.expr, env)
+
+	case *rbVarNode:
+		val, _ := env.Get(n.name)
+		return val, nil
+
+	case *rbConstNode:
+		return i.evalConst(n.name)
+
+	case *rbSelfNode:
+		return i.context, nil
+
+	case *rbAssignNode:
+		return i.evalAssign(n, env)
+
+	case *rbIndexAssignNode:
+		return i.evalIndexAssign(n, env)
+
+	case *rbAttrAssignNode:
+		recv, err := i.eval(n.recv, env)
+		if err != nil {
+			return nil, err
+		}
+		val, err := i.eval(n.value, env)
+		if err != nil {
+			return nil, err
+		}
+		_, err = i.callMethod(recv, n.name+"=", []interface{}{val}, nil)
+		return val, i.annotate(err, n.line)
+
+	case *rbCallNode:
+		return i.evalCall(n, env)
+
+	case *rbAndNode:
+		left, err := i.eval(n.left, env)
+		if err != nil || !rbTruthy(left) {
+			return left, err
+		}
+		return i.eval(n.right, env)
+
+	case *rbOrNode:
+		left, err := i.eval(n.left, env)
+		if err != nil || rbTruthy(left) {
+			return left, err
+		}
+		return i.eval(n.right, env)
+
+	case *rbNotNode:
+		val, err := i.eval(n.expr, env)
+		if err != nil {
+			return nil, err
+		}
+		return !rbTruthy(val), nil
+
+	case *rbIfNode:
+		cond, err := i.eval(n.cond, env)
+		if err != nil {
+			return nil, err
+		}
+		if rbTruthy(cond) {
+			return i.evalStatements(n.then, env)
+		}
+		return i.evalStatements(n.els, env)
+
+	case *rbWhileNode:
+		return i.evalWhile(n, env)
+
+	case *rbCaseNode:
+		return i.evalCase(n, env)
+
+	case *rbBeginNode:
+		return i.evalBegin(n, env)
+
+	case *rbSeqNode:
+		return i.evalStatements(n.stmts, env)
+
+	case *rbNextNode:
+		val, err := i.evalOptional(n.val, env)
+		if err != nil {
+			return nil, err
+		}
+		return nil, rbNextSignal{val: val}
+
+	case *rbBreakNode:
+		val, err := i.evalOptional(n.val, env)
+		if err != nil {
+			return nil, err
+		}
+		return nil, rbBreakSignal{val: val}
+
+	case *rbDefinedNode:
+		return i.evalDefined(n, env)
+
+	default:
+		return nil, newRbError("NotImplementedError", fmt.Sprintf("unsupported expression %T", node))
+	}
+}
+
+func (i *rbInterpreter) evalOptional(node rbNode, env *rbEnv) (interface{}, error) {
+	if node == nil {
+		return nil, nil
+	}
+	return i.eval(node, env)
+}
+
+func (i *rbInterpreter) annotate(err error, line int) error {
+	if rbErr, ok := err.(*rbError); ok && rbErr.line == 0 {
+		rbErr.line = line
+	}
+	return err
+}
+
+func (i *rbInterpreter) evalArgs(nodes []rbNode, env *rbEnv) ([]interface{}, error) {
+	var args []interface{}
+
+	for _, node := range nodes {
+		val, err := i.eval(node, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := node.(*rbSplatNode); ok {
+			switch typedVal := val.(type) {
+			case *rbArray:
+				args = append(args, typedVal.items...)
+			case *rbRange:
+				args = append(args, typedVal.Items()...)
+			case nil:
+			default:
+				args = append(args, val)
+			}
+			continue
+		}
+
+		args = append(args, val)
+	}
+
+	return args, nil
+}
+
+func (i *rbInterpreter) evalRange(n *rbRangeNode, env *rbEnv) (interface{}, error) {
+	from, err := i.eval(n.from, env)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := i.eval(n.to, env)
+	if err != nil {
+		return nil, err
+	}
+
+	fromInt, fromOK := from.(int)
+	toInt, toOK := to.(int)
+	if !fromOK || !toOK {
+		return nil, newRbError("ArgumentError", "bad value for range")
+	}
+
+	return &rbRange{from: fromInt, to: toInt, exclusive: n.exclusive}, nil
+}
+
+func (i *rbInterpreter) evalAssign(n *rbAssignNode, env *rbEnv) (interface{}, error) {
+	current, _ := env.Get(n.name)
+
+	switch n.op {
+	case "||":
+		if rbTruthy(current) {
+			return current, nil
+		}
+	case "&&":
+		if !rbTruthy(current) {
+			return current, nil
+		}
+	}
+
+	val, err := i.eval(n.value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "", "||", "&&":
+	default:
+		val, err = i.callMethod(current, n.op, []interface{}{val}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	env.Set(n.name, val)
+
+	return val, nil
+}
+
+func (i *rbInterpreter) evalIndexAssign(n *rbIndexAssignNode, env *rbEnv) (interface{}, error) {
+	recv, err := i.eval(n.recv, env)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := i.evalArgs(n.args, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var current interface{}
+
+	if len(n.op) > 0 {
+		current, err = i.callMethod(recv, "[]", args, nil)
+		if err != nil {
+			return nil, i.annotate(err, n.line)
+		}
+
+		switch n.op {
+		case "||":
+			if rbTruthy(current) {
+				return current, nil
+			}
+		case "&&":
+			if !rbTruthy(current) {
+				return current, nil
+			}
+		}
+	}
+
+	val, err := i.eval(n.value, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "", "||", "&&":
+	default:
+		val, err = i.callMethod(current, n.op, []interface{}{val}, nil)
+		if err != nil {
+			return nil, i.annotate(err, n.line)
+		}
+	}
+
+	_, err = i.callMethod(recv, "[]=", append(args, val), nil)
+	if err != nil {
+		return nil, i.annotate(err, n.line)
+	}
+
+	return val, nil
+}
+
+func (i *rbInterpreter) evalCall(n *rbCallNode, env *rbEnv) (interface{}, error) {
+	var (
+		recv interface{}
+		err  error
+	)
+
+	if n.recv != nil {
+		recv, err = i.eval(n.recv, env)
+		if err != nil {
+			return nil, err
+		}
+		if n.safeNav && recv == nil {
+			return nil, nil
+		}
+	}
+
+	args, err := i.evalArgs(n.args, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *rbBlock
+
+	if n.block != nil {
+		block = &rbBlock{node: n.block, env: env, interp: i}
+	} else if n.blockArg != nil {
+		blockArg, err := i.eval(n.blockArg, env)
+		if err != nil {
+			return nil, err
+		}
+		block, err = i.blockFromArg(blockArg)
+		if err != nil {
+			return nil, i.annotate(err, n.line)
+		}
+	}
+
+	var val interface{}
+
+	if n.recv == nil {
+		val, err = i.context.Call(i, n.name, args, block)
+	} else {
+		val, err = i.callMethod(recv, n.name, args, block)
+
+		// Strings are immutable in Go so mutating methods reassign local variables
+		if varNode, ok := n.recv.(*rbVarNode); ok && err == nil {
+			if _, isStr := recv.(string); isStr && (n.name == "<<" || n.name == "concat" || n.name[len(n.name)-1] == '!') {
+				if str, ok := val.(string); ok {
+					env.Set(varNode.name, str)
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		if brk, ok := err.(rbBreakSignal); ok && block != nil {
+			return brk.val, nil
+		}
+		return nil, i.annotate(err, n.line)
+	}
+
+	return val, nil
+}
+
+func (i *rbInterpreter) blockFromArg(arg interface{}) (*rbBlock, error) {
+	switch typedArg := arg.(type) {
+	case nil:
+		return nil, nil
+	case rbSymbol:
+		return &rbBlock{native: func(args []interface{}) (interface{}, error) {
+			if len(args) == 0 {
+				return nil, newRbError("ArgumentError", "no receiver given")
+			}
+			return i.callMethod(args[0], string(typedArg), args[1:], nil)
+		}}, nil
+	case *rbBlock:
+		return typedArg, nil
+	default:
+		return nil, newRbError("TypeError", fmt.Sprintf("wrong argument type %s (expected Proc)", rbClassName(arg)))
+	}
+}
+
+func (i *rbInterpreter) evalWhile(n *rbWhileNode, env *rbEnv) (interface{}, error) {
+	for {
+		cond, err := i.eval(n.cond, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if rbTruthy(cond) == n.until {
+			return nil, nil
+		}
+
+		_, err = i.evalStatements(n.body, env)
+		if err != nil {
+			switch signal := err.(type) {
+			case rbBreakSignal:
+				return signal.val, nil
+			case rbNextSignal:
+				continue
+			}
+			return nil, err
+		}
+	}
+}
+
+func (i *rbInterpreter) evalCase(n *rbCaseNode, env *rbEnv) (interface{}, error) {
+	var (
+		subject interface{}
+		err     error
+	)
+
+	if n.subject != nil {
+		subject, err = i.eval(n.subject, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, when := range n.whens {
+		values, err := i.evalArgs(when.values, env)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, val := range values {
+			var matched bool
+
+			if n.subject == nil {
+				matched = rbTruthy(val)
+			} else {
+				matchedVal, err := i.callMethod(val, "===", []interface{}{subject}, nil)
+				if err != nil {
+					return nil, err
+				}
+				matched = rbTruthy(matchedVal)
+			}
+
+			if matched {
+				return i.evalStatements(when.body, env)
+			}
+		}
+	}
+
+	return i.evalStatements(n.els, env)
+}
+
+func (i *rbInterpreter) evalBegin(n *rbBeginNode, env *rbEnv) (interface{}, error) {
+	val, err := i.evalStatements(n.body, env)
+
+	if rbErr, ok := err.(*rbError); ok && n.hasRescue {
+		if len(n.rescueVar) > 0 {
+			env.Set(n.rescueVar, rbErr)
+		}
+		val, err = i.evalStatements(n.rescue, env)
+	}
+
+	if n.ensureBody != nil {
+		_, ensureErr := i.evalStatements(n.ensureBody, env)
+		if ensureErr != nil {
+			return nil, ensureErr
+		}
+	}
+
+	return val, err
+}
+
+func (i *rbInterpreter) evalDefined(n *rbDefinedNode, env *rbEnv) (interface{}, error) {
+	switch expr := n.expr.(type) {
+	case *rbVarNode:
+		if _, found := env.Get(expr.name); found {
+			return "local-variable", nil
+		}
+		return nil, nil
+	case *rbConstNode:
+		if _, err := i.evalConst(expr.name); err != nil {
+			return nil, nil
+		}
+		return "constant", nil
+	case *rbCallNode:
+		if expr.recv == nil && i.context.Defines(expr.name) {
+			return "method", nil
+		}
+		if expr.recv != nil {
+			if _, err := i.eval(expr, env); err == nil {
+				return "method", nil
+			}
+		}
+		return nil, nil
+	default:
+		return "expression", nil
+	}
+}
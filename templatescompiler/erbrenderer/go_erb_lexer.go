@@ -0,0 +1,652 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type rbTokenKind int
+
+const (
+	rbTokenEOF rbTokenKind = iota
+	rbTokenNewline
+	rbTokenText     // literal template text
+	rbTokenOutStart // <%=
+	rbTokenOutEnd   // %> closing <%=
+	rbTokenIdent    // local variable or method name
+	rbTokenConst    // constant name
+	rbTokenLabel    // hash key in 'key: value' form
+	rbTokenKeyword  // reserved word
+	rbTokenInt      // integer literal
+	rbTokenFloat    // float literal
+	rbTokenString   // string literal, possibly with interpolation
+	rbTokenSymbol   // symbol literal
+	rbTokenRegexp   // regexp literal
+	rbTokenWords    // %w() literal
+	rbTokenOp       // operator or punctuation
+)
+
+type rbToken struct {
+	kind  rbTokenKind
+	text  string
+	line  int
+	space bool // whitespace precedes token
+
+	parts []rbStringPart // interpolated string contents
+	words []string
+	flags string // regexp flags
+}
+
+// rbStringPart is either a literal string or tokens of an interpolated expression
+type rbStringPart struct {
+	lit    string
+	tokens []rbToken
+	isCode bool
+}
+
+var rbKeywords = map[string]bool{
+	"if": true, "elsif": true, "else": true, "unless": true, "end": true,
+	"do": true, "while": true, "until": true, "case": true, "when": true,
+	"then": true, "and": true, "or": true, "not": true, "nil": true,
+	"true": true, "false": true, "self": true, "begin": true, "rescue": true,
+	"ensure": true, "return": true, "next": true, "break": true, "in": true,
+	"defined?": true,
+}
+
+var rbOperators = []string{
+	"**=", "<=>", "===", "...", "||=", "&&=", "<<=",
+	"**", "==", "!=", ">=", "<=", "&&", "||", "<<", ">>", "=~", "!~",
+	"+=", "-=", "*=", "/=", "..", "::", "=>", "->", "&.",
+	"+", "-", "*", "/", "%", "=", "<", ">", "!", "(", ")", "[", "]",
+	"{", "}", ",", ".", "?", ":", ";", "|", "&", "^", "~",
+}
+
+// erbTokenize splits ERB template into text and Ruby code tokens.
+// Each ERB tag acts as a statement boundary similarly to code generated by Ruby's ERB.
+func erbTokenize(template string) ([]rbToken, error) {
+	var tokens []rbToken
+
+	line := 1
+	pos := 0
+	text := ""
+	textLine := 1
+
+	flushText := func() {
+		if len(text) > 0 {
+			tokens = append(tokens, rbToken{kind: rbTokenText, text: text, line: textLine})
+			text = ""
+		}
+	}
+
+	for pos < len(template) {
+		idx := strings.Index(template[pos:], "<%")
+		if idx < 0 {
+			if len(text) == 0 {
+				textLine = line
+			}
+			text += template[pos:]
+			break
+		}
+
+		if len(text) == 0 {
+			textLine = line
+		}
+		text += template[pos : pos+idx]
+		line += strings.Count(template[pos:pos+idx], "\n")
+		pos += idx
+
+		if strings.HasPrefix(template[pos:], "<%%") {
+			text += "<%"
+			pos += 3
+			continue
+		}
+
+		start := pos + 2
+		end := strings.Index(template[start:], "%>")
+		if end < 0 {
+			return nil, newRbErrorAt("SyntaxError", "unterminated ERB tag", line)
+		}
+
+		code := template[start : start+end]
+		pos = start + end + 2
+
+		if strings.HasPrefix(code, "-") {
+			code = code[1:]
+			// Trim indentation preceding the tag when it starts the line
+			lastNewline := strings.LastIndex(text, "\n")
+			if strings.TrimLeft(text[lastNewline+1:], " \t") == "" {
+				text = text[:lastNewline+1]
+			}
+		}
+
+		trimNewline := false
+		if strings.HasSuffix(code, "-") {
+			code = code[:len(code)-1]
+			trimNewline = true
+		}
+
+		flushText()
+
+		codeLine := line
+		line += strings.Count(code, "\n")
+
+		switch {
+		case strings.HasPrefix(code, "#"):
+			// comment
+		case strings.HasPrefix(code, "="):
+			codeTokens, err := rbTokenize(code[1:], codeLine)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, rbToken{kind: rbTokenOutStart, line: codeLine})
+			tokens = append(tokens, codeTokens...)
+			tokens = append(tokens, rbToken{kind: rbTokenOutEnd, line: line})
+		default:
+			codeTokens, err := rbTokenize(code, codeLine)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, codeTokens...)
+		}
+
+		tokens = append(tokens, rbToken{kind: rbTokenNewline, line: line})
+
+		if trimNewline {
+			if strings.HasPrefix(template[pos:], "\r\n") {
+				pos += 2
+				line++
+			} else if strings.HasPrefix(template[pos:], "\n") {
+				pos++
+				line++
+			}
+		}
+	}
+
+	flushText()
+
+	tokens = append(tokens, rbToken{kind: rbTokenEOF, line: line})
+
+	return tokens, nil
+}
+
+type rbLexer struct {
+	src  []rune
+	pos  int
+	line int
+
+	tokens []rbToken
+	space  bool
+}
+
+func rbTokenize(src string, line int) ([]rbToken, error) {
+	l := &rbLexer{src: []rune(src), line: line}
+
+	err := l.run()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.tokens, nil
+}
+
+func (l *rbLexer) peekAt(offset int) rune {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *rbLexer) emit(token rbToken) {
+	token.line = l.line
+	token.space = l.space
+	l.tokens = append(l.tokens, token)
+	l.space = false
+}
+
+func (l *rbLexer) lastToken() *rbToken {
+	if len(l.tokens) == 0 {
+		return nil
+	}
+	return &l.tokens[len(l.tokens)-1]
+}
+
+// valueEnded returns true if previous token completes an operand
+// which is used to disambiguate regexps, unary operators and percent literals.
+func (l *rbLexer) valueEnded() bool {
+	last := l.lastToken()
+	if last == nil {
+		return false
+	}
+
+	switch last.kind {
+	case rbTokenIdent, rbTokenConst, rbTokenInt, rbTokenFloat, rbTokenString,
+		rbTokenSymbol, rbTokenRegexp, rbTokenWords:
+		return true
+	case rbTokenKeyword:
+		switch last.text {
+		case "end", "nil", "true", "false", "self":
+			return true
+		}
+	case rbTokenOp:
+		switch last.text {
+		case ")", "]", "}":
+			return true
+		}
+	}
+
+	return false
+}
+
+// commandArgStart returns true when operator-like character likely starts
+// an argument of a method called without parentheses (e.g. 'split /,/')
+func (l *rbLexer) commandArgStart() bool {
+	last := l.lastToken()
+	return last != nil && last.kind == rbTokenIdent && l.space && !unicode.IsSpace(l.peekAt(1))
+}
+
+func (l *rbLexer) run() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+			l.space = true
+
+		case c == '\\' && l.peekAt(1) == '\n':
+			l.pos += 2
+			l.line++
+			l.space = true
+
+		case c == '\n':
+			l.emit(rbToken{kind: rbTokenNewline})
+			l.pos++
+			l.line++
+			l.space = true
+
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+
+		case unicode.IsDigit(c):
+			l.lexNumber()
+
+		case c == '_' || unicode.IsLetter(c) || c == '@' || c == '$':
+			l.lexIdent()
+
+		case c == '"' || c == '\'' || c == '`':
+			l.pos++
+			err := l.lexString(c, c, c != '\'')
+			if err != nil {
+				return err
+			}
+
+		case c == ':' && l.peekAt(1) == '"':
+			l.pos += 2
+			err := l.lexString('"', '"', true)
+			if err != nil {
+				return err
+			}
+			l.lastToken().kind = rbTokenSymbol
+
+		case c == ':' && l.peekAt(1) != ':' && (unicode.IsLetter(l.peekAt(1)) || l.peekAt(1) == '_') &&
+			!(l.valueEnded() && !l.space):
+			l.pos++
+			start := l.pos
+			for l.pos < len(l.src) && l.isIdentChar(l.src[l.pos]) {
+				l.pos++
+			}
+			if (l.peekAt(0) == '?' || l.peekAt(0) == '!') && l.peekAt(1) != '=' {
+				l.pos++
+			}
+			l.emit(rbToken{kind: rbTokenSymbol, text: string(l.src[start:l.pos])})
+
+		case c == ':' && !l.valueEnded() && l.operatorSymbol() != "":
+			op := l.operatorSymbol()
+			l.pos += 1 + len([]rune(op))
+			l.emit(rbToken{kind: rbTokenSymbol, text: op})
+
+		case c == '/' && (!l.valueEnded() || l.commandArgStart()):
+			l.pos++
+			err := l.lexRegexp('/')
+			if err != nil {
+				return err
+			}
+
+		case c == '%' && (!l.valueEnded() || l.commandArgStart()) && l.isPercentLiteral():
+			err := l.lexPercentLiteral()
+			if err != nil {
+				return err
+			}
+
+		default:
+			matched := false
+			for _, op := range rbOperators {
+				if l.hasPrefix(op) {
+					l.emit(rbToken{kind: rbTokenOp, text: op})
+					l.pos += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return newRbErrorAt("SyntaxError", fmt.Sprintf("unexpected character '%c'", c), l.line)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *rbLexer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(l.src[l.pos:]), prefix)
+}
+
+func (l *rbLexer) isIdentChar(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func (l *rbLexer) lexNumber() {
+	start := l.pos
+	isFloat := false
+
+	for l.pos < len(l.src) && (unicode.IsDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+		l.pos++
+	}
+
+	if l.peekAt(0) == '.' && unicode.IsDigit(l.peekAt(1)) {
+		isFloat = true
+		l.pos++
+		for l.pos < len(l.src) && (unicode.IsDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+	}
+
+	if (l.peekAt(0) == 'e' || l.peekAt(0) == 'E') &&
+		(unicode.IsDigit(l.peekAt(1)) || ((l.peekAt(1) == '-' || l.peekAt(1) == '+') && unicode.IsDigit(l.peekAt(2)))) {
+		isFloat = true
+		l.pos += 2
+		for l.pos < len(l.src) && unicode.IsDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+
+	text := strings.Replace(string(l.src[start:l.pos]), "_", "", -1)
+
+	if isFloat {
+		l.emit(rbToken{kind: rbTokenFloat, text: text})
+	} else {
+		l.emit(rbToken{kind: rbTokenInt, text: text})
+	}
+}
+
+func (l *rbLexer) lexIdent() {
+	start := l.pos
+	l.pos++
+
+	for l.pos < len(l.src) && l.isIdentChar(l.src[l.pos]) {
+		l.pos++
+	}
+
+	// Method names may end with ? or ! (e.g. empty?, gsub!)
+	if (l.peekAt(0) == '?' || l.peekAt(0) == '!') && l.peekAt(1) != '=' {
+		l.pos++
+	}
+
+	text := string(l.src[start:l.pos])
+
+	last := l.lastToken()
+	afterDot := last != nil && last.kind == rbTokenOp && (last.text == "." || last.text == "&.")
+	afterTernary := last != nil && last.kind == rbTokenOp && last.text == "?"
+
+	// Labels are used as hash keys and keyword arguments (e.g. {key: 'val'})
+	if l.peekAt(0) == ':' && l.peekAt(1) != ':' && !afterDot && !afterTernary {
+		l.pos++
+		l.emit(rbToken{kind: rbTokenLabel, text: text})
+		return
+	}
+
+	switch {
+	case !afterDot && rbKeywords[text]:
+		l.emit(rbToken{kind: rbTokenKeyword, text: text})
+	case unicode.IsUpper([]rune(text)[0]):
+		l.emit(rbToken{kind: rbTokenConst, text: text})
+	default:
+		l.emit(rbToken{kind: rbTokenIdent, text: text})
+	}
+}
+
+func (l *rbLexer) closingDelimiter(open rune) rune {
+	switch open {
+	case '(':
+		return ')'
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	case '<':
+		return '>'
+	default:
+		return open
+	}
+}
+
+func (l *rbLexer) isPercentLiteral() bool {
+	next := l.peekAt(1)
+	switch next {
+	case 'w', 'W', 'q', 'Q', 'i', 'I':
+		return strings.ContainsRune("([{<|!/", l.peekAt(2))
+	case '(', '[', '{', '<', '|', '!':
+		return true
+	}
+	return false
+}
+
+func (l *rbLexer) lexPercentLiteral() error {
+	l.pos++
+
+	kind := 'Q'
+	if unicode.IsLetter(l.peekAt(0)) {
+		kind = l.peekAt(0)
+		l.pos++
+	}
+
+	open := l.peekAt(0)
+	close := l.closingDelimiter(open)
+	l.pos++
+
+	switch kind {
+	case 'w', 'W', 'i', 'I':
+		start := l.pos
+		depth := 1
+		for l.pos < len(l.src) {
+			c := l.src[l.pos]
+			if c == open && open != close {
+				depth++
+			} else if c == close {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+			if c == '\n' {
+				l.line++
+			}
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return newRbErrorAt("SyntaxError", "unterminated list", l.line)
+		}
+		words := strings.Fields(string(l.src[start:l.pos]))
+		l.pos++
+		token := rbToken{kind: rbTokenWords, words: words}
+		if kind == 'i' || kind == 'I' {
+			token.text = "symbols"
+		}
+		l.emit(token)
+		return nil
+	case 'q':
+		return l.lexString(open, close, false)
+	default:
+		return l.lexString(open, close, true)
+	}
+}
+
+func (l *rbLexer) lexString(open, close rune, interpolate bool) error {
+	var (
+		parts []rbStringPart
+		buf   []rune
+		depth = 1
+		line  = l.line
+	)
+
+	for {
+		if l.pos >= len(l.src) {
+			return newRbErrorAt("SyntaxError", "unterminated string meets end of file", line)
+		}
+
+		c := l.src[l.pos]
+
+		switch {
+		case c == '\\':
+			next := l.peekAt(1)
+			l.pos += 2
+			if !interpolate {
+				if next == close || next == '\\' || next == open {
+					buf = append(buf, next)
+				} else {
+					buf = append(buf, '\\', next)
+				}
+				continue
+			}
+			switch next {
+			case 'n':
+				buf = append(buf, '\n')
+			case 't':
+				buf = append(buf, '\t')
+			case 'r':
+				buf = append(buf, '\r')
+			case 'e':
+				buf = append(buf, '\x1b')
+			case 's':
+				buf = append(buf, ' ')
+			case '0':
+				buf = append(buf, 0)
+			case '\n':
+				l.line++
+			default:
+				buf = append(buf, next)
+			}
+
+		case interpolate && c == '#' && l.peekAt(1) == '{':
+			l.pos += 2
+			start := l.pos
+			braces := 1
+			for l.pos < len(l.src) && braces > 0 {
+				switch l.src[l.pos] {
+				case '{':
+					braces++
+				case '}':
+					braces--
+				case '\n':
+					l.line++
+				}
+				l.pos++
+			}
+			if braces > 0 {
+				return newRbErrorAt("SyntaxError", "unterminated string interpolation", line)
+			}
+			codeTokens, err := rbTokenize(string(l.src[start:l.pos-1]), l.line)
+			if err != nil {
+				return err
+			}
+			if len(buf) > 0 {
+				parts = append(parts, rbStringPart{lit: string(buf)})
+				buf = nil
+			}
+			parts = append(parts, rbStringPart{tokens: codeTokens, isCode: true})
+
+		case c == open && open != close:
+			depth++
+			buf = append(buf, c)
+			l.pos++
+
+		case c == close:
+			depth--
+			l.pos++
+			if depth == 0 {
+				if len(buf) > 0 || len(parts) == 0 {
+					parts = append(parts, rbStringPart{lit: string(buf)})
+				}
+				l.emit(rbToken{kind: rbTokenString, parts: parts})
+				l.tokens[len(l.tokens)-1].line = line
+				return nil
+			}
+			buf = append(buf, c)
+
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			buf = append(buf, c)
+			l.pos++
+		}
+	}
+}
+
+func (l *rbLexer) lexRegexp(close rune) error {
+	var buf []rune
+
+	line := l.line
+
+	for {
+		if l.pos >= len(l.src) {
+			return newRbErrorAt("SyntaxError", "unterminated regexp meets end of file", line)
+		}
+
+		c := l.src[l.pos]
+
+		if c == '\\' && l.peekAt(1) == close {
+			buf = append(buf, close)
+			l.pos += 2
+			continue
+		}
+
+		if c == '\\' {
+			buf = append(buf, c, l.peekAt(1))
+			l.pos += 2
+			continue
+		}
+
+		l.pos++
+
+		if c == close {
+			break
+		}
+
+		buf = append(buf, c)
+	}
+
+	start := l.pos
+	for l.pos < len(l.src) && strings.ContainsRune("imxo", l.src[l.pos]) {
+		l.pos++
+	}
+
+	l.emit(rbToken{kind: rbTokenRegexp, text: string(buf), flags: string(l.src[start:l.pos])})
+
+	return nil
+}
+
+var rbOperatorSymbols = []string{"[]=", "[]", "<=>", "===", "==", "=~", "<=", ">=", "<<", ">>", "**", "+", "-", "*", "/", "%", "<", ">", "!", "&", "|", "^"}
+
+// operatorSymbol returns operator following ':' when it forms symbol such as :+
+func (l *rbLexer) operatorSymbol() string {
+	for _, op := range rbOperatorSymbols {
+		if l.pos+1 < len(l.src) && strings.HasPrefix(string(l.src[l.pos+1:]), op) {
+			return op
+		}
+	}
+	return ""
+}
@@ -0,0 +1,1041 @@
+package erbrenderer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var rbKnownConsts = map[string]bool{
+	"Object": true, "BasicObject": true, "Kernel": true, "Comparable": true, "Enumerable": true,
+	"Hash": true, "Array": true, "String": true, "Symbol": true, "Integer": true, "Fixnum": true,
+	"Bignum": true, "Float": true, "Numeric": true, "NilClass": true, "TrueClass": true,
+	"FalseClass": true, "Regexp": true, "Range": true, "Enumerator": true, "OpenStruct": true,
+	"JSON": true, "YAML": true, "Psych": true, "Base64": true, "StandardError": true, "RuntimeError": true,
+	"ArgumentError": true, "TypeError": true, "NoMethodError": true, "NameError": true,
+}
+
+func (i *rbInterpreter) evalConst(name string) (interface{}, error) {
+	if rbKnownConsts[name] {
+		return rbClass(name), nil
+	}
+	return nil, newRbError("NameError", fmt.Sprintf("uninitialized constant %s", name))
+}
+
+func rbCheckArgs(args []interface{}, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		switch {
+		case min == max:
+			return rbArgumentError(len(args), strconv.Itoa(min))
+		case max < 0:
+			return rbArgumentError(len(args), fmt.Sprintf("%d+", min))
+		default:
+			return rbArgumentError(len(args), fmt.Sprintf("%d..%d", min, max))
+		}
+	}
+	return nil
+}
+
+func rbArgInt(val interface{}) (int, error) {
+	switch typedVal := val.(type) {
+	case int:
+		return typedVal, nil
+	case float64:
+		result, err := rbFloatToInteger(typedVal)
+		if err != nil {
+			return 0, err
+		}
+		return rbArgInt(result)
+	case *big.Int:
+		return 0, newRbError("RangeError", "bignum too big to convert into `long'")
+	default:
+		return 0, rbTypeError(val, "Integer")
+	}
+}
+
+func rbArgString(val interface{}) (string, error) {
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+	return "", rbTypeError(val, "String")
+}
+
+func rbNoBlockError() error {
+	return newRbError("LocalJumpError", "no block given (yield)")
+}
+
+// callMethod dispatches method call to receiver
+func (i *rbInterpreter) callMethod(recv interface{}, name string, args []interface{}, block *rbBlock) (interface{}, error) {
+	var (
+		val     interface{}
+		handled bool
+		err     error
+	)
+
+	if block == nil && len(args) == 0 && rbEnumeratorMethods[name] {
+		switch recv.(type) {
+		case *rbArray, *rbHash, *rbRange, *rbEnumerator:
+			return &rbEnumerator{recv: recv, method: name}, nil
+		}
+	}
+
+	switch typedRecv := recv.(type) {
+	case nil:
+		val, handled, err = i.callNilMethod(name, args)
+	case bool:
+		val, handled, err = i.callBoolMethod(typedRecv, name, args)
+	case int, float64:
+		val, handled, err = i.callNumericMethod(typedRecv, name, args, block)
+	case *big.Int:
+		val, handled, err = i.callBignumMethod(typedRecv, name, args, block)
+	case string:
+		val, handled, err = i.callStringMethod(typedRecv, name, args, block)
+	case rbSymbol:
+		val, handled, err = i.callSymbolMethod(typedRecv, name, args)
+	case *rbArray:
+		val, handled, err = i.callArrayMethod(typedRecv, name, args, block)
+	case *rbHash:
+		val, handled, err = i.callHashMethod(typedRecv, name, args, block)
+	case *rbRange:
+		val, handled, err = i.callRangeMethod(typedRecv, name, args, block)
+	case *rbEnumerator:
+		val, handled, err = i.callEnumeratorMethod(typedRecv, name, args, block)
+	case *rbRegexp:
+		val, handled, err = i.callRegexpMethod(typedRecv, name, args)
+	case *rbMatchData:
+		val, handled, err = i.callMatchDataMethod(typedRecv, name, args)
+	case *rbOpenStruct:
+		val, handled, err = i.callOpenStructMethod(typedRecv, name, args, block)
+	case rbClass:
+		val, handled, err = i.callClassMethod(typedRecv, name, args, block)
+	case *rbElseBlock:
+		val, handled, err = i.callElseBlockMethod(typedRecv, name, args, block)
+	case *rbError:
+		val, handled, err = i.callErrorMethod(typedRecv, name, args)
+	case *rbTemplateContext:
+		return typedRecv.Call(i, name, args, block)
+	case *rbLink:
+		val, handled, err = typedRecv.Call(name, args, block)
+	case *rbBlock:
+		if name == "call" || name == "yield" || name == "()" {
+			return typedRecv.Call(args...)
+		}
+	}
+
+	if handled {
+		return val, err
+	}
+
+	val, handled, err = i.callObjectMethod(recv, name, args, block)
+	if handled {
+		return val, err
+	}
+
+	return nil, rbNoMethodError(name, recv)
+}
+
+// callObjectMethod implements methods available on every object
+func (i *rbInterpreter) callObjectMethod(recv interface{}, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "nil?":
+		return recv == nil, true, nil
+	case "==", "eql?", "equal?":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return rbEqual(recv, args[0]), true, nil
+	case "!=":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return !rbEqual(recv, args[0]), true, nil
+	case "!":
+		return !rbTruthy(recv), true, nil
+	case "===":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return rbEqual(recv, args[0]), true, nil
+	case "=~":
+		return nil, true, nil
+	case "is_a?", "kind_of?", "instance_of?":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		class, ok := args[0].(rbClass)
+		if !ok {
+			return nil, true, newRbError("TypeError", "class or module required")
+		}
+		return rbIsA(recv, class), true, nil
+	case "class":
+		return rbClass(rbClassName(recv)), true, nil
+	case "to_s":
+		return rbToS(recv), true, nil
+	case "inspect":
+		return rbInspect(recv), true, nil
+	case "to_json":
+		str, err := rbToJSON(recv, "")
+		return str, true, err
+	case "to_yaml":
+		str, err := rbToYAML(recv)
+		return str, true, err
+	case "freeze", "itself", "dup", "clone", "taint", "untaint":
+		return rbDup(recv, name), true, nil
+	case "frozen?":
+		return false, true, nil
+	case "tap":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		_, err := block.Call(recv)
+		return recv, true, err
+	case "then", "yield_self":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		val, err := block.Call(recv)
+		return val, true, err
+	case "send", "public_send", "__send__":
+		if err := rbCheckArgs(args, 1, -1); err != nil {
+			return nil, true, err
+		}
+		val, err := i.callMethod(recv, rbToS(args[0]), args[1:], block)
+		return val, true, err
+	case "respond_to?":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		return i.respondTo(recv, rbToS(args[0])), true, nil
+	case "instance_variable_get":
+		return nil, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) respondTo(recv interface{}, name string) bool {
+	if os, ok := recv.(*rbOpenStruct); ok {
+		_, found := os.hash.Get(name)
+		return found
+	}
+
+	// Probe method on a copy so that mutating methods do not affect receiver
+	_, err := i.callMethod(rbDup(recv, "dup"), name, nil, nil)
+	if rbErr, ok := err.(*rbError); ok && rbErr.class == "NoMethodError" && strings.Contains(rbErr.msg, "`"+name+"'") {
+		return false
+	}
+
+	return true
+}
+
+func rbDup(val interface{}, name string) interface{} {
+	if name != "dup" && name != "clone" {
+		return val
+	}
+
+	switch typedVal := val.(type) {
+	case *rbArray:
+		return newRbArray(append([]interface{}{}, typedVal.items...)...)
+	case *rbHash:
+		return typedVal.Copy()
+	case *rbOpenStruct:
+		return &rbOpenStruct{hash: typedVal.hash.Copy()}
+	default:
+		return val
+	}
+}
+
+func (i *rbInterpreter) callNilMethod(name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "to_a":
+		return newRbArray(), true, nil
+	case "to_h":
+		return newRbHash(), true, nil
+	case "to_i":
+		return 0, true, nil
+	case "to_f":
+		return 0.0, true, nil
+	case "&":
+		return false, true, nil
+	case "|":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return rbTruthy(args[0]), true, nil
+	}
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callBoolMethod(recv bool, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "&", "|", "^":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		other := rbTruthy(args[0])
+		switch name {
+		case "&":
+			return recv && other, true, nil
+		case "|":
+			return recv || other, true, nil
+		default:
+			return recv != other, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callSymbolMethod(recv rbSymbol, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "to_sym":
+		return recv, true, nil
+	case "to_proc":
+		block, err := i.blockFromArg(recv)
+		return block, true, err
+	case "length", "size", "upcase", "downcase", "capitalize", "empty?", "start_with?", "end_with?", "[]":
+		val, err := i.callMethod(string(recv), name, args, nil)
+		if str, ok := val.(string); ok && name != "[]" {
+			return rbSymbol(str), true, err
+		}
+		return val, true, err
+	case "<=>":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		result, err := rbCompare(recv, args[0])
+		if err != nil {
+			return nil, true, nil
+		}
+		return result, true, nil
+	}
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callNumericMethod(recv interface{}, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	recvInt, isInt := recv.(int)
+	recvFloat, _ := rbToFloat(recv)
+
+	switch name {
+	case "+", "-", "*", "/", "%", "modulo", "**", "pow", "div", "fdiv":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, err := rbArithmetic(recv, name, args[0])
+		return val, true, err
+
+	case "<", "<=", ">", ">=", "<=>":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		result, err := rbCompare(recv, args[0])
+		if err != nil {
+			if name == "<=>" {
+				return nil, true, nil
+			}
+			return nil, true, err
+		}
+		return rbCompareResult(name, result), true, nil
+
+	case "between?":
+		if err := rbCheckArgs(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		low, err := rbCompare(recv, args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		high, err := rbCompare(recv, args[1])
+		if err != nil {
+			return nil, true, err
+		}
+		return low >= 0 && high <= 0, true, nil
+
+	case "-@":
+		if isInt {
+			val, err := rbArithmetic(0, "-", recvInt)
+			return val, true, err
+		}
+		return -recvFloat, true, nil
+
+	case "to_s", "inspect":
+		if isInt && len(args) == 1 {
+			base, err := rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			if base < 2 || base > 36 {
+				return nil, true, newRbError("ArgumentError", fmt.Sprintf("invalid radix %d", base))
+			}
+			return strconv.FormatInt(int64(recvInt), base), true, nil
+		}
+		return rbInspect(recv), true, nil
+
+	case "to_i", "to_int", "truncate":
+		if isInt {
+			return recvInt, true, nil
+		}
+		val, err := rbFloatToInteger(recvFloat)
+		return val, true, err
+
+	case "to_f":
+		return recvFloat, true, nil
+
+	case "abs", "magnitude":
+		if isInt {
+			if recvInt < 0 {
+				val, err := rbArithmetic(0, "-", recvInt)
+				return val, true, err
+			}
+			return recvInt, true, nil
+		}
+		return math.Abs(recvFloat), true, nil
+
+	case "round", "floor", "ceil":
+		digits := 0
+		if len(args) > 0 {
+			var err error
+			digits, err = rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		if isInt && digits >= 0 {
+			return recvInt, true, nil
+		}
+		factor := math.Pow(10, float64(digits))
+		var result float64
+		switch name {
+		case "round":
+			result = math.Round(recvFloat*factor) / factor
+		case "floor":
+			result = math.Floor(recvFloat*factor) / factor
+		default:
+			result = math.Ceil(recvFloat*factor) / factor
+		}
+		if digits > 0 {
+			return result, true, nil
+		}
+		val, err := rbFloatToInteger(result)
+		return val, true, err
+
+	case "zero?":
+		return recvFloat == 0, true, nil
+	case "positive?":
+		return recvFloat > 0, true, nil
+	case "negative?":
+		return recvFloat < 0, true, nil
+	case "integer?":
+		return isInt, true, nil
+	case "nan?":
+		return math.IsNaN(recvFloat), true, nil
+	case "finite?":
+		return !math.IsInf(recvFloat, 0) && !math.IsNaN(recvFloat), true, nil
+	case "infinite?":
+		if math.IsInf(recvFloat, 1) {
+			return 1, true, nil
+		} else if math.IsInf(recvFloat, -1) {
+			return -1, true, nil
+		}
+		return nil, true, nil
+	}
+
+	if !isInt {
+		return nil, false, nil
+	}
+
+	switch name {
+	case "even?":
+		return recvInt%2 == 0, true, nil
+	case "odd?":
+		return recvInt%2 != 0, true, nil
+	case "succ", "next":
+		val, err := rbArithmetic(recvInt, "+", 1)
+		return val, true, err
+	case "pred":
+		val, err := rbArithmetic(recvInt, "-", 1)
+		return val, true, err
+	case "chr":
+		return string(rune(recvInt)), true, nil
+	case "times":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		for idx := 0; idx < recvInt; idx++ {
+			if _, err := block.Call(idx); err != nil {
+				return nil, true, err
+			}
+		}
+		return recvInt, true, nil
+	case "upto", "downto":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		limit, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		var items []interface{}
+		if name == "upto" {
+			for idx := recvInt; idx <= limit; idx++ {
+				items = append(items, idx)
+			}
+		} else {
+			for idx := recvInt; idx >= limit; idx-- {
+				items = append(items, idx)
+			}
+		}
+		if block == nil {
+			return newRbArray(items...), true, nil
+		}
+		for _, item := range items {
+			if _, err := block.Call(item); err != nil {
+				return nil, true, err
+			}
+		}
+		return recvInt, true, nil
+	case "&", "|", "^", "<<", ">>":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		other, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		switch name {
+		case "&":
+			return recvInt & other, true, nil
+		case "|":
+			return recvInt | other, true, nil
+		case "^":
+			return recvInt ^ other, true, nil
+		case "<<":
+			val, err := rbShift(big.NewInt(int64(recvInt)), other)
+			return val, true, err
+		default:
+			val, err := rbShift(big.NewInt(int64(recvInt)), -other)
+			return val, true, err
+		}
+	case "~":
+		return ^recvInt, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callBignumMethod(recv *big.Int, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "-@":
+		return rbNormalizeInteger(new(big.Int).Neg(recv)), true, nil
+
+	case "abs", "magnitude":
+		return rbNormalizeInteger(new(big.Int).Abs(recv)), true, nil
+
+	case "to_s", "inspect":
+		if len(args) == 1 {
+			base, err := rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			if base < 2 || base > 36 {
+				return nil, true, newRbError("ArgumentError", fmt.Sprintf("invalid radix %d", base))
+			}
+			return recv.Text(base), true, nil
+		}
+		return rbInspect(recv), true, nil
+
+	case "to_i", "to_int", "truncate", "round", "floor", "ceil":
+		if len(args) == 0 {
+			return recv, true, nil
+		}
+
+	case "integer?":
+		return true, true, nil
+	case "even?":
+		return recv.Bit(0) == 0, true, nil
+	case "odd?":
+		return recv.Bit(0) == 1, true, nil
+	case "succ", "next":
+		val, err := rbArithmetic(recv, "+", 1)
+		return val, true, err
+	case "pred":
+		val, err := rbArithmetic(recv, "-", 1)
+		return val, true, err
+	}
+
+	return i.callNumericMethod(recv, name, args, block)
+}
+
+func rbCompareResult(op string, result int) interface{} {
+	switch op {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	default:
+		return result
+	}
+}
+
+func rbArithmetic(left interface{}, op string, right interface{}) (interface{}, error) {
+	rightFloat, ok := rbToFloat(right)
+	if !ok {
+		return nil, newRbError("TypeError", fmt.Sprintf("%s can't be coerced into %s", rbClassName(right), rbClassName(left)))
+	}
+
+	leftFloat, _ := rbToFloat(left)
+
+	leftInt, leftIsInt := rbToBigInt(left)
+	rightInt, rightIsInt := rbToBigInt(right)
+
+	if leftIsInt && rightIsInt && op != "fdiv" {
+		result := new(big.Int)
+
+		switch op {
+		case "+":
+			return rbNormalizeInteger(result.Add(leftInt, rightInt)), nil
+		case "-":
+			return rbNormalizeInteger(result.Sub(leftInt, rightInt)), nil
+		case "*":
+			return rbNormalizeInteger(result.Mul(leftInt, rightInt)), nil
+		case "/", "div", "%", "modulo":
+			if rightInt.Sign() == 0 {
+				return nil, newRbError("ZeroDivisionError", "divided by 0")
+			}
+			remainder := new(big.Int)
+			result.QuoRem(leftInt, rightInt, remainder)
+			// Ruby rounds integer division towards negative infinity
+			if remainder.Sign() != 0 && (remainder.Sign() < 0) != (rightInt.Sign() < 0) {
+				result.Sub(result, big.NewInt(1))
+				remainder.Add(remainder, rightInt)
+			}
+			if op == "/" || op == "div" {
+				return rbNormalizeInteger(result), nil
+			}
+			return rbNormalizeInteger(remainder), nil
+		case "**", "pow":
+			// Like Ruby, give up on exact result and fall back to Float when exponent is too large
+			if rightInt.Sign() >= 0 && (leftInt.CmpAbs(big.NewInt(1)) <= 0 ||
+				(rightInt.IsInt64() && rightInt.Int64() <= rbMaxIntegerBits/int64(leftInt.BitLen()))) {
+				return rbNormalizeInteger(result.Exp(leftInt, rightInt, nil)), nil
+			}
+			return math.Pow(leftFloat, rightFloat), nil
+		}
+	}
+
+	switch op {
+	case "+":
+		return leftFloat + rightFloat, nil
+	case "-":
+		return leftFloat - rightFloat, nil
+	case "*":
+		return leftFloat * rightFloat, nil
+	case "/", "fdiv":
+		return leftFloat / rightFloat, nil
+	case "div":
+		return rbFloatToInteger(math.Floor(leftFloat / rightFloat))
+	case "%", "modulo":
+		return leftFloat - rightFloat*math.Floor(leftFloat/rightFloat), nil
+	default:
+		return math.Pow(leftFloat, rightFloat), nil
+	}
+}
+
+// rbMaxIntegerBits limits size of integers produced by ** and <<
+// so that templates cannot exhaust memory
+const rbMaxIntegerBits = 1 << 20
+
+// rbNormalizeInteger keeps integers that fit into int as int
+// and only uses *big.Int (Ruby's Bignum) for larger values
+func rbNormalizeInteger(val *big.Int) interface{} {
+	if val.IsInt64() {
+		return int(val.Int64())
+	}
+	return val
+}
+
+// rbFloatToInteger truncates float the way Float#to_i does
+func rbFloatToInteger(val float64) (interface{}, error) {
+	switch {
+	case math.IsNaN(val):
+		return nil, newRbError("FloatDomainError", "NaN")
+	case math.IsInf(val, 1):
+		return nil, newRbError("FloatDomainError", "Infinity")
+	case math.IsInf(val, -1):
+		return nil, newRbError("FloatDomainError", "-Infinity")
+	}
+
+	result, _ := big.NewFloat(math.Trunc(val)).Int(nil)
+
+	return rbNormalizeInteger(result), nil
+}
+
+func rbShift(val *big.Int, width int) (interface{}, error) {
+	if width < 0 {
+		return rbNormalizeInteger(new(big.Int).Rsh(val, uint(-width))), nil
+	}
+	if width > rbMaxIntegerBits {
+		return nil, newRbError("RangeError", "shift width too big")
+	}
+	return rbNormalizeInteger(new(big.Int).Lsh(val, uint(width))), nil
+}
+
+func (i *rbInterpreter) callRegexpMethod(recv *rbRegexp, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "=~", "match", "match?", "===":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		if args[0] == nil {
+			if name == "===" || name == "match?" {
+				return false, true, nil
+			}
+			return nil, true, nil
+		}
+		str, ok := args[0].(string)
+		if !ok {
+			if sym, isSym := args[0].(rbSymbol); isSym {
+				str = string(sym)
+			} else if name == "===" {
+				return false, true, nil
+			} else {
+				return nil, true, rbTypeError(args[0], "String")
+			}
+		}
+		return rbRegexpMatch(recv, str, name), true, nil
+	case "source":
+		return recv.source, true, nil
+	}
+	return nil, false, nil
+}
+
+func rbRegexpMatch(re *rbRegexp, str string, name string) interface{} {
+	loc := re.re.FindStringSubmatchIndex(str)
+
+	switch name {
+	case "match?", "===":
+		return loc != nil
+	case "match":
+		if loc == nil {
+			return nil
+		}
+		return newRbMatchData(re, str, loc)
+	default:
+		if loc == nil {
+			return nil
+		}
+		return len([]rune(str[:loc[0]]))
+	}
+}
+
+type rbMatchData struct {
+	groups []interface{}
+	names  []string
+	pre    string
+	post   string
+}
+
+func newRbMatchData(re *rbRegexp, str string, loc []int) *rbMatchData {
+	md := &rbMatchData{names: re.re.SubexpNames(), pre: str[:loc[0]], post: str[loc[1]:]}
+
+	for idx := 0; idx < len(loc)/2; idx++ {
+		if loc[2*idx] < 0 {
+			md.groups = append(md.groups, nil)
+		} else {
+			md.groups = append(md.groups, str[loc[2*idx]:loc[2*idx+1]])
+		}
+	}
+
+	return md
+}
+
+func (i *rbInterpreter) callMatchDataMethod(recv *rbMatchData, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "[]":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		switch key := args[0].(type) {
+		case int:
+			if key < 0 {
+				key += len(recv.groups)
+			}
+			if key < 0 || key >= len(recv.groups) {
+				return nil, true, nil
+			}
+			return recv.groups[key], true, nil
+		case string, rbSymbol:
+			for idx, groupName := range recv.names {
+				if groupName == rbToS(key) && len(groupName) > 0 {
+					return recv.groups[idx], true, nil
+				}
+			}
+			return nil, true, newRbError("IndexError", fmt.Sprintf("undefined group name reference: %s", rbToS(key)))
+		}
+		return nil, true, rbTypeError(args[0], "Integer")
+	case "captures":
+		return newRbArray(recv.groups[1:]...), true, nil
+	case "to_a":
+		return newRbArray(recv.groups...), true, nil
+	case "pre_match":
+		return recv.pre, true, nil
+	case "post_match":
+		return recv.post, true, nil
+	case "to_s":
+		return recv.groups[0], true, nil
+	}
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callOpenStructMethod(recv *rbOpenStruct, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "[]":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		val, _ := recv.hash.Get(rbToS(args[0]))
+		return val, true, nil
+	case "[]=":
+		if err := rbCheckArgs(args, 2, 2); err != nil {
+			return nil, true, err
+		}
+		recv.hash.Set(rbToS(args[0]), args[1])
+		return args[1], true, nil
+	case "to_h":
+		return recv.hash.Copy(), true, nil
+	case "each_pair":
+		val, handled, err := i.callHashMethod(recv.hash, "each_pair", args, block)
+		if val == recv.hash {
+			val = recv
+		}
+		return val, handled, err
+	case "dig":
+		val, handled, err := i.callHashMethod(recv.hash, "dig", args, block)
+		return val, handled, err
+	case "respond_to?":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		_, found := recv.hash.Get(rbToS(args[0]))
+		return found, true, nil
+	}
+
+	if strings.HasSuffix(name, "=") && len(args) == 1 && name != "==" {
+		recv.hash.Set(strings.TrimSuffix(name, "="), args[0])
+		return args[0], true, nil
+	}
+
+	if len(args) == 0 {
+		if val, found := recv.hash.Get(name); found {
+			return val, true, nil
+		}
+
+		switch name {
+		case "nil?", "to_s", "inspect", "class", "to_json", "to_yaml", "freeze", "dup", "clone", "frozen?", "itself", "tap", "then":
+			return nil, false, nil
+		}
+
+		// OpenStruct returns nil for unknown attributes
+		return nil, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callElseBlockMethod(recv *rbElseBlock, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "else":
+		if !recv.active {
+			return nil, true, nil
+		}
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		val, err := block.Call()
+		return val, true, err
+	case "else_if_p":
+		if !recv.active {
+			return &rbElseBlock{}, true, nil
+		}
+		val, err := i.context.Call(i, "if_p", args, block)
+		return val, true, err
+	case "else_if_link":
+		if !recv.active {
+			return &rbElseBlock{}, true, nil
+		}
+		val, err := i.context.Call(i, "if_link", args, block)
+		return val, true, err
+	}
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callErrorMethod(recv *rbError, name string, args []interface{}) (interface{}, bool, error) {
+	switch name {
+	case "message", "to_s":
+		return recv.msg, true, nil
+	case "inspect":
+		return recv.Inspect(), true, nil
+	case "class":
+		return rbClass(recv.class), true, nil
+	}
+	return nil, false, nil
+}
+
+func (i *rbInterpreter) callClassMethod(recv rbClass, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	switch name {
+	case "===":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		return rbIsA(args[0], recv), true, nil
+	case "name", "to_s", "inspect":
+		return string(recv), true, nil
+	}
+
+	switch recv {
+	case "JSON":
+		switch name {
+		case "dump", "generate", "pretty_generate":
+			if err := rbCheckArgs(args, 1, 2); err != nil {
+				return nil, true, err
+			}
+			// Ruby renderer patches JSON.dump to allow scalar values
+			if name == "dump" {
+				switch args[0].(type) {
+				case string, int, float64:
+					return rbInspect(args[0]), true, nil
+				}
+			}
+			indent := ""
+			if name == "pretty_generate" {
+				indent = "  "
+			}
+			str, err := rbToJSON(args[0], indent)
+			return str, true, err
+		case "parse", "load":
+			if err := rbCheckArgs(args, 1, 2); err != nil {
+				return nil, true, err
+			}
+			str, err := rbArgString(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			val, err := rbFromJSON([]byte(str))
+			return val, true, err
+		}
+
+	case "YAML", "Psych":
+		if name == "dump" {
+			if err := rbCheckArgs(args, 1, 1); err != nil {
+				return nil, true, err
+			}
+			str, err := rbToYAML(args[0])
+			return str, true, err
+		}
+
+	case "Base64":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		str, err := rbArgString(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		switch name {
+		case "encode64":
+			encoded := base64.StdEncoding.EncodeToString([]byte(str))
+			var lines []string
+			for len(encoded) > 60 {
+				lines = append(lines, encoded[:60])
+				encoded = encoded[60:]
+			}
+			lines = append(lines, encoded)
+			return strings.Join(lines, "\n") + "\n", true, nil
+		case "strict_encode64":
+			return base64.StdEncoding.EncodeToString([]byte(str)), true, nil
+		case "urlsafe_encode64":
+			return base64.URLEncoding.EncodeToString([]byte(str)), true, nil
+		case "decode64", "strict_decode64", "urlsafe_decode64":
+			encoding := base64.StdEncoding
+			if name == "urlsafe_decode64" {
+				encoding = base64.URLEncoding
+			}
+			decoded, err := encoding.DecodeString(strings.Replace(str, "\n", "", -1))
+			if err != nil {
+				return nil, true, newRbError("ArgumentError", "invalid base64")
+			}
+			return string(decoded), true, nil
+		}
+
+	case "Hash":
+		if name == "new" {
+			return newRbHash(), true, nil
+		}
+
+	case "Array":
+		if name == "new" {
+			arr := newRbArray()
+			if len(args) > 0 {
+				size, err := rbArgInt(args[0])
+				if err != nil {
+					return nil, true, err
+				}
+				var fill interface{}
+				if len(args) > 1 {
+					fill = args[1]
+				}
+				for idx := 0; idx < size; idx++ {
+					if block != nil {
+						val, err := block.Call(idx)
+						if err != nil {
+							return nil, true, err
+						}
+						arr.items = append(arr.items, val)
+					} else {
+						arr.items = append(arr.items, fill)
+					}
+				}
+			}
+			return arr, true, nil
+		}
+
+	case "String":
+		if name == "new" {
+			if len(args) > 0 {
+				return rbToS(args[0]), true, nil
+			}
+			return "", true, nil
+		}
+
+	case "OpenStruct":
+		if name == "new" {
+			if len(args) > 0 {
+				if hash, ok := args[0].(*rbHash); ok {
+					result := newRbHash()
+					for _, key := range hash.keys {
+						result.Set(rbToS(key), hash.vals[key])
+					}
+					return &rbOpenStruct{hash: result}, true, nil
+				}
+			}
+			return &rbOpenStruct{hash: newRbHash()}, true, nil
+		}
+
+	case "StandardError", "RuntimeError", "ArgumentError", "TypeError":
+		if name == "new" {
+			msg := string(recv)
+			if len(args) > 0 {
+				msg = rbToS(args[0])
+			}
+			return newRbError(string(recv), msg), true, nil
+		}
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,1231 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+type rbNode interface{}
+
+type (
+	rbTextNode struct {
+		text string
+	}
+
+	rbOutputNode struct {
+		expr rbNode
+	}
+
+	rbLiteralNode struct {
+		val interface{}
+	}
+
+	rbStringNode struct {
+		parts []rbNode
+	}
+
+	rbSymbolNode struct {
+		str *rbStringNode
+	}
+
+	rbArrayNode struct {
+		elems []rbNode
+	}
+
+	rbHashNode struct {
+		keys []rbNode
+		vals []rbNode
+	}
+
+	rbRangeNode struct {
+		from, to  rbNode
+		exclusive bool
+	}
+
+	rbSplatNode struct {
+		expr rbNode
+	}
+
+	rbVarNode struct {
+		name string
+	}
+
+	rbConstNode struct {
+		name string
+	}
+
+	rbSelfNode struct{}
+
+	rbAssignNode struct {
+		name  string
+		op    string
+		value rbNode
+	}
+
+	rbIndexAssignNode struct {
+		recv  rbNode
+		args  []rbNode
+		op    string
+		value rbNode
+		line  int
+	}
+
+	rbAttrAssignNode struct {
+		recv  rbNode
+		name  string
+		value rbNode
+		line  int
+	}
+
+	rbCallNode struct {
+		recv     rbNode // nil for calls on template context
+		name     string
+		args     []rbNode
+		block    *rbBlockNode
+		blockArg rbNode
+		safeNav  bool
+		line     int
+	}
+
+	rbBlockNode struct {
+		params []rbParam
+		body   []rbNode
+	}
+
+	rbAndNode struct {
+		left, right rbNode
+	}
+
+	rbOrNode struct {
+		left, right rbNode
+	}
+
+	rbNotNode struct {
+		expr rbNode
+	}
+
+	rbIfNode struct {
+		cond      rbNode
+		then, els []rbNode
+	}
+
+	rbWhileNode struct {
+		cond  rbNode
+		body  []rbNode
+		until bool
+	}
+
+	rbCaseNode struct {
+		subject rbNode
+		whens   []rbWhen
+		els     []rbNode
+	}
+
+	rbBeginNode struct {
+		body       []rbNode
+		rescueVar  string
+		rescue     []rbNode
+		hasRescue  bool
+		ensureBody []rbNode
+	}
+
+	rbSeqNode struct {
+		stmts []rbNode
+	}
+
+	rbNextNode struct {
+		val rbNode
+	}
+
+	rbBreakNode struct {
+		val rbNode
+	}
+
+	rbDefinedNode struct {
+		expr rbNode
+	}
+)
+
+type rbWhen struct {
+	values []rbNode
+	body   []rbNode
+}
+
+type rbParam struct {
+	name  string
+	sub   []rbParam
+	splat bool
+}
+
+type rbParser struct {
+	tokens []rbToken
+	pos    int
+
+	locals []map[string]bool
+	noDo   int
+}
+
+func rbParse(tokens []rbToken) (stmts []rbNode, err error) {
+	p := &rbParser{tokens: tokens, locals: []map[string]bool{{}}}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if parseErr, ok := r.(*rbError); ok {
+				err = parseErr
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	stmts = p.parseStatements()
+
+	if p.peek().kind != rbTokenEOF {
+		p.fail("unexpected %s", p.describe(p.peek()))
+	}
+
+	return stmts, nil
+}
+
+func (p *rbParser) fail(msg string, args ...interface{}) {
+	panic(newRbErrorAt("SyntaxError", fmt.Sprintf(msg, args...), p.peek().line))
+}
+
+func (p *rbParser) describe(t rbToken) string {
+	switch t.kind {
+	case rbTokenEOF:
+		return "end-of-input"
+	case rbTokenNewline:
+		return "end-of-line"
+	case rbTokenText, rbTokenOutStart:
+		return "template text"
+	case rbTokenOutEnd:
+		return "'%>'"
+	case rbTokenString:
+		return "string literal"
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+func (p *rbParser) peek() rbToken {
+	return p.tokens[p.pos]
+}
+
+func (p *rbParser) peekAt(offset int) rbToken {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *rbParser) next() rbToken {
+	t := p.tokens[p.pos]
+	if t.kind != rbTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *rbParser) isOp(text string) bool {
+	t := p.peek()
+	return t.kind == rbTokenOp && t.text == text
+}
+
+func (p *rbParser) isKeyword(text string) bool {
+	t := p.peek()
+	return t.kind == rbTokenKeyword && t.text == text
+}
+
+func (p *rbParser) acceptOp(text string) bool {
+	if p.isOp(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *rbParser) acceptKeyword(text string) bool {
+	if p.isKeyword(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *rbParser) expectOp(text string) {
+	if !p.acceptOp(text) {
+		p.fail("unexpected %s, expecting '%s'", p.describe(p.peek()), text)
+	}
+}
+
+func (p *rbParser) expectKeyword(text string) {
+	if !p.acceptKeyword(text) {
+		p.fail("unexpected %s, expecting '%s'", p.describe(p.peek()), text)
+	}
+}
+
+func (p *rbParser) skipNewlines() {
+	for p.peek().kind == rbTokenNewline {
+		p.next()
+	}
+}
+
+func (p *rbParser) skipTerms() {
+	for p.peek().kind == rbTokenNewline || p.isOp(";") {
+		p.next()
+	}
+}
+
+// Local variables are tracked to distinguish them from method calls without arguments
+func (p *rbParser) declareLocal(name string) {
+	p.locals[len(p.locals)-1][name] = true
+}
+
+func (p *rbParser) isLocal(name string) bool {
+	for i := len(p.locals) - 1; i >= 0; i-- {
+		if p.locals[i][name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *rbParser) atTerminator() bool {
+	t := p.peek()
+
+	switch t.kind {
+	case rbTokenEOF, rbTokenOutEnd:
+		return true
+	case rbTokenKeyword:
+		switch t.text {
+		case "end", "else", "elsif", "when", "rescue", "ensure", "in":
+			return true
+		}
+	case rbTokenOp:
+		return t.text == "}" || t.text == ")"
+	}
+
+	return false
+}
+
+func (p *rbParser) parseStatements() []rbNode {
+	var stmts []rbNode
+
+	for {
+		p.skipTerms()
+
+		if p.atTerminator() {
+			return stmts
+		}
+
+		stmt := p.parseStatement()
+		stmts = append(stmts, stmt)
+
+		// Template text separates statements the same way as newline does
+		if _, isText := stmt.(*rbTextNode); isText {
+			continue
+		}
+
+		switch t := p.peek(); {
+		case t.kind == rbTokenNewline, t.kind == rbTokenText, t.kind == rbTokenOutStart:
+		case t.kind == rbTokenOp && t.text == ";":
+		case p.atTerminator():
+		default:
+			p.fail("unexpected %s", p.describe(t))
+		}
+	}
+}
+
+func (p *rbParser) parseStatement() rbNode {
+	t := p.peek()
+
+	switch t.kind {
+	case rbTokenText:
+		p.next()
+		return &rbTextNode{text: t.text}
+
+	case rbTokenOutStart:
+		p.next()
+		p.skipTerms()
+		if p.peek().kind == rbTokenOutEnd {
+			p.next()
+			return &rbOutputNode{expr: &rbLiteralNode{val: nil}}
+		}
+		expr := p.parseStatement()
+		p.skipTerms()
+		if p.peek().kind != rbTokenOutEnd {
+			p.fail("unexpected %s, expecting '%%>'", p.describe(p.peek()))
+		}
+		p.next()
+		return &rbOutputNode{expr: expr}
+	}
+
+	stmt := p.parseExprStmt()
+
+	for {
+		switch {
+		case p.acceptKeyword("if"):
+			stmt = &rbIfNode{cond: p.parseExprStmt(), then: []rbNode{stmt}}
+		case p.acceptKeyword("unless"):
+			stmt = &rbIfNode{cond: p.parseExprStmt(), els: []rbNode{stmt}}
+		case p.acceptKeyword("while"):
+			stmt = &rbWhileNode{cond: p.parseExprStmt(), body: []rbNode{stmt}}
+		case p.acceptKeyword("until"):
+			stmt = &rbWhileNode{cond: p.parseExprStmt(), body: []rbNode{stmt}, until: true}
+		case p.acceptKeyword("rescue"):
+			stmt = &rbBeginNode{body: []rbNode{stmt}, rescue: []rbNode{p.parseExprStmt()}, hasRescue: true}
+		default:
+			return stmt
+		}
+	}
+}
+
+func (p *rbParser) parseExprStmt() rbNode {
+	left := p.parseNotExpr()
+
+	for {
+		switch {
+		case p.acceptKeyword("and"):
+			p.skipNewlines()
+			left = &rbAndNode{left: left, right: p.parseNotExpr()}
+		case p.acceptKeyword("or"):
+			p.skipNewlines()
+			left = &rbOrNode{left: left, right: p.parseNotExpr()}
+		default:
+			return left
+		}
+	}
+}
+
+func (p *rbParser) parseNotExpr() rbNode {
+	if p.acceptKeyword("not") {
+		return &rbNotNode{expr: p.parseNotExpr()}
+	}
+	return p.parseExpr()
+}
+
+var rbAssignOps = map[string]string{
+	"=": "", "+=": "+", "-=": "-", "*=": "*", "/=": "/", "**=": "**",
+	"||=": "||", "&&=": "&&", "<<=": "<<",
+}
+
+func (p *rbParser) parseExpr() rbNode {
+	t := p.peek()
+
+	if t.kind == rbTokenIdent {
+		next := p.peekAt(1)
+		if op, found := rbAssignOps[next.text]; found && next.kind == rbTokenOp {
+			p.next()
+			p.next()
+			p.skipNewlines()
+			p.declareLocal(t.text)
+			return &rbAssignNode{name: t.text, op: op, value: p.parseAssignValue()}
+		}
+	}
+
+	left := p.parseTernary()
+
+	if op, found := rbAssignOps[p.peek().text]; found && p.peek().kind == rbTokenOp {
+		if call, ok := left.(*rbCallNode); ok && call.recv != nil && call.block == nil {
+			switch {
+			case call.name == "[]":
+				p.next()
+				p.skipNewlines()
+				return &rbIndexAssignNode{recv: call.recv, args: call.args, op: op, value: p.parseAssignValue(), line: call.line}
+			case len(call.args) == 0 && op == "":
+				p.next()
+				p.skipNewlines()
+				return &rbAttrAssignNode{recv: call.recv, name: call.name, value: p.parseAssignValue(), line: call.line}
+			}
+		}
+		p.fail("unexpected '%s'", p.peek().text)
+	}
+
+	return left
+}
+
+func (p *rbParser) parseAssignValue() rbNode {
+	value := p.parseExpr()
+
+	// 'a = 1, 2' assigns an array
+	if p.isOp(",") {
+		elems := []rbNode{value}
+		for p.acceptOp(",") {
+			p.skipNewlines()
+			elems = append(elems, p.parseArg())
+		}
+		return &rbArrayNode{elems: elems}
+	}
+
+	if p.acceptKeyword("rescue") {
+		return &rbBeginNode{body: []rbNode{value}, rescue: []rbNode{p.parseExpr()}, hasRescue: true}
+	}
+
+	return value
+}
+
+func (p *rbParser) parseTernary() rbNode {
+	cond := p.parseRange()
+
+	if p.acceptOp("?") {
+		p.skipNewlines()
+		then := p.parseTernary()
+		p.skipNewlines()
+		if p.peek().kind == rbTokenLabel {
+			// 'a ? b :c' lexed as label is not supported, but 'a ? b : c' is
+			p.fail("unexpected label '%s:'", p.peek().text)
+		}
+		p.expectOp(":")
+		p.skipNewlines()
+		els := p.parseTernary()
+		return &rbIfNode{cond: cond, then: []rbNode{then}, els: []rbNode{els}}
+	}
+
+	return cond
+}
+
+func (p *rbParser) parseRange() rbNode {
+	left := p.parseOrOr()
+
+	if p.isOp("..") || p.isOp("...") {
+		exclusive := p.next().text == "..."
+		return &rbRangeNode{from: left, to: p.parseOrOr(), exclusive: exclusive}
+	}
+
+	return left
+}
+
+func (p *rbParser) parseOrOr() rbNode {
+	left := p.parseAndAnd()
+	for p.acceptOp("||") {
+		p.skipNewlines()
+		left = &rbOrNode{left: left, right: p.parseAndAnd()}
+	}
+	return left
+}
+
+func (p *rbParser) parseAndAnd() rbNode {
+	left := p.parseDefined()
+	for p.acceptOp("&&") {
+		p.skipNewlines()
+		left = &rbAndNode{left: left, right: p.parseDefined()}
+	}
+	return left
+}
+
+func (p *rbParser) parseDefined() rbNode {
+	if p.acceptKeyword("defined?") {
+		parens := p.acceptOp("(")
+		expr := p.parseExpr()
+		if parens {
+			p.expectOp(")")
+		}
+		return &rbDefinedNode{expr: expr}
+	}
+	return p.parseEquality()
+}
+
+func (p *rbParser) parseEquality() rbNode {
+	left := p.parseComparison()
+
+	t := p.peek()
+	if t.kind == rbTokenOp {
+		switch t.text {
+		case "==", "!=", "===", "=~", "!~", "<=>":
+			p.next()
+			p.skipNewlines()
+			right := p.parseComparison()
+			switch t.text {
+			case "!=":
+				return &rbNotNode{expr: &rbCallNode{recv: left, name: "==", args: []rbNode{right}, line: t.line}}
+			case "!~":
+				return &rbNotNode{expr: &rbCallNode{recv: left, name: "=~", args: []rbNode{right}, line: t.line}}
+			default:
+				return &rbCallNode{recv: left, name: t.text, args: []rbNode{right}, line: t.line}
+			}
+		}
+	}
+
+	return left
+}
+
+func (p *rbParser) parseBinary(operand func() rbNode, ops ...string) rbNode {
+	left := operand()
+
+	for {
+		t := p.peek()
+		matched := false
+
+		if t.kind == rbTokenOp {
+			for _, op := range ops {
+				if t.text == op {
+					matched = true
+					break
+				}
+			}
+		}
+
+		if !matched {
+			return left
+		}
+
+		p.next()
+		p.skipNewlines()
+		left = &rbCallNode{recv: left, name: t.text, args: []rbNode{operand()}, line: t.line}
+	}
+}
+
+func (p *rbParser) parseComparison() rbNode {
+	return p.parseBinary(p.parseBitOr, "<", "<=", ">", ">=")
+}
+
+func (p *rbParser) parseBitOr() rbNode {
+	return p.parseBinary(p.parseBitAnd, "|", "^")
+}
+
+func (p *rbParser) parseBitAnd() rbNode {
+	return p.parseBinary(p.parseShift, "&")
+}
+
+func (p *rbParser) parseShift() rbNode {
+	return p.parseBinary(p.parseAdditive, "<<", ">>")
+}
+
+func (p *rbParser) parseAdditive() rbNode {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *rbParser) parseMultiplicative() rbNode {
+	return p.parseBinary(p.parseUnaryMinus, "*", "/", "%")
+}
+
+func (p *rbParser) parseUnaryMinus() rbNode {
+	t := p.peek()
+
+	if t.kind == rbTokenOp && t.text == "-" {
+		next := p.peekAt(1)
+		if (next.kind == rbTokenInt || next.kind == rbTokenFloat) && !next.space {
+			p.next()
+			p.tokens[p.pos].text = "-" + next.text
+			return p.parsePow()
+		}
+		p.next()
+		return &rbCallNode{recv: p.parseUnaryMinus(), name: "-@", line: t.line}
+	}
+
+	return p.parsePow()
+}
+
+func (p *rbParser) parsePow() rbNode {
+	left := p.parseUnary()
+
+	if t := p.peek(); t.kind == rbTokenOp && t.text == "**" {
+		p.next()
+		p.skipNewlines()
+		return &rbCallNode{recv: left, name: "**", args: []rbNode{p.parseUnaryMinus()}, line: t.line}
+	}
+
+	return left
+}
+
+func (p *rbParser) parseUnary() rbNode {
+	t := p.peek()
+
+	if t.kind == rbTokenOp {
+		switch t.text {
+		case "!":
+			p.next()
+			return &rbNotNode{expr: p.parseUnary()}
+		case "+":
+			p.next()
+			return p.parseUnary()
+		case "~":
+			p.next()
+			return &rbCallNode{recv: p.parseUnary(), name: "~", line: t.line}
+		}
+	}
+
+	return p.parsePostfix(p.parsePrimary())
+}
+
+func (p *rbParser) parsePostfix(node rbNode) rbNode {
+	for {
+		t := p.peek()
+
+		// Method chains may continue on the next line with a leading dot
+		if t.kind == rbTokenNewline {
+			offset := 0
+			for p.peekAt(offset).kind == rbTokenNewline {
+				offset++
+			}
+			next := p.peekAt(offset)
+			if next.kind == rbTokenOp && (next.text == "." || next.text == "&.") {
+				p.pos += offset
+				t = p.peek()
+			}
+		}
+
+		switch {
+		case t.kind == rbTokenOp && (t.text == "." || t.text == "&."):
+			p.next()
+			p.skipNewlines()
+			nameToken := p.next()
+			switch nameToken.kind {
+			case rbTokenIdent, rbTokenConst, rbTokenKeyword:
+			case rbTokenLabel:
+				// 'a.b ?c :d' style is not supported
+				p.fail("unexpected label '%s:'", nameToken.text)
+			default:
+				p.fail("unexpected %s, expecting method name", p.describe(nameToken))
+			}
+			call := &rbCallNode{recv: node, name: nameToken.text, safeNav: t.text == "&.", line: nameToken.line}
+			p.parseCallArgsAndBlock(call, true)
+			node = call
+
+		case t.kind == rbTokenOp && t.text == "::":
+			p.next()
+			nameToken := p.next()
+			if nameToken.kind == rbTokenConst {
+				if constNode, ok := node.(*rbConstNode); ok && !p.isOp("(") {
+					node = &rbConstNode{name: constNode.name + "::" + nameToken.text}
+					continue
+				}
+			}
+			call := &rbCallNode{recv: node, name: nameToken.text, line: nameToken.line}
+			p.parseCallArgsAndBlock(call, true)
+			node = call
+
+		case t.kind == rbTokenOp && t.text == "[" && (!t.space || p.isVarNode(node)):
+			p.next()
+			args, _ := p.parseArgList("]")
+			node = &rbCallNode{recv: node, name: "[]", args: args, line: t.line}
+
+		default:
+			return node
+		}
+	}
+}
+
+func (p *rbParser) isVarNode(node rbNode) bool {
+	_, ok := node.(*rbVarNode)
+	return ok
+}
+
+// canStartCommandArg checks whether token may start an argument
+// of a method called without parentheses (e.g. p "name")
+func (p *rbParser) canStartCommandArg() bool {
+	t := p.peek()
+
+	if !t.space {
+		return false
+	}
+
+	switch t.kind {
+	case rbTokenInt, rbTokenFloat, rbTokenString, rbTokenSymbol, rbTokenRegexp,
+		rbTokenWords, rbTokenIdent, rbTokenConst, rbTokenLabel:
+		return true
+	case rbTokenKeyword:
+		switch t.text {
+		case "nil", "true", "false", "self", "not", "defined?":
+			return true
+		}
+	case rbTokenOp:
+		switch t.text {
+		case "[", "(", "::":
+			return true
+		case "-", "*", "&", "!", ":":
+			return !p.peekAt(1).space
+		}
+	}
+
+	return false
+}
+
+func (p *rbParser) parseCallArgsAndBlock(call *rbCallNode, allowCommand bool) {
+	switch {
+	case p.isOp("(") && !p.peek().space:
+		p.next()
+		call.args, call.blockArg = p.parseArgList(")")
+	case allowCommand && p.canStartCommandArg():
+		call.args, call.blockArg = p.parseCommandArgs()
+	}
+
+	call.block = p.parseBlock()
+}
+
+func (p *rbParser) parseBlock() *rbBlockNode {
+	switch {
+	case p.isOp("{"):
+		p.next()
+		block := p.parseBlockBody()
+		p.expectOp("}")
+		return block
+	case p.noDo == 0 && p.isKeyword("do"):
+		p.next()
+		block := p.parseBlockBody()
+		p.expectKeyword("end")
+		return block
+	}
+	return nil
+}
+
+func (p *rbParser) parseBlockBody() *rbBlockNode {
+	p.locals = append(p.locals, map[string]bool{})
+	defer func() { p.locals = p.locals[:len(p.locals)-1] }()
+
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	block := &rbBlockNode{}
+
+	p.skipNewlines()
+
+	if p.acceptOp("||") {
+		return &rbBlockNode{body: p.parseStatements()}
+	}
+
+	if p.acceptOp("|") {
+		block.params = p.parseBlockParams("|")
+		p.expectOp("|")
+	}
+
+	block.body = p.parseStatements()
+
+	return block
+}
+
+func (p *rbParser) parseBlockParams(closer string) []rbParam {
+	var params []rbParam
+
+	for !p.isOp(closer) {
+		var param rbParam
+
+		switch t := p.next(); {
+		case t.kind == rbTokenOp && t.text == "(":
+			param.sub = p.parseBlockParams(")")
+			p.expectOp(")")
+		case t.kind == rbTokenOp && t.text == "*":
+			param.splat = true
+			param.name = p.next().text
+			p.declareLocal(param.name)
+		case t.kind == rbTokenIdent:
+			param.name = t.text
+			p.declareLocal(param.name)
+		default:
+			p.fail("unexpected %s in block parameters", p.describe(t))
+		}
+
+		params = append(params, param)
+
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+
+	return params
+}
+
+func (p *rbParser) parseArg() rbNode {
+	if p.acceptOp("*") {
+		return &rbSplatNode{expr: p.parseTernary()}
+	}
+	return p.parseNotExpr()
+}
+
+// parseArgList parses arguments until closer collecting trailing
+// key-value pairs into a hash and returning block argument separately
+func (p *rbParser) parseArgList(closer string) ([]rbNode, rbNode) {
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	var (
+		args     []rbNode
+		hash     *rbHashNode
+		blockArg rbNode
+	)
+
+	p.skipNewlines()
+
+	for !p.isOp(closer) {
+		blockArg = p.parseArgInto(&args, &hash, blockArg)
+		p.skipNewlines()
+		if !p.acceptOp(",") {
+			break
+		}
+		p.skipNewlines()
+	}
+
+	p.expectOp(closer)
+
+	if hash != nil {
+		args = append(args, hash)
+	}
+
+	return args, blockArg
+}
+
+func (p *rbParser) parseCommandArgs() ([]rbNode, rbNode) {
+	p.noDo++
+	defer func() { p.noDo-- }()
+
+	var (
+		args     []rbNode
+		hash     *rbHashNode
+		blockArg rbNode
+	)
+
+	for {
+		blockArg = p.parseArgInto(&args, &hash, blockArg)
+		if !p.acceptOp(",") {
+			break
+		}
+		p.skipNewlines()
+	}
+
+	if hash != nil {
+		args = append(args, hash)
+	}
+
+	return args, blockArg
+}
+
+func (p *rbParser) parseArgInto(args *[]rbNode, hash **rbHashNode, blockArg rbNode) rbNode {
+	t := p.peek()
+
+	addPair := func(key, val rbNode) {
+		if *hash == nil {
+			*hash = &rbHashNode{}
+		}
+		(*hash).keys = append((*hash).keys, key)
+		(*hash).vals = append((*hash).vals, val)
+	}
+
+	switch {
+	case t.kind == rbTokenLabel:
+		p.next()
+		p.skipNewlines()
+		addPair(&rbLiteralNode{val: rbSymbol(t.text)}, p.parseArg())
+	case t.kind == rbTokenOp && t.text == "&":
+		p.next()
+		return p.parseTernary()
+	default:
+		arg := p.parseArg()
+		if p.acceptOp("=>") {
+			p.skipNewlines()
+			addPair(arg, p.parseArg())
+		} else {
+			*args = append(*args, arg)
+		}
+	}
+
+	return blockArg
+}
+
+func (p *rbParser) parsePrimary() rbNode {
+	t := p.next()
+
+	switch t.kind {
+	case rbTokenInt:
+		i, err := strconv.ParseInt(t.text, 0, 64)
+		if err != nil {
+			// Literals which do not fit into int become Bignums
+			bigInt, ok := new(big.Int).SetString(t.text, 0)
+			if !ok {
+				p.pos--
+				p.fail("invalid integer '%s'", t.text)
+			}
+			return &rbLiteralNode{val: bigInt}
+		}
+		return &rbLiteralNode{val: int(i)}
+
+	case rbTokenFloat:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			p.pos--
+			p.fail("invalid float '%s'", t.text)
+		}
+		return &rbLiteralNode{val: f}
+
+	case rbTokenString:
+		str := p.parseStringParts(t)
+		// Adjacent string literals are concatenated
+		for p.peek().kind == rbTokenString {
+			str.parts = append(str.parts, p.parseStringParts(p.next()).parts...)
+		}
+		return str
+
+	case rbTokenSymbol:
+		if t.parts != nil {
+			return &rbSymbolNode{str: p.parseStringParts(t)}
+		}
+		return &rbLiteralNode{val: rbSymbol(t.text)}
+
+	case rbTokenRegexp:
+		re, err := newRbRegexp(t.text, t.flags)
+		if err != nil {
+			p.pos--
+			p.fail("invalid regexp /%s/: %s", t.text, err.Error())
+		}
+		return &rbLiteralNode{val: re}
+
+	case rbTokenWords:
+		node := &rbArrayNode{}
+		for _, word := range t.words {
+			if t.text == "symbols" {
+				node.elems = append(node.elems, &rbLiteralNode{val: rbSymbol(word)})
+			} else {
+				node.elems = append(node.elems, &rbStringNode{parts: []rbNode{&rbLiteralNode{val: word}}})
+			}
+		}
+		return node
+
+	case rbTokenIdent:
+		if p.isLocal(t.text) && !(p.isOp("(") && !p.peek().space) {
+			return &rbVarNode{name: t.text}
+		}
+		call := &rbCallNode{name: t.text, line: t.line}
+		p.parseCallArgsAndBlock(call, true)
+		return call
+
+	case rbTokenConst:
+		if p.isOp("(") && !p.peek().space {
+			call := &rbCallNode{name: t.text, line: t.line}
+			p.parseCallArgsAndBlock(call, false)
+			return call
+		}
+		return &rbConstNode{name: t.text}
+
+	case rbTokenKeyword:
+		return p.parseKeyword(t)
+
+	case rbTokenOp:
+		switch t.text {
+		case "(":
+			p.skipTerms()
+			if p.acceptOp(")") {
+				return &rbLiteralNode{val: nil}
+			}
+			stmts := p.parseStatements()
+			p.skipTerms()
+			p.expectOp(")")
+			return &rbSeqNode{stmts: stmts}
+
+		case "[":
+			elems, _ := p.parseArgList("]")
+			return &rbArrayNode{elems: elems}
+
+		case "{":
+			return p.parseHash()
+
+		case "::":
+			nameToken := p.next()
+			return &rbConstNode{name: nameToken.text}
+		}
+	}
+
+	p.pos--
+	p.fail("unexpected %s", p.describe(t))
+
+	return nil
+}
+
+func (p *rbParser) parseStringParts(t rbToken) *rbStringNode {
+	node := &rbStringNode{}
+
+	for _, part := range t.parts {
+		if !part.isCode {
+			node.parts = append(node.parts, &rbLiteralNode{val: part.lit})
+			continue
+		}
+
+		sub := &rbParser{
+			tokens: append(append([]rbToken{}, part.tokens...), rbToken{kind: rbTokenEOF, line: t.line}),
+			locals: p.locals,
+		}
+
+		node.parts = append(node.parts, &rbSeqNode{stmts: sub.parseStatements()})
+
+		if sub.peek().kind != rbTokenEOF {
+			sub.fail("unexpected %s in string interpolation", sub.describe(sub.peek()))
+		}
+	}
+
+	return node
+}
+
+func (p *rbParser) parseHash() rbNode {
+	savedNoDo := p.noDo
+	p.noDo = 0
+	defer func() { p.noDo = savedNoDo }()
+
+	node := &rbHashNode{}
+
+	p.skipNewlines()
+
+	for !p.isOp("}") {
+		t := p.peek()
+
+		if t.kind == rbTokenLabel {
+			p.next()
+			node.keys = append(node.keys, &rbLiteralNode{val: rbSymbol(t.text)})
+		} else {
+			node.keys = append(node.keys, p.parseArg())
+			p.skipNewlines()
+			p.expectOp("=>")
+		}
+
+		p.skipNewlines()
+		node.vals = append(node.vals, p.parseArg())
+		p.skipNewlines()
+
+		if !p.acceptOp(",") {
+			break
+		}
+
+		p.skipNewlines()
+	}
+
+	p.expectOp("}")
+
+	return node
+}
+
+func (p *rbParser) parseKeyword(t rbToken) rbNode {
+	switch t.text {
+	case "nil":
+		return &rbLiteralNode{val: nil}
+	case "true":
+		return &rbLiteralNode{val: true}
+	case "false":
+		return &rbLiteralNode{val: false}
+	case "self":
+		return &rbSelfNode{}
+
+	case "if", "unless":
+		return p.parseIf(t.text == "unless")
+
+	case "while", "until":
+		p.noDo++
+		cond := p.parseExprStmt()
+		p.noDo--
+		p.acceptKeyword("do")
+		body := p.parseStatements()
+		p.expectKeyword("end")
+		return &rbWhileNode{cond: cond, body: body, until: t.text == "until"}
+
+	case "case":
+		return p.parseCase()
+
+	case "begin":
+		return p.parseBegin()
+
+	case "next", "break":
+		var val rbNode
+		if !p.atTerminator() && p.peek().kind != rbTokenNewline && !p.isOp(";") && !p.isKeyword("if") && !p.isKeyword("unless") {
+			val = p.parseExpr()
+		}
+		if t.text == "next" {
+			return &rbNextNode{val: val}
+		}
+		return &rbBreakNode{val: val}
+	}
+
+	p.pos--
+	p.fail("unexpected keyword '%s'", t.text)
+
+	return nil
+}
+
+func (p *rbParser) parseIf(negate bool) rbNode {
+	cond := p.parseExprStmt()
+	p.acceptKeyword("then")
+
+	node := &rbIfNode{cond: cond}
+	if negate {
+		node.els = p.parseStatements()
+	} else {
+		node.then = p.parseStatements()
+	}
+
+	switch {
+	case !negate && p.acceptKeyword("elsif"):
+		node.els = []rbNode{p.parseIf(false)}
+		return node
+	case p.acceptKeyword("else"):
+		if negate {
+			node.then = p.parseStatements()
+		} else {
+			node.els = p.parseStatements()
+		}
+	}
+
+	p.expectKeyword("end")
+
+	return node
+}
+
+func (p *rbParser) parseCase() rbNode {
+	node := &rbCaseNode{}
+
+	if p.peek().kind != rbTokenNewline {
+		node.subject = p.parseExprStmt()
+	}
+
+	p.skipTerms()
+
+	for p.acceptKeyword("when") {
+		var when rbWhen
+
+		for {
+			p.skipNewlines()
+			when.values = append(when.values, p.parseArg())
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+
+		p.acceptKeyword("then")
+		when.body = p.parseStatements()
+		node.whens = append(node.whens, when)
+	}
+
+	if p.acceptKeyword("else") {
+		node.els = p.parseStatements()
+	}
+
+	p.expectKeyword("end")
+
+	return node
+}
+
+func (p *rbParser) parseBegin() rbNode {
+	node := &rbBeginNode{body: p.parseStatements()}
+
+	if p.acceptKeyword("rescue") {
+		node.hasRescue = true
+
+		for p.peek().kind == rbTokenConst || p.isOp(",") {
+			p.next()
+		}
+
+		if p.acceptOp("=>") {
+			node.rescueVar = p.next().text
+			p.declareLocal(node.rescueVar)
+		}
+
+		p.acceptKeyword("then")
+		node.rescue = p.parseStatements()
+	}
+
+	if p.acceptKeyword("ensure") {
+		node.ensureBody = p.parseStatements()
+	}
+
+	p.expectKeyword("end")
+
+	return node
+}
@@ -0,0 +1,123 @@
+package erbrenderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type goERBRenderer struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewGoERBRenderer returns renderer that evaluates ERB templates without
+// shelling out to ruby. It supports the subset of Ruby commonly used in
+// release job templates.
+func NewGoERBRenderer(
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) ERBRenderer {
+	return goERBRenderer{
+		fs:     fs,
+		logger: logger,
+		logTag: "goERBRenderer",
+	}
+}
+
+// NewDefaultERBRenderer returns ruby based renderer when ruby is available
+// and falls back to pure Go renderer otherwise.
+func NewDefaultERBRenderer(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+) ERBRenderer {
+	if runner.CommandExists("ruby") {
+		return NewERBRenderer(fs, runner, logger)
+	}
+
+	logger.Debug("erbRenderer", "Ruby is not available, using built-in ERB renderer")
+
+	return NewGoERBRenderer(fs, logger)
+}
+
+func (r goERBRenderer) Render(srcPath, dstPath string, context TemplateEvaluationContext) error {
+	r.logger.Debug(r.logTag, "Rendering template %s", dstPath)
+
+	contextBytes, err := json.Marshal(context)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling context")
+	}
+
+	template, err := r.fs.ReadFileString(srcPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading template")
+	}
+
+	templateContext, err := newRbTemplateContext(contextBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Building template evaluation context")
+	}
+
+	result, renderErr := r.render(template, templateContext)
+	if renderErr != nil {
+		line := "unknown"
+		if renderErr.line > 0 {
+			line = strconv.Itoa(renderErr.line)
+		}
+
+		return bosherr.Errorf("Error filling in template '%s' for %s/%s (line %s: %s)",
+			srcPath, rbToS(templateContext.name), rbToS(templateContext.index), line, renderErr.Inspect())
+	}
+
+	err = r.fs.WriteFileString(dstPath, result)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing rendered template")
+	}
+
+	return nil
+}
+
+func (r goERBRenderer) render(template string, context *rbTemplateContext) (result string, renderErr *rbError) {
+	// Unexpected interpreter failures are reported as template errors instead of crashing
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, renderErr = "", newRbError("RuntimeError", fmt.Sprintf("Go ERB renderer failed: %v", recovered))
+		}
+	}()
+
+	tokens, err := erbTokenize(template)
+	if err != nil {
+		return "", rbAsError(err)
+	}
+
+	stmts, err := rbParse(tokens)
+	if err != nil {
+		return "", rbAsError(err)
+	}
+
+	interp := &rbInterpreter{context: context}
+
+	_, err = interp.evalStatements(stmts, newRbEnv(nil))
+	if err != nil {
+		return "", rbAsError(err)
+	}
+
+	return interp.out.String(), nil
+}
+
+func rbAsError(err error) *rbError {
+	switch typedErr := err.(type) {
+	case *rbError:
+		return typedErr
+	case rbNextSignal, rbBreakSignal:
+		return newRbError("LocalJumpError", err.Error())
+	default:
+		return newRbError("RuntimeError", err.Error())
+	}
+}
@@ -0,0 +1,228 @@
+package erbrenderer_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GoERBRenderer", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		logger      boshlog.Logger
+		erbRenderer ERBRenderer
+		context     json.RawMessage
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		erbRenderer = NewGoERBRenderer(fs, logger)
+
+		context = json.RawMessage(`{
+			"index": 1,
+			"job": {"name": "fake-job"},
+			"networks": {"default": {"ip": "10.0.0.5"}},
+			"global_properties": {"a": {"b": "global"}},
+			"cluster_properties": {"a": {"c": "cluster"}, "list": [3, 1, 2]},
+			"job_properties": null,
+			"default_properties": {"a.b": null, "a.c": null, "a.d": "default", "list": [], "missing": null}
+		}`)
+	})
+
+	render := func(template string) (string, error) {
+		err := fs.WriteFileString("/src", template)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = erbRenderer.Render("/src", "/dst", context)
+		if err != nil {
+			return "", err
+		}
+
+		return fs.ReadFileString("/dst")
+	}
+
+	It("renders properties merged from global, cluster and default properties", func() {
+		Expect(render(`<%= p("a.b") %> <%= p("a.c") %> <%= p("a.d") %>`)).To(Equal("global cluster default"))
+	})
+
+	It("prefers job properties over global and cluster properties when provided", func() {
+		context = json.RawMessage(`{
+			"job": {"name": "fake-job"},
+			"global_properties": {"a": {"b": "global"}},
+			"job_properties": {"a": {"b": "job"}},
+			"default_properties": {"a.b": null}
+		}`)
+
+		Expect(render(`<%= p("a.b") %>`)).To(Equal("job"))
+	})
+
+	It("exposes spec, name and index", func() {
+		Expect(render(`<%= name %>/<%= index %> <%= spec.networks.default.ip %> <%= spec.job.name %>`)).To(
+			Equal("fake-job/1 10.0.0.5 fake-job"))
+	})
+
+	It("renders loops and common string methods", func() {
+		rendered, err := render(`<% p("list").sort.each do |n| %>[<%= n.to_s.rjust(2, "0") %>]<% end %> <%= p("a.d").upcase.gsub("DEF", "x") %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rendered).To(Equal("[01][02][03] xAULT"))
+	})
+
+	It("renders if_p blocks and their else branches", func() {
+		Expect(render(`<% if_p("a.b") do |v| %><%= v %><% end %>|<% if_p("missing") do |v| %>found<% end.else do %>else<% end %>`)).To(
+			Equal("global|else"))
+	})
+
+	It("supports trimming newlines after tags ending with -%>", func() {
+		Expect(render("<% [1, 2].each do |n| -%>\n<%= n %>\n<% end -%>\ndone")).To(Equal("1\n2\ndone"))
+	})
+
+	It("treats links as unavailable", func() {
+		Expect(render(`<% if_link("db") do |db| %>link<% end.else do %>no link<% end %>`)).To(Equal("no link"))
+
+		_, err := render("\n<%= link('db').address %>")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Error filling in template '/src' for fake-job/1 (line 2: #<RuntimeError: Can't find link 'db'>)"))
+	})
+
+	It("renders links from the context", func() {
+		context = json.RawMessage(`{
+			"index": 1,
+			"job": {"name": "fake-job"},
+			"default_properties": {},
+			"links": {
+				"db": {
+					"address": "10.0.0.5",
+					"instances": [{"index": 0, "address": "10.0.0.5", "bootstrap": true}],
+					"properties": {"db": {"port": 5432}}
+				}
+			}
+		}`)
+
+		Expect(render(`<%= link("db").address %>:<%= link("db").p("db.port") %> <%= link("db").instances.map { |i| i.address }.join(",") %>`)).To(
+			Equal("10.0.0.5:5432 10.0.0.5"))
+
+		Expect(render(`<% if_link("db") do |db| %><%= db.p("db.user", "admin") %><% end.else do %>no link<% end %>`)).To(Equal("admin"))
+
+		_, err := render(`<%= link("db").p("db.user") %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Can't find property 'db.user'"))
+	})
+
+	It("returns an error including line number when property is missing", func() {
+		_, err := render("line\n<%= p('unknown', 'x') %>\n<%= p(['unknown', 'other']) %>")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Error filling in template '/src' for fake-job/1 (line 3: #<TemplateEvaluationContext::UnknownProperty: Can't find property 'unknown', or 'other'>)"))
+	})
+
+	It("returns an error when template has invalid syntax", func() {
+		_, err := render("<% if true %>")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("SyntaxError"))
+	})
+
+	It("returns an error when calling undefined method", func() {
+		_, err := render("<%= unknown_helper %>")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("#<NameError: undefined local variable or method `unknown_helper'"))
+	})
+
+	It("returns an error when integer is converted to string with invalid radix", func() {
+		_, err := render(`<%= 10.to_s(1) %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("#<ArgumentError: invalid radix 1>"))
+
+		_, err = render(`<%= 10.to_s(37) %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("#<ArgumentError: invalid radix 37>"))
+
+		Expect(render(`<%= 255.to_s(16) %>`)).To(Equal("ff"))
+	})
+
+	It("promotes integers which do not fit into int64 to bignums", func() {
+		Expect(render(`<%= 2**64 %> <%= (2**64).class %> <%= 2**64 - 2**64 %>`)).To(Equal("18446744073709551616 Integer 0"))
+
+		_, err := render(`<%= [1][2**64] %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("#<RangeError: bignum too big to convert into `long'>"))
+
+		_, err = render(`<%= (0.0/0.0).to_i %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("#<FloatDomainError: NaN>"))
+	})
+
+	It("iterates enumerators returned by iteration methods called without a block", func() {
+		rendered, err := render(`<%= p("list").each_with_index.map { |n, i| n * i }.inspect %>`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rendered).To(Equal("[0, 1, 4]"))
+	})
+
+	It("returns an error when calling unsupported enumerator method", func() {
+		_, err := render(`<%= [1].each.next %>`)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("undefined method `next' for #<Enumerator: [1]:each>:Enumerator"))
+	})
+
+	It("returns an error when reading template fails", func() {
+		err := fs.WriteFileString("/src", "content")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.ReadFileError = errors.New("fake-read-error")
+
+		err = erbRenderer.Render("/src", "/dst", context)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading template"))
+		Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+	})
+
+	It("returns an error when writing rendered template fails", func() {
+		err := fs.WriteFileString("/src", "content")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.WriteFileError = errors.New("fake-write-error")
+
+		err = erbRenderer.Render("/src", "/dst", context)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Writing rendered template"))
+		Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+	})
+})
+
+var _ = Describe("NewDefaultERBRenderer", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		runner *fakesys.FakeCmdRunner
+		logger boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		fs.TempDirDir = "fake-temp-dir"
+		runner = fakesys.NewFakeCmdRunner()
+
+		err := fs.WriteFileString("/src", "<%= 1 + 1 %>")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("uses ruby when it is available", func() {
+		runner.AvailableCommands["ruby"] = true
+
+		err := NewDefaultERBRenderer(fs, runner, logger).Render("/src", "/dst", json.RawMessage(`{}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runner.RunComplexCommands).To(HaveLen(1))
+		Expect(runner.RunComplexCommands[0].Name).To(Equal("ruby"))
+	})
+
+	It("renders templates without ruby when it is not available", func() {
+		err := NewDefaultERBRenderer(fs, runner, logger).Render("/src", "/dst", json.RawMessage(`{}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runner.RunComplexCommands).To(BeEmpty())
+		Expect(fs.ReadFileString("/dst")).To(Equal("2"))
+	})
+})
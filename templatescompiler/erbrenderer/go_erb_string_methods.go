@@ -0,0 +1,901 @@
+package erbrenderer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func (i *rbInterpreter) callStringMethod(recv string, name string, args []interface{}, block *rbBlock) (interface{}, bool, error) {
+	// Bang methods return modified copy; variables are reassigned by the caller
+	if strings.HasSuffix(name, "!") && name != "!" {
+		switch strings.TrimSuffix(name, "!") {
+		case "upcase", "downcase", "capitalize", "swapcase", "strip", "lstrip", "rstrip",
+			"chomp", "chop", "squeeze", "reverse", "gsub", "sub", "tr", "delete":
+			return i.callStringMethod(recv, strings.TrimSuffix(name, "!"), args, block)
+		}
+	}
+
+	switch name {
+	case "length", "size":
+		return len([]rune(recv)), true, nil
+	case "bytesize":
+		return len(recv), true, nil
+	case "to_s", "to_str":
+		return recv, true, nil
+	case "to_sym", "intern":
+		return rbSymbol(recv), true, nil
+	case "to_i":
+		base := 10
+		if len(args) > 0 {
+			var err error
+			base, err = rbArgInt(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		return rbParseLeadingInt(recv, base), true, nil
+	case "hex":
+		return rbParseLeadingInt(strings.TrimPrefix(strings.TrimPrefix(recv, "0x"), "0X"), 16), true, nil
+	case "to_f":
+		return rbParseLeadingFloat(recv), true, nil
+	case "upcase":
+		return strings.ToUpper(recv), true, nil
+	case "downcase":
+		return strings.ToLower(recv), true, nil
+	case "capitalize":
+		runes := []rune(strings.ToLower(recv))
+		if len(runes) > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		return string(runes), true, nil
+	case "swapcase":
+		runes := []rune(recv)
+		for idx, r := range runes {
+			if unicode.IsUpper(r) {
+				runes[idx] = unicode.ToLower(r)
+			} else {
+				runes[idx] = unicode.ToUpper(r)
+			}
+		}
+		return string(runes), true, nil
+	case "strip":
+		return strings.Trim(recv, " \t\n\v\f\r\x00"), true, nil
+	case "lstrip":
+		return strings.TrimLeft(recv, " \t\n\v\f\r\x00"), true, nil
+	case "rstrip":
+		return strings.TrimRight(recv, " \t\n\v\f\r\x00"), true, nil
+	case "chomp":
+		if len(args) > 0 {
+			suffix, err := rbArgString(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			return strings.TrimSuffix(recv, suffix), true, nil
+		}
+		if strings.HasSuffix(recv, "\r\n") {
+			return recv[:len(recv)-2], true, nil
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(recv, "\n"), "\r"), true, nil
+	case "chop":
+		runes := []rune(recv)
+		if len(runes) == 0 {
+			return "", true, nil
+		}
+		return string(runes[:len(runes)-1]), true, nil
+	case "chars":
+		arr := newRbArray()
+		for _, r := range recv {
+			arr.items = append(arr.items, string(r))
+		}
+		return arr, true, nil
+	case "lines", "each_line":
+		arr := newRbArray()
+		for _, line := range strings.SplitAfter(recv, "\n") {
+			if len(line) > 0 {
+				arr.items = append(arr.items, line)
+			}
+		}
+		if name == "each_line" && block != nil {
+			_, err := i.eachItem(arr.items, block)
+			return recv, true, err
+		}
+		return arr, true, nil
+	case "each_char":
+		if block == nil {
+			return nil, true, rbNoBlockError()
+		}
+		for _, r := range recv {
+			if _, err := block.Call(string(r)); err != nil {
+				return nil, true, err
+			}
+		}
+		return recv, true, nil
+	case "reverse":
+		runes := []rune(recv)
+		for left, right := 0, len(runes)-1; left < right; left, right = left+1, right-1 {
+			runes[left], runes[right] = runes[right], runes[left]
+		}
+		return string(runes), true, nil
+	case "empty?":
+		return len(recv) == 0, true, nil
+	case "include?":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		sub, err := rbArgString(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		return strings.Contains(recv, sub), true, nil
+	case "start_with?", "end_with?":
+		for _, arg := range args {
+			if re, ok := arg.(*rbRegexp); ok && name == "start_with?" {
+				if loc := re.re.FindStringIndex(recv); loc != nil && loc[0] == 0 {
+					return true, true, nil
+				}
+				continue
+			}
+			str, err := rbArgString(arg)
+			if err != nil {
+				return nil, true, err
+			}
+			if (name == "start_with?" && strings.HasPrefix(recv, str)) || (name == "end_with?" && strings.HasSuffix(recv, str)) {
+				return true, true, nil
+			}
+		}
+		return false, true, nil
+	case "index", "rindex":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		var pos int
+		switch pattern := args[0].(type) {
+		case string:
+			if name == "index" {
+				pos = strings.Index(recv, pattern)
+			} else {
+				pos = strings.LastIndex(recv, pattern)
+			}
+		case *rbRegexp:
+			pos = -1
+			if locs := pattern.re.FindAllStringIndex(recv, -1); len(locs) > 0 {
+				if name == "index" {
+					pos = locs[0][0]
+				} else {
+					pos = locs[len(locs)-1][0]
+				}
+			}
+		default:
+			return nil, true, rbTypeError(args[0], "String")
+		}
+		if pos < 0 {
+			return nil, true, nil
+		}
+		return len([]rune(recv[:pos])), true, nil
+	case "+":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		str, err := rbArgString(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		return recv + str, true, nil
+	case "<<", "concat":
+		var buf strings.Builder
+		buf.WriteString(recv)
+		for _, arg := range args {
+			if code, ok := arg.(int); ok {
+				buf.WriteRune(rune(code))
+				continue
+			}
+			str, err := rbArgString(arg)
+			if err != nil {
+				return nil, true, err
+			}
+			buf.WriteString(str)
+		}
+		return buf.String(), true, nil
+	case "*":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		count, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if count < 0 {
+			return nil, true, newRbError("ArgumentError", "negative argument")
+		}
+		return strings.Repeat(recv, count), true, nil
+	case "%":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		fmtArgs := []interface{}{args[0]}
+		if arr, ok := args[0].(*rbArray); ok {
+			fmtArgs = arr.items
+		}
+		str, err := rbFormat(recv, fmtArgs)
+		return str, true, err
+	case "<", "<=", ">", ">=", "<=>":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		result, err := rbCompare(recv, args[0])
+		if err != nil {
+			if name == "<=>" {
+				return nil, true, nil
+			}
+			return nil, true, err
+		}
+		return rbCompareResult(name, result), true, nil
+	case "=~", "match", "match?":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		re, err := rbToRegexp(args[0], name != "=~")
+		if err != nil {
+			return nil, true, err
+		}
+		return rbRegexpMatch(re, recv, name), true, nil
+	case "scan":
+		if err := rbCheckArgs(args, 1, 1); err != nil {
+			return nil, true, err
+		}
+		re, err := rbToRegexp(args[0], true)
+		if err != nil {
+			return nil, true, err
+		}
+		arr := newRbArray()
+		for _, match := range re.re.FindAllStringSubmatch(recv, -1) {
+			if len(match) == 1 {
+				arr.items = append(arr.items, match[0])
+			} else {
+				groups := newRbArray()
+				for _, group := range match[1:] {
+					groups.items = append(groups.items, group)
+				}
+				arr.items = append(arr.items, groups)
+			}
+		}
+		if block != nil {
+			_, err := i.eachItem(arr.items, block)
+			return recv, true, err
+		}
+		return arr, true, nil
+	case "split":
+		val, err := rbSplit(recv, args)
+		return val, true, err
+	case "gsub", "sub":
+		val, err := i.substitute(recv, name == "gsub", args, block)
+		return val, true, err
+	case "tr", "delete", "squeeze", "count":
+		val, err := rbTranslate(recv, name, args)
+		return val, true, err
+	case "[]", "slice":
+		val, err := rbStringIndex(recv, args)
+		return val, true, err
+	case "center", "ljust", "rjust":
+		if err := rbCheckArgs(args, 1, 2); err != nil {
+			return nil, true, err
+		}
+		width, err := rbArgInt(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		pad := " "
+		if len(args) > 1 {
+			pad, err = rbArgString(args[1])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		return rbJustify(recv, name, width, pad), true, nil
+	case "ord":
+		if len(recv) == 0 {
+			return nil, true, newRbError("ArgumentError", "empty string")
+		}
+		return int([]rune(recv)[0]), true, nil
+	case "succ", "next":
+		runes := []rune(recv)
+		if len(runes) > 0 {
+			runes[len(runes)-1]++
+		}
+		return string(runes), true, nil
+	case "encode", "force_encoding", "unicode_normalize", "b", "scrub":
+		return recv, true, nil
+	case "format":
+		str, err := rbFormat(recv, args)
+		return str, true, err
+	case "unpack", "unpack1", "crypt":
+		return nil, true, newRbError("NotImplementedError", fmt.Sprintf("String#%s is not supported", name))
+	}
+
+	return nil, false, nil
+}
+
+func rbToRegexp(val interface{}, allowString bool) (*rbRegexp, error) {
+	switch typedVal := val.(type) {
+	case *rbRegexp:
+		return typedVal, nil
+	case string:
+		if allowString {
+			return newRbRegexp(regexp.QuoteMeta(typedVal), "")
+		}
+		return nil, newRbError("TypeError", "wrong argument type String (expected Regexp)")
+	default:
+		return nil, newRbError("TypeError", fmt.Sprintf("wrong argument type %s (expected Regexp)", rbClassName(val)))
+	}
+}
+
+func rbParseLeadingInt(str string, base int) int {
+	str = strings.TrimSpace(strings.Replace(str, "_", "", -1))
+
+	end := 0
+	for end < len(str) {
+		c := str[end]
+		if end == 0 && (c == '-' || c == '+') {
+			end++
+			continue
+		}
+		if _, err := strconv.ParseInt(string(c), base, 64); err != nil {
+			break
+		}
+		end++
+	}
+
+	i, err := strconv.ParseInt(str[:end], base, 64)
+	if err != nil {
+		return 0
+	}
+
+	return int(i)
+}
+
+var rbLeadingFloatRegexp = regexp.MustCompile(`^[+-]?(\d+(\.\d+)?([eE][+-]?\d+)?|\.\d+)`)
+
+func rbParseLeadingFloat(str string) float64 {
+	match := rbLeadingFloatRegexp.FindString(strings.TrimSpace(strings.Replace(str, "_", "", -1)))
+
+	f, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return 0
+	}
+
+	return f
+}
+
+func rbSplit(str string, args []interface{}) (interface{}, error) {
+	if err := rbCheckArgs(args, 0, 2); err != nil {
+		return nil, err
+	}
+
+	limit := 0
+	if len(args) > 1 {
+		var err error
+		limit, err = rbArgInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	n := -1
+	if limit > 0 {
+		n = limit
+	}
+
+	var pieces []string
+
+	var sep interface{} = " "
+	if len(args) > 0 && args[0] != nil {
+		sep = args[0]
+	}
+
+	switch typedSep := sep.(type) {
+	case string:
+		switch typedSep {
+		case " ":
+			// awk-style split on whitespace ignoring leading whitespace
+			trimmed := strings.TrimLeft(str, " \t\n\v\f\r")
+			if n > 0 {
+				pieces = regexp.MustCompile(`[ \t\n\v\f\r]+`).Split(trimmed, n)
+			} else {
+				pieces = strings.Fields(trimmed)
+				if len(trimmed) > 0 && strings.TrimRight(trimmed, " \t\n\v\f\r") != trimmed {
+					pieces = append(pieces, "")
+				}
+			}
+		case "":
+			for _, r := range str {
+				pieces = append(pieces, string(r))
+			}
+		default:
+			pieces = strings.SplitN(str, typedSep, n)
+		}
+	case *rbRegexp:
+		if typedSep.source == "" {
+			for _, r := range str {
+				pieces = append(pieces, string(r))
+			}
+		} else {
+			pieces = typedSep.re.Split(str, n)
+		}
+	default:
+		return nil, newRbError("TypeError", fmt.Sprintf("wrong argument type %s (expected Regexp)", rbClassName(sep)))
+	}
+
+	// Trailing empty strings are removed unless limit is given
+	if limit == 0 {
+		for len(pieces) > 0 && pieces[len(pieces)-1] == "" {
+			pieces = pieces[:len(pieces)-1]
+		}
+	}
+
+	arr := newRbArray()
+	for _, piece := range pieces {
+		arr.items = append(arr.items, piece)
+	}
+
+	return arr, nil
+}
+
+var rbBackrefRegexp = regexp.MustCompile(`\\(\d|k<(\w+)>|&|\\)`)
+
+func (i *rbInterpreter) substitute(str string, global bool, args []interface{}, block *rbBlock) (interface{}, error) {
+	minArgs := 2
+	if block != nil {
+		minArgs = 1
+	}
+
+	if err := rbCheckArgs(args, minArgs, 2); err != nil {
+		return nil, err
+	}
+
+	re, err := rbToRegexp(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result  strings.Builder
+		last    int
+		callErr error
+	)
+
+	limit := -1
+	if !global {
+		limit = 1
+	}
+
+	for _, loc := range re.re.FindAllStringSubmatchIndex(str, limit) {
+		result.WriteString(str[last:loc[0]])
+		last = loc[1]
+
+		md := newRbMatchData(re, str, loc)
+
+		var replacement string
+
+		switch {
+		case len(args) > 1:
+			switch typedRepl := args[1].(type) {
+			case string:
+				replacement = rbBackrefRegexp.ReplaceAllStringFunc(typedRepl, func(ref string) string {
+					switch {
+					case ref == `\\`:
+						return `\`
+					case ref == `\&`:
+						return rbToS(md.groups[0])
+					case strings.HasPrefix(ref, `\k<`):
+						for idx, groupName := range md.names {
+							if groupName == ref[3:len(ref)-1] {
+								return rbToS(md.groups[idx])
+							}
+						}
+						return ""
+					default:
+						idx, _ := strconv.Atoi(ref[1:])
+						if idx < len(md.groups) {
+							return rbToS(md.groups[idx])
+						}
+						return ""
+					}
+				})
+			case *rbHash:
+				val, _ := typedRepl.Get(md.groups[0])
+				replacement = rbToS(val)
+			default:
+				return nil, rbTypeError(args[1], "String")
+			}
+		default:
+			val, err := block.Call(md.groups[0])
+			if err != nil {
+				callErr = err
+				break
+			}
+			replacement = rbToS(val)
+		}
+
+		if callErr != nil {
+			return nil, callErr
+		}
+
+		result.WriteString(replacement)
+	}
+
+	result.WriteString(str[last:])
+
+	return result.String(), nil
+}
+
+// rbCharSet expands tr-style character specification (e.g. 'a-z', '^0-9')
+func rbCharSet(spec string) (map[rune]bool, []rune, bool) {
+	runes := []rune(spec)
+	negate := len(runes) > 1 && runes[0] == '^'
+	if negate {
+		runes = runes[1:]
+	}
+
+	set := map[rune]bool{}
+	var list []rune
+
+	for idx := 0; idx < len(runes); idx++ {
+		if idx+2 < len(runes) && runes[idx+1] == '-' {
+			for r := runes[idx]; r <= runes[idx+2]; r++ {
+				set[r] = true
+				list = append(list, r)
+			}
+			idx += 2
+			continue
+		}
+		set[runes[idx]] = true
+		list = append(list, runes[idx])
+	}
+
+	return set, list, negate
+}
+
+func rbTranslate(str string, name string, args []interface{}) (interface{}, error) {
+	expected := 1
+	if name == "tr" {
+		expected = 2
+	}
+
+	if name == "squeeze" && len(args) == 0 {
+		args = []interface{}{""}
+	}
+
+	if err := rbCheckArgs(args, expected, expected); err != nil {
+		return nil, err
+	}
+
+	from, err := rbArgString(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	set, fromList, negate := rbCharSet(from)
+	matches := func(r rune) bool { return len(from) == 0 || set[r] != negate }
+
+	var (
+		result strings.Builder
+		count  int
+		last   rune = -1
+	)
+
+	var toList []rune
+	if name == "tr" {
+		to, err := rbArgString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		_, toList, _ = rbCharSet(to)
+	}
+
+	for _, r := range str {
+		if !matches(r) {
+			result.WriteRune(r)
+			last = -1
+			continue
+		}
+
+		count++
+
+		switch name {
+		case "tr":
+			if len(toList) == 0 {
+				continue
+			}
+			replacement := toList[len(toList)-1]
+			if !negate {
+				for idx, fr := range fromList {
+					if fr == r && idx < len(toList) {
+						replacement = toList[idx]
+						break
+					}
+				}
+			}
+			result.WriteRune(replacement)
+		case "squeeze":
+			if r != last {
+				result.WriteRune(r)
+			}
+			last = r
+		}
+	}
+
+	if name == "count" {
+		return count, nil
+	}
+
+	return result.String(), nil
+}
+
+func rbStringIndex(str string, args []interface{}) (interface{}, error) {
+	if err := rbCheckArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+
+	runes := []rune(str)
+
+	switch idx := args[0].(type) {
+	case int:
+		length := 1
+		if len(args) > 1 {
+			var err error
+			length, err = rbArgInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		if idx < 0 {
+			idx += len(runes)
+		}
+		if idx < 0 || idx > len(runes) || (idx == len(runes) && len(args) == 1) || length < 0 {
+			return nil, nil
+		}
+		end := idx + length
+		if end > len(runes) {
+			end = len(runes)
+		}
+		return string(runes[idx:end]), nil
+	case *rbRange:
+		start, end, ok := rbRangeBounds(idx, len(runes))
+		if !ok {
+			return nil, nil
+		}
+		return string(runes[start:end]), nil
+	case string:
+		if strings.Contains(str, idx) {
+			return idx, nil
+		}
+		return nil, nil
+	case *rbRegexp:
+		match := idx.re.FindStringSubmatch(str)
+		if match == nil {
+			return nil, nil
+		}
+		group := 0
+		if len(args) > 1 {
+			var err error
+			group, err = rbArgInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		if group >= len(match) {
+			return nil, nil
+		}
+		return match[group], nil
+	default:
+		return nil, rbTypeError(args[0], "Integer")
+	}
+}
+
+// rbRangeBounds converts range to slice bounds for collection of given length
+func rbRangeBounds(r *rbRange, length int) (int, int, bool) {
+	start, end := r.from, r.to
+
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+	if !r.exclusive {
+		end++
+	}
+
+	if start < 0 || start > length {
+		return 0, 0, false
+	}
+	if end > length {
+		end = length
+	}
+	if end < start {
+		end = start
+	}
+
+	return start, end, true
+}
+
+func rbJustify(str, name string, width int, pad string) string {
+	length := len([]rune(str))
+	if width <= length || len(pad) == 0 {
+		return str
+	}
+
+	padding := func(n int) string {
+		padRunes := []rune(strings.Repeat(pad, n))
+		return string(padRunes[:n])
+	}
+
+	total := width - length
+
+	switch name {
+	case "ljust":
+		return str + padding(total)
+	case "rjust":
+		return padding(total) + str
+	default:
+		left := total / 2
+		return padding(left) + str + padding(total-left)
+	}
+}
+
+var rbFormatRegexp = regexp.MustCompile(`%(?:<(\w+)>|\{(\w+)\})?([-+ 0#]*)(\*|\d+)?(?:\.(\d+))?([sdifgeExXobcp%])?`)
+
+// rbFormat implements subset of Kernel#format directives
+func rbFormat(format string, args []interface{}) (string, error) {
+	var (
+		result strings.Builder
+		last   int
+		argIdx int
+		err    error
+	)
+
+	nextArg := func() (interface{}, error) {
+		if argIdx >= len(args) {
+			return nil, newRbError("ArgumentError", "too few arguments")
+		}
+		arg := args[argIdx]
+		argIdx++
+		return arg, nil
+	}
+
+	namedArg := func(name string) (interface{}, error) {
+		if len(args) > 0 {
+			if hash, ok := args[0].(*rbHash); ok {
+				if val, found := hash.Get(rbSymbol(name)); found {
+					return val, nil
+				}
+				if val, found := hash.Get(name); found {
+					return val, nil
+				}
+			}
+		}
+		return nil, newRbError("KeyError", fmt.Sprintf("key<%s> not found", name))
+	}
+
+	for _, loc := range rbFormatRegexp.FindAllStringSubmatchIndex(format, -1) {
+		result.WriteString(format[last:loc[0]])
+		last = loc[1]
+
+		group := func(n int) string {
+			if loc[2*n] < 0 {
+				return ""
+			}
+			return format[loc[2*n]:loc[2*n+1]]
+		}
+
+		verb := group(6)
+
+		if verb == "%" {
+			result.WriteString("%")
+			continue
+		}
+
+		var arg interface{}
+
+		switch {
+		case len(group(2)) > 0:
+			arg, err = namedArg(group(2))
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(rbToS(arg))
+			continue
+		case len(group(1)) > 0:
+			arg, err = namedArg(group(1))
+		default:
+			if verb == "" {
+				result.WriteString(format[loc[0]:loc[1]])
+				continue
+			}
+			arg, err = nextArg()
+		}
+		if err != nil {
+			return "", err
+		}
+
+		width := group(4)
+		if width == "*" {
+			widthArg, err := nextArg()
+			if err != nil {
+				return "", err
+			}
+			width = rbToS(widthArg)
+		}
+
+		spec := "%" + group(3) + width
+		if len(group(5)) > 0 {
+			spec += "." + group(5)
+		}
+
+		switch verb {
+		case "s":
+			result.WriteString(fmt.Sprintf(spec+"s", rbToS(arg)))
+		case "p":
+			result.WriteString(fmt.Sprintf(spec+"s", rbInspect(arg)))
+		case "d", "i":
+			n, err := rbFormatInt(arg)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(fmt.Sprintf(spec+"d", n))
+		case "x", "X", "o", "b":
+			n, err := rbFormatInt(arg)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(fmt.Sprintf(spec+verb, n))
+		case "c":
+			if str, ok := arg.(string); ok {
+				result.WriteString(fmt.Sprintf(spec+"s", string([]rune(str)[:1])))
+			} else {
+				n, err := rbFormatInt(arg)
+				if err != nil {
+					return "", err
+				}
+				result.WriteString(fmt.Sprintf(spec+"c", rune(n)))
+			}
+		default:
+			f, ok := rbToFloat(arg)
+			if !ok {
+				if str, isStr := arg.(string); isStr {
+					f, err = strconv.ParseFloat(str, 64)
+					if err != nil {
+						return "", newRbError("ArgumentError", fmt.Sprintf("invalid value for Float(): %s", rbInspect(arg)))
+					}
+				} else {
+					return "", rbTypeError(arg, "Float")
+				}
+			}
+			result.WriteString(fmt.Sprintf(spec+verb, f))
+		}
+	}
+
+	result.WriteString(format[last:])
+
+	return result.String(), nil
+}
+
+func rbFormatInt(arg interface{}) (int, error) {
+	switch typedArg := arg.(type) {
+	case int:
+		return typedArg, nil
+	case float64:
+		return int(typedArg), nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(typedArg))
+		if err != nil {
+			return 0, newRbError("ArgumentError", fmt.Sprintf("invalid value for Integer(): %s", rbInspect(arg)))
+		}
+		return n, nil
+	default:
+		return 0, rbTypeError(arg, "Integer")
+	}
+}
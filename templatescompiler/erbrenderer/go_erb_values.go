@@ -0,0 +1,729 @@
+package erbrenderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Values used by Go ERB renderer mirror Ruby objects:
+// nil, bool, int, *big.Int, float64, string, rbSymbol, *rbArray, *rbHash,
+// *rbOpenStruct, *rbRange, *rbRegexp, *rbEnumerator, rbClass, *rbElseBlock and *rbLink.
+
+type rbSymbol string
+
+type rbClass string
+
+type rbArray struct {
+	items []interface{}
+}
+
+func newRbArray(items ...interface{}) *rbArray {
+	return &rbArray{items: items}
+}
+
+// rbHash keeps insertion order like Ruby hashes do
+type rbHash struct {
+	keys []interface{}
+	vals map[interface{}]interface{}
+}
+
+func newRbHash() *rbHash {
+	return &rbHash{vals: map[interface{}]interface{}{}}
+}
+
+func (h *rbHash) Get(key interface{}) (interface{}, bool) {
+	val, found := h.vals[h.normalizeKey(key)]
+	return val, found
+}
+
+func (h *rbHash) Set(key, val interface{}) {
+	key = h.normalizeKey(key)
+	if _, found := h.vals[key]; !found {
+		h.keys = append(h.keys, key)
+	}
+	h.vals[key] = val
+}
+
+func (h *rbHash) Delete(key interface{}) (interface{}, bool) {
+	key = h.normalizeKey(key)
+	val, found := h.vals[key]
+	if !found {
+		return nil, false
+	}
+	delete(h.vals, key)
+	for i, k := range h.keys {
+		if k == key {
+			h.keys = append(h.keys[:i], h.keys[i+1:]...)
+			break
+		}
+	}
+	return val, true
+}
+
+func (h *rbHash) Len() int { return len(h.keys) }
+
+func (h *rbHash) Copy() *rbHash {
+	result := newRbHash()
+	for _, key := range h.keys {
+		result.Set(key, h.vals[key])
+	}
+	return result
+}
+
+// normalizeKey makes integral keys comparable regardless of their Go type
+func (h *rbHash) normalizeKey(key interface{}) interface{} {
+	switch typedKey := key.(type) {
+	case string, rbSymbol, int, float64, bool, nil, rbClass:
+		return typedKey
+	default:
+		return rbInspect(key)
+	}
+}
+
+type rbOpenStruct struct {
+	hash *rbHash
+}
+
+type rbRange struct {
+	from, to  int
+	exclusive bool
+}
+
+func (r *rbRange) Items() []interface{} {
+	var items []interface{}
+	last := r.to
+	if r.exclusive {
+		last--
+	}
+	for i := r.from; i <= last; i++ {
+		items = append(items, i)
+	}
+	return items
+}
+
+// rbEnumerator is returned by iteration methods called without a block;
+// iterating it calls method on recv with a block again
+type rbEnumerator struct {
+	recv   interface{}
+	method string
+	args   []interface{}
+}
+
+type rbRegexp struct {
+	re     *regexp.Regexp
+	source string
+}
+
+func newRbRegexp(source, flags string) (*rbRegexp, error) {
+	goSource := strings.Replace(source, `\Z`, `\z`, -1)
+	goSource = strings.Replace(goSource, `\h`, `[0-9a-fA-F]`, -1)
+
+	var goFlags string
+
+	if strings.Contains(flags, "i") {
+		goFlags += "i"
+	}
+	if strings.Contains(flags, "m") {
+		goFlags += "s"
+	}
+	// Ruby anchors ^ and $ always match at line boundaries
+	goFlags += "m"
+
+	re, err := regexp.Compile("(?" + goFlags + ")" + goSource)
+	if err != nil {
+		return nil, newRbError("RegexpError", err.Error())
+	}
+
+	return &rbRegexp{re: re, source: source}, nil
+}
+
+type rbElseBlock struct {
+	active bool
+}
+
+func rbTruthy(val interface{}) bool {
+	switch typedVal := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return typedVal
+	default:
+		return true
+	}
+}
+
+func rbClassName(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return "NilClass"
+	case bool:
+		if typedVal {
+			return "TrueClass"
+		}
+		return "FalseClass"
+	case int, *big.Int:
+		return "Integer"
+	case float64:
+		return "Float"
+	case string:
+		return "String"
+	case rbSymbol:
+		return "Symbol"
+	case *rbArray:
+		return "Array"
+	case *rbHash:
+		return "Hash"
+	case *rbOpenStruct:
+		return "OpenStruct"
+	case *rbRange:
+		return "Range"
+	case *rbRegexp:
+		return "Regexp"
+	case *rbEnumerator:
+		return "Enumerator"
+	case rbClass:
+		return "Class"
+	case *rbElseBlock:
+		if typedVal.active {
+			return "TemplateEvaluationContext::ActiveElseBlock"
+		}
+		return "TemplateEvaluationContext::InactiveElseBlock"
+	case *rbMatchData:
+		return "MatchData"
+	case *rbBlock:
+		return "Proc"
+	case *rbError:
+		return typedVal.class
+	case *rbTemplateContext:
+		return "TemplateEvaluationContext"
+	case *rbLink:
+		return "TemplateEvaluationContext::EvaluationLink"
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
+
+func rbIsA(val interface{}, class rbClass) bool {
+	name := rbClassName(val)
+
+	switch class {
+	case "Object", "BasicObject", "Kernel":
+		return true
+	case "Numeric", "Comparable":
+		return name == "Integer" || name == "Float" || (class == "Comparable" && name == "String")
+	case "Fixnum", "Bignum":
+		return name == "Integer"
+	case "Enumerable":
+		return name == "Array" || name == "Hash" || name == "Range" || name == "Enumerator"
+	default:
+		return name == string(class)
+	}
+}
+
+func rbToS(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return typedVal
+	case rbSymbol:
+		return string(typedVal)
+	case rbClass:
+		return string(typedVal)
+	case *rbRange:
+		return rbInspect(typedVal)
+	case *rbRegexp:
+		return "(?-mix:" + typedVal.source + ")"
+	default:
+		return rbInspect(val)
+	}
+}
+
+func rbInspect(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(typedVal)
+	case int:
+		return strconv.Itoa(typedVal)
+	case *big.Int:
+		return typedVal.String()
+	case float64:
+		return rbFormatFloat(typedVal)
+	case string:
+		return rbInspectString(typedVal)
+	case rbSymbol:
+		return ":" + string(typedVal)
+	case rbClass:
+		return string(typedVal)
+	case *rbArray:
+		var parts []string
+		for _, item := range typedVal.items {
+			parts = append(parts, rbInspect(item))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *rbHash:
+		var parts []string
+		for _, key := range typedVal.keys {
+			parts = append(parts, rbInspect(key)+"=>"+rbInspect(typedVal.vals[key]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case *rbOpenStruct:
+		var parts []string
+		for _, key := range typedVal.hash.keys {
+			parts = append(parts, " "+rbToS(key)+"="+rbInspect(typedVal.hash.vals[key]))
+		}
+		return "#<OpenStruct" + strings.Join(parts, ",") + ">"
+	case *rbRange:
+		if typedVal.exclusive {
+			return fmt.Sprintf("%d...%d", typedVal.from, typedVal.to)
+		}
+		return fmt.Sprintf("%d..%d", typedVal.from, typedVal.to)
+	case *rbRegexp:
+		return "/" + typedVal.source + "/"
+	case *rbEnumerator:
+		var args []string
+		for _, arg := range typedVal.args {
+			args = append(args, rbInspect(arg))
+		}
+		method := typedVal.method
+		if len(args) > 0 {
+			method += "(" + strings.Join(args, ", ") + ")"
+		}
+		return "#<Enumerator: " + rbInspect(typedVal.recv) + ":" + method + ">"
+	default:
+		return "#<" + rbClassName(val) + ">"
+	}
+}
+
+func rbInspectString(str string) string {
+	var buf bytes.Buffer
+
+	buf.WriteByte('"')
+
+	for _, r := range str {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\x1b':
+			buf.WriteString(`\e`)
+		case '#':
+			buf.WriteByte('#')
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&buf, `\x%02X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteByte('"')
+
+	// Ruby escapes interpolation sequences
+	return strings.NewReplacer("#{", `\#{`, "#$", `\#$`, "#@", `\#@`).Replace(buf.String())
+}
+
+func rbFormatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	abs := math.Abs(f)
+
+	if abs != 0 && (abs >= 1e16 || abs < 1e-4) {
+		str := strconv.FormatFloat(f, 'e', -1, 64)
+		pieces := strings.SplitN(str, "e", 2)
+		if !strings.Contains(pieces[0], ".") {
+			pieces[0] += ".0"
+		}
+		exp := pieces[1]
+		sign := exp[:1]
+		digits := strings.TrimLeft(exp[1:], "0")
+		if len(digits) < 2 {
+			digits = fmt.Sprintf("%02s", digits)
+		}
+		return pieces[0] + "e" + sign + digits
+	}
+
+	str := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(str, ".") {
+		str += ".0"
+	}
+
+	return str
+}
+
+func rbEqual(a, b interface{}) bool {
+	switch typedA := a.(type) {
+	case int:
+		switch typedB := b.(type) {
+		case int:
+			return typedA == typedB
+		case float64:
+			return float64(typedA) == typedB
+		case *big.Int:
+			return rbEqual(typedB, typedA)
+		}
+		return false
+	case float64:
+		switch typedB := b.(type) {
+		case int:
+			return typedA == float64(typedB)
+		case float64:
+			return typedA == typedB
+		case *big.Int:
+			return rbEqual(typedB, typedA)
+		}
+		return false
+	case *big.Int:
+		result, err := rbCompare(typedA, b)
+		return err == nil && result == 0
+	case *rbArray:
+		typedB, ok := b.(*rbArray)
+		if !ok || len(typedA.items) != len(typedB.items) {
+			return false
+		}
+		for i := range typedA.items {
+			if !rbEqual(typedA.items[i], typedB.items[i]) {
+				return false
+			}
+		}
+		return true
+	case *rbHash:
+		typedB, ok := b.(*rbHash)
+		if !ok || typedA.Len() != typedB.Len() {
+			return false
+		}
+		for _, key := range typedA.keys {
+			valB, found := typedB.Get(key)
+			if !found || !rbEqual(typedA.vals[key], valB) {
+				return false
+			}
+		}
+		return true
+	case *rbOpenStruct:
+		typedB, ok := b.(*rbOpenStruct)
+		return ok && rbEqual(typedA.hash, typedB.hash)
+	case *rbRange:
+		typedB, ok := b.(*rbRange)
+		return ok && *typedA == *typedB
+	case *rbRegexp:
+		typedB, ok := b.(*rbRegexp)
+		return ok && typedA.source == typedB.source
+	default:
+		return a == b
+	}
+}
+
+// rbCompare implements <=> for values that can be ordered
+func rbCompare(a, b interface{}) (int, error) {
+	switch typedA := a.(type) {
+	case int, float64, *big.Int:
+		if bigA, ok := rbToBigInt(a); ok {
+			if bigB, ok := rbToBigInt(b); ok {
+				return bigA.Cmp(bigB), nil
+			}
+		}
+		fa, _ := rbToFloat(a)
+		fb, ok := rbToFloat(b)
+		if !ok {
+			break
+		}
+		switch {
+		case fa < fb:
+			return -1, nil
+		case fa > fb:
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if typedB, ok := b.(string); ok {
+			return strings.Compare(typedA, typedB), nil
+		}
+	case rbSymbol:
+		if typedB, ok := b.(rbSymbol); ok {
+			return strings.Compare(string(typedA), string(typedB)), nil
+		}
+	case *rbArray:
+		if typedB, ok := b.(*rbArray); ok {
+			for i := 0; i < len(typedA.items) && i < len(typedB.items); i++ {
+				result, err := rbCompare(typedA.items[i], typedB.items[i])
+				if err != nil || result != 0 {
+					return result, err
+				}
+			}
+			return rbCompare(len(typedA.items), len(typedB.items))
+		}
+	}
+
+	return 0, newRbError("ArgumentError", fmt.Sprintf("comparison of %s with %s failed", rbClassName(a), rbInspect(b)))
+}
+
+func rbToFloat(val interface{}) (float64, bool) {
+	switch typedVal := val.(type) {
+	case int:
+		return float64(typedVal), true
+	case *big.Int:
+		result, _ := new(big.Float).SetInt(typedVal).Float64()
+		return result, true
+	case float64:
+		return typedVal, true
+	default:
+		return 0, false
+	}
+}
+
+func rbToBigInt(val interface{}) (*big.Int, bool) {
+	switch typedVal := val.(type) {
+	case int:
+		return big.NewInt(int64(typedVal)), true
+	case *big.Int:
+		return typedVal, true
+	default:
+		return nil, false
+	}
+}
+
+func rbSort(items []interface{}, less func(a, b interface{}) (int, error)) error {
+	var sortErr error
+
+	sort.SliceStable(items, func(i, j int) bool {
+		result, err := less(items[i], items[j])
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+		return result < 0
+	})
+
+	return sortErr
+}
+
+// rbFromJSON decodes JSON preserving order of object keys
+func rbFromJSON(bs []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.UseNumber()
+
+	val, err := rbDecodeJSONValue(decoder)
+	if err != nil {
+		return nil, newRbError("JSON::ParserError", err.Error())
+	}
+
+	return val, nil
+}
+
+func rbDecodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typedToken := token.(type) {
+	case json.Delim:
+		switch typedToken {
+		case '{':
+			hash := newRbHash()
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				val, err := rbDecodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				hash.Set(keyToken.(string), val)
+			}
+			_, err = decoder.Token()
+			return hash, err
+		case '[':
+			arr := newRbArray()
+			for decoder.More() {
+				val, err := rbDecodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				arr.items = append(arr.items, val)
+			}
+			_, err = decoder.Token()
+			return arr, err
+		}
+	case json.Number:
+		if i, err := strconv.Atoi(typedToken.String()); err == nil {
+			return i, nil
+		}
+		return typedToken.Float64()
+	case string, bool, nil:
+		return typedToken, nil
+	}
+
+	return nil, fmt.Errorf("Unexpected JSON token '%v'", token)
+}
+
+// rbToJSON generates JSON similarly to Ruby's JSON.generate and JSON.pretty_generate
+func rbToJSON(val interface{}, indent string) (string, error) {
+	var buf bytes.Buffer
+
+	err := rbWriteJSON(&buf, val, indent, "")
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func rbWriteJSON(buf *bytes.Buffer, val interface{}, indent, prefix string) error {
+	newline, sep, nextPrefix := "", ":", prefix+indent
+	if len(indent) > 0 {
+		newline, sep = "\n", ": "
+	}
+
+	switch typedVal := val.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool, int, *big.Int:
+		buf.WriteString(rbInspect(typedVal))
+	case float64:
+		if math.IsInf(typedVal, 0) || math.IsNaN(typedVal) {
+			return newRbError("JSON::GeneratorError", rbFormatFloat(typedVal)+" not allowed in JSON")
+		}
+		buf.WriteString(rbFormatFloat(typedVal))
+	case string, rbSymbol, rbClass, *rbRange, *rbRegexp:
+		buf.WriteString(rbJSONString(rbToS(typedVal)))
+	case *rbArray:
+		if len(typedVal.items) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[" + newline)
+		for i, item := range typedVal.items {
+			if i > 0 {
+				buf.WriteString("," + newline)
+			}
+			buf.WriteString(nextPrefix)
+			err := rbWriteJSON(buf, item, indent, nextPrefix)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteString(newline + prefix + "]")
+	case *rbHash:
+		if typedVal.Len() == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{" + newline)
+		for i, key := range typedVal.keys {
+			if i > 0 {
+				buf.WriteString("," + newline)
+			}
+			buf.WriteString(nextPrefix + rbJSONString(rbToS(key)) + sep)
+			err := rbWriteJSON(buf, typedVal.vals[key], indent, nextPrefix)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteString(newline + prefix + "}")
+	case *rbOpenStruct:
+		buf.WriteString(rbJSONString(rbToS(typedVal)))
+	default:
+		buf.WriteString(rbJSONString(rbInspect(typedVal)))
+	}
+
+	return nil
+}
+
+func rbJSONString(str string) string {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(str)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// rbToYAML produces YAML document similar to Ruby's to_yaml
+func rbToYAML(val interface{}) (string, error) {
+	bs, err := yaml.Marshal(rbToYAMLValue(val))
+	if err != nil {
+		return "", newRbError("RuntimeError", err.Error())
+	}
+
+	switch val.(type) {
+	case *rbArray, *rbHash, *rbOpenStruct:
+		if bytes.HasPrefix(bs, []byte("[]")) || bytes.HasPrefix(bs, []byte("{}")) {
+			return "--- " + string(bs), nil
+		}
+		return "---\n" + string(bs), nil
+	default:
+		return "--- " + string(bs), nil
+	}
+}
+
+func rbToYAMLValue(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case *rbArray:
+		items := []interface{}{}
+		for _, item := range typedVal.items {
+			items = append(items, rbToYAMLValue(item))
+		}
+		return items
+	case *rbHash:
+		result := yaml.MapSlice{}
+		for _, key := range typedVal.keys {
+			result = append(result, yaml.MapItem{Key: rbToYAMLValue(key), Value: rbToYAMLValue(typedVal.vals[key])})
+		}
+		return result
+	case *rbOpenStruct:
+		return rbToYAMLValue(typedVal.hash)
+	case rbSymbol:
+		return ":" + string(typedVal)
+	case rbClass, *rbRange, *rbRegexp:
+		return rbToS(typedVal)
+	default:
+		return val
+	}
+}
+
+func rbToOpenStruct(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case *rbHash:
+		result := newRbHash()
+		for _, key := range typedVal.keys {
+			result.Set(key, rbToOpenStruct(typedVal.vals[key]))
+		}
+		return &rbOpenStruct{hash: result}
+	case *rbArray:
+		result := newRbArray()
+		for _, item := range typedVal.items {
+			result.items = append(result.items, rbToOpenStruct(item))
+		}
+		return result
+	default:
+		return val
+	}
+}
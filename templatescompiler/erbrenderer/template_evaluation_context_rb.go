@@ -44,6 +44,7 @@ class TemplateEvaluationContext
     @properties = openstruct(properties)
     @raw_properties = properties
     @spec = openstruct(spec)
+    @links = spec['links'] || {}
   end
 
   def get_binding
@@ -73,8 +74,19 @@ class TemplateEvaluationContext
     InactiveElseBlock.new
   end
   
+  def link(name)
+    link_spec = @links[name]
+    raise("Can't find link '#{name}'") if link_spec.nil?
+
+    create_evaluation_link(link_spec)
+  end
+
   def if_link(name)
-    false
+    link_spec = @links[name]
+    return ActiveElseBlock.new(self) if link_spec.nil?
+
+    yield create_evaluation_link(link_spec)
+    InactiveElseBlock.new
   end
 
   private
@@ -110,6 +122,14 @@ class TemplateEvaluationContext
     end
   end
 
+  def create_evaluation_link(link_spec)
+    instances = (link_spec['instances'] || []).map do |instance|
+      EvaluationLinkInstance.new(instance['index'], instance['address'], instance['bootstrap'])
+    end
+
+    EvaluationLink.new(instances, link_spec['properties'] || {}, link_spec['address'])
+  end
+
   def lookup_property(collection, name)
     keys = name.split(".")
     ref = collection
@@ -131,6 +151,63 @@ class TemplateEvaluationContext
     end
   end
 
+  class EvaluationLinkInstance
+    attr_reader :index, :address, :bootstrap
+
+    def initialize(index, address, bootstrap)
+      @index = index
+      @address = address
+      @bootstrap = bootstrap
+    end
+  end
+
+  class EvaluationLink
+    attr_reader :instances, :properties, :address
+
+    def initialize(instances, properties, address)
+      @instances = instances
+      @properties = properties
+      @address = address
+    end
+
+    def p(*args)
+      names = Array(args[0])
+
+      names.each do |name|
+        result = lookup_property(@properties, name)
+        return result unless result.nil?
+      end
+
+      return args[1] if args.length == 2
+      raise UnknownProperty.new(names)
+    end
+
+    def if_p(*names)
+      values = names.map do |name|
+        value = lookup_property(@properties, name)
+        return ActiveElseBlock.new(self) if value.nil?
+        value
+      end
+
+      yield *values
+      InactiveElseBlock.new
+    end
+
+    private
+
+    def lookup_property(collection, name)
+      keys = name.split(".")
+      ref = collection
+
+      keys.each do |key|
+        ref = ref[key]
+        return nil if ref.nil?
+      end
+
+      ref
+    end
+  end
+
   class ActiveElseBlock
     def initialize(template)
       @context = template
@@ -143,6 +220,10 @@ class TemplateEvaluationContext
     def else_if_p(*names, &block)
       @context.if_p(*names, &block)
     end
+
+    def else_if_link(name, &block)
+      @context.if_link(name, &block)
+    end
   end
 
   class InactiveElseBlock
@@ -151,6 +232,10 @@ class TemplateEvaluationContext
     def else_if_p(*names)
       InactiveElseBlock.new
     end
+
+    def else_if_link(name)
+      InactiveElseBlock.new
+    end
   end
 end
 
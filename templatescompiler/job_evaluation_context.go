@@ -2,6 +2,9 @@ package templatescompiler
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 
 	bireljob "github.com/cloudfoundry/bosh-cli/release/job"
 	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
@@ -11,9 +14,17 @@ import (
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+// JobEvaluationContext is a template evaluation context that
+// can check job properties against release job spec before rendering.
+type JobEvaluationContext interface {
+	bierbrenderer.TemplateEvaluationContext
+	Validate() error
+}
+
 type jobEvaluationContext struct {
 	releaseJob           bireljob.Job
 	releaseJobProperties *biproperty.Map
+	links                map[string]Link
 	jobProperties        biproperty.Map
 	globalProperties     biproperty.Map
 	deploymentName       string
@@ -42,6 +53,9 @@ type RootContext struct {
 	ClusterProperties biproperty.Map  `json:"cluster_properties"` // values from instance group (deployment job) properties
 	JobProperties     *biproperty.Map `json:"job_properties"`     // values from release job (aka template) properties
 	DefaultProperties biproperty.Map  `json:"default_properties"` // values from release's job's spec
+
+	// Usually is accessed with <%= link("name").p("property") %>
+	Links map[string]Link `json:"links,omitempty"`
 }
 
 type jobContext struct {
@@ -57,16 +71,18 @@ type networkContext struct {
 func NewJobEvaluationContext(
 	releaseJob bireljob.Job,
 	releaseJobProperties *biproperty.Map,
+	links map[string]Link,
 	jobProperties biproperty.Map,
 	globalProperties biproperty.Map,
 	deploymentName string,
 	address string,
 	uuidGen boshuuid.Generator,
 	logger boshlog.Logger,
-) bierbrenderer.TemplateEvaluationContext {
+) JobEvaluationContext {
 	return jobEvaluationContext{
 		releaseJob:           releaseJob,
 		releaseJobProperties: releaseJobProperties,
+		links:                links,
 		jobProperties:        jobProperties,
 		globalProperties:     globalProperties,
 		deploymentName:       deploymentName,
@@ -92,6 +108,7 @@ func (ec jobEvaluationContext) MarshalJSON() ([]byte, error) {
 		ClusterProperties: ec.jobProperties,
 		JobProperties:     ec.releaseJobProperties,
 		DefaultProperties: defaultProperties,
+		Links:             ec.links,
 	}
 
 	if len(ec.address) > 0 {
@@ -113,6 +130,213 @@ func (ec jobEvaluationContext) MarshalJSON() ([]byte, error) {
 	return jsonBytes, nil
 }
 
+// Validate checks resolved property values against types and required flags
+// declared in release job spec. All violations are reported together.
+func (ec jobEvaluationContext) Validate() error {
+	var names []string
+
+	for name := range ec.releaseJob.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	properties := ec.resolvedProperties()
+
+	var errs []error
+
+	for _, name := range names {
+		definition := ec.releaseJob.Properties[name]
+
+		value, found := ec.lookupProperty(properties, strings.Split(name, "."))
+		if !found || value == nil {
+			value = definition.Default
+		}
+
+		if value == nil {
+			if definition.Required {
+				errs = append(errs, bosherr.Errorf("Property '%s' is required but was not provided", name))
+			}
+			continue
+		}
+
+		if len(definition.Type) > 0 {
+			err := ec.checkPropertyType(definition.Type, value)
+			if err != nil {
+				errs = append(errs, bosherr.WrapErrorf(err, "Property '%s'", name))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.WrapErrorf(bosherr.NewMultiError(errs...), "Validating properties of job '%s'", ec.releaseJob.Name())
+	}
+
+	return nil
+}
+
+// linkProperties returns values of given job properties to be exposed via provided link;
+// properties that were not set fall back to defaults from release job spec
+func (ec jobEvaluationContext) linkProperties(names []string) (biproperty.Map, error) {
+	properties := ec.resolvedProperties()
+	result := biproperty.Map{}
+
+	for _, name := range names {
+		definition, found := ec.releaseJob.Properties[name]
+		if !found {
+			return nil, bosherr.Errorf("Link property '%s' is not defined in job spec", name)
+		}
+
+		value, found := ec.lookupProperty(properties, strings.Split(name, "."))
+		if !found || value == nil {
+			value = definition.Default
+		}
+
+		keys := strings.Split(name, ".")
+		dst := result
+
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := dst[key].(biproperty.Map)
+			if !ok {
+				next = biproperty.Map{}
+				dst[key] = next
+			}
+			dst = next
+		}
+
+		dst[keys[len(keys)-1]] = value
+	}
+
+	return result, nil
+}
+
+// resolvedProperties mirrors property resolution done by ERB renderer:
+// job properties are used when given, otherwise cluster properties are merged over global ones
+func (ec jobEvaluationContext) resolvedProperties() biproperty.Map {
+	if ec.releaseJobProperties != nil {
+		return *ec.releaseJobProperties
+	}
+
+	result := biproperty.Map{}
+
+	ec.mergeProperties(result, ec.globalProperties)
+	ec.mergeProperties(result, ec.jobProperties)
+
+	return result
+}
+
+func (ec jobEvaluationContext) mergeProperties(dst biproperty.Map, src biproperty.Map) {
+	for key, val := range src {
+		srcMap, srcIsMap := val.(biproperty.Map)
+		dstMap, dstIsMap := dst[key].(biproperty.Map)
+
+		if srcIsMap && dstIsMap {
+			merged := biproperty.Map{}
+			ec.mergeProperties(merged, dstMap)
+			ec.mergeProperties(merged, srcMap)
+			dst[key] = merged
+		} else {
+			dst[key] = val
+		}
+	}
+}
+
+func (ec jobEvaluationContext) lookupProperty(properties interface{}, path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return properties, true
+	}
+
+	var (
+		val   interface{}
+		found bool
+	)
+
+	switch typedProperties := properties.(type) {
+	case biproperty.Map:
+		val, found = typedProperties[path[0]]
+	case map[string]interface{}:
+		val, found = typedProperties[path[0]]
+	case map[interface{}]interface{}:
+		val, found = typedProperties[path[0]]
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	return ec.lookupProperty(val, path[1:])
+}
+
+func (ec jobEvaluationContext) checkPropertyType(propertyType string, value interface{}) error {
+	var valid bool
+
+	switch propertyType {
+	case "string":
+		_, valid = value.(string)
+
+	case "integer":
+		switch typedValue := value.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			valid = true
+		case float32:
+			valid = float64(typedValue) == float64(int64(typedValue))
+		case float64:
+			valid = typedValue == float64(int64(typedValue))
+		}
+
+	case "boolean":
+		_, valid = value.(bool)
+
+	case "certificate":
+		switch typedValue := value.(type) {
+		case string:
+			valid = strings.Contains(typedValue, "-----BEGIN ")
+		default:
+			_, valid = ec.lookupProperty(value, []string{"certificate"})
+		}
+
+	case "array":
+		switch value.(type) {
+		case biproperty.List, []interface{}:
+			valid = true
+		}
+
+	case "hash":
+		switch value.(type) {
+		case biproperty.Map, map[string]interface{}, map[interface{}]interface{}:
+			valid = true
+		}
+
+	default:
+		return bosherr.Errorf("Unknown type '%s' declared in job spec", propertyType)
+	}
+
+	if !valid {
+		return bosherr.Errorf("Expected value to be of type '%s' but was '%s'", propertyType, ec.describeValue(value))
+	}
+
+	return nil
+}
+
+func (ec jobEvaluationContext) describeValue(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case biproperty.List, []interface{}:
+		return "array"
+	case biproperty.Map, map[string]interface{}, map[interface{}]interface{}:
+		return "hash"
+	case float32, float64:
+		return "number"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "integer"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
 func (ec jobEvaluationContext) propertyDefaults(properties map[string]bireljob.PropertyDefinition) biproperty.Map {
 	result := biproperty.Map{}
 	for propertyKey, property := range properties {
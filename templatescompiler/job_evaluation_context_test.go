@@ -25,6 +25,7 @@ var _ = Describe("JobEvaluationContext", func() {
 	var (
 		releaseJob              *boshreljob.Job
 		jobProperties           *biproperty.Map
+		links                   map[string]Link
 		instanceGroupProperties biproperty.Map
 		deploymentProperties    biproperty.Map
 		erbRenderer             erbrenderer.ERBRenderer
@@ -49,6 +50,7 @@ var _ = Describe("JobEvaluationContext", func() {
 
 		uuidGen = fakeuuid.NewFakeGenerator()
 		jobProperties = nil
+		links = nil
 	})
 
 	JustBeforeEach(func() {
@@ -57,6 +59,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		jobEvaluationContext = NewJobEvaluationContext(
 			*releaseJob,
 			jobProperties,
+			links,
 			instanceGroupProperties,
 			deploymentProperties,
 			"fake-deployment-name",
@@ -103,6 +106,23 @@ var _ = Describe("JobEvaluationContext", func() {
 		generatedContext := act()
 		Expect(generatedContext.Bootstrap).To(Equal(true))
 	})
+
+	Context("when job consumes links", func() {
+		BeforeEach(func() {
+			links = map[string]Link{
+				"db": {
+					Address:    "1.2.3.4",
+					Instances:  []LinkInstance{{Index: 0, Address: "1.2.3.4", Bootstrap: true}},
+					Properties: biproperty.Map{"port": "5432"},
+				},
+			}
+		})
+
+		It("it has links available in the spec", func() {
+			generatedContext := act()
+			Expect(generatedContext.Links).To(Equal(links))
+		})
+	})
 	Context("when the UUID generator raise an error", func() {
 		It("it raises an error", func() {
 			uuidGen.GenerateError = errors.Error("boom")
@@ -116,7 +136,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fs := boshsys.NewOsFileSystem(logger)
 		commandRunner := boshsys.NewExecCmdRunner(logger)
-		erbRenderer = erbrenderer.NewERBRenderer(fs, commandRunner, logger)
+		erbRenderer = erbrenderer.NewDefaultERBRenderer(fs, commandRunner, logger)
 
 		srcFile, err := ioutil.TempFile("", "source.txt.erb")
 		Expect(err).ToNot(HaveOccurred())
@@ -135,6 +155,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		jobEvaluationContext := NewJobEvaluationContext(
 			*releaseJob,
 			jobProperties,
+			nil,
 			instanceGroupProperties,
 			deploymentProperties,
 			"fake-deployment-name",
@@ -263,4 +284,86 @@ var _ = Describe("JobEvaluationContext", func() {
 			})
 		})
 	})
+
+	Describe("Validate", func() {
+		validate := func() error {
+			return NewJobEvaluationContext(
+				*releaseJob,
+				jobProperties,
+				nil,
+				instanceGroupProperties,
+				deploymentProperties,
+				"fake-deployment-name",
+				"1.2.3.4",
+				uuidGen,
+				boshlog.NewLogger(boshlog.LevelNone),
+			).Validate()
+		}
+
+		It("returns no error when properties satisfy spec", func() {
+			releaseJob.Properties = map[string]boshreljob.PropertyDefinition{
+				"str":     {Type: "string", Required: true},
+				"int":     {Type: "integer", Default: 5},
+				"bool":    {Type: "boolean", Default: false},
+				"cert":    {Type: "certificate"},
+				"list":    {Type: "array"},
+				"hash":    {Type: "hash"},
+				"untyped": {},
+			}
+
+			instanceGroupProperties = biproperty.Map{
+				"str":  "value",
+				"int":  float64(10),
+				"cert": biproperty.Map{"certificate": "cert", "private_key": "key"},
+				"list": biproperty.List{"a"},
+				"hash": biproperty.Map{"a": "b"},
+			}
+
+			Expect(validate()).ToNot(HaveOccurred())
+		})
+
+		It("accepts required property that has a default", func() {
+			releaseJob.Properties = map[string]boshreljob.PropertyDefinition{
+				"prop": {Required: true, Default: "default"},
+			}
+
+			Expect(validate()).ToNot(HaveOccurred())
+		})
+
+		It("uses job properties when they are given", func() {
+			releaseJob.Properties = map[string]boshreljob.PropertyDefinition{
+				"prop.nested": {Type: "integer", Required: true},
+			}
+
+			instanceGroupProperties = biproperty.Map{"prop": biproperty.Map{"nested": 1}}
+			jobProperties = &biproperty.Map{}
+
+			err := validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Property 'prop.nested' is required but was not provided"))
+		})
+
+		It("reports every violation with job and property names", func() {
+			releaseJob.Properties = map[string]boshreljob.PropertyDefinition{
+				"missing":     {Required: true},
+				"wrong.int":   {Type: "integer"},
+				"wrong.cert":  {Type: "certificate"},
+				"wrong.other": {Type: "unknown"},
+			}
+
+			deploymentProperties = biproperty.Map{
+				"wrong": biproperty.Map{"int": 1.5, "cert": "not-a-cert"},
+			}
+			instanceGroupProperties = biproperty.Map{
+				"wrong": biproperty.Map{"other": "val"},
+			}
+
+			err := validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`Validating properties of job 'fake-job-name': Property 'missing' is required but was not provided
+Property 'wrong.cert': Expected value to be of type 'certificate' but was 'string'
+Property 'wrong.int': Expected value to be of type 'integer' but was 'number'
+Property 'wrong.other': Unknown type 'unknown' declared in job spec`))
+		})
+	})
 })
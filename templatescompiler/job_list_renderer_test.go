@@ -32,6 +32,7 @@ var _ = Describe("JobListRenderer", func() {
 
 		releaseJobs          []boshreljob.Job
 		releaseJobProperties map[string]*biproperty.Map
+		linkOverrides        map[string]LinkOverrides
 		jobProperties        biproperty.Map
 		globalProperties     biproperty.Map
 		deploymentName       string
@@ -41,6 +42,9 @@ var _ = Describe("JobListRenderer", func() {
 
 		jobListRenderer JobListRenderer
 
+		expectedLinks []map[string]Link
+
+		expectRender0 *gomock.Call
 		expectRender1 *gomock.Call
 	)
 
@@ -61,6 +65,8 @@ var _ = Describe("JobListRenderer", func() {
 			"fake-release-job-name-1": &biproperty.Map{},
 		}
 
+		linkOverrides = nil
+
 		jobProperties = biproperty.Map{
 			"fake-key": "fake-job-value",
 		}
@@ -78,16 +84,18 @@ var _ = Describe("JobListRenderer", func() {
 		}
 
 		jobListRenderer = NewJobListRenderer(mockJobRenderer, logger)
+
+		expectedLinks = []map[string]Link{{}, {}}
 	})
 
 	JustBeforeEach(func() {
-		mockJobRenderer.EXPECT().Render(releaseJobs[0], releaseJobProperties[releaseJobs[0].Name()], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[0], nil)
-		expectRender1 = mockJobRenderer.EXPECT().Render(releaseJobs[1], releaseJobProperties[releaseJobs[1].Name()], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[1], nil)
+		expectRender0 = mockJobRenderer.EXPECT().Render(releaseJobs[0], releaseJobProperties[releaseJobs[0].Name()], expectedLinks[0], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[0], nil)
+		expectRender1 = mockJobRenderer.EXPECT().Render(releaseJobs[1], releaseJobProperties[releaseJobs[1].Name()], expectedLinks[1], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[1], nil)
 	})
 
 	Describe("Render", func() {
 		It("returns a new RenderedJobList with all the RenderedJobs", func() {
-			renderedJobList, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address)
+			renderedJobList, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(renderedJobList.All()).To(Equal([]RenderedJob{
 				renderedJobs[0],
@@ -95,6 +103,150 @@ var _ = Describe("JobListRenderer", func() {
 			}))
 		})
 
+		Context("when jobs provide and consume links", func() {
+			BeforeEach(func() {
+				releaseJobs[0].Properties = map[string]boshreljob.PropertyDefinition{
+					"fake-template-property": {},
+					"fake-port":              {Default: 5432},
+				}
+				releaseJobs[0].Provides = []boshreljob.LinkDefinition{
+					{Name: "fake-conn", Type: "fake-db", Properties: []string{"fake-template-property", "fake-port"}},
+				}
+				releaseJobs[1].Consumes = []boshreljob.LinkDefinition{
+					{Name: "fake-db", Type: "fake-db"},
+					{Name: "fake-cache", Type: "fake-cache", Optional: true},
+				}
+			})
+
+			Context("when links are resolved", func() {
+				BeforeEach(func() {
+					expectedLinks[1] = map[string]Link{
+						"fake-db": {
+							Address:   "1.2.3.4",
+							Instances: []LinkInstance{{Index: 0, Address: "1.2.3.4", Bootstrap: true}},
+							Properties: biproperty.Map{
+								"fake-template-property": "fake-template-property-value",
+								"fake-port":              5432,
+							},
+						},
+					}
+				})
+
+				It("renders consuming jobs with links from colocated jobs of matching type", func() {
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				Context("when manifest refers to provided link by its alias", func() {
+					BeforeEach(func() {
+						linkOverrides = map[string]LinkOverrides{
+							"fake-release-job-name-0": {
+								Provides: map[string]ProvidedLinkOverride{"fake-conn": {As: "fake-alias"}},
+							},
+							"fake-release-job-name-1": {
+								Consumes: map[string]ConsumedLinkOverride{"fake-db": {From: "fake-alias"}},
+							},
+						}
+					})
+
+					It("renders consuming jobs with link provided under that alias", func() {
+						_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+						Expect(err).ToNot(HaveOccurred())
+					})
+				})
+			})
+
+			Context("when manifest specifies links manually", func() {
+				BeforeEach(func() {
+					manualLink := Link{
+						Instances:  []LinkInstance{{Index: 0, Address: "fake-external-address", Bootstrap: true}},
+						Properties: biproperty.Map{"fake-port": 1234},
+					}
+
+					linkOverrides = map[string]LinkOverrides{
+						"fake-release-job-name-1": {
+							Consumes: map[string]ConsumedLinkOverride{"fake-db": {Manual: &manualLink}},
+						},
+					}
+
+					expectedLinks[1] = map[string]Link{"fake-db": manualLink}
+				})
+
+				It("renders consuming jobs with manual links", func() {
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("when links cannot be resolved", func() {
+				JustBeforeEach(func() {
+					expectRender0.Times(0)
+					expectRender1.Times(0)
+				})
+
+				It("returns an error when required link is not provided", func() {
+					releaseJobs[0].Provides = nil
+
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Link 'fake-db' consumed by job 'fake-release-job-name-1' is required but no job provides link of type 'fake-db'"))
+				})
+
+				It("returns an error when required link is set to nil", func() {
+					linkOverrides = map[string]LinkOverrides{
+						"fake-release-job-name-1": {
+							Consumes: map[string]ConsumedLinkOverride{"fake-db": {Disabled: true}},
+						},
+					}
+
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Link 'fake-db' consumed by job 'fake-release-job-name-1' is required and cannot be set to nil"))
+				})
+
+				It("returns an error when there are multiple providers of the same type", func() {
+					releaseJobs[1].Provides = []boshreljob.LinkDefinition{{Name: "fake-other-conn", Type: "fake-db"}}
+
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Link 'fake-db' consumed by job 'fake-release-job-name-1' has multiple providers of type 'fake-db' (fake-release-job-name-0.fake-conn, fake-release-job-name-1.fake-other-conn), use 'from' to choose one"))
+				})
+
+				It("returns an error when link referred with 'from' is of different type", func() {
+					releaseJobs[0].Provides[0].Type = "fake-other-type"
+					linkOverrides = map[string]LinkOverrides{
+						"fake-release-job-name-1": {
+							Consumes: map[string]ConsumedLinkOverride{"fake-db": {From: "fake-conn"}},
+						},
+					}
+
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Link 'fake-db' consumed by job 'fake-release-job-name-1' is of type 'fake-db' but link 'fake-conn' provided by job 'fake-release-job-name-0' is of type 'fake-other-type'"))
+				})
+
+				It("returns an error when manifest specifies link that job does not consume", func() {
+					linkOverrides = map[string]LinkOverrides{
+						"fake-release-job-name-0": {
+							Consumes: map[string]ConsumedLinkOverride{"fake-unknown": {From: "fake-conn"}},
+						},
+					}
+
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Job 'fake-release-job-name-0' does not consume link 'fake-unknown'"))
+				})
+
+				It("returns an error when provided link exposes property that is not in job spec", func() {
+					releaseJobs[0].Provides[0].Properties = []string{"fake-unknown-property"}
+
+					_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Link property 'fake-unknown-property' is not defined in job spec"))
+				})
+			})
+		})
+
 		Context("when rendering a job fails", func() {
 			JustBeforeEach(func() {
 				expectRender1.Return(nil, bosherr.Error("fake-render-error"))
@@ -103,7 +255,7 @@ var _ = Describe("JobListRenderer", func() {
 			It("returns an error and cleans up any sucessfully rendered jobs", func() {
 				renderedJobs[0].EXPECT().DeleteSilently()
 
-				_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address)
+				_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, linkOverrides, jobProperties, globalProperties, deploymentName, address)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-render-error"))
 			})
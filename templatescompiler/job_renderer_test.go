@@ -56,7 +56,7 @@ var _ = Describe("JobRenderer", func() {
 
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 
-		context = NewJobEvaluationContext(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4", nil, logger)
+		context = NewJobEvaluationContext(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4", nil, logger)
 
 		fakeERBRenderer = fakebirender.NewFakeERBRender()
 
@@ -87,7 +87,7 @@ var _ = Describe("JobRenderer", func() {
 
 	Describe("Render", func() {
 		It("renders job templates", func() {
-			renderedjob, err := jobRenderer.Render(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
+			renderedjob, err := jobRenderer.Render(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(fakeERBRenderer.RenderInputs).To(Equal([]fakebirender.RenderInput{
@@ -115,10 +115,26 @@ var _ = Describe("JobRenderer", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := jobRenderer.Render(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
+				_, err := jobRenderer.Render(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-template-render-error"))
 			})
 		})
+
+		Context("when job properties do not satisfy job spec", func() {
+			BeforeEach(func() {
+				job.Properties = map[string]boshreljob.PropertyDefinition{
+					"fake-required-property": boshreljob.PropertyDefinition{Required: true},
+				}
+			})
+
+			It("returns an error without rendering templates", func() {
+				_, err := jobRenderer.Render(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Property 'fake-required-property' is required but was not provided"))
+
+				Expect(fakeERBRenderer.RenderInputs).To(BeEmpty())
+			})
+		})
 	})
 })
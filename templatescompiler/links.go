@@ -0,0 +1,249 @@
+package templatescompiler
+
+import (
+	"sort"
+	"strings"
+
+	bireljob "github.com/cloudfoundry/bosh-cli/release/job"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+// Link is exposed in ERB templates via link() and if_link()
+type Link struct {
+	Address    string         `json:"address,omitempty"`
+	Instances  []LinkInstance `json:"instances"`
+	Properties biproperty.Map `json:"properties"`
+}
+
+type LinkInstance struct {
+	Index     int    `json:"index"`
+	Address   string `json:"address"`
+	Bootstrap bool   `json:"bootstrap"`
+}
+
+// LinkOverrides are specified for a release job in the deployment manifest
+// to change how its links are provided and consumed.
+type LinkOverrides struct {
+	Provides map[string]ProvidedLinkOverride
+	Consumes map[string]ConsumedLinkOverride
+}
+
+type ProvidedLinkOverride struct {
+	As string
+}
+
+type ConsumedLinkOverride struct {
+	From     string
+	Disabled bool
+	Manual   *Link
+}
+
+type linkProvider struct {
+	name    string
+	linkDef bireljob.LinkDefinition
+	jobName string
+	link    Link
+}
+
+type linkResolver struct {
+	releaseJobs          []bireljob.Job
+	releaseJobProperties map[string]*biproperty.Map
+	linkOverrides        map[string]LinkOverrides
+	jobProperties        biproperty.Map
+	globalProperties     biproperty.Map
+	address              string
+}
+
+// Resolve returns links consumed by each release job keyed by job name.
+// All jobs are colocated on a single instance so links can only be provided
+// by jobs on that instance or specified manually in the deployment manifest.
+func (r linkResolver) Resolve() (map[string]map[string]Link, error) {
+	var errs []error
+
+	providers, err := r.providers()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]map[string]Link{}
+
+	for _, releaseJob := range r.releaseJobs {
+		overrides := r.linkOverrides[releaseJob.Name()]
+		links := map[string]Link{}
+
+		consumedNames := map[string]struct{}{}
+
+		for _, linkDef := range releaseJob.Consumes {
+			consumedNames[linkDef.Name] = struct{}{}
+
+			link, found, err := r.resolveConsumedLink(releaseJob, linkDef, overrides.Consumes[linkDef.Name], providers)
+			if err != nil {
+				errs = append(errs, err)
+			} else if found {
+				links[linkDef.Name] = link
+			}
+		}
+
+		for _, name := range sortedConsumedLinkNames(overrides.Consumes) {
+			if _, found := consumedNames[name]; !found {
+				errs = append(errs, bosherr.Errorf("Job '%s' does not consume link '%s'", releaseJob.Name(), name))
+			}
+		}
+
+		result[releaseJob.Name()] = links
+	}
+
+	if len(errs) > 0 {
+		return nil, bosherr.WrapError(bosherr.NewMultiError(errs...), "Resolving links")
+	}
+
+	return result, nil
+}
+
+func (r linkResolver) providers() ([]linkProvider, error) {
+	var (
+		providers []linkProvider
+		errs      []error
+	)
+
+	for _, releaseJob := range r.releaseJobs {
+		overrides := r.linkOverrides[releaseJob.Name()]
+
+		context := jobEvaluationContext{
+			releaseJob:           releaseJob,
+			releaseJobProperties: r.releaseJobProperties[releaseJob.Name()],
+			jobProperties:        r.jobProperties,
+			globalProperties:     r.globalProperties,
+		}
+
+		providedNames := map[string]struct{}{}
+
+		for _, linkDef := range releaseJob.Provides {
+			providedNames[linkDef.Name] = struct{}{}
+
+			name := linkDef.Name
+			if override, found := overrides.Provides[linkDef.Name]; found && override.As != "" {
+				name = override.As
+			}
+
+			properties, err := context.linkProperties(linkDef.Properties)
+			if err != nil {
+				errs = append(errs, bosherr.WrapErrorf(err, "Providing link '%s' from job '%s'", linkDef.Name, releaseJob.Name()))
+				continue
+			}
+
+			providers = append(providers, linkProvider{
+				name:    name,
+				linkDef: linkDef,
+				jobName: releaseJob.Name(),
+				link: Link{
+					Address:    r.address,
+					Instances:  []LinkInstance{{Index: 0, Address: r.address, Bootstrap: true}},
+					Properties: properties,
+				},
+			})
+		}
+
+		for _, name := range sortedProvidedLinkNames(overrides.Provides) {
+			if _, found := providedNames[name]; !found {
+				errs = append(errs, bosherr.Errorf("Job '%s' does not provide link '%s'", releaseJob.Name(), name))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, bosherr.WrapError(bosherr.NewMultiError(errs...), "Resolving links")
+	}
+
+	return providers, nil
+}
+
+func (r linkResolver) resolveConsumedLink(
+	releaseJob bireljob.Job,
+	linkDef bireljob.LinkDefinition,
+	override ConsumedLinkOverride,
+	providers []linkProvider,
+) (Link, bool, error) {
+	if override.Disabled {
+		if !linkDef.Optional {
+			return Link{}, false, bosherr.Errorf("Link '%s' consumed by job '%s' is required and cannot be set to nil", linkDef.Name, releaseJob.Name())
+		}
+		return Link{}, false, nil
+	}
+
+	if override.Manual != nil {
+		return *override.Manual, true, nil
+	}
+
+	var candidates []linkProvider
+
+	for _, provider := range providers {
+		if len(override.From) > 0 {
+			if provider.name != override.From {
+				continue
+			}
+			if provider.linkDef.Type != linkDef.Type {
+				return Link{}, false, bosherr.Errorf(
+					"Link '%s' consumed by job '%s' is of type '%s' but link '%s' provided by job '%s' is of type '%s'",
+					linkDef.Name, releaseJob.Name(), linkDef.Type, provider.name, provider.jobName, provider.linkDef.Type)
+			}
+		} else if provider.linkDef.Type != linkDef.Type {
+			continue
+		}
+
+		candidates = append(candidates, provider)
+	}
+
+	switch len(candidates) {
+	case 0:
+		if linkDef.Optional {
+			return Link{}, false, nil
+		}
+
+		if len(override.From) > 0 {
+			return Link{}, false, bosherr.Errorf(
+				"Link '%s' consumed by job '%s' refers to link '%s' which is not provided by any job", linkDef.Name, releaseJob.Name(), override.From)
+		}
+
+		return Link{}, false, bosherr.Errorf(
+			"Link '%s' consumed by job '%s' is required but no job provides link of type '%s'", linkDef.Name, releaseJob.Name(), linkDef.Type)
+
+	case 1:
+		return candidates[0].link, true, nil
+
+	default:
+		var names []string
+		for _, candidate := range candidates {
+			names = append(names, candidate.jobName+"."+candidate.name)
+		}
+
+		return Link{}, false, bosherr.Errorf(
+			"Link '%s' consumed by job '%s' has multiple providers of type '%s' (%s), use 'from' to choose one",
+			linkDef.Name, releaseJob.Name(), linkDef.Type, strings.Join(names, ", "))
+	}
+}
+
+func sortedConsumedLinkNames(overrides map[string]ConsumedLinkOverride) []string {
+	var names []string
+
+	for name := range overrides {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedProvidedLinkNames(overrides map[string]ProvidedLinkOverride) []string {
+	var names []string
+
+	for name := range overrides {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"sync"
+)
+
+// stepStarter is implemented by stages that can report a step
+// whose closure is executed outside of the stage
+type stepStarter interface {
+	startStep(name string) func(error) error
+}
+
+type concurrentStage struct {
+	stage Stage
+	lock  *sync.Mutex
+}
+
+// NewConcurrentStage returns stage that can be performed from multiple goroutines.
+// Each step is reported by the wrapped stage once it finishes so that
+// lines of steps performed at the same time are not interleaved.
+func NewConcurrentStage(stage Stage) Stage {
+	return concurrentStage{stage: stage, lock: &sync.Mutex{}}
+}
+
+func (s concurrentStage) Perform(name string, closure func() error) error {
+	starter, ok := s.stage.(stepStarter)
+	if !ok {
+		err := closure()
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		return s.stage.Perform(name, func() error { return err })
+	}
+
+	s.lock.Lock()
+	finish := starter.startStep(name)
+	s.lock.Unlock()
+
+	err := closure()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return finish(err)
+}
+
+// PerformComplex does not run concurrently with other steps
+// since complex stages span multiple lines
+func (s concurrentStage) PerformComplex(name string, closure func(Stage) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.stage.PerformComplex(name, closure)
+}
@@ -0,0 +1,93 @@
+package ui_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("ConcurrentStage", func() {
+	var (
+		fakeTimeService *fakeclock.FakeClock
+		logger          boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		fakeTimeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	Describe("Perform", func() {
+		It("prints each step once it finishes with duration measured from its start", func() {
+			uiOut := bytes.NewBufferString("")
+			ui := NewWriterUI(uiOut, bytes.NewBufferString(""), logger)
+			stage := NewConcurrentStage(NewStage(ui, fakeTimeService, logger))
+
+			err := stage.Perform("Step 1", func() error {
+				fakeTimeService.Increment(time.Minute)
+
+				err := stage.Perform("Step 2", func() error {
+					fakeTimeService.Increment(time.Minute)
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeTimeService.Increment(time.Minute)
+				return errors.New("fake-err")
+			})
+			Expect(err).To(MatchError("fake-err"))
+
+			Expect(uiOut.String()).To(Equal(
+				"Step 2... Finished (00:01:00)\n" +
+					"Step 1... Failed (00:03:00)\n",
+			))
+		})
+
+		It("writes started event when step starts and finished event when it finishes", func() {
+			events := &fakeEventWriter{}
+			stage := NewConcurrentStage(NewEventStage(events, fakeTimeService, logger))
+
+			err := stage.Perform("Step 1", func() error {
+				return stage.Perform("Step 2", func() error { return nil })
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			var states []string
+			for _, event := range events.Events {
+				states = append(states, event.Stage[0]+" "+event.State)
+			}
+
+			Expect(states).To(Equal([]string{
+				"Step 1 started",
+				"Step 2 started",
+				"Step 2 finished",
+				"Step 1 finished",
+			}))
+		})
+
+		It("reports step to other stages after it finishes", func() {
+			fakeStage := fakeui.NewFakeStage()
+			stage := NewConcurrentStage(fakeStage)
+
+			skipErr := NewSkipStageError(errors.New("fake-err"), "fake-skip")
+
+			err := stage.Perform("Step 1", func() error {
+				Expect(fakeStage.PerformCalls).To(BeEmpty())
+				return skipErr
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+				{Name: "Step 1", Error: skipErr, SkipError: skipErr},
+			}))
+		})
+	})
+})
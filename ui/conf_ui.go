@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"code.cloudfoundry.org/clock"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	. "github.com/cloudfoundry/bosh-cli/ui/table"
@@ -8,6 +9,7 @@ import (
 
 type ConfUI struct {
 	parent      UI
+	events      EventWriter
 	isTTY       bool
 	logger      boshlog.Logger
 	showColumns []Header
@@ -48,6 +50,23 @@ func (ui *ConfUI) EnableJSON() {
 	ui.parent = NewJSONUI(ui.parent, ui.logger)
 }
 
+func (ui *ConfUI) EnableEvents(timeService clock.Clock) {
+	eventsUI := NewEventsUI(ui.parent, timeService, ui.logger)
+	ui.parent = eventsUI
+	ui.events = eventsUI
+}
+
+// WriteEvent writes event only when events output was enabled
+func (ui *ConfUI) WriteEvent(event Event) {
+	if ui.events != nil {
+		ui.events.WriteEvent(event)
+	}
+}
+
+func (ui *ConfUI) IsEventsEnabled() bool {
+	return ui.events != nil
+}
+
 func (ui *ConfUI) ShowColumns(columns []Header) {
 	ui.showColumns = columns
 }
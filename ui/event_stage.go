@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type eventStage struct {
+	events      EventWriter
+	timeService clock.Clock
+
+	path []string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+// NewEventStage returns stage that reports its progress as events
+// (started, finished, skipped or failed) instead of printing lines.
+// Events include names of all enclosing stages.
+func NewEventStage(events EventWriter, timeService clock.Clock, logger boshlog.Logger) Stage {
+	return &eventStage{
+		events:      events,
+		timeService: timeService,
+
+		logTag: "eventStage",
+		logger: logger,
+	}
+}
+
+func (s *eventStage) Perform(name string, closure func() error) error {
+	return s.perform(name, closure)
+}
+
+func (s *eventStage) PerformComplex(name string, closure func(Stage) error) error {
+	subStage := &eventStage{
+		events:      s.events,
+		timeService: s.timeService,
+
+		path: s.stagePath(name),
+
+		logTag: s.logTag,
+		logger: s.logger,
+	}
+
+	return s.perform(name, func() error { return closure(subStage) })
+}
+
+func (s *eventStage) perform(name string, closure func() error) error {
+	finish := s.startStep(name)
+	return finish(closure())
+}
+
+func (s *eventStage) startStep(name string) func(error) error {
+	path := s.stagePath(name)
+
+	s.events.WriteEvent(Event{
+		Time:  s.timeService.Now(),
+		Type:  EventTypeStage,
+		State: EventStateStarted,
+		Stage: path,
+	})
+
+	startTime := s.timeService.Now()
+
+	return func(err error) error {
+		stopTime := s.timeService.Now()
+		duration := stopTime.Sub(startTime).Seconds()
+
+		event := Event{
+			Time:     stopTime,
+			Type:     EventTypeStage,
+			State:    EventStateFinished,
+			Stage:    path,
+			Duration: &duration,
+		}
+
+		if err != nil {
+			if skipErr, ok := err.(SkipStageError); ok {
+				event.State = EventStateSkipped
+				event.Message = skipErr.SkipMessage()
+				s.events.WriteEvent(event)
+				s.logger.Info(s.logTag, "Skipped stage '%s': %s", name, skipErr.Error())
+				return nil
+			}
+
+			event.State = EventStateFailed
+			event.Error = err.Error()
+			s.events.WriteEvent(event)
+			return err
+		}
+
+		s.events.WriteEvent(event)
+		return nil
+	}
+}
+
+func (s *eventStage) stagePath(name string) []string {
+	path := make([]string, len(s.path), len(s.path)+1)
+	copy(path, s.path)
+	return append(path, name)
+}
@@ -0,0 +1,119 @@
+package ui_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type fakeEventWriter struct {
+	Events []Event
+}
+
+func (w *fakeEventWriter) WriteEvent(event Event) {
+	w.Events = append(w.Events, event)
+}
+
+var _ = Describe("EventStage", func() {
+	var (
+		events          *fakeEventWriter
+		fakeTimeService *fakeclock.FakeClock
+		startTime       time.Time
+		stage           Stage
+	)
+
+	BeforeEach(func() {
+		events = &fakeEventWriter{}
+		startTime = time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+		fakeTimeService = fakeclock.NewFakeClock(startTime)
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		stage = NewEventStage(events, fakeTimeService, logger)
+	})
+
+	durationOf := func(d float64) *float64 { return &d }
+
+	Describe("Perform", func() {
+		It("writes started and finished events", func() {
+			err := stage.Perform("Simple stage", func() error {
+				fakeTimeService.Increment(time.Minute)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(events.Events).To(Equal([]Event{
+				{Time: startTime, Type: "stage", State: "started", Stage: []string{"Simple stage"}},
+				{Time: startTime.Add(time.Minute), Type: "stage", State: "finished", Stage: []string{"Simple stage"}, Duration: durationOf(60)},
+			}))
+		})
+
+		It("writes failed event with error", func() {
+			stageErr := errors.New("fake-err")
+
+			err := stage.Perform("Simple stage", func() error { return stageErr })
+			Expect(err).To(Equal(stageErr))
+
+			Expect(events.Events[1]).To(Equal(Event{
+				Time: startTime, Type: "stage", State: "failed", Stage: []string{"Simple stage"}, Duration: durationOf(0), Error: "fake-err",
+			}))
+		})
+
+		It("writes skipped event with skip message", func() {
+			err := stage.Perform("Simple stage", func() error {
+				return NewSkipStageError(errors.New("fake-cause"), "fake-skip-message")
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(events.Events[1]).To(Equal(Event{
+				Time: startTime, Type: "stage", State: "skipped", Stage: []string{"Simple stage"}, Duration: durationOf(0), Message: "fake-skip-message",
+			}))
+		})
+	})
+
+	Describe("PerformComplex", func() {
+		It("includes names of enclosing stages in events", func() {
+			err := stage.PerformComplex("Complex stage", func(stage Stage) error {
+				return stage.PerformComplex("Nested stage", func(stage Stage) error {
+					return stage.Perform("Simple stage", func() error {
+						fakeTimeService.Increment(time.Second)
+						return nil
+					})
+				})
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			var states [][]string
+
+			for _, event := range events.Events {
+				states = append(states, append([]string{event.State}, event.Stage...))
+			}
+
+			Expect(states).To(Equal([][]string{
+				{"started", "Complex stage"},
+				{"started", "Complex stage", "Nested stage"},
+				{"started", "Complex stage", "Nested stage", "Simple stage"},
+				{"finished", "Complex stage", "Nested stage", "Simple stage"},
+				{"finished", "Complex stage", "Nested stage"},
+				{"finished", "Complex stage"},
+			}))
+
+			Expect(events.Events[5].Duration).To(Equal(durationOf(1)))
+		})
+
+		It("writes failed event when nested stage fails", func() {
+			err := stage.PerformComplex("Complex stage", func(stage Stage) error {
+				return stage.Perform("Simple stage", func() error { return errors.New("fake-err") })
+			})
+			Expect(err).To(HaveOccurred())
+
+			Expect(events.Events[3].State).To(Equal("failed"))
+			Expect(events.Events[3].Stage).To(Equal([]string{"Complex stage"}))
+			Expect(events.Events[3].Error).To(Equal("fake-err"))
+		})
+	})
+})
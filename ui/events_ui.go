@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+const (
+	EventTypeStage  = "stage"
+	EventTypeOutput = "output"
+	EventTypeError  = "error"
+	EventTypeTable  = "table"
+
+	EventStateStarted  = "started"
+	EventStateFinished = "finished"
+	EventStateSkipped  = "skipped"
+	EventStateFailed   = "failed"
+)
+
+// Event is a single entry of newline delimited JSON event stream
+type Event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+
+	// Stage events
+	State    string   `json:"state,omitempty"`
+	Stage    []string `json:"stage,omitempty"`
+	Duration *float64 `json:"duration,omitempty"` // seconds
+
+	Message string     `json:"message,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	Table   *tableResp `json:"table,omitempty"`
+}
+
+type EventWriter interface {
+	WriteEvent(Event)
+}
+
+// EventsUI emits each piece of output as soon as it's produced
+// as a JSON event on its own line instead of human readable text.
+type EventsUI struct {
+	parent      UI
+	timeService clock.Clock
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewEventsUI(parent UI, timeService clock.Clock, logger boshlog.Logger) *EventsUI {
+	return &EventsUI{
+		parent:      parent,
+		timeService: timeService,
+
+		logTag: "EventsUI",
+		logger: logger,
+	}
+}
+
+func (ui *EventsUI) WriteEvent(event Event) {
+	if event.Time.IsZero() {
+		event.Time = ui.timeService.Now()
+	}
+
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		ui.logger.Error(ui.logTag, "Failed to marshal event: %s", err)
+		return
+	}
+
+	ui.parent.PrintBlock(string(bytes) + "\n")
+}
+
+func (ui *EventsUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.WriteEvent(Event{Type: EventTypeError, Message: fmt.Sprintf(pattern, args...)})
+}
+
+func (ui *EventsUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.WriteEvent(Event{Type: EventTypeOutput, Message: fmt.Sprintf(pattern, args...)})
+}
+
+func (ui *EventsUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.WriteEvent(Event{Type: EventTypeOutput, Message: fmt.Sprintf(pattern, args...)})
+}
+
+func (ui *EventsUI) EndLinef(pattern string, args ...interface{}) {
+	ui.WriteEvent(Event{Type: EventTypeOutput, Message: fmt.Sprintf(pattern, args...)})
+}
+
+func (ui *EventsUI) PrintBlock(block string) {
+	ui.WriteEvent(Event{Type: EventTypeOutput, Message: block})
+}
+
+func (ui *EventsUI) PrintErrorBlock(block string) {
+	ui.WriteEvent(Event{Type: EventTypeError, Message: block})
+}
+
+func (ui *EventsUI) PrintTable(table Table) {
+	resp := newTableResp(table)
+	ui.WriteEvent(Event{Type: EventTypeTable, Table: &resp})
+}
+
+func (ui *EventsUI) AskForText(_ string) (string, error) {
+	panic("Cannot ask for input in events UI")
+}
+
+func (ui *EventsUI) AskForChoice(_ string, _ []string) (int, error) {
+	panic("Cannot ask for a choice in events UI")
+}
+
+func (ui *EventsUI) AskForPassword(_ string) (string, error) {
+	panic("Cannot ask for password in events UI")
+}
+
+func (ui *EventsUI) AskForConfirmation() error {
+	panic("Cannot ask for confirmation in events UI")
+}
+
+func (ui *EventsUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+func (ui *EventsUI) Flush() {
+	ui.parent.Flush()
+}
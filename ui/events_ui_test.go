@@ -0,0 +1,98 @@
+package ui_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("EventsUI", func() {
+	var (
+		parentUI *fakeui.FakeUI
+		ui       *EventsUI
+	)
+
+	BeforeEach(func() {
+		parentUI = &fakeui.FakeUI{}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		timeService := fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 20, 30, 0, time.UTC))
+		ui = NewEventsUI(parentUI, timeService, logger)
+	})
+
+	Describe("WriteEvent", func() {
+		It("writes event as a single line of JSON", func() {
+			duration := 1.5
+
+			ui.WriteEvent(Event{
+				Time:     time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC),
+				Type:     EventTypeStage,
+				State:    EventStateFailed,
+				Stage:    []string{"deploying", "Creating VM"},
+				Duration: &duration,
+				Error:    "fake-err",
+			})
+
+			Expect(parentUI.Blocks).To(Equal([]string{
+				`{"time":"2017-03-01T10:00:00Z","type":"stage","state":"failed","stage":["deploying","Creating VM"],"duration":1.5,"error":"fake-err"}` + "\n",
+			}))
+		})
+
+		It("uses current time if event time is not set", func() {
+			ui.WriteEvent(Event{Type: EventTypeStage})
+
+			Expect(parentUI.Blocks).To(Equal([]string{`{"time":"2017-03-01T10:20:30Z","type":"stage"}` + "\n"}))
+		})
+	})
+
+	Describe("printing", func() {
+		It("writes lines and blocks as output events", func() {
+			ui.PrintLinef("fake-line-%d", 1)
+			ui.BeginLinef("fake-begin")
+			ui.EndLinef("fake-end")
+			ui.PrintBlock("fake-block")
+
+			Expect(parentUI.Blocks).To(Equal([]string{
+				`{"time":"2017-03-01T10:20:30Z","type":"output","message":"fake-line-1"}` + "\n",
+				`{"time":"2017-03-01T10:20:30Z","type":"output","message":"fake-begin"}` + "\n",
+				`{"time":"2017-03-01T10:20:30Z","type":"output","message":"fake-end"}` + "\n",
+				`{"time":"2017-03-01T10:20:30Z","type":"output","message":"fake-block"}` + "\n",
+			}))
+		})
+
+		It("writes errors as error events", func() {
+			ui.ErrorLinef("fake-error-%d", 1)
+			ui.PrintErrorBlock("fake-error-block")
+
+			Expect(parentUI.Blocks).To(Equal([]string{
+				`{"time":"2017-03-01T10:20:30Z","type":"error","message":"fake-error-1"}` + "\n",
+				`{"time":"2017-03-01T10:20:30Z","type":"error","message":"fake-error-block"}` + "\n",
+			}))
+		})
+
+		It("writes tables as table events", func() {
+			ui.PrintTable(Table{
+				Content: "things",
+				Header:  []Header{NewHeader("Header1")},
+				Rows:    [][]Value{{ValueString{S: "r1c1"}}},
+			})
+
+			Expect(parentUI.Blocks).To(Equal([]string{
+				`{"time":"2017-03-01T10:20:30Z","type":"table","table":{"Content":"things","Header":{"header1":"Header1"},"Rows":[{"header1":"r1c1"}],"Notes":null}}` + "\n",
+			}))
+		})
+	})
+
+	Describe("Flush", func() {
+		It("flushes parent", func() {
+			ui.Flush()
+			Expect(parentUI.Flushed).To(BeTrue())
+		})
+	})
+})
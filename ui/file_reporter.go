@@ -29,7 +29,15 @@ func (r FileReporter) TrackUpload(size int64, reader io.ReadCloser) ReadSeekClos
 }
 
 func (r FileReporter) TrackDownload(size int64, writer io.Writer) io.Writer {
-	return io.MultiWriter(writer, r.buildBar(size))
+	return r.TrackResumedDownload(0, size, writer)
+}
+
+// TrackResumedDownload shows progress of total size with bytes
+// before offset already counted as downloaded
+func (r FileReporter) TrackResumedDownload(offset, size int64, writer io.Writer) io.Writer {
+	bar := r.buildBar(size)
+	bar.Set64(offset)
+	return io.MultiWriter(writer, bar)
 }
 
 func (r FileReporter) buildBar(size int64) *pb.ProgressBar {
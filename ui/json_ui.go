@@ -61,6 +61,44 @@ func (ui *jsonUI) PrintErrorBlock(block string) {
 }
 
 func (ui *jsonUI) PrintTable(table Table) {
+	ui.uiResp.Tables = append(ui.uiResp.Tables, newTableResp(table))
+}
+
+func (ui *jsonUI) AskForText(_ string) (string, error) {
+	panic("Cannot ask for input in JSON UI")
+}
+
+func (ui *jsonUI) AskForChoice(_ string, _ []string) (int, error) {
+	panic("Cannot ask for a choice in JSON UI")
+}
+
+func (ui *jsonUI) AskForPassword(_ string) (string, error) {
+	panic("Cannot ask for password in JSON UI")
+}
+
+func (ui *jsonUI) AskForConfirmation() error {
+	panic("Cannot ask for confirmation in JSON UI")
+}
+
+func (ui *jsonUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+func (ui *jsonUI) Flush() {
+	defer ui.parent.Flush()
+
+	if !reflect.DeepEqual(ui.uiResp, uiResp{}) {
+		bytes, err := json.MarshalIndent(ui.uiResp, "", "    ")
+		if err != nil {
+			ui.logger.Error(ui.logTag, "Failed to marshal UI response")
+			return
+		}
+
+		ui.parent.PrintBlock(string(bytes))
+	}
+}
+
+func newTableResp(table Table) tableResp {
 	table.FillFirstColumn = true
 
 	header := map[string]string{}
@@ -93,48 +131,14 @@ func (ui *jsonUI) PrintTable(table Table) {
 	resp := tableResp{
 		Content: table.Content,
 		Header:  header,
-		Rows:    ui.stringRows(table.Header, table.AsRows()),
+		Rows:    tableStringRows(table.Header, table.AsRows()),
 		Notes:   table.Notes,
 	}
 
-	ui.uiResp.Tables = append(ui.uiResp.Tables, resp)
-}
-
-func (ui *jsonUI) AskForText(_ string) (string, error) {
-	panic("Cannot ask for input in JSON UI")
-}
-
-func (ui *jsonUI) AskForChoice(_ string, _ []string) (int, error) {
-	panic("Cannot ask for a choice in JSON UI")
-}
-
-func (ui *jsonUI) AskForPassword(_ string) (string, error) {
-	panic("Cannot ask for password in JSON UI")
-}
-
-func (ui *jsonUI) AskForConfirmation() error {
-	panic("Cannot ask for confirmation in JSON UI")
-}
-
-func (ui *jsonUI) IsInteractive() bool {
-	return ui.parent.IsInteractive()
-}
-
-func (ui *jsonUI) Flush() {
-	defer ui.parent.Flush()
-
-	if !reflect.DeepEqual(ui.uiResp, uiResp{}) {
-		bytes, err := json.MarshalIndent(ui.uiResp, "", "    ")
-		if err != nil {
-			ui.logger.Error(ui.logTag, "Failed to marshal UI response")
-			return
-		}
-
-		ui.parent.PrintBlock(string(bytes))
-	}
+	return resp
 }
 
-func (ui *jsonUI) stringRows(header []Header, rows [][]Value) []map[string]string {
+func tableStringRows(header []Header, rows [][]Value) []map[string]string {
 	result := []map[string]string{}
 
 	for _, row := range rows {
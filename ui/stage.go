@@ -36,6 +36,23 @@ func NewStage(ui UI, timeService clock.Clock, logger boshlog.Logger) Stage {
 }
 
 func (s *stage) Perform(name string, closure func() error) error {
+	s.beginLine(name)
+	startTime := s.timeService.Now()
+	return s.endLine(name, startTime, closure())
+}
+
+// startStep measures duration of a step from now but prints it only once
+// the step is finished so that concurrently performed steps do not interleave
+func (s *stage) startStep(name string) func(error) error {
+	startTime := s.timeService.Now()
+
+	return func(err error) error {
+		s.beginLine(name)
+		return s.endLine(name, startTime, err)
+	}
+}
+
+func (s *stage) beginLine(name string) {
 	if !s.simpleMode {
 		// enter simple mode (only line break if exiting complex mode)
 		s.ui.BeginLinef("\n")
@@ -43,8 +60,9 @@ func (s *stage) Perform(name string, closure func() error) error {
 	}
 
 	s.ui.BeginLinef("%s...", name)
-	startTime := s.timeService.Now()
-	err := closure()
+}
+
+func (s *stage) endLine(name string, startTime time.Time, err error) error {
 	if err != nil {
 		if skipErr, ok := err.(SkipStageError); ok {
 			s.ui.EndLinef(" Skipped [%s] (%s)", skipErr.SkipMessage(), s.elapsedSince(startTime))